@@ -4,6 +4,8 @@ package agent
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/dublyo/dockerizer/internal/ai"
@@ -18,6 +20,7 @@ type Agent struct {
 	inspectors  []Inspector
 	maxAttempts int
 	events      chan AgentEvent
+	stream      ai.StreamFunc
 }
 
 // AgentConfig configures the agent
@@ -26,6 +29,11 @@ type AgentConfig struct {
 	MaxAttempts int
 	WorkDir     string
 	Verbose     bool
+	Stream      ai.StreamFunc // Optional: receives each generation as it streams in
+	Session     *Session      // Optional: a saved session to resume (see LoadSession)
+	Sandbox     *Sandbox      // Optional: runtime, daemon and limits for test containers
+	Output      io.Writer     // Optional: receives image build output as it runs
+	NoRedact    bool          // Return file contents to the AI without redacting secrets
 }
 
 // AgentEvent represents an event during agent execution
@@ -49,6 +57,7 @@ const (
 	EventSuccess    EventType = "success"
 	EventError      EventType = "error"
 	EventComplete   EventType = "complete"
+	EventCleanup    EventType = "cleanup"
 )
 
 // Inspector validates tool calls before execution
@@ -70,36 +79,105 @@ func New(cfg AgentConfig) *Agent {
 
 	tools := NewToolDispatcher(cfg.WorkDir)
 	tools.SetInspectors(inspectors)
+	tools.SetSandbox(cfg.Sandbox)
+	tools.SetOutput(cfg.Output)
+	tools.SetRedaction(!cfg.NoRedact)
+
+	session := cfg.Session
+	if session == nil {
+		session = NewSession()
+	}
+	session.WorkDir = cfg.WorkDir
+	if cfg.AIProvider != nil {
+		session.Provider = cfg.AIProvider.Name()
+	}
 
 	return &Agent{
 		provider:    cfg.AIProvider,
 		tools:       tools,
-		session:     NewSession(),
+		session:     session,
 		maxAttempts: cfg.MaxAttempts,
 		events:      make(chan AgentEvent, 100),
 		inspectors:  inspectors,
+		stream:      cfg.Stream,
 	}
 }
 
-// Events returns the event channel for monitoring
+// Events returns the event channel for monitoring; it is closed when Run
+// returns
 func (a *Agent) Events() <-chan AgentEvent {
 	return a.events
 }
 
-// Run executes the agent loop
+// Session returns the agent's session
+func (a *Agent) Session() *Session {
+	return a.session
+}
+
+// Run executes the agent loop. A resumed session continues after its last
+// attempt with the instructions it had accumulated; instructions are added to
+// them. The session is saved after every attempt. An agent runs once.
 func (a *Agent) Run(ctx context.Context, scan *scanner.ScanResult, instructions string) (*Result, error) {
+	defer close(a.events)
 	a.emit(EventStart, "Starting agent", nil)
 
+	session := a.session
+	if len(session.Attempts) == 0 {
+		session.Instructions = instructions
+	} else if instructions != "" {
+		session.Instructions = strings.TrimSpace(session.Instructions + "\n\n" + instructions)
+	}
+	session.Status = SessionRunning
+	a.saveSession()
+
 	result := &Result{
+		SessionID: session.ID,
 		StartTime: time.Now(),
-		Attempts:  make([]Attempt, 0),
+		Attempts:  append([]Attempt(nil), session.Attempts...),
 	}
 
-	for attempt := 1; attempt <= a.maxAttempts; attempt++ {
-		a.emit(EventAnalyzing, fmt.Sprintf("Attempt %d/%d: Analyzing project", attempt, a.maxAttempts), nil)
+	// Don't leave test containers behind, nor test images unless they worked
+	defer func() {
+		for _, r := range a.tools.Cleanup(ctx, !result.Success) {
+			a.emit(EventCleanup, "Removed "+r, nil)
+		}
+	}()
+
+	// A deterministic fix for the previous failure, tried without the AI.
+	// Each class is patched at most once per run.
+	var patched *Output
+	patchedClasses := make(map[string]bool)
+
+	first := len(session.Attempts) + 1
+	last := first + a.maxAttempts - 1
+	for attempt := first; attempt <= last; attempt++ {
+		a.emit(EventAnalyzing, fmt.Sprintf("Attempt %d/%d: Analyzing project", attempt, last), nil)
 
-		attemptResult := a.runAttempt(ctx, scan, instructions, attempt)
+		attemptResult := a.runAttempt(ctx, scan, session.Instructions, attempt, patched)
+		patched = nil
 		result.Attempts = append(result.Attempts, attemptResult)
+		result.Usage = append(result.Usage, attemptResult.Usage...)
+		session.Usage = append(session.Usage, attemptResult.Usage...)
+
+		// Stop retrying once the caller cancels; the cut-short attempt is not
+		// saved, so resuming runs it again
+		if err := ctx.Err(); err != nil {
+			session.Status = SessionInterrupted
+			a.saveSession()
+			result.EndTime = time.Now()
+			return result, err
+		}
+		// Recognize common failures, so the next attempt gets a targeted hint
+		// instead of the raw log, or a patch that needs no generation at all
+		var diag *Diagnosis
+		if !attemptResult.Success {
+			diag = ClassifyError(attemptResult.Error)
+			if diag != nil {
+				attemptResult.Class = diag.Class
+				result.Attempts[len(result.Attempts)-1].Class = diag.Class
+			}
+		}
+		session.Attempts = append(session.Attempts, attemptResult)
 
 		if attemptResult.Success {
 			a.emit(EventSuccess, "Docker configuration generated successfully", nil)
@@ -108,45 +186,85 @@ func (a *Agent) Run(ctx context.Context, scan *scanner.ScanResult, instructions
 			break
 		}
 
-		if attempt < a.maxAttempts {
+		// Add the error to context for next attempt
+		session.Instructions = strings.TrimSpace(session.Instructions + "\n\n" + failureInstructions(diag, attemptResult.Error))
+		a.saveSession()
+		if attempt == last {
+			break
+		}
+		if diag != nil && !patchedClasses[diag.Class] && attemptResult.Output != nil {
+			out := *attemptResult.Output
+			if diag.Patch(&out) {
+				patched = &out
+				patchedClasses[diag.Class] = true
+				a.emit(EventFixing, fmt.Sprintf("Attempt %d failed (%s), applying a known fix", attempt, diag.Class), diag.Evidence)
+				continue
+			}
+		}
+		if diag != nil {
+			a.emit(EventFixing, fmt.Sprintf("Attempt %d failed (%s), asking for a targeted fix", attempt, diag.Class), diag.Evidence)
+		} else {
 			a.emit(EventFixing, fmt.Sprintf("Build failed, analyzing error for fix (attempt %d)", attempt), attemptResult.Error)
-			// Add the error to context for next attempt
-			instructions = fmt.Sprintf("%s\n\nPrevious attempt failed with error:\n%s\n\nPlease fix this issue.", instructions, attemptResult.Error)
 		}
 	}
 
+	session.Status = SessionFailed
+	if result.Success {
+		session.Status = SessionSucceeded
+	}
+	a.saveSession()
+
 	result.EndTime = time.Now()
 	a.emit(EventComplete, "Agent completed", result)
 
 	return result, nil
 }
 
-// runAttempt executes a single attempt
-func (a *Agent) runAttempt(ctx context.Context, scan *scanner.ScanResult, instructions string, attemptNum int) Attempt {
+// saveSession persists the session; a failure is reported but doesn't stop the run
+func (a *Agent) saveSession() {
+	if err := a.session.Save(); err != nil {
+		a.emit(EventError, err.Error(), nil)
+	}
+}
+
+// runAttempt executes a single attempt, generating the configuration unless
+// a patched one is given
+func (a *Agent) runAttempt(ctx context.Context, scan *scanner.ScanResult, instructions string, attemptNum int, patched *Output) Attempt {
 	attempt := Attempt{
 		Number:    attemptNum,
 		StartTime: time.Now(),
 	}
 
-	// Generate Docker configuration
-	a.emit(EventGenerating, "Generating Docker configuration", nil)
-	response, err := a.provider.Generate(ctx, scan, instructions)
-	if err != nil {
-		attempt.Error = err.Error()
-		attempt.EndTime = time.Now()
-		return attempt
-	}
-
-	attempt.Output = &Output{
-		Dockerfile:    response.Dockerfile,
-		DockerCompose: response.DockerCompose,
-		Dockerignore:  response.Dockerignore,
-		EnvExample:    response.EnvExample,
+	if patched != nil {
+		attempt.Output = patched
+		attempt.Patched = true
+		a.session.AddMessage("tool", "applied a deterministic fix to the previous configuration")
+	} else {
+		// Generate Docker configuration
+		a.emit(EventGenerating, "Generating Docker configuration", nil)
+		a.session.AddMessage("user", strings.TrimSpace("Generate Docker configuration.\n\n"+instructions))
+		response, err := ai.GenerateStream(ctx, a.provider, scan, instructions, a.stream)
+		if err != nil {
+			attempt.Error = err.Error()
+			attempt.EndTime = time.Now()
+			return attempt
+		}
+		attempt.Usage = response.Usage
+		a.session.AddMessage("assistant", response.Explanation)
+
+		attempt.Output = &Output{
+			Dockerfile:    response.Dockerfile,
+			DockerCompose: response.DockerCompose,
+			Dockerignore:  response.Dockerignore,
+			EnvExample:    response.EnvExample,
+			Warnings:      response.Warnings,
+		}
 	}
 
 	// Write files
 	if err := a.tools.WriteDockerFiles(ctx, attempt.Output); err != nil {
 		attempt.Error = fmt.Sprintf("failed to write files: %v", err)
+		a.session.AddMessage("tool", attempt.Error)
 		attempt.EndTime = time.Now()
 		return attempt
 	}
@@ -154,11 +272,12 @@ func (a *Agent) runAttempt(ctx context.Context, scan *scanner.ScanResult, instru
 	// Build Docker image
 	a.emit(EventBuilding, "Building Docker image", nil)
 	buildResult, err := a.tools.Execute(ctx, "docker_build", map[string]interface{}{
-		"dockerfile": "Dockerfile",
+		"dockerfile": a.tools.DockerfileName(),
 		"tag":        "dockerize-test:latest",
 	})
 	if err != nil {
 		attempt.Error = fmt.Sprintf("build failed: %v", err)
+		a.session.AddMessage("tool", attempt.Error)
 		attempt.BuildLog = buildResult
 		attempt.EndTime = time.Now()
 		return attempt
@@ -173,6 +292,7 @@ func (a *Agent) runAttempt(ctx context.Context, scan *scanner.ScanResult, instru
 	})
 	if err != nil {
 		attempt.Error = fmt.Sprintf("test failed: %v", err)
+		a.session.AddMessage("tool", attempt.Error)
 		attempt.TestLog = testResult
 		attempt.EndTime = time.Now()
 		return attempt
@@ -180,6 +300,7 @@ func (a *Agent) runAttempt(ctx context.Context, scan *scanner.ScanResult, instru
 	attempt.TestLog = testResult
 
 	// Success!
+	a.session.AddMessage("tool", "build and test succeeded")
 	attempt.Success = true
 	attempt.EndTime = time.Now()
 	return attempt
@@ -201,59 +322,35 @@ func (a *Agent) emit(eventType EventType, message string, data interface{}) {
 
 // Result contains the overall agent result
 type Result struct {
+	SessionID   string
 	Success     bool
 	StartTime   time.Time
 	EndTime     time.Time
 	Attempts    []Attempt
 	FinalOutput *Output
+	Usage       []ai.Usage // Every AI call across all attempts
 }
 
 // Attempt represents a single generation attempt
 type Attempt struct {
-	Number    int
-	StartTime time.Time
-	EndTime   time.Time
-	Success   bool
-	Error     string
-	Output    *Output
-	BuildLog  string
-	TestLog   string
+	Number    int        `json:"number"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   time.Time  `json:"end_time"`
+	Success   bool       `json:"success"`
+	Error     string     `json:"error,omitempty"`
+	Class     string     `json:"class,omitempty"`   // Recognized failure class, see ClassifyError
+	Patched   bool       `json:"patched,omitempty"` // Output is a deterministic fix, not a generation
+	Output    *Output    `json:"output,omitempty"`
+	Usage     []ai.Usage `json:"usage,omitempty"`
+	BuildLog  string     `json:"build_log,omitempty"`
+	TestLog   string     `json:"test_log,omitempty"`
 }
 
 // Output contains the generated files
 type Output struct {
-	Dockerfile    string
-	DockerCompose string
-	Dockerignore  string
-	EnvExample    string
-}
-
-// Session manages conversation history
-type Session struct {
-	ID       string
-	Messages []Message
-}
-
-// Message represents a conversation message
-type Message struct {
-	Role    string
-	Content string
-	Time    time.Time
-}
-
-// NewSession creates a new session
-func NewSession() *Session {
-	return &Session{
-		ID:       fmt.Sprintf("session-%d", time.Now().UnixNano()),
-		Messages: make([]Message, 0),
-	}
-}
-
-// AddMessage adds a message to the session
-func (s *Session) AddMessage(role, content string) {
-	s.Messages = append(s.Messages, Message{
-		Role:    role,
-		Content: content,
-		Time:    time.Now(),
-	})
+	Dockerfile    string   `json:"dockerfile"`
+	DockerCompose string   `json:"docker_compose,omitempty"`
+	Dockerignore  string   `json:"dockerignore,omitempty"`
+	EnvExample    string   `json:"env_example,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
 }
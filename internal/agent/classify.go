@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxEvidenceLines of a classified failure go to the fix loop
+	maxEvidenceLines = 5
+	// maxUnclassifiedLines is the log tail sent when no class matches
+	maxUnclassifiedLines = 60
+)
+
+// Diagnosis is a failure matched to a known class
+type Diagnosis struct {
+	Class    string   // e.g. "missing-lockfile"
+	Hint     string   // Targeted fix instruction for the AI
+	Evidence []string // Log lines that matched
+	patch    func(out *Output, match []string) bool
+	match    []string // Submatches of the first matching line
+}
+
+// errorClass recognizes a common build or run failure
+type errorClass struct {
+	name  string
+	re    *regexp.Regexp
+	hint  string
+	patch func(out *Output, match []string) bool // Optional deterministic fix
+}
+
+// errorClasses are checked in order; the first with a matching line wins
+var errorClasses = []errorClass{
+	{
+		name: "missing-lockfile",
+		re: regexp.MustCompile(`(?i)(?:"?/?(package-lock\.json|yarn\.lock|pnpm-lock\.yaml|bun\.lockb?|poetry\.lock|Gemfile\.lock|composer\.lock|Cargo\.lock)"?: not found` +
+			`|npm ci.*(?:can only install|package-lock\.json)|ERR_PNPM_(?:NO|OUTDATED)_LOCKFILE|lockfile (?:needs to be updated|would have been modified)|YN0028)`),
+		hint:  "The lockfile is missing or out of date. Copy lockfiles with a wildcard (e.g. package-lock.json*) so a missing one is not an error, and install without a frozen-lockfile flag (npm install instead of npm ci) when no lockfile exists.",
+		patch: patchMissingLockfile,
+	},
+	{
+		name:  "missing-native-dep",
+		re:    regexp.MustCompile(`(?i)(gyp ERR!|fatal error: ([\w./-]+\.h): No such file|pg_config executable not found|cannot find -l(\w+)|error: command '(?:gcc|cc|g\+\+|x86_64-linux-gnu-gcc)' failed|Could not find (?:openssl|pkg-config)|No package '([\w.-]+)' found)`),
+		hint:  "A dependency compiles native code and the build stage lacks its toolchain or headers. Install the compiler and the missing development package in the build stage only (Alpine: apk add python3 make g++ plus the -dev package; Debian: apt-get install build-essential plus the -dev package).",
+		patch: patchNativeToolchain,
+	},
+	{
+		name: "wrong-output-path",
+		re:   regexp.MustCompile(`(?i)(?:failed to compute cache key|COPY failed|failed to calculate checksum).*?"?(/[\w./-]+)"?: (?:not found|no such file)|COPY failed: (?:stat|file not found).*?(/[\w./-]+)`),
+		hint: "A COPY source does not exist: the build writes its output somewhere else. Check the build script and the framework's output directory (dist, build, out, .next/standalone, target/release) and copy that path from the build stage.",
+	},
+	{
+		name: "port-bind",
+		re:   regexp.MustCompile(`(?i)(EADDRINUSE|address already in use|listen EACCES|bind: permission denied|(?:could not|cannot|failed to) bind)`),
+		hint: "The app cannot bind its port. A non-root user cannot bind ports below 1024: listen on a port such as 8080 via the PORT environment variable, on 0.0.0.0, and EXPOSE the same port.",
+	},
+	{
+		name: "permission-denied",
+		re:   regexp.MustCompile(`(?i)(EACCES: permission denied(?:, (?:mkdir|open|scandir) '([^']+)')?|PermissionError: \[Errno 13\]|mkdir: can't create directory|Permission denied)`),
+		hint: "The app cannot write a file or directory as the non-root user. Keep the non-root USER, but create the directories it writes to and COPY --chown (or chown) them to that user before switching to it.",
+	},
+	{
+		name: "unknown-image",
+		re:   regexp.MustCompile(`(?i)(manifest for (\S+) not found|pull access denied for (\S+)|(\S+): not found: manifest unknown)`),
+		hint: "A base image tag does not exist. Use an existing official tag for the detected version (e.g. node:20-alpine, python:3.12-slim).",
+	},
+	{
+		name: "out-of-memory",
+		re:   regexp.MustCompile(`(?i)(JavaScript heap out of memory|killed for running out of memory|Killed\s*$)`),
+		hint: "The build or app ran out of memory. Reduce memory use (e.g. NODE_OPTIONS=--max-old-space-size=384 for Node builds) rather than removing build steps.",
+	},
+}
+
+// ClassifyError matches a failure log to a known class, or returns nil
+func ClassifyError(log string) *Diagnosis {
+	lines := strings.Split(log, "\n")
+	for _, c := range errorClasses {
+		var d *Diagnosis
+		for _, line := range lines {
+			m := c.re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			if d == nil {
+				d = &Diagnosis{Class: c.name, Hint: c.hint, patch: c.patch, match: m}
+			}
+			if len(d.Evidence) < maxEvidenceLines {
+				d.Evidence = append(d.Evidence, strings.TrimSpace(line))
+			}
+		}
+		if d != nil {
+			return d
+		}
+	}
+	return nil
+}
+
+// Patch applies the class's deterministic fix to out, reporting whether it changed anything
+func (d *Diagnosis) Patch(out *Output) bool {
+	if d.patch == nil || out == nil {
+		return false
+	}
+	return d.patch(out, d.match)
+}
+
+// Instructions describes the failure for the next generation
+func (d *Diagnosis) Instructions() string {
+	return fmt.Sprintf("Previous attempt failed (%s). %s\nRelevant log lines:\n%s", d.Class, d.Hint, strings.Join(d.Evidence, "\n"))
+}
+
+// failureInstructions tells the next attempt why the previous one failed:
+// a targeted hint when the failure is recognized, the log tail otherwise
+func failureInstructions(d *Diagnosis, errText string) string {
+	if d != nil {
+		return d.Instructions() + "\n\nPlease fix this issue."
+	}
+	lines := strings.Split(strings.TrimSpace(errText), "\n")
+	if len(lines) > maxUnclassifiedLines {
+		lines = append([]string{"..."}, lines[len(lines)-maxUnclassifiedLines:]...)
+	}
+	return fmt.Sprintf("Previous attempt failed with error:\n%s\n\nPlease fix this issue.", strings.Join(lines, "\n"))
+}
+
+var (
+	lockfileCopy   = regexp.MustCompile(`(?m)^(COPY\b.*?\s)((?:package-lock\.json|yarn\.lock|pnpm-lock\.yaml|bun\.lockb?|poetry\.lock|Gemfile\.lock|composer\.lock|Cargo\.lock))(\s)`)
+	frozenInstalls = strings.NewReplacer(
+		"npm ci", "npm install",
+		"pnpm install --frozen-lockfile", "pnpm install",
+		"yarn install --frozen-lockfile", "yarn install",
+		"yarn install --immutable", "yarn install",
+		"bun install --frozen-lockfile", "bun install",
+	)
+)
+
+// patchMissingLockfile makes lockfile COPYs optional and drops frozen installs
+func patchMissingLockfile(out *Output, _ []string) bool {
+	patched := lockfileCopy.ReplaceAllString(out.Dockerfile, "$1$2*$3")
+	patched = frozenInstalls.Replace(patched)
+	if patched == out.Dockerfile {
+		return false
+	}
+	out.Dockerfile = patched
+	return true
+}
+
+var firstFrom = regexp.MustCompile(`(?im)^FROM\s+(\S+).*$`)
+
+// patchNativeToolchain adds the node-gyp toolchain to an Alpine or Debian
+// build stage when a Node native addon failed to compile
+func patchNativeToolchain(out *Output, match []string) bool {
+	if !strings.Contains(strings.ToLower(match[0]), "gyp") {
+		return false
+	}
+	loc := firstFrom.FindStringSubmatchIndex(out.Dockerfile)
+	if loc == nil {
+		return false
+	}
+	image := strings.ToLower(out.Dockerfile[loc[2]:loc[3]])
+	var install string
+	switch {
+	case strings.Contains(out.Dockerfile, "python3 make g++"), strings.Contains(out.Dockerfile, "build-essential"):
+		return false // Toolchain already there; a header is missing
+	case strings.Contains(image, "alpine"):
+		install = "RUN apk add --no-cache python3 make g++"
+	case strings.HasPrefix(image, "node"):
+		install = "RUN apt-get update && apt-get install -y --no-install-recommends python3 make g++ && rm -rf /var/lib/apt/lists/*"
+	default:
+		return false
+	}
+	out.Dockerfile = out.Dockerfile[:loc[1]] + "\n" + install + out.Dockerfile[loc[1]:]
+	return true
+}
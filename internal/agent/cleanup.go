@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResourceLabel marks the test containers and networks the agent creates, so
+// leftovers from crashed or killed runs can be found later
+const ResourceLabel = "dockerizer.agent"
+
+// cleanupTimeout bounds removing resources, which must outlive a cancelled run
+const cleanupTimeout = 30 * time.Second
+
+// resourceRegistry tracks the Docker containers, images and networks a run creates
+type resourceRegistry struct {
+	mu         sync.Mutex
+	containers map[string]bool
+	images     map[string]bool
+	networks   map[string]bool
+}
+
+func newResourceRegistry() *resourceRegistry {
+	return &resourceRegistry{
+		containers: make(map[string]bool),
+		images:     make(map[string]bool),
+		networks:   make(map[string]bool),
+	}
+}
+
+func (r *resourceRegistry) addContainer(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.containers[name] = true
+}
+
+func (r *resourceRegistry) removeContainer(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.containers, name)
+}
+
+func (r *resourceRegistry) addImage(tag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.images[tag] = true
+}
+
+func (r *resourceRegistry) addNetwork(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.networks[name] = true
+}
+
+// take returns and forgets the tracked resources
+func (r *resourceRegistry) take(images bool) (containers, imageTags, networks []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name := range r.containers {
+		containers = append(containers, name)
+	}
+	r.containers = make(map[string]bool)
+	if images {
+		for tag := range r.images {
+			imageTags = append(imageTags, tag)
+		}
+		r.images = make(map[string]bool)
+	}
+	for name := range r.networks {
+		networks = append(networks, name)
+	}
+	r.networks = make(map[string]bool)
+	return containers, imageTags, networks
+}
+
+// Cleanup force-removes the containers and networks the dispatcher's tools
+// created and, when images is true, the images they built. It runs even after
+// ctx is cancelled, bounded by its own timeout.
+func (td *ToolDispatcher) Cleanup(ctx context.Context, images bool) []string {
+	containers, imageTags, networks := td.resources.take(images)
+	if len(containers) == 0 && len(imageTags) == 0 && len(networks) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+	return removeResources(ctx, td.sandbox, containers, imageTags, networks)
+}
+
+// Sweep finds and removes agent resources left behind by earlier runs in the
+// sandbox: containers labelled ResourceLabel or named dockerize-test-*, the
+// dockerize-test and dockerize-build images, and labelled networks. With
+// dryRun it only lists them.
+func Sweep(ctx context.Context, sb *Sandbox, dryRun bool) ([]string, error) {
+	containers, err := dockerList(ctx, sb, "ps", "-a", "--filter", "label="+ResourceLabel, "--format", "{{.Names}}")
+	if err != nil {
+		return nil, err
+	}
+	named, err := dockerList(ctx, sb, "ps", "-a", "--filter", "name=dockerize-test-", "--format", "{{.Names}}")
+	if err != nil {
+		return nil, err
+	}
+	containers = appendUnique(containers, named...)
+
+	var images []string
+	for _, repo := range []string{"dockerize-test", "dockerize-build"} {
+		tags, err := dockerList(ctx, sb, "images", repo, "--format", "{{.Repository}}:{{.Tag}}")
+		if err != nil {
+			return nil, err
+		}
+		images = appendUnique(images, tags...)
+	}
+
+	networks, err := dockerList(ctx, sb, "network", "ls", "--filter", "label="+ResourceLabel, "--format", "{{.Name}}")
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		var found []string
+		for _, c := range containers {
+			found = append(found, "container "+c)
+		}
+		for _, img := range images {
+			found = append(found, "image "+img)
+		}
+		for _, n := range networks {
+			found = append(found, "network "+n)
+		}
+		return found, nil
+	}
+	return removeResources(ctx, sb, containers, images, networks), nil
+}
+
+// removeResources force-removes containers, then images and networks, and
+// returns what was removed
+func removeResources(ctx context.Context, sb *Sandbox, containers, images, networks []string) []string {
+	var removed []string
+	for _, c := range containers {
+		if sb.command(ctx, "rm", "-f", c).Run() == nil {
+			removed = append(removed, "container "+c)
+		}
+	}
+	for _, img := range images {
+		if sb.command(ctx, "rmi", "-f", img).Run() == nil {
+			removed = append(removed, "image "+img)
+		}
+	}
+	for _, n := range networks {
+		if sb.command(ctx, "network", "rm", n).Run() == nil {
+			removed = append(removed, "network "+n)
+		}
+	}
+	return removed
+}
+
+// dockerList runs a docker listing command and returns its non-empty lines,
+// skipping dangling "<none>" images
+func dockerList(ctx context.Context, sb *Sandbox, args ...string) ([]string, error) {
+	cmd := sb.command(ctx, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s %s failed: %w: %s", sb.withDefaults().Runtime, args[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s %s failed: %w", sb.withDefaults().Runtime, args[0], err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.Contains(line, "<none>") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,206 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Polling a test container starts fast and backs off to this interval
+	minPollInterval = 500 * time.Millisecond
+	maxPollInterval = 5 * time.Second
+	// failureLogLines is how much of a failed container's log goes to the fix loop
+	failureLogLines = 40
+)
+
+// containerState is the part of `docker inspect` State the test loop reads
+type containerState struct {
+	Status    string
+	Running   bool
+	ExitCode  int
+	OOMKilled bool
+	Error     string
+	Health    *struct {
+		Status        string
+		FailingStreak int
+		Log           []struct {
+			ExitCode int
+			Output   string
+		}
+	}
+}
+
+// healthcheckConfig is an image's HEALTHCHECK; durations are nanoseconds in
+// `docker inspect` output, which decode directly into time.Duration
+type healthcheckConfig struct {
+	Test        []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// ContainerFailure describes why a test container did not come up, for the fix loop
+type ContainerFailure struct {
+	Reason     string // e.g. "container exited", "health check failed"
+	Status     string // Container status: running, exited, ...
+	ExitCode   int
+	OOMKilled  bool
+	HealthLog  string // Output of the last health check
+	HealthExit int    // Exit code of the last health check
+	Logs       string // Tail of the container log
+}
+
+// Error summarizes the failure with the evidence needed to fix it
+func (f *ContainerFailure) Error() string {
+	var b strings.Builder
+	b.WriteString(f.Reason)
+	if f.Status != "" && f.Status != "running" {
+		fmt.Fprintf(&b, " (status %s, exit code %d)", f.Status, f.ExitCode)
+	}
+	if f.OOMKilled {
+		b.WriteString("; killed for running out of memory")
+	}
+	if f.HealthLog != "" {
+		fmt.Fprintf(&b, "\nlast health check (exit %d): %s", f.HealthExit, strings.TrimSpace(f.HealthLog))
+	}
+	if f.Logs != "" {
+		fmt.Fprintf(&b, "\ncontainer logs (last %d lines):\n%s", failureLogLines, strings.TrimSpace(f.Logs))
+	}
+	return b.String()
+}
+
+// waitForContainer polls a started container with backoff until it is
+// healthy, logs the ready string, or has stayed up for window. An image
+// HEALTHCHECK extends the wait to the time Docker needs to call it unhealthy.
+func waitForContainer(ctx context.Context, sb *Sandbox, name string, hc *healthcheckConfig, window time.Duration, ready string) error {
+	if hc != nil {
+		if w := healthcheckWait(hc); w > window {
+			window = w
+		}
+	}
+	deadline := time.Now().Add(window)
+
+	delay := minPollInterval
+	for {
+		state, err := inspectContainer(ctx, sb, name)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !state.Running:
+			return newContainerFailure(ctx, sb, name, "container exited", state)
+		case state.Health != nil && state.Health.Status == "healthy":
+			return nil
+		case state.Health != nil && state.Health.Status == "unhealthy":
+			return newContainerFailure(ctx, sb, name, "health check failed", state)
+		case ready != "" && strings.Contains(containerLogs(ctx, sb, name, 0), ready):
+			return nil
+		}
+
+		if !time.Now().Before(deadline) {
+			switch {
+			case state.Health != nil:
+				return newContainerFailure(ctx, sb, name, fmt.Sprintf("health check still %s after %s", state.Health.Status, window), state)
+			case ready != "":
+				return newContainerFailure(ctx, sb, name, fmt.Sprintf("%q not logged within %s", ready, window), state)
+			}
+			return nil // Stayed up for the whole window
+		}
+
+		wait := delay
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if delay *= 2; delay > maxPollInterval {
+			delay = maxPollInterval
+		}
+	}
+}
+
+// healthcheckWait is how long Docker may take to report a container unhealthy:
+// the start period plus every retry, with Docker's defaults for unset values
+func healthcheckWait(hc *healthcheckConfig) time.Duration {
+	interval, timeout, retries := hc.Interval, hc.Timeout, hc.Retries
+	if interval == 0 {
+		interval = 30 * time.Second
+	}
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	if retries == 0 {
+		retries = 3
+	}
+	return hc.StartPeriod + time.Duration(retries+1)*(interval+timeout)
+}
+
+// imageHealthcheck returns the image's HEALTHCHECK, or nil when it has none
+func imageHealthcheck(ctx context.Context, sb *Sandbox, image string) *healthcheckConfig {
+	out, err := sb.command(ctx, "image", "inspect", "--format", "{{json .Config.Healthcheck}}", image).Output()
+	if err != nil {
+		return nil
+	}
+	var hc healthcheckConfig
+	if json.Unmarshal(bytes.TrimSpace(out), &hc) != nil || len(hc.Test) == 0 || hc.Test[0] == "NONE" {
+		return nil
+	}
+	return &hc
+}
+
+// inspectContainer reads a container's state
+func inspectContainer(ctx context.Context, sb *Sandbox, name string) (*containerState, error) {
+	out, err := sb.command(ctx, "inspect", "--format", "{{json .State}}", name).Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%s inspect failed: %w", sb.withDefaults().Runtime, err)
+	}
+	var state containerState
+	if err := json.Unmarshal(bytes.TrimSpace(out), &state); err != nil {
+		return nil, fmt.Errorf("failed to parse container state: %w", err)
+	}
+	return &state, nil
+}
+
+// containerLogs returns a container's combined output, the last tail lines if tail > 0
+func containerLogs(ctx context.Context, sb *Sandbox, name string, tail int) string {
+	args := []string{"logs"}
+	if tail > 0 {
+		args = append(args, "--tail", fmt.Sprint(tail))
+	}
+	cmd := sb.command(ctx, append(args, name)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	_ = cmd.Run()
+	return out.String()
+}
+
+func newContainerFailure(ctx context.Context, sb *Sandbox, name, reason string, state *containerState) *ContainerFailure {
+	f := &ContainerFailure{
+		Reason:    reason,
+		Status:    state.Status,
+		ExitCode:  state.ExitCode,
+		OOMKilled: state.OOMKilled,
+		Logs:      containerLogs(ctx, sb, name, failureLogLines),
+	}
+	if state.Error != "" {
+		f.Reason += ": " + state.Error
+	}
+	if state.Health != nil && len(state.Health.Log) > 0 {
+		last := state.Health.Log[len(state.Health.Log)-1]
+		f.HealthLog, f.HealthExit = last.Output, last.ExitCode
+	}
+	return f
+}
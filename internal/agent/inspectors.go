@@ -3,8 +3,11 @@ package agent
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
 )
 
 // SecurityInspector checks for security issues in tool calls
@@ -58,7 +61,7 @@ func (i *SyntaxInspector) Inspect(ctx context.Context, tool string, args map[str
 	path, _ := args["path"].(string)
 	content, _ := args["content"].(string)
 
-	if path == "Dockerfile" || strings.HasSuffix(path, "/Dockerfile") {
+	if base := filepath.Base(path); base == "Dockerfile" || base == "Containerfile" {
 		return validateDockerfileSyntax(content)
 	}
 
@@ -66,58 +69,9 @@ func (i *SyntaxInspector) Inspect(ctx context.Context, tool string, args map[str
 }
 
 func validateDockerfileSyntax(content string) error {
-	lines := strings.Split(content, "\n")
-	hasFROM := false
-
-	validInstructions := map[string]bool{
-		"FROM": true, "RUN": true, "CMD": true, "LABEL": true,
-		"EXPOSE": true, "ENV": true, "ADD": true, "COPY": true,
-		"ENTRYPOINT": true, "VOLUME": true, "USER": true,
-		"WORKDIR": true, "ARG": true, "ONBUILD": true,
-		"STOPSIGNAL": true, "HEALTHCHECK": true, "SHELL": true,
-	}
-
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Handle line continuation
-		for strings.HasSuffix(line, "\\") && i+1 < len(lines) {
-			i++
-			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[i])
-		}
-
-		// Get instruction
-		parts := strings.Fields(line)
-		if len(parts) == 0 {
-			continue
-		}
-
-		instruction := strings.ToUpper(parts[0])
-
-		// Check for FROM
-		if instruction == "FROM" {
-			hasFROM = true
-		}
-
-		// Validate instruction
-		if !validInstructions[instruction] && !strings.HasPrefix(line, "# ") {
-			// Could be a parser directive (first line only)
-			if !hasFROM && strings.Contains(line, "=") {
-				continue
-			}
-			return fmt.Errorf("invalid instruction on line %d: %s", i+1, instruction)
-		}
-	}
-
-	if !hasFROM {
-		return fmt.Errorf("Dockerfile must have a FROM instruction")
+	if err := dockerfile.Parse(content).Err(); err != nil {
+		return fmt.Errorf("invalid Dockerfile: %w", err)
 	}
-
 	return nil
 }
 
@@ -196,7 +150,7 @@ func (i *ContentInspector) Inspect(ctx context.Context, tool string, args map[st
 	}
 
 	// Check for common typos in Dockerfiles
-	if strings.HasSuffix(path, "Dockerfile") {
+	if strings.HasSuffix(path, "Dockerfile") || strings.HasSuffix(path, "Containerfile") {
 		typos := map[string]string{
 			"FORMO":     "FROM",
 			"COPPY":     "COPY",
@@ -0,0 +1,306 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sandbox configures where the agent builds and runs test containers, and the
+// limits they run under
+type Sandbox struct {
+	Runtime   string // Container CLI: "docker" (default), "podman" or "nerdctl"; "auto" to detect
+	Rootless  bool   // Set by Detect when the runtime runs containers without root
+	Host      string // Remote daemon, e.g. ssh://user@sandbox or tcp://10.0.0.5:2376
+	Context   string // Docker context, or Podman connection, naming the daemon instead of Host
+	Network   string // Bridge network for test containers
+	Internal  bool   // Cut the network off from outside traffic
+	Memory    string // Memory limit, e.g. "512m"
+	CPUs      string // CPU limit, e.g. "1.5"
+	PidsLimit int    // Process limit
+
+	controllers []string // cgroup controllers delegated to a local rootless runtime
+}
+
+// Runtimes are the container CLIs the agent drives, in the order Detect
+// tries them
+var Runtimes = []string{"docker", "podman", "nerdctl"}
+
+// DefaultSandbox runs test containers with the local docker CLI on a dedicated
+// bridge network, limited to 512 MiB, one CPU and 256 processes
+func DefaultSandbox() *Sandbox {
+	return &Sandbox{
+		Runtime:   "docker",
+		Network:   "dockerizer-agent",
+		Memory:    "512m",
+		CPUs:      "1",
+		PidsLimit: 256,
+	}
+}
+
+// withDefaults fills unset fields from DefaultSandbox
+func (s *Sandbox) withDefaults() *Sandbox {
+	d := DefaultSandbox()
+	if s == nil {
+		return d
+	}
+	out := *s
+	if out.Runtime == "" || out.Runtime == "auto" {
+		out.Runtime = d.Runtime // Detect resolves auto
+	}
+	if out.Network == "" {
+		out.Network = d.Network
+	}
+	if out.Memory == "" {
+		out.Memory = d.Memory
+	}
+	if out.CPUs == "" {
+		out.CPUs = d.CPUs
+	}
+	if out.PidsLimit == 0 {
+		out.PidsLimit = d.PidsLimit
+	}
+	return &out
+}
+
+// Detect picks the runtime when Runtime is "auto": the first of Runtimes
+// installed whose daemon answers, else the first installed. It then records
+// whether the runtime is rootless and, for a local rootless runtime, which
+// limits its cgroups allow.
+func (s *Sandbox) Detect(ctx context.Context) error {
+	var rootless bool
+	var err error
+	if s.Runtime == "auto" {
+		s.Runtime = ""
+		for _, rt := range Runtimes {
+			if _, lookErr := exec.LookPath(rt); lookErr != nil {
+				continue
+			}
+			if s.Runtime == "" {
+				s.Runtime = rt
+			}
+			if rootless, err = s.probe(ctx, rt); err == nil {
+				s.Runtime = rt
+				break
+			}
+		}
+		if s.Runtime == "" {
+			return fmt.Errorf("no container runtime found in PATH (install docker, podman or nerdctl)")
+		}
+	} else {
+		rootless, err = s.probe(ctx, s.withDefaults().Runtime)
+	}
+
+	if err != nil || !rootless {
+		return nil // A runtime that does not answer fails on the first build
+	}
+	s.Rootless = true
+	if s.Host == "" && s.Context == "" {
+		s.controllers = delegatedControllers()
+	}
+	return nil
+}
+
+// probe asks a runtime's daemon whether it is rootless
+func (s *Sandbox) probe(ctx context.Context, runtime string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	sb := *s.withDefaults()
+	sb.Runtime = runtime
+
+	switch runtime {
+	case "podman":
+		out, err := sb.command(ctx, "info", "--format", "{{.Host.Security.Rootless}}").Output()
+		return strings.TrimSpace(string(out)) == "true", err
+	case "nerdctl":
+		// Run by a user, nerdctl talks to that user's rootless containerd
+		err := sb.command(ctx, "info").Run()
+		return os.Geteuid() != 0, err
+	default:
+		out, err := sb.command(ctx, "info", "--format", "{{json .SecurityOptions}}").Output()
+		return strings.Contains(string(out), "rootless"), err
+	}
+}
+
+// delegatedControllers lists the cgroup controllers systemd delegates to
+// the user's services, which is what a rootless runtime can limit; none on
+// cgroup v1
+func delegatedControllers() []string {
+	uid := strconv.Itoa(os.Getuid())
+	content, err := os.ReadFile(filepath.Join("/sys/fs/cgroup/user.slice", "user-"+uid+".slice", "user@"+uid+".service", "cgroup.controllers"))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(content))
+}
+
+// Validate checks the runtime is supported and installed; call it after
+// Detect
+func (s *Sandbox) Validate() error {
+	sb := s.withDefaults()
+	if !slices.Contains(Runtimes, sb.Runtime) {
+		return fmt.Errorf("unsupported container runtime %q (use docker, podman, nerdctl or auto)", sb.Runtime)
+	}
+	if sb.Host != "" && sb.Context != "" {
+		return fmt.Errorf("a daemon host (%s) and a context (%s) both pick the daemon; use one", sb.Host, sb.Context)
+	}
+	if sb.Runtime == "nerdctl" && sb.Host != "" && !strings.HasPrefix(sb.Host, "unix://") {
+		return fmt.Errorf("nerdctl only talks to a local containerd socket (unix://...); use docker or podman for %s", sb.Host)
+	}
+	if sb.Runtime == "nerdctl" && sb.Context != "" {
+		return fmt.Errorf("nerdctl has no contexts; use docker or podman for context %s", sb.Context)
+	}
+	if _, err := exec.LookPath(sb.Runtime); err != nil {
+		return fmt.Errorf("%s not found in PATH", sb.Runtime)
+	}
+	return nil
+}
+
+// Ping checks that the daemon answers, so a wrong host or context, or a
+// daemon that is not running, fails up front and says where it looked
+// rather than on the first build
+func (s *Sandbox) Ping(ctx context.Context) error {
+	sb := s.withDefaults()
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := sb.command(ctx, "version")
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		reason := strings.TrimSpace(stderr.String())
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			reason = "no answer in 20s"
+		case reason == "":
+			reason = err.Error()
+		}
+		if i := strings.IndexByte(reason, '\n'); i > 0 {
+			reason = reason[:i]
+		}
+		return fmt.Errorf("cannot reach the %s daemon %s: %s", sb.Runtime, sb.Target(), reason)
+	}
+	return nil
+}
+
+// Target says which daemon the runtime talks to, for messages
+func (s *Sandbox) Target() string {
+	sb := s.withDefaults()
+	hostVar, contextVar := "DOCKER_HOST", "DOCKER_CONTEXT"
+	switch sb.Runtime {
+	case "podman":
+		hostVar, contextVar = "CONTAINER_HOST", "CONTAINER_CONNECTION"
+	case "nerdctl":
+		hostVar, contextVar = "CONTAINERD_ADDRESS", ""
+	}
+	switch {
+	case sb.Context != "":
+		return fmt.Sprintf("of context %q", sb.Context)
+	case sb.Host != "":
+		return "at " + sb.Host
+	case contextVar != "" && os.Getenv(contextVar) != "" && os.Getenv(hostVar) == "":
+		return fmt.Sprintf("of context %q (%s)", os.Getenv(contextVar), contextVar)
+	case os.Getenv(hostVar) != "":
+		return fmt.Sprintf("at %s (%s)", os.Getenv(hostVar), hostVar)
+	}
+	return "on this machine"
+}
+
+// DroppedLimits are the limits a local rootless runtime cannot enforce,
+// because systemd does not delegate their cgroup controller to the user;
+// test containers run without them
+func (s *Sandbox) DroppedLimits() []string {
+	var dropped []string
+	for _, l := range []struct{ flag, controller string }{{"--memory", "memory"}, {"--cpus", "cpu"}, {"--pids-limit", "pids"}} {
+		if !s.canLimit(l.controller) {
+			dropped = append(dropped, l.flag)
+		}
+	}
+	return dropped
+}
+
+// canLimit reports whether test containers can be limited by a cgroup
+// controller: always, unless the runtime is local and rootless
+func (s *Sandbox) canLimit(controller string) bool {
+	return !s.Rootless || s.Host != "" || s.Context != "" || slices.Contains(s.controllers, controller)
+}
+
+// command builds a container CLI command against the sandbox's daemon
+func (s *Sandbox) command(ctx context.Context, args ...string) *exec.Cmd {
+	sb := s.withDefaults()
+	if sb.Runtime == "podman" && (sb.Host != "" || sb.Context != "") {
+		args = append([]string{"--remote"}, args...)
+	}
+	cmd := exec.CommandContext(ctx, sb.Runtime, args...)
+	cmd.Env = sb.environ()
+	return cmd
+}
+
+// environ is the process environment pointed at the sandbox's daemon
+func (s *Sandbox) environ() []string {
+	env := os.Environ()
+	switch {
+	case s.Context != "" && s.Runtime == "podman":
+		env = append(withoutEnv(env, "CONTAINER_HOST"), "CONTAINER_CONNECTION="+s.Context)
+	case s.Context != "":
+		// DOCKER_HOST would win over the context
+		env = append(withoutEnv(env, "DOCKER_HOST"), "DOCKER_CONTEXT="+s.Context)
+	case s.Host == "":
+	case s.Runtime == "podman":
+		env = append(env, "CONTAINER_HOST="+s.Host)
+	case s.Runtime == "nerdctl":
+		env = append(env, "CONTAINERD_ADDRESS="+strings.TrimPrefix(s.Host, "unix://"))
+	default:
+		env = append(env, "DOCKER_HOST="+s.Host)
+	}
+	return env
+}
+
+// withoutEnv drops name's NAME=value entries from env
+func withoutEnv(env []string, name string) []string {
+	return slices.DeleteFunc(env, func(e string) bool {
+		return strings.HasPrefix(e, name+"=")
+	})
+}
+
+// runArgs are the isolation and limit flags for a test container
+func (s *Sandbox) runArgs() []string {
+	sb := s.withDefaults()
+	args := []string{"--network", sb.Network}
+	if sb.canLimit("memory") {
+		args = append(args, "--memory", sb.Memory, "--memory-swap", sb.Memory) // No swap beyond the memory limit
+	}
+	if sb.canLimit("cpu") {
+		args = append(args, "--cpus", sb.CPUs)
+	}
+	if sb.canLimit("pids") {
+		args = append(args, "--pids-limit", strconv.Itoa(sb.PidsLimit))
+	}
+	return append(args, "--security-opt", "no-new-privileges")
+}
+
+// ensureNetwork creates the sandbox network unless it exists, reporting
+// whether it did
+func (s *Sandbox) ensureNetwork(ctx context.Context) (bool, error) {
+	sb := s.withDefaults()
+	if sb.command(ctx, "network", "inspect", sb.Network).Run() == nil {
+		return false, nil
+	}
+
+	args := []string{"network", "create", "--driver", "bridge", "--label", ResourceLabel + "=true"}
+	if sb.Internal {
+		args = append(args, "--internal")
+	}
+	out, err := sb.command(ctx, append(args, sb.Network)...).CombinedOutput()
+	if err != nil {
+		return false, fmt.Errorf("failed to create network %s: %w: %s", sb.Network, err, strings.TrimSpace(string(out)))
+	}
+	return true, nil
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/ai"
+)
+
+// Session status values
+const (
+	SessionRunning     = "running"
+	SessionSucceeded   = "succeeded"
+	SessionFailed      = "failed"
+	SessionInterrupted = "interrupted"
+)
+
+// Session manages conversation history and is saved after every attempt so an
+// interrupted run can be resumed
+type Session struct {
+	ID           string     `json:"id"`
+	WorkDir      string     `json:"work_dir"`
+	Provider     string     `json:"provider,omitempty"`     // Provider name, e.g. "anthropic>openai"
+	Status       string     `json:"status"`                 // running, succeeded, failed or interrupted
+	Instructions string     `json:"instructions,omitempty"` // For the next attempt, including earlier errors
+	Attempts     []Attempt  `json:"attempts"`
+	Messages     []Message  `json:"messages"`
+	Usage        []ai.Usage `json:"usage,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Message represents a conversation message
+type Message struct {
+	Role    string    `json:"role"`
+	Content string    `json:"content"`
+	Time    time.Time `json:"time"`
+}
+
+// NewSession creates a new session
+func NewSession() *Session {
+	now := time.Now()
+	return &Session{
+		ID:        fmt.Sprintf("session-%d", now.UnixNano()),
+		Status:    SessionRunning,
+		Attempts:  make([]Attempt, 0),
+		Messages:  make([]Message, 0),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// AddMessage adds a message to the session
+func (s *Session) AddMessage(role, content string) {
+	s.Messages = append(s.Messages, Message{
+		Role:    role,
+		Content: content,
+		Time:    time.Now(),
+	})
+}
+
+// SessionDir is where a project's agent sessions are saved
+func SessionDir(workDir string) string {
+	return filepath.Join(workDir, ".dockerizer", "sessions")
+}
+
+// Save writes the session to SessionDir(WorkDir)/<id>.json
+func (s *Session) Save() error {
+	s.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	dir := SessionDir(s.WorkDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	// Write then rename, so an interruption never leaves a truncated session
+	path := filepath.Join(dir, s.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// LoadSession reads a saved session of the project in workDir
+func LoadSession(workDir, id string) (*Session, error) {
+	id = strings.TrimSuffix(filepath.Base(id), ".json")
+	data, err := os.ReadFile(filepath.Join(SessionDir(workDir), id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("session %s not found in %s", id, SessionDir(workDir))
+		}
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", id, err)
+	}
+	s.WorkDir = workDir
+	return &s, nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -13,16 +14,9 @@ import (
 
 	"github.com/dublyo/dockerizer/internal/detector"
 	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/internal/redact"
 	"github.com/dublyo/dockerizer/internal/scanner"
-	"github.com/dublyo/dockerizer/providers/dotnet"
-	"github.com/dublyo/dockerizer/providers/elixir"
-	"github.com/dublyo/dockerizer/providers/golang"
-	"github.com/dublyo/dockerizer/providers/java"
-	"github.com/dublyo/dockerizer/providers/nodejs"
-	"github.com/dublyo/dockerizer/providers/php"
-	"github.com/dublyo/dockerizer/providers/python"
-	"github.com/dublyo/dockerizer/providers/ruby"
-	"github.com/dublyo/dockerizer/providers/rust"
+	"github.com/dublyo/dockerizer/providers/all"
 )
 
 // securePath validates and resolves a path to ensure it stays within the base directory.
@@ -111,6 +105,23 @@ type ToolDispatcher struct {
 	workDir    string
 	tools      map[string]Tool
 	inspectors []Inspector
+	resources  *resourceRegistry // Containers and images created by the tools
+	sandbox    *Sandbox          // Shared with the tools; see SetSandbox
+	output     *outputSink       // Shared with the tools; see SetOutput
+}
+
+// outputSink optionally receives the output of long-running tools as it is
+// produced
+type outputSink struct {
+	w io.Writer
+}
+
+// tee returns a writer filling buf and, when set, the sink
+func (o *outputSink) tee(buf io.Writer) io.Writer {
+	if o == nil || o.w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, o.w)
 }
 
 // Tool represents an executable tool
@@ -123,18 +134,21 @@ type Tool interface {
 // NewToolDispatcher creates a new tool dispatcher
 func NewToolDispatcher(workDir string) *ToolDispatcher {
 	td := &ToolDispatcher{
-		workDir: workDir,
-		tools:   make(map[string]Tool),
+		workDir:   workDir,
+		tools:     make(map[string]Tool),
+		resources: newResourceRegistry(),
+		sandbox:   DefaultSandbox(),
+		output:    &outputSink{},
 	}
 
 	// Register built-in tools
-	td.Register(&DockerBuildTool{workDir: workDir})
-	td.Register(&DockerRunTool{workDir: workDir})
-	td.Register(&DockerLogsTool{})
-	td.Register(&DockerStopTool{})
+	td.Register(&DockerBuildTool{workDir: workDir, resources: td.resources, sandbox: td.sandbox, output: td.output})
+	td.Register(&DockerRunTool{workDir: workDir, resources: td.resources, sandbox: td.sandbox})
+	td.Register(&DockerLogsTool{sandbox: td.sandbox})
+	td.Register(&DockerStopTool{sandbox: td.sandbox})
 	td.Register(&FileWriteTool{workDir: workDir})
 	td.Register(&FileReadTool{workDir: workDir})
-	td.Register(&ShellTool{workDir: workDir})
+	td.Register(&ShellTool{workDir: workDir, sandbox: td.sandbox})
 
 	// Register dockerizer-specific tools
 	td.Register(&DockrizerAnalyzeTool{workDir: workDir})
@@ -148,6 +162,25 @@ func (td *ToolDispatcher) Register(tool Tool) {
 	td.tools[tool.Name()] = tool
 }
 
+// SetSandbox configures where and how the Docker tools build and run
+// containers; unset fields keep their defaults
+func (td *ToolDispatcher) SetSandbox(sandbox *Sandbox) {
+	*td.sandbox = *sandbox.withDefaults()
+}
+
+// SetOutput streams the output of image builds to w as they run
+func (td *ToolDispatcher) SetOutput(w io.Writer) {
+	td.output.w = w
+}
+
+// SetRedaction redacts secrets in the file contents file_read returns,
+// which the AI sees; it is on by default
+func (td *ToolDispatcher) SetRedaction(enable bool) {
+	if t, ok := td.tools["file_read"].(*FileReadTool); ok {
+		t.noRedact = !enable
+	}
+}
+
 // SetInspectors configures the inspectors to run before tool execution
 func (td *ToolDispatcher) SetInspectors(inspectors []Inspector) {
 	td.inspectors = inspectors
@@ -170,13 +203,37 @@ func (td *ToolDispatcher) Execute(ctx context.Context, name string, args map[str
 	return tool.Execute(ctx, args)
 }
 
+// DockerfileName is the name the Dockerfile is written under: that of an
+// existing Containerfile, else Dockerfile
+func (td *ToolDispatcher) DockerfileName() string {
+	if name := generator.ExistingFile(td.workDir, generator.DockerfileNames...); name != "" {
+		return name
+	}
+	return "Dockerfile"
+}
+
+// ComposeName is the name the compose file is written under: that of an
+// existing compose.yaml or docker-compose.yaml, else docker-compose.yml
+func (td *ToolDispatcher) ComposeName() string {
+	if name := generator.ExistingFile(td.workDir, generator.ComposeNames...); name != "" {
+		return name
+	}
+	return "docker-compose.yml"
+}
+
 // WriteDockerFiles writes the generated Docker files
 func (td *ToolDispatcher) WriteDockerFiles(ctx context.Context, output *Output) error {
+	// Keep saved agent sessions (and their build logs) out of the build context
+	dockerignore := output.Dockerignore
+	if dockerignore != "" && !strings.Contains(dockerignore, ".dockerizer") {
+		dockerignore = strings.TrimRight(dockerignore, "\n") + "\n.dockerizer/\n"
+	}
+
 	files := map[string]string{
-		"Dockerfile":         output.Dockerfile,
-		"docker-compose.yml": output.DockerCompose,
-		".dockerignore":      output.Dockerignore,
-		".env.example":       output.EnvExample,
+		td.DockerfileName(): output.Dockerfile,
+		td.ComposeName():    output.DockerCompose,
+		".dockerignore":     dockerignore,
+		".env.example":      output.EnvExample,
 	}
 
 	for name, content := range files {
@@ -203,7 +260,10 @@ func (td *ToolDispatcher) ListTools() []Tool {
 
 // DockerBuildTool builds Docker images
 type DockerBuildTool struct {
-	workDir string
+	workDir   string
+	resources *resourceRegistry
+	sandbox   *Sandbox
+	output    *outputSink
 }
 
 func (t *DockerBuildTool) Name() string        { return "docker_build" }
@@ -219,18 +279,22 @@ func (t *DockerBuildTool) Execute(ctx context.Context, args map[string]interface
 		tag = "dockerize-build:latest"
 	}
 
-	cmd := exec.CommandContext(ctx, "docker", "build", "-f", dockerfile, "-t", tag, ".")
+	if t.resources != nil {
+		t.resources.addImage(tag)
+	}
+
+	cmd := t.sandbox.command(ctx, "build", "-f", dockerfile, "-t", tag, ".")
 	cmd.Dir = t.workDir
 
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	cmd.Stdout = t.output.tee(&stdout)
+	cmd.Stderr = t.output.tee(&stderr)
 
 	err := cmd.Run()
 	output := stdout.String() + stderr.String()
 
 	if err != nil {
-		return output, fmt.Errorf("docker build failed: %w\n%s", err, output)
+		return output, fmt.Errorf("%s build failed: %w\n%s", t.sandbox.withDefaults().Runtime, err, output)
 	}
 
 	return output, nil
@@ -238,7 +302,9 @@ func (t *DockerBuildTool) Execute(ctx context.Context, args map[string]interface
 
 // DockerRunTool runs Docker containers
 type DockerRunTool struct {
-	workDir string
+	workDir   string
+	resources *resourceRegistry
+	sandbox   *Sandbox
 }
 
 func (t *DockerRunTool) Name() string        { return "docker_run" }
@@ -250,15 +316,41 @@ func (t *DockerRunTool) Execute(ctx context.Context, args map[string]interface{}
 		return "", fmt.Errorf("image is required")
 	}
 
+	// Seconds a container without a HEALTHCHECK must stay up (or log ready)
 	timeout := 30
-	if t, ok := args["timeout"].(int); ok {
+	switch t := args["timeout"].(type) {
+	case int:
 		timeout = t
+	case float64:
+		timeout = int(t)
 	}
+	ready, _ := args["ready"].(string)
 
 	containerName := fmt.Sprintf("dockerize-test-%d", time.Now().UnixNano())
+	if t.resources != nil {
+		t.resources.addContainer(containerName)
+	}
+
+	// Remove the container even when ctx is cancelled mid-test
+	cleanup := func() {
+		rmCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+		defer cancel()
+		if t.sandbox.command(rmCtx, "rm", "-f", containerName).Run() == nil && t.resources != nil {
+			t.resources.removeContainer(containerName)
+		}
+	}
+	defer cleanup()
 
-	// Start container in detached mode
-	runCmd := exec.CommandContext(ctx, "docker", "run", "-d", "--name", containerName, image)
+	// Start container in detached mode on the sandbox network, with its limits
+	created, err := t.sandbox.ensureNetwork(ctx)
+	if err != nil {
+		return "", err
+	}
+	if created && t.resources != nil {
+		t.resources.addNetwork(t.sandbox.withDefaults().Network)
+	}
+	runArgs := append([]string{"run", "-d", "--name", containerName, "--label", ResourceLabel + "=true"}, t.sandbox.runArgs()...)
+	runCmd := t.sandbox.command(ctx, append(runArgs, image)...)
 	runCmd.Dir = t.workDir
 
 	var stdout bytes.Buffer
@@ -266,43 +358,32 @@ func (t *DockerRunTool) Execute(ctx context.Context, args map[string]interface{}
 	runCmd.Stderr = &stdout
 
 	if err := runCmd.Run(); err != nil {
-		return stdout.String(), fmt.Errorf("docker run failed: %w", err)
+		return stdout.String(), fmt.Errorf("%s run failed: %w", t.sandbox.withDefaults().Runtime, err)
 	}
 
-	// Wait for container to be healthy or timeout
-	time.Sleep(time.Duration(timeout) * time.Second)
-
-	// Check container status
-	inspectCmd := exec.CommandContext(ctx, "docker", "inspect", "--format", "{{.State.Status}}", containerName)
-	var inspectOut bytes.Buffer
-	inspectCmd.Stdout = &inspectOut
-
-	if err := inspectCmd.Run(); err == nil {
-		status := strings.TrimSpace(inspectOut.String())
-		if status != "running" {
-			// Get logs for debugging
-			logsCmd := exec.CommandContext(ctx, "docker", "logs", containerName)
-			var logsOut bytes.Buffer
-			logsCmd.Stdout = &logsOut
-			logsCmd.Stderr = &logsOut
-			_ = logsCmd.Run()
-
-			// Cleanup
-			_ = exec.CommandContext(ctx, "docker", "rm", "-f", containerName).Run()
-
-			return logsOut.String(), fmt.Errorf("container exited with status: %s", status)
+	// Wait until the container is healthy, logs the ready string, or stays up
+	// for the timeout; failures carry the exit code, health log and logs
+	hc := imageHealthcheck(ctx, t.sandbox, image)
+	if err := waitForContainer(ctx, t.sandbox, containerName, hc, time.Duration(timeout)*time.Second, ready); err != nil {
+		if f, ok := err.(*ContainerFailure); ok {
+			return f.Logs, f
 		}
+		return "", err
 	}
 
-	// Container is running, clean up
-	_ = exec.CommandContext(ctx, "docker", "stop", containerName).Run()
-	_ = exec.CommandContext(ctx, "docker", "rm", containerName).Run()
+	// Container is up; stop it gracefully before the deferred removal
+	_ = t.sandbox.command(ctx, "stop", containerName).Run()
 
+	if hc != nil {
+		return "Container started and passed its health check", nil
+	}
 	return "Container started and ran successfully", nil
 }
 
 // DockerLogsTool gets container logs
-type DockerLogsTool struct{}
+type DockerLogsTool struct {
+	sandbox *Sandbox
+}
 
 func (t *DockerLogsTool) Name() string        { return "docker_logs" }
 func (t *DockerLogsTool) Description() string { return "Get logs from a Docker container" }
@@ -318,7 +399,7 @@ func (t *DockerLogsTool) Execute(ctx context.Context, args map[string]interface{
 		tail = t
 	}
 
-	cmd := exec.CommandContext(ctx, "docker", "logs", "--tail", tail, container)
+	cmd := t.sandbox.command(ctx, "logs", "--tail", tail, container)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -328,7 +409,9 @@ func (t *DockerLogsTool) Execute(ctx context.Context, args map[string]interface{
 }
 
 // DockerStopTool stops containers
-type DockerStopTool struct{}
+type DockerStopTool struct {
+	sandbox *Sandbox
+}
 
 func (t *DockerStopTool) Name() string        { return "docker_stop" }
 func (t *DockerStopTool) Description() string { return "Stop a Docker container" }
@@ -339,7 +422,7 @@ func (t *DockerStopTool) Execute(ctx context.Context, args map[string]interface{
 		return "", fmt.Errorf("container name is required")
 	}
 
-	cmd := exec.CommandContext(ctx, "docker", "stop", container)
+	cmd := t.sandbox.command(ctx, "stop", container)
 	var stdout bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stdout
@@ -382,9 +465,10 @@ func (t *FileWriteTool) Execute(ctx context.Context, args map[string]interface{}
 	return fmt.Sprintf("Written %d bytes to %s", len(content), path), nil
 }
 
-// FileReadTool reads files
+// FileReadTool reads files, with secrets redacted unless noRedact is set
 type FileReadTool struct {
-	workDir string
+	workDir  string
+	noRedact bool
 }
 
 func (t *FileReadTool) Name() string        { return "file_read" }
@@ -416,12 +500,17 @@ func (t *FileReadTool) Execute(ctx context.Context, args map[string]interface{})
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	return string(content), nil
+	if t.noRedact {
+		return string(content), nil
+	}
+	text, _ := redact.Secrets(string(content))
+	return text, nil
 }
 
 // ShellTool executes shell commands with strict allowlisting and argument validation
 type ShellTool struct {
 	workDir string
+	sandbox *Sandbox
 }
 
 func (t *ShellTool) Name() string        { return "shell" }
@@ -438,8 +527,16 @@ func (t *ShellTool) Execute(ctx context.Context, args map[string]interface{}) (s
 		return "", err
 	}
 
+	// Commands written for docker run on the sandbox's runtime
+	sb := t.sandbox.withDefaults()
+	command = strings.TrimSpace(command)
+	if name, rest, _ := strings.Cut(command, " "); name == "docker" && sb.Runtime != "docker" {
+		command = sb.Runtime + " " + rest
+	}
+
 	cmd := exec.CommandContext(ctx, "sh", "-c", command)
 	cmd.Dir = t.workDir
+	cmd.Env = sb.environ()
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -481,14 +578,14 @@ func (t *ShellTool) validateShellCommand(command string) error {
 
 	baseCmd := filepath.Base(parts[0])
 
-	// Only allow docker and docker-compose
+	// Only allow the container runtimes and docker-compose
 	switch baseCmd {
-	case "docker":
+	case "docker", "podman", "nerdctl":
 		return t.validateDockerCommand(parts[1:])
 	case "docker-compose":
 		return t.validateDockerComposeCommand(parts[1:])
 	default:
-		return fmt.Errorf("only docker and docker-compose commands are allowed, got: %s", baseCmd)
+		return fmt.Errorf("only docker (or podman, nerdctl) and docker-compose commands are allowed, got: %s", baseCmd)
 	}
 }
 
@@ -650,16 +747,7 @@ func (t *DockrizerAnalyzeTool) Execute(ctx context.Context, args map[string]inte
 	}
 
 	// Create registry and detect
-	registry := detector.NewRegistry()
-	nodejs.RegisterAll(registry)
-	python.RegisterAll(registry)
-	golang.RegisterAll(registry)
-	rust.RegisterAll(registry)
-	ruby.RegisterAll(registry)
-	php.RegisterAll(registry)
-	java.RegisterAll(registry)
-	dotnet.RegisterAll(registry)
-	elixir.RegisterAll(registry)
+	registry := all.NewRegistry()
 
 	det := detector.New(registry)
 	result, err := det.Detect(ctx, scan)
@@ -677,6 +765,16 @@ func (t *DockrizerAnalyzeTool) Execute(ctx context.Context, args map[string]inte
 		"provider":   result.Provider,
 	}
 
+	// In a monorepo, list the deployable packages for dockerizer_generate's app arg
+	if ws := scan.Metadata.Workspace; ws != nil {
+		apps := []map[string]string{}
+		for _, pkg := range detector.DeployablePackages(ws) {
+			apps = append(apps, map[string]string{"name": pkg.Name, "dir": pkg.Dir})
+		}
+		output["workspace"] = ws.Tool
+		output["apps"] = apps
+	}
+
 	jsonOutput, err := json.MarshalIndent(output, "", "  ")
 	if err != nil {
 		return "", err
@@ -709,29 +807,43 @@ func (t *DockrizerGenerateTool) Execute(ctx context.Context, args map[string]int
 		overwrite = true
 	}
 
+	app, _ := args["app"].(string)
+
 	// Scan repository
 	scan, err := scanner.New(scanner.WithIgnoreHidden(false)).Scan(ctx, path)
 	if err != nil {
 		return "", fmt.Errorf("scan failed: %w", err)
 	}
 
+	// In a monorepo, generate for the selected package, built from the root
+	ws := scan.Metadata.Workspace
+	rootPkg := scan.Metadata.PackageJSON
+	var wsPkg *scanner.WorkspacePackage
+	if ws != nil {
+		if wsPkg, err = detector.SelectWorkspaceApp(ws, app); err != nil {
+			return "", err
+		}
+	} else if app != "" {
+		return "", fmt.Errorf("app %q needs a pnpm, yarn, npm or bun workspace", app)
+	}
+	if wsPkg != nil {
+		path = filepath.Join(path, wsPkg.Dir)
+		if scan, err = scanner.New(scanner.WithIgnoreHidden(false)).Scan(ctx, path); err != nil {
+			return "", fmt.Errorf("scan failed: %w", err)
+		}
+	}
+
 	// Create registry and detect
-	registry := detector.NewRegistry()
-	nodejs.RegisterAll(registry)
-	python.RegisterAll(registry)
-	golang.RegisterAll(registry)
-	rust.RegisterAll(registry)
-	ruby.RegisterAll(registry)
-	php.RegisterAll(registry)
-	java.RegisterAll(registry)
-	dotnet.RegisterAll(registry)
-	elixir.RegisterAll(registry)
+	registry := all.NewRegistry()
 
 	det := detector.New(registry)
 	result, err := det.Detect(ctx, scan)
 	if err != nil {
 		return "", fmt.Errorf("detection failed: %w", err)
 	}
+	if wsPkg != nil && result.Detected {
+		detector.ApplyWorkspace(result, ws, wsPkg, rootPkg)
+	}
 
 	if !result.Detected {
 		return "", fmt.Errorf("could not detect project stack")
@@ -743,6 +855,7 @@ func (t *DockrizerGenerateTool) Execute(ctx context.Context, args map[string]int
 		generator.WithCompose(true),
 		generator.WithIgnore(true),
 		generator.WithEnv(true),
+		generator.WithExistingNames(path),
 	)
 
 	output, err := gen.Generate(result, path)
@@ -762,6 +875,10 @@ func (t *DockrizerGenerateTool) Execute(ctx context.Context, args map[string]int
 		"framework": result.Framework,
 		"files":    files,
 	}
+	if wsPkg != nil {
+		resultOutput["app"] = wsPkg.Name
+		resultOutput["dir"] = wsPkg.Dir
+	}
 
 	jsonOutput, err := json.MarshalIndent(resultOutput, "", "  ")
 	if err != nil {
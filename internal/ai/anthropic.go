@@ -1,7 +1,6 @@
 package ai
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -17,7 +16,7 @@ type AnthropicProvider struct {
 	apiKey  string
 	model   string
 	baseURL string
-	client  *http.Client
+	http    *transport
 }
 
 // NewAnthropicProvider creates a new Anthropic provider
@@ -29,9 +28,7 @@ func NewAnthropicProvider(apiKey, model string) *AnthropicProvider {
 		apiKey:  apiKey,
 		model:   model,
 		baseURL: "https://api.anthropic.com/v1",
-		client: &http.Client{
-			Timeout: 120 * time.Second,
-		},
+		http:    newTransport("anthropic", "API", 120*time.Second),
 	}
 }
 
@@ -40,84 +37,274 @@ func (p *AnthropicProvider) Name() string {
 	return "anthropic"
 }
 
+// SetHTTPConfig configures the timeout, retries and circuit breaker of the
+// provider's requests
+func (p *AnthropicProvider) SetHTTPConfig(cfg HTTPConfig) {
+	p.http.configure(cfg)
+}
+
+// Metrics returns the request metrics of the provider
+func (p *AnthropicProvider) Metrics() []Metrics {
+	return []Metrics{p.http.snapshot()}
+}
+
 // IsAvailable checks if the provider is configured
 func (p *AnthropicProvider) IsAvailable() bool {
 	return p.apiKey != ""
 }
 
+// anthropicMessage is a message of the conversation; Content is the prompt
+// string or a list of content blocks
+type anthropicMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// anthropicBlock is a content block: text, a tool call of the model or the
+// result of one
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// anthropicReply is the content and token usage of one response
+type anthropicReply struct {
+	Content []anthropicBlock `json:"content"`
+	Usage   struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
 // Generate creates Docker configuration using Anthropic Claude
 func (p *AnthropicProvider) Generate(ctx context.Context, scan *scanner.ScanResult, instructions string) (*Response, error) {
-	prompt := BuildPrompt(scan, instructions)
+	return p.generate(ctx, scan, instructions, nil)
+}
 
-	// Build request
-	reqBody := map[string]interface{}{
-		"model":      p.model,
-		"max_tokens": 4096,
-		"system":     SystemPrompt + "\n\nIMPORTANT: Respond with valid JSON only, no markdown code blocks.",
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-	}
+// GenerateStream creates Docker configuration, reporting the response as it streams in
+func (p *AnthropicProvider) GenerateStream(ctx context.Context, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error) {
+	return p.generate(ctx, scan, instructions, fn)
+}
 
-	reqJSON, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
+// generate sends the tree and the manifests and answers the model's
+// file_read calls until it calls docker_config with its answer, which is
+// sent back once when it doesn't parse. A nil fn doesn't stream.
+func (p *AnthropicProvider) generate(ctx context.Context, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error) {
+	prompt, report := BuildToolPrompt(scan, instructions, PromptBudget(p.Name(), p.model))
+	files := newFileReader(scan, &report)
+	messages := []anthropicMessage{{Role: "user", Content: prompt}}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/messages", bytes.NewReader(reqJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	var usage []Usage
+	retries := 0
+	for turn := 1; turn <= maxToolTurns+maxParseRetries; turn++ {
+		last := turn >= maxToolTurns
+		var reply *anthropicReply
+		var err error
+		if fn != nil {
+			reply, err = p.stream(ctx, messages, last, fn)
+		} else {
+			reply, err = p.complete(ctx, messages, last)
+		}
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, NewUsage(p.Name(), p.model, reply.Usage.InputTokens, reply.Usage.OutputTokens))
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", p.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+		var text string
+		var answer *anthropicBlock
+		var content, results []anthropicBlock
+		for _, block := range reply.Content {
+			switch block.Type {
+			case "text":
+				if block.Text == "" {
+					continue // The API rejects empty text blocks
+				}
+				text += block.Text
+			case "tool_use":
+				if len(block.Input) == 0 {
+					block.Input = json.RawMessage("{}")
+				}
+				if block.Name == responseTool {
+					answer = &block
+					break
+				}
+				result, err := files.call(block.Name, block.Input)
+				if err != nil {
+					result = err.Error()
+				}
+				results = append(results, anthropicBlock{Type: "tool_result", ToolUseID: block.ID, Content: result, IsError: err != nil})
+			}
+			content = append(content, block)
+		}
 
-	resp, err := p.client.Do(req)
+		if answer == nil && len(results) == 0 {
+			// Not expected with tool_choice, but a text answer is parsed too
+			if text == "" {
+				return nil, fmt.Errorf("no text in AI response")
+			}
+			response, err := parseResponseText(text, report, usage...)
+			if err == nil || retries == maxParseRetries {
+				return response, err
+			}
+			retries++
+			messages = append(messages,
+				anthropicMessage{Role: "assistant", Content: content},
+				anthropicMessage{Role: "user", Content: retryPrompt(err)})
+			continue
+		}
+		if answer != nil {
+			response, err := parseResponseText(string(answer.Input), report, usage...)
+			if err == nil || retries == maxParseRetries {
+				return response, err
+			}
+			retries++
+			results = append(results, anthropicBlock{Type: "tool_result", ToolUseID: answer.ID, Content: retryPrompt(err), IsError: true})
+		}
+		messages = append(messages,
+			anthropicMessage{Role: "assistant", Content: content},
+			anthropicMessage{Role: "user", Content: results})
+	}
+	return nil, fmt.Errorf("no answer from AI after %d requests", maxToolTurns+maxParseRetries)
+}
+
+// complete sends one request and decodes the whole response
+func (p *AnthropicProvider) complete(ctx context.Context, messages []anthropicMessage, last bool) (*anthropicReply, error) {
+	resp, err := p.send(ctx, messages, last, false)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+	var reply anthropicReply
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	// Parse response
-	var result struct {
-		Content []struct {
-			Type string `json:"type"`
-			Text string `json:"text"`
-		} `json:"content"`
+	if len(reply.Content) == 0 {
+		return nil, fmt.Errorf("no response from AI")
 	}
+	return &reply, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// stream sends one streaming request, reporting the docker_config input to
+// fn as it streams, and assembles the response from its events
+func (p *AnthropicProvider) stream(ctx context.Context, messages []anthropicMessage, last bool, fn StreamFunc) (*anthropicReply, error) {
+	resp, err := p.send(ctx, messages, last, true)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if len(result.Content) == 0 {
-		return nil, fmt.Errorf("no response from AI")
+	var reply anthropicReply
+	var inputs []string // JSON input of each tool_use block
+	dec := newSectionDecoder(p.Name(), fn)
+	err = readSSE(ctx, resp.Body, func(data string) error {
+		var event struct {
+			Type    string `json:"type"`
+			Index   int    `json:"index"`
+			Message struct {
+				Usage struct {
+					InputTokens int `json:"input_tokens"`
+				} `json:"usage"`
+			} `json:"message"`
+			ContentBlock anthropicBlock `json:"content_block"`
+			Delta        struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+			} `json:"delta"`
+			Usage struct {
+				OutputTokens int `json:"output_tokens"`
+			} `json:"usage"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal([]byte(data), &event) != nil {
+			return nil
+		}
+		switch event.Type {
+		case "message_start":
+			reply.Usage.InputTokens = event.Message.Usage.InputTokens
+		case "message_delta":
+			// Output tokens are cumulative
+			reply.Usage.OutputTokens = event.Usage.OutputTokens
+		case "content_block_start":
+			if event.Index == len(reply.Content) {
+				reply.Content = append(reply.Content, event.ContentBlock)
+				inputs = append(inputs, "")
+			}
+		case "content_block_delta":
+			if event.Index >= len(reply.Content) {
+				return nil
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				reply.Content[event.Index].Text += event.Delta.Text
+			case "input_json_delta":
+				inputs[event.Index] += event.Delta.PartialJSON
+				// The answer streams as the input of docker_config
+				if reply.Content[event.Index].Name == responseTool {
+					dec.Write(event.Delta.PartialJSON)
+				}
+			}
+		case "message_stop":
+			return io.EOF
+		case "error":
+			return fmt.Errorf("API error: %s", event.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stream failed: %w", err)
 	}
 
-	// Find text content
-	var textContent string
-	for _, c := range result.Content {
-		if c.Type == "text" {
-			textContent = c.Text
-			break
+	for i := range reply.Content {
+		if reply.Content[i].Type == "tool_use" && inputs[i] != "" {
+			reply.Content[i].Input = json.RawMessage(inputs[i])
 		}
 	}
-
-	if textContent == "" {
+	if len(reply.Content) == 0 {
 		return nil, fmt.Errorf("no text in AI response")
 	}
+	return &reply, nil
+}
+
+// send posts a messages request offering file_read and docker_config, which
+// the last request has to call, and checks the response status
+func (p *AnthropicProvider) send(ctx context.Context, messages []anthropicMessage, last, stream bool) (*http.Response, error) {
+	// Build request
+	reqBody := map[string]interface{}{
+		"model":      p.model,
+		"max_tokens": maxOutputTokens,
+		"system":     toolSystemPrompt + "\n\nIMPORTANT: Answer by calling the " + responseTool + " tool; its input is the JSON object.",
+		"messages":   messages,
+		"tools": []map[string]interface{}{
+			{"name": fileReadTool, "description": fileReadDescription, "input_schema": fileReadSchema},
+			{"name": responseTool, "description": responseToolDescription, "input_schema": responseSchema},
+		},
+		// Every answer is a tool call, so it is always a JSON object
+		"tool_choice": map[string]string{"type": "any"},
+	}
+	if last {
+		reqBody["tool_choice"] = map[string]string{"type": "tool", "name": responseTool}
+	}
+	if stream {
+		reqBody["stream"] = true
+	}
 
-	// Parse the JSON response
-	var response Response
-	if err := json.Unmarshal([]byte(textContent), &response); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	return &response, nil
+	header := http.Header{}
+	header.Set("x-api-key", p.apiKey)
+	header.Set("anthropic-version", "2023-06-01")
+	return p.http.post(ctx, p.baseURL+"/messages", reqJSON, header, stream)
 }
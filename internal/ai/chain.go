@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// ChainProvider tries providers in order, failing over to the next one on errors
+// such as rate limits, timeouts, or unparseable responses
+type ChainProvider struct {
+	providers []Provider
+}
+
+// NewChainProvider creates a provider chain (e.g. anthropic → openai → ollama)
+func NewChainProvider(providers ...Provider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+// Name returns the chain name, e.g. "anthropic>openai>ollama"
+func (c *ChainProvider) Name() string {
+	names := make([]string, len(c.providers))
+	for i, p := range c.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ">")
+}
+
+// IsAvailable returns true if at least one provider in the chain is available
+func (c *ChainProvider) IsAvailable() bool {
+	for _, p := range c.providers {
+		if p.IsAvailable() {
+			return true
+		}
+	}
+	return false
+}
+
+// Metrics returns the request metrics of each provider in the chain
+func (c *ChainProvider) Metrics() []Metrics {
+	var metrics []Metrics
+	for _, p := range c.providers {
+		metrics = append(metrics, ProviderMetrics(p)...)
+	}
+	return metrics
+}
+
+// Generate asks each available provider in turn and returns the first success
+func (c *ChainProvider) Generate(ctx context.Context, scan *scanner.ScanResult, instructions string) (*Response, error) {
+	return c.GenerateStream(ctx, scan, instructions, nil)
+}
+
+// GenerateStream is Generate with streaming progress from each provider tried.
+// Events carry the provider name, so a failover shows up as a new provider.
+func (c *ChainProvider) GenerateStream(ctx context.Context, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error) {
+	var failures []string
+
+	for _, p := range c.providers {
+		if !p.IsAvailable() {
+			failures = append(failures, fmt.Sprintf("%s: not available", p.Name()))
+			continue
+		}
+
+		resp, err := GenerateStream(ctx, p, scan, instructions, fn)
+		if err == nil {
+			if len(failures) > 0 {
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf("generated by %s after failover (%s)", p.Name(), strings.Join(failures, "; ")))
+			}
+			return resp, nil
+		}
+
+		// Don't fail over once the caller has given up
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", p.Name(), err))
+	}
+
+	return nil, fmt.Errorf("%w: all providers failed: %s", errors.ErrAIRequestFailed, strings.Join(failures, "; "))
+}
+
+// Scorer rates an AI response; higher is better. The CLI scores with the
+// Dockerfile validator, which lives outside this package.
+type Scorer func(resp *Response) int
+
+// ABProvider asks two providers concurrently and keeps the better-scoring response
+type ABProvider struct {
+	a, b   Provider
+	scorer Scorer
+}
+
+// NewABProvider creates an A/B provider that keeps the response scorer rates higher
+func NewABProvider(a, b Provider, scorer Scorer) *ABProvider {
+	return &ABProvider{a: a, b: b, scorer: scorer}
+}
+
+// Name returns the A/B pair name, e.g. "anthropic|openai"
+func (p *ABProvider) Name() string {
+	return p.a.Name() + "|" + p.b.Name()
+}
+
+// IsAvailable returns true if either provider is available
+func (p *ABProvider) IsAvailable() bool {
+	return p.a.IsAvailable() || p.b.IsAvailable()
+}
+
+// Metrics returns the request metrics of both providers
+func (p *ABProvider) Metrics() []Metrics {
+	return append(ProviderMetrics(p.a), ProviderMetrics(p.b)...)
+}
+
+// Generate runs both providers and returns the response with the higher score.
+// If only one succeeds its response is returned; ties go to provider A.
+func (p *ABProvider) Generate(ctx context.Context, scan *scanner.ScanResult, instructions string) (*Response, error) {
+	type outcome struct {
+		resp *Response
+		err  error
+	}
+
+	var wg sync.WaitGroup
+	results := make([]outcome, 2)
+	for i, provider := range []Provider{p.a, p.b} {
+		if !provider.IsAvailable() {
+			results[i] = outcome{err: fmt.Errorf("not available")}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, provider Provider) {
+			defer wg.Done()
+			resp, err := provider.Generate(ctx, scan, instructions)
+			results[i] = outcome{resp: resp, err: err}
+		}(i, provider)
+	}
+	wg.Wait()
+
+	a, b := results[0], results[1]
+
+	// Both calls are paid for, whichever response wins
+	var usage []Usage
+	for _, r := range results {
+		if r.resp != nil {
+			usage = append(usage, r.resp.Usage...)
+		}
+	}
+
+	switch {
+	case a.err != nil && b.err != nil:
+		return nil, fmt.Errorf("%w: %s: %v; %s: %v", errors.ErrAIRequestFailed, p.a.Name(), a.err, p.b.Name(), b.err)
+	case a.err != nil:
+		return b.resp, nil
+	case b.err != nil:
+		return a.resp, nil
+	}
+
+	scoreA, scoreB := p.scorer(a.resp), p.scorer(b.resp)
+	winner, winnerName, loserName, winScore, loseScore := a.resp, p.a.Name(), p.b.Name(), scoreA, scoreB
+	if scoreB > scoreA {
+		winner, winnerName, loserName, winScore, loseScore = b.resp, p.b.Name(), p.a.Name(), scoreB, scoreA
+	}
+	winner.Warnings = append(winner.Warnings, fmt.Sprintf("A/B: chose %s (score %d) over %s (score %d)", winnerName, winScore, loserName, loseScore))
+	winner.Usage = usage
+
+	return winner, nil
+}
@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	dzerrors "github.com/dublyo/dockerizer/internal/errors"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// fakeProvider answers with resp or fails with err, counting its calls
+type fakeProvider struct {
+	name        string
+	unavailable bool
+	resp        *Response
+	err         error
+	calls       int
+}
+
+func (p *fakeProvider) Name() string      { return p.name }
+func (p *fakeProvider) IsAvailable() bool { return !p.unavailable }
+
+func (p *fakeProvider) Generate(ctx context.Context, scan *scanner.ScanResult, instructions string) (*Response, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	resp := *p.resp
+	resp.Usage = []Usage{{Provider: p.name}}
+	return &resp, nil
+}
+
+func TestChainProvider(t *testing.T) {
+	limited := &fakeProvider{name: "anthropic", err: fmt.Errorf("rate limited (429)")}
+	down := &fakeProvider{name: "openai", unavailable: true}
+	ollama := &fakeProvider{name: "ollama", resp: &Response{Dockerfile: "FROM alpine:3.20\n"}}
+	chain := NewChainProvider(limited, down, ollama)
+
+	if chain.Name() != "anthropic>openai>ollama" || !chain.IsAvailable() {
+		t.Errorf("Name() = %q, IsAvailable() = %v", chain.Name(), chain.IsAvailable())
+	}
+	resp, err := chain.Generate(context.Background(), nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Dockerfile != "FROM alpine:3.20\n" || down.calls != 0 {
+		t.Errorf("Dockerfile = %q, unavailable provider called %d times", resp.Dockerfile, down.calls)
+	}
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "generated by ollama after failover") ||
+		!strings.Contains(resp.Warnings[0], "rate limited") || !strings.Contains(resp.Warnings[0], "openai: not available") {
+		t.Errorf("Warnings = %q", resp.Warnings)
+	}
+
+	ollama.err = fmt.Errorf("connection refused")
+	if _, err := chain.Generate(context.Background(), nil, ""); !errors.Is(err, dzerrors.ErrAIRequestFailed) {
+		t.Errorf("all providers failing: error = %v, want ErrAIRequestFailed", err)
+	}
+
+	// A cancelled run does not fail over
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limited.calls, ollama.calls = 0, 0
+	if _, err := chain.Generate(ctx, nil, ""); !errors.Is(err, context.Canceled) || ollama.calls != 0 {
+		t.Errorf("cancelled: error = %v, next provider called %d times", err, ollama.calls)
+	}
+}
+
+func TestABProvider(t *testing.T) {
+	// scoreLines prefers shorter Dockerfiles, standing in for the validator
+	scoreLines := func(resp *Response) int { return -strings.Count(resp.Dockerfile, "\n") }
+	short := &fakeProvider{name: "openai", resp: &Response{Dockerfile: "FROM alpine:3.20\n"}}
+	long := &fakeProvider{name: "anthropic", resp: &Response{Dockerfile: "FROM alpine:3.20\nRUN true\n"}}
+
+	resp, err := NewABProvider(long, short, scoreLines).Generate(context.Background(), nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Dockerfile != short.resp.Dockerfile {
+		t.Errorf("chose %q, want the higher-scoring response of openai", resp.Dockerfile)
+	}
+	if len(resp.Usage) != 2 || len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "chose openai (score -1) over anthropic (score -2)") {
+		t.Errorf("Usage = %v, Warnings = %q; want both calls and the choice", resp.Usage, resp.Warnings)
+	}
+
+	// Ties go to A
+	tied := &fakeProvider{name: "ollama", resp: long.resp}
+	if resp, _ = NewABProvider(long, tied, scoreLines).Generate(context.Background(), nil, ""); !strings.Contains(resp.Warnings[0], "chose anthropic") {
+		t.Errorf("tie: Warnings = %q", resp.Warnings)
+	}
+
+	// One failing side leaves the other's response
+	failing := &fakeProvider{name: "ollama", err: fmt.Errorf("model not found")}
+	if resp, err = NewABProvider(failing, long, scoreLines).Generate(context.Background(), nil, ""); err != nil || resp.Dockerfile != long.resp.Dockerfile {
+		t.Errorf("one failing: %v, %v", resp, err)
+	}
+	if _, err = NewABProvider(failing, &fakeProvider{name: "openai", err: fmt.Errorf("401")}, scoreLines).Generate(context.Background(), nil, ""); !errors.Is(err, dzerrors.ErrAIRequestFailed) {
+		t.Errorf("both failing: error = %v, want ErrAIRequestFailed", err)
+	}
+}
@@ -0,0 +1,165 @@
+package ai
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+)
+
+// maxBuildLog bounds the build log sent with a fix request; the error is
+// at its end
+const maxBuildLog = 12_000
+
+// FixInstructions asks for a minimal patch of an existing Dockerfile that
+// fixes the build failure in buildLog, rather than a new Dockerfile that
+// would throw away manual changes
+func FixInstructions(dockerfile, buildLog, instructions string) string {
+	var sb strings.Builder
+	sb.WriteString(`Fix the existing Dockerfile below instead of writing a new one. Change only
+the lines that cause the problem and keep everything else as it is, comments
+and manual changes included. Put a unified diff of the Dockerfile in patch
+(--- a/Dockerfile, +++ b/Dockerfile, then @@ hunks with 3 lines of context
+copied exactly), say what was wrong in explanation, and leave dockerfile and
+the other files empty.
+
+## Existing Dockerfile
+
+` + "```dockerfile\n" + strings.TrimSuffix(dockerfile, "\n") + "\n```\n")
+	if log := strings.TrimSpace(buildLog); log != "" {
+		if len(log) > maxBuildLog {
+			log = "...\n" + log[len(log)-maxBuildLog:]
+		}
+		sb.WriteString("\n## Build Log\n\n```\n" + log + "\n```\n")
+	}
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		sb.WriteString("\n## Also\n\n" + instructions + "\n")
+	}
+	return sb.String()
+}
+
+// patchHunk is one @@ hunk of a unified diff
+type patchHunk struct {
+	start    int // 1-based line the hunk claims to start at in the original
+	old, new []string
+}
+
+// ApplyPatch applies a unified diff to content. Models get line numbers
+// wrong more often than context, so each hunk is placed where its context
+// and removed lines match, nearest to the line it names; trailing
+// whitespace is ignored when nothing matches exactly.
+func ApplyPatch(content, patch string) (string, error) {
+	hunks, err := parsePatch(patch)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+	var out []string
+	next := 0 // First line of the original not yet copied
+	for i, h := range hunks {
+		at := findHunk(lines, h, next)
+		if at < 0 {
+			return "", fmt.Errorf("%w: hunk %d (line %d) does not match the Dockerfile", errors.ErrAIResponseInvalid, i+1, h.start)
+		}
+		out = append(out, lines[next:at]...)
+		out = append(out, h.new...)
+		next = at + len(h.old)
+	}
+	out = append(out, lines[next:]...)
+
+	result := strings.Join(out, "\n")
+	if len(out) > 0 && (strings.HasSuffix(content, "\n") || content == "") {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// parsePatch reads the hunks of a unified diff, skipping its file headers
+func parsePatch(patch string) ([]patchHunk, error) {
+	var hunks []patchHunk
+	var h *patchHunk
+	for _, line := range strings.Split(strings.TrimRight(patch, "\n"), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "@@") {
+			hunks = append(hunks, patchHunk{start: hunkStart(line)})
+			h = &hunks[len(hunks)-1]
+			continue
+		}
+		if h == nil || strings.HasPrefix(line, `\`) {
+			continue // Headers before the first hunk; "\ No newline at end of file"
+		}
+		if strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "diff ") {
+			h = nil // The header of another file
+			continue
+		}
+		switch {
+		case line == "":
+			// Editors and models drop the space of empty context lines
+			h.old, h.new = append(h.old, ""), append(h.new, "")
+		case line[0] == ' ':
+			h.old, h.new = append(h.old, line[1:]), append(h.new, line[1:])
+		case line[0] == '-':
+			h.old = append(h.old, line[1:])
+		case line[0] == '+':
+			h.new = append(h.new, line[1:])
+		default:
+			return nil, fmt.Errorf("%w: not a unified diff line: %q", errors.ErrAIResponseInvalid, line)
+		}
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("%w: the patch has no @@ hunks", errors.ErrAIResponseInvalid)
+	}
+	return hunks, nil
+}
+
+// hunkStart reads the original start line of a hunk header such as
+// "@@ -12,4 +12,5 @@"; 0 when it has none
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 2 || !strings.HasPrefix(fields[1], "-") {
+		return 0
+	}
+	start, _, _ := strings.Cut(fields[1][1:], ",")
+	n, _ := strconv.Atoi(start)
+	return n
+}
+
+// findHunk returns the index of the line at or after from where the hunk's
+// original lines are, nearest to its stated start, or -1
+func findHunk(lines []string, h patchHunk, from int) int {
+	hint := max(h.start-1, from)
+	if len(h.old) == 0 {
+		// A pure addition goes after its stated line
+		return min(max(h.start, from), len(lines))
+	}
+	for _, equal := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	} {
+		matches := func(at int) bool {
+			if at < from || at+len(h.old) > len(lines) {
+				return false
+			}
+			for i, old := range h.old {
+				if !equal(lines[at+i], old) {
+					return false
+				}
+			}
+			return true
+		}
+		for d := 0; hint-d >= from || hint+d < len(lines); d++ {
+			if matches(hint + d) {
+				return hint + d
+			}
+			if d > 0 && matches(hint-d) {
+				return hint - d
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+
+	dzerrors "github.com/dublyo/dockerizer/internal/errors"
+)
+
+func TestApplyPatch(t *testing.T) {
+	original := `FROM node:20-alpine
+# Keep in sync with .nvmrc
+WORKDIR /app
+
+COPY package.json ./
+RUN npm install
+COPY . .
+CMD ["node", "index.js"]
+`
+	// The model's line numbers are off by two and the empty context line
+	// lost its space
+	patch := "```diff\n--- a/Dockerfile\n+++ b/Dockerfile\n@@ -5,5 +5,6 @@\n WORKDIR /app\n\n-COPY package.json ./\n-RUN npm install\n+COPY package.json package-lock.json ./\n+RUN npm ci\n COPY . .\n+USER node\n```"
+	got, err := ApplyPatch(original, unfence(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `FROM node:20-alpine
+# Keep in sync with .nvmrc
+WORKDIR /app
+
+COPY package.json package-lock.json ./
+RUN npm ci
+COPY . .
+USER node
+CMD ["node", "index.js"]
+`
+	if got != want {
+		t.Errorf("patched:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := ApplyPatch(original, "@@ -1 +1 @@\n-FROM node:18\n+FROM node:22\n"); !errors.Is(err, dzerrors.ErrAIResponseInvalid) {
+		t.Errorf("mismatched hunk: err = %v", err)
+	}
+}
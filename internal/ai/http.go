@@ -0,0 +1,252 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+)
+
+// HTTPConfig configures how a provider sends requests: the timeout, retries
+// with exponential backoff and the circuit breaker
+type HTTPConfig struct {
+	Timeout          time.Duration // Per request; 0 uses the provider's. Streams are bounded by the context instead
+	MaxRetries       int           // Retries of rate-limited (429), 5xx and failed requests
+	MinBackoff       time.Duration // Wait before the first retry, doubled for each next one
+	MaxBackoff       time.Duration // Longest wait; a longer Retry-After fails the request instead
+	BreakerThreshold int           // Requests failing in a row that open the circuit; 0 never opens it
+	BreakerCooldown  time.Duration // How long an open circuit refuses requests before trying again
+}
+
+// DefaultHTTPConfig retries three times, waiting 1s, 2s and 4s (or what the
+// provider asks for, up to 30s), and stops calling a provider for a minute
+// after five requests failed in a row
+func DefaultHTTPConfig() HTTPConfig {
+	return HTTPConfig{
+		MaxRetries:       3,
+		MinBackoff:       time.Second,
+		MaxBackoff:       30 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Minute,
+	}
+}
+
+// Metrics counts the requests of a provider
+type Metrics struct {
+	Provider    string `json:"provider"`
+	Requests    int    `json:"requests"` // Requests sent, retries included
+	Retries     int    `json:"retries"`
+	RateLimited int    `json:"rate_limited"` // 429 responses
+	Failures    int    `json:"failures"`     // Requests that failed after their retries
+	CircuitOpen int    `json:"circuit_open"` // Requests refused while the circuit was open
+}
+
+// String summarizes the metrics, e.g. "anthropic: 4 requests, 1 retried, 1 rate limited"
+func (m Metrics) String() string {
+	s := fmt.Sprintf("%s: %d requests", m.Provider, m.Requests)
+	if m.Retries > 0 {
+		s += fmt.Sprintf(", %d retried", m.Retries)
+	}
+	if m.RateLimited > 0 {
+		s += fmt.Sprintf(", %d rate limited", m.RateLimited)
+	}
+	if m.Failures > 0 {
+		s += fmt.Sprintf(", %d failed", m.Failures)
+	}
+	if m.CircuitOpen > 0 {
+		s += fmt.Sprintf(", %d refused by the open circuit", m.CircuitOpen)
+	}
+	return s
+}
+
+// MetricsProvider is a Provider that counts its requests
+type MetricsProvider interface {
+	Provider
+	Metrics() []Metrics
+}
+
+// ProviderMetrics returns the request metrics of a provider, or of each
+// provider of a chain or A/B pair
+func ProviderMetrics(p Provider) []Metrics {
+	if mp, ok := p.(MetricsProvider); ok {
+		return mp.Metrics()
+	}
+	return nil
+}
+
+// transport sends the requests of a provider, so that a run survives the
+// transient errors of an API and stops calling one that keeps failing
+type transport struct {
+	label   string // Names the API in errors, e.g. "API" or "Ollama"
+	timeout time.Duration
+	config  HTTPConfig
+	client  *http.Client
+
+	mu        sync.Mutex
+	metrics   Metrics
+	failures  int       // Requests failed in a row
+	openUntil time.Time // The circuit refuses requests until then
+}
+
+func newTransport(provider, label string, timeout time.Duration) *transport {
+	t := &transport{label: label, timeout: timeout, metrics: Metrics{Provider: provider}}
+	t.configure(DefaultHTTPConfig())
+	return t
+}
+
+// configure applies cfg and closes the circuit
+func (t *transport) configure(cfg HTTPConfig) {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = t.timeout
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.config = cfg
+	t.client = &http.Client{Timeout: timeout}
+	t.failures, t.openUntil = 0, time.Time{}
+}
+
+// snapshot returns the metrics so far
+func (t *transport) snapshot() Metrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.metrics
+}
+
+// post sends a JSON request and returns the response once its status is OK.
+// Rate-limited (429), 5xx and failed requests are retried with exponential
+// backoff, honoring Retry-After; other statuses fail at once. A stream has no
+// request timeout, as the context bounds it.
+func (t *transport) post(ctx context.Context, url string, body []byte, header http.Header, stream bool) (*http.Response, error) {
+	t.mu.Lock()
+	if time.Now().Before(t.openUntil) {
+		t.metrics.CircuitOpen++
+		wait := time.Until(t.openUntil).Round(time.Second)
+		t.mu.Unlock()
+		return nil, fmt.Errorf("%w: %d requests failed in a row; trying again in %s", errors.ErrAICircuitOpen, t.failures, wait)
+	}
+	client, cfg := t.client, t.config
+	t.mu.Unlock()
+	if stream {
+		c := *client
+		c.Timeout = 0
+		client = &c
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		t.count(func(m *Metrics) { m.Requests++ })
+		resp, err := client.Do(req)
+		var wait time.Duration
+		switch {
+		case err != nil:
+			// Don't retry or blame the provider once the caller has given up
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("request failed: %w", err)
+			}
+			err = fmt.Errorf("request failed: %w", err)
+		case resp.StatusCode == http.StatusOK:
+			t.succeeded()
+			return resp, nil
+		default:
+			data, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			switch {
+			case resp.StatusCode == http.StatusTooManyRequests:
+				t.count(func(m *Metrics) { m.RateLimited++ })
+				err = fmt.Errorf("%w: %s", errors.ErrAIRateLimited, string(data))
+				wait = retryAfter(resp.Header)
+			case resp.StatusCode >= 500:
+				err = fmt.Errorf("%s error (status %d): %s", t.label, resp.StatusCode, string(data))
+			default:
+				// The request itself is wrong; sending it again won't help
+				return nil, fmt.Errorf("%s error (status %d): %s", t.label, resp.StatusCode, string(data))
+			}
+		}
+
+		if attempt >= cfg.MaxRetries || wait > cfg.MaxBackoff {
+			t.failed()
+			return nil, err
+		}
+		if wait == 0 {
+			wait = backoff(cfg, attempt)
+		}
+		t.count(func(m *Metrics) { m.Retries++ })
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (t *transport) count(fn func(m *Metrics)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.metrics)
+}
+
+// succeeded closes the circuit
+func (t *transport) succeeded() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failures, t.openUntil = 0, time.Time{}
+}
+
+// failed records a request that failed after its retries and opens the
+// circuit when too many failed in a row. After the cooldown the next request
+// goes through; if it fails too, the circuit opens again.
+func (t *transport) failed() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.metrics.Failures++
+	t.failures++
+	if t.config.BreakerThreshold > 0 && t.failures >= t.config.BreakerThreshold {
+		t.openUntil = time.Now().Add(t.config.BreakerCooldown)
+	}
+}
+
+// backoff is the wait before a retry: MinBackoff doubled per attempt, up to
+// MaxBackoff, plus up to a quarter more so that clients don't retry in step
+func backoff(cfg HTTPConfig, attempt int) time.Duration {
+	wait := cfg.MinBackoff << attempt
+	if wait > cfg.MaxBackoff || wait <= 0 {
+		wait = cfg.MaxBackoff
+	}
+	if wait >= 4 {
+		wait += rand.N(wait / 4)
+	}
+	return wait
+}
+
+// retryAfter reads how long a rate-limited response asks to wait, from
+// retry-after-ms (OpenAI) or Retry-After in seconds or as a date; 0 if neither
+func retryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.Atoi(header.Get("Retry-After-Ms")); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
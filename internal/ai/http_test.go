@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	dzerrors "github.com/dublyo/dockerizer/internal/errors"
+)
+
+func TestTransportRetries(t *testing.T) {
+	statuses := []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "key" {
+			t.Errorf("header not sent on attempt %d", calls+1)
+		}
+		w.Header().Set("Retry-After-Ms", "10")
+		w.WriteHeader(statuses[calls])
+		calls++
+	}))
+	defer server.Close()
+
+	tr := newTransport("test", "API", time.Second)
+	tr.configure(HTTPConfig{MaxRetries: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Second})
+	resp, err := tr.post(context.Background(), server.URL, []byte("{}"), http.Header{"X-Api-Key": {"key"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	want := Metrics{Provider: "test", Requests: 3, Retries: 2, RateLimited: 1}
+	if got := tr.snapshot(); got != want {
+		t.Errorf("metrics = %+v, want %+v", got, want)
+	}
+}
+
+func TestTransportCircuitBreaker(t *testing.T) {
+	status, calls := http.StatusBadGateway, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	tr := newTransport("test", "API", time.Second)
+	tr.configure(HTTPConfig{BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	post := func() error {
+		resp, err := tr.post(context.Background(), server.URL, nil, nil, false)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// Client errors are neither retried nor held against the provider
+	status = http.StatusBadRequest
+	if err := post(); err == nil || calls != 1 {
+		t.Fatalf("400: err %v after %d calls", err, calls)
+	}
+	status = http.StatusBadGateway
+	for i := 0; i < 2; i++ {
+		if err := post(); err == nil {
+			t.Fatal("502 should fail without retries")
+		}
+	}
+	if err := post(); !errors.Is(err, dzerrors.ErrAICircuitOpen) || calls != 3 {
+		t.Errorf("open circuit: err %v after %d calls", err, calls)
+	}
+	if m := tr.snapshot(); m.Failures != 2 || m.CircuitOpen != 1 {
+		t.Errorf("metrics = %+v", m)
+	}
+}
@@ -1,7 +1,6 @@
 package ai
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,11 +11,15 @@ import (
 	"github.com/dublyo/dockerizer/internal/scanner"
 )
 
+// ollamaContextTokens is the context window requested from Ollama, whose own
+// default (2048 to 4096 tokens) silently drops the start of longer prompts
+const ollamaContextTokens = 16384
+
 // OllamaProvider implements AI generation using Ollama (local LLM)
 type OllamaProvider struct {
 	baseURL string
 	model   string
-	client  *http.Client
+	http    *transport
 }
 
 // NewOllamaProvider creates a new Ollama provider
@@ -30,9 +33,7 @@ func NewOllamaProvider(baseURL, model string) *OllamaProvider {
 	return &OllamaProvider{
 		baseURL: baseURL,
 		model:   model,
-		client: &http.Client{
-			Timeout: 300 * time.Second, // Longer timeout for local models
-		},
+		http:    newTransport("ollama", "Ollama", 300*time.Second), // Longer timeout for local models
 	}
 }
 
@@ -41,13 +42,24 @@ func (p *OllamaProvider) Name() string {
 	return "ollama"
 }
 
+// SetHTTPConfig configures the timeout, retries and circuit breaker of the
+// provider's requests
+func (p *OllamaProvider) SetHTTPConfig(cfg HTTPConfig) {
+	p.http.configure(cfg)
+}
+
+// Metrics returns the request metrics of the provider
+func (p *OllamaProvider) Metrics() []Metrics {
+	return []Metrics{p.http.snapshot()}
+}
+
 // IsAvailable checks if Ollama is running
 func (p *OllamaProvider) IsAvailable() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
 	req, _ := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/api/tags", nil)
-	resp, err := p.client.Do(req)
+	resp, err := p.http.client.Do(req)
 	if err != nil {
 		return false
 	}
@@ -57,61 +69,136 @@ func (p *OllamaProvider) IsAvailable() bool {
 
 // Generate creates Docker configuration using Ollama
 func (p *OllamaProvider) Generate(ctx context.Context, scan *scanner.ScanResult, instructions string) (*Response, error) {
-	prompt := BuildPrompt(scan, instructions)
+	return p.generate(ctx, scan, instructions, nil)
+}
 
-	// Build request
-	reqBody := map[string]interface{}{
-		"model":  p.model,
-		"prompt": SystemPrompt + "\n\n" + prompt + "\n\nRespond with valid JSON only.",
-		"stream": false,
-		"format": "json",
-		"options": map[string]interface{}{
-			"temperature": 0.2,
-			"num_predict": 4096,
-		},
+// GenerateStream creates Docker configuration, reporting the response as it streams in
+func (p *OllamaProvider) GenerateStream(ctx context.Context, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error) {
+	return p.generate(ctx, scan, instructions, fn)
+}
+
+// generate sends the prompt and parses the answer, sending the prompt again
+// with the parse error when it doesn't parse. A nil fn doesn't stream.
+func (p *OllamaProvider) generate(ctx context.Context, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error) {
+	prompt, report := BuildPromptWithBudget(scan, instructions, PromptBudget(p.Name(), p.model))
+
+	var usage []Usage
+	for retries := 0; ; retries++ {
+		var text string
+		var u Usage
+		var err error
+		if fn != nil {
+			text, u, err = p.stream(ctx, prompt, fn)
+		} else {
+			text, u, err = p.complete(ctx, prompt)
+		}
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+
+		response, err := parseResponseText(text, report, usage...)
+		if err == nil || retries == maxParseRetries {
+			return response, err
+		}
+		prompt += "\n\n" + retryPrompt(err)
 	}
+}
 
-	reqJSON, err := json.Marshal(reqBody)
+// complete sends the prompt and decodes the whole answer
+func (p *OllamaProvider) complete(ctx context.Context, prompt string) (string, Usage, error) {
+	resp, err := p.send(ctx, prompt, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return "", Usage{}, err
 	}
+	defer resp.Body.Close()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/generate", bytes.NewReader(reqJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Parse response
+	var result struct {
+		Response        string `json:"response"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", Usage{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if result.Response == "" {
+		return "", Usage{}, fmt.Errorf("empty response from Ollama")
+	}
+
+	return result.Response, NewUsage(p.Name(), p.model, result.PromptEvalCount, result.EvalCount), nil
+}
 
-	resp, err := p.client.Do(req)
+// stream sends the prompt, reporting the answer to fn as it streams in
+func (p *OllamaProvider) stream(ctx context.Context, prompt string, fn StreamFunc) (string, Usage, error) {
+	resp, err := p.send(ctx, prompt, true)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return "", Usage{}, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Ollama error (status %d): %s", resp.StatusCode, string(body))
+	// Ollama streams one JSON object per line
+	var usage Usage
+	dec := newSectionDecoder(p.Name(), fn)
+	jd := json.NewDecoder(resp.Body)
+	for {
+		var chunk struct {
+			Response        string `json:"response"`
+			Done            bool   `json:"done"`
+			Error           string `json:"error"`
+			PromptEvalCount int    `json:"prompt_eval_count"`
+			EvalCount       int    `json:"eval_count"`
+		}
+		if err := jd.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			if ctx.Err() != nil {
+				return "", Usage{}, ctx.Err()
+			}
+			return "", Usage{}, fmt.Errorf("failed to decode response: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", Usage{}, fmt.Errorf("Ollama error: %s", chunk.Error)
+		}
+		dec.Write(chunk.Response)
+		if chunk.Done {
+			// The final object carries the token counts
+			usage = NewUsage(p.Name(), p.model, chunk.PromptEvalCount, chunk.EvalCount)
+			break
+		}
 	}
 
-	// Parse response
-	var result struct {
-		Response string `json:"response"`
+	if dec.Text() == "" {
+		return "", Usage{}, fmt.Errorf("empty response from Ollama")
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if usage.Provider == "" {
+		usage = NewUsage(p.Name(), p.model, 0, 0)
 	}
+	return dec.Text(), usage, nil
+}
 
-	if result.Response == "" {
-		return nil, fmt.Errorf("empty response from Ollama")
+// send posts a generate request and checks the response status
+func (p *OllamaProvider) send(ctx context.Context, prompt string, stream bool) (*http.Response, error) {
+	// Build request
+	reqBody := map[string]interface{}{
+		"model":  p.model,
+		"prompt": SystemPrompt + "\n\n" + prompt + "\n\nRespond with valid JSON only.",
+		"stream": stream,
+		"format": responseSchema, // Constrains the answer to the Response schema
+		"options": map[string]interface{}{
+			"temperature": 0.2,
+			"num_predict": maxOutputTokens,
+			"num_ctx":     ContextTokens(p.Name(), p.model),
+		},
 	}
 
-	// Parse the JSON response
-	var response Response
-	if err := json.Unmarshal([]byte(result.Response), &response); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	return &response, nil
+	return p.http.post(ctx, p.baseURL+"/api/generate", reqJSON, nil, stream)
 }
@@ -1,12 +1,12 @@
 package ai
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/dublyo/dockerizer/internal/scanner"
@@ -17,7 +17,7 @@ type OpenAIProvider struct {
 	apiKey  string
 	model   string
 	baseURL string
-	client  *http.Client
+	http    *transport
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
@@ -29,9 +29,7 @@ func NewOpenAIProvider(apiKey, model string) *OpenAIProvider {
 		apiKey:  apiKey,
 		model:   model,
 		baseURL: "https://api.openai.com/v1",
-		client: &http.Client{
-			Timeout: 120 * time.Second,
-		},
+		http:    newTransport("openai", "API", 120*time.Second),
 	}
 }
 
@@ -40,73 +38,257 @@ func (p *OpenAIProvider) Name() string {
 	return "openai"
 }
 
+// SetHTTPConfig configures the timeout, retries and circuit breaker of the
+// provider's requests
+func (p *OpenAIProvider) SetHTTPConfig(cfg HTTPConfig) {
+	p.http.configure(cfg)
+}
+
+// Metrics returns the request metrics of the provider
+func (p *OpenAIProvider) Metrics() []Metrics {
+	return []Metrics{p.http.snapshot()}
+}
+
 // IsAvailable checks if the provider is configured
 func (p *OpenAIProvider) IsAvailable() bool {
 	return p.apiKey != ""
 }
 
+// openaiMessage is a chat message: a prompt, an answer with tool calls or
+// the result of one call
+type openaiMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content,omitempty"`
+	ToolCalls  []openaiToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// openaiToolCall is a function call of the model
+type openaiToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// openaiReply is the message and token usage of one response
+type openaiReply struct {
+	Message openaiMessage
+	Usage   struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	}
+}
+
 // Generate creates Docker configuration using OpenAI
 func (p *OpenAIProvider) Generate(ctx context.Context, scan *scanner.ScanResult, instructions string) (*Response, error) {
-	prompt := BuildPrompt(scan, instructions)
+	return p.generate(ctx, scan, instructions, nil)
+}
 
-	// Build request
-	reqBody := map[string]interface{}{
-		"model": p.model,
-		"messages": []map[string]string{
-			{"role": "system", "content": SystemPrompt},
-			{"role": "user", "content": prompt},
-		},
-		"max_tokens":      4096,
-		"temperature":     0.2,
-		"response_format": map[string]string{"type": "json_object"},
-	}
+// GenerateStream creates Docker configuration, reporting the response as it streams in
+func (p *OpenAIProvider) GenerateStream(ctx context.Context, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error) {
+	return p.generate(ctx, scan, instructions, fn)
+}
 
-	reqJSON, err := json.Marshal(reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// generate sends the tree and the manifests, answers the model's file_read
+// calls and parses its final answer, asking once more when it doesn't parse.
+// A nil fn doesn't stream.
+func (p *OpenAIProvider) generate(ctx context.Context, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error) {
+	prompt, report := BuildToolPrompt(scan, instructions, PromptBudget(p.Name(), p.model))
+	files := newFileReader(scan, &report)
+	messages := []openaiMessage{
+		{Role: "system", Content: toolSystemPrompt},
+		{Role: "user", Content: prompt},
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewReader(reqJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	var usage []Usage
+	retries := 0
+	for turn := 1; turn <= maxToolTurns+maxParseRetries; turn++ {
+		last := turn >= maxToolTurns
+		var reply *openaiReply
+		var err error
+		if fn != nil {
+			reply, err = p.stream(ctx, messages, last, fn)
+		} else {
+			reply, err = p.complete(ctx, messages, last)
+		}
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, NewUsage(p.Name(), p.model, reply.Usage.PromptTokens, reply.Usage.CompletionTokens))
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+		if len(reply.Message.ToolCalls) == 0 {
+			if reply.Message.Content == "" {
+				return nil, fmt.Errorf("no response from AI")
+			}
+			response, err := parseResponseText(reply.Message.Content, report, usage...)
+			if err == nil || retries == maxParseRetries {
+				return response, err
+			}
+			retries++
+			messages = append(messages,
+				openaiMessage{Role: "assistant", Content: reply.Message.Content},
+				openaiMessage{Role: "user", Content: retryPrompt(err)})
+			continue
+		}
+		messages = append(messages, openaiMessage{Role: "assistant", Content: reply.Message.Content, ToolCalls: reply.Message.ToolCalls})
+		for _, call := range reply.Message.ToolCalls {
+			result, err := files.call(call.Function.Name, []byte(call.Function.Arguments))
+			if err != nil {
+				result = "error: " + err.Error()
+			}
+			messages = append(messages, openaiMessage{Role: "tool", Content: result, ToolCallID: call.ID})
+		}
+	}
+	return nil, fmt.Errorf("no answer from AI after %d requests", maxToolTurns+maxParseRetries)
+}
 
-	resp, err := p.client.Do(req)
+// complete sends one request and decodes the whole response
+func (p *OpenAIProvider) complete(ctx context.Context, messages []openaiMessage, last bool) (*openaiReply, error) {
+	resp, err := p.send(ctx, messages, last, false)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	// Parse response
 	var result struct {
 		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
+			Message openaiMessage `json:"message"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
-
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-
 	if len(result.Choices) == 0 {
 		return nil, fmt.Errorf("no response from AI")
 	}
 
-	// Parse the JSON response
-	var response Response
-	if err := json.Unmarshal([]byte(result.Choices[0].Message.Content), &response); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	reply := &openaiReply{Message: result.Choices[0].Message}
+	reply.Usage.PromptTokens, reply.Usage.CompletionTokens = result.Usage.PromptTokens, result.Usage.CompletionTokens
+	return reply, nil
+}
+
+// stream sends one streaming request, reporting the answer text to fn, and
+// assembles the message from its chunks
+func (p *OpenAIProvider) stream(ctx context.Context, messages []openaiMessage, last bool, fn StreamFunc) (*openaiReply, error) {
+	resp, err := p.send(ctx, messages, last, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	reply := &openaiReply{Message: openaiMessage{Role: "assistant"}}
+	dec := newSectionDecoder(p.Name(), fn)
+	err = readSSE(ctx, resp.Body, func(data string) error {
+		if data == "[DONE]" {
+			return io.EOF
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content   string `json:"content"`
+					ToolCalls []struct {
+						Index    int    `json:"index"`
+						ID       string `json:"id"`
+						Function struct {
+							Name      string `json:"name"`
+							Arguments string `json:"arguments"`
+						} `json:"function"`
+					} `json:"tool_calls"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+		if json.Unmarshal([]byte(data), &chunk) != nil {
+			return nil
+		}
+		if len(chunk.Choices) > 0 {
+			delta := chunk.Choices[0].Delta
+			dec.Write(delta.Content)
+			// A call streams as its id and name, then pieces of its arguments
+			for _, tc := range delta.ToolCalls {
+				for tc.Index >= len(reply.Message.ToolCalls) {
+					reply.Message.ToolCalls = append(reply.Message.ToolCalls, openaiToolCall{Type: "function"})
+				}
+				call := &reply.Message.ToolCalls[tc.Index]
+				if tc.ID != "" {
+					call.ID = tc.ID
+				}
+				call.Function.Name += tc.Function.Name
+				call.Function.Arguments += tc.Function.Arguments
+			}
+		}
+		// The final chunk, with no choices, carries the usage
+		if chunk.Usage != nil {
+			reply.Usage.PromptTokens, reply.Usage.CompletionTokens = chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stream failed: %w", err)
+	}
+
+	reply.Message.Content = dec.Text()
+	return reply, nil
+}
+
+// send posts a chat completion request offering file_read, which the last
+// request may not call, and checks the response status
+func (p *OpenAIProvider) send(ctx context.Context, messages []openaiMessage, last, stream bool) (*http.Response, error) {
+	// Build request
+	reqBody := map[string]interface{}{
+		"model":           p.model,
+		"messages":        messages,
+		"max_tokens":      maxOutputTokens,
+		"temperature":     0.2,
+		"response_format": responseFormat(p.model),
+		"tools": []map[string]interface{}{
+			{"type": "function", "function": map[string]interface{}{
+				"name": fileReadTool, "description": fileReadDescription, "parameters": fileReadSchema,
+			}},
+		},
+	}
+	if last {
+		reqBody["tool_choice"] = "none"
+	}
+	if stream {
+		reqBody["stream"] = true
+		reqBody["stream_options"] = map[string]bool{"include_usage": true}
+	}
+
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	return &response, nil
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+p.apiKey)
+	return p.http.post(ctx, p.baseURL+"/chat/completions", reqJSON, header, stream)
+}
+
+// responseFormat constrains answers to the Response schema on models with
+// structured outputs, and to a JSON object on older ones
+func responseFormat(model string) map[string]interface{} {
+	model = strings.ToLower(model)
+	if strings.HasPrefix(model, "gpt-3.5") || model == "gpt-4" || strings.HasPrefix(model, "gpt-4-") ||
+		model == "gpt-4o-2024-05-13" || strings.HasPrefix(model, "o1-mini") || strings.HasPrefix(model, "o1-preview") {
+		return map[string]interface{}{"type": "json_object"}
+	}
+	return map[string]interface{}{
+		"type": "json_schema",
+		"json_schema": map[string]interface{}{
+			"name":   responseTool,
+			"strict": true,
+			"schema": responseSchema,
+		},
+	}
 }
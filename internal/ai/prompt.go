@@ -0,0 +1,311 @@
+package ai
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+const (
+	// maxOutputTokens is what each provider asks the model to generate at most
+	maxOutputTokens = 4096
+	// maxPromptTokens caps the prompt even for large context windows, to bound cost
+	maxPromptTokens = 60000
+	// defaultContextTokens is assumed for unknown models
+	defaultContextTokens = 16384
+	// minFileTokens is the smallest useful piece of a truncated key file
+	minFileTokens = 100
+)
+
+// PromptReport describes what was cut from a prompt to fit its token budget
+type PromptReport struct {
+	Budget         int      // Prompt token budget
+	Tokens         int      // Estimated tokens in the built prompt
+	TreeSummarized bool     // The file tree was summarized per directory
+	Truncated      []string // Key files cut down to their head and tail
+	Omitted        []string // Key files left out
+	Read           []string // Files the model read with the file_read tool
+	Redacted       []string // Files read with secrets redacted
+}
+
+// Warnings describes the cuts for the user; empty when the prompt is complete
+func (r PromptReport) Warnings() []string {
+	var warnings []string
+	if r.TreeSummarized {
+		warnings = append(warnings, "prompt: file tree summarized per directory to fit the model context")
+	}
+	if len(r.Truncated) > 0 {
+		warnings = append(warnings, "prompt: truncated (head and tail kept): "+strings.Join(r.Truncated, ", "))
+	}
+	if len(r.Omitted) > 0 {
+		warnings = append(warnings, "prompt: omitted to fit the model context: "+strings.Join(r.Omitted, ", "))
+	}
+	if len(r.Redacted) > 0 {
+		warnings = append(warnings, "prompt: secrets redacted from files the model read: "+strings.Join(r.Redacted, ", "))
+	}
+	return warnings
+}
+
+// EstimateTokens roughly estimates the tokens in s. Code and JSON tokenize at
+// about 3.5 characters per token; erring high keeps prompts inside the window.
+func EstimateTokens(s string) int {
+	return (len(s)*2 + 6) / 7
+}
+
+// ContextTokens returns the context window of a provider's model.
+// DOCKERIZER_AI_CONTEXT_TOKENS overrides it (e.g. for a large Ollama num_ctx).
+func ContextTokens(provider, model string) int {
+	if v, err := strconv.Atoi(os.Getenv("DOCKERIZER_AI_CONTEXT_TOKENS")); err == nil && v > 0 {
+		return v
+	}
+
+	model = strings.ToLower(model)
+	switch provider {
+	case "anthropic":
+		return 200000
+	case "openai":
+		switch {
+		case strings.HasPrefix(model, "gpt-3.5"):
+			return 16385
+		case model == "gpt-4" || strings.HasPrefix(model, "gpt-4-0"):
+			return 8192
+		default: // gpt-4o, gpt-4-turbo, gpt-4.1, o-series
+			return 128000
+		}
+	case "ollama":
+		return ollamaContextTokens
+	}
+	return defaultContextTokens
+}
+
+// PromptBudget returns the tokens available for the user prompt of a model
+func PromptBudget(provider, model string) int {
+	budget := ContextTokens(provider, model) - maxOutputTokens - EstimateTokens(SystemPrompt)
+	if budget > maxPromptTokens {
+		budget = maxPromptTokens
+	}
+	if budget < 1000 {
+		budget = 1000
+	}
+	return budget
+}
+
+// BuildPromptWithBudget constructs the prompt for AI generation within budget
+// tokens. Manifests come before version files, configuration, existing Docker
+// files and lockfiles; files that don't fit are truncated to their head and
+// tail or left out, and a large file tree is summarized per directory.
+func BuildPromptWithBudget(scan *scanner.ScanResult, instructions string, budget int) (string, PromptReport) {
+	return buildPrompt(scan, scan.KeyFiles, nil, instructions, budget)
+}
+
+// BuildToolPrompt constructs the prompt for providers that let the model read
+// files with the file_read tool: the file tree and the manifests, with the
+// other key files only named
+func BuildToolPrompt(scan *scanner.ScanResult, instructions string, budget int) (string, PromptReport) {
+	var manifests []scanner.KeyFile
+	var more []string
+	for _, kf := range scan.KeyFiles {
+		if keyFilePriority(kf.Path) == 0 {
+			manifests = append(manifests, kf)
+		} else {
+			more = append(more, kf.Path)
+		}
+	}
+	sort.Strings(more)
+	return buildPrompt(scan, manifests, more, instructions, budget)
+}
+
+// buildPrompt builds a prompt with the given key files; more names files the
+// model can read itself
+func buildPrompt(scan *scanner.ScanResult, keyFiles []scanner.KeyFile, more []string, instructions string, budget int) (string, PromptReport) {
+	report := PromptReport{Budget: budget}
+
+	var b strings.Builder
+	b.WriteString("Generate Docker configuration for this project:\n\n")
+
+	var instructionsPart string
+	if instructions != "" {
+		instructionsPart = fmt.Sprintf("## Additional Instructions\n%s\n", instructions)
+	}
+	remaining := budget - EstimateTokens(b.String()) - EstimateTokens(instructionsPart)
+
+	// Add file tree, summarized when it would take over a quarter of the budget
+	tree := strings.Join(scan.FileTree.Files, "\n")
+	if EstimateTokens(tree) > budget/4 {
+		tree = summarizeTree(scan.FileTree.Files, budget/4)
+		report.TreeSummarized = true
+	}
+	treePart := "## Project Structure\n```\n" + tree + "\n```\n\n"
+	b.WriteString(treePart)
+	remaining -= EstimateTokens(treePart)
+
+	// Add key files content, most important first
+	b.WriteString("## Key Files\n")
+	keyFiles = append([]scanner.KeyFile(nil), keyFiles...)
+	sort.SliceStable(keyFiles, func(i, j int) bool {
+		return keyFilePriority(keyFiles[i].Path) < keyFilePriority(keyFiles[j].Path)
+	})
+	for _, kf := range keyFiles {
+		part := fmt.Sprintf("### %s\n```\n%s\n```\n\n", kf.Path, kf.Content)
+		tokens := EstimateTokens(part)
+
+		// No single file may take more than a third of the budget
+		limit := remaining
+		if limit > budget/3 {
+			limit = budget / 3
+		}
+		if tokens > limit {
+			overhead := EstimateTokens(fmt.Sprintf("### %s\n```\n\n```\n\n", kf.Path)) + 20
+			if limit-overhead < minFileTokens {
+				report.Omitted = append(report.Omitted, kf.Path)
+				continue
+			}
+			content := truncateMiddle(kf.Content, (limit-overhead)*7/2)
+			part = fmt.Sprintf("### %s\n```\n%s\n```\n\n", kf.Path, content)
+			tokens = EstimateTokens(part)
+			report.Truncated = append(report.Truncated, kf.Path)
+		}
+		b.WriteString(part)
+		remaining -= tokens
+	}
+
+	if len(more) > 0 {
+		b.WriteString("## More Files\nRead these, or any other file of the project structure, with " + fileReadTool + " when you need them:\n")
+		for _, file := range more {
+			b.WriteString("- " + file + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	// Add user instructions if provided
+	b.WriteString(instructionsPart)
+
+	prompt := b.String()
+	report.Tokens = EstimateTokens(prompt)
+	return prompt, report
+}
+
+// keyFilePriority orders key files for the prompt; lower comes first
+func keyFilePriority(file string) int {
+	base := path.Base(file)
+	switch {
+	case strings.HasSuffix(base, ".lock") || strings.HasSuffix(base, "-lock.json") ||
+		strings.HasSuffix(base, "-lock.yaml") || base == "go.sum":
+		return 4
+	case strings.HasPrefix(base, "Dockerfile") || strings.HasPrefix(base, "docker-compose"):
+		return 3
+	case strings.HasPrefix(base, "."):
+		if strings.HasPrefix(base, ".dockerizer") {
+			return 2
+		}
+		return 1 // .nvmrc, .python-version, .tool-versions, ...
+	case base == "Procfile":
+		return 2
+	}
+	return 0 // package.json, go.mod, pyproject.toml, pom.xml, ...
+}
+
+// truncateMiddle keeps about maxChars of content: two thirds from the head and
+// one third from the tail, cut at line boundaries
+func truncateMiddle(content string, maxChars int) string {
+	if len(content) <= maxChars {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	headChars, tailChars := maxChars*2/3, maxChars/3
+
+	head, size := 0, 0
+	for head < len(lines) && size+len(lines[head])+1 <= headChars {
+		size += len(lines[head]) + 1
+		head++
+	}
+	tail, size := len(lines), 0
+	for tail > head && size+len(lines[tail-1])+1 <= tailChars {
+		size += len(lines[tail-1]) + 1
+		tail--
+	}
+
+	omitted := tail - head
+	if omitted <= 0 {
+		return content
+	}
+	var b strings.Builder
+	b.WriteString(strings.Join(lines[:head], "\n"))
+	fmt.Fprintf(&b, "\n... [%d lines omitted] ...\n", omitted)
+	b.WriteString(strings.Join(lines[tail:], "\n"))
+	return b.String()
+}
+
+// summarizeTree lists root files and, for each directory up to two levels
+// deep, its file count and most common extensions, within about maxTokens
+func summarizeTree(files []string, maxTokens int) string {
+	type dirStats struct {
+		count int
+		exts  map[string]int
+	}
+	var rootFiles []string
+	dirs := make(map[string]*dirStats)
+	for _, f := range files {
+		parts := strings.Split(f, "/")
+		if len(parts) == 1 {
+			rootFiles = append(rootFiles, f)
+			continue
+		}
+		for depth := 1; depth <= 2 && depth < len(parts); depth++ {
+			dir := strings.Join(parts[:depth], "/") + "/"
+			st := dirs[dir]
+			if st == nil {
+				st = &dirStats{exts: make(map[string]int)}
+				dirs[dir] = st
+			}
+			st.count++
+			if ext := path.Ext(f); ext != "" {
+				st.exts[ext]++
+			}
+		}
+	}
+
+	names := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		names = append(names, dir)
+	}
+	sort.Strings(names)
+
+	lines := append([]string(nil), rootFiles...)
+	for _, dir := range names {
+		st := dirs[dir]
+		exts := make([]string, 0, len(st.exts))
+		for ext := range st.exts {
+			exts = append(exts, ext)
+		}
+		sort.Slice(exts, func(i, j int) bool {
+			if st.exts[exts[i]] != st.exts[exts[j]] {
+				return st.exts[exts[i]] > st.exts[exts[j]]
+			}
+			return exts[i] < exts[j]
+		})
+		if len(exts) > 3 {
+			exts = exts[:3]
+		}
+		line := fmt.Sprintf("%s (%d files", dir, st.count)
+		for _, ext := range exts {
+			line += fmt.Sprintf(", %d %s", st.exts[ext], ext)
+		}
+		lines = append(lines, line+")")
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		if EstimateTokens(b.String()+line) > maxTokens {
+			fmt.Fprintf(&b, "... %d more entries", len(lines)-i)
+			break
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
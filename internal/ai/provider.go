@@ -23,6 +23,8 @@ type Response struct {
 	EnvExample    string   `json:"env_example"`
 	Explanation   string   `json:"explanation"`
 	Warnings      []string `json:"warnings"`
+	Patch         string   `json:"patch,omitempty"` // Unified diff of an existing Dockerfile, when asked for a fix
+	Usage         []Usage  `json:"-"`               // One entry per provider call behind the response
 }
 
 // Config for AI providers
@@ -32,20 +34,30 @@ type Config struct {
 	Model     string `json:"model"`
 	MaxTokens int    `json:"max_tokens"`
 	BaseURL   string `json:"base_url"` // For custom endpoints
+	// HTTP configures timeouts, retries and circuit breaking; zero uses DefaultHTTPConfig
+	HTTP HTTPConfig `json:"-"`
 }
 
 // NewProvider creates a new AI provider based on config
 func NewProvider(cfg Config) (Provider, error) {
+	var provider interface {
+		Provider
+		SetHTTPConfig(HTTPConfig)
+	}
 	switch cfg.Provider {
 	case "openai":
-		return NewOpenAIProvider(cfg.APIKey, cfg.Model), nil
+		provider = NewOpenAIProvider(cfg.APIKey, cfg.Model)
 	case "anthropic":
-		return NewAnthropicProvider(cfg.APIKey, cfg.Model), nil
+		provider = NewAnthropicProvider(cfg.APIKey, cfg.Model)
 	case "ollama":
-		return NewOllamaProvider(cfg.BaseURL, cfg.Model), nil
+		provider = NewOllamaProvider(cfg.BaseURL, cfg.Model)
 	default:
 		return nil, fmt.Errorf("unknown AI provider: %s", cfg.Provider)
 	}
+	if cfg.HTTP != (HTTPConfig{}) {
+		provider.SetHTTPConfig(cfg.HTTP)
+	}
+	return provider, nil
 }
 
 // SystemPrompt is the system prompt for AI generation
@@ -71,30 +83,12 @@ Output format: Respond with a JSON object containing:
 - env_example: The .env.example content (string)
 - explanation: Brief explanation of choices made (string)
 - warnings: Array of strings with any issues (e.g., ["warning1", "warning2"] or [] if none)
+- patch: Only when asked to fix an existing Dockerfile: a unified diff of it (string); otherwise ""
 
 IMPORTANT: Always respond with valid JSON only. No markdown. The warnings field MUST be an array.`
 
-// BuildPrompt constructs the prompt for AI generation
+// BuildPrompt constructs the prompt for AI generation within the default budget
 func BuildPrompt(scan *scanner.ScanResult, instructions string) string {
-	prompt := "Generate Docker configuration for this project:\n\n"
-
-	// Add file tree
-	prompt += "## Project Structure\n```\n"
-	for _, f := range scan.FileTree.Files {
-		prompt += f + "\n"
-	}
-	prompt += "```\n\n"
-
-	// Add key files content
-	prompt += "## Key Files\n"
-	for _, kf := range scan.KeyFiles {
-		prompt += fmt.Sprintf("### %s\n```\n%s\n```\n\n", kf.Path, kf.Content)
-	}
-
-	// Add user instructions if provided
-	if instructions != "" {
-		prompt += fmt.Sprintf("## Additional Instructions\n%s\n", instructions)
-	}
-
+	prompt, _ := BuildPromptWithBudget(scan, instructions, PromptBudget("", ""))
 	return prompt
 }
@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+)
+
+// responseTool is the tool Anthropic models answer with; its input is the response
+const responseTool = "docker_config"
+
+// responseToolDescription tells the model what docker_config is for
+const responseToolDescription = "Submit the generated Docker configuration. Call it once, after reading the files you need."
+
+// maxParseRetries is how often a provider is asked again after an answer
+// that doesn't parse
+const maxParseRetries = 1
+
+// responseSchema is the JSON schema of Response. It is strict enough for
+// OpenAI structured outputs: every property is required and no other is allowed.
+var responseSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"dockerfile":     map[string]string{"type": "string", "description": "The Dockerfile"},
+		"docker_compose": map[string]string{"type": "string", "description": "The docker-compose.yml"},
+		"dockerignore":   map[string]string{"type": "string", "description": "The .dockerignore"},
+		"env_example":    map[string]string{"type": "string", "description": "The .env.example"},
+		"explanation":    map[string]string{"type": "string", "description": "Brief explanation of the choices made"},
+		"warnings": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]string{"type": "string"},
+			"description": "Issues found, or an empty array",
+		},
+		"patch": map[string]string{"type": "string", "description": "Unified diff of the existing Dockerfile when asked to fix one; otherwise empty"},
+	},
+	"required":             []string{"dockerfile", "docker_compose", "dockerignore", "env_example", "explanation", "warnings", "patch"},
+	"additionalProperties": false,
+}
+
+// retryPrompt asks for an answer again after it failed to parse
+func retryPrompt(err error) string {
+	return fmt.Sprintf("That answer could not be used (%v). Answer again with the JSON object only: no markdown and no text around it.", err)
+}
+
+// extractResponse parses an answer into a Response. It takes the JSON object
+// alone, fenced in markdown or surrounded by text, and falls back to the
+// labelled code blocks of a free-text answer. Each file is cut out of any
+// markdown fence it arrived in, so one never carries the next.
+func extractResponse(text string) (*Response, error) {
+	text = strings.TrimSpace(strings.TrimPrefix(text, "\ufeff"))
+
+	response, err := decodeResponse(text)
+	if err != nil {
+		if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start >= 0 && end > start {
+			response, _ = decodeResponse(text[start : end+1])
+		}
+	}
+	if response == nil || response.Dockerfile == "" && response.Patch == "" {
+		if sections := sectionsResponse(text); sections != nil {
+			response = sections
+		}
+	}
+	if response == nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrAIResponseInvalid, err)
+	}
+
+	for _, field := range []*string{&response.Dockerfile, &response.DockerCompose, &response.Dockerignore, &response.EnvExample, &response.Patch} {
+		*field = unfence(*field)
+	}
+	if strings.TrimSpace(response.Dockerfile) == "" && strings.TrimSpace(response.Patch) == "" {
+		return nil, fmt.Errorf("%w: no Dockerfile in the answer", errors.ErrAIResponseInvalid)
+	}
+	return response, nil
+}
+
+// decodeResponse decodes the JSON object of an answer, taking a single
+// string for warnings too
+func decodeResponse(data string) (*Response, error) {
+	var raw struct {
+		Response
+		Warnings json.RawMessage `json:"warnings"`
+	}
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, err
+	}
+	response := raw.Response
+	var warning string
+	if json.Unmarshal(raw.Warnings, &response.Warnings) != nil && json.Unmarshal(raw.Warnings, &warning) == nil && warning != "" {
+		response.Warnings = []string{warning}
+	}
+	return &response, nil
+}
+
+// sectionsResponse takes the files of a free-text answer from its code
+// blocks, named by their language or the line before them; nil without a
+// Dockerfile or patch
+func sectionsResponse(text string) *Response {
+	response := &Response{}
+	var label, block string
+	var inBlock bool
+	for _, line := range strings.SplitAfter(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "```") {
+			if inBlock {
+				block += line
+			} else if trimmed != "" {
+				label = trimmed
+			}
+			continue
+		}
+		if !inBlock {
+			label = strings.ToLower(strings.TrimPrefix(trimmed, "```") + " " + label)
+			block, inBlock = "", true
+			continue
+		}
+		inBlock = false
+
+		var field *string
+		switch {
+		case strings.HasPrefix(label, "diff") || strings.HasPrefix(label, "patch"):
+			field = &response.Patch
+		case strings.Contains(label, "compose"):
+			field = &response.DockerCompose
+		case strings.Contains(label, "dockerignore"):
+			field = &response.Dockerignore
+		case strings.Contains(label, ".env") || strings.Contains(label, "env.example") || strings.HasPrefix(label, "env ") || strings.HasPrefix(label, "dotenv"):
+			field = &response.EnvExample
+		case strings.Contains(label, "dockerfile"):
+			field = &response.Dockerfile
+		case strings.HasPrefix(label, "yaml") || strings.HasPrefix(label, "yml"):
+			field = &response.DockerCompose
+		}
+		if field != nil && *field == "" {
+			*field = block
+		}
+		label = ""
+	}
+	if response.Dockerfile == "" && response.Patch == "" {
+		return nil
+	}
+	response.Warnings = []string{"the AI answered in free text; the files were taken from its code blocks"}
+	return response
+}
+
+// unfence returns a file without the markdown fence around it, ending at the
+// first fence line: no file dockerizer writes has one, so anything after it
+// belongs to another section
+func unfence(content string) string {
+	if trimmed := strings.TrimLeft(content, " \t\r\n"); strings.HasPrefix(trimmed, "```") {
+		_, content, _ = strings.Cut(trimmed, "\n")
+	}
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			return strings.Join(lines[:i], "")
+		}
+	}
+	return content
+}
@@ -0,0 +1,61 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+
+	dzerrors "github.com/dublyo/dockerizer/internal/errors"
+)
+
+func TestExtractResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		dockerfile string
+		compose    string
+		warnings   int
+	}{
+		{
+			name:       "json",
+			text:       `{"dockerfile":"FROM alpine\n","docker_compose":"services: {}\n","warnings":[]}`,
+			dockerfile: "FROM alpine\n",
+			compose:    "services: {}\n",
+		},
+		{
+			name:       "fenced json with text around it",
+			text:       "Here it is:\n```json\n{\"dockerfile\":\"FROM alpine\\n\",\"warnings\":\"pin the base image\"}\n```\nDone.",
+			dockerfile: "FROM alpine\n",
+			warnings:   1,
+		},
+		{
+			name:       "fenced fields",
+			text:       `{"dockerfile":"` + "```dockerfile\\nFROM alpine\\n```\\n\\n```yaml\\nservices: {}\\n```" + `","docker_compose":"` + "```yaml\\nservices: {}\\n```" + `"}`,
+			dockerfile: "FROM alpine\n",
+			compose:    "services: {}\n",
+		},
+		{
+			name:       "free text sections",
+			text:       "### Dockerfile\n```dockerfile\nFROM alpine\nCMD [\"app\"]\n```\n\n### docker-compose.yml\n```yaml\nservices:\n  app: {}\n```\n",
+			dockerfile: "FROM alpine\nCMD [\"app\"]\n",
+			compose:    "services:\n  app: {}\n",
+			warnings:   1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := extractResponse(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Dockerfile != tt.dockerfile || resp.DockerCompose != tt.compose || len(resp.Warnings) != tt.warnings {
+				t.Errorf("got dockerfile %q, compose %q, warnings %v", resp.Dockerfile, resp.DockerCompose, resp.Warnings)
+			}
+		})
+	}
+
+	for _, text := range []string{"I can't help with that.", `{"dockerfile":""}`} {
+		if _, err := extractResponse(text); !errors.Is(err, dzerrors.ErrAIResponseInvalid) {
+			t.Errorf("extractResponse(%q) error = %v", text, err)
+		}
+	}
+}
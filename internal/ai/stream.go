@@ -0,0 +1,237 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// StreamEvent is a piece of a response as it streams in
+type StreamEvent struct {
+	Provider string // Provider writing the response
+	Field    string // Response field being written (e.g. "dockerfile"); empty between fields
+	Text     string // Decoded text appended to Field
+}
+
+// StreamFunc receives streaming progress
+type StreamFunc func(StreamEvent)
+
+// StreamingProvider is a Provider that can report its response while generating it
+type StreamingProvider interface {
+	Provider
+	GenerateStream(ctx context.Context, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error)
+}
+
+// GenerateStream generates with streaming when the provider supports it and
+// falls back to a blocking Generate otherwise. A nil fn never streams.
+func GenerateStream(ctx context.Context, p Provider, scan *scanner.ScanResult, instructions string, fn StreamFunc) (*Response, error) {
+	if sp, ok := p.(StreamingProvider); ok && fn != nil {
+		return sp.GenerateStream(ctx, scan, instructions, fn)
+	}
+	return p.Generate(ctx, scan, instructions)
+}
+
+// parseResponseText parses the answer of a provider, warns about anything
+// cut from the prompt and records the usage of the calls
+func parseResponseText(text string, report PromptReport, usage ...Usage) (*Response, error) {
+	response, err := extractResponse(text)
+	if err != nil {
+		return nil, err
+	}
+	response.Warnings = append(response.Warnings, report.Warnings()...)
+	response.Usage = usage
+	return response, nil
+}
+
+// readSSE calls fn with the data of each server-sent event until the stream
+// ends, fn returns io.EOF, or the context is cancelled
+func readSSE(ctx context.Context, body io.Reader, fn func(data string) error) error {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, ok := strings.CutPrefix(sc.Text(), "data:")
+		if !ok {
+			continue
+		}
+		if err := fn(strings.TrimSpace(data)); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return sc.Err()
+}
+
+// sectionDecoder turns the raw text of a streaming JSON response into events
+// carrying the decoded value of each top-level string field
+type sectionDecoder struct {
+	provider string
+	fn       StreamFunc
+	raw      strings.Builder // Everything written, for the final parse
+
+	state     int
+	depth     int
+	expectKey bool
+	key       strings.Builder
+	field     string
+	unicode   []byte // Hex digits of a \u escape
+	surrogate rune   // Pending high surrogate of a UTF-16 pair
+}
+
+const (
+	decOutside = iota
+	decKey
+	decKeyEscape
+	decAfterKey
+	decBeforeValue
+	decValue
+	decValueEscape
+	decValueUnicode
+	decSkipString
+	decSkipEscape
+)
+
+func newSectionDecoder(provider string, fn StreamFunc) *sectionDecoder {
+	return &sectionDecoder{provider: provider, fn: fn}
+}
+
+// Write feeds the next chunk of raw response text
+func (d *sectionDecoder) Write(chunk string) {
+	d.raw.WriteString(chunk)
+
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 && d.fn != nil {
+			d.fn(StreamEvent{Provider: d.provider, Field: d.field, Text: text.String()})
+		}
+		text.Reset()
+	}
+
+	for i := 0; i < len(chunk); i++ {
+		c := chunk[i]
+		switch d.state {
+		case decOutside:
+			switch c {
+			case '{', '[':
+				d.depth++
+				d.expectKey = d.depth == 1 && c == '{'
+			case '}', ']':
+				d.depth--
+			case ',':
+				d.expectKey = d.depth == 1
+			case '"':
+				if d.depth == 1 && d.expectKey {
+					d.key.Reset()
+					d.state = decKey
+				} else {
+					d.state = decSkipString
+				}
+			}
+		case decKey:
+			switch c {
+			case '\\':
+				d.state = decKeyEscape
+			case '"':
+				d.expectKey = false
+				d.state = decAfterKey
+			default:
+				d.key.WriteByte(c)
+			}
+		case decKeyEscape:
+			d.key.WriteByte(c)
+			d.state = decKey
+		case decAfterKey:
+			if c == ':' {
+				d.state = decBeforeValue
+			}
+		case decBeforeValue:
+			switch c {
+			case ' ', '\t', '\n', '\r':
+			case '"':
+				d.field = d.key.String()
+				d.state = decValue
+			default:
+				// Not a string (e.g. the warnings array); scan it as structure
+				d.state = decOutside
+				i--
+			}
+		case decValue:
+			switch c {
+			case '\\':
+				d.state = decValueEscape
+			case '"':
+				flush()
+				d.field = ""
+				d.state = decOutside
+			default:
+				text.WriteByte(c)
+			}
+		case decValueEscape:
+			d.state = decValue
+			switch c {
+			case 'n':
+				text.WriteByte('\n')
+			case 't':
+				text.WriteByte('\t')
+			case 'r':
+				text.WriteByte('\r')
+			case 'b':
+				text.WriteByte('\b')
+			case 'f':
+				text.WriteByte('\f')
+			case 'u':
+				d.unicode = d.unicode[:0]
+				d.state = decValueUnicode
+			default:
+				text.WriteByte(c) // \" \\ \/
+			}
+		case decValueUnicode:
+			d.unicode = append(d.unicode, c)
+			if len(d.unicode) < 4 {
+				continue
+			}
+			d.state = decValue
+			n, err := strconv.ParseUint(string(d.unicode), 16, 16)
+			if err != nil {
+				continue
+			}
+			r := rune(n)
+			switch {
+			case utf16.IsSurrogate(r) && d.surrogate == 0:
+				d.surrogate = r
+			case d.surrogate != 0:
+				text.WriteRune(utf16.DecodeRune(d.surrogate, r))
+				d.surrogate = 0
+			default:
+				text.WriteRune(r)
+			}
+		case decSkipString:
+			switch c {
+			case '\\':
+				d.state = decSkipEscape
+			case '"':
+				d.state = decOutside
+			}
+		case decSkipEscape:
+			d.state = decSkipString
+		}
+	}
+	flush()
+}
+
+// Text returns the complete raw response
+func (d *sectionDecoder) Text() string {
+	return d.raw.String()
+}
@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// streamResponse has escaped quotes and backslashes, \u escapes with a
+// surrogate pair, raw multi-byte UTF-8, an escaped key and values that are
+// not strings
+const streamResponse = `{"dockerfile": "FROM node:20\nRUN echo \"hi\" \\ done\n# caf\u00e9 \ud83d\udc33 🐳",
+  "warnings": ["a \"quoted\" } warning", "b"],
+  "explanation":"x\/y\tz\r\b\f",
+  "nested": {"dockerfile": "not a field"},
+  "docker_\"compose\"": "services: {}\n",
+  "count": 2}`
+
+func TestSectionDecoder(t *testing.T) {
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(streamResponse), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]string)
+	for k, v := range decoded {
+		if s, ok := v.(string); ok {
+			want[k] = s
+		}
+	}
+
+	check := func(t *testing.T, chunks ...string) {
+		t.Helper()
+		got := make(map[string]string)
+		d := newSectionDecoder("test", func(e StreamEvent) {
+			if e.Provider != "test" || e.Field == "" {
+				t.Errorf("event %+v", e)
+			}
+			got[e.Field] += e.Text
+		})
+		for _, chunk := range chunks {
+			d.Write(chunk)
+		}
+		if d.Text() != streamResponse {
+			t.Errorf("Text() = %q, want the unsplit response", d.Text())
+		}
+		if len(got) != len(want) {
+			t.Errorf("fields = %q, want %q", got, want)
+		}
+		for field, text := range want {
+			if got[field] != text {
+				t.Errorf("%s = %q, want %q", field, got[field], text)
+			}
+		}
+	}
+
+	check(t, streamResponse)
+	for i := 1; i < len(streamResponse); i++ {
+		check(t, streamResponse[:i], streamResponse[i:])
+		if t.Failed() {
+			t.Fatalf("split at byte %d: %q | %q", i, streamResponse[:i], streamResponse[i:])
+		}
+	}
+
+	bytes := make([]string, len(streamResponse))
+	for i := 0; i < len(streamResponse); i++ {
+		bytes[i] = streamResponse[i : i+1]
+	}
+	check(t, bytes...)
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dublyo/dockerizer/internal/redact"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// fileReadTool is the tool the model reads project files with. It is named
+// after the agent's file_read tool and, like it, cannot leave the project.
+const fileReadTool = "file_read"
+
+// maxToolTurns bounds the requests of one generation; the last one has to answer
+const maxToolTurns = 6
+
+// fileReadDescription tells the model what file_read does
+const fileReadDescription = "Read a file of the project by its path relative to the project root, as listed in the project structure. Secrets in the content are redacted."
+
+// fileReadSchema is the JSON schema of the file_read arguments
+var fileReadSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"path": map[string]string{
+			"type":        "string",
+			"description": "File path relative to the project root, e.g. src/index.ts",
+		},
+	},
+	"required": []string{"path"},
+}
+
+// toolSystemPrompt is the system prompt of providers that offer file_read
+const toolSystemPrompt = SystemPrompt + `
+
+The prompt holds the project structure and its manifests only. Read the other
+files you need (entry points, configuration, existing Docker files) with the
+file_read tool before answering, then answer with the JSON object only.`
+
+// fileReader serves file_read calls from the scanned project, within what is
+// left of the prompt budget
+type fileReader struct {
+	scan      *scanner.ScanResult
+	report    *PromptReport
+	remaining int // Tokens left for file contents
+}
+
+func newFileReader(scan *scanner.ScanResult, report *PromptReport) *fileReader {
+	return &fileReader{scan: scan, report: report, remaining: report.Budget - report.Tokens}
+}
+
+// call runs a tool call of the model. Errors are for the model too: they are
+// sent back as the tool result.
+func (r *fileReader) call(name string, arguments []byte) (string, error) {
+	if name != fileReadTool {
+		return "", fmt.Errorf("unknown tool: %s", name)
+	}
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil || args.Path == "" {
+		return "", fmt.Errorf("path is required")
+	}
+
+	// Only files of the scanned tree, so ignored files stay private
+	file := path.Clean(strings.TrimPrefix(filepath.ToSlash(args.Path), "./"))
+	if !r.scan.HasFile(file) {
+		return "", fmt.Errorf("not a file of the project structure: %s", args.Path)
+	}
+	content, err := r.scan.ReadFile(filepath.FromSlash(file))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	text := string(content)
+	if !utf8.ValidString(text) || strings.ContainsRune(text, 0) {
+		return "", fmt.Errorf("binary file: %s", file)
+	}
+
+	if r.scan.Redacted {
+		var findings []redact.Finding
+		text, findings = redact.Secrets(text)
+		if len(findings) > 0 && !slices.Contains(r.report.Redacted, file) {
+			r.report.Redacted = append(r.report.Redacted, file)
+		}
+	}
+
+	// Like key files, no file may take more than a third of the budget
+	limit := min(r.remaining, r.report.Budget/3)
+	if limit < minFileTokens {
+		return "", fmt.Errorf("the prompt budget is used up; answer with the files read so far")
+	}
+	if EstimateTokens(text) > limit {
+		text = truncateMiddle(text, limit*7/2)
+		if !slices.Contains(r.report.Truncated, file) {
+			r.report.Truncated = append(r.report.Truncated, file)
+		}
+	}
+	r.remaining -= EstimateTokens(text)
+	if !slices.Contains(r.report.Read, file) {
+		r.report.Read = append(r.report.Read, file)
+	}
+	return text, nil
+}
@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+const toolAnswer = `{"dockerfile":"FROM node:20-alpine\n","docker_compose":"","dockerignore":"","env_example":"","explanation":"","warnings":[]}`
+
+func scanToolProject(t *testing.T) *scanner.ScanResult {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"name":"app","scripts":{"start":"node src/index.js"}}`,
+		"src/index.js": "const key = \"sk_live_abcdefghijklmnop1234\"\nrequire('http').createServer().listen(3000)\n",
+		"Procfile":     "web: node src/index.js\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scan, err := scanner.New().Scan(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return scan
+}
+
+func TestAnthropicToolCalls(t *testing.T) {
+	scan := scanToolProject(t)
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, req)
+		switch len(requests) {
+		case 1:
+			fmt.Fprint(w, `{"content":[{"type":"tool_use","id":"t1","name":"file_read","input":{"path":"src/index.js"}},
+				{"type":"tool_use","id":"t2","name":"file_read","input":{"path":"../etc/passwd"}}],
+				"usage":{"input_tokens":100,"output_tokens":10}}`)
+		case 2:
+			// An answer without a Dockerfile is sent back once
+			fmt.Fprint(w, `{"content":[{"type":"tool_use","id":"a1","name":"docker_config","input":{"dockerfile":""}}],"usage":{}}`)
+		default:
+			fmt.Fprintf(w, `{"content":[{"type":"tool_use","id":"a2","name":"docker_config","input":%s}],"usage":{"input_tokens":200,"output_tokens":50}}`, toolAnswer)
+		}
+	}))
+	defer server.Close()
+
+	p := NewAnthropicProvider("key", "")
+	p.baseURL = server.URL
+	resp, err := p.Generate(context.Background(), scan, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Dockerfile != "FROM node:20-alpine\n" || len(resp.Usage) != 3 {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	// The prompt has the manifests; the rest is read on demand
+	prompt, _ := json.Marshal(requests[0]["messages"])
+	if !strings.Contains(string(prompt), "package.json") || strings.Contains(string(prompt), "createServer") {
+		t.Errorf("first prompt should hold the manifests only: %s", prompt)
+	}
+	results, _ := json.Marshal(requests[1]["messages"].([]interface{})[2])
+	for _, want := range []string{`"tool_use_id":"t1"`, "createServer", "[REDACTED]", `"is_error":true`} {
+		if !strings.Contains(string(results), want) {
+			t.Errorf("tool results missing %s: %s", want, results)
+		}
+	}
+	if strings.Contains(string(results), "sk_live_") {
+		t.Errorf("secret sent to the provider: %s", results)
+	}
+	if !strings.Contains(strings.Join(resp.Warnings, "\n"), "secrets redacted from files the model read: src/index.js") {
+		t.Errorf("warnings = %v", resp.Warnings)
+	}
+	retry, _ := json.Marshal(requests[2]["messages"].([]interface{})[4])
+	if !strings.Contains(string(retry), `"tool_use_id":"a1"`) || !strings.Contains(string(retry), "no Dockerfile") {
+		t.Errorf("retry = %s", retry)
+	}
+}
+
+func TestOpenAIToolCallsStream(t *testing.T) {
+	scan := scanToolProject(t)
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, req)
+		if len(requests) == 1 {
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"c1\",\"function\":{\"name\":\"file_read\",\"arguments\":\"\"}}]}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"{\\\"path\\\":\\\"Procfile\\\"}\"}}]}}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		content, _ := json.Marshal(toolAnswer)
+		fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%s}}]}\n\n", content)
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":300,\"completion_tokens\":40}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("key", "")
+	p.baseURL = server.URL
+	var streamed strings.Builder
+	resp, err := p.GenerateStream(context.Background(), scan, "", func(e StreamEvent) {
+		if e.Field == "dockerfile" {
+			streamed.WriteString(e.Text)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed.String() != "FROM node:20-alpine\n" || resp.Usage[1].PromptTokens != 300 {
+		t.Fatalf("streamed %q, response %+v", streamed.String(), resp)
+	}
+
+	messages := requests[1]["messages"].([]interface{})
+	call, _ := json.Marshal(messages[2])
+	result, _ := json.Marshal(messages[3])
+	if !strings.Contains(string(call), `"id":"c1"`) || !strings.Contains(string(result), "web: node src/index.js") {
+		t.Errorf("call %s, result %s", call, result)
+	}
+}
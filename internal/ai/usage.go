@@ -0,0 +1,180 @@
+package ai
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Usage is the token usage and estimated cost of one AI provider call
+type Usage struct {
+	Time             time.Time `json:"time"`
+	Command          string    `json:"command,omitempty"` // CLI command that made the call
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	Priced           bool      `json:"priced"` // false when the model has no known price
+}
+
+// modelPrice is the USD price per million tokens for models matching prefix
+type modelPrice struct {
+	prefix string
+	input  float64
+	output float64
+}
+
+// modelPrices are list prices per million tokens; the longest matching prefix
+// wins. Local (Ollama) models are free. Opus prices changed within the 4.x
+// line, so each Opus 4 version is listed and newer ones stay unpriced until
+// added.
+var modelPrices = []modelPrice{
+	{"claude-opus-4-0", 15, 75},
+	{"claude-opus-4-2025", 15, 75}, // claude-opus-4-20250514
+	{"claude-opus-4-1", 15, 75},
+	{"claude-opus-4-5", 5, 25},
+	{"claude-sonnet-4", 3, 15},
+	{"claude-3-7-sonnet", 3, 15},
+	{"claude-3-5-sonnet", 3, 15},
+	{"claude-3-5-haiku", 0.8, 4},
+	{"claude-haiku-4", 1, 5},
+	{"claude-3-opus", 15, 75},
+	{"claude-3-haiku", 0.25, 1.25},
+	{"gpt-4o-mini", 0.15, 0.6},
+	{"gpt-4o", 2.5, 10},
+	{"gpt-4.1-nano", 0.1, 0.4},
+	{"gpt-4.1-mini", 0.4, 1.6},
+	{"gpt-4.1", 2, 8},
+	{"gpt-4-turbo", 10, 30},
+	{"gpt-4", 30, 60},
+	{"gpt-3.5-turbo", 0.5, 1.5},
+	{"o1-mini", 1.1, 4.4},
+	{"o1", 15, 60},
+	{"o3-mini", 1.1, 4.4},
+	{"o3", 2, 8},
+	{"o4-mini", 1.1, 4.4},
+}
+
+// NewUsage records a call's tokens and prices them from the model price table
+func NewUsage(provider, model string, promptTokens, completionTokens int) Usage {
+	u := Usage{
+		Time:             time.Now().UTC(),
+		Provider:         provider,
+		Model:            model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+	}
+	if provider == "ollama" {
+		u.Priced = true
+		return u
+	}
+
+	var best *modelPrice
+	for i, p := range modelPrices {
+		if strings.HasPrefix(model, p.prefix) && (best == nil || len(p.prefix) > len(best.prefix)) {
+			best = &modelPrices[i]
+		}
+	}
+	if best != nil {
+		u.CostUSD = (float64(promptTokens)*best.input + float64(completionTokens)*best.output) / 1e6
+		u.Priced = true
+	}
+	return u
+}
+
+// UsageLogPath is where CLI usage records are kept, one JSON object per line
+func UsageLogPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the usage log: %w", err)
+	}
+	return filepath.Join(home, ".dockerizer", "usage.jsonl"), nil
+}
+
+// RecordUsage appends usage records to the usage log
+func RecordUsage(records []Usage) error {
+	if len(records) == 0 {
+		return nil
+	}
+	path, err := UsageLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create usage directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open usage log: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, u := range records {
+		if err := enc.Encode(u); err != nil {
+			return fmt.Errorf("failed to write usage log: %w", err)
+		}
+	}
+	return nil
+}
+
+// LoadUsage reads the usage records made since the given time (zero for all).
+// A missing log means no usage yet.
+func LoadUsage(since time.Time) ([]Usage, error) {
+	path, err := UsageLogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open usage log: %w", err)
+	}
+	defer f.Close()
+
+	var records []Usage
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var u Usage
+		if json.Unmarshal(sc.Bytes(), &u) != nil {
+			continue // Skip corrupt lines rather than losing the whole report
+		}
+		if !u.Time.Before(since) {
+			records = append(records, u)
+		}
+	}
+	return records, sc.Err()
+}
+
+// SumUsage totals the tokens and cost of usage records
+func SumUsage(records []Usage) Usage {
+	total := Usage{Priced: true}
+	for _, u := range records {
+		total.PromptTokens += u.PromptTokens
+		total.CompletionTokens += u.CompletionTokens
+		total.CostUSD += u.CostUSD
+		total.Priced = total.Priced && u.Priced
+	}
+	return total
+}
+
+// String summarizes the usage, e.g. "anthropic/claude-sonnet-4: 1200 in + 800 out tokens, ~$0.0156"
+func (u Usage) String() string {
+	s := fmt.Sprintf("%d in + %d out tokens", u.PromptTokens, u.CompletionTokens)
+	if u.Priced {
+		s += fmt.Sprintf(", ~$%.4f", u.CostUSD)
+	} else {
+		s += ", cost unknown"
+	}
+	if u.Provider != "" {
+		s = u.Provider + "/" + u.Model + ": " + s
+	}
+	return s
+}
@@ -0,0 +1,27 @@
+package ai
+
+import "testing"
+
+func TestNewUsagePricing(t *testing.T) {
+	tests := []struct {
+		model  string
+		cost   float64 // For a million prompt and a million completion tokens
+		priced bool
+	}{
+		{"claude-opus-4-20250514", 90, true},
+		{"claude-opus-4-0", 90, true},
+		{"claude-opus-4-1-20250805", 90, true},
+		{"claude-opus-4-5-20251101", 30, true},
+		{"claude-opus-4-9", 0, false},
+		{"claude-sonnet-4-5-20250929", 18, true},
+		{"gpt-4o-mini", 0.75, true},
+		{"gpt-4o-2024-08-06", 12.5, true},
+		{"unknown-model", 0, false},
+	}
+	for _, tt := range tests {
+		u := NewUsage("anthropic", tt.model, 1e6, 1e6)
+		if u.Priced != tt.priced || u.CostUSD != tt.cost {
+			t.Errorf("NewUsage(%q) = $%v (priced %v), want $%v (priced %v)", tt.model, u.CostUSD, u.Priced, tt.cost, tt.priced)
+		}
+	}
+}
@@ -4,14 +4,45 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/dublyo/dockerizer/internal/agent"
 	"github.com/dublyo/dockerizer/internal/ai"
 	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/internal/tui"
 	"github.com/spf13/cobra"
 )
 
+// AgentOutput is the JSON output of an agent run
+type AgentOutput struct {
+	Success   bool           `json:"success"`
+	SessionID string         `json:"session_id,omitempty"`
+	Attempts  []AgentAttempt `json:"attempts"`
+	Files     []string       `json:"files,omitempty"`
+	Warnings  []string       `json:"warnings,omitempty"`
+	Usage     []ai.Usage     `json:"usage,omitempty"`
+	Duration  string         `json:"duration"`
+	Resume    string         `json:"resume,omitempty"` // Command that continues the session
+	Error     string         `json:"error,omitempty"`
+}
+
+// AgentAttempt is one generate, build and test attempt of an agent run
+type AgentAttempt struct {
+	Number   int    `json:"number"`
+	Success  bool   `json:"success"`
+	Patched  bool   `json:"patched,omitempty"` // A deterministic fix, not a generation
+	Class    string `json:"class,omitempty"`   // Recognized failure class
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// CleanupOutput is the JSON output of agent cleanup
+type CleanupOutput struct {
+	DryRun  bool     `json:"dry_run,omitempty"`
+	Removed []string `json:"removed"`
+}
+
 var agentCmd = &cobra.Command{
 	Use:   "agent [path]",
 	Short: "Run in agent mode for iterative Docker configuration",
@@ -23,81 +54,218 @@ Docker image builds and runs successfully.
 Examples:
   dockerizer agent ./my-project
   dockerizer agent --provider anthropic ./my-project
-  dockerizer agent --max-attempts 10 ./my-project`,
+  dockerizer agent --max-attempts 10 ./my-project
+  dockerizer agent --provider anthropic,openai ./my-project
+  dockerizer agent --provider anthropic,openai --ab ./my-project
+  dockerizer agent --resume session-1760000000000000000 ./my-project
+  dockerizer agent --runtime podman --memory 1g ./my-project
+  dockerizer agent --docker-host ssh://ci@sandbox ./my-project
+  dockerizer agent --docker-context staging ./my-project
+
+Each run is saved to .dockerizer/sessions/<id>.json in the project after every
+attempt. --resume continues an interrupted or failed session with its earlier
+attempts and errors, using the session's providers unless --provider is given.
+
+Test containers run on a dedicated bridge network (dockerizer-agent) with
+memory, CPU and process limits and no-new-privileges. They build and run
+with docker, podman or nerdctl: --runtime picks one, and by default the
+first installed whose daemon answers is used. Rootless runtimes skip the
+limits whose cgroup controller systemd does not delegate to the user.
+
+The daemon is the local one unless DOCKER_HOST, DOCKER_CONTEXT,
+--docker-host or --docker-context points elsewhere, such as a remote
+machine or a docker:dind service in CI (with DOCKER_TLS_VERIFY and
+DOCKER_CERT_PATH for its TLS port). It is checked before the first
+attempt, and a run stops if it cannot be reached.
+
+On a terminal, progress is shown as a live list of steps per attempt above
+the scrolling build output; --no-tui prints plain progress lines instead.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runAgent,
 }
 
+var agentCleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove containers and images left behind by agent runs",
+	Long: `Remove the dockerize-test-* containers and the dockerize-test and
+dockerize-build images that interrupted or crashed agent runs leave behind.
+
+Examples:
+  dockerizer agent cleanup
+  dockerizer agent cleanup --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runAgentCleanup,
+}
+
 func init() {
-	agentCmd.Flags().String("provider", "openai", "AI provider (openai, anthropic, ollama)")
-	agentCmd.Flags().String("model", "", "Model to use (default depends on provider)")
+	agentCleanupCmd.Flags().Bool("dry-run", false, "List leftovers without removing them")
+	agentCmd.AddCommand(agentCleanupCmd)
+
+	agentCmd.Flags().String("provider", "openai", "AI provider (openai, anthropic, ollama); comma-separate for failover")
+	agentCmd.Flags().String("model", "", "Model to use with a single provider (default depends on provider)")
 	agentCmd.Flags().Int("max-attempts", 5, "Maximum fix attempts")
 	agentCmd.Flags().String("instructions", "", "Additional instructions for the AI")
+	agentCmd.Flags().Bool("ab", false, "Ask two providers and keep the better result (requires --provider a,b)")
+	agentCmd.Flags().String("resume", "", "Resume a saved agent session by ID")
+	agentCmd.PersistentFlags().String("runtime", "auto", "Container runtime: docker, podman, nerdctl or auto (the first installed and running)")
+	agentCmd.PersistentFlags().String("docker-host", "", "Build and test on a remote daemon (e.g. ssh://user@host); default: DOCKER_HOST")
+	agentCmd.PersistentFlags().String("docker-context", "", "Build and test on the daemon of a docker context (podman: connection); default: DOCKER_CONTEXT")
+	agentCmd.Flags().String("memory", "512m", "Memory limit for test containers")
+	agentCmd.Flags().String("cpus", "1", "CPU limit for test containers")
+	agentCmd.Flags().Int("pids-limit", 256, "Process limit for test containers")
+	agentCmd.Flags().Bool("isolate-network", false, "Block outside network access for test containers")
+	agentCmd.Flags().Bool("no-tui", false, "Print plain progress lines instead of the terminal UI")
 
 	rootCmd.AddCommand(agentCmd)
 }
 
-func runAgent(cmd *cobra.Command, args []string) error {
+func runAgent(cmd *cobra.Command, args []string) (err error) {
 	path := "."
 	if len(args) > 0 {
 		path = args[0]
 	}
+	output := AgentOutput{Attempts: []AgentAttempt{}}
+	if jsonOut {
+		// Failures before the result is printed still answer in JSON
+		defer func() {
+			if err != nil && output.Duration == "" {
+				output.Error = err.Error()
+				output.Duration = "0s"
+				_ = printJSON(output)
+			}
+		}()
+	}
 
 	providerName, _ := cmd.Flags().GetString("provider")
 	model, _ := cmd.Flags().GetString("model")
 	maxAttempts, _ := cmd.Flags().GetInt("max-attempts")
 	instructions, _ := cmd.Flags().GetString("instructions")
+	abMode, _ := cmd.Flags().GetBool("ab")
+	resumeID, _ := cmd.Flags().GetString("resume")
+	noTUI, _ := cmd.Flags().GetBool("no-tui")
+	if err := requireNetwork("the agent"); err != nil {
+		return err
+	}
+
+	// Load the session to resume; it remembers which providers it used
+	var session *agent.Session
+	if resumeID != "" {
+		session, err = agent.LoadSession(path, resumeID)
+		if err != nil {
+			return err
+		}
+		if session.Status == agent.SessionSucceeded {
+			return fmt.Errorf("session %s already succeeded", session.ID)
+		}
+		if !cmd.Flags().Changed("provider") && session.Provider != "" {
+			if a, b, ok := strings.Cut(session.Provider, "|"); ok {
+				providerName, abMode = a+","+b, true
+			} else {
+				providerName = strings.ReplaceAll(session.Provider, ">", ",")
+			}
+		}
+		printInfo("Resuming %s after %d attempt(s) (%s)", session.ID, len(session.Attempts), session.Status)
+	}
 
-	// Get API key from environment
-	var apiKey string
-	switch providerName {
-	case "openai":
-		apiKey = os.Getenv("OPENAI_API_KEY")
-	case "anthropic":
-		apiKey = os.Getenv("ANTHROPIC_API_KEY")
-	case "ollama":
-		// No API key needed for Ollama
+	// Create AI providers (comma-separated names form a failover chain)
+	names := strings.Split(providerName, ",")
+	if len(names) > 1 && model != "" {
+		return fmt.Errorf("--model applies to a single provider; leave it out to use the default model of each provider in %s", providerName)
 	}
 
-	if apiKey == "" && providerName != "ollama" {
-		printError("API key not found. Set %s_API_KEY environment variable", providerName)
-		return fmt.Errorf("missing API key")
+	aiConfig := projectConfig(path).AI
+	var providers []ai.Provider
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+
+		// Get API key from the environment, the OS secret store or the
+		// config; Ollama needs none
+		apiKey, _ := lookupAPIKey(name, aiConfig)
+		if apiKey == "" && name != "ollama" {
+			printError("API key not found. Set %s_API_KEY or run: dockerizer auth login %s", strings.ToUpper(name), name)
+			return fmt.Errorf("missing API key")
+		}
+
+		p, err := ai.NewProvider(ai.Config{
+			Provider: name,
+			APIKey:   apiKey,
+			Model:    model,
+			HTTP:     aiHTTPConfig(aiConfig),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create AI provider: %w", err)
+		}
+		providers = append(providers, p)
 	}
 
-	// Create AI provider
-	aiProvider, err := ai.NewProvider(ai.Config{
-		Provider: providerName,
-		APIKey:   apiKey,
-		Model:    model,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create AI provider: %w", err)
+	var aiProvider ai.Provider
+	switch {
+	case len(providers) == 1:
+		aiProvider = providers[0]
+	case abMode:
+		if len(providers) != 2 {
+			return fmt.Errorf("--ab requires exactly two providers, got %d", len(providers))
+		}
+		aiProvider = ai.NewABProvider(providers[0], providers[1], validationScore)
+	default:
+		aiProvider = ai.NewChainProvider(providers...)
 	}
 
 	if !aiProvider.IsAvailable() {
 		return fmt.Errorf("AI provider %s is not available", providerName)
 	}
 
+	sandbox, err := detectSandbox(cmd)
+	if err != nil {
+		return err
+	}
+	if dropped := sandbox.DroppedLimits(); len(dropped) > 0 {
+		printInfo("⚠ Rootless %s cannot apply %s here (cgroup controllers not delegated); test containers run without them", sandbox.Runtime, strings.Join(dropped, ", "))
+	}
+
 	// Scan the repository first
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
+	// Ctrl+C cancels the agent, including an in-flight AI generation
+	ctx, stop := interruptible(ctx)
+	defer stop()
 
 	printInfo("Scanning %s...", path)
-	scan, err := scanner.New().Scan(ctx, path)
+	scan, err := scanner.New(scanner.WithRedaction(!noRedact)).Scan(ctx, path)
 	if err != nil {
 		return fmt.Errorf("scan failed: %w", err)
 	}
 
+	warnRedactions(scan)
+
 	// Create and run agent
-	ag := agent.New(agent.AgentConfig{
+	cfg := agent.AgentConfig{
 		AIProvider:  aiProvider,
 		MaxAttempts: maxAttempts,
 		WorkDir:     path,
 		Verbose:     verbose,
-	})
+		Stream:      newStreamFunc(),
+		Session:     session,
+		Sandbox:     sandbox,
+		NoRedact:    noRedact,
+	}
+	var pane *tui.Pane
+	if !noTUI && !quiet && !jsonOut && tui.Available() {
+		pane = tui.NewPane(os.Stdout, "Agent: "+path, agentLogLines)
+		cfg.Stream = newPaneStreamFunc(pane)
+		cfg.Output = pane
+	}
+	ag := agent.New(cfg)
+	resumeHint := fmt.Sprintf("Resume with: dockerizer agent --resume %s %s", ag.Session().ID, path)
 
 	// Monitor events in background
+	eventsDone := make(chan struct{})
 	go func() {
+		defer close(eventsDone)
+		if pane != nil {
+			showAgentEvents(pane, ag.Events())
+			return
+		}
 		for event := range ag.Events() {
 			switch event.Type {
 			case agent.EventStart:
@@ -118,16 +286,76 @@ func runAgent(cmd *cobra.Command, args []string) error {
 				printError(event.Message)
 			case agent.EventComplete:
 				printInfo("Agent completed")
+			case agent.EventCleanup:
+				printVerbose(event.Message)
 			}
 		}
 	}()
 
 	// Run agent
+	start := time.Now()
 	result, err := ag.Run(ctx, scan, instructions)
+	<-eventsDone
+	runErr := err
+	if ctx.Err() == context.Canceled {
+		runErr = ctx.Err()
+	} else if err == nil && !result.Success {
+		runErr = fmt.Errorf("failed after %d attempts", len(result.Attempts))
+	}
+	recordRun("agent", path, start, nil, result != nil && result.Success, runErr)
+	if pane != nil {
+		if result != nil && !result.Success && len(result.Attempts) > 0 {
+			pane.Finish(tui.StepFailed, result.Attempts[len(result.Attempts)-1].Error)
+		}
+		pane.Close()
+	}
+	if result != nil {
+		recordUsage("agent", result.Usage)
+	}
+	reportRequests(aiProvider)
+	output.SessionID = ag.Session().ID
 	if err != nil {
+		if ctx.Err() == context.Canceled {
+			printInfo("%s", resumeHint)
+			output.Resume = strings.TrimPrefix(resumeHint, "Resume with: ")
+			return fmt.Errorf("agent cancelled")
+		}
 		return fmt.Errorf("agent failed: %w", err)
 	}
 
+	if jsonOut {
+		output.Success = result.Success
+		output.Usage = result.Usage
+		output.Duration = time.Since(start).Round(time.Millisecond).String()
+		for _, a := range result.Attempts {
+			output.Attempts = append(output.Attempts, AgentAttempt{
+				Number:   a.Number,
+				Success:  a.Success,
+				Patched:  a.Patched,
+				Class:    a.Class,
+				Error:    a.Error,
+				Duration: a.EndTime.Sub(a.StartTime).Round(time.Millisecond).String(),
+			})
+		}
+		if final := result.FinalOutput; result.Success && final != nil {
+			for _, f := range []struct{ name, content string }{
+				{"Dockerfile", final.Dockerfile},
+				{"docker-compose.yml", final.DockerCompose},
+				{".dockerignore", final.Dockerignore},
+				{".env.example", final.EnvExample},
+			} {
+				if f.content != "" {
+					output.Files = append(output.Files, f.name)
+				}
+			}
+			output.Warnings = final.Warnings
+		}
+		if !result.Success {
+			output.Resume = strings.TrimPrefix(resumeHint, "Resume with: ")
+		}
+		return printJSON(output)
+	}
+
 	// Print results
 	if result.Success {
 		printSuccess("Docker configuration generated successfully after %d attempt(s)", len(result.Attempts))
@@ -137,6 +365,9 @@ func runAgent(cmd *cobra.Command, args []string) error {
 		printInfo("  - docker-compose.yml")
 		printInfo("  - .dockerignore")
 		printInfo("  - .env.example")
+		for _, w := range result.FinalOutput.Warnings {
+			printInfo("⚠ %s", w)
+		}
 	} else {
 		printError("Agent failed after %d attempts", len(result.Attempts))
 		for i, attempt := range result.Attempts {
@@ -144,7 +375,116 @@ func runAgent(cmd *cobra.Command, args []string) error {
 				printError("  Attempt %d: %s", i+1, attempt.Error)
 			}
 		}
+		printInfo("%s", resumeHint)
+	}
+	if len(result.Usage) > 0 {
+		printInfo("AI usage: %d call(s), %s", len(result.Usage), ai.SumUsage(result.Usage))
 	}
 
 	return nil
 }
+
+// agentLogLines is the height of the agent pane's log window
+const agentLogLines = 10
+
+// showAgentEvents drives the progress pane from the agent's events: a note
+// per attempt, a step per stage, and the failure that ends an attempt
+func showAgentEvents(pane *tui.Pane, events <-chan agent.AgentEvent) {
+	for event := range events {
+		switch event.Type {
+		case agent.EventAnalyzing:
+			pane.Note(event.Message)
+		case agent.EventGenerating:
+			pane.Step("Generating Docker configuration")
+		case agent.EventBuilding:
+			pane.Step("Building Docker image")
+		case agent.EventTesting:
+			pane.Step("Testing container")
+		case agent.EventFixing:
+			detail := event.Message
+			switch data := event.Data.(type) {
+			case string:
+				detail += "\n" + data
+			case []string:
+				detail += "\n" + strings.Join(data, "\n")
+			}
+			pane.Finish(tui.StepFailed, detail)
+		case agent.EventSuccess:
+			pane.Finish(tui.StepDone, "")
+		case agent.EventError:
+			fmt.Fprintln(pane, "error: "+event.Message)
+		case agent.EventCleanup:
+			if verbose {
+				fmt.Fprintln(pane, event.Message)
+			}
+		}
+	}
+}
+
+func runAgentCleanup(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	sandbox, err := detectSandbox(cmd)
+	if err != nil {
+		return err
+	}
+	resources, err := agent.Sweep(ctx, sandbox, dryRun)
+	if err != nil {
+		return fmt.Errorf("cleanup failed: %w", err)
+	}
+	if jsonOut {
+		return printJSON(CleanupOutput{DryRun: dryRun, Removed: append([]string{}, resources...)})
+	}
+	if len(resources) == 0 {
+		printInfo("No agent leftovers found")
+		return nil
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	for _, r := range resources {
+		printInfo("%s %s", verb, r)
+	}
+	return nil
+}
+
+// detectSandbox reads the sandbox from the flags, picks the runtime when it
+// is auto and checks its daemon answers
+func detectSandbox(cmd *cobra.Command) (*agent.Sandbox, error) {
+	sandbox := sandboxFromFlags(cmd)
+	if err := sandbox.Detect(context.Background()); err != nil {
+		return nil, err
+	}
+	if err := sandbox.Validate(); err != nil {
+		return nil, err
+	}
+	if err := sandbox.Ping(context.Background()); err != nil {
+		return nil, err
+	}
+	if sandbox.Rootless {
+		printVerbose("Container runtime: %s (rootless), daemon %s", sandbox.Runtime, sandbox.Target())
+	} else {
+		printVerbose("Container runtime: %s, daemon %s", sandbox.Runtime, sandbox.Target())
+	}
+	return sandbox, nil
+}
+
+// sandboxFromFlags reads the test container runtime, daemon and limits
+func sandboxFromFlags(cmd *cobra.Command) *agent.Sandbox {
+	sandbox := agent.DefaultSandbox()
+	sandbox.Runtime, _ = cmd.Flags().GetString("runtime")
+	sandbox.Host, _ = cmd.Flags().GetString("docker-host")
+	sandbox.Context, _ = cmd.Flags().GetString("docker-context")
+	if cmd.Flags().Lookup("memory") != nil {
+		sandbox.Memory, _ = cmd.Flags().GetString("memory")
+		sandbox.CPUs, _ = cmd.Flags().GetString("cpus")
+		sandbox.PidsLimit, _ = cmd.Flags().GetInt("pids-limit")
+		sandbox.Internal, _ = cmd.Flags().GetBool("isolate-network")
+	}
+	return sandbox
+}
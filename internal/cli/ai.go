@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/ai"
+	"github.com/dublyo/dockerizer/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var aiCmd = &cobra.Command{
+	Use:   "ai",
+	Short: "Inspect AI provider usage",
+}
+
+var aiUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Report AI token usage and estimated cost",
+	Long: `Report the tokens and estimated cost of AI provider calls made by
+dockerize, init and agent, per provider and model.
+
+Usage is recorded in ~/.dockerizer/usage.jsonl. Costs are estimates from
+list prices; local Ollama models are free.
+
+Examples:
+  dockerizer ai usage
+  dockerizer ai usage --days 7
+  dockerizer ai usage --days 0 --json`,
+	Args: cobra.NoArgs,
+	RunE: runAIUsage,
+}
+
+func init() {
+	aiUsageCmd.Flags().Int("days", 30, "Report the last N days (0 for all time)")
+
+	aiCmd.AddCommand(aiUsageCmd)
+	rootCmd.AddCommand(aiCmd)
+}
+
+// usageSummary is the usage of one provider/model in the report
+type usageSummary struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	Priced           bool    `json:"priced"`
+}
+
+func runAIUsage(cmd *cobra.Command, args []string) error {
+	days, _ := cmd.Flags().GetInt("days")
+
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+	records, err := ai.LoadUsage(since)
+	if err != nil {
+		return err
+	}
+
+	byModel := make(map[string]*usageSummary)
+	for _, u := range records {
+		key := u.Provider + "/" + u.Model
+		s := byModel[key]
+		if s == nil {
+			s = &usageSummary{Provider: u.Provider, Model: u.Model, Priced: true}
+			byModel[key] = s
+		}
+		s.Calls++
+		s.PromptTokens += u.PromptTokens
+		s.CompletionTokens += u.CompletionTokens
+		s.CostUSD += u.CostUSD
+		s.Priced = s.Priced && u.Priced
+	}
+	summaries := make([]usageSummary, 0, len(byModel))
+	for _, s := range byModel {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].CostUSD != summaries[j].CostUSD {
+			return summaries[i].CostUSD > summaries[j].CostUSD
+		}
+		return summaries[i].Provider+summaries[i].Model < summaries[j].Provider+summaries[j].Model
+	})
+	total := ai.SumUsage(records)
+
+	if jsonOut {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"days":              days,
+			"calls":             len(records),
+			"prompt_tokens":     total.PromptTokens,
+			"completion_tokens": total.CompletionTokens,
+			"cost_usd":          total.CostUSD,
+			"models":            summaries,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal usage: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	period := "all time"
+	if days > 0 {
+		period = fmt.Sprintf("last %d days", days)
+	}
+	if len(records) == 0 {
+		fmt.Printf("No AI usage recorded (%s)\n", period)
+		return nil
+	}
+
+	fmt.Printf("AI usage (%s)\n\n", period)
+	fmt.Printf("  %-36s %6s %12s %12s %10s\n", "PROVIDER/MODEL", "CALLS", "PROMPT", "COMPLETION", "COST")
+	for _, s := range summaries {
+		fmt.Printf("  %-36s %6d %12d %12d %10s\n", s.Provider+"/"+s.Model, s.Calls, s.PromptTokens, s.CompletionTokens, formatCost(s.CostUSD, s.Priced))
+	}
+	fmt.Printf("  %-36s %6d %12d %12d %10s\n", "TOTAL", len(records), total.PromptTokens, total.CompletionTokens, formatCost(total.CostUSD, total.Priced))
+	if !total.Priced {
+		fmt.Println("\n  * includes models without a known price")
+	}
+	return nil
+}
+
+// formatCost formats an estimated cost, flagging totals that miss unpriced models
+func formatCost(usd float64, priced bool) string {
+	s := fmt.Sprintf("$%.4f", usd)
+	if !priced {
+		s += "*"
+	}
+	return s
+}
+
+// recordUsage logs a command's AI calls to the usage log and reports them in
+// verbose output
+func recordUsage(command string, usage []ai.Usage) {
+	if len(usage) == 0 {
+		return
+	}
+	for i := range usage {
+		usage[i].Command = command
+		printVerbose("AI usage: %s", usage[i])
+	}
+	if len(usage) > 1 {
+		printVerbose("AI usage total: %s", ai.SumUsage(usage))
+	}
+	if err := ai.RecordUsage(usage); err != nil {
+		printVerbose("Could not record AI usage: %v", err)
+	}
+}
+
+// aiHTTPConfig is the request timeout and retries of the ai configuration
+func aiHTTPConfig(cfg config.AIConfig) ai.HTTPConfig {
+	httpConfig := ai.DefaultHTTPConfig()
+	httpConfig.Timeout = time.Duration(cfg.Timeout) * time.Second
+	if cfg.MaxRetries != nil {
+		httpConfig.MaxRetries = max(*cfg.MaxRetries, 0)
+	}
+	return httpConfig
+}
+
+// reportRequests prints the request metrics of the providers that were
+// called: with --verbose, or whenever requests were retried or failed
+func reportRequests(provider ai.Provider) {
+	for _, m := range ai.ProviderMetrics(provider) {
+		switch {
+		case m.Retries > 0 || m.Failures > 0 || m.CircuitOpen > 0:
+			printInfo("AI requests: %s", m)
+		case m.Requests > 0:
+			printVerbose("AI requests: %s", m)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/config"
+	"github.com/dublyo/dockerizer/internal/secrets"
+	"github.com/dublyo/dockerizer/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage AI provider API keys",
+	Long: `Keep AI provider API keys in the operating system's secret store rather
+than in plain text: the macOS Keychain, the Windows Credential Manager, or
+the Secret Service (GNOME Keyring, KWallet) through libsecret's secret-tool
+on Linux.
+
+An API key is looked up in this order:
+  env       ANTHROPIC_API_KEY or OPENAI_API_KEY
+  keychain  the key saved with dockerizer auth login
+  config    ai.api_key, when ai.provider names the same provider
+
+Examples:
+  dockerizer auth login anthropic
+  echo "$OPENAI_API_KEY" | dockerizer auth login openai
+  dockerizer auth status
+  dockerizer auth logout openai`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:       "login <provider>",
+	Short:     "Save a provider's API key in the OS secret store",
+	Long:      "Save a provider's API key in the OS secret store. The key is prompted for\nwithout echo, or read from stdin when it is not a terminal.",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: keyProviders,
+	RunE:      runAuthLogin,
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:       "logout <provider>",
+	Short:     "Remove a provider's API key from the OS secret store",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: keyProviders,
+	RunE:      runAuthLogout,
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show where each provider's API key comes from",
+	Args:  cobra.NoArgs,
+	RunE:  runAuthStatus,
+}
+
+// keyProviders are the AI providers that need an API key
+var keyProviders = []string{"anthropic", "openai"}
+
+// apiKeyEnv names the environment variable holding each provider's API key
+var apiKeyEnv = map[string]string{
+	"anthropic": "ANTHROPIC_API_KEY",
+	"openai":    "OPENAI_API_KEY",
+}
+
+// Where an API key was found
+const (
+	keySourceEnv      = "env"
+	keySourceKeychain = "keychain"
+	keySourceConfig   = "config"
+)
+
+func init() {
+	authStatusCmd.Flags().String("path", ".", "Path to the project whose config is checked")
+	authStatusCmd.Flags().Bool("show-secrets", false, "Show API keys in full")
+
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authLogoutCmd)
+	authCmd.AddCommand(authStatusCmd)
+	rootCmd.AddCommand(authCmd)
+}
+
+// lookupAPIKey finds a provider's API key in the environment, the OS secret
+// store, or cfg when it configures the same provider, and reports which one
+// it came from. It returns "" when the provider has no key.
+func lookupAPIKey(provider string, cfg config.AIConfig) (key, source string) {
+	env, ok := apiKeyEnv[provider]
+	if !ok {
+		return "", ""
+	}
+	if key := os.Getenv(env); key != "" {
+		return key, keySourceEnv
+	}
+
+	key, err := secrets.Get(provider)
+	switch {
+	case err == nil && key != "":
+		return key, keySourceKeychain
+	case err != nil && !errors.Is(err, secrets.ErrNotFound) && !errors.Is(err, secrets.ErrUnavailable):
+		printVerbose("Could not read the %s API key from the secret store: %v", provider, err)
+	}
+
+	if cfg.Provider == provider && cfg.APIKey != "" {
+		return cfg.APIKey, keySourceConfig
+	}
+	return "", ""
+}
+
+// checkKeyProvider rejects providers that take no API key
+func checkKeyProvider(provider string) error {
+	if _, ok := apiKeyEnv[provider]; !ok {
+		return fmt.Errorf("unknown provider %q: expected %s (ollama needs no key)", provider, strings.Join(keyProviders, " or "))
+	}
+	return nil
+}
+
+// AuthChange is the JSON output of auth login and logout
+type AuthChange struct {
+	Provider string `json:"provider"`
+	Store    string `json:"store"`
+	Changed  bool   `json:"changed"` // login saved, or logout removed, a key
+}
+
+func runAuthLogin(cmd *cobra.Command, args []string) error {
+	provider := args[0]
+	if err := checkKeyProvider(provider); err != nil {
+		return err
+	}
+
+	var key string
+	if tui.Available() {
+		var err error
+		if key, err = tui.ReadSecret(fmt.Sprintf("%s API key: ", provider)); err != nil {
+			return err
+		}
+	} else {
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		key = strings.TrimSpace(line)
+	}
+	if key == "" {
+		return fmt.Errorf("no API key given")
+	}
+
+	store := secrets.Default()
+	if err := store.Set(provider, key); err != nil {
+		if errors.Is(err, secrets.ErrUnavailable) {
+			return fmt.Errorf("%w: install libsecret's secret-tool, or set %s instead", err, apiKeyEnv[provider])
+		}
+		return fmt.Errorf("failed to save the API key: %w", err)
+	}
+	if jsonOut {
+		return printJSON(AuthChange{Provider: provider, Store: store.Name(), Changed: true})
+	}
+	printSuccess("Saved the %s API key in the %s", provider, store.Name())
+
+	if os.Getenv(apiKeyEnv[provider]) != "" {
+		printInfo("Note: %s is set and takes precedence over the saved key", apiKeyEnv[provider])
+	}
+	if cfg, err := config.LoadForProject("."); err == nil && cfg.AI.Provider == provider && cfg.AI.APIKey != "" {
+		printInfo("A plaintext key is still in the config; remove it with: dockerizer config unset ai.api_key")
+	}
+	return nil
+}
+
+func runAuthLogout(cmd *cobra.Command, args []string) error {
+	provider := args[0]
+	if err := checkKeyProvider(provider); err != nil {
+		return err
+	}
+
+	store := secrets.Default()
+	err := store.Delete(provider)
+	switch {
+	case jsonOut && (err == nil || errors.Is(err, secrets.ErrNotFound)):
+		return printJSON(AuthChange{Provider: provider, Store: store.Name(), Changed: err == nil})
+	case errors.Is(err, secrets.ErrNotFound):
+		printInfo("No %s API key is saved in the %s", provider, store.Name())
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to remove the API key: %w", err)
+	}
+	printSuccess("Removed the %s API key from the %s", provider, store.Name())
+	return nil
+}
+
+// authStatus is one provider's line of auth status
+type authStatus struct {
+	Provider string `json:"provider"`
+	Source   string `json:"source,omitempty"`
+	From     string `json:"from,omitempty"`
+	Key      string `json:"key,omitempty"`
+}
+
+func runAuthStatus(cmd *cobra.Command, args []string) error {
+	projectPath, _ := cmd.Flags().GetString("path")
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+
+	cfg := projectConfig(projectPath)
+	store := secrets.Default()
+
+	var statuses []authStatus
+	for _, provider := range keyProviders {
+		key, source := lookupAPIKey(provider, cfg.AI)
+		status := authStatus{Provider: provider, Source: source, Key: key}
+		switch source {
+		case keySourceEnv:
+			status.From = apiKeyEnv[provider]
+		case keySourceKeychain:
+			status.From = store.Name()
+		case keySourceConfig:
+			status.From = "ai.api_key"
+		}
+		if !showSecrets && key != "" {
+			status.Key = config.Mask(key)
+		}
+		statuses = append(statuses, status)
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Store     string       `json:"store"`
+			Providers []authStatus `json:"providers"`
+		}{store.Name(), statuses})
+	}
+
+	printInfo("Secret store: %s", store.Name())
+	printInfo("")
+	for _, s := range statuses {
+		if s.Source == "" {
+			printInfo("  %-10s ✗ not set", s.Provider)
+			continue
+		}
+		printInfo("  %-10s ✓ %-9s %-28s %s", s.Provider, s.Source, s.From, s.Key)
+	}
+	return nil
+}
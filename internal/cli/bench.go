@@ -0,0 +1,489 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/internal/imagesize"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// BenchOutput is the JSON output for bench command
+type BenchOutput struct {
+	Path     string         `json:"path"`
+	Built    bool           `json:"built"` // False when only estimated
+	Variants []BenchVariant `json:"variants"`
+}
+
+// BenchVariant is how one variant of the Dockerfile built
+type BenchVariant struct {
+	Name     string   `json:"name"`
+	Cold     float64  `json:"cold_seconds,omitempty"` // Without the build cache
+	Warm     float64  `json:"warm_seconds,omitempty"` // With the cache, after a source change
+	Size     int64    `json:"size,omitempty"`         // Uncompressed, from docker image inspect
+	Estimate int64    `json:"estimate"`               // Compressed, from dockerizer size
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+
+	dockerfile string
+	files      map[string]string // Other files the build needs, by path
+}
+
+// benchSpec is a variant as given with --variant
+type benchSpec struct {
+	name        string
+	file        string // Existing Dockerfile, instead of generating one
+	base        string // alpine or slim
+	goBase      string // distroless or scratch, for Go apps
+	cacheMounts *bool
+}
+
+// defaultBenchVariants are benched when no --variant is given
+var defaultBenchVariants = []string{"alpine", "slim", "alpine+cache-mounts"}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [path]",
+	Short: "Build variants of the Dockerfile and compare their build time and size",
+	Long: `Build two or more variants of the project's Dockerfile and compare how long
+they take to build, cold and warm, and how large the image is.
+
+Each --variant joins options with +:
+  default           The Dockerfile dockerize generates
+  alpine, slim      Official base images switched to their alpine or slim
+                    (Debian) variant, where the RUN lines allow it
+  distroless,       The final base image of a Go app
+  scratch
+  cache-mounts,     With or without BuildKit cache mounts
+  no-cache-mounts
+  file:PATH         An existing Dockerfile of the project instead of a
+                    generated one; it combines with alpine and slim only
+
+The default is alpine, slim and alpine+cache-mounts. Builds run in a copy of
+the project: the cold build without the build cache (base images are pulled
+first, so it times the build alone), the warm build with the cache after a
+change to a source file. Sizes are uncompressed, as docker images shows
+them; the estimate column is what dockerizer size expects a pull to
+download. With --estimate or --offline, or without docker, only the estimates
+are shown.
+
+Examples:
+  dockerizer bench
+  dockerizer bench ./my-project --variant alpine --variant slim
+  dockerizer bench --variant file:Dockerfile --variant default+cache-mounts
+  dockerizer bench --variant alpine --variant distroless --variant scratch --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBench,
+}
+
+func init() {
+	benchCmd.Flags().StringArray("variant", nil, "Variant to bench, e.g. slim+cache-mounts (repeatable; default: alpine, slim, alpine+cache-mounts)")
+	benchCmd.Flags().Bool("estimate", false, "Only estimate image sizes, without building")
+	benchCmd.Flags().Bool("keep", false, "Keep the built images (tagged dockerizer-bench:<variant>)")
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	names, _ := cmd.Flags().GetStringArray("variant")
+	estimateOnly, _ := cmd.Flags().GetBool("estimate")
+	keep, _ := cmd.Flags().GetBool("keep")
+
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if len(names) == 0 {
+		names = defaultBenchVariants
+	}
+	specs := make([]benchSpec, 0, len(names))
+	for _, name := range names {
+		spec, err := parseBenchVariant(name)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) < 2 {
+		return fmt.Errorf("bench needs at least two variants to compare")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
+
+	printInfo("Scanning %s...", path)
+	scan, err := scanner.New().Scan(ctx, path)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	var result *detector.DetectionResult
+	for _, spec := range specs {
+		if spec.file != "" || result != nil {
+			continue
+		}
+		if scan.Metadata.Workspace != nil || scan.Metadata.Orchestrator != nil {
+			return fmt.Errorf("%s is a monorepo; generate its Dockerfile with dockerize --app and bench it with --variant file:PATH", path)
+		}
+		if result, err = detector.New(setupRegistry()).Detect(ctx, scan); err != nil {
+			return fmt.Errorf("detection failed: %w", err)
+		}
+		if !result.Detected {
+			return fmt.Errorf("no stack detected in %s", path)
+		}
+	}
+
+	output := BenchOutput{Path: path, Built: !estimateOnly}
+	sizer := baseSizer(ctx, "")
+	for _, spec := range specs {
+		v := benchVariant(path, spec, result, scan, sizer)
+		output.Variants = append(output.Variants, v)
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil && output.Built {
+		printInfo("docker not found; estimating sizes only")
+		output.Built = false
+	}
+	// The builds pull base images and dependencies
+	if err := requireNetwork("bench builds"); err != nil && output.Built {
+		printInfo("%v; estimating sizes only", err)
+		output.Built = false
+	}
+	if output.Built {
+		if err := runBenchBuilds(ctx, path, output.Variants, keep); err != nil {
+			return err
+		}
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output)
+	}
+	printBenchTable(output)
+	return nil
+}
+
+// parseBenchVariant reads a --variant value such as slim+cache-mounts
+func parseBenchVariant(name string) (benchSpec, error) {
+	spec := benchSpec{name: name}
+	for _, option := range strings.Split(name, "+") {
+		switch option {
+		case "default":
+		case "alpine", "slim":
+			spec.base = option
+		case "distroless", "scratch":
+			spec.goBase = option
+		case "cache-mounts", "no-cache-mounts":
+			enable := option == "cache-mounts"
+			spec.cacheMounts = &enable
+		default:
+			file, ok := strings.CutPrefix(option, "file:")
+			if !ok || file == "" {
+				return spec, fmt.Errorf("unknown variant option %q in %q", option, name)
+			}
+			spec.file = file
+		}
+	}
+	if spec.file != "" && (spec.goBase != "" || spec.cacheMounts != nil) {
+		return spec, fmt.Errorf("variant %q: an existing Dockerfile combines with alpine and slim only", name)
+	}
+	return spec, nil
+}
+
+// benchVariant prepares the Dockerfile of a variant and estimates its size;
+// problems are recorded on the variant, so the others still run
+func benchVariant(path string, spec benchSpec, result *detector.DetectionResult, scan *scanner.ScanResult, sizer func(string) (int64, error)) BenchVariant {
+	v := BenchVariant{Name: spec.name, files: make(map[string]string)}
+	if spec.file != "" {
+		content, err := os.ReadFile(filepath.Join(path, spec.file))
+		if err != nil {
+			v.Error = err.Error()
+			return v
+		}
+		v.dockerfile = string(content)
+		if ignore, err := os.ReadFile(filepath.Join(path, spec.file+".dockerignore")); err == nil {
+			v.files[".dockerignore"] = string(ignore)
+		}
+	} else {
+		if spec.goBase != "" && result.Language != "go" {
+			v.Error = spec.goBase + " is a base image for Go apps only"
+			return v
+		}
+		genOpts := append([]generator.Option{
+			generator.WithCompose(false),
+			generator.WithEnv(false),
+		}, projectGeneratorOptions(path, spec.goBase, "", "", "", "", nil)...)
+		genOpts = append(genOpts, generator.WithDockerfilePath("Dockerfile"),
+			generator.WithCacheMounts(useCacheMounts(path, spec.cacheMounts)))
+		out, err := generator.New(genOpts...).Generate(result, "")
+		if err != nil {
+			v.Error = fmt.Sprintf("generation failed: %v", err)
+			return v
+		}
+		v.dockerfile = out.Dockerfile
+		if out.Dockerignore != "" {
+			v.files[".dockerignore"] = out.Dockerignore
+		}
+		if out.Entrypoint != "" {
+			v.files["docker-entrypoint.sh"] = out.Entrypoint
+		}
+	}
+
+	df := dockerfile.Parse(v.dockerfile)
+	if err := df.Err(); err != nil {
+		v.Error = err.Error()
+		return v
+	}
+	if spec.base != "" {
+		changed, err := df.SetBaseVariant(spec.base)
+		if err != nil {
+			v.Error = err.Error()
+			return v
+		}
+		if len(changed) == 0 && !strings.Contains(v.dockerfile, spec.base) {
+			v.Warnings = append(v.Warnings, "no base image switched to "+spec.base)
+		}
+		v.dockerfile = df.String()
+	}
+	v.Estimate = imagesize.Estimate(df, imagesize.Options{Scan: scan, BaseSize: sizer}).Total
+	return v
+}
+
+// runBenchBuilds builds each variant in one copy of the project, so the
+// warm builds can change a file without touching the project
+func runBenchBuilds(ctx context.Context, path string, variants []BenchVariant, keep bool) error {
+	dir, err := os.MkdirTemp("", "dockerizer-bench-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	printInfo("Copying %s to a build context...", path)
+	if err := copyBuildContext(path, dir); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", path, err)
+	}
+
+	for i := range variants {
+		v := &variants[i]
+		if v.Error != "" {
+			continue
+		}
+		printInfo("Building %s (%d/%d)...", v.Name, i+1, len(variants))
+		if err := benchBuild(ctx, dir, i, v, keep); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			v.Error = err.Error()
+			printVerbose("%s failed: %v", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// benchBuild builds one variant cold and warm, and measures its image
+func benchBuild(ctx context.Context, dir string, i int, v *BenchVariant, keep bool) error {
+	name := fmt.Sprintf("Dockerfile.bench-%d", i)
+	files := map[string]string{name: v.dockerfile}
+	for file, content := range v.files {
+		if file == ".dockerignore" {
+			file = name + ".dockerignore" // Read by BuildKit for this Dockerfile only
+		}
+		files[file] = content
+	}
+	for file, content := range files {
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(file, ".sh") {
+			mode = 0755
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), mode); err != nil {
+			return err
+		}
+	}
+
+	tag := "dockerizer-bench:" + benchTag(v.Name)
+	if !keep {
+		defer exec.Command("docker", "rmi", "-f", tag).Run()
+	}
+	df := dockerfile.Parse(v.dockerfile)
+	pulled := make(map[string]bool)
+	for _, stage := range df.Stages {
+		image := df.ExpandArgs(stage.Image)
+		if df.BaseStage(stage) != nil || pulled[image] || strings.EqualFold(image, "scratch") || strings.Contains(image, "$") {
+			continue
+		}
+		pulled[image] = true
+		printVerbose("Pulling %s...", image)
+		if _, err := dockerOutput(ctx, "pull", "--quiet", image); err != nil {
+			return fmt.Errorf("pull %s: %w", image, err)
+		}
+	}
+
+	build := []string{"build", "-f", filepath.Join(dir, name), "-t", tag, dir}
+	cold, err := timeDocker(ctx, append([]string{"build", "--no-cache"}, build[1:]...)...)
+	if err != nil {
+		return err
+	}
+	// A source change invalidates the layers that copy the project, but not
+	// the dependency layers before them
+	marker := filepath.Join(dir, ".dockerizer-bench")
+	if err := os.WriteFile(marker, []byte(strconv.FormatInt(time.Now().UnixNano(), 10)+"\n"), 0644); err != nil {
+		return err
+	}
+	warm, err := timeDocker(ctx, build...)
+	if err != nil {
+		return err
+	}
+	v.Cold, v.Warm = cold.Seconds(), warm.Seconds()
+
+	out, err := dockerOutput(ctx, "image", "inspect", "--format", "{{.Size}}", tag)
+	if err != nil {
+		return err
+	}
+	if v.Size, err = strconv.ParseInt(strings.TrimSpace(out), 10, 64); err != nil {
+		return fmt.Errorf("unexpected image size %q", strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// timeDocker runs a docker command and returns how long it took
+func timeDocker(ctx context.Context, args ...string) (time.Duration, error) {
+	start := time.Now()
+	if _, err := dockerOutput(ctx, args...); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// dockerOutput runs a docker command; its error ends with the last lines of
+// the command's output
+func dockerOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+		if len(lines) > 5 {
+			lines = lines[len(lines)-5:]
+		}
+		return "", fmt.Errorf("docker %s: %w\n%s", args[0], err, strings.Join(lines, "\n"))
+	}
+	return string(out), nil
+}
+
+// benchTagChars are the characters a variant name loses in an image tag
+var benchTagChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// benchTag turns a variant name into an image tag
+func benchTag(name string) string {
+	tag := strings.Trim(benchTagChars.ReplaceAllString(name, "-"), "-.")
+	if len(tag) > 128 {
+		tag = tag[:128]
+	}
+	if tag == "" {
+		tag = "variant"
+	}
+	return tag
+}
+
+// copyBuildContext copies the project at src to dst, without .git and the
+// top-level directories its .dockerignore excludes (dependencies, build
+// output), which docker would not send anyway
+func copyBuildContext(src, dst string) error {
+	ignored := func(string, bool) bool { return false }
+	if content, err := os.ReadFile(filepath.Join(src, ".dockerignore")); err == nil {
+		ignored = scanner.IgnoreMatcher(string(content))
+	}
+	return filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case entry.IsDir():
+			if entry.Name() == ".git" || !strings.ContainsRune(rel, filepath.Separator) && ignored(filepath.ToSlash(rel), true) {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !entry.Type().IsRegular():
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// printBenchTable prints the variants side by side
+func printBenchTable(output BenchOutput) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if output.Built {
+		fmt.Fprintln(w, "VARIANT\tCOLD\tWARM\tSIZE\tESTIMATE")
+	} else {
+		fmt.Fprintln(w, "VARIANT\tESTIMATE")
+	}
+	for _, v := range output.Variants {
+		switch {
+		case v.Error != "":
+			fmt.Fprintf(w, "%s\tfailed\n", v.Name)
+		case output.Built:
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.Name, benchDuration(v.Cold), benchDuration(v.Warm),
+				imagesize.Format(v.Size), imagesize.Format(v.Estimate))
+		default:
+			fmt.Fprintf(w, "%s\t%s\n", v.Name, imagesize.Format(v.Estimate))
+		}
+	}
+	w.Flush()
+
+	for _, v := range output.Variants {
+		for _, warning := range v.Warnings {
+			printInfo("  ⚠ %s: %s", v.Name, warning)
+		}
+		if v.Error != "" {
+			printError("%s: %s", v.Name, v.Error)
+		}
+	}
+}
+
+// benchDuration formats a build time to a tenth of a second
+func benchDuration(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(100 * time.Millisecond).String()
+}
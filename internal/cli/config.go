@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/dublyo/dockerizer/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change configuration",
+	Long: `Show and change the layered configuration.
+
+Settings are applied in order, each layer overriding only what it sets:
+  default   built-in defaults
+  global    ~/.config/dockerizer/config.yml (or the legacy ~/.dockerizer.yml)
+  project   .dockerizer.yml in the project
+  env       DOCKERIZER_AI_PROVIDER, DOCKERIZER_AI_MODEL, OPENAI_API_KEY, ANTHROPIC_API_KEY
+
+API keys saved with dockerizer auth login live in the OS secret store and
+are not part of these layers.
+
+Keys are dotted paths into the file, e.g. ai.provider or
+environments.prod.memory_limit. API keys are masked unless --show-secrets
+is given.
+
+Examples:
+  dockerizer config list
+  dockerizer config get ai.provider
+  dockerizer config set ai.provider anthropic
+  dockerizer config set --project defaults.proxy traefik
+  dockerizer config unset ai.model`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the effective settings and where each comes from",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Write a setting to the global (or --project) config",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting from the global (or --project) config",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+func init() {
+	configCmd.PersistentFlags().String("path", ".", "Path to the project")
+	configCmd.PersistentFlags().Bool("show-secrets", false, "Show API keys in full")
+	configListCmd.Flags().Bool("keys", false, "List the settable keys")
+	configSetCmd.Flags().Bool("project", false, "Write to the project's .dockerizer.yml")
+	configUnsetCmd.Flags().Bool("project", false, "Remove from the project's .dockerizer.yml")
+
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// displayValue masks secrets unless --show-secrets is set
+func displayValue(cmd *cobra.Command, key, value string) string {
+	if show, _ := cmd.Flags().GetBool("show-secrets"); !show && config.IsSecret(key) {
+		return config.Mask(value)
+	}
+	return value
+}
+
+// ConfigChange is the JSON output of config get, set and unset
+type ConfigChange struct {
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+	File    string `json:"file,omitempty"`    // Written by set and unset
+	Changed bool   `json:"changed,omitempty"` // set wrote, or unset removed, the key
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	projectPath, _ := cmd.Flags().GetString("path")
+	showKeys, _ := cmd.Flags().GetBool("keys")
+
+	if showKeys {
+		if jsonOut {
+			return printJSON(config.Keys())
+		}
+		for _, key := range config.Keys() {
+			fmt.Println(key)
+		}
+		return nil
+	}
+
+	entries, err := config.Entries(projectPath)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		entries[i].Value = displayValue(cmd, entries[i].Key, entries[i].Value)
+	}
+
+	if jsonOut {
+		return printJSON(struct {
+			Layers  []config.Layer `json:"layers"`
+			Entries []config.Entry `json:"entries"`
+		}{config.Layers(projectPath), entries})
+	}
+
+	for _, layer := range config.Layers(projectPath) {
+		status := ""
+		if !layer.Exists {
+			status = " (not found)"
+		}
+		printInfo("# %-7s %s%s", layer.Name, layer.Path, status)
+	}
+	printInfo("")
+	for _, e := range entries {
+		printInfo("%-38s = %-28s [%s]", e.Key, e.Value, e.Source)
+	}
+	return nil
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	projectPath, _ := cmd.Flags().GetString("path")
+
+	cfg, err := config.LoadForProject(projectPath)
+	if err != nil {
+		return err
+	}
+	value, err := cfg.Get(args[0])
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		return printJSON(ConfigChange{Key: args[0], Value: displayValue(cmd, args[0], value)})
+	}
+	fmt.Println(displayValue(cmd, args[0], value))
+	return nil
+}
+
+// configTarget is the file set and unset write to
+func configTarget(cmd *cobra.Command) (string, error) {
+	if project, _ := cmd.Flags().GetBool("project"); project {
+		projectPath, _ := cmd.Flags().GetString("path")
+		return config.ProjectPath(projectPath), nil
+	}
+	return config.GlobalPath()
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	path, err := configTarget(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := config.Set(path, key, value); err != nil {
+		return err
+	}
+	if jsonOut {
+		return printJSON(ConfigChange{Key: key, Value: displayValue(cmd, key, value), File: path, Changed: true})
+	}
+	printSuccess("Set %s = %s in %s", key, displayValue(cmd, key, value), path)
+	if config.IsSecret(key) {
+		if project, _ := cmd.Flags().GetBool("project"); project {
+			printInfo("⚠ %s holds a credential now; keep it out of version control", path)
+		}
+		printInfo("Tip: dockerizer auth login <provider> keeps API keys in the OS secret store instead")
+	}
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	path, err := configTarget(cmd)
+	if err != nil {
+		return err
+	}
+
+	removed, err := config.Unset(path, args[0])
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		return printJSON(ConfigChange{Key: args[0], File: path, Changed: removed})
+	}
+	if !removed {
+		printInfo("%s is not set in %s", args[0], path)
+		return nil
+	}
+	printSuccess("Removed %s from %s", args[0], path)
+	return nil
+}
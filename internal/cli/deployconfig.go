@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+var deployConfigCmd = &cobra.Command{
+	Use:   "deploy-config [path]",
+	Short: "Generate a deployment descriptor for a PaaS, ECS or Terraform",
+	Long: `Generate the deployment descriptor of a platform that runs the image of the
+project's Dockerfile:
+
+  fly        fly.toml       app, [build], release_command, [env], [processes],
+                            [http_service] with its health check
+  render     render.yaml    Blueprint with a docker web service, preDeployCommand,
+                            envVars, and a worker service per Procfile process
+  railway    railway.json   Dockerfile builder, healthcheckPath, preDeployCommand
+  ecs        ecs-task-definition.json
+                            Fargate task definition: port mappings, environment,
+                            SSM secrets, health check, awslogs, task size
+  terraform  docker.tf      kreuzwerker/docker image and containers, with
+                            sensitive variables for secrets
+
+The port and health check path come from the Dockerfile's EXPOSE and
+HEALTHCHECK, the release command from the Procfile release process (or the
+detected ORM migration), and the environment from the variables the app
+reads. The memory and CPU limits of ecs and terraform are those of an
+environment (--environment), as for docker-compose.<env>.yml. Without a
+Dockerfile the one dockerizer would generate is used; run dockerizer first
+so the platform has it to build.
+
+Existing files are kept unless --force is given.
+
+Examples:
+  dockerizer deploy-config --platform fly
+  dockerizer deploy-config ./my-project --platform render --dry-run
+  dockerizer deploy-config --platform railway --force
+  dockerizer deploy-config --platform ecs --environment staging`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDeployConfig,
+}
+
+func init() {
+	deployConfigCmd.Flags().String("platform", "", "Platform to deploy to ("+strings.Join(generator.DeployPlatformNames(), ", ")+")")
+	deployConfigCmd.Flags().String("environment", "prod", "Environment whose resource limits ecs and terraform use")
+	deployConfigCmd.Flags().Bool("dry-run", false, "Print the descriptor instead of writing it")
+	deployConfigCmd.Flags().BoolP("force", "f", false, "Overwrite an existing descriptor")
+	_ = deployConfigCmd.MarkFlagRequired("platform")
+	rootCmd.AddCommand(deployConfigCmd)
+}
+
+func runDeployConfig(cmd *cobra.Command, args []string) (err error) {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	platform, _ := cmd.Flags().GetString("platform")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+	environment, _ := cmd.Flags().GetString("environment")
+	output := &ExportOutput{Platform: platform, DryRun: dryRun}
+	defer func() { err = output.finish(err) }()
+
+	if _, ok := generator.DeployPlatforms[platform]; !ok {
+		return fmt.Errorf("unsupported platform %q (supported: %s)", platform, strings.Join(generator.DeployPlatformNames(), ", "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	scan, err := scanner.New().Scan(ctx, path)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	result, err := detector.New(setupRegistry()).Detect(ctx, scan)
+	if err != nil {
+		return fmt.Errorf("detection failed: %w", err)
+	}
+	if !result.Detected {
+		return fmt.Errorf("no stack detected in %s", path)
+	}
+
+	// The platform builds the project's Dockerfile
+	file := dockerfileIn(path)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		genOpts := append([]generator.Option{
+			generator.WithCompose(false),
+			generator.WithIgnore(false),
+			generator.WithEnv(false),
+		}, projectGeneratorOptions(path, "", "", "", "", "", nil)...)
+		generated, err := generator.New(genOpts...).Generate(result, "")
+		if err != nil {
+			return fmt.Errorf("generation failed: %w", err)
+		}
+		content = []byte(generated.Dockerfile)
+		output.warn("%s has no Dockerfile yet; run dockerizer first so %s has one to build", path, platform)
+	}
+	df := dockerfile.Parse(string(content))
+	rel, err := filepath.Rel(path, file)
+	if err != nil {
+		rel = filepath.Base(file)
+	}
+
+	target := generator.NewDeployTarget(result, df, exportName(path, scan), filepath.ToSlash(rel))
+	limits := projectConfig(path).Environments[environment]
+	target.Resources = generator.Environment{
+		Name:              environment,
+		Restart:           limits.Restart,
+		MemoryLimit:       limits.MemoryLimit,
+		MemoryReservation: limits.MemoryReservation,
+		CPUs:              limits.CPUs,
+	}
+	name, descriptor, err := generator.DeployConfig(platform, target)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", platform, err)
+	}
+	if err := writeExported(output, path, name, descriptor, force); err != nil {
+		return err
+	}
+	for _, note := range generator.DeployNotes(platform, target) {
+		output.warn("%s", note)
+	}
+	return nil
+}
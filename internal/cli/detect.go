@@ -9,6 +9,7 @@ import (
 
 	"github.com/dublyo/dockerizer/internal/detector"
 	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
 	"github.com/spf13/cobra"
 )
 
@@ -21,13 +22,17 @@ type DetectionOutput struct {
 	Confidence int                    `json:"confidence,omitempty"`
 	Provider   string                 `json:"provider,omitempty"`
 	Candidates []CandidateOutput      `json:"candidates,omitempty"`
+	Rejected   []CandidateOutput      `json:"rejected,omitempty"`
 	Variables  map[string]interface{} `json:"variables,omitempty"`
+	Hints      []detector.Hint        `json:"hints,omitempty"`
+	Scan       *scanner.ScanStats     `json:"scan,omitempty"`
 }
 
 // CandidateOutput represents a candidate in JSON output
 type CandidateOutput struct {
-	Provider   string `json:"provider"`
-	Confidence int    `json:"confidence"`
+	Provider   string             `json:"provider"`
+	Confidence int                `json:"confidence"`
+	Signals    []providers.Signal `json:"signals,omitempty"`
 }
 
 var detectCmd = &cobra.Command{
@@ -39,16 +44,22 @@ This command scans the repository and attempts to identify the language,
 framework, and version being used. It shows the confidence level and
 any alternative candidates that were considered.
 
+With --explain, each candidate is listed with the signals that fired (files
+found, dependencies matched) and the points each contributed, along with
+providers that found some signals but did not match.
+
 Examples:
   dockerizer detect .
   dockerizer detect ./my-project
-  dockerizer detect --json ./my-project`,
+  dockerizer detect --json ./my-project
+  dockerizer detect --explain ./my-project`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runDetect,
 }
 
 func init() {
 	detectCmd.Flags().Bool("all", false, "Show all candidates, not just the best match")
+	detectCmd.Flags().Bool("explain", false, "Show the signals behind each candidate's score")
 }
 
 func runDetect(cmd *cobra.Command, args []string) error {
@@ -58,6 +69,7 @@ func runDetect(cmd *cobra.Command, args []string) error {
 	}
 
 	showAll, _ := cmd.Flags().GetBool("all")
+	explain, _ := cmd.Flags().GetBool("explain")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
@@ -81,13 +93,19 @@ func runDetect(cmd *cobra.Command, args []string) error {
 
 	// Output
 	if jsonOut {
-		return outputDetectJSON(result, showAll)
+		return outputDetectJSON(result, &scan.Stats, showAll, explain)
 	}
 
-	return outputDetectText(result, showAll)
+	if err := outputDetectText(result, showAll && !explain); err != nil {
+		return err
+	}
+	if explain {
+		outputDetectExplanation(result, &scan.Stats)
+	}
+	return nil
 }
 
-func outputDetectJSON(result *detector.DetectionResult, showAll bool) error {
+func outputDetectJSON(result *detector.DetectionResult, stats *scanner.ScanStats, showAll, explain bool) error {
 	output := DetectionOutput{
 		Detected:   result.Detected,
 		Language:   result.Language,
@@ -96,13 +114,27 @@ func outputDetectJSON(result *detector.DetectionResult, showAll bool) error {
 		Confidence: result.Confidence,
 		Provider:   result.Provider,
 		Variables:  result.Variables,
+		Hints:      result.Hints,
 	}
 
-	if showAll {
+	if showAll || explain {
 		for _, c := range result.Candidates {
-			output.Candidates = append(output.Candidates, CandidateOutput{
+			candidate := CandidateOutput{
 				Provider:   c.Provider,
 				Confidence: c.Confidence,
+			}
+			if explain {
+				candidate.Signals = c.Signals
+			}
+			output.Candidates = append(output.Candidates, candidate)
+		}
+	}
+	if explain {
+		output.Scan = stats
+		for _, c := range result.Rejected {
+			output.Rejected = append(output.Rejected, CandidateOutput{
+				Provider: c.Provider,
+				Signals:  c.Signals,
 			})
 		}
 	}
@@ -116,6 +148,10 @@ func outputDetectText(result *detector.DetectionResult, showAll bool) error {
 	if !result.Detected {
 		printInfo("No stack detected")
 		printInfo("")
+		if len(result.Hints) > 0 {
+			printHints(result.Hints)
+			return nil
+		}
 		printInfo("Possible reasons:")
 		printInfo("  - Unrecognized project structure")
 		printInfo("  - Missing package files (package.json, go.mod, etc.)")
@@ -167,3 +203,88 @@ func outputDetectText(result *detector.DetectionResult, showAll bool) error {
 
 	return nil
 }
+
+// outputDetectExplanation prints the signals behind every candidate's score,
+// and those found by providers that did not match
+func outputDetectExplanation(result *detector.DetectionResult, stats *scanner.ScanStats) {
+	if len(result.Candidates) > 0 {
+		fmt.Println("  Score breakdown:")
+		for i, c := range result.Candidates {
+			marker := " "
+			if i == 0 {
+				marker = "→"
+			}
+			fmt.Printf("  %s %s (%d%%)\n", marker, c.Provider, c.Confidence)
+			printSignals(c.Signals)
+			if total := c.SignalTotal(); total > c.Confidence {
+				fmt.Printf("        capped at %d (signals total %d)\n", c.Confidence, total)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(result.Rejected) > 0 {
+		fmt.Println("  Not matched (some signals found):")
+		for _, c := range result.Rejected {
+			fmt.Printf("    %s\n", c.Provider)
+			printSignals(c.Signals)
+		}
+		fmt.Println()
+	}
+
+	printScanStats(stats)
+}
+
+// printScanStats summarizes what the scan left out, since a file it skipped
+// can't count as a signal
+func printScanStats(stats *scanner.ScanStats) {
+	fmt.Println("  Scan:")
+	fmt.Printf("    %d files, %d directories, %d KB read\n", stats.Files, stats.Dirs, stats.BytesRead/1024)
+	if stats.Ignored > 0 {
+		fmt.Printf("    %d ignored (built-in list, .gitignore, .dockerizerignore)\n", stats.Ignored)
+		for _, p := range stats.IgnoredPaths {
+			fmt.Printf("      %s\n", p)
+		}
+	}
+	if stats.Hidden > 0 {
+		fmt.Printf("    %d hidden files or directories skipped\n", stats.Hidden)
+	}
+	if stats.TooDeep > 0 {
+		fmt.Printf("    %d entries below the depth limit\n", stats.TooDeep)
+	}
+	for _, p := range stats.TooLarge {
+		fmt.Printf("    not read, over the size limit: %s\n", p)
+	}
+	if stats.Symlinks > 0 {
+		fmt.Printf("    %d symlinks skipped (broken, outside the project, or to a directory)\n", stats.Symlinks)
+	}
+	if stats.SymlinkCycles > 0 {
+		fmt.Printf("    %d symlink cycles skipped\n", stats.SymlinkCycles)
+	}
+	if stats.Truncated {
+		fmt.Println("    file limit reached; the rest of the tree was not scanned")
+	}
+	if stats.BudgetExhausted {
+		fmt.Println("    read budget used up; some files were not read")
+	}
+	fmt.Println()
+}
+
+func printSignals(signals []providers.Signal) {
+	for _, s := range signals {
+		fmt.Printf("      %+4d  %s\n", s.Points, s.Reason)
+	}
+}
+
+// printHints prints nearest-provider guidance for undetected stacks
+func printHints(hints []detector.Hint) {
+	printInfo("Closest matches:")
+	for _, h := range hints {
+		label := h.Language
+		if h.SuspectedStack != "" {
+			label = fmt.Sprintf("%s (%s)", h.Language, h.SuspectedStack)
+		}
+		printInfo("  %s: %s", label, h.Message)
+	}
+	printInfo("")
+}
@@ -2,24 +2,24 @@ package cli
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/dublyo/dockerizer/internal/ai"
+	"github.com/dublyo/dockerizer/internal/config"
 	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
 	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/internal/imagesize"
+	"github.com/dublyo/dockerizer/internal/registry"
 	"github.com/dublyo/dockerizer/internal/scanner"
-	"github.com/dublyo/dockerizer/providers/dotnet"
-	"github.com/dublyo/dockerizer/providers/elixir"
-	"github.com/dublyo/dockerizer/providers/golang"
-	"github.com/dublyo/dockerizer/providers/java"
-	"github.com/dublyo/dockerizer/providers/nodejs"
-	"github.com/dublyo/dockerizer/providers/php"
-	"github.com/dublyo/dockerizer/providers/python"
-	"github.com/dublyo/dockerizer/providers/ruby"
-	"github.com/dublyo/dockerizer/providers/rust"
+	"github.com/dublyo/dockerizer/providers/all"
 )
 
 // DockerizeResult is the JSON output structure
@@ -30,56 +30,448 @@ type DockerizeResult struct {
 	Version    string   `json:"version,omitempty"`
 	Confidence int      `json:"confidence,omitempty"`
 	Files      []string `json:"files,omitempty"`
+	Backups    []string `json:"backups,omitempty"`     // With --backup
+	BaseReason string   `json:"base_reason,omitempty"` // Why the images are Debian ones, with the auto base
+	Warnings   []string `json:"warnings,omitempty"`
 	Error      string   `json:"error,omitempty"`
+
+	// With --dry-run: the generated files by name, none of them written
+	DryRun   bool              `json:"dry_run,omitempty"`
+	Contents map[string]string `json:"contents,omitempty"`
+
+	Usage []ai.Usage `json:"usage,omitempty"`
+
+	Size *imagesize.Report `json:"size,omitempty"` // With --size
+
+	Check *ValidationOutput `json:"check,omitempty"` // docker compose config's findings, and the Dockerfile's validation with --check
+
+	Hints []detector.Hint `json:"hints,omitempty"`
+}
+
+// projectGeneratorOptions returns generator options from the project's
+// .dockerizer.yml; a non-empty flag value takes precedence. Compose
+// overrides are generated for envs, or else for the configured environments.
+func projectGeneratorOptions(path, goBaseImage, proxy, windows, gpu, javaRuntime string, envs []string) []generator.Option {
+	cfg := projectConfig(path)
+	if goBaseImage == "" {
+		goBaseImage = cfg.Providers.Go.BaseImage
+	}
+	if proxy == "" {
+		proxy = cfg.Defaults.Proxy
+	}
+
+	var opts []generator.Option
+	if goBaseImage != "" {
+		opts = append(opts, generator.WithGoBaseImage(goBaseImage))
+	}
+	if proxy != "" {
+		opts = append(opts, generator.WithProxy(proxy))
+	}
+	if windows != "" || cfg.Providers.Dotnet.WindowsVersion != "" {
+		opts = append(opts, generator.WithWindows(windows, cfg.Providers.Dotnet.WindowsVersion))
+	}
+	if cfg.Providers.Dotnet.Windows != "" {
+		opts = append(opts, generator.WithDefaultWindows(cfg.Providers.Dotnet.Windows))
+	}
+	if gpu != "" || cfg.Providers.Python.CUDAVersion != "" {
+		opts = append(opts, generator.WithGPU(gpu, cfg.Providers.Python.CUDAVersion))
+	}
+	if cfg.Providers.Python.GPU != "" {
+		opts = append(opts, generator.WithDefaultGPU(cfg.Providers.Python.GPU))
+	}
+	if javaRuntime != "" {
+		opts = append(opts, generator.WithJavaRuntime(javaRuntime))
+	}
+	if cfg.Providers.Java.Runtime != "" {
+		opts = append(opts, generator.WithDefaultJavaRuntime(cfg.Providers.Java.Runtime))
+	}
+	if cfg.Providers.Rust.CargoChef != "" {
+		opts = append(opts, generator.WithCargoChef(cfg.Providers.Rust.CargoChef))
+	}
+	if cfg.Providers.NodeJS.StaticServer != "" {
+		opts = append(opts, generator.WithStaticServer(cfg.Providers.NodeJS.StaticServer))
+	}
+	if cfg.Providers.NodeJS.BasePath != "" {
+		opts = append(opts, generator.WithBasePath(cfg.Providers.NodeJS.BasePath))
+	}
+	if cfg.Defaults.Base != "" {
+		opts = append(opts, generator.WithBase(cfg.Defaults.Base))
+	}
+	if cfg.Defaults.User != "" {
+		opts = append(opts, generator.WithUser(cfg.Defaults.User))
+	}
+	if cfg.Defaults.Harden {
+		opts = append(opts, generator.WithHarden(true))
+	}
+	if cfg.Defaults.WithTests {
+		opts = append(opts, generator.WithTests(true))
+	}
+	if cfg.Defaults.DockerfilePath != "" {
+		opts = append(opts, generator.WithDockerfilePath(cfg.Defaults.DockerfilePath))
+	}
+	if cfg.Defaults.ComposePath != "" {
+		opts = append(opts, generator.WithComposePath(cfg.Defaults.ComposePath))
+	}
+	if cfg.Defaults.Banner != "" {
+		opts = append(opts, generator.WithBanner(cfg.Defaults.Banner))
+	}
+	if cfg.Defaults.Provenance == nil || *cfg.Defaults.Provenance {
+		opts = append(opts, generator.WithProvenance(Version, cfg.Defaults.ProvenanceTime))
+	}
+	if len(cfg.Dockerfile.BaseImages) > 0 || len(cfg.Dockerfile.Packages) > 0 {
+		opts = append(opts, generator.WithPreserved(generator.Preserved{
+			BaseImages: cfg.Dockerfile.BaseImages,
+			Packages:   cfg.Dockerfile.Packages,
+		}))
+	}
+	if len(cfg.Setup) > 0 {
+		jobs := make([]generator.SetupJob, 0, len(cfg.Setup))
+		for _, job := range cfg.Setup {
+			jobs = append(jobs, generator.SetupJob{Name: job.Name, Command: job.Command, Target: job.Target})
+		}
+		opts = append(opts, generator.WithSetupJobs(jobs))
+	}
+	if len(cfg.Matrix) > 0 {
+		names := make([]string, 0, len(cfg.Matrix))
+		for name := range cfg.Matrix {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		profiles := make([]generator.MatrixProfile, 0, len(names))
+		for _, name := range names {
+			c := cfg.Matrix[name]
+			profile := generator.MatrixProfile{Name: name, Platform: c.Platform, Options: []generator.Option{generator.WithDev(c.Dev)}}
+			if c.Base != "" {
+				profile.Options = append(profile.Options, generator.WithBase(c.Base))
+			}
+			if c.User != "" {
+				profile.Options = append(profile.Options, generator.WithUser(c.User))
+			}
+			if c.CacheMounts != nil {
+				profile.Options = append(profile.Options, generator.WithCacheMounts(*c.CacheMounts))
+			}
+			profiles = append(profiles, profile)
+		}
+		opts = append(opts, generator.WithMatrix(profiles))
+	}
+
+	if len(envs) == 0 {
+		for name := range cfg.Environments {
+			envs = append(envs, name)
+		}
+		sort.Strings(envs)
+	}
+	if len(envs) > 0 {
+		environments := make([]generator.Environment, 0, len(envs))
+		for _, name := range envs {
+			c := cfg.Environments[name]
+			environments = append(environments, generator.Environment{
+				Name:              name,
+				Restart:           c.Restart,
+				MemoryLimit:       c.MemoryLimit,
+				MemoryReservation: c.MemoryReservation,
+				CPUs:              c.CPUs,
+				DebugPort:         c.DebugPort,
+				MountSource:       c.MountSource,
+				Env:               c.Env,
+			})
+		}
+		opts = append(opts, generator.WithEnvironments(environments))
+	}
+	return opts
+}
+
+// digestResolver resolves base image tags to digests on their registries
+func digestResolver(ctx context.Context) func(image string) (string, error) {
+	client := registry.New()
+	return func(image string) (string, error) {
+		printVerbose("Resolving digest of %s...", image)
+		return client.Digest(ctx, image)
+	}
+}
+
+// useCacheMounts decides whether Dockerfiles get BuildKit cache mounts: the
+// --cache-mounts flag, else defaults.cache_mounts, else whether BuildKit is
+// available
+func useCacheMounts(path string, flag *bool) bool {
+	if flag != nil {
+		return *flag
+	}
+	if configured := projectConfig(path).Defaults.CacheMounts; configured != nil {
+		return *configured
+	}
+	enabled := buildKitAvailable()
+	printVerbose("BuildKit available: %v", enabled)
+	return enabled
+}
+
+// buildKitAvailable reports whether docker builds with BuildKit: as
+// DOCKER_BUILDKIT says, else when the docker CLI has the buildx plugin (the
+// default builder since Docker 23)
+func buildKitAvailable() bool {
+	switch strings.ToLower(os.Getenv("DOCKER_BUILDKIT")) {
+	case "0", "false":
+		return false
+	case "1", "true":
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return exec.CommandContext(ctx, "docker", "buildx", "version").Run() == nil
+}
+
+// dockerizeOptions are the generation settings of a dockerize run
+type dockerizeOptions struct {
+	outputDir      string // Empty means the project directory
+	app            string // Workspace package
+	target         string // Nx, Gradle or Bazel project
+	goBaseImage    string
+	base           string // Base image family: auto, alpine or debian
+	user           string // UID[:GID] the images run as
+	javaRuntime    string
+	proxy          string
+	windows        string // Windows container base for .NET apps
+	gpu            string // GPU mode for Python ML apps
+	envs           []string
+	dockerfilePath string // Relative to the output directory
+	composePath    string // Relative to the output directory
+	forceAI        bool
+	overwrite      bool
+	backup         bool // Save overwritten files as .bak
+	pinDigests     bool
+	size           bool  // Estimate the image size after generating
+	check          bool  // Validate the generated Dockerfile, with BuildKit's checks
+	dev            bool  // docker compose watch rules and dev servers
+	harden         bool  // Read-only root filesystem and dropped capabilities in compose
+	withTests      bool  // A test stage running the test suite
+	withDeps       bool  // Object stores and message brokers in compose
+	cacheMounts    *bool // Nil: configured, else when BuildKit is available
+	dryRun         bool  // Print the files instead of writing them
+
+	includeDockerfile bool
+	includeCompose    bool
+	includeIgnore     bool
+	includeEnv        bool
+	includeDocs       bool // DOCKER.md
+}
+
+// generationTargets are the --only names of the generated files
+var generationTargets = []string{"dockerfile", "compose", "ignore", "env", "docs"}
+
+// selectOnly generates just the named files (see generationTargets)
+func (o *dockerizeOptions) selectOnly(names []string) error {
+	o.includeDockerfile, o.includeCompose, o.includeIgnore, o.includeEnv, o.includeDocs = false, false, false, false, false
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "dockerfile":
+			o.includeDockerfile = true
+		case "compose":
+			o.includeCompose = true
+		case "ignore", "dockerignore":
+			o.includeIgnore = true
+		case "env":
+			o.includeEnv = true
+		case "docs":
+			o.includeDocs = true
+		default:
+			return fmt.Errorf("unknown --only target %q (use %s)", name, strings.Join(generationTargets, ", "))
+		}
+	}
+	return nil
 }
 
 // executeDockerize runs the full dockerizer workflow
-func executeDockerize(path, outputDir string, forceAI, overwrite, includeCompose, includeIgnore, includeEnv bool) error {
+func executeDockerize(path string, opts dockerizeOptions) (err error) {
+	outputDir := opts.outputDir
+	var result *detector.DetectionResult
+	var output *generator.Output
+	defer func(start time.Time) {
+		if !opts.dryRun { // Previews are not runs
+			recordRun("dockerize", path, start, result, output != nil && len(output.Usage) > 0, err)
+		}
+	}(time.Now())
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
+	// Ctrl+C cancels an in-flight AI generation
+	ctx, stop := interruptible(ctx)
+	defer stop()
 
 	// Step 1: Scan the repository
 	printInfo("Scanning %s...", path)
-	scan, err := scanner.New().Scan(ctx, path)
+	prog := newProgress()
+	prog.begin("scan")
+	scan, err := scanner.New(scanner.WithRedaction(!noRedact), prog.scanOption()).Scan(ctx, path)
+	prog.end()
 	if err != nil {
 		return outputError("scan failed", err)
 	}
 	printVerbose("Found %d files in %d directories", len(scan.FileTree.Files), len(scan.FileTree.Dirs))
 
+	// In a monorepo, detect the selected package and build it from the root
+	ws := scan.Metadata.Workspace
+	rootPkg := scan.Metadata.PackageJSON
+	var wsPkg *scanner.WorkspacePackage
+	if ws != nil {
+		wsPkg, err = detector.SelectWorkspaceApp(ws, opts.app)
+		if err != nil {
+			return outputError("workspace", err)
+		}
+	} else if opts.app != "" {
+		return outputError("workspace", fmt.Errorf("--app needs a pnpm, yarn, npm or bun workspace in %s", path))
+	}
+	// In an Nx, Gradle or Bazel repository, build the selected project
+	orch := scan.Metadata.Orchestrator
+	var target *scanner.BuildProject
+	if orch != nil && ws == nil {
+		target, err = detector.SelectBuildTarget(orch, opts.target)
+		if err != nil {
+			return outputError("build target", err)
+		}
+	} else if opts.target != "" {
+		return outputError("build target", fmt.Errorf("--target needs an Nx, Gradle or Bazel build in %s", path))
+	}
+	rootScan := scan
+	appDir := path
+	if wsPkg != nil {
+		printInfo("Workspace package: %s (%s)", wsPkg.Name, wsPkg.Dir)
+		appDir = filepath.Join(path, wsPkg.Dir)
+	} else if target != nil {
+		printInfo("Build target: %s (%s, %s)", target.Name, orch.Tool, target.Dir)
+		appDir = filepath.Join(path, target.Dir)
+	}
+	if appDir != path {
+		prog.begin("scan")
+		scan, err = scanner.New(scanner.WithRedaction(!noRedact), prog.scanOption()).Scan(ctx, appDir)
+		prog.end()
+		if err != nil {
+			return outputError("scan failed", err)
+		}
+	}
+	if outputDir == "" {
+		outputDir = appDir
+	}
+
 	// Step 2: Detect the stack
 	printInfo("Detecting stack...")
 	registry := setupRegistry()
-	det := detector.New(registry)
-	result, err := det.Detect(ctx, scan)
+	det := detector.New(registry, prog.detectOption())
+	prog.begin("detect")
+	result, err = det.Detect(ctx, scan)
+	prog.end()
 	if err != nil {
 		return outputError("detection failed", err)
 	}
+	if wsPkg != nil && result.Detected {
+		detector.ApplyWorkspace(result, ws, wsPkg, rootPkg)
+	}
+	if target != nil {
+		if err := detector.ApplyBuildTarget(result, rootScan, orch, target); err != nil {
+			return outputError("build target", err)
+		}
+	}
 
 	// Configure generator options
 	genOpts := []generator.Option{
-		generator.WithOverwrite(overwrite),
-		generator.WithCompose(includeCompose),
-		generator.WithIgnore(includeIgnore),
-		generator.WithEnv(includeEnv),
+		generator.WithOverwrite(opts.overwrite),
+		generator.WithBackup(opts.backup),
+		generator.WithDockerfile(opts.includeDockerfile),
+		generator.WithCompose(opts.includeCompose),
+		generator.WithIgnore(opts.includeIgnore),
+		generator.WithEnv(opts.includeEnv),
+		generator.WithDocs(opts.includeDocs),
+	}
+	genOpts = append(genOpts, projectGeneratorOptions(path, opts.goBaseImage, opts.proxy, opts.windows, opts.gpu, opts.javaRuntime, opts.envs)...)
+	if opts.base != "" {
+		genOpts = append(genOpts, generator.WithBase(opts.base))
+	}
+	if opts.user != "" {
+		genOpts = append(genOpts, generator.WithUser(opts.user))
+	}
+	if opts.dockerfilePath != "" {
+		genOpts = append(genOpts, generator.WithDockerfilePath(opts.dockerfilePath))
+	}
+	if opts.composePath != "" {
+		genOpts = append(genOpts, generator.WithComposePath(opts.composePath))
+	}
+	genOpts = append(genOpts, generator.WithExistingNames(outputDir))
+	if opts.overwrite && opts.includeDockerfile && !opts.dryRun {
+		warnHandWritten(path, dockerfileIn(outputDir))
+	}
+	if sub, ok := outputSubdir(appDir, outputDir); ok {
+		genOpts = append(genOpts, generator.WithOutputSubdir(sub))
+	} else {
+		printInfo("⚠ %s is outside %s: docker-compose.yml builds from its own directory; adjust its build context", outputDir, appDir)
+	}
+	genOpts = append(genOpts, generator.WithCacheMounts(useCacheMounts(path, opts.cacheMounts)))
+	if opts.dev {
+		genOpts = append(genOpts, generator.WithDev(true))
+	}
+	if opts.harden {
+		genOpts = append(genOpts, generator.WithHarden(true))
+	}
+	if opts.withTests {
+		genOpts = append(genOpts, generator.WithTests(true))
+	}
+	if services, _ := result.Variables["backingServices"].([]detector.BackingService); len(services) > 0 {
+		if opts.withDeps {
+			genOpts = append(genOpts, generator.WithDeps(true))
+		} else if opts.includeCompose {
+			printInfo("Found %s: add --with-deps to run %s next to the app in docker-compose.yml", backingSDKs(services), backingNames(services))
+		}
+	}
+	if opts.pinDigests || projectConfig(path).Defaults.PinDigests {
+		if err := requireNetwork("pinning base image digests"); err != nil {
+			return outputError("pin digests", err)
+		}
+		genOpts = append(genOpts, generator.WithDigestResolver(digestResolver(ctx)))
 	}
 
 	// Setup AI provider for fallback if needed
 	var aiProvider ai.Provider
-	useAI := !result.Detected || result.Confidence < 80 || forceAI
+	useAI := !result.Detected || result.Confidence < 80 || opts.forceAI
+	if opts.forceAI {
+		if err := requireNetwork("--ai"); err != nil {
+			return outputError("AI generation", err)
+		}
+	}
+	if useAI && offline {
+		printVerbose("Offline: skipping AI providers")
+		useAI = false
+	}
 
 	if useAI {
-		aiProvider = getAIProvider()
+		aiProvider = getAIProvider(projectConfig(path).AI)
 		if aiProvider != nil {
-			genOpts = append(genOpts, generator.WithAIProvider(aiProvider))
+			genOpts = append(genOpts, generator.WithAIProvider(aiProvider), generator.WithAIStream(newStreamFunc()))
+			warnRedactions(scan)
 		}
 	}
 
 	// If no stack detected and no AI available, fail
 	if !result.Detected {
 		if aiProvider == nil {
+			if offline {
+				return outputError("no stack detected", requireNetwork("AI generation"))
+			}
+			if jsonOut {
+				_ = outputJSON(DockerizeResult{
+					Success: false,
+					Error:   "no stack detected: could not identify the project type",
+					Hints:   result.Hints,
+				})
+				return fmt.Errorf("no stack detected")
+			}
 			printInfo("")
 			printInfo("Could not detect project stack.")
 			printInfo("")
+			if len(result.Hints) > 0 {
+				printHints(result.Hints)
+				printInfo("To use AI-powered detection:")
+				printInfo("  1. Set ANTHROPIC_API_KEY or OPENAI_API_KEY, run dockerizer auth login, or run Ollama locally")
+				printInfo("  2. Run with --ai flag: dockerizer --ai %s", path)
+				return outputError("no stack detected", fmt.Errorf("no provider matched; see hints above"))
+			}
 			printInfo("Common indicators looked for:")
 			printInfo("  Node.js:  package.json")
 			printInfo("  Python:   requirements.txt, pyproject.toml, setup.py")
@@ -92,7 +484,7 @@ func executeDockerize(path, outputDir string, forceAI, overwrite, includeCompose
 			printInfo("  Elixir:   mix.exs")
 			printInfo("")
 			printInfo("To use AI-powered detection:")
-			printInfo("  1. Set ANTHROPIC_API_KEY, OPENAI_API_KEY, or run Ollama locally")
+			printInfo("  1. Set ANTHROPIC_API_KEY or OPENAI_API_KEY, run dockerizer auth login, or run Ollama locally")
 			printInfo("  2. Run with --ai flag: dockerizer --ai %s", path)
 			return outputError("no stack detected", fmt.Errorf("could not identify the project type; ensure project files exist or use --ai with an API key"))
 		}
@@ -109,17 +501,45 @@ func executeDockerize(path, outputDir string, forceAI, overwrite, includeCompose
 
 	gen := generator.New(genOpts...)
 
-	// Use AI generation if stack not detected or confidence is low
-	var output *generator.Output
+	// Use AI generation if stack not detected or confidence is low; an
+	// empty path renders without writing
+	writeDir := outputDir
+	if opts.dryRun {
+		writeDir = ""
+	}
+	prog.begin("generate")
 	if useAI && aiProvider != nil {
-		output, err = gen.GenerateWithAIFallback(ctx, result, scan, outputDir)
+		output, err = gen.GenerateWithAIFallback(ctx, result, scan, writeDir)
+		reportRequests(aiProvider)
 	} else {
-		output, err = gen.Generate(result, outputDir)
+		output, err = gen.Generate(result, writeDir)
 	}
+	prog.end()
 
 	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return outputError("generation cancelled", ctx.Err())
+		}
 		return outputError("generation failed", err)
 	}
+	recordUsage("dockerize", output.Usage)
+	if output.BaseReason != "" {
+		printInfo("Using Debian base images, as dependencies need glibc: %s (--base alpine keeps Alpine)", output.BaseReason)
+	}
+	var size *imagesize.Report
+	if opts.size && output.Dockerfile != "" {
+		size = imagesize.Estimate(dockerfile.Parse(output.Dockerfile), imagesize.Options{Scan: scan, BaseSize: baseSizer(ctx, "")})
+	}
+	// Verify what was written: the compose file with docker compose config,
+	// when it is available, and the Dockerfile with --check
+	var checkDockerfile, checkCompose string
+	if opts.check {
+		checkDockerfile = output.Dockerfile
+	}
+	if !opts.dryRun && output.ComposeFile != "" {
+		checkCompose = filepath.Join(outputDir, output.ComposeFile)
+	}
+	check := verify(ctx, checkDockerfile, opts.check, checkCompose)
 
 	// Output results
 	if jsonOut {
@@ -127,14 +547,28 @@ func executeDockerize(path, outputDir string, forceAI, overwrite, includeCompose
 		for f := range output.Files {
 			files = append(files, f)
 		}
-		return outputJSON(DockerizeResult{
+		res := DockerizeResult{
 			Success:    true,
 			Language:   result.Language,
 			Framework:  result.Framework,
 			Version:    result.Version,
 			Confidence: result.Confidence,
 			Files:      files,
-		})
+			Backups:    output.Backups,
+			BaseReason: output.BaseReason,
+			Warnings:   output.Warnings,
+			Usage:      output.Usage,
+			Size:       size,
+			Check:      check,
+		}
+		if opts.dryRun {
+			res.DryRun, res.Contents = true, output.Contents()
+		}
+		return outputJSON(res)
+	}
+	if opts.dryRun {
+		printDryRun(output)
+		return nil
 	}
 
 	// Print generated files
@@ -142,6 +576,23 @@ func executeDockerize(path, outputDir string, forceAI, overwrite, includeCompose
 	for filename := range output.Files {
 		printInfo("  - %s", filename)
 	}
+	for _, b := range output.Backups {
+		printInfo("Saved the previous %s as %s", strings.TrimSuffix(b, ".bak"), b)
+	}
+	for _, w := range output.Warnings {
+		printInfo("⚠ %s", w)
+	}
+	if size != nil {
+		printInfo("")
+		printInfo("Estimated image size: about %s (compressed; dockerizer size for the breakdown)", imagesize.Format(size.Total))
+		for _, s := range size.Suggestions {
+			printInfo("  - %s", s)
+		}
+	}
+	if check != nil {
+		printCheck(check)
+	}
+	prog.summary()
 
 	// Print next steps
 	printInfo("")
@@ -149,27 +600,72 @@ func executeDockerize(path, outputDir string, forceAI, overwrite, includeCompose
 	printInfo("  1. Review the generated Dockerfile")
 	printInfo("  2. Update .env.example with your values")
 	printInfo("  3. Build: docker compose build")
-	printInfo("  4. Run: docker compose up")
+	if opts.dev {
+		printInfo("  4. Develop: docker compose watch")
+	} else {
+		printInfo("  4. Run: docker compose up")
+	}
+	if len(output.BuildSecrets) > 0 {
+		printInfo("")
+		printInfo("Private registry credentials are passed as build secrets (compose mounts them):")
+		printInfo("  docker build %s .", strings.Join(output.BuildSecrets, " "))
+	}
 
 	return nil
 }
 
-// setupRegistry creates and configures the provider registry
-func setupRegistry() *detector.Registry {
-	registry := detector.NewRegistry()
+// outputSubdir returns the output directory relative to the project
+// directory ("" when they are the same); ok is false when it lies outside
+func outputSubdir(appDir, outputDir string) (sub string, ok bool) {
+	absApp, err1 := filepath.Abs(appDir)
+	absOut, err2 := filepath.Abs(outputDir)
+	if err1 != nil || err2 != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(absApp, absOut)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
 
-	// Register all providers
-	nodejs.RegisterAll(registry)
-	python.RegisterAll(registry)
-	golang.RegisterAll(registry)
-	rust.RegisterAll(registry)
-	ruby.RegisterAll(registry)
-	php.RegisterAll(registry)
-	java.RegisterAll(registry)
-	dotnet.RegisterAll(registry)
-	elixir.RegisterAll(registry)
+// printDryRun prints every generated file under a header naming it, with
+// warnings on stderr so stdout holds only the files
+func printDryRun(output *generator.Output) {
+	names := make([]string, 0, len(output.Files))
+	for name := range output.Files {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// Dockerfile (or Containerfile) first, the rest by name
+		if first := slices.Contains(generator.DockerfileNames, names[i]); first != slices.Contains(generator.DockerfileNames, names[j]) {
+			return first
+		}
+		return names[i] < names[j]
+	})
 
-	return registry
+	for i, name := range names {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("==> %s <==\n", name)
+		content := output.Files[name].String()
+		fmt.Print(content)
+		if !strings.HasSuffix(content, "\n") {
+			fmt.Println()
+		}
+	}
+	for _, w := range output.Warnings {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", w)
+	}
+}
+
+// setupRegistry creates and configures the provider registry
+func setupRegistry() *detector.Registry {
+	return all.NewRegistry()
 }
 
 // outputError handles error output
@@ -187,53 +683,193 @@ func outputError(context string, err error) error {
 
 // outputJSON prints JSON output
 func outputJSON(result DockerizeResult) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(result)
+	return printJSON(result)
+}
+
+// dockerfileIn is the Dockerfile of a directory, or its Containerfile when
+// it has no Dockerfile
+func dockerfileIn(dir string) string {
+	if name := generator.ExistingFile(dir, generator.DockerfileNames...); name != "" {
+		return filepath.Join(dir, name)
+	}
+	return filepath.Join(dir, "Dockerfile")
 }
 
-// getAIProvider creates an AI provider from environment variables
-func getAIProvider() ai.Provider {
-	// Try Anthropic first
-	if apiKey := os.Getenv("ANTHROPIC_API_KEY"); apiKey != "" {
-		model := os.Getenv("ANTHROPIC_MODEL")
-		if model == "" {
-			model = "claude-3-5-haiku-20241022"
+// warnHandWritten points to dockerizer import before a Dockerfile that
+// dockerizer didn't generate is overwritten, unless its choices are recorded
+func warnHandWritten(path, file string) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return
+	}
+	if _, ok := generator.ParseProvenance(string(content)); ok {
+		return
+	}
+	cfg := projectConfig(path)
+	if len(cfg.Dockerfile.BaseImages) > 0 || len(cfg.Dockerfile.Packages) > 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "⚠ %s was not generated by dockerizer; run dockerizer import first to keep its base images and system packages\n", file)
+}
+
+// projectConfig returns the layered configuration for a project, or the
+// defaults when it does not load
+func projectConfig(path string) *config.Config {
+	cfg, err := config.LoadForProject(path)
+	if err != nil {
+		printVerbose("Ignoring config: %v", err)
+		cfg = config.DefaultConfig()
+	}
+	return cfg
+}
+
+// getAIProvider creates an AI provider from environment variables and the
+// ai section of the configuration.
+//
+// Providers are tried in the order given by DOCKERIZER_AI_PROVIDERS
+// (default "anthropic,openai,ollama", with the configured ai.provider
+// first); when more than one is available they are combined into a failover
+// chain. Setting DOCKERIZER_AI_MODE=ab asks the first two available
+// providers and keeps the better-scoring result. With --offline it returns
+// nil without probing any provider.
+func getAIProvider(cfg config.AIConfig) ai.Provider {
+	if offline {
+		return nil
+	}
+	order := []string{"anthropic", "openai", "ollama"}
+	if cfg.Provider != "" {
+		order = append([]string{cfg.Provider}, slices.DeleteFunc(order, func(name string) bool { return name == cfg.Provider })...)
+	}
+	if list := os.Getenv("DOCKERIZER_AI_PROVIDERS"); list != "" {
+		order = nil
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				order = append(order, name)
+			}
+		}
+	}
+
+	var available []ai.Provider
+	for _, name := range order {
+		if provider := newAIProviderFromEnv(name, cfg); provider != nil {
+			available = append(available, provider)
 		}
+	}
+
+	switch {
+	case len(available) == 0:
+		return nil
+	case len(available) == 1:
+		return available[0]
+	case os.Getenv("DOCKERIZER_AI_MODE") == "ab":
+		printVerbose("Using A/B AI mode (%s vs %s)", available[0].Name(), available[1].Name())
+		return ai.NewABProvider(available[0], available[1], validationScore)
+	default:
+		chain := ai.NewChainProvider(available...)
+		printVerbose("Using AI provider chain: %s", chain.Name())
+		return chain
+	}
+}
+
+// requireAIProvider returns the AI provider for a feature that cannot run
+// without one, failing with --offline or when no provider is available
+func requireAIProvider(dir, feature string) (ai.Provider, error) {
+	if err := requireNetwork(feature); err != nil {
+		return nil, err
+	}
+	provider := getAIProvider(projectConfig(dir).AI)
+	if provider == nil {
+		return nil, fmt.Errorf("no AI provider available; set ANTHROPIC_API_KEY or OPENAI_API_KEY, or run Ollama")
+	}
+	return provider, nil
+}
+
+// newAIProviderFromEnv creates a single AI provider configured from environment variables.
+// Settings the environment leaves unset come from cfg when it configures the
+// same provider; API keys are also looked up in the OS secret store. It
+// returns nil if the provider is unknown or not available.
+func newAIProviderFromEnv(name string, cfg config.AIConfig) ai.Provider {
+	setting := func(env, configured, def string) string {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+		if cfg.Provider == name && configured != "" {
+			return configured
+		}
+		return def
+	}
+
+	switch name {
+	case "anthropic":
+		apiKey, _ := lookupAPIKey(name, cfg)
+		if apiKey == "" {
+			return nil
+		}
+		model := setting("ANTHROPIC_MODEL", cfg.Model, "claude-3-5-haiku-20241022")
 		provider := ai.NewAnthropicProvider(apiKey, model)
+		provider.SetHTTPConfig(aiHTTPConfig(cfg))
 		if provider.IsAvailable() {
 			printVerbose("Using Anthropic AI provider (model: %s)", model)
 			return provider
 		}
-	}
-
-	// Try OpenAI
-	if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
-		model := os.Getenv("OPENAI_MODEL")
-		if model == "" {
-			model = "gpt-4o-mini"
+	case "openai":
+		apiKey, _ := lookupAPIKey(name, cfg)
+		if apiKey == "" {
+			return nil
 		}
+		model := setting("OPENAI_MODEL", cfg.Model, "gpt-4o-mini")
 		provider := ai.NewOpenAIProvider(apiKey, model)
+		provider.SetHTTPConfig(aiHTTPConfig(cfg))
 		if provider.IsAvailable() {
 			printVerbose("Using OpenAI AI provider (model: %s)", model)
 			return provider
 		}
+	case "ollama":
+		baseURL := setting("OLLAMA_BASE_URL", cfg.BaseURL, "http://localhost:11434")
+		model := setting("OLLAMA_MODEL", cfg.Model, "llama3")
+		provider := ai.NewOllamaProvider(baseURL, model)
+		provider.SetHTTPConfig(aiHTTPConfig(cfg))
+		if provider.IsAvailable() {
+			printVerbose("Using Ollama AI provider (model: %s)", model)
+			return provider
+		}
+	default:
+		printVerbose("Ignoring unknown AI provider: %s", name)
 	}
 
-	// Try Ollama (local)
-	baseURL := os.Getenv("OLLAMA_BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:11434"
+	return nil
+}
+
+// warnRedactions tells the user which secrets were kept out of the files sent to the AI provider
+func warnRedactions(scan *scanner.ScanResult) {
+	if noRedact {
+		printInfo("⚠ Secret redaction is disabled: file contents are sent to the AI provider as-is")
+		return
+	}
+	if len(scan.Redactions) == 0 {
+		return
 	}
-	model := os.Getenv("OLLAMA_MODEL")
-	if model == "" {
-		model = "llama3"
+	printInfo("⚠ Redacted %d secret(s) from files sent to the AI provider:", len(scan.Redactions))
+	for _, r := range scan.Redactions {
+		printInfo("    %s:%d (%s)", r.Path, r.Line, r.Rule)
 	}
-	provider := ai.NewOllamaProvider(baseURL, model)
-	if provider.IsAvailable() {
-		printVerbose("Using Ollama AI provider (model: %s)", model)
-		return provider
+	printInfo("  Move secrets out of these files; use --no-redact to send them unredacted")
+}
+
+// backingSDKs lists the SDKs that use backing services, for a hint
+func backingSDKs(services []detector.BackingService) string {
+	var parts []string
+	for _, s := range services {
+		parts = append(parts, fmt.Sprintf("%s SDKs (%s)", s.Kind, strings.Join(s.SDKs, ", ")))
 	}
+	return strings.Join(parts, ", ")
+}
 
-	return nil
+// backingNames lists the compose services --with-deps would add
+func backingNames(services []detector.BackingService) string {
+	names := make([]string, len(services))
+	for i, s := range services {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
 }
@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// DriftOutput is the JSON output for drift command
+type DriftOutput struct {
+	Image    string              `json:"image"`
+	Drifted  bool                `json:"drifted"`
+	Expected *ImageRuntimeConfig `json:"expected"`
+	Actual   *ImageRuntimeConfig `json:"actual"`
+	Issues   []DriftIssue        `json:"issues,omitempty"`
+}
+
+// DriftIssue describes a single difference between the image and the generated config
+type DriftIssue struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ImageRuntimeConfig is the subset of an image's runtime config that dockerizer controls
+type ImageRuntimeConfig struct {
+	Env          map[string]string `json:"env,omitempty"`
+	ExposedPorts []string          `json:"exposed_ports,omitempty"`
+	Entrypoint   []string          `json:"entrypoint,omitempty"`
+	Cmd          []string          `json:"cmd,omitempty"`
+	User         string            `json:"user,omitempty"`
+}
+
+var driftCmd = &cobra.Command{
+	Use:   "drift <image> [path]",
+	Short: "Compare a built image against the generated configuration",
+	Long: `Inspect the runtime config of a built or pulled image and compare it with
+what dockerizer would generate for the project today.
+
+The following fields are compared against the final stage of the generated
+Dockerfile:
+  - ENV variables
+  - EXPOSE ports
+  - ENTRYPOINT and CMD
+  - USER
+
+Fields the generated Dockerfile does not set are inherited from the base
+image and are not reported. Drift usually means the Dockerfile was edited
+by hand or the image is stale; re-run dockerizer or rebuild before rolling
+out a new deployment.
+
+Examples:
+  dockerizer drift myapp:latest
+  dockerizer drift myapp:latest ./my-project
+  dockerizer drift --json registry.example.com/myapp:v1.2.0 .`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDrift,
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+}
+
+func runDrift(cmd *cobra.Command, args []string) error {
+	image := args[0]
+	path := "."
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
+
+	// Inspect the image
+	printVerbose("Inspecting image %s...", image)
+	actual, err := inspectImageConfig(ctx, image)
+	if err != nil {
+		printError("image inspection failed: %v", err)
+		return err
+	}
+
+	// Generate the expected configuration
+	printVerbose("Scanning %s...", path)
+	scan, err := scanner.New().Scan(ctx, path)
+	if err != nil {
+		printError("scan failed: %v", err)
+		return err
+	}
+
+	registry := setupRegistry()
+	result, err := detector.New(registry).Detect(ctx, scan)
+	if err != nil {
+		printError("detection failed: %v", err)
+		return err
+	}
+	if !result.Detected {
+		printError("no stack detected in %s", path)
+		return fmt.Errorf("no stack detected")
+	}
+
+	genOpts := append([]generator.Option{
+		generator.WithCompose(false),
+		generator.WithIgnore(false),
+		generator.WithEnv(false),
+	}, projectGeneratorOptions(path, "", "", "", "", "", nil)...)
+	output, err := generator.New(genOpts...).Generate(result, "")
+	if err != nil {
+		printError("generation failed: %v", err)
+		return err
+	}
+
+	expected := runtimeConfigFromDockerfile(output.Dockerfile)
+	issues := compareRuntimeConfig(expected, actual)
+
+	// Output
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(DriftOutput{
+			Image:    image,
+			Drifted:  len(issues) > 0,
+			Expected: expected,
+			Actual:   actual,
+			Issues:   issues,
+		}); err != nil {
+			return err
+		}
+	} else if len(issues) == 0 {
+		printSuccess("No drift detected for %s (%s/%s)", image, result.Language, result.Framework)
+	} else {
+		fmt.Printf("Drift detected for %s (%s/%s):\n", image, result.Language, result.Framework)
+		for _, issue := range issues {
+			fmt.Printf("  %s\n", issue.Field)
+			fmt.Printf("    expected: %s\n", issue.Expected)
+			fmt.Printf("    actual:   %s\n", issue.Actual)
+		}
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("image drift detected in %d field(s)", len(issues))
+	}
+
+	return nil
+}
+
+// inspectImageConfig reads the runtime config of a local image via docker inspect
+func inspectImageConfig(ctx context.Context, image string) (*ImageRuntimeConfig, error) {
+	cmd := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{json .Config}}", image)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("docker image inspect failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var raw struct {
+		Env          []string            `json:"Env"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		Entrypoint   []string            `json:"Entrypoint"`
+		Cmd          []string            `json:"Cmd"`
+		User         string              `json:"User"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse image config: %w", err)
+	}
+
+	cfg := &ImageRuntimeConfig{
+		Env:        make(map[string]string),
+		Entrypoint: raw.Entrypoint,
+		Cmd:        raw.Cmd,
+		User:       raw.User,
+	}
+	for _, kv := range raw.Env {
+		key, value, _ := strings.Cut(kv, "=")
+		cfg.Env[key] = value
+	}
+	for port := range raw.ExposedPorts {
+		cfg.ExposedPorts = append(cfg.ExposedPorts, port)
+	}
+	sort.Strings(cfg.ExposedPorts)
+
+	return cfg, nil
+}
+
+// runtimeConfigFromDockerfile extracts the runtime config the final stage of
+// a Dockerfile sets, over what it inherits from the earlier stages it is
+// built FROM
+func runtimeConfigFromDockerfile(content string) *ImageRuntimeConfig {
+	cfg := &ImageRuntimeConfig{Env: make(map[string]string)}
+	df := dockerfile.Parse(content)
+	var stages []*dockerfile.Stage
+	for s := df.FinalStage(); s != nil; s = df.BaseStage(s) {
+		stages = append([]*dockerfile.Stage{s}, stages...)
+	}
+
+	for _, stage := range stages {
+		cmdSet := false
+		for _, in := range stage.Instructions {
+			switch in.Cmd {
+			case "ENV":
+				for _, v := range in.Env() {
+					cfg.Env[v.Name] = v.Value
+				}
+			case "EXPOSE":
+				for _, port := range in.Args {
+					if !strings.Contains(port, "/") {
+						port += "/tcp"
+					}
+					cfg.ExposedPorts = append(cfg.ExposedPorts, port)
+				}
+			case "ENTRYPOINT":
+				cfg.Entrypoint = commandArgs(in)
+				// ENTRYPOINT resets a CMD inherited from the base, but not one
+				// set earlier in the same stage
+				if !cmdSet {
+					cfg.Cmd = nil
+				}
+			case "CMD":
+				cfg.Cmd = commandArgs(in)
+				cmdSet = true
+			case "USER":
+				cfg.User = in.Value
+			}
+		}
+	}
+
+	sort.Strings(cfg.ExposedPorts)
+	cfg.ExposedPorts = slices.Compact(cfg.ExposedPorts)
+	return cfg
+}
+
+// commandArgs returns the arguments of an exec-form CMD or ENTRYPOINT, or
+// the shell that runs a shell-form one
+func commandArgs(in *dockerfile.Instruction) []string {
+	if in.JSON {
+		return in.Args
+	}
+	return []string{"/bin/sh", "-c", in.Value}
+}
+
+// compareRuntimeConfig reports fields where the image differs from the generated config
+func compareRuntimeConfig(expected, actual *ImageRuntimeConfig) []DriftIssue {
+	var issues []DriftIssue
+
+	// ENV: only keys set by the Dockerfile are compared, base images add many more
+	keys := make([]string, 0, len(expected.Env))
+	for key := range expected.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		want := expected.Env[key]
+		got, ok := actual.Env[key]
+		switch {
+		case !ok:
+			issues = append(issues, DriftIssue{Field: "ENV " + key, Expected: want, Actual: "(unset)"})
+		case strings.Contains(want, "$"):
+			// Value references other variables; presence is all we can check
+		case got != want:
+			issues = append(issues, DriftIssue{Field: "ENV " + key, Expected: want, Actual: got})
+		}
+	}
+
+	if len(expected.ExposedPorts) > 0 && strings.Join(expected.ExposedPorts, " ") != strings.Join(actual.ExposedPorts, " ") {
+		issues = append(issues, DriftIssue{
+			Field:    "EXPOSE",
+			Expected: strings.Join(expected.ExposedPorts, " "),
+			Actual:   formatDriftList(actual.ExposedPorts),
+		})
+	}
+
+	if expected.Entrypoint != nil && !equalStrings(expected.Entrypoint, actual.Entrypoint) {
+		issues = append(issues, DriftIssue{
+			Field:    "ENTRYPOINT",
+			Expected: formatDriftCommand(expected.Entrypoint),
+			Actual:   formatDriftCommand(actual.Entrypoint),
+		})
+	}
+
+	if expected.Cmd != nil && !equalStrings(expected.Cmd, actual.Cmd) {
+		issues = append(issues, DriftIssue{
+			Field:    "CMD",
+			Expected: formatDriftCommand(expected.Cmd),
+			Actual:   formatDriftCommand(actual.Cmd),
+		})
+	}
+
+	if expected.User != "" && expected.User != actual.User {
+		issues = append(issues, DriftIssue{
+			Field:    "USER",
+			Expected: expected.User,
+			Actual:   formatDriftList([]string{actual.User}),
+		})
+	}
+
+	return issues
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatDriftCommand(parts []string) string {
+	if len(parts) == 0 {
+		return "(unset)"
+	}
+	data, _ := json.Marshal(parts)
+	return string(data)
+}
+
+func formatDriftList(values []string) string {
+	joined := strings.TrimSpace(strings.Join(values, " "))
+	if joined == "" {
+		return "(unset)"
+	}
+	return joined
+}
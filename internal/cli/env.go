@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// EnvCheckOutput is the JSON output of env check
+type EnvCheckOutput struct {
+	Success  bool            `json:"success"`
+	Source   string          `json:"source"`            // The env file checked, or "environment"
+	Required int             `json:"required"`          // Variables the app reads without a default
+	Missing  []EnvCheckIssue `json:"missing,omitempty"` // Required variables unset or empty
+	Unknown  []string        `json:"unknown,omitempty"` // Set in the env file, but neither read nor declared
+	Error    string          `json:"error,omitempty"`
+}
+
+// EnvCheckIssue is a required variable the checked environment lacks
+type EnvCheckIssue struct {
+	Name        string `json:"name"`
+	File        string `json:"file,omitempty"` // First file that reads it
+	Description string `json:"description,omitempty"`
+	Empty       bool   `json:"empty,omitempty"` // Set, but to an empty value
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Check environment variables against what the app reads",
+}
+
+var envCheckCmd = &cobra.Command{
+	Use:   "check [path]",
+	Short: "Report missing and unknown variables of a .env file or the environment",
+	Long: `Compare a .env file, or the environment of a CI job, with the variables
+the project reads and declares.
+
+A variable is required when the source reads it without an inline default,
+and neither docker-compose.yml nor the Dockerfile dockerizer generates sets
+it. Required variables that are unset or empty are reported as missing.
+Variables the env file sets that the source doesn't read, and that neither
+the generated nor the project's own .env.example declares, are reported as
+unknown; they are often misspelled or left over. With --from-env the process
+environment is checked instead, for missing variables only.
+
+The check only reports unless --strict is given, which fails it (exit code
+1) on any missing or unknown variable, as a CI gate.
+
+Examples:
+  dockerizer env check
+  dockerizer env check ./my-project --env-file .env.production
+  dockerizer env check --from-env --strict`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEnvCheck,
+}
+
+func init() {
+	envCheckCmd.Flags().String("env-file", "", "Env file to check (default: .env in the project directory)")
+	envCheckCmd.Flags().Bool("from-env", false, "Check the process environment instead of an env file")
+	envCheckCmd.Flags().Bool("strict", false, "Fail on missing or unknown variables")
+
+	envCmd.AddCommand(envCheckCmd)
+	rootCmd.AddCommand(envCmd)
+}
+
+func runEnvCheck(cmd *cobra.Command, args []string) (err error) {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	envFile, _ := cmd.Flags().GetString("env-file")
+	fromEnv, _ := cmd.Flags().GetBool("from-env")
+	strict, _ := cmd.Flags().GetBool("strict")
+	if fromEnv && envFile != "" {
+		return fmt.Errorf("--env-file and --from-env are exclusive")
+	}
+
+	output := &EnvCheckOutput{Source: "environment"}
+	defer func() {
+		if !jsonOut {
+			return
+		}
+		output.Success = err == nil
+		if err != nil {
+			output.Error = err.Error()
+		}
+		if jsonErr := printJSON(output); jsonErr != nil {
+			err = jsonErr
+		}
+	}()
+
+	// The variables to check against
+	var set map[string]string
+	if fromEnv {
+		set = make(map[string]string)
+		for _, kv := range os.Environ() {
+			name, value, _ := strings.Cut(kv, "=")
+			set[name] = value
+		}
+	} else {
+		if envFile == "" {
+			envFile = filepath.Join(path, ".env")
+		}
+		output.Source = envFile
+		if set, err = readEnvFile(envFile); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	scan, err := scanner.New().Scan(ctx, path)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	result, err := detector.New(setupRegistry()).Detect(ctx, scan)
+	if err != nil {
+		return fmt.Errorf("detection failed: %w", err)
+	}
+	if !result.Detected {
+		return fmt.Errorf("no stack detected in %s", path)
+	}
+	genOpts := append([]generator.Option{
+		generator.WithIgnore(false),
+		generator.WithDocs(false),
+	}, projectGeneratorOptions(path, "", "", "", "", "", nil)...)
+	generated, err := generator.New(genOpts...).Generate(result, "")
+	if err != nil {
+		return fmt.Errorf("generation failed: %w", err)
+	}
+
+	// What the image and compose file set needn't be in the env file
+	provided := runtimeConfigFromDockerfile(generated.Dockerfile).Env
+	for _, m := range composeEnvEntry.FindAllStringSubmatch(generated.DockerCompose, -1) {
+		provided[m[1]] = ""
+	}
+	known := make(map[string]bool)
+	for name := range provided {
+		known[name] = true
+	}
+	for _, name := range declaredEnv(generated.EnvExample) {
+		known[name] = true
+	}
+	if example, err := os.ReadFile(filepath.Join(path, ".env.example")); err == nil {
+		for _, name := range declaredEnv(string(example)) {
+			known[name] = true
+		}
+	}
+
+	envVars, _ := result.Variables["envVars"].([]scanner.EnvVar)
+	for _, v := range envVars {
+		known[v.Name] = true
+		if _, ok := provided[v.Name]; ok || v.Default != "" {
+			continue
+		}
+		output.Required++
+		if value, ok := set[v.Name]; !ok || value == "" {
+			output.Missing = append(output.Missing, EnvCheckIssue{Name: v.Name, File: v.File, Description: v.Description, Empty: ok})
+		}
+	}
+	if !fromEnv {
+		for name := range set {
+			if !known[name] {
+				output.Unknown = append(output.Unknown, name)
+			}
+		}
+		sort.Strings(output.Unknown)
+	}
+	sort.Slice(output.Missing, func(i, j int) bool { return output.Missing[i].Name < output.Missing[j].Name })
+
+	if !jsonOut {
+		printEnvCheck(output)
+	}
+	if strict && len(output.Missing)+len(output.Unknown) > 0 {
+		return fmt.Errorf("env check failed: %d missing, %d unknown", len(output.Missing), len(output.Unknown))
+	}
+	return nil
+}
+
+// printEnvCheck prints the text report of env check
+func printEnvCheck(output *EnvCheckOutput) {
+	if len(output.Missing) == 0 && len(output.Unknown) == 0 {
+		printSuccess("%s sets the %d required variables, and only known ones", output.Source, output.Required)
+		return
+	}
+	if len(output.Missing) > 0 {
+		fmt.Printf("Missing required variables (%d of %d):\n", len(output.Missing), output.Required)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, m := range output.Missing {
+			note := "read by " + m.File
+			if m.Empty {
+				note = "empty; " + note
+			}
+			if m.Description != "" {
+				note += "; " + m.Description
+			}
+			fmt.Fprintf(w, "  %s\t(%s)\n", m.Name, note)
+		}
+		w.Flush()
+	}
+	if len(output.Unknown) > 0 {
+		fmt.Println("Unknown variables (not read by the app or declared in .env.example):")
+		for _, name := range output.Unknown {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+}
+
+// composeEnvEntry matches the NAME=value entries of a compose environment list
+var composeEnvEntry = regexp.MustCompile(`(?m)^\s+- ([A-Za-z_][A-Za-z0-9_]*)=`)
+
+// envAssignment matches a variable of an env file: NAME=value, with an
+// optional export, or commented out as an example
+var envAssignment = regexp.MustCompile(`^(?:#\s*)?(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+// declaredEnv returns the variables an env file declares, including the
+// commented-out examples
+func declaredEnv(content string) []string {
+	var names []string
+	for _, line := range strings.Split(content, "\n") {
+		if m := envAssignment.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// readEnvFile reads the variables an env file sets, unquoting their values
+func readEnvFile(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		m := envAssignment.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value := strings.TrimSpace(m[2])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		vars[m[1]] = value
+	}
+	return vars, s.Err()
+}
@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/redact"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// ExplainOutput is the JSON output for explain command
+type ExplainOutput struct {
+	Path string `json:"path"`
+	*dockerfile.Explanation
+	AI *AIReview `json:"ai,omitempty"`
+}
+
+// AIReview is what an AI provider adds to an explanation
+type AIReview struct {
+	Provider string   `json:"provider"`
+	Review   string   `json:"review"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [dockerfile]",
+	Short: "Explain what each instruction of a Dockerfile does",
+	Long: `Annotate every instruction of an existing Dockerfile: what it does, the
+best practices it meets or misses, and whether it adds a layer to the final
+image. Nothing is sent anywhere; the explanation comes from the file alone.
+
+With --ai the Dockerfile and the project next to it are also sent to the
+configured AI provider for a review (secrets redacted unless --no-redact).
+
+Examples:
+  dockerizer explain
+  dockerizer explain docker/worker.Dockerfile
+  dockerizer explain ./my-project --ai`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	explainCmd.Flags().Bool("ai", false, "Add a review by the configured AI provider")
+	rootCmd.AddCommand(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	useAI, _ := cmd.Flags().GetBool("ai")
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = dockerfileIn(path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		printError("failed to read file: %v", err)
+		return err
+	}
+	df := dockerfile.Parse(string(content))
+	output := ExplainOutput{Path: path, Explanation: df.Explain()}
+
+	// Copying the whole context is only as good as its .dockerignore
+	dir := filepath.Dir(path)
+	if df.CopiesContext() {
+		if _, err := os.Stat(filepath.Join(dir, ".dockerignore")); err != nil {
+			output.Findings = append(output.Findings, dockerfile.Note{Message: "COPY . copies the whole build context, but there is no .dockerignore: .git, node_modules and local .env files end up in the image"})
+		}
+	}
+
+	if useAI {
+		review, err := reviewDockerfile(dir, string(content))
+		if err != nil {
+			printError("AI review failed: %v", err)
+			return err
+		}
+		output.AI = review
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output)
+	}
+	printExplanation(output)
+	return nil
+}
+
+// reviewDockerfile asks the configured AI provider to review a Dockerfile of
+// the project in dir
+func reviewDockerfile(dir, content string) (*AIReview, error) {
+	provider, err := requireAIProvider(dir, "explain --ai")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	scan, err := scanner.New(scanner.WithRedaction(!noRedact)).Scan(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("scan failed: %w", err)
+	}
+	if !noRedact {
+		var findings []redact.Finding
+		content, findings = redact.Secrets(content)
+		for _, f := range findings {
+			scan.Redactions = append(scan.Redactions, scanner.Redaction{Path: "Dockerfile", Line: f.Line, Rule: f.Rule})
+		}
+	}
+	warnRedactions(scan)
+
+	printInfo("Asking %s to review the Dockerfile...", provider.Name())
+	instructions := "Review the existing Dockerfile below instead of writing a new one. " +
+		"In explanation, say what the image does and what to improve, most important first; " +
+		"list concrete problems in warnings. Return the Dockerfile unchanged as dockerfile " +
+		"and leave the other files empty.\n\n```dockerfile\n" + content + "\n```"
+	resp, err := provider.Generate(ctx, scan, instructions)
+	reportRequests(provider)
+	if err != nil {
+		return nil, err
+	}
+	recordUsage("explain", resp.Usage)
+	return &AIReview{Provider: provider.Name(), Review: resp.Explanation, Warnings: resp.Warnings}, nil
+}
+
+// printExplanation prints an explanation instruction by instruction
+func printExplanation(output ExplainOutput) {
+	e := output.Explanation
+	fmt.Printf("%s: %d stage(s), %d layer(s) on top of the final base image, %d issue(s)\n", output.Path, e.Stages, e.Layers, e.Issues())
+	for _, a := range e.Annotations {
+		fmt.Printf("\nLine %d: %s\n", a.Line, a.Source)
+		fmt.Printf("  %s\n", a.Summary)
+		fmt.Printf("  Layer: %s\n", a.Layer)
+		for _, n := range a.Notes {
+			fmt.Printf("  %s %s\n", noteMark(n), n.Message)
+		}
+	}
+	if len(e.Findings) > 0 {
+		fmt.Println("\nOverall:")
+		for _, n := range e.Findings {
+			fmt.Printf("  %s %s\n", noteMark(n), n.Message)
+		}
+	}
+	if output.AI != nil {
+		fmt.Printf("\nAI review (%s):\n%s\n", output.AI.Provider, output.AI.Review)
+		for _, w := range output.AI.Warnings {
+			fmt.Printf("  ⚠ %s\n", w)
+		}
+	}
+}
+
+func noteMark(n dockerfile.Note) string {
+	if n.Good {
+		return "✓"
+	}
+	return "✗"
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// ExportOutput is the JSON output of export and deploy-config
+type ExportOutput struct {
+	Success  bool     `json:"success"`
+	Format   string   `json:"format,omitempty"`   // export
+	Platform string   `json:"platform,omitempty"` // deploy-config
+	Files    []string `json:"files,omitempty"`    // Written
+	Kept     []string `json:"kept,omitempty"`     // Existing files left as they were
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+
+	// With --dry-run: the files by name, none of them written
+	DryRun   bool              `json:"dry_run,omitempty"`
+	Contents map[string]string `json:"contents,omitempty"`
+}
+
+// warn records a warning of an export, printing it unless it goes out as JSON
+func (o *ExportOutput) warn(format string, args ...interface{}) {
+	w := fmt.Sprintf(format, args...)
+	o.Warnings = append(o.Warnings, w)
+	if !jsonOut {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", w)
+	}
+}
+
+// finish prints the JSON output of an export when --json is set, with the
+// error it failed with
+func (o *ExportOutput) finish(err error) error {
+	if !jsonOut {
+		return err
+	}
+	o.Success = err == nil
+	if err != nil {
+		o.Error = err.Error()
+	}
+	if jsonErr := printJSON(o); jsonErr != nil {
+		return jsonErr
+	}
+	return err
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [path]",
+	Short: "Export the detected build to another build system",
+	Long: `Export what dockerizer detects to a build system other than Docker.
+
+--format buildpacks writes a project.toml for Cloud Native Buildpacks (pack
+build, kpack): the Paketo builder, the buildpack of the language and the
+BP_* variables selecting the runtime version and the build, such as
+BP_NODE_VERSION or BP_WEB_SERVER_ROOT for static sites. Python apps without a
+web process get one in the Procfile, with the start command dockerizer would
+use, as the Python buildpack needs it.
+
+Heroku apps convert the other way round: dockerizer reads their Procfile
+(web, release and worker processes) and app.json (config vars and add-ons)
+when generating the Docker configuration.
+
+Existing files are kept unless --force is given; a web process is added to
+an existing Procfile.
+
+Examples:
+  dockerizer export --format buildpacks
+  dockerizer export ./my-project --format buildpacks --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().String("format", "buildpacks", "Build system to export to (buildpacks)")
+	exportCmd.Flags().Bool("dry-run", false, "Print the files instead of writing them")
+	exportCmd.Flags().BoolP("force", "f", false, "Overwrite existing files")
+	rootCmd.AddCommand(exportCmd)
+}
+
+func runExport(cmd *cobra.Command, args []string) (err error) {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	format, _ := cmd.Flags().GetString("format")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+	output := &ExportOutput{Format: format, DryRun: dryRun}
+	defer func() { err = output.finish(err) }()
+
+	if format != "buildpacks" {
+		return fmt.Errorf("unsupported export format %q (supported: buildpacks)", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	scan, err := scanner.New().Scan(ctx, path)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	result, err := detector.New(setupRegistry()).Detect(ctx, scan)
+	if err != nil {
+		return fmt.Errorf("detection failed: %w", err)
+	}
+	if !result.Detected {
+		return fmt.Errorf("no stack detected in %s", path)
+	}
+
+	name := exportName(path, scan)
+	project, err := generator.ProjectTOML(result, name)
+	if err != nil {
+		return err
+	}
+	files := map[string]string{"project.toml": project}
+	order := []string{"project.toml"}
+	if start := determineStartCommand(result, scan); result.Language == "python" && result.Variables["webCommand"] == nil && start.Cmd != "" {
+		// A Procfile with only release or worker processes gets a web one
+		procfile, _ := os.ReadFile(filepath.Join(path, "Procfile"))
+		if len(procfile) > 0 && !strings.HasSuffix(string(procfile), "\n") {
+			procfile = append(procfile, '\n')
+		}
+		files["Procfile"] = string(procfile) + "web: " + start.Cmd + "\n"
+		order = append(order, "Procfile")
+	}
+
+	for _, name := range order {
+		if err := writeExported(output, path, name, files[name], force); err != nil {
+			return err
+		}
+	}
+
+	// Buildpacks run the web process; a release process runs on deploy
+	for _, proc := range scan.Metadata.Procfile {
+		if proc.Name == "release" {
+			output.warn("Buildpacks don't run the Procfile release process (%s); run it before each deploy", proc.Command)
+		}
+	}
+	if !dryRun {
+		printInfo("Build the image with: pack build %s --path %s", name, path)
+	}
+	return nil
+}
+
+// writeExported writes a file of an export to the project directory, or
+// prints it for --dry-run, and records it in the output. Existing files are
+// kept unless force is set or the export only adds to them.
+func writeExported(output *ExportOutput, path, name, content string, force bool) error {
+	if output.DryRun {
+		if jsonOut {
+			if output.Contents == nil {
+				output.Contents = make(map[string]string)
+			}
+			output.Contents[name] = content
+			return nil
+		}
+		fmt.Printf("# %s\n%s\n", name, content)
+		return nil
+	}
+	file := filepath.Join(path, name)
+	if existing, err := os.ReadFile(file); err == nil && (string(existing) == content || !force && !strings.HasPrefix(content, string(existing))) {
+		printInfo("Kept %s (exists; --force overwrites it)", name)
+		output.Kept = append(output.Kept, name)
+		return nil
+	}
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	printSuccess("Wrote %s", name)
+	output.Files = append(output.Files, name)
+	return nil
+}
+
+// exportName is the image name of an export: the app.json name, or the
+// project directory's
+func exportName(path string, scan *scanner.ScanResult) string {
+	name := "app"
+	if app := scan.Metadata.AppJSON; app != nil && app.Name != "" {
+		name = app.Name
+	} else if abs, err := filepath.Abs(path); err == nil {
+		name = filepath.Base(abs)
+	}
+	return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+}
@@ -0,0 +1,253 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/ai"
+	"github.com/dublyo/dockerizer/internal/errors"
+	"github.com/dublyo/dockerizer/internal/redact"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// FixOutput is the JSON output for fix command
+type FixOutput struct {
+	Path        string   `json:"path"`
+	Provider    string   `json:"provider"`
+	Explanation string   `json:"explanation"`
+	Warnings    []string `json:"warnings,omitempty"`
+	Patch       string   `json:"patch"`      // The unified diff the provider answered with
+	Dockerfile  string   `json:"dockerfile"` // The Dockerfile with the patch applied
+	Changed     bool     `json:"changed"`
+	Applied     bool     `json:"applied"`
+}
+
+var fixCmd = &cobra.Command{
+	Use:   "fix [dockerfile]",
+	Short: "Ask the AI provider for a minimal patch to a broken Dockerfile",
+	Long: `Fix an existing Dockerfile with a minimal patch instead of generating a new
+one, so manual changes survive. The Dockerfile, a scan of its project and
+the build log go to the configured AI provider, which answers with a unified
+diff; the diff is shown and applied once confirmed.
+
+Without --log the Dockerfile is built with docker to get the log. With
+--instructions the fix can also cover problems that don't fail the build.
+The log and Dockerfile are redacted like the project files unless
+--no-redact.
+
+Examples:
+  dockerizer fix
+  docker build . 2>&1 | dockerizer fix --log -
+  dockerizer fix docker/worker.Dockerfile --log build.log --dry-run
+  dockerizer fix --instructions "run as a non-root user" --yes`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runFix,
+}
+
+func init() {
+	fixCmd.Flags().String("log", "", "Build log to fix (- reads stdin); default: build the Dockerfile with docker")
+	fixCmd.Flags().String("instructions", "", "What else to fix or change")
+	fixCmd.Flags().Bool("dry-run", false, "Show the patch without applying it")
+	fixCmd.Flags().BoolP("yes", "y", false, "Apply the patch without asking")
+	rootCmd.AddCommand(fixCmd)
+}
+
+func runFix(cmd *cobra.Command, args []string) error {
+	logPath, _ := cmd.Flags().GetString("log")
+	instructions, _ := cmd.Flags().GetString("instructions")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = dockerfileIn(path)
+	}
+	dir := filepath.Dir(path)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		printError("failed to read file: %v", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
+
+	buildLog, err := fixBuildLog(ctx, logPath, path, dir, instructions == "")
+	if err != nil {
+		return err
+	}
+	if buildLog == "" && instructions == "" {
+		printSuccess("%s builds; nothing to fix (use --instructions to ask for other changes)", path)
+		return nil
+	}
+
+	provider, err := requireAIProvider(dir, "fix")
+	if err != nil {
+		return err
+	}
+	scan, err := scanner.New(scanner.WithRedaction(!noRedact)).Scan(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	sent := string(content)
+	if !noRedact {
+		var findings []redact.Finding
+		sent, findings = redact.Secrets(sent)
+		for _, f := range findings {
+			scan.Redactions = append(scan.Redactions, scanner.Redaction{Path: filepath.Base(path), Line: f.Line, Rule: f.Rule})
+		}
+		buildLog, _ = redact.Secrets(buildLog)
+	}
+	warnRedactions(scan)
+
+	printInfo("Asking %s for a patch...", provider.Name())
+	resp, fixed, err := requestFix(ctx, provider, scan, string(content), ai.FixInstructions(sent, buildLog, instructions))
+	reportRequests(provider)
+	if err != nil {
+		printError("fix failed: %v", err)
+		return err
+	}
+
+	output := FixOutput{
+		Path:        path,
+		Provider:    provider.Name(),
+		Explanation: resp.Explanation,
+		Warnings:    resp.Warnings,
+		Patch:       resp.Patch,
+		Dockerfile:  fixed,
+		Changed:     fixed != string(content),
+	}
+
+	if !jsonOut {
+		if output.Explanation != "" {
+			printInfo("%s", output.Explanation)
+		}
+		for _, w := range output.Warnings {
+			printInfo("  ⚠ %s", w)
+		}
+	}
+	if output.Changed && !jsonOut {
+		fmt.Print(tui.Diff(path, string(content), fixed))
+	}
+	switch {
+	case !output.Changed:
+		if !jsonOut {
+			printInfo("The AI suggested no change to %s", path)
+		}
+	case dryRun:
+	default:
+		if confirmFix(path, yes) {
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte(fixed), info.Mode().Perm()); err != nil {
+				printError("failed to write %s: %v", path, err)
+				return err
+			}
+			output.Applied = true
+			if !jsonOut {
+				printSuccess("Patched %s", path)
+			}
+		}
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output)
+	}
+	return nil
+}
+
+// fixBuildLog reads the build log from logPath, or builds the Dockerfile to
+// get one. A build that succeeds has no log to fix; without docker that is
+// an error only when the log is required.
+func fixBuildLog(ctx context.Context, logPath, path, dir string, required bool) (string, error) {
+	switch logPath {
+	case "-":
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	case "":
+	default:
+		data, err := os.ReadFile(logPath)
+		return string(data), err
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		if !required {
+			printVerbose("docker not found; fixing without a build log")
+			return "", nil
+		}
+		return "", fmt.Errorf("docker not found: pass the build log with --log")
+	}
+	printInfo("Building %s to find the problem...", path)
+	build := exec.CommandContext(ctx, "docker", "build", "--progress=plain", "-f", path, dir)
+	out, err := build.CombinedOutput()
+	if err == nil {
+		return "", nil
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return string(out), nil
+}
+
+// requestFix asks the provider for a patch and applies it to content,
+// asking once more when the patch doesn't apply
+func requestFix(ctx context.Context, provider ai.Provider, scan *scanner.ScanResult, content, instructions string) (*ai.Response, string, error) {
+	var usage []ai.Usage
+	defer func() { recordUsage("fix", usage) }()
+
+	prompt := instructions
+	for attempt := 0; ; attempt++ {
+		resp, err := provider.Generate(ctx, scan, prompt)
+		if err != nil {
+			return nil, "", err
+		}
+		usage = append(usage, resp.Usage...)
+
+		// A whole Dockerfile would undo manual changes (and redactions), so
+		// only a patch is applied
+		fixed, err := "", fmt.Errorf("%w: a whole Dockerfile instead of a patch", errors.ErrAIResponseInvalid)
+		if resp.Patch != "" {
+			fixed, err = ai.ApplyPatch(content, resp.Patch)
+		}
+		if err == nil {
+			return resp, fixed, nil
+		}
+		if attempt > 0 {
+			return nil, "", err
+		}
+		printVerbose("Patch did not apply (%v); asking again", err)
+		prompt = instructions + fmt.Sprintf("\n\nYour previous answer could not be applied (%v). Answer with a patch whose context and removed lines are copied exactly from the Dockerfile above.\n\n```diff\n%s\n```\n", err, resp.Patch)
+	}
+}
+
+// confirmFix asks whether to apply the patch; unattended runs apply it only
+// with --yes
+func confirmFix(path string, yes bool) bool {
+	if yes {
+		return true
+	}
+	if jsonOut || !stdinIsTerminal() {
+		printInfo("Run again with --yes to apply the patch")
+		return false
+	}
+	p := &prompter{reader: bufio.NewReader(os.Stdin), interactive: true}
+	return p.confirm(fmt.Sprintf("Apply the patch to %s? [Y/n] ", path), nil, true)
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate [path]",
+	Short: "Generate Docker configuration (same as the root command)",
+	Long: `Generate Docker configuration for a project, exactly like running
+dockerizer [path]. With --stdout every file is rendered and printed under a
+"==> name <==" header instead of being written; with --json the files come
+back as a map of name to content. Nothing on disk is touched.
+
+Examples:
+  dockerizer generate ./my-project
+  dockerizer generate --stdout ./my-project
+  dockerizer generate --stdout --json . | jq -r '.contents.Dockerfile'`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDockerize,
+}
+
+func init() {
+	addDockerizeFlags(generateCmd)
+	generateCmd.Flags().Bool("stdout", false, "Print the generated files instead of writing them")
+	generateCmd.Flags().Bool("dry-run", false, "Same as --stdout")
+	rootCmd.AddCommand(generateCmd)
+}
@@ -0,0 +1,319 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/config"
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [path]",
+	Short: "Learn the choices of an existing Dockerfile",
+	Long: `Parse the project's existing Dockerfile into a build plan: its stages with
+their base images, commands and system packages, the ports it exposes and
+its start command. Output is JSON or YAML, as with plan.
+
+The Dockerfile is compared with the one dockerizer would generate, and the
+choices that differ are recorded in the dockerfile section of the project's
+.dockerizer.yml, so later dockerize and update runs keep them:
+  - base images, by stage name ("final" for the last stage), such as a
+    company base image or another distribution
+  - system packages the final stage installs that the template doesn't,
+    such as curl or tini
+
+With --dry-run nothing is recorded. Importing again replaces the choices
+recorded before.
+
+Examples:
+  dockerizer import
+  dockerizer import ./my-project --format yaml
+  dockerizer import --dockerfile docker/Dockerfile.prod --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runImport,
+}
+
+func init() {
+	importCmd.Flags().String("dockerfile", "", "Dockerfile to import (default: the project's Dockerfile)")
+	importCmd.Flags().String("format", "json", "Output format (json, yaml)")
+	importCmd.Flags().StringP("output", "o", "", "Write the plan to file instead of stdout")
+	importCmd.Flags().Bool("dry-run", false, "Show the plan without recording the choices in .dockerizer.yml")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	file, _ := cmd.Flags().GetString("dockerfile")
+	format, _ := cmd.Flags().GetString("format")
+	outputFile, _ := cmd.Flags().GetString("output")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if file == "" {
+		file = dockerfileIn(path)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read Dockerfile: %w", err)
+	}
+	existing := dockerfile.Parse(string(content))
+	if err := existing.Err(); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	scan, err := scanner.New(scanner.WithIgnoreHidden(false)).Scan(ctx, path)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	result, err := detector.New(setupRegistry()).Detect(ctx, scan)
+	if err != nil {
+		return fmt.Errorf("detection failed: %w", err)
+	}
+
+	// The Dockerfile dockerizer generates without any preserved choices
+	var generated *dockerfile.Dockerfile
+	if result.Detected {
+		genOpts := append([]generator.Option{
+			generator.WithCompose(false),
+			generator.WithIgnore(false),
+			generator.WithEnv(false),
+		}, projectGeneratorOptions(path, "", "", "", "", "", nil)...)
+		genOpts = append(genOpts, generator.WithPreserved(generator.Preserved{}))
+		output, err := generator.New(genOpts...).Generate(result, "")
+		if err != nil {
+			return fmt.Errorf("generation failed: %w", err)
+		}
+		generated = dockerfile.Parse(output.Dockerfile)
+	}
+
+	plan := importPlan(existing, result)
+	plan.Preserved = learnChoices(existing, generated)
+
+	if !dryRun {
+		if err := recordChoices(path, plan.Preserved); err != nil {
+			return fmt.Errorf("failed to record the choices: %w", err)
+		}
+	}
+
+	var data []byte
+	switch format {
+	case "yaml", "yml":
+		data, err = yaml.Marshal(plan)
+	default:
+		data, err = json.MarshalIndent(plan, "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		printInfo("Plan written to %s", outputFile)
+	} else {
+		fmt.Println(string(data))
+	}
+
+	// The plan may be piped, so the summary goes to stderr
+	if !quiet {
+		summarizeChoices(os.Stderr, path, plan.Preserved, dryRun)
+	}
+	return nil
+}
+
+// importPlan describes an existing Dockerfile as a build plan: a phase per
+// stage, the ports of the final stage and its start command
+func importPlan(df *dockerfile.Dockerfile, result *detector.DetectionResult) BuildPlan {
+	plan := BuildPlan{
+		Version:   "1.0",
+		Generator: fmt.Sprintf("dockerizer %s", Version),
+		Detection: DetectionPlan{
+			Detected:   result.Detected,
+			Language:   result.Language,
+			Framework:  result.Framework,
+			Version:    result.Version,
+			Confidence: result.Confidence,
+			Provider:   result.Provider,
+		},
+		Variables: result.Variables,
+		Phases:    []BuildPhase{},
+	}
+
+	for _, s := range df.Stages {
+		phase := BuildPhase{Name: s.Name, Commands: []string{}}
+		if phase.Name == "" {
+			phase.Name = fmt.Sprintf("stage-%d", s.Index+1)
+		}
+		if base := df.BaseStage(s); base != nil {
+			phase.DependsOn = append(phase.DependsOn, base.Name)
+		} else {
+			phase.Image = df.ExpandArgs(s.Image)
+		}
+		for _, in := range s.Instructions {
+			switch in.Cmd {
+			case "RUN":
+				phase.Commands = append(phase.Commands, instructionCommand(in))
+			case "COPY":
+				if from, ok := in.Flag("from"); ok && !slices.Contains(phase.DependsOn, strings.ToLower(from)) && df.Stage(from) != nil {
+					phase.DependsOn = append(phase.DependsOn, strings.ToLower(from))
+				}
+			}
+		}
+		phase.Packages = df.Packages(s)
+		plan.Phases = append(plan.Phases, phase)
+	}
+
+	if final := df.FinalStage(); final != nil {
+		for _, in := range final.Instructions {
+			switch in.Cmd {
+			case "EXPOSE":
+				plan.Ports = append(plan.Ports, in.Args...)
+			case "CMD":
+				plan.Start.Cmd = instructionCommand(in)
+			case "ENTRYPOINT":
+				plan.Start.Entrypoint = instructionCommand(in)
+			}
+		}
+	}
+	return plan
+}
+
+// instructionCommand is the command of a RUN, CMD or ENTRYPOINT as a shell
+// would show it
+func instructionCommand(in *dockerfile.Instruction) string {
+	if in.JSON {
+		return strings.Join(in.Args, " ")
+	}
+	return in.Value
+}
+
+// learnChoices compares an existing Dockerfile with the generated one and
+// returns the base images and final stage packages that differ. Stages are
+// matched by name, and the last stages with each other. Without a generated
+// Dockerfile (the stack wasn't detected) the final stage's image and
+// packages are kept.
+func learnChoices(existing, generated *dockerfile.Dockerfile) *PreservedPlan {
+	learned := &PreservedPlan{BaseImages: map[string]string{}}
+	for _, s := range existing.Stages {
+		if existing.BaseStage(s) != nil {
+			continue
+		}
+		image := baseImageName(existing, s)
+		final := s == existing.FinalStage()
+
+		var match *dockerfile.Stage
+		if generated != nil {
+			if s.Name != "" {
+				match = generated.Stage(s.Name)
+			}
+			if match == nil && final {
+				match = generated.FinalStage()
+			}
+			if match == nil || generated.BaseStage(match) != nil || baseImageName(generated, match) == image {
+				continue
+			}
+		} else if !final {
+			continue
+		}
+		key := s.Name
+		if final || match == generated.FinalStage() {
+			key = generator.FinalStage
+		}
+		learned.BaseImages[key] = image
+	}
+
+	for _, p := range stagePackages(existing) {
+		if generated == nil || !slices.Contains(stagePackages(generated), p) {
+			learned.Packages = append(learned.Packages, p)
+		}
+	}
+	if len(learned.BaseImages) == 0 && len(learned.Packages) == 0 {
+		return nil
+	}
+	return learned
+}
+
+// baseImageName is a stage's base image with ARGs expanded and any digest
+// removed, as pinning is a setting of its own (defaults.pin_digests)
+func baseImageName(df *dockerfile.Dockerfile, s *dockerfile.Stage) string {
+	image, _, _ := strings.Cut(df.ExpandArgs(s.Image), "@")
+	return image
+}
+
+// stagePackages are the packages the final stage and the stages it is built
+// on install
+func stagePackages(df *dockerfile.Dockerfile) []string {
+	var packages []string
+	for s := df.FinalStage(); s != nil; s = df.BaseStage(s) {
+		packages = append(df.Packages(s), packages...)
+	}
+	return packages
+}
+
+// recordChoices writes the learned choices to the project's .dockerizer.yml,
+// replacing those recorded by an earlier import
+func recordChoices(path string, learned *PreservedPlan) error {
+	if learned == nil {
+		learned = &PreservedPlan{}
+	}
+	file := config.ProjectPath(path)
+	for name := range projectConfig(path).Dockerfile.BaseImages {
+		if _, ok := learned.BaseImages[name]; !ok {
+			if _, err := config.Unset(file, "dockerfile.base_images."+name); err != nil {
+				return err
+			}
+		}
+	}
+	for name, image := range learned.BaseImages {
+		if err := config.Set(file, "dockerfile.base_images."+name, image); err != nil {
+			return err
+		}
+	}
+	if len(learned.Packages) == 0 {
+		_, err := config.Unset(file, "dockerfile.packages")
+		return err
+	}
+	return config.Set(file, "dockerfile.packages", strings.Join(learned.Packages, ","))
+}
+
+// summarizeChoices tells what import recorded
+func summarizeChoices(w io.Writer, path string, learned *PreservedPlan, dryRun bool) {
+	if learned == nil {
+		fmt.Fprintln(w, "The Dockerfile makes no choices the generated one lacks; nothing to keep")
+		return
+	}
+	verb := "Recorded in " + filepath.Base(config.ProjectPath(path))
+	if dryRun {
+		verb = "Would record (--dry-run)"
+	}
+	fmt.Fprintf(w, "%s:\n", verb)
+	names := make([]string, 0, len(learned.BaseImages))
+	for name := range learned.BaseImages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  base image of %s: %s\n", name, learned.BaseImages[name])
+	}
+	if len(learned.Packages) > 0 {
+		fmt.Fprintf(w, "  system packages: %s\n", strings.Join(learned.Packages, ", "))
+	}
+}
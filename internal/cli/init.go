@@ -4,16 +4,23 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/dublyo/dockerizer/internal/ai"
 	"github.com/dublyo/dockerizer/internal/config"
 	"github.com/dublyo/dockerizer/internal/detector"
 	"github.com/dublyo/dockerizer/internal/generator"
 	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/internal/secrets"
+	"github.com/dublyo/dockerizer/internal/tui"
 	"github.com/spf13/cobra"
 )
 
@@ -28,18 +35,216 @@ This command guides you through:
   3. Configuration customization
   4. File generation
 
+Every prompt can be answered up front with a flag or an answers file, and
+answered prompts are not asked. With --yes, or when stdin is not a
+terminal, the remaining prompts take their defaults, so init runs
+unattended in scripts and CI. Flags take precedence over the answers file.
+API keys are read from ANTHROPIC_API_KEY and OPENAI_API_KEY, or from the
+OS secret store (see dockerizer auth login). A key typed at the prompt is
+saved in the secret store when the config is saved.
+
+With --json, init runs unattended and prints the result as JSON: the
+detected stack, the files written and whether the config was saved.
+--quiet runs it without the setup transcript.
+
+Answers file (YAML):
+  ai: false              # Use AI even though the stack was detected
+  provider: anthropic    # anthropic, openai, ollama or none
+  model: claude-3-5-haiku-20241022
+  base_url: http://localhost:11434   # Ollama only
+  overwrite: true
+  compose: true
+  dockerignore: true
+  env_example: true
+  save_config: false
+
 Examples:
   dockerizer init
-  dockerizer init ./my-project`,
+  dockerizer init ./my-project
+  dockerizer init --yes --force ./my-project
+  dockerizer init --answers init.yaml ./my-project
+  dockerizer init -y --ai --provider ollama --model mistral .`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runInit,
 }
 
 func init() {
 	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().BoolP("yes", "y", false, "Accept the default for every prompt not answered by a flag or answers file")
+	initCmd.Flags().String("answers", "", "YAML file answering the prompts")
+	initCmd.Flags().Bool("ai", false, "Use AI even when the stack is detected")
+	initCmd.Flags().String("provider", "", "AI provider: anthropic, openai, ollama or none")
+	initCmd.Flags().String("model", "", "AI model name")
+	initCmd.Flags().String("base-url", "", "Ollama URL")
+	initCmd.Flags().BoolP("force", "f", false, "Overwrite existing files")
+	initCmd.Flags().Bool("no-compose", false, "Skip docker-compose.yml generation")
+	initCmd.Flags().Bool("no-ignore", false, "Skip .dockerignore generation")
+	initCmd.Flags().Bool("no-env", false, "Skip .env.example generation")
+	initCmd.Flags().Bool("save-config", false, "Save the AI configuration for future use")
+	initCmd.Flags().Bool("no-tui", false, "Use plain line prompts instead of the terminal UI")
+}
+
+// InitOutput is the JSON output of init
+type InitOutput struct {
+	Success      bool     `json:"success"`
+	Path         string   `json:"path"`
+	Language     string   `json:"language,omitempty"`
+	Framework    string   `json:"framework,omitempty"`
+	Version      string   `json:"version,omitempty"`
+	Confidence   int      `json:"confidence,omitempty"`
+	Provider     string   `json:"provider,omitempty"` // AI provider that generated the files
+	Files        []string `json:"files,omitempty"`
+	Warnings     []string `json:"warnings,omitempty"`
+	BuildSecrets []string `json:"build_secrets,omitempty"`
+	ConfigSaved  bool     `json:"config_saved,omitempty"`
+	Duration     string   `json:"duration"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// initAnswers answers the init prompts ahead of time; unset fields are asked
+// interactively, or take their defaults when running unattended
+type initAnswers struct {
+	AI           *bool  `yaml:"ai"`       // Use AI even though the stack was detected
+	Provider     string `yaml:"provider"` // anthropic, openai, ollama or none
+	Model        string `yaml:"model"`
+	BaseURL      string `yaml:"base_url"` // Ollama only
+	Overwrite    *bool  `yaml:"overwrite"`
+	Compose      *bool  `yaml:"compose"`
+	Dockerignore *bool  `yaml:"dockerignore"`
+	EnvExample   *bool  `yaml:"env_example"`
+	SaveConfig   *bool  `yaml:"save_config"`
+}
+
+// loadInitAnswers reads the answers file, if any, and applies the flags on top
+func loadInitAnswers(cmd *cobra.Command) (*initAnswers, error) {
+	answers := &initAnswers{}
+	if file, _ := cmd.Flags().GetString("answers"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read answers: %w", err)
+		}
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		if err := dec.Decode(answers); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("invalid answers file %s: %w", file, err)
+		}
+	}
+
+	flags := cmd.Flags()
+	for name, field := range map[string]*string{
+		"provider": &answers.Provider,
+		"model":    &answers.Model,
+		"base-url": &answers.BaseURL,
+	} {
+		if flags.Changed(name) {
+			*field, _ = flags.GetString(name)
+		}
+	}
+	for name, field := range map[string]struct {
+		answer *(*bool)
+		negate bool
+	}{
+		"ai":          {&answers.AI, false},
+		"force":       {&answers.Overwrite, false},
+		"no-compose":  {&answers.Compose, true},
+		"no-ignore":   {&answers.Dockerignore, true},
+		"no-env":      {&answers.EnvExample, true},
+		"save-config": {&answers.SaveConfig, false},
+	} {
+		if flags.Changed(name) {
+			v, _ := flags.GetBool(name)
+			v = v != field.negate
+			*field.answer = &v
+		}
+	}
+
+	answers.Provider = strings.ToLower(answers.Provider)
+	switch answers.Provider {
+	case "", "anthropic", "openai", "ollama", "none":
+	default:
+		return nil, fmt.Errorf("unsupported provider %q: use anthropic, openai, ollama or none", answers.Provider)
+	}
+	return answers, nil
+}
+
+// prompter asks the init questions, skipping those answered ahead of time.
+// When not interactive, unanswered questions take their defaults.
+type prompter struct {
+	reader      *bufio.Reader
+	out         io.Writer // Where prompts and progress go; io.Discard for --json and --quiet
+	interactive bool
+	ui          bool // Choices are selectable lists in the terminal UI
+}
+
+// choose offers numbered options and returns the chosen number ("1", "2",
+// ...), the preset answer, or def when running unattended
+func (p *prompter) choose(title string, options []tui.Option, preset, def string) (string, error) {
+	if p.ui && preset == "" {
+		selected, _ := strconv.Atoi(def)
+		i, err := tui.Select(title, options, selected-1)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(i + 1), nil
+	}
+
+	fmt.Fprintf(p.out, "  %s:\n", title)
+	for i, opt := range options {
+		line := fmt.Sprintf("    %d. %s", i+1, opt.Label)
+		if opt.Detail != "" {
+			line += " (" + opt.Detail + ")"
+		}
+		fmt.Fprintln(p.out, line)
+	}
+	fmt.Fprintln(p.out)
+	return p.ask(fmt.Sprintf("  Choice [1-%d]: ", len(options)), preset, def), nil
+}
+
+// ask prints the prompt and returns the preset answer, the user's reply, or
+// def when running unattended
+func (p *prompter) ask(prompt, preset, def string) string {
+	fmt.Fprint(p.out, prompt)
+	switch {
+	case preset != "":
+		fmt.Fprintln(p.out, preset)
+		return preset
+	case !p.interactive:
+		fmt.Fprintln(p.out, def)
+		return def
+	}
+	return readLine(p.reader)
+}
+
+// confirm asks a yes/no question; an empty reply takes def
+func (p *prompter) confirm(prompt string, preset *bool, def bool) bool {
+	answer := ""
+	if preset != nil {
+		answer = yesNo(*preset)
+	}
+	switch strings.ToLower(p.ask(prompt, answer, yesNo(def))) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	}
+	return def
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "y"
+	}
+	return "n"
+}
+
+// stdinIsTerminal reports whether prompts can be answered interactively
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
 }
 
-func runInit(cmd *cobra.Command, args []string) error {
+func runInit(cmd *cobra.Command, args []string) (err error) {
 	path := "."
 	if len(args) > 0 {
 		path = args[0]
@@ -51,135 +256,192 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	reader := bufio.NewReader(os.Stdin)
+	answers, err := loadInitAnswers(cmd)
+	if err != nil {
+		printError("%v", err)
+		return err
+	}
+	yes, _ := cmd.Flags().GetBool("yes")
+	noTUI, _ := cmd.Flags().GetBool("no-tui")
+	p := &prompter{
+		reader:      bufio.NewReader(os.Stdin),
+		out:         os.Stdout,
+		interactive: !yes && !jsonOut && stdinIsTerminal(),
+	}
+	p.ui = p.interactive && !noTUI && tui.Available()
+	if jsonOut || quiet && !p.interactive {
+		p.out = io.Discard
+	}
 
 	// Welcome message
-	fmt.Println()
-	fmt.Println("  Dockerizer - Interactive Setup")
-	fmt.Println("  https://dockerizer.dev")
-	fmt.Println()
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "  Dockerizer - Interactive Setup")
+	fmt.Fprintln(p.out, "  https://dockerizer.dev")
+	fmt.Fprintln(p.out)
+
+	var result *detector.DetectionResult
+	var output *generator.Output
+	summary := InitOutput{Path: absPath}
+	defer func(start time.Time) {
+		recordRun("init", absPath, start, result, output != nil && len(output.Usage) > 0, err)
+		if jsonOut {
+			summary.Success = err == nil
+			summary.Duration = time.Since(start).Round(time.Millisecond).String()
+			if err != nil {
+				summary.Error = err.Error()
+			}
+			_ = printJSON(summary)
+		}
+	}(time.Now())
 
 	// Step 1: Scan and detect
-	fmt.Printf("  Scanning %s...\n", absPath)
+	fmt.Fprintf(p.out, "  Scanning %s...\n", absPath)
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
 
-	scan, err := scanner.New(scanner.WithIgnoreHidden(false)).Scan(ctx, absPath)
+	scan, err := scanner.New(scanner.WithIgnoreHidden(false), scanner.WithRedaction(!noRedact)).Scan(ctx, absPath)
 	if err != nil {
 		return fmt.Errorf("scan failed: %w", err)
 	}
 
 	registry := setupRegistry()
 	det := detector.New(registry)
-	result, err := det.Detect(ctx, scan)
+	result, err = det.Detect(ctx, scan)
 	if err != nil {
 		return fmt.Errorf("detection failed: %w", err)
 	}
 
 	// Step 2: Show detection results
-	fmt.Println()
+	detected := result.Detected
+	if detected {
+		summary.Language = result.Language
+		summary.Framework = result.Framework
+		summary.Version = result.Version
+		summary.Confidence = result.Confidence
+	}
+	fmt.Fprintln(p.out)
 	if result.Detected {
-		fmt.Printf("  Detected Stack:\n")
-		fmt.Printf("    Language:   %s\n", result.Language)
-		fmt.Printf("    Framework:  %s\n", result.Framework)
+		fmt.Fprintf(p.out, "  Detected Stack:\n")
+		fmt.Fprintf(p.out, "    Language:   %s\n", result.Language)
+		fmt.Fprintf(p.out, "    Framework:  %s\n", result.Framework)
 		if result.Version != "" {
-			fmt.Printf("    Version:    %s\n", result.Version)
+			fmt.Fprintf(p.out, "    Version:    %s\n", result.Version)
 		}
-		fmt.Printf("    Confidence: %d%%\n", result.Confidence)
-		fmt.Println()
+		fmt.Fprintf(p.out, "    Confidence: %d%%\n", result.Confidence)
+		fmt.Fprintln(p.out)
 
 		// Ask for confirmation
-		if result.Confidence < 90 {
-			fmt.Print("  Detection confidence is low. Use AI to improve? [Y/n]: ")
+		var useAI bool
+		if offline {
+			fmt.Fprintln(p.out, "  Offline: using the detected stack without AI")
+		} else if result.Confidence < 90 {
+			useAI = p.confirm("  Detection confidence is low. Use AI to improve? [Y/n]: ", answers.AI, true)
 		} else {
-			fmt.Print("  Proceed with this detection? [Y/n]: ")
+			useAI = !p.confirm("  Proceed with this detection? [Y/n]: ", negate(answers.AI), true)
 		}
-		response := readLine(reader)
-		if strings.ToLower(response) == "n" {
+		if useAI {
 			result.Detected = false // Force AI mode
 		}
 	}
 
 	// Step 3: AI Configuration (if needed or requested)
 	var aiProvider ai.Provider
-	if !result.Detected || result.Confidence < 80 {
-		fmt.Println()
-		fmt.Println("  AI-Powered Generation")
-		fmt.Println("  ---------------------")
-		fmt.Println()
-		fmt.Println("  Select AI provider:")
-		fmt.Println("    1. Anthropic (Claude) - Recommended")
-		fmt.Println("    2. OpenAI (GPT-4)")
-		fmt.Println("    3. Ollama (Local)")
-		fmt.Println("    4. Skip AI (use template only)")
-		fmt.Println()
-		fmt.Print("  Choice [1-4]: ")
-
-		choice := readLine(reader)
+	var aiConfig config.AIConfig // What to save for future use
+	if (!result.Detected || result.Confidence < 80) && offline {
+		if !detected {
+			return requireNetwork("AI generation")
+		}
+		result.Detected = true
+	} else if !result.Detected || result.Confidence < 80 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "  AI-Powered Generation")
+		fmt.Fprintln(p.out, "  ---------------------")
+		fmt.Fprintln(p.out)
+
+		choices := map[string]string{"anthropic": "1", "openai": "2", "ollama": "3", "none": "4"}
+		choice, err := p.choose("Select AI provider", []tui.Option{
+			{Label: "Anthropic (Claude)", Detail: "Recommended"},
+			{Label: "OpenAI (GPT-4)"},
+			{Label: "Ollama (Local)"},
+			{Label: "Skip AI", Detail: "use template only"},
+		}, choices[answers.Provider], "1")
+		if err != nil {
+			return err
+		}
 		switch choice {
 		case "1", "":
-			aiProvider = configureAnthropic(reader)
+			aiProvider, err = configureAnthropic(p, answers.Model, &aiConfig)
 		case "2":
-			aiProvider = configureOpenAI(reader)
+			aiProvider, err = configureOpenAI(p, answers.Model, &aiConfig)
 		case "3":
-			aiProvider = configureOllama(reader)
-		case "4":
-			// Skip AI
-			if !result.Detected {
+			aiProvider, err = configureOllama(p, answers.Model, answers.BaseURL, &aiConfig)
+		}
+		if err != nil {
+			return err
+		}
+		// Without a provider, fall back to the detection when there is one
+		if aiProvider == nil {
+			if !detected {
 				return fmt.Errorf("cannot proceed without AI - no stack detected")
 			}
+			result.Detected = true
 		}
 	}
 
 	// Step 4: Generation options
-	fmt.Println()
-	fmt.Println("  Generation Options")
-	fmt.Println("  ------------------")
-	fmt.Println()
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "  Generation Options")
+	fmt.Fprintln(p.out, "  ------------------")
+	fmt.Fprintln(p.out)
 
 	// Check existing files
 	existingFiles := checkExistingFiles(absPath)
 	overwrite := false
 	if len(existingFiles) > 0 {
-		fmt.Println("  Existing files found:")
+		fmt.Fprintln(p.out, "  Existing files found:")
 		for _, f := range existingFiles {
-			fmt.Printf("    - %s\n", f)
-		}
-		fmt.Println()
-		fmt.Print("  Overwrite existing files? [y/N]: ")
-		if strings.ToLower(readLine(reader)) == "y" {
-			overwrite = true
+			fmt.Fprintf(p.out, "    - %s\n", f)
 		}
+		fmt.Fprintln(p.out)
+		overwrite = p.confirm("  Overwrite existing files? [y/N]: ", answers.Overwrite, false)
 	}
 
 	// Ask about compose/ignore/env
-	fmt.Print("  Generate docker-compose.yml? [Y/n]: ")
-	includeCompose := strings.ToLower(readLine(reader)) != "n"
-
-	fmt.Print("  Generate .dockerignore? [Y/n]: ")
-	includeIgnore := strings.ToLower(readLine(reader)) != "n"
-
-	fmt.Print("  Generate .env.example? [Y/n]: ")
-	includeEnv := strings.ToLower(readLine(reader)) != "n"
+	includeCompose := p.confirm("  Generate docker-compose.yml? [Y/n]: ", answers.Compose, true)
+	includeIgnore := p.confirm("  Generate .dockerignore? [Y/n]: ", answers.Dockerignore, true)
+	includeEnv := p.confirm("  Generate .env.example? [Y/n]: ", answers.EnvExample, true)
 
 	// Step 5: Generate
-	fmt.Println()
-	fmt.Println("  Generating Docker configuration...")
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "  Generating Docker configuration...")
 
 	genOpts := []generator.Option{
 		generator.WithOverwrite(overwrite),
 		generator.WithCompose(includeCompose),
 		generator.WithIgnore(includeIgnore),
 		generator.WithEnv(includeEnv),
+		generator.WithCacheMounts(useCacheMounts(absPath, nil)),
+		generator.WithExistingNames(absPath),
+	}
+	if projectConfig(absPath).Defaults.PinDigests {
+		if err := requireNetwork("pinning base image digests (pin_digests)"); err != nil {
+			return err
+		}
+		genOpts = append(genOpts, generator.WithDigestResolver(digestResolver(ctx)))
 	}
 
 	if aiProvider != nil {
-		genOpts = append(genOpts, generator.WithAIProvider(aiProvider))
+		genOpts = append(genOpts, generator.WithAIProvider(aiProvider), generator.WithAIStream(newStreamFunc()))
+		warnRedactions(scan)
 	}
 
 	gen := generator.New(genOpts...)
 
-	var output *generator.Output
+	// Keep what generation may replace, for the diff view
+	before := readGeneratedFiles(absPath)
+
 	if aiProvider != nil {
 		output, err = gen.GenerateWithAIFallback(ctx, result, scan, absPath)
 	} else {
@@ -189,130 +451,266 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("generation failed: %w", err)
 	}
+	recordUsage("init", output.Usage)
+	for filename := range output.Files {
+		summary.Files = append(summary.Files, filename)
+	}
+	sort.Strings(summary.Files)
+	summary.Warnings = output.Warnings
+	summary.BuildSecrets = output.BuildSecrets
+	if aiProvider != nil && len(output.Usage) > 0 {
+		summary.Provider = aiProvider.Name()
+	}
 
 	// Step 6: Summary
-	fmt.Println()
-	fmt.Println("  Generated files:")
-	for filename := range output.Files {
-		fmt.Printf("    - %s\n", filename)
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "  Generated files:")
+	if p.ui {
+		if err := reviewChanges(absPath, output, before); err != nil {
+			return err
+		}
+	} else {
+		for filename := range output.Files {
+			fmt.Fprintf(p.out, "    - %s\n", filename)
+		}
 	}
 
-	fmt.Println()
-	fmt.Println("  Next steps:")
-	fmt.Println("    1. Review the generated Dockerfile")
-	fmt.Println("    2. Update .env.example with your values")
-	fmt.Println("    3. Build: docker compose build")
-	fmt.Println("    4. Run:   docker compose up")
-	fmt.Println()
+	fmt.Fprintln(p.out)
+	fmt.Fprintln(p.out, "  Next steps:")
+	fmt.Fprintln(p.out, "    1. Review the generated Dockerfile")
+	fmt.Fprintln(p.out, "    2. Update .env.example with your values")
+	fmt.Fprintln(p.out, "    3. Build: docker compose build")
+	fmt.Fprintln(p.out, "    4. Run:   docker compose up")
+	if len(output.BuildSecrets) > 0 {
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "  Private registry credentials are passed as build secrets (compose mounts them):")
+		fmt.Fprintf(p.out, "    docker build %s .\n", strings.Join(output.BuildSecrets, " "))
+	}
+	fmt.Fprintln(p.out)
 
 	// Ask to save config
-	fmt.Print("  Save AI configuration for future use? [y/N]: ")
-	if strings.ToLower(readLine(reader)) == "y" {
-		saveConfig(aiProvider)
+	if p.confirm("  Save AI configuration for future use? [y/N]: ", answers.SaveConfig, false) {
+		summary.ConfigSaved = saveConfig(p, aiProvider, aiConfig)
 	}
 
 	return nil
 }
 
+// generatedFileNames are the files generation may write into a project
+var generatedFileNames = []string{
+	"Dockerfile",
+	"Dockerfile.dockerignore",
+	"Containerfile",
+	"Containerfile.dockerignore",
+	"docker-entrypoint.sh",
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yaml",
+	"docker-compose.yml",
+	".dockerignore",
+	".env.example",
+}
+
+// readGeneratedFiles returns the current content of the files generation
+// may write, keyed by name
+func readGeneratedFiles(path string) map[string]string {
+	files := make(map[string]string)
+	for _, name := range generatedFileNames {
+		if content, err := os.ReadFile(filepath.Join(path, name)); err == nil {
+			files[name] = string(content)
+		}
+	}
+	return files
+}
+
+// reviewChanges lists the generated files with what changed on disk, then
+// lets the user page through the diff of each changed file
+func reviewChanges(path string, output *generator.Output, before map[string]string) error {
+	names := make([]string, 0, len(output.Files))
+	for name := range output.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type change struct{ name, old, new string }
+	var changes []change
+	for _, name := range names {
+		old, existed := before[name]
+		current, _ := os.ReadFile(filepath.Join(path, name))
+		status := ""
+		switch {
+		case !existed:
+			status = "new"
+		case string(current) == old && output.Files[name].String() != old:
+			status = "kept existing file"
+		case string(current) == old:
+			status = "unchanged"
+		default:
+			added, removed := tui.DiffStat(old, string(current))
+			status = fmt.Sprintf("+%d -%d", added, removed)
+		}
+		fmt.Printf("    - %-24s %s\n", name, status)
+		if string(current) != old {
+			changes = append(changes, change{name, old, string(current)})
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	options := make([]tui.Option, 0, len(changes)+1)
+	for _, c := range changes {
+		added, removed := tui.DiffStat(c.old, c.new)
+		options = append(options, tui.Option{Label: c.name, Detail: fmt.Sprintf("+%d -%d", added, removed)})
+	}
+	options = append(options, tui.Option{Label: "Done"})
+
+	selected := 0
+	for {
+		i, err := tui.Select("Review changes", options, selected)
+		if err == tui.ErrInterrupted || i == len(changes) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c := changes[i]
+		fmt.Println()
+		fmt.Print(tui.Diff(c.name, c.old, c.new))
+		fmt.Println()
+		selected = min(i+1, len(changes))
+	}
+}
+
+// readAPIKey prompts for an API key, hiding it when stdin is a terminal
+func (p *prompter) readAPIKey() string {
+	fmt.Fprintln(p.out)
+	if tui.Available() {
+		if key, err := tui.ReadSecret("  API Key: "); err == nil {
+			return key
+		}
+	}
+	fmt.Fprint(p.out, "  API Key: ")
+	return readLine(p.reader)
+}
+
 func readLine(reader *bufio.Reader) string {
 	line, _ := reader.ReadString('\n')
 	return strings.TrimSpace(line)
 }
 
-func configureAnthropic(reader *bufio.Reader) ai.Provider {
-	apiKey := os.Getenv("ANTHROPIC_API_KEY")
-	if apiKey == "" {
-		fmt.Println()
-		fmt.Println("  Anthropic API Key required.")
-		fmt.Println("  Get one at: https://console.anthropic.com/")
-		fmt.Println()
-		fmt.Print("  API Key: ")
-		apiKey = readLine(reader)
+// negate returns the opposite of an optional answer
+func negate(b *bool) *bool {
+	if b == nil {
+		return nil
 	}
+	v := !*b
+	return &v
+}
 
+func configureAnthropic(p *prompter, model string, chosen *config.AIConfig) (ai.Provider, error) {
+	apiKey, source := lookupAPIKey("anthropic", config.AIConfig{})
 	if apiKey == "" {
-		return nil
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "  Anthropic API Key required.")
+		fmt.Fprintln(p.out, "  Get one at: https://console.anthropic.com/")
+		if !p.interactive {
+			fmt.Fprintln(p.out, "  Set ANTHROPIC_API_KEY or run dockerizer auth login anthropic to use Anthropic unattended.")
+			return nil, nil
+		}
+		apiKey = p.readAPIKey()
 	}
 
-	fmt.Println()
-	fmt.Println("  Select model:")
-	fmt.Println("    1. claude-3-5-haiku-20241022 (Fast, recommended)")
-	fmt.Println("    2. claude-3-5-sonnet-20241022 (Better quality)")
-	fmt.Println()
-	fmt.Print("  Choice [1-2]: ")
+	if apiKey == "" {
+		return nil, nil
+	}
 
-	model := "claude-3-5-haiku-20241022"
-	if readLine(reader) == "2" {
-		model = "claude-3-5-sonnet-20241022"
+	fmt.Fprintln(p.out)
+	if model == "" {
+		choice, err := p.choose("Select model", []tui.Option{
+			{Label: "claude-3-5-haiku-20241022", Detail: "Fast, recommended"},
+			{Label: "claude-3-5-sonnet-20241022", Detail: "Better quality"},
+		}, "", "1")
+		if err != nil {
+			return nil, err
+		}
+		model = "claude-3-5-haiku-20241022"
+		if choice == "2" {
+			model = "claude-3-5-sonnet-20241022"
+		}
+	} else {
+		fmt.Fprintf(p.out, "  Model: %s\n", model)
 	}
 
 	provider := ai.NewAnthropicProvider(apiKey, model)
 	if !provider.IsAvailable() {
-		fmt.Println("  Warning: Could not connect to Anthropic API")
-		return nil
+		fmt.Fprintln(p.out, "  Warning: Could not connect to Anthropic API")
+		return nil, nil
 	}
 
-	fmt.Printf("  Using Anthropic (%s)\n", model)
-	return provider
+	fmt.Fprintf(p.out, "  Using Anthropic (%s)\n", model)
+	*chosen = config.AIConfig{Provider: "anthropic", Model: model, APIKey: typedKey(apiKey, source)}
+	return provider, nil
 }
 
-func configureOpenAI(reader *bufio.Reader) ai.Provider {
-	apiKey := os.Getenv("OPENAI_API_KEY")
+func configureOpenAI(p *prompter, model string, chosen *config.AIConfig) (ai.Provider, error) {
+	apiKey, source := lookupAPIKey("openai", config.AIConfig{})
 	if apiKey == "" {
-		fmt.Println()
-		fmt.Println("  OpenAI API Key required.")
-		fmt.Println("  Get one at: https://platform.openai.com/api-keys")
-		fmt.Println()
-		fmt.Print("  API Key: ")
-		apiKey = readLine(reader)
+		fmt.Fprintln(p.out)
+		fmt.Fprintln(p.out, "  OpenAI API Key required.")
+		fmt.Fprintln(p.out, "  Get one at: https://platform.openai.com/api-keys")
+		if !p.interactive {
+			fmt.Fprintln(p.out, "  Set OPENAI_API_KEY or run dockerizer auth login openai to use OpenAI unattended.")
+			return nil, nil
+		}
+		apiKey = p.readAPIKey()
 	}
 
 	if apiKey == "" {
-		return nil
+		return nil, nil
 	}
 
-	fmt.Println()
-	fmt.Println("  Select model:")
-	fmt.Println("    1. gpt-4o-mini (Fast, cheap)")
-	fmt.Println("    2. gpt-4o (Better quality)")
-	fmt.Println()
-	fmt.Print("  Choice [1-2]: ")
-
-	model := "gpt-4o-mini"
-	if readLine(reader) == "2" {
-		model = "gpt-4o"
+	fmt.Fprintln(p.out)
+	if model == "" {
+		choice, err := p.choose("Select model", []tui.Option{
+			{Label: "gpt-4o-mini", Detail: "Fast, cheap"},
+			{Label: "gpt-4o", Detail: "Better quality"},
+		}, "", "1")
+		if err != nil {
+			return nil, err
+		}
+		model = "gpt-4o-mini"
+		if choice == "2" {
+			model = "gpt-4o"
+		}
+	} else {
+		fmt.Fprintf(p.out, "  Model: %s\n", model)
 	}
 
 	provider := ai.NewOpenAIProvider(apiKey, model)
 	if !provider.IsAvailable() {
-		fmt.Println("  Warning: Could not connect to OpenAI API")
-		return nil
+		fmt.Fprintln(p.out, "  Warning: Could not connect to OpenAI API")
+		return nil, nil
 	}
 
-	fmt.Printf("  Using OpenAI (%s)\n", model)
-	return provider
+	fmt.Fprintf(p.out, "  Using OpenAI (%s)\n", model)
+	*chosen = config.AIConfig{Provider: "openai", Model: model, APIKey: typedKey(apiKey, source)}
+	return provider, nil
 }
 
-func configureOllama(reader *bufio.Reader) ai.Provider {
-	baseURL := os.Getenv("OLLAMA_BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:11434"
+func configureOllama(p *prompter, model, baseURL string, chosen *config.AIConfig) (ai.Provider, error) {
+	def := os.Getenv("OLLAMA_BASE_URL")
+	if def == "" {
+		def = "http://localhost:11434"
 	}
 
-	fmt.Println()
-	fmt.Printf("  Ollama URL [%s]: ", baseURL)
-	if url := readLine(reader); url != "" {
-		baseURL = url
+	fmt.Fprintln(p.out)
+	if baseURL = p.ask(fmt.Sprintf("  Ollama URL [%s]: ", def), baseURL, def); baseURL == "" {
+		baseURL = def
 	}
 
-	fmt.Println()
-	fmt.Println("  Select model:")
-	fmt.Println("    1. llama3 (Good balance)")
-	fmt.Println("    2. codellama (Code-focused)")
-	fmt.Println("    3. mistral (Fast)")
-	fmt.Println("    4. Custom model")
-	fmt.Println()
-	fmt.Print("  Choice [1-4]: ")
+	fmt.Fprintln(p.out)
 
 	models := map[string]string{
 		"1": "llama3",
@@ -320,11 +718,22 @@ func configureOllama(reader *bufio.Reader) ai.Provider {
 		"3": "mistral",
 	}
 
-	choice := readLine(reader)
-	model := models[choice]
-	if choice == "4" || model == "" {
-		fmt.Print("  Model name: ")
-		model = readLine(reader)
+	if model == "" {
+		choice, err := p.choose("Select model", []tui.Option{
+			{Label: "llama3", Detail: "Good balance"},
+			{Label: "codellama", Detail: "Code-focused"},
+			{Label: "mistral", Detail: "Fast"},
+			{Label: "Custom model"},
+		}, "", "1")
+		if err != nil {
+			return nil, err
+		}
+		model = models[choice]
+		if choice == "4" || model == "" {
+			model = p.ask("  Model name: ", "", "llama3")
+		}
+	} else {
+		fmt.Fprintf(p.out, "  Model: %s\n", model)
 	}
 	if model == "" {
 		model = "llama3"
@@ -332,17 +741,21 @@ func configureOllama(reader *bufio.Reader) ai.Provider {
 
 	provider := ai.NewOllamaProvider(baseURL, model)
 	if !provider.IsAvailable() {
-		fmt.Println("  Warning: Could not connect to Ollama")
-		fmt.Println("  Make sure Ollama is running: ollama serve")
-		return nil
+		fmt.Fprintln(p.out, "  Warning: Could not connect to Ollama")
+		fmt.Fprintln(p.out, "  Make sure Ollama is running: ollama serve")
+		return nil, nil
 	}
 
-	fmt.Printf("  Using Ollama (%s)\n", model)
-	return provider
+	fmt.Fprintf(p.out, "  Using Ollama (%s)\n", model)
+	*chosen = config.AIConfig{Provider: "ollama", Model: model, BaseURL: baseURL}
+	return provider, nil
 }
 
+// checkExistingFiles lists the files in path that generation would replace,
+// under any of the names Docker, Podman and Compose use
 func checkExistingFiles(path string) []string {
-	files := []string{"Dockerfile", "docker-compose.yml", ".dockerignore", ".env.example"}
+	files := append(append([]string{}, generator.DockerfileNames...), generator.ComposeNames...)
+	files = append(files, ".dockerignore", ".env.example")
 	var existing []string
 	for _, f := range files {
 		if _, err := os.Stat(filepath.Join(path, f)); err == nil {
@@ -352,21 +765,56 @@ func checkExistingFiles(path string) []string {
 	return existing
 }
 
-func saveConfig(provider ai.Provider) {
-	if provider == nil {
-		return
+// typedKey returns an API key entered at the prompt, or "" when it was found
+// in the environment, secret store or config and so needs no saving
+func typedKey(key, source string) string {
+	if source != "" {
+		return ""
 	}
+	return key
+}
 
-	cfg := config.DefaultConfig()
+// saveConfig writes the chosen AI settings to the global config, keeping
+// its other settings, and reports whether it did
+func saveConfig(p *prompter, provider ai.Provider, chosen config.AIConfig) bool {
+	if provider == nil {
+		return false
+	}
 
-	// Try to determine provider type from the provider
-	// This is a simplified version - in reality you'd want to store this info
-	configPath := filepath.Join(os.Getenv("HOME"), ".dockerizer.yml")
+	// Keep a typed API key out of the config file when the OS secret store
+	// can hold it
+	apiKey := chosen.APIKey
+	if apiKey != "" {
+		store := secrets.Default()
+		if err := store.Set(chosen.Provider, apiKey); err != nil {
+			fmt.Fprintf(p.out, "  Could not use the OS secret store (%v); saving the API key in the config\n", err)
+		} else {
+			fmt.Fprintf(p.out, "  API key saved in the %s\n", store.Name())
+			apiKey = ""
+		}
+	}
 
-	if err := cfg.Save(configPath); err != nil {
-		fmt.Printf("  Warning: Could not save config: %v\n", err)
-		return
+	configPath, err := config.GlobalPath()
+	if err != nil {
+		fmt.Fprintf(p.out, "  Warning: Could not save config: %v\n", err)
+		return false
+	}
+	settings := []struct{ key, value string }{
+		{"ai.provider", chosen.Provider},
+		{"ai.model", chosen.Model},
+		{"ai.base_url", chosen.BaseURL},
+		{"ai.api_key", apiKey},
+	}
+	for _, s := range settings {
+		if s.value == "" {
+			continue
+		}
+		if err := config.Set(configPath, s.key, s.value); err != nil {
+			fmt.Fprintf(p.out, "  Warning: Could not save config: %v\n", err)
+			return false
+		}
 	}
 
-	fmt.Printf("  Config saved to %s\n", configPath)
+	fmt.Fprintf(p.out, "  Config saved to %s\n", configPath)
+	return true
 }
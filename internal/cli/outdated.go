@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+// OutdatedOutput is the JSON output for outdated command
+type OutdatedOutput struct {
+	Files []OutdatedFile `json:"files"`
+}
+
+// OutdatedFile lists the base images of one Dockerfile that have newer tags
+type OutdatedFile struct {
+	Path    string          `json:"path"`
+	Applied bool            `json:"applied,omitempty"`
+	Images  []OutdatedImage `json:"images,omitempty"`
+}
+
+// OutdatedImage is a base image with a newer tag in its family
+type OutdatedImage struct {
+	Line    int    `json:"line"`
+	Image   string `json:"image"`
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+	Update  string `json:"update"` // major, minor or patch
+}
+
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated [dockerfile...]",
+	Short: "Find newer tags for the base images of Dockerfiles",
+	Long: `List the tags of every base image on its registry and report newer
+versions in the same family: the same variant and precision, so node:20-alpine
+is compared with node:22-alpine and python:3.12-slim with python:3.13-slim.
+Node.js is only moved to even (LTS) major versions.
+
+With --apply the FROM lines are rewritten; images pinned by digest are
+pinned to the digest of their new tag.
+
+Tags without a version (latest, alpine, bookworm-slim), earlier build stages
+and images chosen through an ARG are not checked.
+
+Examples:
+  dockerizer outdated
+  dockerizer outdated Dockerfile worker.Dockerfile
+  dockerizer outdated --apply`,
+	RunE: runOutdated,
+}
+
+func init() {
+	outdatedCmd.Flags().Bool("apply", false, "Rewrite FROM lines to the newest tags")
+	rootCmd.AddCommand(outdatedCmd)
+}
+
+func runOutdated(cmd *cobra.Command, args []string) error {
+	apply, _ := cmd.Flags().GetBool("apply")
+	if len(args) == 0 {
+		args = []string{dockerfileIn(".")}
+	}
+	if err := requireNetwork("outdated"); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	client := registry.New()
+	tagCache := make(map[string][]string)
+
+	var output OutdatedOutput
+	for _, path := range args {
+		file, err := checkOutdated(ctx, client, tagCache, path, apply)
+		if err != nil {
+			printError("%s: %v", path, err)
+			return err
+		}
+		output.Files = append(output.Files, file)
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output)
+	}
+
+	for _, f := range output.Files {
+		if len(f.Images) == 0 {
+			printInfo("%s: base images are up to date", f.Path)
+			continue
+		}
+		printInfo("%s:", f.Path)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  LINE\tIMAGE\tCURRENT\tLATEST\tUPDATE")
+		for _, img := range f.Images {
+			fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\n", img.Line, img.Image, img.Current, img.Latest, img.Update)
+		}
+		w.Flush()
+		if f.Applied {
+			printSuccess("Updated %s", f.Path)
+		}
+	}
+	return nil
+}
+
+// checkOutdated finds newer tags for the base images of the Dockerfile at
+// path, and rewrites them when apply is set
+func checkOutdated(ctx context.Context, client *registry.Client, tagCache map[string][]string, path string, apply bool) (OutdatedFile, error) {
+	file := OutdatedFile{Path: path}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return file, err
+	}
+	df := dockerfile.Parse(string(content))
+	if err := df.Err(); err != nil {
+		return file, err
+	}
+
+	updates := make(map[int]string) // Stage index -> new image
+	for _, stage := range df.Stages {
+		name, pinned, _ := strings.Cut(df.ExpandArgs(stage.Image), "@")
+		if name == "" || strings.Contains(name, "$") || strings.EqualFold(name, "scratch") {
+			continue
+		}
+		if s := df.Stage(name); s != nil && s.Index < stage.Index {
+			continue
+		}
+		ref, err := registry.ParseReference(name)
+		if err != nil || ref.Tag == "" {
+			continue
+		}
+
+		key := ref.Host + "/" + ref.Repository
+		tags, ok := tagCache[key]
+		if !ok {
+			printVerbose("Listing tags of %s...", name)
+			if tags, err = client.Tags(ctx, name); err != nil {
+				return file, fmt.Errorf("failed to list tags of %s: %w", name, err)
+			}
+			tagCache[key] = tags
+		}
+
+		latest, kind := registry.LatestTag(ref.Repository, ref.Tag, tags)
+		if latest == "" {
+			continue
+		}
+		file.Images = append(file.Images, OutdatedImage{
+			Line:    stage.From.StartLine,
+			Image:   strings.TrimSuffix(name, ":"+ref.Tag),
+			Current: ref.Tag,
+			Latest:  latest,
+			Update:  kind,
+		})
+
+		if !apply {
+			continue
+		}
+		image := strings.TrimSuffix(name, ":"+ref.Tag) + ":" + latest
+		if pinned != "" {
+			digest, err := client.Digest(ctx, image)
+			if err != nil {
+				return file, fmt.Errorf("failed to resolve %s: %w", image, err)
+			}
+			image += "@" + digest
+		}
+		updates[stage.Index] = image
+	}
+
+	if len(updates) == 0 {
+		return file, nil
+	}
+	for i, image := range updates {
+		if err := df.SetBaseImage(i, image); err != nil {
+			return file, err
+		}
+	}
+	if err := os.WriteFile(path, []byte(df.String()), 0644); err != nil {
+		return file, err
+	}
+	file.Applied = true
+	return file, nil
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/spf13/cobra"
+)
+
+// PinOutput is the JSON output for pin command
+type PinOutput struct {
+	Files []PinnedFile `json:"files"`
+}
+
+// PinnedFile lists the base images of one Dockerfile
+type PinnedFile struct {
+	Path    string        `json:"path"`
+	Changed bool          `json:"changed"`
+	Images  []PinnedImage `json:"images"`
+}
+
+// PinnedImage is the base image of one build stage
+type PinnedImage struct {
+	Line   int    `json:"line"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+var pinCmd = &cobra.Command{
+	Use:   "pin [dockerfile...]",
+	Short: "Pin base images in Dockerfiles to registry digests",
+	Long: `Resolve the tag of every base image on its registry and pin it by digest,
+e.g. node:20-alpine becomes node:20-alpine@sha256:.... Images pinned already
+get the digest their tag points to now, so running pin again picks up
+rebuilt base images.
+
+Earlier build stages, scratch, digest-only references and images chosen
+through an ARG are left alone. Private registries use the credentials
+stored by docker login in ~/.docker/config.json.
+
+With --check nothing is written; pin fails when a base image is unpinned or
+its digest is out of date, for use in CI.
+
+Examples:
+  dockerizer pin
+  dockerizer pin Dockerfile docker/worker.Dockerfile
+  dockerizer pin --check`,
+	RunE: runPin,
+}
+
+func init() {
+	pinCmd.Flags().Bool("check", false, "Report unpinned or outdated base images without writing")
+	rootCmd.AddCommand(pinCmd)
+}
+
+func runPin(cmd *cobra.Command, args []string) error {
+	check, _ := cmd.Flags().GetBool("check")
+	if len(args) == 0 {
+		args = []string{dockerfileIn(".")}
+	}
+	if err := requireNetwork("pin"); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	resolve := digestResolver(ctx)
+
+	var output PinOutput
+	for _, path := range args {
+		file, content, err := pinDockerfile(path, resolve)
+		if err != nil {
+			printError("%s: %v", path, err)
+			return err
+		}
+		if file.Changed && !check {
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				printError("failed to write %s: %v", path, err)
+				return err
+			}
+		}
+		output.Files = append(output.Files, file)
+	}
+
+	stale := 0
+	for _, f := range output.Files {
+		if f.Changed {
+			stale++
+		}
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(output); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range output.Files {
+			if !f.Changed {
+				printInfo("%s: base images pinned and up to date", f.Path)
+				continue
+			}
+			verb := "pinned"
+			if check {
+				verb = "needs pinning"
+			}
+			printInfo("%s: %s", f.Path, verb)
+			for _, img := range f.Images {
+				if img.Before != img.After {
+					printInfo("  Line %d: %s -> %s", img.Line, img.Before, img.After)
+				}
+			}
+		}
+	}
+
+	if check && stale > 0 {
+		return fmt.Errorf("%d of %d Dockerfiles have unpinned or outdated base images", stale, len(output.Files))
+	}
+	if !check && stale > 0 && !jsonOut {
+		printSuccess("Pinned base images in %d Dockerfiles", stale)
+	}
+	return nil
+}
+
+// pinDockerfile pins the base images of the Dockerfile at path and returns
+// what changed along with the new content
+func pinDockerfile(path string, resolve func(image string) (string, error)) (PinnedFile, string, error) {
+	file := PinnedFile{Path: path}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return file, "", err
+	}
+
+	df := dockerfile.Parse(string(content))
+	if err := df.Err(); err != nil {
+		return file, "", err
+	}
+	before := make([]string, len(df.Stages))
+	for i, s := range df.Stages {
+		before[i] = s.Image
+	}
+
+	n, err := df.PinBaseImages(resolve)
+	if err != nil {
+		return file, "", err
+	}
+	file.Changed = n > 0
+	for i, s := range df.Stages {
+		if s.Image == before[i] && df.Stage(s.Image) != nil {
+			continue // Built on an earlier stage
+		}
+		file.Images = append(file.Images, PinnedImage{Line: s.From.StartLine, Before: before[i], After: s.Image})
+	}
+	return file, df.String(), nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/generator"
 	"github.com/dublyo/dockerizer/internal/scanner"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -35,6 +36,15 @@ type BuildPlan struct {
 
 	// Start command
 	Start StartCommand `json:"start" yaml:"start"`
+
+	// Services, volumes and networks of the generated docker-compose.yml
+	Compose *ComposePlan `json:"compose,omitempty" yaml:"compose,omitempty"`
+
+	// Ports the image exposes (dockerizer import)
+	Ports []string `json:"ports,omitempty" yaml:"ports,omitempty"`
+
+	// Choices of an existing Dockerfile that generation keeps (dockerizer import)
+	Preserved *PreservedPlan `json:"preserved,omitempty" yaml:"preserved,omitempty"`
 }
 
 // DetectionPlan contains detection metadata
@@ -55,6 +65,9 @@ type BuildPhase struct {
 	OnlyInclude []string `json:"only_include,omitempty" yaml:"only_include,omitempty"`
 	CacheDirs   []string `json:"cache_dirs,omitempty" yaml:"cache_dirs,omitempty"`
 	AptPackages []string `json:"apt_packages,omitempty" yaml:"apt_packages,omitempty"`
+	ApkPackages []string `json:"apk_packages,omitempty" yaml:"apk_packages,omitempty"` // apk packages, for stacks built on Alpine
+	Image       string   `json:"image,omitempty" yaml:"image,omitempty"`               // Base image, for imported stages
+	Packages    []string `json:"packages,omitempty" yaml:"packages,omitempty"`         // System packages an imported stage installs
 }
 
 // CacheDir represents a cache directory for Docker buildkit
@@ -69,10 +82,49 @@ type StartCommand struct {
 	Entrypoint string `json:"entrypoint,omitempty" yaml:"entrypoint,omitempty"`
 }
 
+// PreservedPlan lists where an existing Dockerfile departs from the generated
+// one: base images by stage name ("final" for the last stage) and the system
+// packages its final stage adds
+type PreservedPlan struct {
+	BaseImages map[string]string `json:"base_images,omitempty" yaml:"base_images,omitempty"`
+	Packages   []string          `json:"packages,omitempty" yaml:"packages,omitempty"`
+}
+
+// ComposePlan is the topology of the docker-compose.yml dockerize generates
+type ComposePlan struct {
+	Services []ComposeService `json:"services" yaml:"services"`
+	Volumes  []string         `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	Networks []string         `json:"networks,omitempty" yaml:"networks,omitempty"`
+}
+
+// ComposeService represents a service in the compose plan
+type ComposeService struct {
+	Name      string        `json:"name" yaml:"name"`
+	Image     string        `json:"image,omitempty" yaml:"image,omitempty"`
+	Build     *ComposeBuild `json:"build,omitempty" yaml:"build,omitempty"`
+	Ports     []string      `json:"ports,omitempty" yaml:"ports,omitempty"`
+	DependsOn []string      `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+	Volumes   []string      `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+	Networks  []string      `json:"networks,omitempty" yaml:"networks,omitempty"`
+	Profiles  []string      `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// ComposeBuild is how a service's image is built. Services building the
+// same Dockerfile at different targets share the cache of its common stages.
+type ComposeBuild struct {
+	Context    string   `json:"context" yaml:"context"`
+	Dockerfile string   `json:"dockerfile,omitempty" yaml:"dockerfile,omitempty"`
+	Target     string   `json:"target,omitempty" yaml:"target,omitempty"`
+	Args       []string `json:"args,omitempty" yaml:"args,omitempty"` // Build arg names
+}
+
 var planCmd = &cobra.Command{
 	Use:   "plan [path]",
 	Short: "Show the build plan without generating files",
-	Long: `Output the resolved build plan as JSON or YAML.
+	Long: `Output the resolved build plan as JSON or YAML: the build phases of the
+Dockerfile and the services, volumes and networks of docker-compose.yml.
+With --with-deps the compose topology includes the object stores and
+message brokers the app's SDKs use, as dockerize --with-deps generates it.
 
 This is useful for:
   - Debugging detection issues
@@ -89,6 +141,7 @@ Environment Overrides:
 Examples:
   dockerizer plan ./my-project
   dockerizer plan --format yaml ./my-project
+  dockerizer plan --format yaml --with-deps ./my-project
   dockerizer plan --output plan.json ./my-project
   DOCKERIZER_START_CMD="npm start" dockerizer plan .`,
 	Args: cobra.MaximumNArgs(1),
@@ -98,6 +151,7 @@ Examples:
 func init() {
 	planCmd.Flags().String("format", "json", "Output format (json, yaml)")
 	planCmd.Flags().StringP("output", "o", "", "Write plan to file instead of stdout")
+	planCmd.Flags().Bool("with-deps", false, "Include the services the app's SDKs use in the compose topology")
 	rootCmd.AddCommand(planCmd)
 }
 
@@ -109,6 +163,7 @@ func runPlan(cmd *cobra.Command, args []string) error {
 
 	format, _ := cmd.Flags().GetString("format")
 	outputFile, _ := cmd.Flags().GetString("output")
+	withDeps, _ := cmd.Flags().GetBool("with-deps")
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
@@ -127,12 +182,35 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("detection failed: %w", err)
 	}
 
+	// The start command follows the configured static server
+	if server := projectConfig(path).Providers.NodeJS.StaticServer; server != "" && result.Variables["staticServer"] != nil {
+		result.Variables["staticServer"] = server
+	}
+
 	// Build plan
 	plan := buildPlanFromResult(result, scan)
 
 	// Apply environment overrides
 	applyEnvOverrides(&plan)
 
+	// Compose topology, from the compose file dockerize would write
+	if result.Detected {
+		genOpts := append([]generator.Option{
+			generator.WithIgnore(false),
+			generator.WithEnv(false),
+			generator.WithDeps(withDeps),
+		}, projectGeneratorOptions(path, "", "", "", "", "", nil)...)
+		generated, err := generator.New(genOpts...).Generate(result, "")
+		if err != nil {
+			return fmt.Errorf("generation failed: %w", err)
+		}
+		if generated.DockerCompose != "" {
+			if plan.Compose, err = composePlan(generated.DockerCompose); err != nil {
+				return fmt.Errorf("failed to read generated compose file: %w", err)
+			}
+		}
+	}
+
 	// Output
 	var output []byte
 	switch format {
@@ -192,6 +270,12 @@ func buildPlanFromResult(result *detector.DetectionResult, scan *scanner.ScanRes
 		plan.CacheDirs = []CacheDir{
 			{Path: "/root/.cache/pip", ID: "pip-cache"},
 		}
+		switch result.Variables["packageManager"] {
+		case "uv":
+			plan.CacheDirs = []CacheDir{{Path: "/root/.cache/uv", ID: "uv-cache"}}
+		case "pdm":
+			plan.CacheDirs = append(plan.CacheDirs, CacheDir{Path: "/root/.cache/pdm", ID: "pdm-cache"})
+		}
 	case "go":
 		plan.Phases = buildGoPhases(result, scan)
 		plan.CacheDirs = []CacheDir{
@@ -204,6 +288,16 @@ func buildPlanFromResult(result *detector.DetectionResult, scan *scanner.ScanRes
 			{Path: "/usr/local/cargo/registry", ID: "cargo-registry"},
 			{Path: "/app/target", ID: "cargo-target"},
 		}
+	case "deno":
+		plan.Phases = buildDenoPhases(result, scan)
+		plan.CacheDirs = []CacheDir{
+			{Path: "/deno-dir", ID: "deno-cache"},
+		}
+	case "bun":
+		plan.Phases = buildBunPhases(result, scan)
+		plan.CacheDirs = []CacheDir{
+			{Path: "/root/.bun/install/cache", ID: "bun-cache"},
+		}
 	}
 
 	// Determine start command
@@ -218,7 +312,7 @@ func buildNodeJSPhases(result *detector.DetectionResult, scan *scanner.ScanResul
 	// Setup phase
 	setup := BuildPhase{
 		Name:     "setup",
-		Commands: []string{"npm ci --only=production"},
+		Commands: []string{"npm ci --omit=dev"},
 	}
 
 	// Check for package manager
@@ -230,6 +324,11 @@ func buildNodeJSPhases(result *detector.DetectionResult, scan *scanner.ScanResul
 		setup.Commands = []string{"bun install --frozen-lockfile"}
 	}
 
+	// Alpine packages for native addons (node-gyp toolchain and libraries)
+	if pkgs, _ := result.Variables["nativeBuildPackages"].(string); pkgs != "" {
+		setup.ApkPackages = strings.Fields(pkgs)
+	}
+
 	phases = append(phases, setup)
 
 	// Build phase (if needed)
@@ -266,6 +365,13 @@ func buildPythonPhases(result *detector.DetectionResult, scan *scanner.ScanResul
 			"pip install pipenv",
 			"pipenv install --deploy --system",
 		}
+	} else if scan.FileTree.HasFile("uv.lock") {
+		setup.Commands = []string{"uv sync --frozen --no-dev"}
+	} else if scan.FileTree.HasFile("pdm.lock") {
+		setup.Commands = []string{
+			"pip install pdm",
+			"pdm install --check --prod --no-editable",
+		}
 	}
 
 	phases = append(phases, setup)
@@ -288,6 +394,14 @@ func buildGoPhases(result *detector.DetectionResult, scan *scanner.ScanResult) [
 }
 
 func buildRustPhases(result *detector.DetectionResult, scan *scanner.ScanResult) []BuildPhase {
+	build := "cargo build --release"
+	if pkg, _ := result.Variables["cargoPackage"].(string); pkg != "" {
+		build += " -p " + pkg
+	}
+	if bin, _ := result.Variables["binaryName"].(string); bin != "" {
+		build += " --bin " + bin
+	}
+
 	return []BuildPhase{
 		{
 			Name:     "setup",
@@ -296,23 +410,60 @@ func buildRustPhases(result *detector.DetectionResult, scan *scanner.ScanResult)
 		{
 			Name:      "build",
 			DependsOn: []string{"setup"},
-			Commands:  []string{"cargo build --release"},
+			Commands:  []string{build},
+		},
+	}
+}
+
+func buildDenoPhases(result *detector.DetectionResult, scan *scanner.ScanResult) []BuildPhase {
+	entrypoint, _ := result.Variables["entrypoint"].(string)
+	if entrypoint == "" {
+		entrypoint = "main.ts"
+	}
+
+	phases := []BuildPhase{
+		{
+			Name:     "setup",
+			Commands: []string{"deno cache " + entrypoint},
+		},
+	}
+
+	if buildTask, _ := result.Variables["buildTask"].(bool); buildTask {
+		phases = append(phases, BuildPhase{
+			Name:      "build",
+			DependsOn: []string{"setup"},
+			Commands:  []string{"deno task build"},
+		})
+	}
+
+	return phases
+}
+
+func buildBunPhases(result *detector.DetectionResult, scan *scanner.ScanResult) []BuildPhase {
+	phases := []BuildPhase{
+		{
+			Name:     "setup",
+			Commands: []string{"bun install --frozen-lockfile"},
 		},
 	}
+
+	if hasBuildScript(scan) {
+		phases = append(phases, BuildPhase{
+			Name:      "build",
+			DependsOn: []string{"setup"},
+			Commands:  []string{"bun run build"},
+		})
+	}
+
+	return phases
 }
 
 func determineStartCommand(result *detector.DetectionResult, scan *scanner.ScanResult) StartCommand {
 	// Check for Procfile first
-	for _, kf := range scan.KeyFiles {
-		if kf.Path == "Procfile" {
-			// Parse Procfile for web process
-			lines := parseProcfileLines(kf.Content)
-			for _, line := range lines {
-				if strings.HasPrefix(line, "web:") {
-					return StartCommand{
-						Cmd: strings.TrimSpace(strings.TrimPrefix(line, "web:")),
-					}
-				}
+	if scan.Metadata != nil {
+		for _, proc := range scan.Metadata.Procfile {
+			if proc.Name == "web" {
+				return StartCommand{Cmd: proc.Command}
 			}
 		}
 	}
@@ -332,7 +483,31 @@ func determineStartCommand(result *detector.DetectionResult, scan *scanner.ScanR
 	case "gin", "fiber", "echo":
 		return StartCommand{Cmd: "./server"}
 	case "actix", "axum":
+		if bin, _ := result.Variables["binaryName"].(string); bin != "" {
+			return StartCommand{Cmd: "./target/release/" + bin}
+		}
 		return StartCommand{Cmd: "./app"}
+	case "vite", "cra", "angular", "vuecli", "gatsby":
+		if result.Variables["staticServer"] == "caddy" {
+			return StartCommand{Cmd: "caddy run --config /etc/caddy/Caddyfile"}
+		}
+		return StartCommand{Cmd: "nginx -g 'daemon off;'"}
+	case "deno":
+		entrypoint, _ := result.Variables["entrypoint"].(string)
+		if entrypoint == "" {
+			entrypoint = "main.ts"
+		}
+		perms, _ := result.Variables["permissions"].([]string)
+		return StartCommand{Cmd: strings.Join(append(append([]string{"deno", "run"}, perms...), entrypoint), " ")}
+	case "bun":
+		if scan.Metadata.PackageJSON.HasScript("start") {
+			return StartCommand{Cmd: "bun run start"}
+		}
+		entrypoint, _ := result.Variables["entrypoint"].(string)
+		if entrypoint == "" {
+			entrypoint = "index.ts"
+		}
+		return StartCommand{Cmd: "bun run " + entrypoint}
 	}
 
 	return StartCommand{}
@@ -348,17 +523,6 @@ func hasBuildScript(scan *scanner.ScanResult) bool {
 	return false
 }
 
-func parseProcfileLines(s string) []string {
-	var lines []string
-	for _, line := range strings.Split(s, "\n") {
-		line = strings.TrimSpace(line)
-		if line != "" && !strings.HasPrefix(line, "#") {
-			lines = append(lines, line)
-		}
-	}
-	return lines
-}
-
 func applyEnvOverrides(plan *BuildPlan) {
 	// Override build command
 	if cmd := os.Getenv("DOCKERIZER_BUILD_CMD"); cmd != "" {
@@ -391,3 +555,96 @@ func applyEnvOverrides(plan *BuildPlan) {
 		}
 	}
 }
+
+// composePlan reads the topology of a compose file
+func composePlan(content string) (*ComposePlan, error) {
+	var doc struct {
+		Services yaml.Node `yaml:"services"`
+		Volumes  yaml.Node `yaml:"volumes"`
+		Networks yaml.Node `yaml:"networks"`
+	}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		return nil, err
+	}
+
+	plan := &ComposePlan{
+		Services: []ComposeService{},
+		Volumes:  composeItems(&doc.Volumes),
+		Networks: composeItems(&doc.Networks),
+	}
+	for i := 0; i+1 < len(doc.Services.Content); i += 2 {
+		var raw struct {
+			Image     string    `yaml:"image"`
+			Build     yaml.Node `yaml:"build"`
+			Ports     yaml.Node `yaml:"ports"`
+			DependsOn yaml.Node `yaml:"depends_on"`
+			Volumes   yaml.Node `yaml:"volumes"`
+			Networks  yaml.Node `yaml:"networks"`
+			Profiles  []string  `yaml:"profiles"`
+		}
+		name := doc.Services.Content[i].Value
+		if err := doc.Services.Content[i+1].Decode(&raw); err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+
+		service := ComposeService{
+			Name:      name,
+			Image:     raw.Image,
+			Ports:     composeItems(&raw.Ports),
+			DependsOn: composeItems(&raw.DependsOn),
+			Volumes:   composeItems(&raw.Volumes),
+			Networks:  composeItems(&raw.Networks),
+			Profiles:  raw.Profiles,
+		}
+		switch raw.Build.Kind {
+		case yaml.ScalarNode:
+			service.Build = &ComposeBuild{Context: raw.Build.Value}
+		case yaml.MappingNode:
+			var build struct {
+				Context    string    `yaml:"context"`
+				Dockerfile string    `yaml:"dockerfile"`
+				Target     string    `yaml:"target"`
+				Args       yaml.Node `yaml:"args"`
+			}
+			if err := raw.Build.Decode(&build); err != nil {
+				return nil, fmt.Errorf("service %s: %w", name, err)
+			}
+			service.Build = &ComposeBuild{Context: build.Context, Dockerfile: build.Dockerfile, Target: build.Target}
+			for _, arg := range composeItems(&build.Args) {
+				arg, _, _ = strings.Cut(arg, "=")
+				service.Build.Args = append(service.Build.Args, arg)
+			}
+		}
+		plan.Services = append(plan.Services, service)
+	}
+	return plan, nil
+}
+
+// composeItems lists a compose sequence, or the keys of a compose mapping:
+// depends_on, networks and build args are written either way. Long syntax
+// ports and volumes are shortened to source:target.
+func composeItems(n *yaml.Node) []string {
+	var items []string
+	switch n.Kind {
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if item.Kind == yaml.ScalarNode {
+				items = append(items, item.Value)
+				continue
+			}
+			var long struct {
+				Source    string `yaml:"source"`
+				Published string `yaml:"published"`
+				Target    string `yaml:"target"`
+			}
+			if item.Decode(&long) == nil && long.Target != "" {
+				items = append(items, strings.TrimPrefix(long.Source+long.Published+":", ":")+long.Target)
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(n.Content); i += 2 {
+			items = append(items, n.Content[i].Value)
+		}
+	}
+	return items
+}
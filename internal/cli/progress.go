@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// progress reports the phases of a run with --verbose: live counts of the
+// files scanned and providers evaluated, updated in place when stderr is a
+// terminal, and the time each phase took
+type progress struct {
+	enabled bool
+	live    bool // Counts are redrawn on one stderr line
+	drawn   bool // The live line holds a count to clear
+	phases  []phaseTime
+	current string
+	start   time.Time
+}
+
+// phaseTime is how long a phase of a run took, summed over its repeats
+type phaseTime struct {
+	name    string
+	elapsed time.Duration
+}
+
+func newProgress() *progress {
+	enabled := verbose && !quiet
+	return &progress{enabled: enabled, live: enabled && stderrIsTerminal()}
+}
+
+// stderrIsTerminal reports whether progress can be redrawn in place
+func stderrIsTerminal() bool {
+	fi, err := os.Stderr.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb"
+}
+
+// begin ends the current phase, if any, and starts timing the next
+func (p *progress) begin(name string) {
+	p.end()
+	p.current, p.start = name, time.Now()
+}
+
+// end stops timing the current phase and clears its live line
+func (p *progress) end() {
+	if p.current == "" {
+		return
+	}
+	name, elapsed := p.current, time.Since(p.start)
+	p.current = ""
+	p.clear()
+	for i := range p.phases {
+		if p.phases[i].name == name {
+			p.phases[i].elapsed += elapsed
+			return
+		}
+	}
+	p.phases = append(p.phases, phaseTime{name: name, elapsed: elapsed})
+}
+
+// clear erases the live line
+func (p *progress) clear() {
+	if p.drawn {
+		fmt.Fprint(os.Stderr, "\r\x1b[K")
+		p.drawn = false
+	}
+}
+
+// update redraws the live line of the current phase
+func (p *progress) update(format string, args ...interface{}) {
+	if !p.live {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r\x1b[K  "+format, args...)
+	p.drawn = true
+}
+
+// scanOption reports the files found while scanning
+func (p *progress) scanOption() scanner.Option {
+	return scanner.WithProgress(func(files int) {
+		p.update("Scanning: %d files", files)
+	})
+}
+
+// detectOption reports the providers evaluated while detecting
+func (p *progress) detectOption() detector.Option {
+	return detector.WithProgress(func(provider string, done, total int) {
+		if provider == "" {
+			p.clear()
+			printVerbose("Evaluated %d providers", total)
+			return
+		}
+		p.update("Detecting: %d/%d providers (%s)", done, total, provider)
+	})
+}
+
+// summary ends the current phase and prints how long each took
+func (p *progress) summary() {
+	p.end()
+	if !p.enabled || len(p.phases) == 0 {
+		return
+	}
+	parts := make([]string, 0, len(p.phases))
+	for _, ph := range p.phases {
+		// 12ms under a second, 1.2s above
+		elapsed := ph.elapsed.Round(time.Millisecond)
+		if elapsed >= time.Second {
+			elapsed = ph.elapsed.Round(100 * time.Millisecond)
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", ph.name, elapsed))
+	}
+	printVerbose("Timing: %s", strings.Join(parts, ", "))
+}
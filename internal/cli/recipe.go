@@ -3,6 +3,8 @@ package cli
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/dublyo/dockerizer/internal/agent"
@@ -10,6 +12,39 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// RecipeOutput is the JSON output of a recipe run
+type RecipeOutput struct {
+	Recipe   string       `json:"recipe"`
+	Success  bool         `json:"success"`
+	Steps    []RecipeStep `json:"steps"`
+	Duration string       `json:"duration"`
+}
+
+// RecipeStep is the result of one step, or one foreach iteration
+type RecipeStep struct {
+	Name     string `json:"name"`
+	Success  bool   `json:"success"`
+	Attempts int    `json:"attempts"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// RecipeEntry is a recipe in the JSON output of recipe list
+type RecipeEntry struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Source      string `json:"source"`
+	Path        string `json:"path,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// RecipeTool is a tool in the JSON output of recipe list --tools
+type RecipeTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
 var recipeCmd = &cobra.Command{
 	Use:   "recipe [name]",
 	Short: "Run a predefined recipe workflow",
@@ -20,6 +55,7 @@ Built-in recipes:
   generate       - Generate Docker configuration
   build-and-test - Generate, build, and test
   full-deploy    - Complete deployment workflow
+  monorepo       - Generate for every app in a monorepo
 
 Examples:
   dockerizer recipe analyze --path ./my-project
@@ -27,7 +63,23 @@ Examples:
   dockerizer recipe build-and-test --path ./my-project --image-tag myapp:v1
 
 Custom recipes from file:
-  dockerizer recipe --file ./my-recipe.yaml`,
+  dockerizer recipe --file ./my-recipe.yaml
+
+Recipes in .dockerizer/recipes/*.yaml of the project (--path) and in
+~/.dockerizer/recipes are found by file name and listed by "recipe list".
+A project recipe shadows a user recipe of the same name, and both shadow
+a built-in. Scaffold one with "dockerizer recipe init <name>".
+
+Steps with an id expose their output as ${steps.<id>}; JSON output can be
+addressed by path, e.g. ${steps.analyze.language} or ${last_output.files[0]}.
+Conditions support ==, !=, contains, and/or/not (&&, ||, !) and parentheses.
+A foreach step runs once per list item, available as ${item} (or the name
+set with as:) and ${index}.
+
+Steps run in order unless a step sets depends_on (a list of step ids); then
+each step starts once its dependencies finish and independent steps run
+concurrently, up to parallelism (recipe key or --parallel). timeout bounds
+each attempt of a step, e.g. timeout: 10m.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runRecipe,
 }
@@ -38,13 +90,33 @@ var recipeListCmd = &cobra.Command{
 	RunE:  runRecipeList,
 }
 
+var recipeInitCmd = &cobra.Command{
+	Use:   "init <name>",
+	Short: "Scaffold a new recipe",
+	Long: `Write a commented template recipe to .dockerizer/recipes/<name>.yaml in the
+project (or ~/.dockerizer/recipes with --global), next to a JSON schema
+that editors with YAML language support use to validate and complete it.
+
+Examples:
+  dockerizer recipe init release
+  dockerizer recipe init nightly --global`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRecipeInit,
+}
+
 func init() {
 	recipeCmd.Flags().String("file", "", "Path to custom recipe YAML file")
-	recipeCmd.Flags().String("path", ".", "Path to the project")
+	recipeCmd.PersistentFlags().String("path", ".", "Path to the project")
 	recipeCmd.Flags().String("image-tag", "app:latest", "Docker image tag")
 	recipeCmd.Flags().StringToString("var", nil, "Set recipe variables (key=value)")
+	recipeCmd.Flags().Int("parallel", 0, "Maximum steps to run at once with depends_on (default: recipe setting or 4)")
+
+	recipeListCmd.Flags().Bool("tools", false, "List the tools steps can use")
+	recipeInitCmd.Flags().Bool("global", false, "Create the recipe in ~/.dockerizer/recipes")
+	recipeInitCmd.Flags().Bool("force", false, "Overwrite an existing recipe")
 
 	recipeCmd.AddCommand(recipeListCmd)
+	recipeCmd.AddCommand(recipeInitCmd)
 	rootCmd.AddCommand(recipeCmd)
 }
 
@@ -53,6 +125,7 @@ func runRecipe(cmd *cobra.Command, args []string) error {
 	projectPath, _ := cmd.Flags().GetString("path")
 	imageTag, _ := cmd.Flags().GetString("image-tag")
 	extraVars, _ := cmd.Flags().GetStringToString("var")
+	parallel, _ := cmd.Flags().GetInt("parallel")
 
 	var r *recipe.Recipe
 	var err error
@@ -64,11 +137,15 @@ func runRecipe(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to load recipe: %w", err)
 		}
 	} else if len(args) > 0 {
-		// Load built-in recipe
-		r, err = recipe.GetBuiltinRecipe(args[0])
+		// Load a project, user or built-in recipe
+		var entry recipe.Entry
+		r, entry, err = recipe.Find(projectPath, args[0])
 		if err != nil {
 			return err
 		}
+		if entry.Path != "" {
+			printVerbose("Loaded %s", entry.Path)
+		}
 	} else {
 		return fmt.Errorf("specify a recipe name or --file")
 	}
@@ -78,9 +155,14 @@ func runRecipe(cmd *cobra.Command, args []string) error {
 
 	// Create tool executor
 	toolDispatcher := agent.NewToolDispatcher(projectPath)
+	toolDispatcher.SetRedaction(!noRedact)
+	if err := checkRecipeTools(r, toolDispatcher); err != nil {
+		return err
+	}
 
 	// Create executor
 	executor := recipe.NewExecutor(&toolExecutorAdapter{td: toolDispatcher})
+	executor.SetParallelism(parallel)
 
 	// Set variables
 	executor.SetVariable("path", projectPath)
@@ -92,12 +174,33 @@ func runRecipe(cmd *cobra.Command, args []string) error {
 	// Execute recipe
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
 	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
 
+	start := time.Now()
 	result, err := executor.Execute(ctx, r)
+	// An interrupted or timed-out recipe leaves no containers behind
+	if ctx.Err() != nil {
+		for _, res := range toolDispatcher.Cleanup(ctx, false) {
+			printVerbose("Removed %s", res)
+		}
+	}
 	if err != nil {
 		return fmt.Errorf("recipe failed: %w", err)
 	}
 
+	if jsonOut {
+		output := RecipeOutput{Recipe: r.Name, Success: result.Success, Steps: []RecipeStep{}, Duration: time.Since(start).Round(time.Millisecond).String()}
+		for _, step := range result.Steps {
+			s := RecipeStep{Name: step.Name, Success: step.Success, Attempts: step.Attempts, Output: step.Output, Duration: step.Duration.Round(time.Millisecond).String()}
+			if step.Error != nil {
+				s.Error = step.Error.Error()
+			}
+			output.Steps = append(output.Steps, s)
+		}
+		return printJSON(output)
+	}
+
 	// Print results
 	printInfo("")
 	for _, step := range result.Steps {
@@ -118,17 +221,101 @@ func runRecipe(cmd *cobra.Command, args []string) error {
 }
 
 func runRecipeList(cmd *cobra.Command, args []string) error {
-	printInfo("Available built-in recipes:")
+	projectPath, _ := cmd.Flags().GetString("path")
+	showTools, _ := cmd.Flags().GetBool("tools")
+
+	if showTools && jsonOut {
+		tools := []RecipeTool{}
+		for _, tool := range sortedTools(agent.NewToolDispatcher(projectPath)) {
+			tools = append(tools, RecipeTool{Name: tool.Name(), Description: tool.Description()})
+		}
+		return printJSON(tools)
+	}
+	if showTools {
+		printInfo("Tools available to recipe steps:")
+		printInfo("")
+		for _, tool := range sortedTools(agent.NewToolDispatcher(projectPath)) {
+			printInfo("  %-22s - %s", tool.Name(), tool.Description())
+		}
+		return nil
+	}
+
+	if jsonOut {
+		entries := []RecipeEntry{}
+		for _, entry := range recipe.Discover(projectPath) {
+			e := RecipeEntry{Name: entry.Name, Description: entry.Description, Source: entry.Source, Path: entry.Path}
+			if entry.Err != nil {
+				e.Error = entry.Err.Error()
+			}
+			entries = append(entries, e)
+		}
+		return printJSON(entries)
+	}
+
+	printInfo("Available recipes:")
 	printInfo("")
 
-	for _, name := range recipe.ListBuiltinRecipes() {
-		r, _ := recipe.GetBuiltinRecipe(name)
-		printInfo("  %-15s - %s", name, r.Description)
+	for _, entry := range recipe.Discover(projectPath) {
+		switch {
+		case entry.Err != nil:
+			printInfo("  %-15s - [%s] invalid: %s", entry.Name, entry.Source, strings.Join(strings.Fields(entry.Err.Error()), " "))
+		case entry.Source == recipe.SourceBuiltin:
+			printInfo("  %-15s - %s", entry.Name, entry.Description)
+		default:
+			printInfo("  %-15s - %s [%s]", entry.Name, entry.Description, entry.Source)
+		}
 	}
 
 	return nil
 }
 
+func runRecipeInit(cmd *cobra.Command, args []string) error {
+	projectPath, _ := cmd.Flags().GetString("path")
+	global, _ := cmd.Flags().GetBool("global")
+	force, _ := cmd.Flags().GetBool("force")
+
+	dir := recipe.ProjectRecipeDir(projectPath)
+	if global {
+		var err error
+		if dir, err = recipe.UserRecipeDir(); err != nil {
+			return err
+		}
+	}
+
+	path, err := recipe.Scaffold(dir, args[0], force)
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		return printJSON(map[string]string{"path": path})
+	}
+
+	printSuccess("Created %s", path)
+	printInfo("Edit the steps, then run: dockerizer recipe %s --path %s", args[0], projectPath)
+	return nil
+}
+
+// checkRecipeTools fails when a step names a tool the dispatcher does not have
+func checkRecipeTools(r *recipe.Recipe, td *agent.ToolDispatcher) error {
+	known := make(map[string]bool)
+	for _, tool := range td.ListTools() {
+		known[tool.Name()] = true
+	}
+	for _, step := range r.Steps {
+		if !known[step.Tool] {
+			return fmt.Errorf("step %q uses unknown tool %q (see dockerizer recipe list --tools)", step.Name, step.Tool)
+		}
+	}
+	return nil
+}
+
+// sortedTools returns the dispatcher's tools by name
+func sortedTools(td *agent.ToolDispatcher) []agent.Tool {
+	tools := td.ListTools()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name() < tools[j].Name() })
+	return tools
+}
+
 // toolExecutorAdapter adapts ToolDispatcher to recipe.ToolExecutor
 type toolExecutorAdapter struct {
 	td *agent.ToolDispatcher
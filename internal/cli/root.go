@@ -4,9 +4,15 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/dublyo/dockerizer/internal/errors"
+	"github.com/dublyo/dockerizer/internal/generator"
 	"github.com/spf13/cobra"
 )
 
@@ -17,9 +23,11 @@ var (
 	GitCommit = "unknown"
 
 	// Global flags
-	verbose bool
-	quiet   bool
-	jsonOut bool
+	verbose  bool
+	quiet    bool
+	jsonOut  bool
+	noRedact bool
+	offline  bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -48,6 +56,13 @@ Examples:
 
 For more information, visit: https://dockerizer.dev`,
 	Args: cobra.MaximumNArgs(1),
+	// With --json, stdout carries the JSON result alone: progress and
+	// summaries are left out as with --quiet, and errors go to stderr
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if jsonOut {
+			quiet = true
+		}
+	},
 	RunE: runDockerize,
 }
 
@@ -63,14 +78,12 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress non-essential output")
 	rootCmd.PersistentFlags().BoolVar(&jsonOut, "json", false, "Output in JSON format")
+	rootCmd.PersistentFlags().BoolVar(&noRedact, "no-redact", false, "Send file contents to AI providers without redacting secrets")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", os.Getenv("DOCKERIZER_OFFLINE") != "", "Never use the network: no AI providers, registry queries or availability probes")
 
 	// Dockerizer-specific flags
-	rootCmd.Flags().Bool("ai", false, "Force AI generation even for detected stacks")
-	rootCmd.Flags().Bool("no-compose", false, "Skip docker-compose.yml generation")
-	rootCmd.Flags().Bool("no-ignore", false, "Skip .dockerignore generation")
-	rootCmd.Flags().Bool("no-env", false, "Skip .env.example generation")
-	rootCmd.Flags().BoolP("force", "f", false, "Overwrite existing files")
-	rootCmd.Flags().StringP("output", "o", "", "Output directory (default: same as input)")
+	addDockerizeFlags(rootCmd)
+	rootCmd.Flags().Bool("dry-run", false, "Print the generated files instead of writing them")
 
 	// Add subcommands (agent, serve, recipe add themselves in their own init())
 	rootCmd.AddCommand(detectCmd)
@@ -78,6 +91,43 @@ func init() {
 	rootCmd.AddCommand(validateCmd)
 }
 
+// addDockerizeFlags adds the generation flags shared by the root and
+// generate commands
+func addDockerizeFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("ai", false, "Force AI generation even for detected stacks")
+	cmd.Flags().Bool("no-compose", false, "Skip docker-compose.yml generation")
+	cmd.Flags().Bool("no-ignore", false, "Skip .dockerignore generation")
+	cmd.Flags().Bool("no-env", false, "Skip .env.example generation")
+	cmd.Flags().Bool("docs", false, "Also generate DOCKER.md with build and run instructions")
+	cmd.Flags().StringSlice("only", nil, "Generate only these files: dockerfile, compose, ignore, env, docs")
+	cmd.Flags().String("dockerfile-path", "", "Write the Dockerfile here, relative to the output directory")
+	cmd.Flags().String("compose-path", "", "Write docker-compose.yml here, relative to the output directory")
+	cmd.Flags().Bool("containerfile", false, "Name the Dockerfile Containerfile, as Podman and Buildah expect")
+	cmd.Flags().BoolP("force", "f", false, "Overwrite existing files")
+	cmd.Flags().Bool("backup", false, "With --force, save each file that changes as <name>.bak first")
+	cmd.Flags().StringP("output", "o", "", "Output directory (default: same as input)")
+	cmd.Flags().String("app", "", "Workspace package to dockerize in a monorepo (name or directory)")
+	cmd.Flags().String("target", "", "Project to build in an Nx, Gradle or Bazel repository (name or directory)")
+	cmd.Flags().String("go-base-image", "", "Final stage for Go apps: alpine, distroless or scratch")
+	cmd.Flags().String("base", "", "Base images: alpine, debian, or auto (Debian when a dependency needs glibc; default)")
+	cmd.Flags().String("user", "", "UID[:GID] the images run as (default "+generator.DefaultUser+", OpenShift-compatible)")
+	cmd.Flags().String("windows", "", "Build .NET apps as Windows containers: nanoserver, servercore or linux (default: detected)")
+	cmd.Flags().Lookup("windows").NoOptDefVal = "nanoserver"
+	cmd.Flags().String("gpu", "", "Python ML apps: cuda (CUDA runtime image), cpu (CPU-only PyTorch wheels) or none (default: detected)")
+	cmd.Flags().Lookup("gpu").NoOptDefVal = "cuda"
+	cmd.Flags().String("java-runtime", "", "Spring Boot runtime: jre (the fat jar), layered (extracted jar layers) or jlink (layers on a custom runtime)")
+	cmd.Flags().String("proxy", "", "Reverse proxy service in docker-compose.yml: traefik, nginx, caddy or none")
+	cmd.Flags().Bool("dev", false, "Add docker compose watch rules, running the framework's dev server with hot reload")
+	cmd.Flags().Bool("harden", false, "Run the app read-only in docker-compose.yml, with tmpfs for the paths it writes, no capabilities and no privilege escalation")
+	cmd.Flags().Bool("with-tests", false, "Add a test stage running the test suite (npm test, pytest, go test, cargo test), built with docker build --target test")
+	cmd.Flags().Bool("with-deps", false, "Run the object stores and message brokers the app's SDKs use (minio, redpanda, nats) in docker-compose.yml")
+	cmd.Flags().StringSlice("env", nil, "Also generate docker-compose.<env>.yml overrides (dev, staging, prod or configured)")
+	cmd.Flags().Bool("pin-digests", false, "Pin base images to the digests their tags resolve to on the registry")
+	cmd.Flags().Bool("cache-mounts", false, "Use BuildKit cache mounts for package manager caches (default: when BuildKit is available)")
+	cmd.Flags().Bool("size", false, "Estimate the size of the generated image")
+	cmd.Flags().Bool("check", false, "Lint the generated Dockerfile with BuildKit's build checks (docker build --check)")
+}
+
 // runDockerize is the main command handler
 func runDockerize(cmd *cobra.Command, args []string) error {
 	// Get path argument
@@ -87,19 +137,81 @@ func runDockerize(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get flags
-	forceAI, _ := cmd.Flags().GetBool("ai")
 	noCompose, _ := cmd.Flags().GetBool("no-compose")
 	noIgnore, _ := cmd.Flags().GetBool("no-ignore")
 	noEnv, _ := cmd.Flags().GetBool("no-env")
-	force, _ := cmd.Flags().GetBool("force")
-	outputDir, _ := cmd.Flags().GetString("output")
-
-	if outputDir == "" {
-		outputDir = path
+	docs, _ := cmd.Flags().GetBool("docs")
+	only, _ := cmd.Flags().GetStringSlice("only")
+	opts := dockerizeOptions{
+		includeDockerfile: true,
+		includeCompose:    !noCompose,
+		includeIgnore:     !noIgnore,
+		includeEnv:        !noEnv,
+		includeDocs:       docs,
+	}
+	opts.forceAI, _ = cmd.Flags().GetBool("ai")
+	opts.overwrite, _ = cmd.Flags().GetBool("force")
+	opts.backup, _ = cmd.Flags().GetBool("backup")
+	opts.outputDir, _ = cmd.Flags().GetString("output")
+	opts.goBaseImage, _ = cmd.Flags().GetString("go-base-image")
+	opts.base, _ = cmd.Flags().GetString("base")
+	opts.user, _ = cmd.Flags().GetString("user")
+	opts.proxy, _ = cmd.Flags().GetString("proxy")
+	opts.windows, _ = cmd.Flags().GetString("windows")
+	opts.gpu, _ = cmd.Flags().GetString("gpu")
+	opts.javaRuntime, _ = cmd.Flags().GetString("java-runtime")
+	opts.envs, _ = cmd.Flags().GetStringSlice("env")
+	opts.dev, _ = cmd.Flags().GetBool("dev")
+	opts.withDeps, _ = cmd.Flags().GetBool("with-deps")
+	opts.harden, _ = cmd.Flags().GetBool("harden")
+	opts.withTests, _ = cmd.Flags().GetBool("with-tests")
+	opts.app, _ = cmd.Flags().GetString("app")
+	opts.target, _ = cmd.Flags().GetString("target")
+	opts.dockerfilePath, _ = cmd.Flags().GetString("dockerfile-path")
+	opts.composePath, _ = cmd.Flags().GetString("compose-path")
+	if containerfile, _ := cmd.Flags().GetBool("containerfile"); containerfile {
+		if opts.dockerfilePath != "" {
+			return fmt.Errorf("--containerfile and --dockerfile-path both name the Dockerfile; use one")
+		}
+		opts.dockerfilePath = "Containerfile"
+	}
+	opts.pinDigests, _ = cmd.Flags().GetBool("pin-digests")
+	opts.size, _ = cmd.Flags().GetBool("size")
+	opts.check, _ = cmd.Flags().GetBool("check")
+	if cmd.Flags().Changed("cache-mounts") {
+		cacheMounts, _ := cmd.Flags().GetBool("cache-mounts")
+		opts.cacheMounts = &cacheMounts
+	}
+	opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+	if stdout, _ := cmd.Flags().GetBool("stdout"); stdout {
+		opts.dryRun = true
+	}
+	if len(only) > 0 {
+		if err := opts.selectOnly(only); err != nil {
+			return err
+		}
+	}
+	if err := generator.CheckOutputPath("--dockerfile-path", opts.dockerfilePath); err != nil {
+		return err
+	}
+	if err := generator.CheckOutputPath("--compose-path", opts.composePath); err != nil {
+		return err
 	}
+	if opts.dryRun {
+		// Keep stdout for the files themselves
+		quiet = true
+	}
+
+	// Run the dockerizer workflow (an empty output dir means the project dir)
+	return executeDockerize(path, opts)
+}
 
-	// Run the dockerizer workflow
-	return executeDockerize(path, outputDir, forceAI, force, !noCompose, !noIgnore, !noEnv)
+// requireNetwork fails when feature needs the network and --offline is set
+func requireNetwork(feature string) error {
+	if offline {
+		return fmt.Errorf("%s needs the network: %w", feature, errors.ErrOffline)
+	}
+	return nil
 }
 
 // Print helpers
@@ -124,3 +236,22 @@ func printSuccess(format string, args ...interface{}) {
 		fmt.Printf("✓ "+format+"\n", args...)
 	}
 }
+
+// interruptible cancels ctx on Ctrl+C or SIGTERM, so a long-running command
+// stops what it started and cleans up before exiting. Once cancelled, the
+// signals are let through again: a second Ctrl+C exits at once.
+func interruptible(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// printJSON writes the JSON result of a command to stdout
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/imagesize"
+	"github.com/dublyo/dockerizer/internal/registry"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/spf13/cobra"
+)
+
+// SizeOutput is the JSON output for size command
+type SizeOutput struct {
+	Path     string            `json:"path"`
+	Estimate *imagesize.Report `json:"estimate"`
+	Image    *MeasuredImage    `json:"image,omitempty"`
+	Budget   int64             `json:"budget,omitempty"`
+	Over     bool              `json:"over_budget,omitempty"`
+}
+
+// MeasuredImage is the size of a built image, from docker history
+type MeasuredImage struct {
+	Name   string                   `json:"name"`
+	Total  int64                    `json:"total"` // Uncompressed
+	Layers []imagesize.HistoryLayer `json:"layers"`
+}
+
+var sizeCmd = &cobra.Command{
+	Use:   "size [path]",
+	Short: "Estimate the size of the image a Dockerfile builds",
+	Long: `Estimate the size of the final image, stage by stage and layer by layer,
+from the size of the base images on their registries and the dependencies
+in the project's manifests, with suggestions to make it smaller. The path is
+a Dockerfile or a directory with one (default: .).
+
+Sizes are compressed, as a pull downloads them. With --offline, or when a
+registry can't be reached, base images are sized from a table of common
+images. With --image the layers of a built image are listed from docker
+history too (uncompressed, as docker images shows them).
+
+With --budget the command fails when the image is larger, for use in CI;
+a measured image is held to it rather than the estimate.
+
+Examples:
+  dockerizer size
+  dockerizer size ./my-project --budget 250MB
+  dockerizer size --image myapp:latest`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSize,
+}
+
+func init() {
+	sizeCmd.Flags().String("image", "", "Also measure this built image with docker history")
+	sizeCmd.Flags().String("budget", "", "Fail when the image is larger than this, e.g. 250MB")
+	sizeCmd.Flags().String("platform", "", "Platform of the base images (default: linux/amd64)")
+	rootCmd.AddCommand(sizeCmd)
+}
+
+func runSize(cmd *cobra.Command, args []string) error {
+	image, _ := cmd.Flags().GetString("image")
+	budgetFlag, _ := cmd.Flags().GetString("budget")
+	platform, _ := cmd.Flags().GetString("platform")
+
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = dockerfileIn(path)
+	}
+	output := SizeOutput{Path: path}
+	if budgetFlag != "" {
+		budget, err := imagesize.ParseSize(budgetFlag)
+		if err != nil {
+			return err
+		}
+		output.Budget = budget
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		printError("failed to read file: %v", err)
+		return err
+	}
+	df := dockerfile.Parse(string(content))
+	if err := df.Err(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	scan, err := scanner.New().Scan(ctx, filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	output.Estimate = imagesize.Estimate(df, imagesize.Options{Scan: scan, BaseSize: baseSizer(ctx, platform)})
+
+	total := output.Estimate.Total
+	if image != "" {
+		measured, err := measureImage(ctx, image)
+		if err != nil {
+			printError("failed to measure %s: %v", image, err)
+			return err
+		}
+		output.Image = measured
+		total = measured.Total
+	}
+	output.Over = output.Budget > 0 && total > output.Budget
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(output); err != nil {
+			return err
+		}
+	} else {
+		printSizeReport(output)
+	}
+	if output.Over {
+		return fmt.Errorf("image is %s, over the %s budget", imagesize.Format(total), imagesize.Format(output.Budget))
+	}
+	return nil
+}
+
+// baseSizer looks up base image sizes on their registries; nil offline
+func baseSizer(ctx context.Context, platform string) func(image string) (int64, error) {
+	if offline {
+		return nil
+	}
+	client := registry.New()
+	return func(image string) (int64, error) {
+		printVerbose("Looking up the size of %s...", image)
+		size, err := client.Size(ctx, image, platform)
+		if err != nil {
+			printVerbose("Could not size %s on its registry: %v", image, err)
+		}
+		return size, err
+	}
+}
+
+// measureImage reads the layers of a built image from docker history
+func measureImage(ctx context.Context, image string) (*MeasuredImage, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, fmt.Errorf("docker not found")
+	}
+	out, err := exec.CommandContext(ctx, "docker", "history", "--no-trunc", "--format", "{{json .}}", image).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("%s", exitErr.Stderr)
+		}
+		return nil, err
+	}
+	layers, err := imagesize.ParseHistory(string(out))
+	if err != nil {
+		return nil, err
+	}
+	measured := &MeasuredImage{Name: image, Layers: layers}
+	for _, l := range layers {
+		measured.Total += l.Size
+	}
+	return measured, nil
+}
+
+// printSizeReport prints an estimate stage by stage
+func printSizeReport(output SizeOutput) {
+	report := output.Estimate
+	fmt.Printf("%s: about %s (compressed)\n", output.Path, imagesize.Format(report.Total))
+	for _, s := range report.Stages {
+		role := "build stage, not shipped"
+		if s.Shipped {
+			role = "shipped"
+		}
+		fmt.Printf("\nStage %s (%s, %s): %s\n", s.Name, s.Base, role, imagesize.Format(s.Size))
+		fmt.Printf("  %-10s %-44s %s\n", imagesize.Format(s.BaseSize), "base image", s.BaseSource)
+		for _, l := range s.Layers {
+			fmt.Printf("  %-10s %-44s line %d: %s\n", imagesize.Format(l.Size), l.Instruction, l.Line, l.Basis)
+		}
+	}
+	if len(report.Suggestions) > 0 {
+		fmt.Println("\nTo make it smaller:")
+		for _, s := range report.Suggestions {
+			fmt.Printf("  - %s\n", s)
+		}
+	}
+
+	if m := output.Image; m != nil {
+		fmt.Printf("\nImage %s: %s (uncompressed, from docker history)\n", m.Name, imagesize.Format(m.Total))
+		for _, l := range m.Layers {
+			createdBy := l.CreatedBy
+			if len(createdBy) > 70 {
+				createdBy = createdBy[:67] + "..."
+			}
+			fmt.Printf("  %-10s %s\n", imagesize.Format(l.Size), createdBy)
+		}
+	}
+	if output.Budget > 0 && !output.Over {
+		fmt.Println()
+		printSuccess("Within the %s budget", imagesize.Format(output.Budget))
+	}
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/history"
+	"github.com/spf13/cobra"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize the local history of dockerizer runs",
+	Long: `Summarize the dockerize, init and agent runs made on this machine: the
+most common stacks, the success and AI fallback rates and the average run
+time.
+
+Runs are recorded in ~/.dockerizer/history.jsonl and never leave the
+machine. Delete the file to reset the history.
+
+Examples:
+  dockerizer stats
+  dockerizer stats --days 7
+  dockerizer stats --days 0 --json`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().Int("days", 30, "Summarize the last N days (0 for all time)")
+	statsCmd.Flags().Int("top", 10, "Number of stacks to list")
+	rootCmd.AddCommand(statsCmd)
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	days, _ := cmd.Flags().GetInt("days")
+	top, _ := cmd.Flags().GetInt("top")
+
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+	runs, err := history.Load(since)
+	if err != nil {
+		return err
+	}
+	stats := history.Summarize(runs)
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Days int `json:"days"`
+			history.Stats
+		}{days, stats})
+	}
+
+	period := "all time"
+	if days > 0 {
+		period = fmt.Sprintf("last %d days", days)
+	}
+	if stats.Runs == 0 {
+		fmt.Printf("No runs recorded (%s)\n", period)
+		return nil
+	}
+
+	fmt.Printf("Dockerizer runs (%s)\n\n", period)
+	fmt.Printf("  Runs:            %d in %d repositories\n", stats.Runs, stats.Repositories)
+	fmt.Printf("  Commands:        %s\n", formatCounts(stats.Commands))
+	fmt.Printf("  Outcomes:        %s\n", formatCounts(stats.Outcomes))
+	fmt.Printf("  Success rate:    %.0f%%\n", stats.SuccessRate*100)
+	fmt.Printf("  AI fallback:     %.0f%% of successful runs\n", stats.AIRate*100)
+	fmt.Printf("  Average time:    %s\n", (time.Duration(stats.AvgDurationMS) * time.Millisecond).Round(time.Millisecond))
+	if stats.AvgConfidence > 0 {
+		fmt.Printf("  Avg confidence:  %d%%\n", stats.AvgConfidence)
+	}
+
+	fmt.Printf("\n  %-32s %6s\n", "STACK", "RUNS")
+	for i, s := range stats.Stacks {
+		if top > 0 && i == top {
+			fmt.Printf("  ... and %d more\n", len(stats.Stacks)-top)
+			break
+		}
+		fmt.Printf("  %-32s %6d\n", s.Stack, s.Runs)
+	}
+	return nil
+}
+
+// formatCounts formats counts as "a 3, b 1", largest first
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	s := ""
+	for i, k := range keys {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s %d", k, counts[k])
+	}
+	return s
+}
+
+// recordRun logs a finished run to the local history. result is the
+// detection, if the run got that far; usedAI tells whether an AI provider
+// produced the configuration. Failing to record never fails the run.
+func recordRun(command, path string, start time.Time, result *detector.DetectionResult, usedAI bool, err error) {
+	run := history.Run{
+		Time:       start.UTC(),
+		Command:    command,
+		Path:       path,
+		AI:         usedAI,
+		DurationMS: time.Since(start).Milliseconds(),
+		Outcome:    history.OutcomeSuccess,
+	}
+	if abs, absErr := filepath.Abs(path); absErr == nil {
+		run.Path = abs
+	}
+	if result != nil {
+		run.Detected = result.Detected
+		run.Language = result.Language
+		run.Framework = result.Framework
+		run.Confidence = result.Confidence
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		run.Outcome = history.OutcomeCancelled
+	default:
+		run.Outcome = history.OutcomeFailure
+		run.Error = err.Error()
+	}
+	if recErr := history.Record(run); recErr != nil {
+		printVerbose("Could not record run history: %v", recErr)
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/ai"
+	"github.com/dublyo/dockerizer/internal/tui"
+)
+
+// streamSections names the response fields for progress output
+var streamSections = map[string]string{
+	"dockerfile":     "Dockerfile",
+	"docker_compose": "docker-compose.yml",
+	"dockerignore":   ".dockerignore",
+	"env_example":    ".env.example",
+	"explanation":    "explanation",
+}
+
+// streamPrinter shows an AI response as it streams in: the Dockerfile is
+// printed live and the other sections get a progress line
+type streamPrinter struct {
+	provider  string
+	field     string
+	lineStart bool
+}
+
+// newStreamFunc returns a StreamFunc for the terminal, or nil when output is
+// quiet or JSON (generation then blocks without progress)
+func newStreamFunc() ai.StreamFunc {
+	if quiet || jsonOut {
+		return nil
+	}
+	p := &streamPrinter{lineStart: true}
+	return p.handle
+}
+
+func (p *streamPrinter) handle(e ai.StreamEvent) {
+	if e.Provider != p.provider {
+		p.newline()
+		if p.provider != "" {
+			fmt.Printf("Switched to %s\n", e.Provider)
+		} else {
+			fmt.Printf("Streaming from %s (Ctrl+C to cancel)\n", e.Provider)
+		}
+		p.provider = e.Provider
+		p.field = ""
+	}
+
+	if e.Field != p.field {
+		p.field = e.Field
+		p.newline()
+		if e.Field == "dockerfile" {
+			fmt.Println("──── Dockerfile ────")
+		} else if name, ok := streamSections[e.Field]; ok {
+			fmt.Printf("  writing %s...\n", name)
+		}
+	}
+
+	if e.Field == "dockerfile" && e.Text != "" {
+		fmt.Print(e.Text)
+		p.lineStart = strings.HasSuffix(e.Text, "\n")
+	}
+}
+
+// newline ends a partially printed line
+func (p *streamPrinter) newline() {
+	if !p.lineStart {
+		fmt.Println()
+		p.lineStart = true
+	}
+}
+
+// newPaneStreamFunc returns a StreamFunc showing the response in a progress
+// pane's log window: the Dockerfile as it arrives and a line per section
+func newPaneStreamFunc(pane *tui.Pane) ai.StreamFunc {
+	var provider, field string
+	return func(e ai.StreamEvent) {
+		if e.Provider != provider {
+			provider, field = e.Provider, ""
+			fmt.Fprintf(pane, "\nStreaming from %s (Ctrl+C to cancel)\n", e.Provider)
+		}
+		if e.Field != field {
+			field = e.Field
+			if name, ok := streamSections[e.Field]; ok {
+				fmt.Fprintf(pane, "\n──── %s ────\n", name)
+			}
+		}
+		if e.Field == "dockerfile" && e.Text != "" {
+			fmt.Fprint(pane, e.Text)
+		}
+	}
+}
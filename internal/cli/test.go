@@ -0,0 +1,447 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/spf13/cobra"
+)
+
+// TestOutput is the JSON output for test command
+type TestOutput struct {
+	Path     string     `json:"path"`
+	Project  string     `json:"project"`
+	Success  bool       `json:"success"`
+	URL      string     `json:"url,omitempty"`
+	Steps    []TestStep `json:"steps"`
+	Logs     string     `json:"logs,omitempty"`
+	Duration string     `json:"duration"`
+}
+
+// TestStep is the result of a single stage of the smoke test
+type TestStep struct {
+	Name     string `json:"name"`
+	Success  bool   `json:"success"`
+	Skipped  bool   `json:"skipped,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// testLogLines is how much of the app log is reported when a test fails
+const testLogLines = 50
+
+var testCmd = &cobra.Command{
+	Use:   "test [path]",
+	Short: "Build and smoke-test the generated Docker configuration",
+	Long: `Build the image and start the stack with the generated docker-compose.yml,
+wait for every health check to pass, probe the app over HTTP, and tear
+everything down again. No AI provider is involved, so this is suitable
+for CI.
+
+Steps:
+  build     docker compose build
+  start     docker compose up --wait (health checks must pass)
+  http      GET the health endpoint on a free host port (status < 500)
+  teardown  docker compose down, removing volumes and built images
+
+The stack runs under its own compose project name with the app published
+on a free port, so it does not clash with a running copy. When .env is
+missing, .env.example is used for the duration of the test.
+
+The HTTP endpoint defaults to the path probed by the Dockerfile's
+HEALTHCHECK, or / when there is none.
+
+--target builds the app service up to a stage of the Dockerfile, such as
+build (the toolchain, dev dependencies and source, for running tests in
+CI), and skips starting the stack. Generated Dockerfiles name their stages
+deps, build and runner.
+
+Examples:
+  dockerizer test
+  dockerizer test ./my-project --endpoint /health
+  dockerizer test --json --timeout 5m .
+  dockerizer test --keep       # Leave the stack running for inspection
+  dockerizer test --target build`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTest,
+}
+
+func init() {
+	rootCmd.AddCommand(testCmd)
+
+	testCmd.Flags().Duration("timeout", 3*time.Minute, "Time allowed for the stack to become healthy")
+	testCmd.Flags().String("endpoint", "", "HTTP path to probe (default: HEALTHCHECK path or /)")
+	testCmd.Flags().Bool("no-http", false, "Skip the HTTP probe")
+	testCmd.Flags().Bool("keep", false, "Leave the stack running after the test")
+	testCmd.Flags().String("target", "", "Only build the app up to this stage of the Dockerfile (e.g. build)")
+}
+
+func runTest(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	endpoint, _ := cmd.Flags().GetString("endpoint")
+	noHTTP, _ := cmd.Flags().GetBool("no-http")
+	keep, _ := cmd.Flags().GetBool("keep")
+	target, _ := cmd.Flags().GetString("target")
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		printError("invalid path: %v", err)
+		return err
+	}
+	dockerfile := generator.ExistingFile(absPath, generator.DockerfileNames...)
+	compose := generator.ExistingFile(absPath, generator.ComposeNames...)
+	for _, f := range []struct{ name, found string }{{"Dockerfile", dockerfile}, {"docker-compose.yml", compose}} {
+		if f.found == "" {
+			printError("%s not found in %s (run dockerizer first)", f.name, path)
+			return fmt.Errorf("%s not found", f.name)
+		}
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		printError("docker not found in PATH")
+		return err
+	}
+
+	if target != "" {
+		if err := checkStage(filepath.Join(absPath, dockerfile), target); err != nil {
+			printError("%v", err)
+			return err
+		}
+	}
+	if endpoint == "" {
+		endpoint = healthcheckPath(filepath.Join(absPath, dockerfile))
+	}
+	if !strings.HasPrefix(endpoint, "/") {
+		endpoint = "/" + endpoint
+	}
+
+	port, err := freePort()
+	if err != nil {
+		printError("no free port: %v", err)
+		return err
+	}
+
+	stack := &composeStack{
+		dir:     absPath,
+		file:    compose,
+		project: fmt.Sprintf("dockerizer-test-%d", time.Now().Unix()),
+	}
+	stack.env = append(os.Environ(), "APP_NAME="+stack.project+"-app", "PORT="+strconv.Itoa(port))
+	if target != "" {
+		// The app service's build, retargeted by an override file
+		override, err := os.CreateTemp("", "dockerizer-test-*.yml")
+		if err != nil {
+			printError("failed to write the compose override: %v", err)
+			return err
+		}
+		defer os.Remove(override.Name())
+		_, err = fmt.Fprintf(override, "services:\n  app:\n    build:\n      target: %s\n", target)
+		if cerr := override.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			printError("failed to write the compose override: %v", err)
+			return err
+		}
+		stack.override = override.Name()
+	}
+
+	// A generated reverse proxy publishes HTTP_PORT and HTTPS_PORT; keep them off 80 and 443
+	for _, name := range []string{"HTTP_PORT", "HTTPS_PORT"} {
+		p, err := freePort()
+		if err != nil {
+			printError("no free port: %v", err)
+			return err
+		}
+		stack.env = append(stack.env, name+"="+strconv.Itoa(p))
+	}
+
+	// The compose file requires .env; fall back to the example for the test
+	envFile := filepath.Join(absPath, ".env")
+	if _, err := os.Stat(envFile); os.IsNotExist(err) {
+		if example, err := os.ReadFile(filepath.Join(absPath, ".env.example")); err == nil {
+			if err := os.WriteFile(envFile, example, 0644); err != nil {
+				printError("failed to write .env: %v", err)
+				return err
+			}
+			printVerbose("Using .env.example as .env")
+			defer os.Remove(envFile)
+		}
+	}
+
+	started := time.Now()
+	out := TestOutput{Path: path, Project: stack.project, Success: true}
+
+	// Ctrl+C skips to the teardown, which runs regardless
+	ctx, cancel := interruptible(context.Background())
+	defer cancel()
+
+	step := func(name string, fn func() (string, error)) bool {
+		printVerbose("Running %s...", name)
+		begin := time.Now()
+		detail, err := fn()
+		s := TestStep{Name: name, Success: err == nil, Detail: detail, Duration: time.Since(begin).Round(time.Millisecond).String()}
+		if err != nil {
+			s.Error = err.Error()
+			out.Success = false
+		}
+		out.Steps = append(out.Steps, s)
+		return err == nil
+	}
+	skip := func(name, reason string) {
+		out.Steps = append(out.Steps, TestStep{Name: name, Success: true, Skipped: true, Detail: reason, Duration: "0s"})
+	}
+
+	ok := step("build", func() (string, error) {
+		buildCtx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+		defer cancel()
+		if target != "" {
+			_, err := stack.run(buildCtx, "build", "app")
+			return "stage " + target, err
+		}
+		_, err := stack.run(buildCtx, "build")
+		return "", err
+	})
+
+	if target != "" {
+		skip("start", "--target only builds")
+	} else if ok {
+		ok = step("start", func() (string, error) {
+			startCtx, cancel := context.WithTimeout(ctx, timeout+time.Minute)
+			defer cancel()
+			secs := strconv.Itoa(int(timeout.Seconds()))
+			_, err := stack.run(startCtx, "up", "-d", "--wait", "--wait-timeout", secs)
+			return "", err
+		})
+	} else {
+		skip("start", "build failed")
+	}
+
+	if target != "" {
+		skip("http", "--target only builds")
+	} else if noHTTP {
+		skip("http", "disabled with --no-http")
+	} else if ok {
+		out.URL = fmt.Sprintf("http://127.0.0.1:%d%s", port, endpoint)
+		ok = step("http", func() (string, error) {
+			return probeHTTP(ctx, out.URL, 30*time.Second)
+		})
+	} else {
+		skip("http", "stack did not start")
+	}
+
+	if !out.Success {
+		out.Logs = stack.logs(ctx)
+	}
+
+	if keep {
+		skip("teardown", "kept with --keep")
+		printInfo("Stack left running as compose project %s (port %d)", stack.project, port)
+	} else {
+		step("teardown", func() (string, error) {
+			downCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Minute)
+			defer cancel()
+			_, err := stack.run(downCtx, "down", "--volumes", "--remove-orphans", "--rmi", "local")
+			return "", err
+		})
+	}
+	out.Duration = time.Since(started).Round(time.Millisecond).String()
+
+	// Output
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		for _, s := range out.Steps {
+			switch {
+			case s.Skipped:
+				printInfo("- %-9s skipped (%s)", s.Name, s.Detail)
+			case s.Success:
+				line := fmt.Sprintf("%-9s %s", s.Name, s.Duration)
+				if s.Detail != "" {
+					line += " (" + s.Detail + ")"
+				}
+				printSuccess("%s", line)
+			default:
+				fmt.Printf("✗ %-9s %s\n", s.Name, s.Duration)
+				for _, l := range strings.Split(s.Error, "\n") {
+					fmt.Printf("    %s\n", l)
+				}
+			}
+		}
+		if out.Logs != "" {
+			fmt.Printf("\nApp logs (last %d lines):\n%s\n", testLogLines, strings.TrimRight(out.Logs, "\n"))
+		}
+	}
+
+	if !out.Success {
+		return fmt.Errorf("smoke test failed")
+	}
+	if !jsonOut && target != "" {
+		printSuccess("Built stage %s in %s", target, out.Duration)
+	} else if !jsonOut {
+		printSuccess("Smoke test passed in %s", out.Duration)
+	}
+	return nil
+}
+
+// composeStack runs docker compose against a project directory under an
+// isolated project name
+type composeStack struct {
+	dir      string
+	file     string // Compose file in dir
+	override string // Compose file layered on file, if any
+	project  string
+	env      []string
+}
+
+// run executes a docker compose subcommand, returning its output; errors
+// carry the tail of the output
+func (s *composeStack) run(ctx context.Context, args ...string) (string, error) {
+	full := []string{"compose", "-p", s.project, "-f", s.file}
+	if s.override != "" {
+		full = append(full, "-f", s.override)
+	}
+	full = append(full, args...)
+	cmd := exec.CommandContext(ctx, "docker", full...)
+	cmd.Dir = s.dir
+	cmd.Env = s.env
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if verbose && !quiet && !jsonOut {
+		cmd.Stdout = io.MultiWriter(&output, os.Stdout)
+		cmd.Stderr = io.MultiWriter(&output, os.Stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return output.String(), fmt.Errorf("docker compose %s timed out", args[0])
+		}
+		return output.String(), fmt.Errorf("docker compose %s failed: %w\n%s", args[0], err, tailLines(output.String(), 20))
+	}
+	return output.String(), nil
+}
+
+// logs returns the tail of the stack's logs
+func (s *composeStack) logs(ctx context.Context) string {
+	logCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	out, _ := s.run(logCtx, "logs", "--no-color", "--tail", strconv.Itoa(testLogLines))
+	return out
+}
+
+// probeHTTP polls url until it answers with a status below 500
+func probeHTTP(ctx context.Context, url string, window time.Duration) (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	deadline := time.Now().Add(window)
+
+	var lastErr error
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", err
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 500 {
+				return fmt.Sprintf("GET %s -> %d", url, resp.StatusCode), nil
+			}
+			lastErr = fmt.Errorf("GET %s returned %s", url, resp.Status)
+		} else {
+			lastErr = err
+		}
+
+		if !time.Now().Before(deadline) {
+			return "", lastErr
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// checkStage reports a stage the Dockerfile doesn't have, with those it has
+func checkStage(path, stage string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	df := dockerfile.Parse(string(content))
+	if df.Stage(stage) != nil {
+		return nil
+	}
+	var names []string
+	for _, s := range df.Stages {
+		if s.Name != "" {
+			names = append(names, s.Name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s has no named stages", filepath.Base(path))
+	}
+	return fmt.Errorf("%s has no stage %q (stages: %s)", filepath.Base(path), stage, strings.Join(names, ", "))
+}
+
+// healthcheckURL matches the path of a localhost URL in a HEALTHCHECK command
+var healthcheckURL = regexp.MustCompile(`localhost:\d+(/[^\s'")|]*)`)
+
+// healthcheckPath returns the path probed by the Dockerfile's HEALTHCHECK, or /
+func healthcheckPath(dockerfile string) string {
+	content, err := os.ReadFile(dockerfile)
+	if err != nil {
+		return "/"
+	}
+	idx := strings.Index(string(content), "HEALTHCHECK")
+	if idx < 0 {
+		return "/"
+	}
+	if m := healthcheckURL.FindStringSubmatch(string(content[idx:])); m != nil {
+		return m[1]
+	}
+	return "/"
+}
+
+// freePort asks the kernel for an unused TCP port
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// tailLines returns the last n lines of s
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
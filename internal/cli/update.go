@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/spf13/cobra"
+)
+
+// Provenance states of a generated file
+const (
+	updateCurrent = "current" // Today's templates produce the same file
+	updateStale   = "stale"   // The templates changed since the file was generated
+	updateUnknown = "unknown" // No provenance comment: hand-written or generated without one
+)
+
+// UpdateOutput is the JSON output for update command
+type UpdateOutput struct {
+	Files       []UpdateFile `json:"files"`
+	Regenerated bool         `json:"regenerated,omitempty"`
+}
+
+// UpdateFile is the provenance of one generated file
+type UpdateFile struct {
+	Path        string `json:"path"`
+	Status      string `json:"status"`
+	Version     string `json:"version,omitempty"`  // dockerizer version that generated it
+	Template    string `json:"template,omitempty"` // Provider template
+	Hash        string `json:"hash,omitempty"`
+	CurrentHash string `json:"current_hash,omitempty"`
+	target      string // --only name that regenerates it
+}
+
+var updateCmd = &cobra.Command{
+	Use:   "update [path]",
+	Short: "Regenerate files whose templates changed since they were generated",
+	Long: `Read the provenance comment ("# dockerizer: version=... template=...
+hash=...") of the generated Dockerfile, docker-compose.yml, .dockerignore,
+.env.example and docker-entrypoint.sh, and compare the template hash with the
+templates of this dockerizer version.
+
+Files generated from older templates are regenerated with the settings of
+.dockerizer.yml; options given on the command line at the time are not
+recorded, so check the result. With --check nothing is written and the
+command fails when a file is stale, for CI.
+
+Examples:
+  dockerizer update --check
+  dockerizer update ./my-project`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runUpdate,
+}
+
+func init() {
+	updateCmd.Flags().Bool("check", false, "Only report stale files; fail when there are any")
+	rootCmd.AddCommand(updateCmd)
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	check, _ := cmd.Flags().GetBool("check")
+
+	files := checkProvenance(path)
+	var stale, targets []string
+	for _, f := range files {
+		if f.Status == updateStale {
+			stale = append(stale, f.Path)
+			if !slices.Contains(targets, f.target) {
+				targets = append(targets, f.target)
+			}
+		}
+	}
+	output := UpdateOutput{Files: files}
+
+	if !check && len(stale) > 0 {
+		opts := dockerizeOptions{overwrite: true}
+		if err := opts.selectOnly(targets); err != nil {
+			return err
+		}
+		if err := executeDockerize(path, opts); err != nil {
+			return err
+		}
+		output.Regenerated = true
+	}
+
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(output); err != nil {
+			return err
+		}
+	} else if len(files) == 0 {
+		printInfo("No generated files in %s", path)
+	} else if !output.Regenerated {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FILE\tSTATUS\tVERSION\tTEMPLATE")
+		for _, f := range files {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Path, f.Status, f.Version, f.Template)
+		}
+		w.Flush()
+	}
+
+	if check && len(stale) > 0 {
+		return fmt.Errorf("stale generated files: %s; run dockerizer update", strings.Join(stale, ", "))
+	}
+	return nil
+}
+
+// checkProvenance reports the provenance of the generated files in a project
+func checkProvenance(path string) []UpdateFile {
+	cfg := projectConfig(path)
+	dockerfile, compose := "Dockerfile", "docker-compose.yml"
+	if name := generator.ExistingFile(path, generator.DockerfileNames...); name != "" {
+		dockerfile = name
+	}
+	if name := generator.ExistingFile(path, generator.ComposeNames...); name != "" {
+		compose = name
+	}
+	if cfg.Defaults.DockerfilePath != "" {
+		dockerfile = cfg.Defaults.DockerfilePath
+	}
+	if cfg.Defaults.ComposePath != "" {
+		compose = cfg.Defaults.ComposePath
+	}
+
+	var files []UpdateFile
+	for _, f := range []struct{ name, target string }{
+		{dockerfile, "dockerfile"},
+		{"docker-entrypoint.sh", "dockerfile"},
+		{compose, "compose"},
+		{".dockerignore", "ignore"},
+		{".env.example", "env"},
+	} {
+		content, err := os.ReadFile(filepath.Join(path, filepath.FromSlash(f.name)))
+		if err != nil {
+			continue
+		}
+		file := UpdateFile{Path: f.name, Status: updateUnknown, target: f.target}
+		if p, ok := generator.ParseProvenance(string(content)); ok {
+			file.Version, file.Template, file.Hash = p.Version, p.Template, p.Hash
+			file.CurrentHash = generator.TemplateHash(p.Template)
+			file.Status = updateCurrent
+			if file.CurrentHash != "" && file.CurrentHash != p.Hash {
+				file.Status = updateStale
+			}
+		}
+		files = append(files, file)
+	}
+	return files
+}
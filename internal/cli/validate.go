@@ -1,12 +1,19 @@
 package cli
 
 import (
-	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/dublyo/dockerizer/internal/ai"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
 	"github.com/spf13/cobra"
 )
 
@@ -21,11 +28,13 @@ type ValidationOutput struct {
 type ValidationIssue struct {
 	Line    int    `json:"line"`
 	Message string `json:"message"`
+	Rule    string `json:"rule,omitempty"` // BuildKit check that found it, with --check
+	File    string `json:"file,omitempty"` // The compose file, for docker compose config's findings
 }
 
 var validateCmd = &cobra.Command{
-	Use:   "validate [dockerfile]",
-	Short: "Validate a Dockerfile",
+	Use:   "validate [dockerfile|compose file]",
+	Short: "Validate a Dockerfile or compose file",
 	Long: `Validate a Dockerfile for common issues and best practices.
 
 This performs syntax validation and checks for common mistakes like:
@@ -33,33 +42,59 @@ This performs syntax validation and checks for common mistakes like:
 - Invalid instruction syntax
 - Deprecated practices
 
+With --check the Dockerfile is also linted by BuildKit's build checks
+(docker build --check), when docker is available, and their findings are
+merged into the warnings. No build context is sent and nothing is built,
+but base images are resolved on their registries.
+
+A compose file (.yml or .yaml) is rendered by docker compose config, which
+reports the interpolation and schema errors docker compose up would. Until
+the .env beside it is written, the .env.example stands in. This is the check
+dockerize runs on the compose file it generates.
+
 Examples:
   dockerizer validate Dockerfile
-  dockerizer validate ./my-project/Dockerfile`,
+  dockerizer validate ./my-project/Dockerfile
+  dockerizer validate --check Dockerfile
+  dockerizer validate docker-compose.yml`,
 	Args: cobra.ExactArgs(1),
 	RunE: runValidate,
 }
 
+func init() {
+	validateCmd.Flags().Bool("check", false, "Also lint with BuildKit's build checks (docker build --check)")
+}
+
 func runValidate(cmd *cobra.Command, args []string) error {
-	filepath := args[0]
+	path := args[0]
 
-	// Read the Dockerfile
-	content, err := os.ReadFile(filepath)
+	// Read the file
+	content, err := os.ReadFile(path)
 	if err != nil {
 		printError("failed to read file: %v", err)
 		return err
 	}
 
-	// Validate
-	errors, warnings := validateDockerfile(string(content))
+	// Validate; a compose file is rendered by docker compose, which is
+	// required for it
+	check, _ := cmd.Flags().GetBool("check")
+	var output *ValidationOutput
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yml" || ext == ".yaml" {
+		if err := composeAvailable(context.Background()); err != nil {
+			printError("cannot validate %s: %v", path, err)
+			return err
+		}
+		output = verify(context.Background(), "", false, path)
+	} else {
+		output = verify(context.Background(), string(content), check, "")
+	}
+	if output == nil {
+		output = &ValidationOutput{Valid: true}
+	}
+	errors, warnings := output.Errors, output.Warnings
 
 	// Output
 	if jsonOut {
-		output := ValidationOutput{
-			Valid:    len(errors) == 0,
-			Errors:   errors,
-			Warnings: warnings,
-		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(output)
@@ -67,21 +102,21 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 	// Text output
 	if len(errors) == 0 && len(warnings) == 0 {
-		printSuccess("Dockerfile is valid")
+		printSuccess("%s is valid", filepath.Base(path))
 		return nil
 	}
 
 	if len(errors) > 0 {
 		fmt.Println("Errors:")
 		for _, e := range errors {
-			fmt.Printf("  Line %d: %s\n", e.Line, e.Message)
+			fmt.Printf("  %s\n", issueText(e))
 		}
 	}
 
 	if len(warnings) > 0 {
 		fmt.Println("Warnings:")
 		for _, w := range warnings {
-			fmt.Printf("  Line %d: %s\n", w.Line, w.Message)
+			fmt.Printf("  %s\n", issueText(w))
 		}
 	}
 
@@ -92,111 +127,151 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-// validateDockerfile performs basic validation on a Dockerfile
+// issueText formats a validation issue for text output
+func issueText(issue ValidationIssue) string {
+	switch {
+	case issue.File != "":
+		return fmt.Sprintf("%s: %s", issue.File, issue.Message) // Compose's messages give the line
+	case issue.Rule != "":
+		return fmt.Sprintf("Line %d: %s (%s)", issue.Line, issue.Message, issue.Rule)
+	}
+	return fmt.Sprintf("Line %d: %s", issue.Line, issue.Message)
+}
+
+// validateDockerfile reports the syntax errors of a Dockerfile, and
+// warnings for common mistakes
 func validateDockerfile(content string) ([]ValidationIssue, []ValidationIssue) {
 	var errors []ValidationIssue
 	var warnings []ValidationIssue
 
-	scanner := bufio.NewScanner(strings.NewReader(content))
-	lineNum := 0
-	hasFROM := false
-	hasMultipleFROM := false
-	fromCount := 0
-
-	validInstructions := map[string]bool{
-		"FROM": true, "RUN": true, "CMD": true, "LABEL": true,
-		"EXPOSE": true, "ENV": true, "ADD": true, "COPY": true,
-		"ENTRYPOINT": true, "VOLUME": true, "USER": true,
-		"WORKDIR": true, "ARG": true, "ONBUILD": true,
-		"STOPSIGNAL": true, "HEALTHCHECK": true, "SHELL": true,
+	df := dockerfile.Parse(content)
+	for _, e := range df.Errors {
+		errors = append(errors, ValidationIssue{Line: e.Line, Message: e.Message})
 	}
 
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Handle line continuation
-		for strings.HasSuffix(line, "\\") && scanner.Scan() {
-			lineNum++
-			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(scanner.Text())
-		}
-
-		// Get the instruction
-		parts := strings.Fields(line)
-		if len(parts) == 0 {
-			continue
-		}
-
-		instruction := strings.ToUpper(parts[0])
-
-		// Check for FROM instruction
-		if instruction == "FROM" {
-			hasFROM = true
-			fromCount++
-			if fromCount > 1 {
-				hasMultipleFROM = true
-			}
-		}
-
-		// Check for valid instruction
-		if !validInstructions[instruction] && !strings.HasPrefix(instruction, "#") {
-			// Could be a parser directive
-			if lineNum == 1 && strings.Contains(line, "=") {
-				continue // Likely a parser directive like "syntax="
-			}
-			errors = append(errors, ValidationIssue{
-				Line:    lineNum,
-				Message: fmt.Sprintf("unknown instruction: %s", instruction),
-			})
-		}
-
-		// Check for deprecated MAINTAINER
-		if instruction == "MAINTAINER" {
+	for _, in := range df.Instructions {
+		switch in.Cmd {
+		case "MAINTAINER":
 			warnings = append(warnings, ValidationIssue{
-				Line:    lineNum,
+				Line:    in.StartLine,
 				Message: "MAINTAINER is deprecated, use LABEL maintainer= instead",
 			})
-		}
-
-		// Check for ADD with URL
-		if instruction == "ADD" && len(parts) > 1 {
-			if strings.HasPrefix(parts[1], "http://") || strings.HasPrefix(parts[1], "https://") {
+		case "ADD":
+			// Check for ADD with URL
+			if len(in.Args) > 0 && (strings.HasPrefix(in.Args[0], "http://") || strings.HasPrefix(in.Args[0], "https://")) {
 				warnings = append(warnings, ValidationIssue{
-					Line:    lineNum,
+					Line:    in.StartLine,
 					Message: "consider using RUN curl/wget instead of ADD for URLs",
 				})
 			}
 		}
+	}
 
-		// Check for latest tag
-		if instruction == "FROM" && len(parts) > 1 {
-			image := parts[1]
-			if strings.HasSuffix(image, ":latest") || (!strings.Contains(image, ":") && !strings.Contains(image, "@")) {
-				warnings = append(warnings, ValidationIssue{
-					Line:    lineNum,
-					Message: "consider using a specific tag instead of 'latest'",
-				})
-			}
+	// Check for latest tag; earlier stages and images chosen by ARGs without
+	// a default have none
+	for i, stage := range df.Stages {
+		image := df.ExpandArgs(stage.Image)
+		if image == "" || strings.Contains(image, "$") || strings.EqualFold(image, "scratch") {
+			continue
+		}
+		if s := df.Stage(image); s != nil && s.Index < i {
+			continue
+		}
+		if strings.HasSuffix(image, ":latest") || !strings.ContainsAny(image[strings.LastIndex(image, "/")+1:], ":@") {
+			warnings = append(warnings, ValidationIssue{
+				Line:    stage.From.StartLine,
+				Message: "consider using a specific tag instead of 'latest'",
+			})
 		}
 	}
 
-	// Check for required FROM
-	if !hasFROM {
-		errors = append(errors, ValidationIssue{
-			Line:    1,
-			Message: "Dockerfile must start with FROM instruction",
-		})
-	}
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Line < warnings[j].Line })
 
 	// Info about multi-stage builds
-	if hasMultipleFROM && verbose {
-		printVerbose("Detected multi-stage build with %d stages", fromCount)
+	if len(df.Stages) > 1 && verbose {
+		printVerbose("Detected multi-stage build with %d stages", len(df.Stages))
 	}
 
 	return errors, warnings
 }
+
+// validationScore rates an AI response for A/B mode by what the validator
+// finds in its Dockerfile: fewer errors first, then fewer warnings
+func validationScore(resp *ai.Response) int {
+	errors, warnings := validateDockerfile(resp.Dockerfile)
+	return -100*len(errors) - len(warnings)
+}
+
+// withBuildkitCheck adds the findings of BuildKit's build checks to
+// warnings. The checks are optional: when docker or the network is not
+// available they are skipped with a note on stderr.
+func withBuildkitCheck(ctx context.Context, content string, warnings []ValidationIssue) []ValidationIssue {
+	findings, err := buildkitCheck(ctx, content)
+	if err != nil {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "⚠ Skipping BuildKit checks: %v\n", err)
+		}
+		return warnings
+	}
+	printVerbose("BuildKit checks: %d findings", len(findings))
+	warnings = append(warnings, findings...)
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Line < warnings[j].Line })
+	return warnings
+}
+
+// buildkitCheck lints a Dockerfile with docker build --check. The Dockerfile
+// is read from stdin, so no build context is sent.
+func buildkitCheck(ctx context.Context, content string) ([]ValidationIssue, error) {
+	if err := requireNetwork("docker build --check"); err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, fmt.Errorf("docker not found")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", "build", "--call=check,format=json", "-")
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	runErr := cmd.Run() // Fails when a check does, with the findings on stdout
+
+	var result struct {
+		Warnings []struct {
+			RuleName    string `json:"ruleName"`
+			Description string `json:"description"`
+			Detail      string `json:"detail"`
+			Location    struct {
+				Ranges []struct {
+					Start struct {
+						Line int `json:"line"`
+					} `json:"start"`
+				} `json:"ranges"`
+			} `json:"location"`
+		} `json:"warnings"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		if runErr != nil {
+			reason, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n")
+			if reason == "" {
+				reason = runErr.Error()
+			}
+			return nil, fmt.Errorf("docker build --check failed: %s", reason)
+		}
+		return nil, fmt.Errorf("unexpected docker build --check output: %w", err)
+	}
+
+	issues := make([]ValidationIssue, 0, len(result.Warnings))
+	for _, w := range result.Warnings {
+		issue := ValidationIssue{Message: w.Detail, Rule: w.RuleName}
+		if issue.Message == "" {
+			issue.Message = w.Description
+		}
+		if len(w.Location.Ranges) > 0 {
+			issue.Line = w.Location.Ranges[0].Start.Line
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// verify is the check of generated files, shared by dockerize and validate.
+// A Dockerfile is validated, and linted by BuildKit's checks with check; a
+// compose file is rendered by docker compose config, which reports the
+// interpolation and schema errors the first up would. Either is skipped
+// when empty; the compose check is skipped, with a verbose note, when
+// docker compose is not available.
+func verify(ctx context.Context, dockerfile string, check bool, composeFile string) *ValidationOutput {
+	var errors, warnings []ValidationIssue
+	if dockerfile != "" {
+		errors, warnings = validateDockerfile(dockerfile)
+		if check {
+			warnings = withBuildkitCheck(ctx, dockerfile, warnings)
+		}
+	}
+	if composeFile != "" {
+		if err := composeAvailable(ctx); err != nil {
+			printVerbose("Skipping docker compose config: %v", err)
+		} else {
+			composeErrors, composeWarnings := composeConfig(ctx, composeFile)
+			errors = append(errors, composeErrors...)
+			warnings = append(warnings, composeWarnings...)
+		}
+	}
+	if errors == nil && warnings == nil && dockerfile == "" {
+		return nil
+	}
+	return &ValidationOutput{Valid: len(errors) == 0, Errors: errors, Warnings: warnings}
+}
+
+// composeAvailable reports why docker compose can't be run, if it can't
+func composeAvailable(ctx context.Context) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("docker not found")
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := exec.CommandContext(ctx, "docker", "compose", "version").Run(); err != nil {
+		return fmt.Errorf("docker compose not available")
+	}
+	return nil
+}
+
+var (
+	// composeLine is the position YAML errors report
+	composeLine = regexp.MustCompile(`\bline (\d+)\b`)
+	// composeWarning is a warning docker compose logs: WARN[0000] message,
+	// or time=... level=warning msg="message"
+	composeWarning = regexp.MustCompile(`^(?:WARN\[\d+\]\s*|.*level=warning msg=)(.*)$`)
+	// envFileEntry is an env_file entry of a generated compose file
+	envFileEntry = regexp.MustCompile(`(?m)^(\s+- )(\S*\.env)\s*$`)
+)
+
+// composeConfig renders a compose file with docker compose config, and
+// returns its errors and warnings. Until the .env the compose file reads
+// is written, the .env.example generated beside it stands in, for
+// env_file and for interpolation, so that a fresh project isn't flagged
+// for every variable it leaves unset.
+func composeConfig(ctx context.Context, composeFile string) (errors, warnings []ValidationIssue) {
+	name := filepath.Base(composeFile)
+	content, err := os.ReadFile(composeFile)
+	if err != nil {
+		return []ValidationIssue{{File: name, Message: err.Error()}}, nil
+	}
+	dir := filepath.Dir(composeFile)
+	args := []string{"compose", "--project-directory", dir}
+	if !exists(filepath.Join(dir, ".env")) && exists(filepath.Join(dir, ".env.example")) {
+		args = append(args, "--env-file", filepath.Join(dir, ".env.example"))
+	}
+	content = envFileEntry.ReplaceAllFunc(content, func(entry []byte) []byte {
+		m := envFileEntry.FindSubmatch(entry)
+		path := filepath.Join(dir, string(m[2]))
+		if exists(path) || !exists(path+".example") {
+			return entry
+		}
+		return []byte(string(m[1]) + string(m[2]) + ".example")
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", append(args, "-f", "-", "config", "-q")...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := composeWarning.FindStringSubmatch(line); m != nil {
+			msg := m[1]
+			if unquoted, err := strconv.Unquote(msg); err == nil {
+				msg = unquoted
+			}
+			warnings = append(warnings, ValidationIssue{File: name, Message: msg})
+			continue
+		}
+		if runErr != nil {
+			issue := ValidationIssue{File: name, Message: line}
+			if m := composeLine.FindStringSubmatch(line); m != nil {
+				issue.Line, _ = strconv.Atoi(m[1])
+			}
+			errors = append(errors, issue)
+		}
+	}
+	if runErr != nil && len(errors) == 0 {
+		errors = append(errors, ValidationIssue{File: name, Message: "docker compose config failed: " + runErr.Error()})
+	}
+	sort.SliceStable(errors, func(i, j int) bool { return errors[i].Line < errors[j].Line })
+	return errors, warnings
+}
+
+// exists reports whether a file exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// printCheck prints the verification of generated files
+func printCheck(check *ValidationOutput) {
+	printInfo("")
+	if len(check.Errors) == 0 && len(check.Warnings) == 0 {
+		printSuccess("Generated files passed validation")
+		return
+	}
+	printInfo("Validation:")
+	for _, e := range check.Errors {
+		printInfo("  ✗ %s", issueText(e))
+	}
+	for _, w := range check.Warnings {
+		printInfo("  ⚠ %s", issueText(w))
+	}
+}
@@ -2,8 +2,8 @@
 package config
 
 import (
+	"fmt"
 	"os"
-	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,16 +18,29 @@ type Config struct {
 
 	// Provider settings
 	Providers ProvidersConfig `yaml:"providers"`
+
+	// Compose overrides per environment (docker-compose.<name>.yml)
+	Environments map[string]EnvironmentConfig `yaml:"environments"`
+
+	// Choices kept from the project's own Dockerfile (dockerizer import)
+	Dockerfile DockerfileConfig `yaml:"dockerfile"`
+
+	// One-shot services of the compose setup profile (seeding, cache warmup)
+	Setup []SetupJobConfig `yaml:"setup"`
+
+	// Named option profiles rendered in the same run (Dockerfile.<name>)
+	Matrix map[string]MatrixProfileConfig `yaml:"matrix"`
 }
 
 // AIConfig contains AI provider settings
 type AIConfig struct {
-	Provider  string `yaml:"provider"`   // openai, anthropic, ollama
-	Model     string `yaml:"model"`      // Model name
-	APIKey    string `yaml:"api_key"`    // API key (can also use env var)
-	BaseURL   string `yaml:"base_url"`   // Custom endpoint
-	MaxTokens int    `yaml:"max_tokens"` // Max tokens for generation
-	Timeout   int    `yaml:"timeout"`    // Timeout in seconds
+	Provider   string `yaml:"provider"`    // openai, anthropic, ollama; empty tries each
+	Model      string `yaml:"model"`       // Model name; empty uses the provider's default
+	APIKey     string `yaml:"api_key"`     // API key (can also use env var)
+	BaseURL    string `yaml:"base_url"`    // Custom endpoint
+	MaxTokens  int    `yaml:"max_tokens"`  // Max tokens for generation
+	Timeout    int    `yaml:"timeout"`     // Request timeout in seconds; 0 uses the provider's (120, or 300 for Ollama)
+	MaxRetries *int   `yaml:"max_retries"` // Retries of rate-limited, 5xx and failed requests; unset retries 3 times
 }
 
 // DefaultsConfig contains default generation settings
@@ -37,21 +50,105 @@ type DefaultsConfig struct {
 	IncludeEnv     bool   `yaml:"include_env"`
 	Overwrite      bool   `yaml:"overwrite"`
 	OutputDir      string `yaml:"output_dir"`
+	Proxy          string `yaml:"proxy"`           // Reverse proxy in compose: traefik, nginx, caddy or none
+	Base           string `yaml:"base"`            // Base images: alpine, debian or auto (Debian when a dependency needs glibc)
+	User           string `yaml:"user"`            // UID[:GID] the images run as (default 1001:0)
+	Harden         bool   `yaml:"harden"`          // Read-only root filesystem and dropped capabilities in compose
+	WithTests      bool   `yaml:"with_tests"`      // A test stage running the test suite
+	PinDigests     bool   `yaml:"pin_digests"`     // Pin base images to registry digests
+	DockerfilePath string `yaml:"dockerfile_path"` // Dockerfile location in the output directory
+	ComposePath    string `yaml:"compose_path"`    // docker-compose.yml location in the output directory
+	CacheMounts    *bool  `yaml:"cache_mounts"`    // BuildKit cache mounts; unset uses them when BuildKit is available
+	Banner         string `yaml:"banner"`          // Replaces the "Generated by Dublyo Dockerizer" comments; "none" removes them
+	Provenance     *bool  `yaml:"provenance"`      // "# dockerizer:" provenance comment; unset writes it
+	ProvenanceTime bool   `yaml:"provenance_time"` // Records the generation time (the output then differs on every run)
 }
 
 // ProvidersConfig contains provider-specific settings
 type ProvidersConfig struct {
-	MinConfidence int `yaml:"min_confidence"` // Minimum confidence threshold
+	MinConfidence int          `yaml:"min_confidence"` // Minimum confidence threshold
+	Go            GoConfig     `yaml:"go"`
+	Dotnet        DotnetConfig `yaml:"dotnet"`
+	Python        PythonConfig `yaml:"python"`
+	Java          JavaConfig   `yaml:"java"`
+	Rust          RustConfig   `yaml:"rust"`
+	NodeJS        NodeJSConfig `yaml:"nodejs"`
+}
+
+// EnvironmentConfig overrides the compose settings of one environment;
+// unset fields keep the environment's defaults
+type EnvironmentConfig struct {
+	Restart           string            `yaml:"restart"`            // Restart policy
+	MemoryLimit       string            `yaml:"memory_limit"`       // e.g. 1G
+	MemoryReservation string            `yaml:"memory_reservation"` // e.g. 256M
+	CPUs              string            `yaml:"cpus"`               // e.g. "1.5"
+	DebugPort         int               `yaml:"debug_port"`         // -1 disables the debugger
+	MountSource       *bool             `yaml:"mount_source"`       // Bind-mount the source
+	Env               map[string]string `yaml:"env"`                // Extra environment variables
+}
+
+// SetupJobConfig is a one-shot service run with docker compose --profile setup up
+type SetupJobConfig struct {
+	Name    string `yaml:"name"`    // Compose service name; replaces a detected job of the same name
+	Command string `yaml:"command"` // Run with sh -c
+	Target  string `yaml:"target"`  // Build stage to run in; empty uses the app image
+}
+
+// MatrixProfileConfig is the options of a matrix profile, written as
+// Dockerfile.<name> with an app-<name> compose service; unset fields keep
+// the run's settings, except dev, which a profile sets for itself
+type MatrixProfileConfig struct {
+	Dev         bool   `yaml:"dev"`          // Build the dev server's stage and run it
+	Base        string `yaml:"base"`         // alpine, debian or auto
+	User        string `yaml:"user"`         // UID[:GID] the image runs as
+	Platform    string `yaml:"platform"`     // e.g. linux/arm64
+	CacheMounts *bool  `yaml:"cache_mounts"` // BuildKit cache mounts
+}
+
+// DockerfileConfig holds choices of an existing Dockerfile that generation keeps
+type DockerfileConfig struct {
+	BaseImages map[string]string `yaml:"base_images"` // Stage name, or "final" for the last stage -> base image
+	Packages   []string          `yaml:"packages"`    // System packages installed in the final stage
+}
+
+// GoConfig contains Go provider settings
+type GoConfig struct {
+	BaseImage string `yaml:"base_image"` // Final stage image: alpine, distroless or scratch
+}
+
+// DotnetConfig contains .NET provider settings
+type DotnetConfig struct {
+	Windows        string `yaml:"windows"`         // Windows container base: nanoserver, servercore or linux
+	WindowsVersion string `yaml:"windows_version"` // Windows base image version, e.g. ltsc2022
+}
+
+// PythonConfig contains Python provider settings
+type PythonConfig struct {
+	GPU         string `yaml:"gpu"`          // ML images: cuda, cpu or none
+	CUDAVersion string `yaml:"cuda_version"` // nvidia/cuda image version, e.g. 12.4.1
+}
+
+// JavaConfig contains Java provider settings
+type JavaConfig struct {
+	Runtime string `yaml:"runtime"` // Spring Boot runtime: jre, layered or jlink
+}
+
+// NodeJSConfig contains Node.js provider settings
+type NodeJSConfig struct {
+	StaticServer string `yaml:"static_server"` // Static SPA server: nginx or caddy
+	BasePath     string `yaml:"base_path"`     // Base path static SPAs are served under, e.g. /app/
+}
+
+// RustConfig contains Rust provider settings
+type RustConfig struct {
+	CargoChef string `yaml:"cargo_chef"` // false builds with cache mounts instead of cargo-chef
 }
 
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
 		AI: AIConfig{
-			Provider:  "openai",
-			Model:     "gpt-4o",
 			MaxTokens: 4096,
-			Timeout:   120,
 		},
 		Defaults: DefaultsConfig{
 			IncludeCompose: true,
@@ -65,24 +162,23 @@ func DefaultConfig() *Config {
 	}
 }
 
-// Load loads configuration from the default locations
+// Load loads the configuration for the current directory
 func Load() (*Config, error) {
-	cfg := DefaultConfig()
-
-	// Check for config in standard locations
-	configPaths := []string{
-		".dockerizer.yml",
-		".dockerizer.yaml",
-		filepath.Join(os.Getenv("HOME"), ".config", "dockerizer", "config.yml"),
-		filepath.Join(os.Getenv("HOME"), ".dockerizer.yml"),
-	}
+	return LoadForProject(".")
+}
 
-	for _, path := range configPaths {
-		if _, err := os.Stat(path); err == nil {
-			if err := cfg.loadFromFile(path); err != nil {
-				return nil, err
-			}
-			break
+// LoadForProject layers the configuration: defaults, then the global
+// config (~/.config/dockerizer/config.yml, or the legacy ~/.dockerizer.yml),
+// then the project's .dockerizer.yml, then environment variables. Each
+// layer overrides only the settings it contains.
+func LoadForProject(dir string) (*Config, error) {
+	cfg := DefaultConfig()
+	for _, layer := range Layers(dir) {
+		if !layer.Exists {
+			continue
+		}
+		if err := cfg.loadFromFile(layer.Path); err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.Path, err)
 		}
 	}
 
@@ -132,6 +228,20 @@ func (c *Config) loadFromEnv() {
 	} else if model := os.Getenv("DOCKERIZE_AI_MODEL"); model != "" {
 		c.AI.Model = model
 	}
+
+	// Provider settings, which generation validates
+	if image := os.Getenv("DOCKERIZER_GO_BASE_IMAGE"); image != "" {
+		c.Providers.Go.BaseImage = image
+	}
+	if chef := os.Getenv("DOCKERIZER_RUST_CARGO_CHEF"); chef != "" {
+		c.Providers.Rust.CargoChef = chef
+	}
+	if server := os.Getenv("DOCKERIZER_STATIC_SERVER"); server != "" {
+		c.Providers.NodeJS.StaticServer = server
+	}
+	if basePath := os.Getenv("DOCKERIZER_BASE_PATH"); basePath != "" {
+		c.Providers.NodeJS.BasePath = basePath
+	}
 }
 
 // Save writes the configuration to a file
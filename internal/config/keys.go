@@ -0,0 +1,533 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layer names, in the order they are applied
+const (
+	LayerDefault = "default"
+	LayerGlobal  = "global"
+	LayerProject = "project"
+	LayerEnv     = "env"
+)
+
+// Layer is a config file contributing to the effective configuration
+type Layer struct {
+	Name   string `json:"name"` // LayerGlobal or LayerProject
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+// GlobalPath is where the user's configuration is written
+func GlobalPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user config: %w", err)
+	}
+	return globalPath(home), nil
+}
+
+func globalPath(home string) string {
+	return filepath.Join(home, ".config", "dockerizer", "config.yml")
+}
+
+// legacyGlobalPath is read when GlobalPath does not exist
+func legacyGlobalPath(home string) string {
+	return filepath.Join(home, ".dockerizer.yml")
+}
+
+// ProjectPath is the project's config file: an existing .dockerizer.yml or
+// .dockerizer.yaml, else .dockerizer.yml
+func ProjectPath(dir string) string {
+	for _, name := range []string{".dockerizer.yml", ".dockerizer.yaml"} {
+		if path := filepath.Join(dir, name); fileExists(path) {
+			return path
+		}
+	}
+	return filepath.Join(dir, ".dockerizer.yml")
+}
+
+// Layers returns the global and project config files for a project; without
+// a home directory there is no global layer
+func Layers(dir string) []Layer {
+	var layers []Layer
+	if home, err := os.UserHomeDir(); err == nil {
+		global := globalPath(home)
+		if !fileExists(global) && fileExists(legacyGlobalPath(home)) {
+			global = legacyGlobalPath(home)
+		}
+		layers = append(layers, Layer{Name: LayerGlobal, Path: global, Exists: fileExists(global)})
+	}
+	project := ProjectPath(dir)
+	return append(layers, Layer{Name: LayerProject, Path: project, Exists: fileExists(project)})
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Entry is one setting of the effective configuration
+type Entry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // Layer that set it
+}
+
+// secretKeys are masked when displayed
+var secretKeys = map[string]bool{"ai.api_key": true}
+
+// IsSecret reports whether a key holds a credential
+func IsSecret(key string) bool {
+	return secretKeys[key]
+}
+
+// Mask hides all but the last four characters of a secret
+func Mask(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
+
+// allowedValues restricts keys with a fixed set of values
+var allowedValues = map[string][]string{
+	"ai.provider":                    {"openai", "anthropic", "ollama"},
+	"defaults.proxy":                 {"traefik", "nginx", "caddy", "none"},
+	"providers.go.base_image":        {"alpine", "distroless", "scratch"},
+	"providers.dotnet.windows":       {"nanoserver", "servercore", "linux"},
+	"providers.python.gpu":           {"cuda", "cpu", "none"},
+	"providers.java.runtime":         {"jre", "layered", "jlink"},
+	"providers.rust.cargo_chef":      {"true", "false"},
+	"providers.nodejs.static_server": {"nginx", "caddy"},
+}
+
+// Keys lists the settable keys; <name> stands for any map key
+func Keys() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		switch t.Kind() {
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				if name := yamlName(t.Field(i)); name != "" {
+					walk(t.Field(i).Type, prefix+name+".")
+				}
+			}
+		case reflect.Map:
+			walk(t.Elem(), prefix+"<name>.")
+		default:
+			keys = append(keys, strings.TrimSuffix(prefix, "."))
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return keys
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// field resolves a dotted key to its value in v, a pointer to a Config.
+// Missing map entries are created when create is set, and otherwise
+// yield an invalid value.
+func field(v reflect.Value, key string, create bool) (reflect.Value, error) {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Struct:
+			found := false
+			for j := 0; j < v.NumField(); j++ {
+				if yamlName(v.Type().Field(j)) == part {
+					v = v.Field(j)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return reflect.Value{}, unknownKey(key)
+			}
+		case reflect.Map:
+			if part == "" {
+				return reflect.Value{}, unknownKey(key)
+			}
+			if v.IsNil() {
+				if !create {
+					return reflect.Value{}, nil
+				}
+				v.Set(reflect.MakeMap(v.Type()))
+			}
+			k := reflect.ValueOf(part)
+			elem := v.MapIndex(k)
+			if !elem.IsValid() {
+				if !create {
+					return reflect.Value{}, nil
+				}
+				elem = reflect.New(v.Type().Elem()).Elem()
+			}
+			if i == len(parts)-1 {
+				if create {
+					v.SetMapIndex(k, elem)
+				}
+				return elem, nil
+			}
+			// Map values are not addressable; work on a copy stored back below
+			cp := reflect.New(v.Type().Elem())
+			cp.Elem().Set(elem)
+			rest, err := field(cp, strings.Join(parts[i+1:], "."), create)
+			if err == nil && create {
+				v.SetMapIndex(k, cp.Elem())
+			}
+			return rest, err
+		default:
+			return reflect.Value{}, unknownKey(key)
+		}
+	}
+	if v.Kind() == reflect.Struct || v.Kind() == reflect.Map {
+		return reflect.Value{}, fmt.Errorf("%s is a section; use one of its keys (see dockerizer config list --keys)", key)
+	}
+	return v, nil
+}
+
+func unknownKey(key string) error {
+	return fmt.Errorf("unknown config key %q (see dockerizer config list --keys)", key)
+}
+
+// format renders a setting for display; unset optional values are ""
+func format(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if items, ok := v.Interface().([]string); ok {
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// Get returns the value of a dotted key, e.g. ai.provider
+func (c *Config) Get(key string) (string, error) {
+	v, err := field(reflect.ValueOf(c), key, false)
+	if err != nil {
+		return "", err
+	}
+	return format(v), nil
+}
+
+// Entries lists the effective settings of a project with the layer that set
+// each one. Map sections list only their configured entries.
+func Entries(dir string) ([]Entry, error) {
+	cfg := DefaultConfig()
+	sources := make(map[string]string)
+	for _, layer := range Layers(dir) {
+		if !layer.Exists {
+			continue
+		}
+		if err := cfg.loadFromFile(layer.Path); err != nil {
+			return nil, fmt.Errorf("%s: %w", layer.Path, err)
+		}
+		doc, err := readNode(layer.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range nodeKeys(doc) {
+			sources[key] = layer.Name
+		}
+	}
+	for key := range cfg.envOverrides() {
+		sources[key] = LayerEnv
+	}
+	cfg.loadFromEnv()
+
+	var entries []Entry
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		switch v.Kind() {
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if name := yamlName(v.Type().Field(i)); name != "" {
+					walk(v.Field(i), prefix+name+".")
+				}
+			}
+		case reflect.Map:
+			names := make([]string, 0, v.Len())
+			for _, k := range v.MapKeys() {
+				names = append(names, k.String())
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				walk(v.MapIndex(reflect.ValueOf(name)), prefix+name+".")
+			}
+		default:
+			key := strings.TrimSuffix(prefix, ".")
+			source := sources[key]
+			if source == "" {
+				source = LayerDefault
+			}
+			entries = append(entries, Entry{Key: key, Value: format(v), Source: source})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return entries, nil
+}
+
+// envOverrides returns the keys environment variables override, and the
+// variable setting each one
+func (c *Config) envOverrides() map[string]string {
+	overrides := make(map[string]string)
+	provider := c.AI.Provider
+	for _, name := range []string{"DOCKERIZER_AI_PROVIDER", "DOCKERIZE_AI_PROVIDER"} {
+		if v := os.Getenv(name); v != "" {
+			overrides["ai.provider"] = name
+			provider = v
+			break
+		}
+	}
+	for _, name := range []string{"DOCKERIZER_AI_MODEL", "DOCKERIZE_AI_MODEL"} {
+		if os.Getenv(name) != "" {
+			overrides["ai.model"] = name
+			break
+		}
+	}
+	switch {
+	case provider == "openai" && os.Getenv("OPENAI_API_KEY") != "":
+		overrides["ai.api_key"] = "OPENAI_API_KEY"
+	case provider == "anthropic" && os.Getenv("ANTHROPIC_API_KEY") != "":
+		overrides["ai.api_key"] = "ANTHROPIC_API_KEY"
+	}
+	return overrides
+}
+
+// parseValue converts a string to the node for a setting of type t
+func parseValue(key, value string, t reflect.Type) (*yaml.Node, error) {
+	if allowed, ok := allowedValues[key]; ok {
+		found := false
+		for _, a := range allowed {
+			found = found || a == value
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid value %q for %s: use %s", value, key, strings.Join(allowed, ", "))
+		}
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s: want true or false", value, key)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s: want a number", value, key)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(n)}, nil
+	case reflect.Slice:
+		// Lists are given comma-separated: curl,tini
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		}
+		return seq, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+}
+
+// Set writes a dotted key into the config file at path, creating the file
+// when needed. Comments and other settings in the file are kept.
+func Set(path, key, value string) error {
+	v, err := field(reflect.ValueOf(DefaultConfig()), key, true)
+	if err != nil {
+		return err
+	}
+	node, err := parseValue(key, value, v.Type())
+	if err != nil {
+		return err
+	}
+
+	doc, err := readNode(path)
+	if err != nil {
+		return err
+	}
+	m := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child := mappingValue(m, part)
+		if child == nil || child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			setMappingValue(m, part, child)
+		}
+		m = child
+	}
+	setMappingValue(m, parts[len(parts)-1], node)
+	return writeNode(path, doc, IsSecret(key))
+}
+
+// Unset removes a dotted key from the config file at path, along with
+// sections left empty. It reports whether the key was set.
+func Unset(path, key string) (bool, error) {
+	if _, err := field(reflect.ValueOf(DefaultConfig()), key, true); err != nil {
+		return false, err
+	}
+	if !fileExists(path) {
+		return false, nil
+	}
+	doc, err := readNode(path)
+	if err != nil {
+		return false, err
+	}
+
+	var remove func(m *yaml.Node, parts []string) bool
+	remove = func(m *yaml.Node, parts []string) bool {
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			if m.Content[i].Value != parts[0] {
+				continue
+			}
+			if len(parts) > 1 {
+				child := m.Content[i+1]
+				if child.Kind != yaml.MappingNode || !remove(child, parts[1:]) {
+					return false
+				}
+				if len(child.Content) > 0 {
+					return true
+				}
+			}
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return true
+		}
+		return false
+	}
+	if !remove(doc.Content[0], strings.Split(key, ".")) {
+		return false, nil
+	}
+	return true, writeNode(path, doc, false)
+}
+
+// readNode parses a config file, or returns an empty document when it does
+// not exist
+func readNode(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if doc.Kind != yaml.DocumentNode {
+		doc = &yaml.Node{Kind: yaml.DocumentNode}
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: expected a mapping at the top level", path)
+	}
+	return doc, nil
+}
+
+// writeNode validates the document against Config and writes it to path.
+// private restricts a new or existing file to its owner.
+func writeNode(path string, doc *yaml.Node, private bool) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	enc.Close()
+
+	dec := yaml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	dec.KnownFields(true)
+	if err := dec.Decode(DefaultConfig()); err != nil {
+		return fmt.Errorf("%s would not be valid: %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if private {
+		mode &^= 0077
+	}
+	if err := os.WriteFile(path, buf.Bytes(), mode); err != nil {
+		return err
+	}
+	return os.Chmod(path, mode)
+}
+
+// mappingValue returns the value of key in a mapping node
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key in a mapping node, appending it when missing
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			// Keep comments attached to the old value
+			value.HeadComment = m.Content[i+1].HeadComment
+			value.LineComment = m.Content[i+1].LineComment
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// nodeKeys lists the dotted keys of the scalars and lists set in a document
+func nodeKeys(doc *yaml.Node) []string {
+	var keys []string
+	var walk func(n *yaml.Node, prefix string)
+	walk = func(n *yaml.Node, prefix string) {
+		if n.Kind != yaml.MappingNode {
+			keys = append(keys, strings.TrimSuffix(prefix, "."))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			walk(n.Content[i+1], prefix+n.Content[i].Value+".")
+		}
+	}
+	if len(doc.Content) > 0 {
+		walk(doc.Content[0], "")
+	}
+	return keys
+}
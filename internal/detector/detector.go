@@ -3,8 +3,10 @@ package detector
 import (
 	"context"
 	"sort"
+	"strings"
 
 	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
 )
 
 // Detector detects the stack of a repository
@@ -19,6 +21,7 @@ type Option func(*detector)
 type detector struct {
 	registry      *Registry
 	minConfidence int // Default 80, below this triggers AI
+	progress      func(provider string, done, total int)
 }
 
 // New creates a new detector
@@ -40,12 +43,25 @@ func WithMinConfidence(confidence int) Option {
 	}
 }
 
+// WithProgress sets a function called before each provider is evaluated,
+// with its name and how many of the total are done, and once more with all
+// of them done and no name
+func WithProgress(fn func(provider string, done, total int)) Option {
+	return func(d *detector) {
+		d.progress = fn
+	}
+}
+
 // Detect runs detection against all registered providers
 func (d *detector) Detect(ctx context.Context, scan *scanner.ScanResult) (*DetectionResult, error) {
-	var candidates []Candidate
+	var candidates, rejected []Candidate
 
 	// Run all providers
-	for _, p := range d.registry.Providers() {
+	all := d.registry.Providers()
+	for i, p := range all {
+		if d.progress != nil {
+			d.progress(p.Name(), i, len(all))
+		}
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
@@ -53,7 +69,8 @@ func (d *detector) Detect(ctx context.Context, scan *scanner.ScanResult) (*Detec
 		default:
 		}
 
-		score, vars, err := p.Detect(ctx, scan)
+		var signals []providers.Signal
+		score, vars, err := p.Detect(providers.WithSignals(ctx, &signals), scan)
 		if err != nil {
 			// Log error but continue with other providers
 			continue
@@ -64,12 +81,24 @@ func (d *detector) Detect(ctx context.Context, scan *scanner.ScanResult) (*Detec
 				Provider:   p.Name(),
 				Confidence: score,
 				Variables:  vars,
+				Signals:    signals,
+			})
+		} else if len(signals) > 0 {
+			rejected = append(rejected, Candidate{
+				Provider: p.Name(),
+				Signals:  signals,
+				Reason:   "required signals missing or score below the provider's minimum",
 			})
 		}
 	}
 
-	// Sort by confidence descending
-	sort.Slice(candidates, func(i, j int) bool {
+	if d.progress != nil {
+		d.progress("", len(all), len(all))
+	}
+
+	// Sort by confidence descending; ties keep registration order, so
+	// specific providers win over the fallbacks registered after them
+	sort.SliceStable(candidates, func(i, j int) bool {
 		return candidates[i].Confidence > candidates[j].Confidence
 	})
 
@@ -77,12 +106,54 @@ func (d *detector) Detect(ctx context.Context, scan *scanner.ScanResult) (*Detec
 		return &DetectionResult{
 			Detected:   false,
 			Candidates: candidates,
+			Rejected:   rejected,
+			Hints:      Suggest(scan, d.registry),
 		}, nil
 	}
 
 	best := candidates[0]
 	provider := d.registry.Get(best.Provider)
 
+	// Environment variables the source reads fill in .env.example
+	if scan.Metadata != nil && len(scan.Metadata.EnvVars) > 0 {
+		best.Variables["envVars"] = scan.Metadata.EnvVars
+	}
+
+	// Private registry credentials become build secrets
+	if scan.Metadata != nil && len(scan.Metadata.BuildSecrets) > 0 {
+		best.Variables["buildSecrets"] = scan.Metadata.BuildSecrets
+	}
+
+	// Object stores and message brokers the SDKs use can run in compose
+	if services := DetectBackingServices(scan); len(services) > 0 {
+		best.Variables["backingServices"] = services
+	}
+
+	// Dependencies built for glibc make the templates use Debian images
+	if packages := DetectGlibcPackages(scan); len(packages) > 0 {
+		best.Variables["glibcPackages"] = packages
+	}
+
+	// Procfile process types become compose services sharing the image
+	if scan.Metadata != nil && len(scan.Metadata.Procfile) > 0 {
+		applyProcfile(best.Variables, scan.Metadata.Procfile)
+	}
+
+	// Seed and cache warmup scripts become services of the setup profile
+	if scan.Metadata != nil {
+		applySetupJobs(best.Variables, scan, provider.Language(), provider.Framework())
+	}
+
+	// The test suite is run by the test stage of --with-tests
+	if scan.Metadata != nil {
+		applyTestSuite(best.Variables, scan, provider.Language())
+	}
+
+	// Heroku config vars and add-ons become environment variables
+	if scan.Metadata != nil && scan.Metadata.AppJSON != nil {
+		applyAppJSON(best.Variables, scan.Metadata.AppJSON)
+	}
+
 	return &DetectionResult{
 		Detected:   true,
 		Confidence: best.Confidence,
@@ -93,9 +164,102 @@ func (d *detector) Detect(ctx context.Context, scan *scanner.ScanResult) (*Detec
 		Template:   provider.Template(),
 		Variables:  best.Variables,
 		Candidates: candidates,
+		Rejected:   rejected,
 	}, nil
 }
 
+// applyProcfile adds template variables for Procfile processes: the web command,
+// the release (one-shot) command, and every other process type
+func applyProcfile(vars map[string]interface{}, procfile []scanner.Process) {
+	var processes []map[string]string
+	for _, proc := range procfile {
+		switch proc.Name {
+		case "web":
+			vars["webCommand"] = proc.Command
+		case "release":
+			vars["releaseCommand"] = proc.Command
+			// The release process replaces a detected migration step
+			delete(vars, "migrateCommand")
+		default:
+			processes = append(processes, map[string]string{
+				"name":    serviceName(proc.Name),
+				"command": proc.Command,
+			})
+		}
+	}
+	if len(processes) > 0 {
+		vars["processes"] = processes
+	}
+}
+
+// addonEnv are the config vars Heroku add-ons set
+var addonEnv = map[string]string{
+	"heroku-postgresql": "DATABASE_URL",
+	"heroku-redis":      "REDIS_URL",
+	"heroku-kafka":      "KAFKA_URL",
+	"rediscloud":        "REDISCLOUD_URL",
+	"cloudamqp":         "CLOUDAMQP_URL",
+	"memcachier":        "MEMCACHIER_SERVERS",
+}
+
+// applyAppJSON adds the config vars of a Heroku app.json, and those its
+// add-ons set, to the environment variables the app reads, so .env.example
+// lists them with their values and descriptions
+func applyAppJSON(vars map[string]interface{}, app *scanner.AppJSON) {
+	current, _ := vars["envVars"].([]scanner.EnvVar)
+	envVars := append([]scanner.EnvVar(nil), current...)
+	add := func(v scanner.EnvVar) {
+		for i := range envVars {
+			if envVars[i].Name == v.Name {
+				if envVars[i].Default == "" {
+					envVars[i].Default = v.Default
+				}
+				envVars[i].Description = v.Description
+				return
+			}
+		}
+		envVars = append(envVars, v)
+	}
+
+	names := make([]string, 0, len(app.Env))
+	for name := range app.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env := app.Env[name]
+		v := scanner.EnvVar{Name: name, Default: env.Value, File: "app.json", Description: env.Description}
+		switch {
+		case env.Generator == "secret":
+			v.Description = strings.TrimSpace(v.Description + " (a random secret: openssl rand -hex 32)")
+		case env.Value == "" && (env.Required == nil || *env.Required):
+			v.Description = strings.TrimSpace(v.Description + " (required)")
+		}
+		add(v)
+	}
+	for _, addon := range app.Addons {
+		if name, ok := addonEnv[addon.Service()]; ok {
+			add(scanner.EnvVar{Name: name, File: "app.json", Description: "set by the " + addon.Service() + " add-on on Heroku"})
+		}
+	}
+	if len(envVars) > 0 {
+		vars["envVars"] = envVars
+	}
+}
+
+// serviceName turns a Procfile process type into a valid compose service name
+func serviceName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '-'
+	}, name)
+}
+
 // MinConfidence returns the minimum confidence threshold
 func (d *detector) MinConfidence() int {
 	return d.minConfidence
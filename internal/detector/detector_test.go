@@ -10,15 +10,7 @@ import (
 
 	"github.com/dublyo/dockerizer/internal/detector"
 	"github.com/dublyo/dockerizer/internal/scanner"
-	"github.com/dublyo/dockerizer/providers/dotnet"
-	"github.com/dublyo/dockerizer/providers/elixir"
-	"github.com/dublyo/dockerizer/providers/golang"
-	"github.com/dublyo/dockerizer/providers/java"
-	"github.com/dublyo/dockerizer/providers/nodejs"
-	"github.com/dublyo/dockerizer/providers/php"
-	"github.com/dublyo/dockerizer/providers/python"
-	"github.com/dublyo/dockerizer/providers/ruby"
-	"github.com/dublyo/dockerizer/providers/rust"
+	"github.com/dublyo/dockerizer/providers/all"
 )
 
 type detectionCase struct {
@@ -33,16 +25,7 @@ type detectionCase struct {
 func TestDetectSampleApps(t *testing.T) {
 	root := findTestRoot(t)
 
-	registry := detector.NewRegistry()
-	nodejs.RegisterAll(registry)
-	python.RegisterAll(registry)
-	golang.RegisterAll(registry)
-	rust.RegisterAll(registry)
-	ruby.RegisterAll(registry)
-	php.RegisterAll(registry)
-	java.RegisterAll(registry)
-	dotnet.RegisterAll(registry)
-	elixir.RegisterAll(registry)
+	registry := all.NewRegistry()
 
 	det := detector.New(registry)
 
@@ -88,6 +71,10 @@ func TestDetectSampleApps(t *testing.T) {
 		// Java
 		{name: "springboot", path: "springboot-app", detected: true, language: "java", framework: "springboot"},
 		{name: "quarkus", path: "quarkus-app", detected: true, language: "java", framework: "quarkus"},
+		{name: "ktor", path: "ktor-app", detected: true, language: "java", framework: "ktor"},
+
+		// Scala
+		{name: "play", path: "play-app", detected: true, language: "scala", framework: "play"},
 
 		// .NET
 		{name: "aspnet", path: "aspnet-app", detected: true, language: "dotnet", framework: "aspnet"},
@@ -100,8 +87,6 @@ func TestDetectSampleApps(t *testing.T) {
 		{name: "codeigniter", path: "codeigniter-app", detected: false},
 		{name: "pyramid", path: "pyramid-app", detected: false},
 		{name: "tornado", path: "tornado-app", detected: false},
-		{name: "ktor", path: "ktor-app", detected: false},
-		{name: "play", path: "play-app", detected: false},
 		{name: "unknown", path: "unknown-app", detected: false},
 	}
 
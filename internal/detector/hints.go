@@ -0,0 +1,158 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// Hint is targeted guidance for a project no provider matched
+type Hint struct {
+	Language          string   `json:"language"`
+	Signals           []string `json:"signals"`                     // Files or dependencies that were found
+	SuspectedStack    string   `json:"suspected_stack,omitempty"`   // Recognized but unsupported framework
+	NearestProviders  []string `json:"nearest_providers,omitempty"` // Providers for the same language
+	Message           string   `json:"message"`
+	SupportInProgress bool     `json:"support_in_progress,omitempty"` // Framework support is tracked upstream
+}
+
+// ecosystem describes the manifest files that identify a language
+type ecosystem struct {
+	language string
+	files    []string
+	exts     []string
+}
+
+// unsupportedStack is a framework we can recognize but have no provider for
+type unsupportedStack struct {
+	name     string
+	language string
+	markers  []string // Substrings searched for in manifest files
+	tracked  bool
+}
+
+var ecosystems = []ecosystem{
+	{language: "nodejs", files: []string{"package.json"}},
+	{language: "python", files: []string{"requirements.txt", "pyproject.toml", "setup.py", "Pipfile"}},
+	{language: "go", files: []string{"go.mod"}},
+	{language: "rust", files: []string{"Cargo.toml"}},
+	{language: "ruby", files: []string{"Gemfile"}},
+	{language: "php", files: []string{"composer.json"}},
+	{language: "java", files: []string{"pom.xml", "build.gradle", "build.gradle.kts"}},
+	{language: "scala", files: []string{"build.sbt"}},
+	{language: "dotnet", exts: []string{".csproj", ".fsproj", ".sln"}},
+	{language: "elixir", files: []string{"mix.exs"}},
+}
+
+var unsupportedStacks = []unsupportedStack{
+	{name: "Vert.x", language: "java", markers: []string{"io.vertx"}},
+	{name: "Dropwizard", language: "java", markers: []string{"io.dropwizard"}},
+	{name: "CodeIgniter", language: "php", markers: []string{"codeigniter4/framework", "codeigniter/framework"}},
+	{name: "Slim", language: "php", markers: []string{"slim/slim"}},
+	{name: "Pyramid", language: "python", markers: []string{"pyramid"}},
+	{name: "Tornado", language: "python", markers: []string{"tornado"}},
+	{name: "Chi", language: "go", markers: []string{"github.com/go-chi/chi"}},
+	{name: "Rocket", language: "rust", markers: []string{"rocket"}},
+}
+
+// manifestFiles lists files searched for unsupported stack markers, per language
+var manifestFiles = map[string][]string{
+	"java":   {"pom.xml", "build.gradle", "build.gradle.kts"},
+	"scala":  {"build.sbt", "project/plugins.sbt"},
+	"php":    {"composer.json"},
+	"python": {"requirements.txt", "pyproject.toml", "setup.py", "Pipfile"},
+	"ruby":   {"Gemfile"},
+	"go":     {"go.mod"},
+	"rust":   {"Cargo.toml"},
+}
+
+// Suggest analyzes which ecosystems had partial signals and returns targeted hints.
+// It is used when no provider matched, so the user gets more than "no stack detected".
+func Suggest(scan *scanner.ScanResult, registry *Registry) []Hint {
+	var hints []Hint
+
+	for _, eco := range ecosystems {
+		signals := ecosystemSignals(scan, eco)
+		if len(signals) == 0 {
+			continue
+		}
+
+		hint := Hint{
+			Language: eco.language,
+			Signals:  signals,
+		}
+		for _, p := range registry.ByLanguage(eco.language) {
+			hint.NearestProviders = append(hint.NearestProviders, p.Name())
+		}
+
+		if stack := findUnsupportedStack(scan, eco.language); stack != nil {
+			hint.SuspectedStack = stack.name
+			hint.SupportInProgress = stack.tracked
+			hint.Message = fmt.Sprintf("found %s and it looks like %s, which has no built-in provider yet",
+				strings.Join(signals, ", "), stack.name)
+			if stack.tracked {
+				hint.Message += " (support is tracked)"
+			}
+		} else if len(hint.NearestProviders) > 0 {
+			hint.Message = fmt.Sprintf("found %s but none of the %s providers (%s) matched",
+				strings.Join(signals, ", "), eco.language, strings.Join(hint.NearestProviders, ", "))
+		} else {
+			hint.Message = fmt.Sprintf("found %s but %s is not supported yet", strings.Join(signals, ", "), eco.language)
+		}
+		hint.Message += "; use --ai, or describe the stack to dockerizer agent --instructions"
+
+		hints = append(hints, hint)
+	}
+
+	return hints
+}
+
+// ecosystemSignals returns the manifest files present for an ecosystem
+func ecosystemSignals(scan *scanner.ScanResult, eco ecosystem) []string {
+	var signals []string
+	for _, f := range eco.files {
+		if scan.FileTree.HasFile(f) {
+			signals = append(signals, f)
+		}
+	}
+	for _, ext := range eco.exts {
+		if files := scan.FileTree.FilesWithExtension(ext); len(files) > 0 {
+			signals = append(signals, files[0])
+		}
+	}
+	return signals
+}
+
+// findUnsupportedStack checks manifest files for markers of known unsupported frameworks
+func findUnsupportedStack(scan *scanner.ScanResult, language string) *unsupportedStack {
+	var content strings.Builder
+	for _, f := range manifestFiles[language] {
+		if !scan.FileTree.HasFile(f) {
+			continue
+		}
+		data, err := scan.ReadFile(f)
+		if err == nil {
+			content.WriteString(strings.ToLower(string(data)))
+			content.WriteString("\n")
+		}
+	}
+	if content.Len() == 0 {
+		return nil
+	}
+
+	haystack := content.String()
+	for i := range unsupportedStacks {
+		stack := &unsupportedStacks[i]
+		// Kotlin/Scala builds may mix languages, so match on marker only
+		if stack.language != language && !(language == "java" && stack.language == "scala") {
+			continue
+		}
+		for _, marker := range stack.markers {
+			if strings.Contains(haystack, marker) {
+				return stack
+			}
+		}
+	}
+	return nil
+}
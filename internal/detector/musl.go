@@ -0,0 +1,64 @@
+package detector
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// glibcPackages are runtime dependencies that don't work on musl (Alpine),
+// with why: they ship or download binaries built for glibc only
+var glibcPackages = map[string]string{
+	// npm
+	"playwright":            "its browsers are built for glibc",
+	"playwright-core":       "its browsers are built for glibc",
+	"puppeteer":             "the Chrome it downloads is built for glibc",
+	"@tensorflow/tfjs-node": "libtensorflow is built for glibc",
+	"onnxruntime-node":      "its binaries are built for glibc",
+	"oracledb":              "Oracle Instant Client needs glibc",
+	"duckdb":                "it has no musl binaries",
+	"grpc":                  "the native grpc addon has no musl binaries",
+	// NuGet
+	"Grpc.Core":                    "its native library is built for glibc",
+	"Microsoft.ML.OnnxRuntime":     "its native library is built for glibc",
+	"SkiaSharp.NativeAssets.Linux": "its native library is built for glibc",
+	"Microsoft.Playwright":         "its browsers are built for glibc",
+	"PuppeteerSharp":               "the Chrome it downloads is built for glibc",
+	"TorchSharp":                   "libtorch is built for glibc",
+}
+
+// packageReference matches the packages of a .csproj
+var packageReference = regexp.MustCompile(`<PackageReference\s+Include="([^"]+)"`)
+
+// DetectGlibcPackages returns the runtime dependencies that need glibc, as
+// "name (reason)", sorted. The production dependencies of package.json and
+// the package references of .csproj files are checked.
+func DetectGlibcPackages(scan *scanner.ScanResult) []string {
+	found := make(map[string]bool)
+	if scan.Metadata != nil && scan.Metadata.PackageJSON != nil {
+		for name := range scan.Metadata.PackageJSON.Dependencies {
+			found[name] = true
+		}
+	}
+	if scan.FileTree != nil {
+		for _, file := range scan.FileTree.FilesWithExtension(".csproj") {
+			data, err := scan.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			for _, m := range packageReference.FindAllStringSubmatch(string(data), -1) {
+				found[m[1]] = true
+			}
+		}
+	}
+
+	var packages []string
+	for name := range found {
+		if reason, ok := glibcPackages[name]; ok {
+			packages = append(packages, name+" ("+reason+")")
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
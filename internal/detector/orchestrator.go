@@ -0,0 +1,204 @@
+package detector
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// SelectBuildTarget picks the orchestrator project to dockerize: the one
+// named by target, or else the only deployable project. It returns nil when
+// target is empty and no project is deployable, in which case the root
+// itself is the project.
+func SelectBuildTarget(o *scanner.Orchestrator, target string) (*scanner.BuildProject, error) {
+	if target != "" {
+		if p := o.Project(target); p != nil {
+			return p, nil
+		}
+		return nil, fmt.Errorf("%w: %q (available: %s)", errors.ErrTargetNotFound, target, strings.Join(buildTargetNames(o, false), ", "))
+	}
+
+	var deployable []*scanner.BuildProject
+	for i := range o.Projects {
+		if o.Projects[i].Application {
+			deployable = append(deployable, &o.Projects[i])
+		}
+	}
+	switch len(deployable) {
+	case 0:
+		return nil, nil
+	case 1:
+		return deployable[0], nil
+	}
+	return nil, fmt.Errorf("%w: choose one with --target (%s)", errors.ErrTargetAmbiguous, strings.Join(buildTargetNames(o, true), ", "))
+}
+
+func buildTargetNames(o *scanner.Orchestrator, deployable bool) []string {
+	var names []string
+	for _, p := range o.Projects {
+		if p.Application || !deployable {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// ApplyBuildTarget switches a detection result to build one project of an
+// orchestrator from the repository root, so the build only compiles the
+// project and what it depends on. result is the detection in the project
+// directory; Nx and Bazel projects need none. root is the repository scan.
+func ApplyBuildTarget(result *DetectionResult, root *scanner.ScanResult, o *scanner.Orchestrator, p *scanner.BuildProject) error {
+	if !p.Application {
+		return fmt.Errorf("%s is not deployable (%s)", p.Name, strings.TrimSpace(p.Kind+" library"))
+	}
+	if result.Variables == nil || o.Tool != "gradle" {
+		// Nx and Bazel replace whatever the project directory looked like
+		vars := make(map[string]interface{})
+		for _, key := range []string{"envVars", "buildSecrets"} {
+			if v, ok := result.Variables[key]; ok {
+				vars[key] = v
+			}
+		}
+		result.Variables = vars
+	}
+	vars := result.Variables
+
+	// The build context is the repository root; the Dockerfile lives in the project
+	vars["buildContext"] = "."
+	if dir := path.Clean(p.Dir); dir != "." {
+		vars["buildContext"] = strings.TrimSuffix(strings.Repeat("../", strings.Count(dir, "/")+1), "/")
+	}
+	vars["dockerfilePath"] = path.Join(p.Dir, "Dockerfile")
+	vars["appDir"] = p.Dir
+
+	switch o.Tool {
+	case "gradle":
+		if !result.Detected || result.Language != "java" {
+			return fmt.Errorf("could not detect a Java app in %s", p.Dir)
+		}
+		vars["buildTool"] = "gradle"
+		vars["gradleProject"] = p.Name
+		vars["gradleDir"] = p.Dir
+		vars["hasWrapper"] = root.FileTree.HasFile("gradlew")
+		// The root build script often declares the toolchain for every
+		// project, and the plugin versions applied with apply false
+		javaVersion := p.JavaVersion
+		if root.Metadata != nil && root.Metadata.Gradle != nil {
+			if javaVersion == "" {
+				javaVersion = root.Metadata.Gradle.JavaVersion
+			}
+			if version := root.Metadata.Gradle.Plugins["org.springframework.boot"]; version != "" && vars["springBootVersion"] == nil {
+				vars["springBootVersion"] = version
+			}
+		}
+		if javaVersion != "" {
+			vars["javaVersion"] = javaVersion
+		}
+	case "nx":
+		applyNx(result, root, p)
+	case "bazel":
+		applyBazel(result, o, p)
+	}
+	result.Detected = true
+	result.Confidence = 100 // The orchestrator declares the project
+	return nil
+}
+
+// nxStaticExecutors build browser apps, served as static files
+var nxStaticExecutors = []string{"vite", "angular", "react", "rollup", "rspack:rspack", "webpack:webpack"}
+
+// applyNx builds an Nx project with nx run <project>:build
+func applyNx(result *DetectionResult, root *scanner.ScanResult, p *scanner.BuildProject) {
+	vars := result.Variables
+	result.Language = "nodejs"
+	result.Template = "nodejs/nx.tmpl"
+	result.Provider = "nx"
+
+	vars["nxProject"] = p.Name
+	if p.Production {
+		vars["nxConfiguration"] = "production"
+	}
+	output := p.Options["outputPath"]
+	if output == "" {
+		output = path.Join("dist", p.Dir)
+	}
+	vars["nxOutputPath"] = output
+
+	kind := "node"
+	switch {
+	case strings.HasPrefix(p.Kind, "@nx/next:") || strings.HasPrefix(p.Kind, "@nrwl/next:"):
+		kind = "next"
+	case p.Options["target"] == "node" || strings.Contains(p.Kind, "/node:") || strings.HasSuffix(p.Kind, ":tsc") || strings.HasSuffix(p.Kind, ":esbuild"):
+	default:
+		for _, executor := range nxStaticExecutors {
+			if strings.Contains(p.Kind, executor) {
+				kind = "static"
+			}
+		}
+	}
+	result.Framework = map[string]string{"node": "node", "next": "nextjs", "static": "spa"}[kind]
+	vars["nxKind"] = kind
+	switch kind {
+	case "static":
+		vars["port"] = "8080" // nginx-unprivileged
+		if strings.Contains(p.Kind, "angular") && strings.HasSuffix(p.Kind, ":application") {
+			vars["nxOutputPath"] = output + "/browser" // The application builder nests the browser bundle
+		}
+	case "next":
+		vars["port"] = "3000"
+		vars["nxPackageJSON"] = true // @nx/next generates one by default
+	default:
+		vars["port"] = "3000"
+		vars["nxPackageJSON"] = p.Options["generatePackageJson"] == "true"
+		main := "main.js"
+		if m := p.Options["main"]; m != "" && strings.HasSuffix(p.Kind, ":tsc") {
+			// tsc keeps the source layout below the project root
+			main = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(m, p.Dir), "/"), path.Ext(m)) + ".js"
+		}
+		vars["nxMain"] = main
+	}
+
+	vars["packageManager"] = "npm"
+	for _, lock := range []struct{ file, pm string }{{"pnpm-lock.yaml", "pnpm"}, {"yarn.lock", "yarn"}, {"bun.lockb", "bun"}, {"bun.lock", "bun"}, {"package-lock.json", "npm"}} {
+		if root.FileTree.HasFile(lock.file) {
+			vars["packageManager"] = lock.pm
+			vars["lockFile"] = lock.file
+			vars["hasLockFile"] = true
+			break
+		}
+	}
+	vars["workspaceTool"] = vars["packageManager"] // Installs the whole monorepo
+}
+
+// applyBazel builds a Bazel binary target with bazel build
+func applyBazel(result *DetectionResult, o *scanner.Orchestrator, p *scanner.BuildProject) {
+	vars := result.Variables
+	result.Language = "bazel"
+	result.Framework = p.Kind
+	result.Template = "bazel/binary.tmpl"
+	result.Provider = "bazel"
+
+	pkg, name, _ := strings.Cut(strings.TrimPrefix(p.Name, "//"), ":")
+	output := path.Join(pkg, name)
+	target := p.Name
+	if p.Kind == "java_binary" {
+		// The deploy jar bundles the dependencies
+		vars["bazelJava"] = true
+		output += "_deploy.jar"
+		target += "_deploy.jar"
+	}
+	vars["bazelTarget"] = target
+	vars["bazelOutput"] = output
+	if o.Version != "" {
+		vars["bazelVersion"] = o.Version
+	}
+	if vars["port"] == nil {
+		vars["port"] = "8080"
+	}
+	if p.Kind != "java_binary" {
+		vars["noShell"] = true // Distroless runtime
+	}
+}
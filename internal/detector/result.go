@@ -1,6 +1,8 @@
 // Package detector provides stack detection functionality.
 package detector
 
+import "github.com/dublyo/dockerizer/providers"
+
 // DetectionResult contains the detection outcome
 type DetectionResult struct {
 	Detected   bool
@@ -14,6 +16,12 @@ type DetectionResult struct {
 
 	// All candidates with scores (for debugging)
 	Candidates []Candidate
+
+	// Providers that found some signals but did not match
+	Rejected []Candidate
+
+	// Guidance for unsupported stacks (only set when nothing was detected)
+	Hints []Hint
 }
 
 // Candidate is a potential match
@@ -22,6 +30,17 @@ type Candidate struct {
 	Confidence int
 	Variables  map[string]interface{} // Template variables extracted during detection
 	Reason     string
+	Signals    []providers.Signal // Evidence found and the points each added
+}
+
+// SignalTotal returns the sum of the candidate's signal points. It can exceed
+// Confidence, which providers cap at 100.
+func (c *Candidate) SignalTotal() int {
+	total := 0
+	for _, s := range c.Signals {
+		total += s.Points
+	}
+	return total
 }
 
 // NeedsAI returns true if the detection confidence is below the threshold
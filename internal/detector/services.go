@@ -0,0 +1,152 @@
+package detector
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// BackingService is an object store or message broker the app's SDKs talk
+// to, which docker-compose.yml can run next to it (dockerize --with-deps)
+type BackingService struct {
+	Name      string   `json:"name"` // Compose service: minio, redpanda or nats
+	Kind      string   `json:"kind"` // What the SDKs use it as: S3, Kafka or NATS
+	SDKs      []string `json:"sdks"`
+	Resources []string `json:"resources,omitempty"` // Buckets or topics to create, as compose values
+	Env       []string `json:"env"`                 // NAME=value entries pointing the app at the service
+}
+
+// backingService describes a service and how to recognize its SDKs
+type backingService struct {
+	name, kind string
+	sdks       []string       // Dependency names; Go modules also match their subpackages
+	endpoint   *regexp.Regexp // Env vars the app reads for the service's address
+	resource   *regexp.Regexp // Env vars naming buckets or topics
+	env        []string       // Set for every app
+	address    string         // Value of the endpoint env vars
+	defaultVar string         // Endpoint env var set when the app reads none
+}
+
+// backingServices are the services --with-deps can run, in compose order
+var backingServices = []backingService{
+	{
+		name: "minio",
+		kind: "S3",
+		sdks: []string{
+			"@aws-sdk/client-s3", "aws-sdk", "minio", // npm
+			"boto3", "aioboto3", "s3fs", // Python
+			"github.com/aws/aws-sdk-go-v2/service/s3", "github.com/aws/aws-sdk-go", "github.com/minio/minio-go", // Go
+			"aws-sdk-s3", "rust-s3", // Ruby and Rust
+			"aws/aws-sdk-php", "league/flysystem-aws-s3-v3", // PHP
+			"s3", "aws-java-sdk-s3", // Maven
+		},
+		endpoint: regexp.MustCompile(`^(S3|MINIO|AWS_S3)_?(ENDPOINT|URL|HOST)`),
+		resource: regexp.MustCompile(`BUCKET`),
+		env: []string{
+			"AWS_ENDPOINT_URL=${AWS_ENDPOINT_URL:-http://minio:9000}",
+			"AWS_ACCESS_KEY_ID=${MINIO_ROOT_USER:-minioadmin}",
+			"AWS_SECRET_ACCESS_KEY=${MINIO_ROOT_PASSWORD:-minioadmin}",
+			"AWS_REGION=${AWS_REGION:-us-east-1}",
+		},
+		address: "http://minio:9000",
+	},
+	{
+		name: "redpanda",
+		kind: "Kafka",
+		sdks: []string{
+			"kafkajs", "node-rdkafka", "@confluentinc/kafka-javascript", // npm
+			"confluent-kafka", "kafka-python", "aiokafka", // Python
+			"github.com/segmentio/kafka-go", "github.com/confluentinc/confluent-kafka-go", "github.com/IBM/sarama", "github.com/Shopify/sarama", "github.com/twmb/franz-go", // Go
+			"ruby-kafka", "karafka", "rdkafka", // Ruby and Rust
+			"kafka-clients", "spring-kafka", // Maven
+		},
+		endpoint:   regexp.MustCompile(`^KAFKA_?(BROKERS?|BOOTSTRAP_SERVERS|URL|HOST)$`),
+		resource:   regexp.MustCompile(`TOPIC`),
+		address:    "redpanda:9092",
+		defaultVar: "KAFKA_BROKERS",
+	},
+	{
+		name: "nats",
+		kind: "NATS",
+		sdks: []string{
+			"nats", "@nats-io/transport-node", // npm and Rust
+			"nats-py",                    // Python
+			"github.com/nats-io/nats.go", // Go
+			"nats-pure", "async-nats",    // Ruby and Rust
+			"jnats", // Maven
+		},
+		endpoint:   regexp.MustCompile(`^NATS_?(URL|SERVERS?|HOST)$`),
+		address:    "nats://nats:4222",
+		defaultVar: "NATS_URL",
+	},
+}
+
+// DetectBackingServices finds the object stores and message brokers the
+// project's dependencies talk to. Buckets and topics come from the env vars
+// the app reads (S3_BUCKET, ORDERS_TOPIC), with their inline defaults.
+func DetectBackingServices(scan *scanner.ScanResult) []BackingService {
+	if scan.Metadata == nil {
+		return nil
+	}
+	deps := scan.Metadata.Dependencies()
+
+	var services []BackingService
+	for _, def := range backingServices {
+		var sdks []string
+		for _, dep := range deps {
+			for _, sdk := range def.sdks {
+				if dep == sdk || strings.Contains(sdk, "/") && strings.HasPrefix(dep, sdk+"/") {
+					sdks = append(sdks, dep)
+					break
+				}
+			}
+		}
+		if len(sdks) == 0 {
+			continue
+		}
+
+		s := BackingService{Name: def.name, Kind: def.kind, SDKs: sdks, Env: append([]string(nil), def.env...)}
+		endpoints := 0
+		for _, v := range scan.Metadata.EnvVars {
+			switch {
+			case def.endpoint.MatchString(v.Name):
+				if !setsEnv(s.Env, v.Name) {
+					s.Env = append(s.Env, v.Name+"=${"+v.Name+":-"+def.address+"}")
+				}
+				endpoints++
+			case def.resource != nil && def.resource.MatchString(v.Name):
+				value := "${" + v.Name + ":-" + resourceName(v) + "}"
+				s.Resources = append(s.Resources, value)
+				s.Env = append(s.Env, v.Name+"="+value)
+			}
+		}
+		if endpoints == 0 && def.defaultVar != "" {
+			// The app reads its address some other way: set the usual name
+			s.Env = append(s.Env, def.defaultVar+"=${"+def.defaultVar+":-"+def.address+"}")
+		}
+		if def.resource != nil && len(s.Resources) == 0 {
+			s.Resources = []string{"app"} // Rename to the buckets or topics the app uses
+		}
+		services = append(services, s)
+	}
+	return services
+}
+
+// setsEnv reports whether env has a NAME=value entry for name
+func setsEnv(env []string, name string) bool {
+	for _, e := range env {
+		if strings.HasPrefix(e, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// resourceName is the bucket or topic an env var names when unset
+func resourceName(v scanner.EnvVar) string {
+	if v.Default != "" && !strings.ContainsAny(v.Default, "${} \"'") {
+		return v.Default
+	}
+	return "app"
+}
@@ -0,0 +1,47 @@
+package detector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+func TestDetectBackingServices(t *testing.T) {
+	scan := &scanner.ScanResult{Metadata: &scanner.Metadata{
+		Requirements: []string{"boto3==1.34.0", "confluent-kafka>=2.3"},
+		GoMod:        &scanner.GoMod{Require: []string{"github.com/nats-io/nats.go", "github.com/aws/aws-sdk-go-v2/service/s3"}},
+		EnvVars: []scanner.EnvVar{
+			{Name: "UPLOADS_BUCKET", Default: "uploads"},
+			{Name: "KAFKA_BOOTSTRAP_SERVERS"},
+			{Name: "ORDERS_TOPIC"},
+		},
+	}}
+	services := DetectBackingServices(scan)
+	if len(services) != 3 {
+		t.Fatalf("DetectBackingServices() = %+v, want minio, redpanda and nats", services)
+	}
+
+	minio := services[0]
+	if minio.Name != "minio" || strings.Join(minio.SDKs, ",") != "boto3,github.com/aws/aws-sdk-go-v2/service/s3" {
+		t.Errorf("minio = %+v", minio)
+	}
+	if len(minio.Resources) != 1 || minio.Resources[0] != "${UPLOADS_BUCKET:-uploads}" {
+		t.Errorf("buckets = %v", minio.Resources)
+	}
+
+	kafka := services[1]
+	if len(kafka.Resources) != 1 || kafka.Resources[0] != "${ORDERS_TOPIC:-app}" {
+		t.Errorf("topics = %v", kafka.Resources)
+	}
+	if !setsEnv(kafka.Env, "KAFKA_BOOTSTRAP_SERVERS") || setsEnv(kafka.Env, "KAFKA_BROKERS") {
+		t.Errorf("kafka env = %v, want the variable the app reads", kafka.Env)
+	}
+	if nats := services[2]; !setsEnv(nats.Env, "NATS_URL") || len(nats.Resources) != 0 {
+		t.Errorf("nats = %+v", nats)
+	}
+
+	if got := DetectBackingServices(&scanner.ScanResult{Metadata: &scanner.Metadata{Requirements: []string{"natsort"}}}); len(got) != 0 {
+		t.Errorf("natsort detected as %+v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package detector
+
+import (
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// setupScripts are package.json scripts of one-shot setup tasks, by the
+// compose service that runs them; the first script found wins
+var setupScripts = []struct {
+	service string
+	scripts []string
+}{
+	{"seed", []string{"db:seed", "seed", "prisma:seed", "seed:run"}},
+	{"warmup", []string{"cache:warm", "cache:warmup", "warmup"}},
+}
+
+// setupFiles are the seed files of frameworks with a seed command
+var setupFiles = map[string]struct{ file, command string }{
+	"rails":   {"db/seeds.rb", "bin/rails db:seed"},
+	"laravel": {"database/seeders/DatabaseSeeder.php", "php artisan db:seed --force"},
+}
+
+// applySetupJobs adds the one-shot jobs of the compose "setup" profile
+// (setupJobs): database seeding and cache warmup, from package.json scripts
+// or the framework's seed file. They run from the app image on demand, never
+// with a plain docker compose up.
+func applySetupJobs(vars map[string]interface{}, scan *scanner.ScanResult, language, framework string) {
+	taken := map[string]bool{}
+	if processes, ok := vars["processes"].([]map[string]string); ok {
+		for _, p := range processes {
+			taken[p["name"]] = true
+		}
+	}
+	var jobs []map[string]string
+	add := func(name, command string) {
+		if !taken[name] {
+			taken[name] = true
+			jobs = append(jobs, map[string]string{"name": name, "command": command})
+		}
+	}
+
+	// Static sites are served by nginx, which can't run the scripts
+	pkg := scan.Metadata.PackageJSON
+	static := vars["staticServer"] != nil || vars["outputMode"] == "static"
+	if pkg != nil && (language == "nodejs" || language == "bun") && !static {
+		run := "npm run"
+		if language == "bun" || vars["packageManager"] == "bun" {
+			run = "bun run" // The bun runner image has no npm
+		}
+		for _, s := range setupScripts {
+			for _, script := range s.scripts {
+				if pkg.HasScript(script) {
+					add(s.service, run+" "+script)
+					break
+				}
+			}
+		}
+	}
+	if seed, ok := setupFiles[framework]; ok && scan.FileTree.HasFile(seed.file) {
+		add("seed", seed.command)
+	}
+
+	if len(jobs) > 0 {
+		vars["setupJobs"] = jobs
+	}
+}
@@ -0,0 +1,116 @@
+package detector
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// npmPlaceholderTest is the test script npm init writes, which always fails
+const npmPlaceholderTest = `echo "Error: no test specified" && exit 1`
+
+// applyTestSuite sets the command of the project's test suite (testSuite),
+// which the test stage of --with-tests runs: npm test, pytest, go test or
+// cargo test. "install" adds what the suite needs over the production
+// dependencies; "devInstall" installs the dev dependencies an image without
+// a build stage left out; "env" is set for the run.
+func applyTestSuite(vars map[string]interface{}, scan *scanner.ScanResult, language string) {
+	suite := map[string]string{}
+	switch language {
+	case "nodejs", "bun":
+		pkg := scan.Metadata.PackageJSON
+		if !pkg.HasScript("test") || strings.TrimSpace(pkg.Scripts["test"]) == npmPlaceholderTest {
+			return
+		}
+		switch pm, _ := vars["packageManager"].(string); {
+		case language == "bun" || pm == "bun":
+			suite["command"], suite["devInstall"] = "bun run test", "bun install"
+		case pm == "pnpm":
+			suite["command"], suite["devInstall"] = "pnpm test", "pnpm install --frozen-lockfile"
+		case pm == "yarn":
+			suite["command"], suite["devInstall"] = "yarn test", "yarn install --frozen-lockfile"
+		case vars["hasLockFile"] == true:
+			suite["command"], suite["devInstall"] = "npm test", "npm ci"
+		default:
+			suite["command"], suite["devInstall"] = "npm test", "npm install"
+		}
+		// Also installs the dev dependencies, which production leaves out
+		suite["env"] = "NODE_ENV=test"
+	case "go":
+		if len(scan.FileTree.FilesMatching("*_test.go")) == 0 {
+			return
+		}
+		suite["command"] = "go test ./..."
+	case "rust":
+		suite["command"] = "cargo test --release"
+	case "python":
+		if !applyPytest(suite, vars, scan) {
+			return
+		}
+	default:
+		return
+	}
+	vars["testSuite"] = suite
+}
+
+// applyPytest sets the pytest run of a Python project, installing its dev
+// dependencies with the package manager the templates use. pytest is found
+// declared, configured, or in a requirements file of its own.
+func applyPytest(suite map[string]string, vars map[string]interface{}, scan *scanner.ScanResult) bool {
+	declared := slices.Contains(scan.Metadata.Dependencies(), "pytest")
+	if p := scan.Metadata.PyProject; p != nil {
+		declared = declared || slices.Contains(p.DevDependencies, "pytest")
+		for _, extra := range p.OptionalDependencies {
+			declared = declared || slices.Contains(extra, "pytest")
+		}
+	}
+	configured := scan.FileTree.HasFile("pytest.ini") || scan.FileTree.HasFile("conftest.py") ||
+		scan.FileTree.HasFile("tests/conftest.py") ||
+		scan.Metadata.PyProject != nil && slices.Contains(scan.Metadata.PyProject.Tools, "pytest")
+	var requirements string
+	for _, file := range []string{"requirements-dev.txt", "requirements-test.txt", "requirements/dev.txt", "requirements/test.txt"} {
+		if scan.FileTree.HasFile(file) {
+			requirements = file
+			break
+		}
+	}
+
+	// pytest comes from PyPI when only its configuration was found
+	addPytest := "pip install --no-cache-dir pytest"
+	switch pm, _ := vars["packageManager"].(string); pm {
+	case "uv":
+		suite["install"], suite["command"] = "uv sync --frozen", "pytest"
+		addPytest = "uv pip install pytest"
+	case "poetry":
+		suite["install"], suite["command"] = "poetry install --no-interaction --no-ansi", "pytest"
+	case "pipenv":
+		suite["install"], suite["command"] = "pipenv install --dev --system --deploy --ignore-pipfile", "pytest"
+	case "pdm":
+		// The project virtualenv has no pip
+		if !declared {
+			return false
+		}
+		suite["install"], suite["command"] = "pdm install --check --dev --no-editable", "pdm run pytest"
+	default:
+		suite["command"] = "python -m pytest"
+		if requirements != "" {
+			suite["install"] = "pip install --no-cache-dir -r " + requirements
+			return true
+		}
+		if declared {
+			return true
+		}
+	}
+	switch {
+	case declared:
+	case configured:
+		if suite["install"] != "" {
+			suite["install"] += " && "
+		}
+		suite["install"] += addPytest
+	default:
+		return false
+	}
+	return true
+}
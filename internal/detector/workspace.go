@@ -0,0 +1,120 @@
+package detector
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// SelectWorkspaceApp picks the workspace package to dockerize: the one named by
+// app (package name or directory), or else the only deployable package. It
+// returns nil when app is empty and no package is deployable, in which case
+// the root itself is the project.
+func SelectWorkspaceApp(ws *scanner.Workspace, app string) (*scanner.WorkspacePackage, error) {
+	if app != "" {
+		if pkg := ws.Package(app); pkg != nil {
+			return pkg, nil
+		}
+		return nil, fmt.Errorf("%w: %q (available: %s)", errors.ErrAppNotFound, app, strings.Join(workspacePackageNames(ws), ", "))
+	}
+
+	deployable := DeployablePackages(ws)
+	switch len(deployable) {
+	case 0:
+		return nil, nil
+	case 1:
+		return deployable[0], nil
+	}
+
+	var names []string
+	for _, pkg := range deployable {
+		names = append(names, pkg.Name)
+	}
+	return nil, fmt.Errorf("%w: choose one with --app (%s)", errors.ErrAppAmbiguous, strings.Join(names, ", "))
+}
+
+// DeployablePackages returns the workspace packages that look like apps
+func DeployablePackages(ws *scanner.Workspace) []*scanner.WorkspacePackage {
+	var deployable []*scanner.WorkspacePackage
+	for i := range ws.Packages {
+		if isDeployable(&ws.Packages[i]) {
+			deployable = append(deployable, &ws.Packages[i])
+		}
+	}
+	return deployable
+}
+
+// isDeployable reports whether a workspace package looks like an app rather than a library
+func isDeployable(pkg *scanner.WorkspacePackage) bool {
+	if pkg.PackageJSON.HasScript("start") {
+		return true
+	}
+	return strings.HasPrefix(pkg.Dir, "apps/") && pkg.PackageJSON.HasScript("build")
+}
+
+func workspacePackageNames(ws *scanner.Workspace) []string {
+	var names []string
+	for _, pkg := range ws.Packages {
+		if pkg.Name != "" {
+			names = append(names, pkg.Name)
+		} else {
+			names = append(names, pkg.Dir)
+		}
+	}
+	return names
+}
+
+// ApplyWorkspace switches a Node.js detection result for a workspace package to
+// the workspace template, which builds from the monorepo root and prunes to
+// the package (turbo prune or pnpm deploy)
+func ApplyWorkspace(result *DetectionResult, ws *scanner.Workspace, pkg *scanner.WorkspacePackage, rootPkg *scanner.PackageJSON) {
+	// Static SPAs are served by their own template
+	if result.Language != "nodejs" || result.Variables["staticServer"] != nil {
+		return
+	}
+
+	vars := result.Variables
+	vars["workspace"] = true
+	vars["workspaceTool"] = ws.Tool
+	vars["packageManager"] = ws.Tool
+	vars["lockFile"] = ws.LockFile
+	vars["hasLockFile"] = ws.LockFile != ""
+	vars["turbo"] = ws.Turbo
+	vars["nx"] = ws.Nx && !ws.Turbo
+	vars["appDir"] = pkg.Dir
+	vars["appPackage"] = pkg.Name
+	vars["appHasBuild"] = pkg.PackageJSON.HasScript("build")
+	vars["appHasStart"] = pkg.PackageJSON.HasScript("start")
+
+	// The build context is the workspace root; the files live in the package
+	vars["buildContext"] = strings.TrimSuffix(strings.Repeat("../", strings.Count(path.Clean(pkg.Dir), "/")+1), "/")
+	vars["dockerfilePath"] = path.Join(pkg.Dir, "Dockerfile")
+
+	if rootPkg != nil {
+		if v, ok := strings.CutPrefix(rootPkg.PackageManager, "pnpm@"); ok {
+			vars["pnpmVersion"] = v
+		}
+		if v := rootPkg.DevDependencies["turbo"]; v != "" {
+			if major := strings.SplitN(strings.TrimLeft(v, "^~>=v "), ".", 2)[0]; major != "" {
+				vars["turboVersion"] = major
+			}
+		}
+	}
+	// pnpm 10 only deploys without injected workspace packages in legacy mode
+	if ws.Tool == "pnpm" {
+		v, _ := vars["pnpmVersion"].(string)
+		major, err := strconv.Atoi(strings.SplitN(v, ".", 2)[0])
+		vars["pnpmLegacyDeploy"] = err != nil || major >= 10
+	}
+
+	// Migrations run from the build stage, which holds the whole workspace
+	if cmd, ok := vars["migrateCommand"].(string); ok {
+		vars["migrateCommand"] = "cd " + pkg.Dir + " && " + cmd
+	}
+
+	result.Template = "nodejs/workspace.tmpl"
+}
@@ -0,0 +1,474 @@
+package dockerfile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Explanation annotates each instruction of a Dockerfile with what it does,
+// the best practices it meets or violates and its effect on the image
+type Explanation struct {
+	Stages      int           `json:"stages"`
+	Layers      int           `json:"layers"` // Layers the final image adds to its base image
+	Annotations []*Annotation `json:"instructions"`
+	Findings    []Note        `json:"findings,omitempty"` // About the file as a whole
+}
+
+// Annotation explains one instruction
+type Annotation struct {
+	Line    int    `json:"line"`
+	Source  string `json:"source"` // The instruction as written
+	Stage   string `json:"stage,omitempty"`
+	Summary string `json:"summary"` // What it does
+	Layer   string `json:"layer"`   // Its effect on the image
+	Notes   []Note `json:"notes,omitempty"`
+}
+
+// Note is a best practice an instruction meets, or a problem with it
+type Note struct {
+	Good    bool   `json:"good"`
+	Message string `json:"message"`
+}
+
+// Issues counts the notes that are problems
+func (e *Explanation) Issues() int {
+	n := 0
+	for _, a := range e.Annotations {
+		for _, note := range a.Notes {
+			if !note.Good {
+				n++
+			}
+		}
+	}
+	for _, note := range e.Findings {
+		if !note.Good {
+			n++
+		}
+	}
+	return n
+}
+
+var (
+	// secretName matches variable names that suggest a secret
+	secretName = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|private_?key|access_?key|credentials?)`)
+	// pipeToShell matches a download piped into a shell
+	pipeToShell = regexp.MustCompile(`\b(curl|wget)\b[^|;&]*\|\s*(sudo\s+)?(ba|z|da)?sh\b`)
+	// installDeps matches the commands that install an app's dependencies
+	installDeps = regexp.MustCompile(`\b(npm (ci|install|i)|yarn( install)?|pnpm (install|i)|bun install|pip3? install|poetry install|uv sync|pipenv install|bundle install|go mod download|composer install|mvn\b[^&;]*dependency:|gradle\b[^&;]*dependencies|cargo fetch|dotnet restore|mix deps\.get)\b`)
+)
+
+// Explain annotates every instruction of the Dockerfile
+func (d *Dockerfile) Explain() *Explanation {
+	e := &Explanation{Stages: len(d.Stages)}
+	final := d.FinalStage()
+
+	// Stages the final image is built on (FROM an earlier stage) ship too
+	shipped := map[*Stage]bool{}
+	for s := final; s != nil; s = d.BaseStage(s) {
+		shipped[s] = true
+	}
+
+	for _, in := range d.Args {
+		e.Annotations = append(e.Annotations, d.explain(in, nil, false))
+	}
+	for _, s := range d.Stages {
+		a := d.explain(s.From, s, shipped[s])
+		e.Annotations = append(e.Annotations, a)
+		for i, in := range s.Instructions {
+			a := d.explain(in, s, shipped[s])
+			d.stageNotes(a, s, i, s == final)
+			if shipped[s] && layerInstructions[in.Cmd] {
+				e.Layers++
+			}
+			e.Annotations = append(e.Annotations, a)
+		}
+	}
+
+	for _, err := range d.Errors {
+		e.Findings = append(e.Findings, Note{Message: err.Error()})
+	}
+	if final == nil {
+		return e
+	}
+	if len(d.Stages) > 1 {
+		e.Findings = append(e.Findings, Note{Good: true, Message: fmt.Sprintf("Multi-stage build: %d stages, and only what the final stage copies ships", len(d.Stages))})
+	}
+	user := ""
+	healthcheck := false
+	for s := final; s != nil; s = d.BaseStage(s) {
+		for i := len(s.Instructions) - 1; i >= 0; i-- {
+			switch in := s.Instructions[i]; in.Cmd {
+			case "USER":
+				if user == "" && len(in.Args) > 0 {
+					user = in.Args[0]
+				}
+			case "HEALTHCHECK":
+				healthcheck = true
+			}
+		}
+	}
+	switch {
+	case user == "" && !nonRootImage(final.Image):
+		e.Findings = append(e.Findings, Note{Message: "No USER: the container runs as root; add a non-root user to the final stage"})
+	case isRoot(user):
+		e.Findings = append(e.Findings, Note{Message: "The final USER is root: the container runs as root"})
+	}
+	if !healthcheck {
+		e.Findings = append(e.Findings, Note{Message: "No HEALTHCHECK: Docker can't tell a hung app from a healthy one (unless compose or the orchestrator defines one)"})
+	}
+	return e
+}
+
+// BaseStage returns the earlier stage a stage is built FROM, or nil when it
+// starts from an image
+func (d *Dockerfile) BaseStage(s *Stage) *Stage {
+	if p := d.Stage(s.Image); p != nil && p.Index < s.Index {
+		return p
+	}
+	return nil
+}
+
+// layerInstructions are the instructions that add a filesystem layer
+var layerInstructions = map[string]bool{"RUN": true, "COPY": true, "ADD": true}
+
+// Source returns an instruction as written, continuation lines included
+func (d *Dockerfile) Source(in *Instruction) string {
+	if in.StartLine < 1 || in.EndLine > len(d.lines) {
+		return in.Cmd + " " + in.Value
+	}
+	return strings.Join(d.lines[in.StartLine-1:in.EndLine], "\n")
+}
+
+// explain annotates one instruction; shipped is set for the stages that end
+// up in the final image
+func (d *Dockerfile) explain(in *Instruction, s *Stage, shipped bool) *Annotation {
+	a := &Annotation{Line: in.StartLine, Source: d.Source(in), Layer: "metadata only, no layer"}
+	if s != nil {
+		a.Stage = stageName(s)
+	}
+	if layerInstructions[in.Cmd] {
+		if shipped {
+			a.Layer = "adds a layer to the final image"
+		} else {
+			a.Layer = fmt.Sprintf("adds a layer to build stage %s, which is left out of the final image", a.Stage)
+		}
+	}
+	value := in.Value
+
+	switch in.Cmd {
+	case "FROM":
+		a.Layer = "base image layers"
+		image := d.ExpandArgs(s.Image)
+		if before := d.BaseStage(s); before != nil {
+			a.Summary = fmt.Sprintf("Starts stage %s from the earlier stage %s", a.Stage, stageName(before))
+			a.Layer = "the layers of stage " + stageName(before)
+			break
+		}
+		a.Summary = fmt.Sprintf("Starts stage %s from %s", a.Stage, image)
+		switch {
+		case strings.EqualFold(image, "scratch"):
+			a.Layer = "empty base image"
+		case strings.Contains(image, "$"):
+			a.Notes = append(a.Notes, Note{Good: true, Message: "base image chosen by a build argument"})
+		case strings.Contains(image, "@sha256:"):
+			a.Notes = append(a.Notes, Note{Good: true, Message: "pinned to a digest: builds are reproducible"})
+		case strings.HasSuffix(image, ":latest") || !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":"):
+			a.Notes = append(a.Notes, Note{Message: "no pinned tag: the build changes whenever latest moves"})
+		default:
+			a.Notes = append(a.Notes, Note{Good: true, Message: "pinned to tag " + image[strings.LastIndex(image, ":")+1:]})
+		}
+		if shipped && smallImage(image) {
+			a.Notes = append(a.Notes, Note{Good: true, Message: "small base image"})
+		}
+	case "RUN":
+		script := value
+		for _, h := range in.Heredocs {
+			script += "\n" + h.Content
+		}
+		a.Summary = "Runs " + quote(value) + " while building"
+		if len(in.Heredocs) > 0 {
+			a.Summary = "Runs an inline script while building"
+		}
+		a.Notes = append(a.Notes, runNotes(in, script)...)
+	case "COPY", "ADD":
+		if len(in.Args) < 2 {
+			a.Summary = "Copies files into the image"
+			break
+		}
+		src, dest := strings.Join(in.Args[:len(in.Args)-1], " "), in.Args[len(in.Args)-1]
+		if from, ok := in.Flag("from"); ok {
+			a.Summary = fmt.Sprintf("Copies %s from %s into %s", src, from, dest)
+			if shipped && d.Stage(from) != nil {
+				a.Notes = append(a.Notes, Note{Good: true, Message: "copies only the build output of stage " + from})
+			}
+		} else {
+			a.Summary = fmt.Sprintf("Copies %s from the build context into %s", src, dest)
+		}
+		if wholeContext(in) {
+			a.Layer += "; copies the whole build context, so keep it small with .dockerignore"
+		}
+		if _, ok := in.Flag("chown"); ok {
+			a.Notes = append(a.Notes, Note{Good: true, Message: "sets the owner while copying, without a separate chown layer"})
+		}
+		if in.Cmd == "ADD" {
+			a.Summary = strings.Replace(a.Summary, "Copies", "Adds", 1)
+			if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+				if _, ok := in.Flag("checksum"); !ok {
+					a.Notes = append(a.Notes, Note{Message: "downloads without --checksum: the file isn't verified"})
+				}
+			} else if !strings.HasPrefix(src, "git@") && !isArchive(src) {
+				a.Notes = append(a.Notes, Note{Message: "ADD also unpacks archives and downloads URLs; COPY says what it does for local files"})
+			}
+		}
+	case "ENV", "ARG":
+		names := assignmentNames(in)
+		if in.Cmd == "ENV" {
+			a.Summary = fmt.Sprintf("Sets %s for the rest of the build and in the container", strings.Join(names, ", "))
+		} else {
+			a.Summary = fmt.Sprintf("Declares the build argument %s", strings.Join(names, ", "))
+		}
+		for _, name := range names {
+			if secretName.MatchString(name) {
+				a.Notes = append(a.Notes, Note{Message: fmt.Sprintf("%s looks like a secret: %s values are recorded in the image; use a build secret (RUN --mount=type=secret) or set it at run time", name, in.Cmd)})
+			}
+		}
+	case "WORKDIR":
+		a.Summary = fmt.Sprintf("Sets the working directory to %s, creating it when missing", value)
+		if !strings.HasPrefix(value, "/") && !strings.HasPrefix(value, "$") {
+			a.Notes = append(a.Notes, Note{Message: "relative WORKDIR: it depends on the one before; use an absolute path"})
+		}
+	case "USER":
+		a.Summary = fmt.Sprintf("Runs the following instructions, and the container, as %s", value)
+		if shipped && isRoot(value) {
+			a.Notes = append(a.Notes, Note{Message: "switches to root"})
+		} else if shipped {
+			a.Notes = append(a.Notes, Note{Good: true, Message: "non-root user"})
+		}
+	case "EXPOSE":
+		a.Summary = fmt.Sprintf("Documents that the app listens on %s; publishing still takes -p or ports:", value)
+	case "CMD", "ENTRYPOINT":
+		if in.Cmd == "CMD" {
+			a.Summary = "Sets the default command: " + quote(commandText(in))
+		} else {
+			a.Summary = "Sets the executable the container runs: " + quote(commandText(in))
+		}
+		if in.JSON {
+			a.Notes = append(a.Notes, Note{Good: true, Message: "exec form: the process gets signals such as SIGTERM directly"})
+		} else {
+			a.Notes = append(a.Notes, Note{Message: "shell form runs under /bin/sh -c, which doesn't pass SIGTERM on; use the exec (JSON) form"})
+		}
+	case "HEALTHCHECK":
+		if strings.EqualFold(value, "NONE") {
+			a.Summary = "Turns off the health check of the base image"
+		} else {
+			a.Summary = "Probes the container's health with " + quote(strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(value, "CMD-SHELL"), "CMD")))
+			a.Notes = append(a.Notes, Note{Good: true, Message: "lets Docker and orchestrators restart a hung container"})
+		}
+	case "LABEL":
+		a.Summary = "Adds metadata labels to the image"
+	case "VOLUME":
+		a.Summary = fmt.Sprintf("Declares a volume at %s; later build steps can't change its contents", value)
+	case "SHELL":
+		a.Summary = "Changes the shell of the shell-form instructions that follow to " + value
+	case "STOPSIGNAL":
+		a.Summary = fmt.Sprintf("Stops the container with %s instead of SIGTERM", value)
+	case "ONBUILD":
+		a.Summary = "Defers " + quote(value) + " to builds that use this image as their base"
+	case "MAINTAINER":
+		a.Summary = "Names the maintainer"
+		a.Notes = append(a.Notes, Note{Message: "MAINTAINER is deprecated; use LABEL maintainer=..."})
+	default:
+		a.Summary = "Not a Dockerfile instruction"
+	}
+	return a
+}
+
+// stageNotes adds the notes that depend on the rest of the stage to the
+// annotation of its i-th instruction
+func (d *Dockerfile) stageNotes(a *Annotation, s *Stage, i int, final bool) {
+	in := s.Instructions[i]
+	switch in.Cmd {
+	case "COPY", "ADD":
+		if !wholeContext(in) {
+			return
+		}
+		// A dependency install after the source copy reruns on every change
+		for _, later := range s.Instructions[i+1:] {
+			if later.Cmd == "RUN" && installDeps.MatchString(later.Value) {
+				a.Notes = append(a.Notes, Note{Message: fmt.Sprintf("copies the source before installing dependencies (line %d), so any source change reinstalls them; copy the manifests first", later.StartLine)})
+				return
+			}
+		}
+	case "CMD", "ENTRYPOINT":
+		for _, later := range s.Instructions[i+1:] {
+			if later.Cmd == in.Cmd {
+				a.Notes = append(a.Notes, Note{Message: fmt.Sprintf("overridden by the %s on line %d", in.Cmd, later.StartLine)})
+				return
+			}
+		}
+	}
+}
+
+// runNotes checks a RUN command against the usual practices
+func runNotes(in *Instruction, script string) []Note {
+	var notes []Note
+	bad := func(msg string) { notes = append(notes, Note{Message: msg}) }
+	good := func(msg string) { notes = append(notes, Note{Good: true, Message: msg}) }
+
+	cacheMount := false
+	for _, f := range in.Flags {
+		if f.Name != "mount" {
+			continue
+		}
+		switch {
+		case strings.Contains(f.Value, "type=cache"):
+			cacheMount = true
+			good("cache mount: package caches stay out of the layer and speed up rebuilds")
+		case strings.Contains(f.Value, "type=secret"):
+			good("secret mount: the secret is only there for this step")
+		}
+	}
+
+	if strings.Contains(script, "apt-get update") && !strings.Contains(script, "apt-get install") {
+		bad("apt-get update on its own is cached apart from the install; run both in one RUN")
+	}
+	if strings.Contains(script, "apt-get install") {
+		if strings.Contains(script, "--no-install-recommends") {
+			good("--no-install-recommends keeps optional packages out")
+		} else {
+			bad("apt-get install without --no-install-recommends pulls in optional packages")
+		}
+		if strings.Contains(script, "/var/lib/apt/lists") || cacheMount {
+			good("apt lists are cleaned up")
+		} else {
+			bad("apt lists (tens of MB) stay in the layer; remove /var/lib/apt/lists/* in the same RUN")
+		}
+	}
+	if strings.Contains(script, "apt-get upgrade") || strings.Contains(script, "apt-get dist-upgrade") {
+		bad("upgrading packages makes builds unpredictable; use a newer base image instead")
+	}
+	if strings.Contains(script, "apk add") {
+		if strings.Contains(script, "--no-cache") || cacheMount {
+			good("apk add --no-cache leaves no package index behind")
+		} else {
+			bad("apk add without --no-cache leaves the package index in the layer")
+		}
+	}
+	if regexp.MustCompile(`\bpip3? install\b`).MatchString(script) {
+		if strings.Contains(script, "--no-cache-dir") || cacheMount {
+			good("pip cache stays out of the layer")
+		} else {
+			bad("pip install without --no-cache-dir keeps the download cache in the layer")
+		}
+	}
+	if regexp.MustCompile(`\bnpm (install|i)\b`).MatchString(script) {
+		bad("npm install may change the lockfile; npm ci installs exactly what it locks")
+	} else if strings.Contains(script, "npm ci") {
+		good("npm ci installs exactly the lockfile")
+	}
+	if pipeToShell.MatchString(script) {
+		bad("pipes a download into a shell; download, verify a checksum, then run it")
+	}
+	if strings.HasPrefix(strings.TrimSpace(in.Value), "cd ") {
+		bad("cd only lasts for this RUN; set the directory with WORKDIR")
+	}
+	if regexp.MustCompile(`\bsudo\b`).MatchString(script) {
+		bad("sudo isn't needed while building; switch USER instead")
+	}
+	return notes
+}
+
+// stageName names a stage for humans: its AS name, else its position
+func stageName(s *Stage) string {
+	if s.Name != "" {
+		return fmt.Sprintf("%q", s.Name)
+	}
+	return fmt.Sprintf("#%d", s.Index+1)
+}
+
+// CopiesContext reports whether a COPY or ADD copies the whole build context
+func (d *Dockerfile) CopiesContext() bool {
+	for _, in := range d.Instructions {
+		if (in.Cmd == "COPY" || in.Cmd == "ADD") && wholeContext(in) {
+			return true
+		}
+	}
+	return false
+}
+
+// wholeContext reports whether a COPY or ADD copies the whole build context
+func wholeContext(in *Instruction) bool {
+	if _, ok := in.Flag("from"); ok || len(in.Args) < 2 {
+		return false
+	}
+	for _, src := range in.Args[:len(in.Args)-1] {
+		if src == "." || src == "./" {
+			return true
+		}
+	}
+	return false
+}
+
+// assignmentNames returns the variable names an ENV or ARG sets
+func assignmentNames(in *Instruction) []string {
+	// ENV NAME value (legacy form) sets a single variable
+	if in.Cmd == "ENV" && len(in.Args) > 1 && !strings.Contains(in.Args[0], "=") {
+		return in.Args[:1]
+	}
+	var names []string
+	for _, arg := range in.Args {
+		if name, _, _ := strings.Cut(arg, "="); name != "" && !strings.ContainsAny(name, `"' `) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// commandText is the command of a CMD or ENTRYPOINT as a shell would show it
+func commandText(in *Instruction) string {
+	if in.JSON {
+		return strings.Join(in.Args, " ")
+	}
+	return in.Value
+}
+
+// quote shortens a command for a summary
+func quote(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 60 {
+		s = s[:57] + "..."
+	}
+	return "`" + s + "`"
+}
+
+// isRoot reports whether a USER value is root
+func isRoot(user string) bool {
+	name, _, _ := strings.Cut(user, ":")
+	return name == "root" || name == "0"
+}
+
+// nonRootImage reports whether a base image runs as a non-root user by default
+func nonRootImage(image string) bool {
+	return strings.Contains(image, "nonroot") || strings.HasPrefix(image, "cgr.dev/chainguard/")
+}
+
+// smallImage reports whether an image is a minimal variant
+func smallImage(image string) bool {
+	for _, marker := range []string{"alpine", "slim", "distroless", "scratch", "chainguard", "busybox"} {
+		if strings.Contains(strings.ToLower(image), marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isArchive reports whether ADD would unpack a local file
+func isArchive(src string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.xz", ".txz"} {
+		if strings.HasSuffix(src, ext) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package dockerfile
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// shellSeparator splits a RUN script into commands
+var shellSeparator = regexp.MustCompile(`&&|\|\||[;|\n]`)
+
+// valueOptions are package manager options that take the next word as value
+var valueOptions = map[string]bool{"--repository": true, "-X": true, "-o": true, "-t": true, "--target-release": true}
+
+// Packages returns the system packages a stage installs with apk add,
+// apt-get install or apt install, in order and without duplicates.
+// Shell variables are left out, and so are packages installed in an apk
+// --virtual group, which builds remove again.
+func (d *Dockerfile) Packages(s *Stage) []string {
+	var packages []string
+	for _, in := range s.Instructions {
+		if in.Cmd != "RUN" {
+			continue
+		}
+		script := in.Value
+		if in.JSON {
+			script = strings.Join(in.Args, " ")
+		}
+		for _, h := range in.Heredocs {
+			script += "\n" + h.Content
+		}
+		for _, command := range shellSeparator.Split(script, -1) {
+			words := strings.Fields(command)
+			for len(words) > 0 && (words[0] == "sudo" || strings.Contains(words[0], "=")) {
+				words = words[1:] // sudo and VAR=value prefixes
+			}
+			if len(words) < 2 || !installs(words[0], words[1]) || slices.Contains(words, "--virtual") || slices.Contains(words, "-t") && words[0] == "apk" {
+				continue
+			}
+			for i := 2; i < len(words); i++ {
+				w := words[i]
+				switch {
+				case valueOptions[w]:
+					i++
+				case strings.HasPrefix(w, "-"), strings.ContainsAny(w, "$`\\(){}<>"):
+				default:
+					if !slices.Contains(packages, w) {
+						packages = append(packages, w)
+					}
+				}
+			}
+		}
+	}
+	return packages
+}
+
+// installs reports whether a command installs packages
+func installs(cmd, sub string) bool {
+	switch cmd {
+	case "apk":
+		return sub == "add"
+	case "apt-get", "apt":
+		return sub == "install"
+	}
+	return false
+}
+
+// InstallPackages installs system packages in the final stage with the
+// package manager of its base image, before it copies files (so the layer
+// stays cached when the sources change) and before its USER, CMD and
+// ENTRYPOINT.
+// Packages the stage (or a stage it is built on) installs already are
+// skipped. It returns the packages added, and fails for images without a
+// package manager such as distroless and scratch.
+func (d *Dockerfile) InstallPackages(packages []string) ([]string, error) {
+	stage := d.FinalStage()
+	if stage == nil {
+		return nil, fmt.Errorf("Dockerfile has no FROM instruction")
+	}
+
+	var installed []string
+	root := stage
+	for s := stage; s != nil; s = d.BaseStage(s) {
+		installed = append(installed, d.Packages(s)...)
+		root = s
+	}
+	var missing []string
+	for _, p := range packages {
+		if !slices.Contains(installed, p) && !slices.Contains(missing, p) {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	var run string
+	image := strings.ToLower(d.ExpandArgs(root.Image))
+	switch {
+	case image == "scratch" || strings.Contains(image, "distroless") || strings.HasPrefix(image, "cgr.dev/"):
+		return nil, fmt.Errorf("%s has no package manager to install %s", root.Image, strings.Join(missing, ", "))
+	case strings.Contains(image, "alpine") || d.usesPackageManager(root, packageManagers["slim"]):
+		run = "RUN apk add --no-cache " + strings.Join(missing, " ")
+	default:
+		run = "RUN apt-get update && apt-get install -y --no-install-recommends " + strings.Join(missing, " ") + " \\\n    && rm -rf /var/lib/apt/lists/*"
+	}
+
+	at := stage.From.EndLine
+	for _, in := range stage.Instructions {
+		if slices.Contains([]string{"COPY", "ADD", "USER", "CMD", "ENTRYPOINT", "HEALTHCHECK"}, in.Cmd) {
+			at = in.StartLine - 1
+			break
+		}
+		at = in.EndLine
+	}
+	for at > stage.From.EndLine && strings.HasPrefix(strings.TrimSpace(d.lines[at-1]), "#") {
+		at-- // Keep the instruction's comment with it
+	}
+	d.splice(at, append(append([]string{"# Additional system packages"}, strings.Split(run, "\n")...), "")...)
+	return missing, nil
+}
@@ -0,0 +1,397 @@
+// Package dockerfile parses Dockerfiles into instructions and build stages,
+// and rewrites them while keeping the rest of the file as written.
+package dockerfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Instructions are the instruction keywords Docker accepts
+var Instructions = map[string]bool{
+	"ADD": true, "ARG": true, "CMD": true, "COPY": true, "ENTRYPOINT": true,
+	"ENV": true, "EXPOSE": true, "FROM": true, "HEALTHCHECK": true, "LABEL": true,
+	"MAINTAINER": true, "ONBUILD": true, "RUN": true, "SHELL": true,
+	"STOPSIGNAL": true, "USER": true, "VOLUME": true, "WORKDIR": true,
+}
+
+// heredocInstructions may take heredoc (<<EOF) arguments
+var heredocInstructions = map[string]bool{"RUN": true, "COPY": true, "ADD": true}
+
+// heredocArg matches a heredoc argument: <<NAME, <<-NAME (strip leading
+// tabs), or either with NAME quoted
+var heredocArg = regexp.MustCompile(`^<<(-?)(?:"(\w+)"|'(\w+)'|(\w+))$`)
+
+// Dockerfile is a parsed Dockerfile
+type Dockerfile struct {
+	Directives   map[string]string // Parser directives such as syntax and escape
+	Escape       byte              // Line continuation character, \ unless set by a directive
+	Args         []*Instruction    // ARGs before the first FROM
+	Stages       []*Stage
+	Instructions []*Instruction // Every instruction, in order
+	Errors       []*SyntaxError // Problems Docker would refuse to build
+	lines        []string
+}
+
+// Stage is a build stage, started by a FROM
+type Stage struct {
+	Index        int
+	Name         string // AS name, lowercased; "" when unnamed
+	Image        string // Base image or earlier stage name
+	Platform     string
+	From         *Instruction
+	Instructions []*Instruction // Instructions after the FROM
+}
+
+// Instruction is one instruction, possibly spread over several lines
+type Instruction struct {
+	Cmd       string // Uppercased keyword
+	Flags     []Flag // --name=value options before the arguments
+	Args      []string
+	Value     string // Arguments as written after the flags, continuations joined
+	JSON      bool   // Exec (JSON array) form
+	Heredocs  []Heredoc
+	StartLine int // 1-based line of the keyword
+	EndLine   int // 1-based last line, including continuations and heredocs
+}
+
+// Flag is an instruction option such as --from=builder
+type Flag struct {
+	Name  string
+	Value string
+}
+
+// Heredoc is an inline file given to RUN, COPY or ADD with <<NAME
+type Heredoc struct {
+	Name    string
+	Content string
+}
+
+// SyntaxError is a problem on a line of a Dockerfile
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// Flag returns the value of the named flag (without the leading --)
+func (in *Instruction) Flag(name string) (string, bool) {
+	for _, f := range in.Flags {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Variable is a name and value an ENV or ARG sets
+type Variable struct {
+	Name  string
+	Value string
+}
+
+// Env returns the variables an ENV instruction sets, in order: NAME=value
+// pairs with their quotes and escapes removed, or the legacy NAME value form,
+// whose value is the rest of the line
+func (in *Instruction) Env() []Variable {
+	if in.Cmd != "ENV" || len(in.Args) == 0 {
+		return nil
+	}
+	if !strings.Contains(in.Args[0], "=") {
+		return []Variable{{Name: in.Args[0], Value: strings.TrimSpace(strings.TrimPrefix(in.Value, in.Args[0]))}}
+	}
+	var vars []Variable
+	for _, word := range shellWords(in.Value) {
+		if name, value, ok := strings.Cut(word, "="); ok && name != "" {
+			vars = append(vars, Variable{Name: name, Value: value})
+		}
+	}
+	return vars
+}
+
+// shellWords splits s into words as a shell would: whitespace separates
+// words except inside quotes, and a backslash escapes the next character
+// outside single quotes
+func shellWords(s string) []string {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\\' && i+1 < len(s) && (quote == 0 || strings.IndexByte("\"\\$`", s[i+1]) >= 0):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+// FinalStage returns the last build stage, or nil when there is none
+func (d *Dockerfile) FinalStage() *Stage {
+	if len(d.Stages) == 0 {
+		return nil
+	}
+	return d.Stages[len(d.Stages)-1]
+}
+
+// Stage returns the stage named name (case-insensitive), or nil
+func (d *Dockerfile) Stage(name string) *Stage {
+	name = strings.ToLower(name)
+	for _, s := range d.Stages {
+		if s.Name != "" && s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Err returns the first syntax error, or nil when the file parsed cleanly
+func (d *Dockerfile) Err() error {
+	if len(d.Errors) == 0 {
+		return nil
+	}
+	return d.Errors[0]
+}
+
+// String returns the Dockerfile's text
+func (d *Dockerfile) String() string {
+	return strings.Join(d.lines, "\n")
+}
+
+// Parse parses a Dockerfile. It never fails: problems Docker would reject,
+// such as unknown instructions or a missing FROM, are listed in Errors.
+func Parse(content string) *Dockerfile {
+	d := &Dockerfile{
+		Directives: make(map[string]string),
+		Escape:     '\\',
+		lines:      strings.Split(content, "\n"),
+	}
+	p := &parser{d: d}
+	p.parse()
+	return d
+}
+
+type parser struct {
+	d   *Dockerfile
+	pos int // Index of the next line
+}
+
+func (p *parser) errorf(line int, format string, args ...interface{}) {
+	p.d.Errors = append(p.d.Errors, &SyntaxError{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) parse() {
+	p.parseDirectives()
+
+	for p.pos < len(p.d.lines) {
+		line := strings.TrimSpace(strings.TrimSuffix(p.d.lines[p.pos], "\r"))
+		p.pos++
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.add(p.parseInstruction(line, p.pos))
+	}
+
+	if len(p.d.Stages) == 0 {
+		p.errorf(1, "Dockerfile must have a FROM instruction")
+	}
+}
+
+// parseDirectives reads the "# key=value" comments at the top of the file
+func (p *parser) parseDirectives() {
+	for p.pos < len(p.d.lines) {
+		line := strings.TrimSpace(p.d.lines[p.pos])
+		if !strings.HasPrefix(line, "#") {
+			return
+		}
+		key, value, ok := strings.Cut(strings.TrimSpace(line[1:]), "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return
+		}
+		if _, seen := p.d.Directives[key]; seen {
+			return
+		}
+		value = strings.TrimSpace(value)
+		p.d.Directives[key] = value
+		if key == "escape" && (value == "`" || value == `\`) {
+			p.d.Escape = value[0]
+		}
+		p.pos++
+	}
+}
+
+// parseInstruction reads the instruction starting with line (at 1-based
+// start), consuming its continuation lines and heredocs
+func (p *parser) parseInstruction(line string, start int) *Instruction {
+	escape := string(p.d.Escape)
+	for strings.HasSuffix(line, escape) {
+		line = strings.TrimSuffix(line, escape)
+		next, ok := p.nextContinuation()
+		if !ok {
+			p.errorf(start, "unterminated line continuation")
+			break
+		}
+		line += " " + next
+	}
+
+	keyword, rest := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		keyword, rest = line[:i], strings.TrimSpace(line[i+1:])
+	}
+	in := &Instruction{Cmd: strings.ToUpper(keyword), StartLine: start}
+
+	for strings.HasPrefix(rest, "--") && in.Cmd != "ONBUILD" {
+		flag, remaining, _ := strings.Cut(rest, " ")
+		name, value, _ := strings.Cut(strings.TrimPrefix(flag, "--"), "=")
+		in.Flags = append(in.Flags, Flag{Name: name, Value: value})
+		rest = strings.TrimSpace(remaining)
+	}
+	in.Value = rest
+
+	if strings.HasPrefix(rest, "[") {
+		var args []string
+		if err := json.Unmarshal([]byte(rest), &args); err == nil {
+			in.Args, in.JSON = args, true
+		}
+	}
+	if !in.JSON {
+		in.Args = strings.Fields(rest)
+	}
+
+	if heredocInstructions[in.Cmd] {
+		p.readHeredocs(in)
+	}
+	in.EndLine = p.pos
+	return in
+}
+
+// nextContinuation returns the next line of a continued instruction,
+// skipping the comments and blank lines Docker drops inside one
+func (p *parser) nextContinuation() (string, bool) {
+	for p.pos < len(p.d.lines) {
+		line := strings.TrimSpace(strings.TrimSuffix(p.d.lines[p.pos], "\r"))
+		p.pos++
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line, true
+	}
+	return "", false
+}
+
+// readHeredocs reads the bodies of the <<NAME arguments of in, which follow
+// the instruction line in order
+func (p *parser) readHeredocs(in *Instruction) {
+	for _, arg := range in.Args {
+		m := heredocArg.FindStringSubmatch(arg)
+		if m == nil {
+			continue
+		}
+		stripTabs, name := m[1] == "-", m[2]+m[3]+m[4]
+
+		var body []string
+		terminated := false
+		for p.pos < len(p.d.lines) {
+			line := strings.TrimSuffix(p.d.lines[p.pos], "\r")
+			p.pos++
+			if stripTabs {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if line == name {
+				terminated = true
+				break
+			}
+			body = append(body, line)
+		}
+		if !terminated {
+			p.errorf(in.StartLine, "unterminated heredoc %s", name)
+		}
+		in.Heredocs = append(in.Heredocs, Heredoc{Name: name, Content: strings.Join(body, "\n") + "\n"})
+	}
+}
+
+// add records in and checks it against the stages so far
+func (p *parser) add(in *Instruction) {
+	d := p.d
+	d.Instructions = append(d.Instructions, in)
+
+	if !Instructions[in.Cmd] {
+		p.errorf(in.StartLine, "unknown instruction: %s", in.Cmd)
+		return
+	}
+
+	switch {
+	case in.Cmd == "FROM":
+		stage := &Stage{Index: len(d.Stages), From: in}
+		stage.Platform, _ = in.Flag("platform")
+		switch {
+		case len(in.Args) == 0:
+			p.errorf(in.StartLine, "FROM requires a base image")
+		case len(in.Args) == 3 && strings.EqualFold(in.Args[1], "AS"):
+			stage.Name = strings.ToLower(in.Args[2])
+		case len(in.Args) != 1:
+			p.errorf(in.StartLine, "FROM takes an image and an optional AS name")
+		}
+		if len(in.Args) > 0 {
+			stage.Image = in.Args[0]
+		}
+		d.Stages = append(d.Stages, stage)
+		return
+	case len(d.Stages) == 0:
+		if in.Cmd == "ARG" {
+			d.Args = append(d.Args, in)
+			return
+		}
+		p.errorf(in.StartLine, "%s instruction before FROM", in.Cmd)
+		return
+	}
+
+	stage := d.FinalStage()
+	stage.Instructions = append(stage.Instructions, in)
+
+	if in.Value == "" && len(in.Flags) == 0 {
+		p.errorf(in.StartLine, "%s requires arguments", in.Cmd)
+		return
+	}
+	if in.Cmd == "COPY" {
+		if from, ok := in.Flag("from"); ok && from == "" {
+			p.errorf(in.StartLine, "COPY --from requires a stage or image")
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package dockerfile
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const multiStage = `# syntax=docker/dockerfile:1
+# escape=\
+# Build stage
+ARG NODE_VERSION=20
+FROM --platform=$BUILDPLATFORM node:${NODE_VERSION}-alpine AS Builder
+WORKDIR /app
+RUN apk add --no-cache \
+    # Needed by node-gyp
+    python3 \
+    make
+RUN <<EOF
+npm ci
+npm run build
+EOF
+
+FROM node:20-alpine
+COPY --from=builder --chown=node:node /app/dist ./dist
+USER node
+CMD ["node", "dist/index.js"]
+`
+
+func TestParse(t *testing.T) {
+	d := Parse(multiStage)
+	if err := d.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	if d.Directives["syntax"] != "docker/dockerfile:1" || d.Escape != '\\' {
+		t.Errorf("Directives = %v, Escape = %q", d.Directives, d.Escape)
+	}
+	if len(d.Args) != 1 || d.Args[0].Args[0] != "NODE_VERSION=20" {
+		t.Errorf("Args = %+v", d.Args)
+	}
+	if len(d.Stages) != 2 {
+		t.Fatalf("got %d stages, want 2", len(d.Stages))
+	}
+
+	builder := d.Stage("builder")
+	if builder == nil || builder.Index != 0 || builder.Platform != "$BUILDPLATFORM" || builder.Image != "node:${NODE_VERSION}-alpine" {
+		t.Fatalf("builder stage = %+v", builder)
+	}
+	run := builder.Instructions[1]
+	if run.Value != "apk add --no-cache  python3  make" || run.StartLine != 7 || run.EndLine != 10 {
+		t.Errorf("continued RUN = %q (lines %d-%d)", run.Value, run.StartLine, run.EndLine)
+	}
+	heredoc := builder.Instructions[2]
+	if len(heredoc.Heredocs) != 1 || heredoc.Heredocs[0].Content != "npm ci\nnpm run build\n" || heredoc.EndLine != 14 {
+		t.Errorf("heredoc RUN = %+v", heredoc)
+	}
+
+	final := d.FinalStage()
+	copyIn := final.Instructions[0]
+	if from, _ := copyIn.Flag("from"); from != "builder" {
+		t.Errorf("COPY --from = %q", from)
+	}
+	if chown, _ := copyIn.Flag("chown"); chown != "node:node" {
+		t.Errorf("COPY --chown = %q", chown)
+	}
+	cmd := final.Instructions[2]
+	if !cmd.JSON || strings.Join(cmd.Args, " ") != "node dist/index.js" {
+		t.Errorf("CMD = %+v", cmd)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	tests := []struct {
+		line string
+		want []Variable
+	}{
+		{`ENV NODE_ENV=production PORT=3000`, []Variable{{"NODE_ENV", "production"}, {"PORT", "3000"}}},
+		{`ENV GREETING="hello world" NAME='a "b"'`, []Variable{{"GREETING", "hello world"}, {"NAME", `a "b"`}}},
+		{`ENV PATH="/app/bin:$PATH" MSG=a\ b ESC="say \"hi\""`, []Variable{{"PATH", "/app/bin:$PATH"}, {"MSG", "a b"}, {"ESC", `say "hi"`}}},
+		{`ENV LEGACY value with spaces`, []Variable{{"LEGACY", "value with spaces"}}},
+		{"ENV A=1 \\\n    B=2", []Variable{{"A", "1"}, {"B", "2"}}},
+	}
+	for _, tt := range tests {
+		d := Parse("FROM alpine\n" + tt.line)
+		got := d.FinalStage().Instructions[0].Env()
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Env() = %q, want %q", tt.line, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: Env()[%d] = %q, want %q", tt.line, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"no FROM", "RUN echo hi\n", "line 1: RUN instruction before FROM"},
+		{"empty", "", "line 1: Dockerfile must have a FROM instruction"},
+		{"unknown instruction", "FROM alpine\nFORM alpine\n", "line 2: unknown instruction: FORM"},
+		{"missing arguments", "FROM alpine\nWORKDIR\n", "line 2: WORKDIR requires arguments"},
+		{"FROM without image", "FROM\n", "line 1: FROM requires a base image"},
+		{"unterminated heredoc", "FROM alpine\nRUN <<EOF\necho hi\n", "line 2: unterminated heredoc EOF"},
+		{"unterminated continuation", "FROM alpine\nRUN echo \\", "line 2: unterminated line continuation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Parse(tt.content).Err()
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Err() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// MAINTAINER is deprecated but still accepted
+	if err := Parse("FROM alpine\nMAINTAINER someone\n").Err(); err != nil {
+		t.Errorf("MAINTAINER: Err() = %v", err)
+	}
+	// A quoted heredoc marker inside a shell string isn't a heredoc
+	if err := Parse("FROM alpine\nRUN sh -c \"cat <<EOF\"\n").Err(); err != nil {
+		t.Errorf("quoted heredoc: Err() = %v", err)
+	}
+}
+
+func TestAddHealthcheck(t *testing.T) {
+	d := Parse(multiStage)
+	added, err := d.AddHealthcheck(Healthcheck{
+		Command:  "wget -qO- http://localhost:3000/ || exit 1",
+		Interval: 30 * time.Second,
+		Retries:  3,
+	})
+	if err != nil || !added {
+		t.Fatalf("AddHealthcheck() = %v, %v", added, err)
+	}
+	want := "USER node\nHEALTHCHECK --interval=30s --retries=3 \\\n  CMD wget -qO- http://localhost:3000/ || exit 1\n\nCMD [\"node\", \"dist/index.js\"]\n"
+	if !strings.HasSuffix(d.String(), want) {
+		t.Errorf("String() ends with:\n%s", d.String()[strings.LastIndex(d.String(), "USER"):])
+	}
+	if hc := d.FinalStage().Instructions[2]; hc.Cmd != "HEALTHCHECK" {
+		t.Errorf("instructions not re-parsed: %+v", hc)
+	}
+
+	again, _ := d.AddHealthcheck(Healthcheck{Command: "true"})
+	if again {
+		t.Error("AddHealthcheck() added a second HEALTHCHECK")
+	}
+	if hc := d.Healthcheck(); hc == nil || hc.Command != "wget -qO- http://localhost:3000/ || exit 1" || hc.Interval != 30*time.Second || hc.Retries != 3 {
+		t.Errorf("Healthcheck() = %+v", hc)
+	}
+	if hc := Parse("FROM alpine\nHEALTHCHECK CMD [\"CMD-SHELL\", \"curl -f localhost\"]\n").Healthcheck(); hc == nil || hc.Command != "curl -f localhost" {
+		t.Errorf("Healthcheck() of the exec form = %+v", hc)
+	}
+}
+
+func TestInjectArg(t *testing.T) {
+	d := Parse("FROM golang:1.22 AS build\nRUN go build\n\nFROM alpine\nCOPY --from=build /app /app\n")
+	if err := d.InjectArg("VERSION", "dev build", "build"); err != nil {
+		t.Fatal(err)
+	}
+	want := "ARG VERSION=\"dev build\"\nFROM golang:1.22 AS build\nARG VERSION\nRUN go build\n"
+	if !strings.HasPrefix(d.String(), want) {
+		t.Errorf("String() =\n%s", d.String())
+	}
+
+	// Injecting again changes nothing
+	before := d.String()
+	if err := d.InjectArg("VERSION", "other", "build"); err != nil || d.String() != before {
+		t.Errorf("second InjectArg() = %v, changed file:\n%s", err, d.String())
+	}
+	if err := d.InjectArg("VERSION", "", "missing"); err == nil {
+		t.Error("InjectArg() with unknown stage: expected error")
+	}
+}
+
+func TestPinBaseImages(t *testing.T) {
+	d := Parse("ARG BASE=alpine\nFROM node:20-alpine AS deps\nFROM deps AS build\nFROM $BASE\nFROM node:20-alpine\nFROM scratch\n")
+	var resolved []string
+	n, err := d.PinBaseImages(func(image string) (string, error) {
+		resolved = append(resolved, image)
+		return "sha256:abc", nil
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("PinBaseImages() = %d, %v", n, err)
+	}
+	if len(resolved) != 1 {
+		t.Errorf("resolved %v, want each image once", resolved)
+	}
+	want := "ARG BASE=alpine\nFROM node:20-alpine@sha256:abc AS deps\nFROM deps AS build\nFROM $BASE\nFROM node:20-alpine@sha256:abc\nFROM scratch\n"
+	if d.String() != want {
+		t.Errorf("String() =\n%s", d.String())
+	}
+
+	// Pinned images are updated when their tag moves, and kept otherwise
+	d = Parse("FROM node:20-alpine@sha256:old AS build\nFROM alpine:3.19@sha256:new\nFROM busybox@sha256:only\n")
+	n, err = d.PinBaseImages(func(image string) (string, error) {
+		if image == "busybox" {
+			t.Error("resolved a digest-only reference")
+		}
+		return "sha256:new", nil
+	})
+	if err != nil || n != 1 {
+		t.Fatalf("PinBaseImages() on pinned file = %d, %v", n, err)
+	}
+	want = "FROM node:20-alpine@sha256:new AS build\nFROM alpine:3.19@sha256:new\nFROM busybox@sha256:only\n"
+	if d.String() != want {
+		t.Errorf("String() =\n%s", d.String())
+	}
+
+	// Images copied from are pinned; stages copied from are not
+	d = Parse("FROM python:3.12-slim AS deps\nCOPY --from=ghcr.io/astral-sh/uv:0.9.5 /uv /bin/\nFROM deps\nCOPY --from=deps /app /app\nCOPY --from=0 /a /b\n")
+	n, err = d.PinBaseImages(func(image string) (string, error) {
+		return "sha256:abc", nil
+	})
+	if err != nil || n != 2 {
+		t.Fatalf("PinBaseImages() with COPY --from = %d, %v", n, err)
+	}
+	want = "FROM python:3.12-slim@sha256:abc AS deps\nCOPY --from=ghcr.io/astral-sh/uv:0.9.5@sha256:abc /uv /bin/\nFROM deps\nCOPY --from=deps /app /app\nCOPY --from=0 /a /b\n"
+	if d.String() != want {
+		t.Errorf("String() =\n%s", d.String())
+	}
+}
+
+func TestSetBaseVariant(t *testing.T) {
+	d := Parse("FROM node:20-alpine@sha256:abc AS deps\nRUN npm ci\nFROM deps AS build\nFROM python:3.12-slim-bookworm AS tools\nRUN apt-get update\nFROM alpine:3.19\nFROM golang:1.22-alpine\n")
+	changed, err := d.SetBaseVariant("alpine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 {
+		t.Errorf("SetBaseVariant(alpine) changed %v; the python stage runs apt-get", changed)
+	}
+
+	changed, err = d.SetBaseVariant("slim")
+	if err != nil || len(changed) != 3 {
+		t.Fatalf("SetBaseVariant(slim) = %v, %v", changed, err)
+	}
+	want := "FROM node:20-slim AS deps\nRUN npm ci\nFROM deps AS build\nFROM python:3.12-slim AS tools\nRUN apt-get update\nFROM debian:bookworm-slim\nFROM golang:1.22-alpine\n"
+	if d.String() != want {
+		t.Errorf("String() =\n%s", d.String())
+	}
+
+	// A stage built on one that installs with apk keeps the alpine image
+	d = Parse("FROM node:20-alpine AS base\nFROM base\nRUN apk add --no-cache curl\n")
+	if changed, _ := d.SetBaseVariant("slim"); len(changed) != 0 {
+		t.Errorf("SetBaseVariant(slim) changed %v under an apk stage", changed)
+	}
+	if _, err := d.SetBaseVariant("ubuntu"); err == nil {
+		t.Error("SetBaseVariant(ubuntu) succeeded")
+	}
+}
+
+func TestParameterizeVersions(t *testing.T) {
+	d := Parse("# syntax=docker/dockerfile:1\n# Build stage\nFROM eclipse-temurin:21-jdk-alpine AS build\nFROM build AS test\nFROM node:20-alpine@sha256:abc AS assets\nFROM eclipse-temurin:21-jre-alpine\nFROM eclipse-temurin:17-jre-alpine\n")
+	args := d.ParameterizeVersions()
+	if len(args) != 1 || args[0] != (VersionArg{Name: "JAVA_VERSION", Value: "21"}) {
+		t.Fatalf("ParameterizeVersions() = %v", args)
+	}
+	want := "# syntax=docker/dockerfile:1\n# Base image versions; override with --build-arg\nARG JAVA_VERSION=21\n\n# Build stage\n" +
+		"FROM eclipse-temurin:${JAVA_VERSION}-jdk-alpine AS build\nFROM build AS test\nFROM node:20-alpine@sha256:abc AS assets\n" +
+		"FROM eclipse-temurin:${JAVA_VERSION}-jre-alpine\nFROM eclipse-temurin:17-jre-alpine\n"
+	if d.String() != want {
+		t.Errorf("String() =\n%s", d.String())
+	}
+	if got := d.ExpandArgs(d.Stages[0].Image); got != "eclipse-temurin:21-jdk-alpine" {
+		t.Errorf("ExpandArgs() = %q", got)
+	}
+
+	// Updating the image bumps the ARG rather than the FROM line
+	if err := d.SetBaseImage(0, "eclipse-temurin:23-jdk-alpine"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(d.String(), "ARG JAVA_VERSION=23\n") || d.Stages[0].Image != "eclipse-temurin:${JAVA_VERSION}-jdk-alpine" {
+		t.Errorf("SetBaseImage() =\n%s", d.String())
+	}
+	if err := d.SetBaseImage(0, "amazoncorretto:23"); err != nil || d.Stages[0].Image != "amazoncorretto:23" {
+		t.Errorf("SetBaseImage() to another image = %v, %q", err, d.Stages[0].Image)
+	}
+}
+
+func TestInstallPackages(t *testing.T) {
+	d := Parse("FROM debian:bookworm-slim AS base\nRUN apt-get update && apt-get install -y --no-install-recommends \\\n    ca-certificates curl \\\n    && rm -rf /var/lib/apt/lists/*\n\nFROM base\nRUN DEBIAN_FRONTEND=noninteractive apt-get install -y gcc\nUSER app\nCMD [\"app\"]\n")
+	if got := d.Packages(d.Stages[0]); strings.Join(got, " ") != "ca-certificates curl" {
+		t.Errorf("Packages() = %v", got)
+	}
+	if got := d.Packages(d.Stages[1]); strings.Join(got, " ") != "gcc" {
+		t.Errorf("Packages() with an env prefix = %v", got)
+	}
+
+	added, err := d.InstallPackages([]string{"curl", "tini"})
+	if err != nil || strings.Join(added, " ") != "tini" {
+		t.Fatalf("InstallPackages() = %v, %v", added, err)
+	}
+	want := "RUN DEBIAN_FRONTEND=noninteractive apt-get install -y gcc\n# Additional system packages\nRUN apt-get update && apt-get install -y --no-install-recommends tini \\\n    && rm -rf /var/lib/apt/lists/*\n\nUSER app\n"
+	if !strings.Contains(d.String(), want) {
+		t.Errorf("String() =\n%s", d.String())
+	}
+
+	d = Parse("FROM node:20-alpine\nRUN apk add --no-cache --virtual .build gcc\nCMD [\"node\"]\n")
+	if _, err := d.InstallPackages([]string{"tini", "gcc"}); err != nil || !strings.Contains(d.String(), "RUN apk add --no-cache tini gcc\n") {
+		t.Errorf("InstallPackages() on alpine = %v:\n%s", err, d.String())
+	}
+	if _, err := Parse("FROM gcr.io/distroless/static\n").InstallPackages([]string{"tini"}); err == nil {
+		t.Error("InstallPackages() on distroless succeeded")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	d := Parse(`FROM python:3.12 AS build
+WORKDIR /app
+COPY . .
+RUN pip install -r requirements.txt
+ENV API_KEY=abc
+
+FROM build
+RUN apt-get update && apt-get install -y curl
+CMD python app.py
+`)
+	e := d.Explain()
+	if e.Stages != 2 || e.Layers != 3 || len(e.Annotations) != 8 {
+		t.Fatalf("stages %d, layers %d, %d annotations", e.Stages, e.Layers, len(e.Annotations))
+	}
+
+	notes := map[int]string{}
+	for _, a := range e.Annotations {
+		for _, n := range a.Notes {
+			if !n.Good {
+				notes[a.Line] += n.Message + "\n"
+			}
+		}
+	}
+	for line, want := range map[int]string{
+		3: "before installing dependencies (line 4)",
+		4: "--no-cache-dir",
+		5: "API_KEY looks like a secret",
+		8: "/var/lib/apt/lists",
+		9: "shell form",
+	} {
+		if !strings.Contains(notes[line], want) {
+			t.Errorf("line %d notes %q, want %q", line, notes[line], want)
+		}
+	}
+	if e.Annotations[5].Summary != `Starts stage #2 from the earlier stage "build"` {
+		t.Errorf("FROM summary = %q", e.Annotations[5].Summary)
+	}
+	if e.Issues() != 8 {
+		t.Errorf("Issues() = %d: %v %+v", e.Issues(), notes, e.Findings)
+	}
+}
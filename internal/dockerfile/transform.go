@@ -0,0 +1,573 @@
+package dockerfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Healthcheck is a HEALTHCHECK to add to a Dockerfile
+type Healthcheck struct {
+	Command     string // Shell-form command, e.g. wget -qO- http://localhost:3000/ || exit 1
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+// lines renders the HEALTHCHECK instruction, options first
+func (h Healthcheck) lines() []string {
+	var opts []string
+	for _, o := range []struct {
+		name string
+		d    time.Duration
+	}{{"interval", h.Interval}, {"timeout", h.Timeout}, {"start-period", h.StartPeriod}} {
+		if o.d > 0 {
+			opts = append(opts, "--"+o.name+"="+o.d.String())
+		}
+	}
+	if h.Retries > 0 {
+		opts = append(opts, "--retries="+strconv.Itoa(h.Retries))
+	}
+	if len(opts) == 0 {
+		return []string{"HEALTHCHECK CMD " + h.Command}
+	}
+	return []string{"HEALTHCHECK " + strings.Join(opts, " ") + ` \`, "  CMD " + h.Command}
+}
+
+// Healthcheck returns the HEALTHCHECK of the final stage, or nil when it has
+// none or turns it off with HEALTHCHECK NONE. Exec-form commands are joined
+// into a shell command.
+func (d *Dockerfile) Healthcheck() *Healthcheck {
+	stage := d.FinalStage()
+	if stage == nil {
+		return nil
+	}
+	var in *Instruction
+	for _, i := range stage.Instructions {
+		if i.Cmd == "HEALTHCHECK" {
+			in = i
+		}
+	}
+	if in == nil || strings.EqualFold(strings.TrimSpace(in.Value), "NONE") {
+		return nil
+	}
+
+	hc := &Healthcheck{Command: strings.TrimSpace(in.Value)}
+	if rest, ok := strings.CutPrefix(hc.Command, "CMD"); ok {
+		hc.Command = strings.TrimSpace(rest)
+	}
+	var args []string
+	if json.Unmarshal([]byte(hc.Command), &args) == nil && len(args) > 0 {
+		if args[0] == "CMD-SHELL" {
+			args = args[1:]
+		}
+		hc.Command = strings.Join(args, " ")
+	}
+	for _, f := range []struct {
+		name string
+		d    *time.Duration
+	}{{"interval", &hc.Interval}, {"timeout", &hc.Timeout}, {"start-period", &hc.StartPeriod}} {
+		if v, ok := in.Flag(f.name); ok {
+			*f.d, _ = time.ParseDuration(v)
+		}
+	}
+	if v, ok := in.Flag("retries"); ok {
+		hc.Retries, _ = strconv.Atoi(v)
+	}
+	return hc
+}
+
+// AddHealthcheck adds hc to the final stage, before its CMD or ENTRYPOINT.
+// It returns false, leaving the file as it was, when the stage already has a
+// HEALTHCHECK (including HEALTHCHECK NONE).
+func (d *Dockerfile) AddHealthcheck(hc Healthcheck) (bool, error) {
+	if strings.TrimSpace(hc.Command) == "" {
+		return false, fmt.Errorf("healthcheck command is empty")
+	}
+	stage := d.FinalStage()
+	if stage == nil {
+		return false, fmt.Errorf("Dockerfile has no FROM instruction")
+	}
+
+	at := stage.From.EndLine
+	for _, in := range stage.Instructions {
+		if in.Cmd == "HEALTHCHECK" {
+			return false, nil
+		}
+		at = in.EndLine
+	}
+	for _, in := range stage.Instructions {
+		if in.Cmd == "CMD" || in.Cmd == "ENTRYPOINT" {
+			d.splice(in.StartLine-1, append(hc.lines(), "")...)
+			return true, nil
+		}
+	}
+	d.splice(at, append([]string{""}, hc.lines()...)...)
+	return true, nil
+}
+
+// InjectArg declares ARG name=value before the first FROM, where base image
+// references can use it, and redeclares it after the FROM of each named
+// stage so the stage's instructions see it as well. Declarations already
+// present are kept.
+func (d *Dockerfile) InjectArg(name, value string, stages ...string) error {
+	if !argName.MatchString(name) {
+		return fmt.Errorf("invalid ARG name %q", name)
+	}
+	for _, s := range stages {
+		if d.Stage(s) == nil {
+			return fmt.Errorf("no build stage named %q", s)
+		}
+	}
+
+	if !declaresArg(d.Args, name) {
+		decl := "ARG " + name
+		if value != "" {
+			decl += "=" + quoteArgValue(value)
+		}
+		at := len(d.lines)
+		if len(d.Stages) > 0 {
+			at = d.Stages[0].From.StartLine - 1
+		}
+		d.splice(at, decl)
+	}
+
+	for _, s := range stages {
+		stage := d.Stage(s)
+		if !declaresArg(stage.Instructions, name) {
+			d.splice(stage.From.EndLine, "ARG "+name)
+		}
+	}
+	return nil
+}
+
+// VersionArg is a build argument holding the version of a base image
+type VersionArg struct {
+	Name  string
+	Value string
+}
+
+// versionArgs names the build argument for the version of each image whose
+// tags start with one
+var versionArgs = map[string]string{
+	"node":                             "NODE_VERSION",
+	"python":                           "PYTHON_VERSION",
+	"ruby":                             "RUBY_VERSION",
+	"rust":                             "RUST_VERSION",
+	"golang":                           "GO_VERSION",
+	"php":                              "PHP_VERSION",
+	"elixir":                           "ELIXIR_VERSION",
+	"eclipse-temurin":                  "JAVA_VERSION",
+	"oven/bun":                         "BUN_VERSION",
+	"denoland/deno":                    "DENO_VERSION",
+	"mcr.microsoft.com/dotnet/sdk":     "DOTNET_VERSION",
+	"mcr.microsoft.com/dotnet/aspnet":  "DOTNET_VERSION",
+	"mcr.microsoft.com/dotnet/runtime": "DOTNET_VERSION",
+}
+
+// tagVersion matches the version a tag starts with: 20, 3.12, v1.45
+var tagVersion = regexp.MustCompile(`^v?\d+(\.\d+)*`)
+
+// ParameterizeVersions moves the versions at the start of the base image
+// tags of well-known images into ARGs declared before the first FROM,
+// turning node:20-alpine into node:${NODE_VERSION}-alpine with
+// ARG NODE_VERSION=20, so builds can pick another version with --build-arg.
+// Stages on the same version share the ARG; a stage on another version
+// keeps its tag. Earlier stages, images pinned by digest or chosen through
+// an ARG, and ARGs the file declares already are left alone. It returns the
+// ARGs added, in order.
+func (d *Dockerfile) ParameterizeVersions() []VersionArg {
+	var args []VersionArg
+	versions := make(map[string]string) // ARG name -> version
+	for i, stage := range d.Stages {
+		image := stage.Image
+		c := strings.LastIndex(image, ":")
+		if c <= strings.LastIndex(image, "/") || strings.ContainsAny(image, "$@") || d.stageBefore(image, i) {
+			continue
+		}
+		repo, tag := image[:c], image[c+1:]
+		name := versionArgs[strings.TrimPrefix(repo, "docker.io/")]
+		version := tagVersion.FindString(tag)
+		if name == "" || version == "" || declaresArg(d.Args, name) {
+			continue
+		}
+		if v, ok := versions[name]; ok && v != version {
+			continue
+		}
+		if !d.replaceImage(stage, repo+":${"+name+"}"+tag[len(version):]) {
+			continue
+		}
+		if _, ok := versions[name]; !ok {
+			versions[name] = version
+			args = append(args, VersionArg{Name: name, Value: version})
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	// Declare them above the comments that introduce the first stage
+	at := d.Stages[0].From.StartLine - 1
+	for at > len(d.Directives) && strings.HasPrefix(strings.TrimSpace(d.lines[at-1]), "#") {
+		at--
+	}
+	decls := []string{"# Base image versions; override with --build-arg"}
+	for _, a := range args {
+		decls = append(decls, "ARG "+a.Name+"="+a.Value)
+	}
+	d.splice(at, append(decls, "")...)
+	return args
+}
+
+// ExpandArgs substitutes the defaults of the ARGs declared before the first
+// FROM in s, such as a base image name. References without a default stay.
+func (d *Dockerfile) ExpandArgs(s string) string {
+	defaults := make(map[string]string)
+	for _, in := range d.Args {
+		for _, arg := range in.Args {
+			if name, value, ok := strings.Cut(arg, "="); ok {
+				defaults[name] = strings.Trim(value, `"'`)
+			}
+		}
+	}
+	return os.Expand(s, func(name string) string {
+		name, def, _ := strings.Cut(name, ":-")
+		if value := defaults[name]; value != "" {
+			return value
+		}
+		if def != "" {
+			return def
+		}
+		return "${" + name + "}"
+	})
+}
+
+// argReference matches $NAME and ${NAME} in a base image name
+var argReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// argValues returns the values the global ARGs referenced by pattern, such
+// as node:${NODE_VERSION}-alpine, take for it to expand to image, or nil
+// when it can't
+func (d *Dockerfile) argValues(pattern, image string) map[string]string {
+	refs := argReference.FindAllStringSubmatchIndex(pattern, -1)
+	if len(refs) == 0 {
+		return nil
+	}
+	var expr strings.Builder
+	var names []string
+	last := 0
+	for _, ref := range refs {
+		var name string
+		if ref[2] >= 0 {
+			name = pattern[ref[2]:ref[3]]
+		} else {
+			name = pattern[ref[4]:ref[5]]
+		}
+		if !declaresArg(d.Args, name) {
+			return nil
+		}
+		expr.WriteString(regexp.QuoteMeta(pattern[last:ref[0]]) + "(.+?)")
+		names = append(names, name)
+		last = ref[1]
+	}
+	expr.WriteString(regexp.QuoteMeta(pattern[last:]))
+
+	m := regexp.MustCompile("^" + expr.String() + "$").FindStringSubmatch(image)
+	if m == nil {
+		return nil
+	}
+	values := make(map[string]string)
+	for i, name := range names {
+		if v, ok := values[name]; ok && v != m[i+1] {
+			return nil
+		}
+		values[name] = m[i+1]
+	}
+	return values
+}
+
+// setArgDefault changes the default of a global ARG in place
+func (d *Dockerfile) setArgDefault(name, value string) bool {
+	re := regexp.MustCompile(`(^|\s)` + regexp.QuoteMeta(name) + `(=("[^"]*"|'[^']*'|\S*))?(\s|$)`)
+	for _, in := range d.Args {
+		for n := in.StartLine - 1; n < in.EndLine; n++ {
+			if loc := re.FindStringSubmatchIndex(d.lines[n]); loc != nil {
+				end := loc[3] + len(name)
+				if loc[4] >= 0 {
+					end = loc[5]
+				}
+				d.lines[n] = d.lines[n][:loc[3]] + name + "=" + quoteArgValue(value) + d.lines[n][end:]
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PinBaseImages appends the digest resolve returns for each base image,
+// turning node:20-alpine into node:20-alpine@sha256:..., or replaces the
+// digest of an image pinned already when its tag now resolves to another.
+// resolve gets the image without any digest and is called once per image.
+// Images copied from with COPY --from=image are pinned the same way.
+// Earlier stages, scratch, digest-only references and images chosen through
+// an ARG are left alone. It returns how many instructions changed.
+func (d *Dockerfile) PinBaseImages(resolve func(image string) (string, error)) (int, error) {
+	digests := make(map[string]string)
+	// pinned returns image pinned to the digest its tag resolves to, or ""
+	// when it is left alone or already pinned to that digest
+	pinned := func(image string, i int) (string, error) {
+		name, current, _ := strings.Cut(image, "@")
+		if name == "" || strings.EqualFold(name, "scratch") || strings.Contains(name, "$") || d.stageBefore(name, i) {
+			return "", nil
+		}
+		if current != "" && strings.LastIndex(name, ":") < strings.LastIndex(name, "/")+1 {
+			return "", nil // Pinned by digest alone; there is no tag to resolve
+		}
+
+		digest, ok := digests[name]
+		if !ok {
+			var err error
+			if digest, err = resolve(name); err != nil {
+				return "", fmt.Errorf("failed to resolve %s: %w", name, err)
+			}
+			digests[name] = digest
+		}
+		if digest == "" || digest == current {
+			return "", nil
+		}
+		return name + "@" + digest, nil
+	}
+
+	changed := 0
+	for i, stage := range d.Stages {
+		image, err := pinned(stage.Image, i)
+		if err != nil {
+			return changed, err
+		}
+		if image != "" && d.replaceImage(stage, image) {
+			changed++
+		}
+
+		for _, in := range stage.Instructions {
+			from, ok := in.Flag("from")
+			if in.Cmd != "COPY" || !ok || !isImageRef(from) {
+				continue
+			}
+			image, err := pinned(from, i)
+			if err != nil {
+				return changed, err
+			}
+			if image != "" && d.replaceFlag(in, "from", from, image) {
+				changed++
+			}
+		}
+	}
+	d.reparse()
+	return changed, nil
+}
+
+// isImageRef reports whether a COPY --from value names an image rather than
+// a stage, which has no registry, tag or digest
+func isImageRef(from string) bool {
+	return strings.ContainsAny(from, ":/@")
+}
+
+// replaceFlag rewrites the value of an instruction's --name flag without
+// parsing again
+func (d *Dockerfile) replaceFlag(in *Instruction, name, old, value string) bool {
+	flag := "--" + name + "=" + old
+	for n := in.StartLine - 1; n < in.EndLine; n++ {
+		if strings.Contains(d.lines[n], flag) {
+			d.lines[n] = strings.Replace(d.lines[n], flag, "--"+name+"="+value, 1)
+			return true
+		}
+	}
+	return false
+}
+
+// SetBaseImage changes the base image of the stage with the given index,
+// keeping its flags and AS name. When the stage takes its image from ARGs
+// declared before the first FROM, as node:${NODE_VERSION}-alpine does, and
+// image fits that pattern, the ARG defaults change instead, so builds can
+// still override them.
+func (d *Dockerfile) SetBaseImage(stage int, image string) error {
+	if stage < 0 || stage >= len(d.Stages) {
+		return fmt.Errorf("no build stage %d", stage)
+	}
+	if strings.TrimSpace(image) == "" || strings.ContainsAny(image, " \t") {
+		return fmt.Errorf("invalid image %q", image)
+	}
+	if values := d.argValues(d.Stages[stage].Image, image); values != nil {
+		for name, value := range values {
+			if !d.setArgDefault(name, value) {
+				return fmt.Errorf("ARG %s has no declaration to change", name)
+			}
+		}
+		d.reparse()
+		return nil
+	}
+	if !d.replaceImage(d.Stages[stage], image) {
+		return fmt.Errorf("base image of stage %d not found on its FROM line", stage)
+	}
+	d.reparse()
+	return nil
+}
+
+// replaceImage rewrites the image on the stage's FROM lines without
+// parsing again, so the other stages' line numbers stay valid
+func (d *Dockerfile) replaceImage(stage *Stage, image string) bool {
+	re := regexp.MustCompile(`(^|\s)` + regexp.QuoteMeta(stage.Image) + `(\s|$)`)
+	for n := stage.From.StartLine - 1; n < stage.From.EndLine; n++ {
+		if loc := re.FindStringSubmatchIndex(d.lines[n]); loc != nil {
+			d.lines[n] = d.lines[n][:loc[3]] + image + d.lines[n][loc[4]:]
+			return true
+		}
+	}
+	return false
+}
+
+// argName matches a valid ARG name
+var argName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// declaresArg reports whether any of instructions is an ARG declaring name
+func declaresArg(instructions []*Instruction, name string) bool {
+	for _, in := range instructions {
+		if in.Cmd != "ARG" {
+			continue
+		}
+		for _, arg := range in.Args {
+			if n, _, _ := strings.Cut(arg, "="); n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quoteArgValue quotes an ARG default that contains whitespace or quotes
+func quoteArgValue(value string) string {
+	if !strings.ContainsAny(value, " \t\"'") {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// stageBefore reports whether name is a stage defined before stage index i
+func (d *Dockerfile) stageBefore(name string, i int) bool {
+	s := d.Stage(name)
+	return s != nil && s.Index < i
+}
+
+// splice inserts lines before the 0-based line index at and parses the
+// result again, so line numbers stay accurate for the next change
+func (d *Dockerfile) splice(at int, lines ...string) {
+	updated := make([]string, 0, len(d.lines)+len(lines))
+	updated = append(updated, d.lines[:at]...)
+	updated = append(updated, lines...)
+	updated = append(updated, d.lines[at:]...)
+	d.lines = updated
+	d.reparse()
+}
+
+func (d *Dockerfile) reparse() {
+	*d = *Parse(d.String())
+}
+
+// variantImages are the official images published with both an alpine and
+// a slim (Debian) variant of their tags
+var variantImages = map[string]bool{"node": true, "python": true, "ruby": true, "rust": true, "oven/bun": true}
+
+// variantSuffix matches the distribution part of a tag: alpine3.20, slim,
+// bookworm, slim-bookworm and so on
+var variantSuffix = regexp.MustCompile(`(^|-)(alpine[0-9.]*|slim(-bookworm|-bullseye)?|(bookworm|bullseye)(-slim)?)$`)
+
+// packageManagers are the commands that only work on one of the variants
+var packageManagers = map[string]*regexp.Regexp{
+	"alpine": regexp.MustCompile(`\bapt(-get)?\s+(install|update|upgrade)\b`),
+	"slim":   regexp.MustCompile(`\bapk\s+(add|update|upgrade)\b`),
+}
+
+// SetBaseVariant switches the base images of the stages to their alpine or
+// slim variant, turning node:20-alpine into node:20-slim or alpine:3.20 into
+// debian:bookworm-slim. Images without both variants are left alone, and so
+// are stages (and the stages built on them) that install packages with the
+// other variant's package manager, as their RUN lines would fail. Digests
+// are dropped with the tag they pinned. It returns the stages changed.
+func (d *Dockerfile) SetBaseVariant(variant string) ([]string, error) {
+	incompatible, ok := packageManagers[variant]
+	if !ok {
+		return nil, fmt.Errorf("unknown base image variant %q (alpine or slim)", variant)
+	}
+
+	var changed []string
+	for i, stage := range d.Stages {
+		name, _, _ := strings.Cut(stage.Image, "@")
+		if name == "" || d.stageBefore(name, i) {
+			continue
+		}
+		image := variantImage(name, variant)
+		if image == "" || image == name || d.usesPackageManager(stage, incompatible) {
+			continue
+		}
+		if d.replaceImage(stage, image) {
+			changed = append(changed, stageName(stage))
+		}
+	}
+	d.reparse()
+	return changed, nil
+}
+
+// variantImage returns the variant of an image, or "" when it has none
+func variantImage(image, variant string) string {
+	repo, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo, tag = image[:i], image[i+1:]
+	}
+	switch repo := strings.TrimPrefix(repo, "docker.io/"); {
+	case repo == "alpine" || repo == "library/alpine":
+		if variant == "slim" {
+			return "debian:bookworm-slim"
+		}
+		return image
+	case repo == "debian" || repo == "library/debian":
+		if variant == "alpine" {
+			return "alpine:3.20"
+		}
+		if !strings.HasSuffix(tag, "-slim") && tag != "latest" {
+			return repo + ":" + tag + "-slim"
+		}
+		return image
+	case !variantImages[strings.TrimPrefix(repo, "library/")]:
+		return ""
+	}
+	version := variantSuffix.ReplaceAllString(tag, "")
+	if version == "" || version == "latest" {
+		return repo + ":" + variant
+	}
+	return repo + ":" + version + "-" + variant
+}
+
+// usesPackageManager reports whether a stage, or a later stage built on it,
+// runs a command re matches
+func (d *Dockerfile) usesPackageManager(stage *Stage, re *regexp.Regexp) bool {
+	for _, s := range d.Stages[stage.Index:] {
+		base := s
+		for base != nil && base != stage {
+			base = d.BaseStage(base)
+		}
+		if base == nil {
+			continue
+		}
+		for _, in := range s.Instructions {
+			if in.Cmd == "RUN" && re.MatchString(d.Source(in)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -8,6 +8,10 @@ var (
 	ErrNoProviderMatch = errors.New("no provider matched the repository")
 	ErrLowConfidence   = errors.New("detection confidence below threshold")
 	ErrEmptyRepository = errors.New("repository is empty or contains no recognizable files")
+	ErrAppNotFound     = errors.New("workspace package not found")
+	ErrAppAmbiguous    = errors.New("workspace has several deployable packages")
+	ErrTargetNotFound  = errors.New("build target not found")
+	ErrTargetAmbiguous = errors.New("build has several deployable targets")
 )
 
 // AI errors
@@ -16,6 +20,7 @@ var (
 	ErrAIRequestFailed   = errors.New("AI provider request failed")
 	ErrAIResponseInvalid = errors.New("AI response could not be parsed")
 	ErrAIRateLimited     = errors.New("AI provider rate limit exceeded")
+	ErrAICircuitOpen     = errors.New("AI provider paused after repeated failures")
 )
 
 // Template errors
@@ -44,6 +49,19 @@ var (
 	ErrNotADirectory = errors.New("specified path is not a directory")
 	ErrAccessDenied  = errors.New("access denied to path")
 	ErrScanCancelled = errors.New("scan was cancelled")
+	ErrFileTooLarge  = errors.New("file exceeds the scan's size limit")
+	ErrReadBudget    = errors.New("scan's read budget is used up")
+)
+
+// Registry errors
+var (
+	ErrImageNotFound = errors.New("image or tag not found in the registry")
+	ErrRegistryAuth  = errors.New("registry authentication failed")
+)
+
+// Network errors
+var (
+	ErrOffline = errors.New("network access is disabled by --offline")
 )
 
 // Generator errors
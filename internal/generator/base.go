@@ -0,0 +1,302 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BaseNames are the base image strategies WithBase takes
+var BaseNames = []string{"auto", "alpine", "debian"}
+
+// WithBase picks the base image family of the Dockerfile: alpine, debian, or
+// auto (the default), which uses Debian when a dependency needs glibc
+func WithBase(base string) Option {
+	return func(g *generator) {
+		g.base = base
+	}
+}
+
+// alpineLanguages are the stacks whose templates build on Alpine, which the
+// Debian rewrite can move to glibc
+var alpineLanguages = map[string]bool{"nodejs": true, "bun": true, "dotnet": true}
+
+// debianLanguages are the stacks whose templates build on Debian already
+var debianLanguages = map[string]bool{"python": true, "ruby": true, "rust": true, "deno": true}
+
+// resolveBase decides whether the Dockerfile moves to Debian images, and
+// returns why for auto, or a warning when the stack can't follow the choice
+func resolveBase(vars map[string]interface{}, language, base string) (debian bool, reason, warning string, err error) {
+	packages, _ := vars["glibcPackages"].([]string)
+	switch base {
+	case "", "auto":
+		if len(packages) == 0 || vars["windows"] != nil {
+			return false, "", "", nil
+		}
+		if !alpineLanguages[language] {
+			if debianLanguages[language] {
+				return false, "", "", nil
+			}
+			return false, "", fmt.Sprintf("%s need glibc, but the %s images stay on Alpine", strings.Join(packages, ", "), language), nil
+		}
+		return true, strings.Join(packages, ", "), "", nil
+	case "alpine":
+		if debianLanguages[language] {
+			return false, "", fmt.Sprintf("--base alpine: the %s images are Debian-based and stay so", language), nil
+		}
+		return false, "", "", nil
+	case "debian":
+		if vars["windows"] != nil || !alpineLanguages[language] && !debianLanguages[language] {
+			return false, "", fmt.Sprintf("--base debian: the %s images stay on Alpine", language), nil
+		}
+		return alpineLanguages[language], "", "", nil
+	}
+	return false, "", "", fmt.Errorf("unsupported base %q (use %s)", base, strings.Join(BaseNames, ", "))
+}
+
+// debianImages are the Debian variants of the Alpine base images
+var debianImages = []struct {
+	alpine *regexp.Regexp
+	debian string
+}{
+	{regexp.MustCompile(`^node:(\S+)-alpine$`), "node:${1}-bookworm-slim"},
+	{regexp.MustCompile(`^oven/bun:(\S+)-alpine$`), "oven/bun:${1}-slim"},
+	{regexp.MustCompile(`^(mcr\.microsoft\.com/dotnet/[a-z-]+):(\S+)-alpine$`), "${1}:${2}-bookworm-slim"},
+}
+
+// debianPackages are the Debian names of Alpine packages that differ; ""
+// drops a package Debian doesn't need
+var debianPackages = map[string]string{
+	"build-base":   "build-essential",
+	"vips-dev":     "libvips-dev",
+	"vips":         "libvips42",
+	"cairo-dev":    "libcairo2-dev",
+	"cairo":        "libcairo2",
+	"pango-dev":    "libpango1.0-dev",
+	"pango":        "libpango-1.0-0 libpangocairo-1.0-0",
+	"jpeg-dev":     "libjpeg-dev",
+	"jpeg":         "libjpeg62-turbo",
+	"giflib-dev":   "libgif-dev",
+	"giflib":       "libgif7",
+	"librsvg-dev":  "librsvg2-dev",
+	"librsvg":      "librsvg2-2",
+	"pixman-dev":   "libpixman-1-dev",
+	"pixman":       "libpixman-1-0",
+	"libstdc++":    "libstdc++6",
+	"icu-libs":     "libicu72",
+	"icu-dev":      "libicu-dev",
+	"openssl-dev":  "libssl-dev",
+	"libc6-compat": "",
+	"gcompat":      "",
+	"musl-dev":     "",
+}
+
+// debianize moves a Dockerfile generated for Alpine to the Debian variants
+// of its base images: apk installs become apt-get ones, BusyBox addgroup
+// and adduser become groupadd and useradd, and a final stage probed with
+// wget installs it, as the slim images have none
+func debianize(content string) string {
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	virtual := make(map[string][]string) // apk --virtual groups, for apk del
+	finalFrom, wget := -1, false
+	for i := 0; i < len(lines); i++ {
+		start := i
+		for strings.HasSuffix(strings.TrimSpace(lines[i]), "\\") && i+1 < len(lines) {
+			i++
+		}
+		instruction := lines[start : i+1]
+		fields := strings.Fields(instruction[0])
+		keyword := ""
+		if len(fields) > 0 {
+			keyword = strings.ToUpper(fields[0])
+		}
+		switch keyword {
+		case "FROM":
+			out = append(out, debianFrom(instruction[0]))
+			out = append(out, instruction[1:]...)
+			finalFrom = len(out) - 1
+			wget = false
+		case "RUN":
+			joined := strings.Join(instruction, "\n")
+			if !strings.Contains(joined, "apk ") && !strings.Contains(joined, "addgroup ") && !strings.Contains(joined, "adduser ") {
+				out = append(out, instruction...)
+				break
+			}
+			out = append(out, rewriteRun(instruction, func(command string) []string {
+				return debianCommand(command, virtual)
+			})...)
+		case "HEALTHCHECK":
+			wget = wget || strings.Contains(strings.Join(instruction, " "), "wget ")
+			out = append(out, instruction...)
+		default:
+			out = append(out, instruction...)
+		}
+	}
+	if wget && finalFrom >= 0 {
+		install := []string{"", "# wget for the HEALTHCHECK (the slim image has none)", "RUN " + strings.Join(aptInstall([]string{"wget"}), " && ")}
+		out = append(out[:finalFrom+1], append(install, out[finalFrom+1:]...)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// debianFrom swaps the image of a FROM line for its Debian variant
+func debianFrom(line string) string {
+	fields := strings.Fields(line)
+	for i := 1; i < len(fields); i++ {
+		if strings.HasPrefix(fields[i], "--") {
+			continue
+		}
+		for _, img := range debianImages {
+			if img.alpine.MatchString(fields[i]) {
+				return strings.Replace(line, fields[i], img.alpine.ReplaceAllString(fields[i], img.debian), 1)
+			}
+		}
+		break
+	}
+	return line
+}
+
+// rewriteRun rewrites each command of a RUN instruction, keeping it on one
+// line or split at each && as it was
+func rewriteRun(instruction []string, rewrite func(command string) []string) []string {
+	parts := make([]string, len(instruction))
+	for i, line := range instruction {
+		parts[i] = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "\\"))
+	}
+	command := strings.TrimSpace(strings.TrimPrefix(strings.Join(parts, " "), "RUN"))
+
+	// Flags of the RUN instruction itself (--mount, --network)
+	var flags []string
+	for strings.HasPrefix(command, "--") {
+		flag, rest, _ := strings.Cut(command, " ")
+		flags = append(flags, flag)
+		command = strings.TrimSpace(rest)
+	}
+
+	var commands []string
+	for _, c := range strings.Split(command, "&&") {
+		commands = append(commands, rewrite(strings.TrimSpace(c))...)
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+	prefix := strings.Join(append([]string{"RUN"}, flags...), " ") + " "
+	if len(instruction) == 1 {
+		return []string{prefix + strings.Join(commands, " && ")}
+	}
+	return strings.Split(prefix+strings.Join(commands, " \\\n    && "), "\n")
+}
+
+// debianCommand rewrites one command of a RUN instruction
+func debianCommand(command string, virtual map[string][]string) []string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil
+	}
+	var opts []string
+	value := func(i *int) string {
+		if *i+1 < len(fields) {
+			*i++
+			return fields[*i]
+		}
+		return ""
+	}
+	switch fields[0] {
+	case "apk":
+		var group string
+		var packages []string
+		del := false
+		for i := 1; i < len(fields); i++ {
+			switch f := fields[i]; {
+			case f == "del":
+				del = true
+			case f == "--virtual" || f == "-t":
+				group = value(&i)
+			case f == "add" || strings.HasPrefix(f, "-"):
+			default:
+				packages = append(packages, f)
+			}
+		}
+		if del {
+			var remove []string
+			for _, p := range packages {
+				if members, ok := virtual[p]; ok {
+					remove = append(remove, members...)
+				} else {
+					remove = append(remove, debianPackageNames(p)...)
+				}
+			}
+			if len(remove) == 0 {
+				return nil
+			}
+			return []string{"apt-get purge -y --auto-remove " + strings.Join(remove, " ")}
+		}
+		var install []string
+		for _, p := range packages {
+			install = append(install, debianPackageNames(p)...)
+		}
+		if group != "" {
+			virtual[group] = install
+		}
+		if len(install) == 0 {
+			return nil
+		}
+		return aptInstall(install)
+	case "addgroup":
+		var name string
+		for i := 1; i < len(fields); i++ {
+			switch f := fields[i]; f {
+			case "-S", "--system":
+				opts = append(opts, "--system")
+			case "-g", "--gid":
+				opts = append(opts, "--gid", value(&i))
+			default:
+				name = f
+			}
+		}
+		return []string{strings.Join(append(append([]string{"groupadd"}, opts...), name), " ")}
+	case "adduser":
+		var name string
+		home := "--create-home"
+		for i := 1; i < len(fields); i++ {
+			switch f := fields[i]; f {
+			case "-S", "--system":
+				opts = append(opts, "--system")
+			case "-u", "--uid":
+				opts = append(opts, "--uid", value(&i))
+			case "-G", "--ingroup":
+				opts = append(opts, "--gid", value(&i))
+			case "-s", "--shell":
+				opts = append(opts, "--shell", value(&i))
+			case "-h", "--home":
+				opts = append(opts, "--home-dir", value(&i))
+			case "-H", "--no-create-home":
+				home = "--no-create-home"
+			case "-D", "--disabled-password":
+			default:
+				name = f
+			}
+		}
+		return []string{strings.Join(append(append([]string{"useradd"}, opts...), home, name), " ")}
+	}
+	return []string{command}
+}
+
+// debianPackageNames are the Debian packages of an Alpine package
+func debianPackageNames(pkg string) []string {
+	if name, ok := debianPackages[pkg]; ok {
+		return strings.Fields(name)
+	}
+	return []string{pkg}
+}
+
+// aptInstall are the commands installing Debian packages without leaving
+// the package lists in the layer
+func aptInstall(packages []string) []string {
+	return []string{
+		"apt-get update",
+		"apt-get install -y --no-install-recommends " + strings.Join(packages, " "),
+		"rm -rf /var/lib/apt/lists/*",
+	}
+}
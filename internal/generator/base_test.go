@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDebianize(t *testing.T) {
+	got := debianize(`FROM node:20-alpine AS builder
+RUN apk add --no-cache --virtual .native-build python3 make g++ vips-dev libc6-compat
+RUN npm ci
+RUN apk del .native-build
+
+FROM mcr.microsoft.com/dotnet/aspnet:8.0-alpine AS runner
+WORKDIR /app
+RUN apk add --no-cache \
+    icu-libs \
+    curl
+RUN addgroup -S dotnet && adduser -S aspnet -G dotnet
+USER aspnet
+HEALTHCHECK --interval=30s \
+  CMD wget --no-verbose --tries=1 --spider http://localhost:8080/ || exit 1
+`)
+	want := `FROM node:20-bookworm-slim AS builder
+RUN apt-get update && apt-get install -y --no-install-recommends python3 make g++ libvips-dev && rm -rf /var/lib/apt/lists/*
+RUN npm ci
+RUN apt-get purge -y --auto-remove python3 make g++ libvips-dev
+
+FROM mcr.microsoft.com/dotnet/aspnet:8.0-bookworm-slim AS runner
+
+# wget for the HEALTHCHECK (the slim image has none)
+RUN apt-get update && apt-get install -y --no-install-recommends wget && rm -rf /var/lib/apt/lists/*
+WORKDIR /app
+RUN apt-get update \
+    && apt-get install -y --no-install-recommends libicu72 curl \
+    && rm -rf /var/lib/apt/lists/*
+RUN groupadd --system dotnet && useradd --system --gid dotnet --create-home aspnet
+USER aspnet
+HEALTHCHECK --interval=30s \
+  CMD wget --no-verbose --tries=1 --spider http://localhost:8080/ || exit 1
+`
+	if got != want {
+		t.Errorf("debianize() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestResolveBase(t *testing.T) {
+	glibc := map[string]interface{}{"glibcPackages": []string{"playwright (its browsers are built for glibc)"}}
+	tests := []struct {
+		name     string
+		vars     map[string]interface{}
+		language string
+		base     string
+		debian   bool
+		warning  string
+	}{
+		{"auto without glibc packages", map[string]interface{}{}, "nodejs", "", false, ""},
+		{"auto with glibc packages", glibc, "nodejs", "auto", true, ""},
+		{"alpine keeps alpine", glibc, "nodejs", "alpine", false, ""},
+		{"debian", map[string]interface{}{}, "dotnet", "debian", true, ""},
+		{"python is debian already", glibc, "python", "debian", false, ""},
+		{"python can't be alpine", map[string]interface{}{}, "python", "alpine", false, "--base alpine"},
+		{"php stays on alpine", map[string]interface{}{}, "php", "debian", false, "--base debian"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debian, reason, warning, err := resolveBase(tt.vars, tt.language, tt.base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if debian != tt.debian || !strings.HasPrefix(warning, tt.warning) || tt.warning == "" && warning != "" {
+				t.Errorf("resolveBase() = %v, %q, %q", debian, reason, warning)
+			}
+			if tt.base == "auto" && reason == "" {
+				t.Error("resolveBase() gave no reason for picking Debian")
+			}
+		})
+	}
+	if _, _, _, err := resolveBase(glibc, "nodejs", "musl"); err == nil {
+		t.Error("resolveBase() accepted an unknown base")
+	}
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+// BuildpacksBuilder is the Cloud Native Buildpacks builder of project.toml
+const BuildpacksBuilder = "paketobuildpacks/builder-jammy-base"
+
+// buildpack is the Paketo buildpack for a language, and the build-time
+// variable that selects the runtime version
+type buildpack struct {
+	uri        string
+	versionEnv string // BP_* variable
+	versionVar string // Detection variable holding the version
+	major      bool   // The variable takes a major version (17), not a constraint (20.*)
+}
+
+// buildpacks are the buildpacks by language; Deno, Bun and Elixir have none
+var buildpacks = map[string]buildpack{
+	"nodejs": {uri: "paketo-buildpacks/nodejs", versionEnv: "BP_NODE_VERSION", versionVar: "nodeVersion"},
+	"python": {uri: "paketo-buildpacks/python", versionEnv: "BP_CPYTHON_VERSION", versionVar: "pythonVersion"},
+	"go":     {uri: "paketo-buildpacks/go", versionEnv: "BP_GO_VERSION", versionVar: "goVersion"},
+	"java":   {uri: "paketo-buildpacks/java", versionEnv: "BP_JVM_VERSION", versionVar: "javaVersion", major: true},
+	"ruby":   {uri: "paketo-buildpacks/ruby", versionEnv: "BP_MRI_VERSION", versionVar: "rubyVersion"},
+	"php":    {uri: "paketo-buildpacks/php", versionEnv: "BP_PHP_VERSION", versionVar: "phpVersion"},
+	"dotnet": {uri: "paketo-buildpacks/dotnet-core", versionEnv: "BP_DOTNET_FRAMEWORK_VERSION", versionVar: "dotnetVersion"},
+	"rust":   {uri: "paketo-community/rust"},
+}
+
+// exactVersion matches a full x.y.z version, used as is
+var exactVersion = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// ProjectTOML renders a Cloud Native Buildpacks project descriptor
+// (project.toml) for a detection result: the builder, the buildpack of the
+// language, and the BP_* build variables pinning the runtime version and
+// the build the Dockerfile runs. pack build and kpack read it.
+func ProjectTOML(result *detector.DetectionResult, name string) (string, error) {
+	bp, ok := buildpacks[result.Language]
+	if !ok {
+		return "", fmt.Errorf("no Cloud Native Buildpack builds %s apps; use the Docker configuration", result.Language)
+	}
+	vars := result.Variables
+	env := make(map[string]string)
+
+	if version, _ := vars[bp.versionVar].(string); bp.versionEnv != "" && version != "" && !strings.ContainsAny(version, "${}") {
+		switch {
+		case bp.major:
+			version, _, _ = strings.Cut(version, ".")
+		case !exactVersion.MatchString(version):
+			version = strings.TrimSuffix(version, ".x") + ".*"
+		}
+		env[bp.versionEnv] = version
+	}
+
+	switch result.Language {
+	case "nodejs":
+		if outputDir, _ := vars["outputDir"].(string); outputDir != "" && vars["staticServer"] != nil {
+			// Static sites are built, then served by nginx
+			bp.uri = "paketo-buildpacks/web-servers"
+			env["BP_NODE_RUN_SCRIPTS"] = "build"
+			env["BP_WEB_SERVER"] = "nginx"
+			env["BP_WEB_SERVER_ROOT"] = outputDir
+			env["BP_WEB_SERVER_ENABLE_PUSH_STATE"] = "true"
+		} else if script, _ := vars["buildScript"].(string); script != "" {
+			env["BP_NODE_RUN_SCRIPTS"] = script
+		}
+	case "go":
+		if main, _ := vars["mainPath"].(string); main != "" && main != "." {
+			env["BP_GO_TARGETS"] = main
+		}
+	case "java":
+		// A project of a Gradle build (--target)
+		if dir, _ := vars["appDir"].(string); dir != "" && vars["buildTool"] == "gradle" {
+			env["BP_GRADLE_BUILT_MODULE"] = dir
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Cloud Native Buildpacks project descriptor\n")
+	b.WriteString("# Generated by Dublyo Dockerizer; build with: pack build " + name + "\n\n")
+	b.WriteString("[_]\nschema-version = \"0.2\"\n")
+	fmt.Fprintf(&b, "id = %s\nname = %s\n\n", strconv.Quote(name), strconv.Quote(name))
+	fmt.Fprintf(&b, "[io.buildpacks]\nbuilder = %s\n", strconv.Quote(BuildpacksBuilder))
+	fmt.Fprintf(&b, "\n[[io.buildpacks.group]]\nuri = %s\n", strconv.Quote(bp.uri))
+
+	names := make([]string, 0, len(env))
+	for k := range env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		fmt.Fprintf(&b, "\n[[io.buildpacks.build.env]]\nname = %s\nvalue = %s\n", strconv.Quote(k), strconv.Quote(env[k]))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,54 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestProjectTOML(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *detector.DetectionResult
+		want   []string
+	}{
+		{
+			name:   "express",
+			result: &detector.DetectionResult{Language: "nodejs", Variables: map[string]interface{}{"nodeVersion": "20", "buildScript": "build"}},
+			want:   []string{`uri = "paketo-buildpacks/nodejs"`, "name = \"BP_NODE_VERSION\"\nvalue = \"20.*\"", "name = \"BP_NODE_RUN_SCRIPTS\"\nvalue = \"build\""},
+		},
+		{
+			name:   "vite",
+			result: &detector.DetectionResult{Language: "nodejs", Variables: map[string]interface{}{"outputDir": "dist", "staticServer": "nginx"}},
+			want:   []string{`uri = "paketo-buildpacks/web-servers"`, "name = \"BP_WEB_SERVER_ROOT\"\nvalue = \"dist\""},
+		},
+		{
+			name:   "spring",
+			result: &detector.DetectionResult{Language: "java", Variables: map[string]interface{}{"javaVersion": "17.0.2", "buildTool": "gradle", "appDir": "services/api"}},
+			want:   []string{"name = \"BP_JVM_VERSION\"\nvalue = \"17\"", "name = \"BP_GRADLE_BUILT_MODULE\"\nvalue = \"services/api\""},
+		},
+		{
+			name:   "go",
+			result: &detector.DetectionResult{Language: "go", Variables: map[string]interface{}{"goVersion": "1.22.3", "mainPath": "./cmd/server"}},
+			want:   []string{"name = \"BP_GO_VERSION\"\nvalue = \"1.22.3\"", "name = \"BP_GO_TARGETS\"\nvalue = \"./cmd/server\""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProjectTOML(tt.result, "app")
+			if err != nil {
+				t.Fatalf("ProjectTOML() error = %v", err)
+			}
+			for _, want := range append(tt.want, `builder = "`+BuildpacksBuilder+`"`) {
+				if !strings.Contains(got, want) {
+					t.Errorf("project.toml lacks %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+
+	if _, err := ProjectTOML(&detector.DetectionResult{Language: "deno"}, "app"); err == nil {
+		t.Error("ProjectTOML() for deno: want an error")
+	}
+}
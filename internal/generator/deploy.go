@@ -0,0 +1,333 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"gopkg.in/yaml.v3"
+)
+
+// DeployPlatforms are the platforms DeployConfig writes a descriptor for,
+// with the file each reads
+var DeployPlatforms = map[string]string{
+	"fly":       "fly.toml",
+	"render":    "render.yaml",
+	"railway":   "railway.json",
+	"ecs":       "ecs-task-definition.json",
+	"terraform": "docker.tf",
+}
+
+// DeployTarget is what a platform needs to run the image: where the
+// Dockerfile is, the port and health check of the app, and the processes
+// and commands of its build plan
+type DeployTarget struct {
+	Name       string
+	Dockerfile string // Relative to the project directory, the build context
+	Port       string
+	HealthPath string // HTTP path probed by the HEALTHCHECK; "" for none
+	Health     *dockerfile.Healthcheck
+	Command    string // Start command of the web process
+	Release    string // Run once before each deploy (migrations)
+	Processes  []DeployProcess
+	Env        []scanner.EnvVar
+	Resources  Environment // Memory and CPU limits (ecs, terraform)
+}
+
+// DeployProcess is a process beside the web one, run from the same image
+type DeployProcess struct {
+	Name    string
+	Command string
+}
+
+// healthURL finds the local URL a HEALTHCHECK probes
+var healthURL = regexp.MustCompile(`https?://(?:localhost|127\.0\.0\.1|0\.0\.0\.0)(?::[^/\s'"]+)?(/[^\s'"|;&)]*)?`)
+
+// NewDeployTarget derives the deployment of a project from its detection
+// and the Dockerfile the platform builds: the port it exposes, the path its
+// HEALTHCHECK probes and its CMD, and the Procfile release and worker
+// processes or the ORM migration of the build plan.
+func NewDeployTarget(result *detector.DetectionResult, df *dockerfile.Dockerfile, name, file string) DeployTarget {
+	vars := result.Variables
+	t := DeployTarget{Name: name, Dockerfile: file}
+	t.Port, _ = vars["port"].(string)
+	t.Command, _ = vars["webCommand"].(string)
+	t.Release, _ = vars["releaseCommand"].(string)
+	if t.Release == "" {
+		t.Release, _ = vars["migrateCommand"].(string)
+	}
+	if processes, ok := vars["processes"].([]map[string]string); ok {
+		for _, p := range processes {
+			t.Processes = append(t.Processes, DeployProcess{Name: p["name"], Command: p["command"]})
+		}
+	}
+	t.Env, _ = vars["envVars"].([]scanner.EnvVar)
+
+	var entrypoint, cmd string
+	if final := df.FinalStage(); final != nil {
+		for _, in := range final.Instructions {
+			switch in.Cmd {
+			case "EXPOSE":
+				if len(in.Args) == 0 {
+					break
+				}
+				// A port set by a stage ARG or ENV leaves the detected one
+				if port, _, _ := strings.Cut(df.ExpandArgs(in.Args[0]), "/"); !strings.ContainsAny(port, "${}") {
+					t.Port = port
+				}
+			case "ENTRYPOINT":
+				entrypoint = in.Value
+				if in.JSON {
+					entrypoint = strings.Join(in.Args, " ")
+				}
+			case "CMD":
+				cmd = in.Value
+				if in.JSON {
+					cmd = strings.Join(in.Args, " ")
+				}
+			}
+		}
+	}
+	if t.Command == "" {
+		t.Command = strings.TrimSpace(entrypoint + " " + cmd)
+	}
+	if t.Health = df.Healthcheck(); t.Health != nil {
+		if m := healthURL.FindStringSubmatch(df.ExpandArgs(t.Health.Command)); m != nil {
+			t.HealthPath = m[1]
+			if t.HealthPath == "" {
+				t.HealthPath = "/"
+			}
+		}
+	}
+	if t.Port == "" {
+		t.Port = "8080"
+	}
+	return t
+}
+
+// DeployPlatformNames are the names of DeployPlatforms, sorted
+func DeployPlatformNames() []string {
+	names := make([]string, 0, len(DeployPlatforms))
+	for name := range DeployPlatforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DeployConfig renders the descriptor of a platform (fly, render or
+// railway) for a deploy target and returns the file name it goes in
+func DeployConfig(platform string, t DeployTarget) (string, string, error) {
+	file, ok := DeployPlatforms[platform]
+	if !ok {
+		return "", "", fmt.Errorf("unsupported platform %q (supported: %s)", platform, strings.Join(DeployPlatformNames(), ", "))
+	}
+	var content string
+	var err error
+	switch platform {
+	case "fly":
+		content = flyTOML(t)
+	case "render":
+		content, err = renderYAML(t)
+	case "railway":
+		content, err = railwayJSON(t)
+	case "ecs":
+		content, err = ecsTaskDefinition(t)
+	case "terraform":
+		content = terraformDocker(t)
+	}
+	return file, content, err
+}
+
+// flyTOML renders a Fly.io app configuration
+func flyTOML(t DeployTarget) string {
+	var b strings.Builder
+	b.WriteString("# Fly.io app configuration\n")
+	b.WriteString("# Generated by Dublyo Dockerizer; deploy with: fly deploy\n\n")
+	fmt.Fprintf(&b, "app = %s\nprimary_region = \"iad\"\n", strconv.Quote(t.Name))
+	fmt.Fprintf(&b, "\n[build]\n  dockerfile = %s\n", strconv.Quote(t.Dockerfile))
+	if t.Release != "" {
+		fmt.Fprintf(&b, "\n[deploy]\n  release_command = %s\n", strconv.Quote(t.Release))
+	}
+
+	var secrets []string
+	b.WriteString("\n[env]\n")
+	fmt.Fprintf(&b, "  PORT = %s\n", strconv.Quote(t.Port))
+	for _, v := range t.Env {
+		switch {
+		case v.Name == "PORT":
+		case v.Default != "":
+			fmt.Fprintf(&b, "  %s = %s\n", v.Name, strconv.Quote(v.Default))
+		default:
+			secrets = append(secrets, v.Name+"=...")
+		}
+	}
+	if len(secrets) > 0 {
+		fmt.Fprintf(&b, "  # Secrets: fly secrets set %s\n", strings.Join(secrets, " "))
+	}
+
+	if len(t.Processes) > 0 {
+		b.WriteString("\n[processes]\n")
+		fmt.Fprintf(&b, "  app = %s\n", strconv.Quote(t.Command))
+		for _, p := range t.Processes {
+			fmt.Fprintf(&b, "  %s = %s\n", p.Name, strconv.Quote(p.Command))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n[http_service]\n  internal_port = %s\n", t.Port)
+	b.WriteString("  force_https = true\n  auto_stop_machines = \"stop\"\n  auto_start_machines = true\n  min_machines_running = 0\n")
+	if len(t.Processes) > 0 {
+		b.WriteString("  processes = [\"app\"]\n")
+	}
+	if t.HealthPath != "" {
+		b.WriteString("\n  [[http_service.checks]]\n    grace_period = \"10s\"\n    interval = \"30s\"\n    method = \"GET\"\n    timeout = \"5s\"\n")
+		fmt.Fprintf(&b, "    path = %s\n", strconv.Quote(t.HealthPath))
+	}
+	return b.String()
+}
+
+// renderService is a service of a Render Blueprint
+type renderService struct {
+	Type             string      `yaml:"type"`
+	Name             string      `yaml:"name"`
+	Runtime          string      `yaml:"runtime"`
+	DockerfilePath   string      `yaml:"dockerfilePath"`
+	DockerContext    string      `yaml:"dockerContext"`
+	DockerCommand    string      `yaml:"dockerCommand,omitempty"`
+	HealthCheckPath  string      `yaml:"healthCheckPath,omitempty"`
+	PreDeployCommand string      `yaml:"preDeployCommand,omitempty"`
+	EnvVars          []renderEnv `yaml:"envVars,omitempty"`
+}
+
+// renderEnv is an environment variable of a Render service; sync: false
+// ones are asked for in the dashboard
+type renderEnv struct {
+	Key   string `yaml:"key"`
+	Value string `yaml:"value,omitempty"`
+	Sync  *bool  `yaml:"sync,omitempty"`
+}
+
+// renderYAML renders a Render Blueprint with a web service and a worker per
+// Procfile process
+func renderYAML(t DeployTarget) (string, error) {
+	noSync := false
+	env := []renderEnv{{Key: "PORT", Value: t.Port}}
+	for _, v := range t.Env {
+		switch {
+		case v.Name == "PORT":
+		case v.Default != "":
+			env = append(env, renderEnv{Key: v.Name, Value: v.Default})
+		default:
+			env = append(env, renderEnv{Key: v.Name, Sync: &noSync})
+		}
+	}
+
+	services := []renderService{{
+		Type:             "web",
+		Name:             t.Name,
+		Runtime:          "docker",
+		DockerfilePath:   "./" + t.Dockerfile,
+		DockerContext:    ".",
+		HealthCheckPath:  t.HealthPath,
+		PreDeployCommand: t.Release,
+		EnvVars:          env,
+	}}
+	for _, p := range t.Processes {
+		services = append(services, renderService{
+			Type:           "worker",
+			Name:           t.Name + "-" + p.Name,
+			Runtime:        "docker",
+			DockerfilePath: "./" + t.Dockerfile,
+			DockerContext:  ".",
+			DockerCommand:  p.Command,
+			EnvVars:        env,
+		})
+	}
+
+	var b strings.Builder
+	b.WriteString("# Render Blueprint\n# Generated by Dublyo Dockerizer; create it under Blueprints in the Render dashboard\n\n")
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]interface{}{"services": services}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// railwayJSON renders a Railway service configuration. Railway takes
+// variables and further services in its dashboard, not in the file.
+func railwayJSON(t DeployTarget) (string, error) {
+	deploy := map[string]interface{}{
+		"restartPolicyType":       "ON_FAILURE",
+		"restartPolicyMaxRetries": 10,
+	}
+	if t.HealthPath != "" {
+		deploy["healthcheckPath"] = t.HealthPath
+		deploy["healthcheckTimeout"] = 300
+	}
+	if t.Release != "" {
+		deploy["preDeployCommand"] = []string{t.Release}
+	}
+	config := map[string]interface{}{
+		"$schema": "https://railway.com/railway.schema.json",
+		"build": map[string]string{
+			"builder":        "DOCKERFILE",
+			"dockerfilePath": t.Dockerfile,
+		},
+		"deploy": deploy,
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// DeployNotes are what a platform's descriptor can't express: the settings
+// and services to add by hand
+func DeployNotes(platform string, t DeployTarget) []string {
+	var notes []string
+	switch platform {
+	case "railway":
+		if len(t.Env) > 0 {
+			names := make([]string, 0, len(t.Env))
+			for _, v := range t.Env {
+				names = append(names, v.Name)
+			}
+			sort.Strings(names)
+			notes = append(notes, "set the variables in the Railway dashboard: PORT="+t.Port+", "+strings.Join(names, ", "))
+		} else {
+			notes = append(notes, "set PORT="+t.Port+" in the Railway dashboard")
+		}
+		for _, p := range t.Processes {
+			notes = append(notes, fmt.Sprintf("add a service for the %s process with the start command %q", p.Name, p.Command))
+		}
+	case "ecs":
+		notes = append(notes, "replace ACCOUNT_ID and us-east-1 with your account and region, and push the image to the ECR repository "+t.Name)
+		for _, p := range t.Processes {
+			notes = append(notes, fmt.Sprintf("run the %s process as its own service, with the command %q", p.Name, p.Command))
+		}
+		if t.Release != "" {
+			notes = append(notes, fmt.Sprintf("run %q as a one-off task (aws ecs run-task) before each deploy", t.Release))
+		}
+	case "terraform":
+		if t.Release != "" {
+			notes = append(notes, fmt.Sprintf("run %q in the app container before each deploy", t.Release))
+		}
+	}
+	switch {
+	case platform == "ecs" || platform == "terraform":
+		if t.Health == nil {
+			notes = append(notes, "the Dockerfile has no HEALTHCHECK, so no health check is configured")
+		}
+	case t.HealthPath == "":
+		notes = append(notes, "the Dockerfile has no HTTP HEALTHCHECK, so no health check is configured")
+	}
+	return notes
+}
@@ -0,0 +1,110 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+func TestDeployConfig(t *testing.T) {
+	result := &detector.DetectionResult{
+		Language: "nodejs",
+		Variables: map[string]interface{}{
+			"port":           "3000",
+			"releaseCommand": "npx prisma migrate deploy",
+			"processes":      []map[string]string{{"name": "worker", "command": "node worker.js"}},
+			"envVars":        []scanner.EnvVar{{Name: "LOG_LEVEL", Default: "info"}, {Name: "DATABASE_URL"}},
+		},
+	}
+	df := dockerfile.Parse(`FROM node:20-alpine
+ARG PORT=8080
+EXPOSE ${PORT}
+HEALTHCHECK CMD wget -qO- http://localhost:${PORT}/healthz || exit 1
+CMD ["node", "dist/main.js"]
+`)
+	target := NewDeployTarget(result, df, "shop", "Dockerfile")
+	if target.Port != "3000" || target.HealthPath != "/healthz" || target.Command != "node dist/main.js" {
+		t.Fatalf("NewDeployTarget() = %+v", target)
+	}
+	target.Resources = Environment{Name: "prod"}
+
+	tests := []struct {
+		platform string
+		file     string
+		want     []string
+	}{
+		{"fly", "fly.toml", []string{
+			"release_command = \"npx prisma migrate deploy\"\n",
+			"  LOG_LEVEL = \"info\"\n  # Secrets: fly secrets set DATABASE_URL=...\n",
+			"  app = \"node dist/main.js\"\n  worker = \"node worker.js\"\n",
+			"internal_port = 3000\n",
+			"path = \"/healthz\"\n",
+		}},
+		{"render", "render.yaml", []string{
+			"healthCheckPath: /healthz\n",
+			"preDeployCommand: npx prisma migrate deploy\n",
+			"- key: DATABASE_URL\n        sync: false\n",
+			"name: shop-worker\n",
+		}},
+		{"railway", "railway.json", []string{
+			`"healthcheckPath": "/healthz"`,
+			`"builder": "DOCKERFILE"`,
+		}},
+		{"ecs", "ecs-task-definition.json", []string{
+			`"containerPort": 3000`,
+			`"cpu": "256"`,
+			`"memory": "1024"`,
+			`"valueFrom": "arn:aws:ssm:us-east-1:ACCOUNT_ID:parameter/shop/DATABASE_URL"`,
+			`"wget -qO- http://localhost:${PORT}/healthz || exit 1"`,
+		}},
+		{"terraform", "docker.tf", []string{
+			`"DATABASE_URL=${var.database_url}",`,
+			`test         = ["CMD-SHELL", "wget -qO- http://localhost:$${PORT}/healthz || exit 1"]`,
+			`resource "docker_container" "worker" {`,
+			"memory  = 1024\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			file, content, err := DeployConfig(tt.platform, target)
+			if err != nil {
+				t.Fatalf("DeployConfig() error = %v", err)
+			}
+			if file != tt.file {
+				t.Errorf("file = %q, want %q", file, tt.file)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(content, want) {
+					t.Errorf("%s lacks %q:\n%s", file, want, content)
+				}
+			}
+		})
+	}
+
+	if _, _, err := DeployConfig("heroku", target); err == nil {
+		t.Error("DeployConfig(heroku): want an error")
+	}
+}
+
+func TestFargateSize(t *testing.T) {
+	tests := []struct {
+		memory, cpus string
+		cpu, mem     int
+	}{
+		{"256M", "", 256, 512},
+		{"1G", "", 256, 1024},
+		{"1.5G", "", 256, 2048},
+		{"3G", "", 512, 3072},
+		{"512M", "1.5", 2048, 4096},
+		{"8G", "", 1024, 8192},
+	}
+	for _, tt := range tests {
+		cpu, mem := fargateSize(Environment{MemoryLimit: tt.memory, CPUs: tt.cpus})
+		if cpu != tt.cpu || mem != tt.mem {
+			t.Errorf("fargateSize(%s, %s) = %d, %d; want %d, %d", tt.memory, tt.cpus, cpu, mem, tt.cpu, tt.mem)
+		}
+	}
+}
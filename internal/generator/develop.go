@@ -0,0 +1,143 @@
+package generator
+
+// devWatch is one docker compose watch rule of the app service
+type devWatch struct {
+	Action string   // sync, sync+restart or rebuild
+	Path   string   // Relative to the project directory
+	Target string   // Container path for sync actions
+	Ignore []string // Relative to Path
+}
+
+// devServer is how a framework runs with hot reload: command in the image
+// stage target ("" for the final stage), writing its build output to
+// the outputs directories, which are not synced
+type devServer struct {
+	command []string
+	target  string
+	outputs []string
+}
+
+// devServerFor returns the hot-reloading dev server of a framework, or nil
+// when the production image has to restart or rebuild to pick up changes
+func devServerFor(vars map[string]interface{}) *devServer {
+	if vars["workspace"] == true || vars["webCommand"] != nil {
+		return nil // The Procfile or the monorepo decides how the app starts
+	}
+	port := "3000"
+	if p, ok := vars["port"].(string); ok && p != "" {
+		port = p
+	}
+	switch vars["language"] {
+	case "nodejs":
+		// The build stage has the dev dependencies and the source
+		switch vars["framework"] {
+		case "nextjs":
+			return &devServer{[]string{"npx", "next", "dev", "--hostname", "0.0.0.0", "--port", port}, BuildStage, []string{"node_modules", ".next"}}
+		case "nuxt":
+			return &devServer{[]string{"npx", "nuxt", "dev", "--host", "0.0.0.0", "--port", port}, BuildStage, []string{"node_modules", ".nuxt", ".output"}}
+		case "sveltekit":
+			return &devServer{[]string{"npx", "vite", "dev", "--host", "0.0.0.0", "--port", port}, BuildStage, []string{"node_modules", ".svelte-kit"}}
+		case "astro":
+			return &devServer{[]string{"npx", "astro", "dev", "--host", "0.0.0.0", "--port", port}, BuildStage, []string{"node_modules", ".astro", "dist"}}
+		}
+	case "python":
+		outputs := []string{".venv", "__pycache__"}
+		module, _ := vars["moduleName"].(string)
+		switch vars["framework"] {
+		case "fastapi":
+			if module == "" {
+				module = "main"
+			}
+			return &devServer{command: []string{"uvicorn", module + ":app", "--reload", "--host", "0.0.0.0", "--port", port}, outputs: outputs}
+		case "flask":
+			if module == "" {
+				module = "app"
+			}
+			return &devServer{command: []string{"flask", "--app", module, "run", "--debug", "--host", "0.0.0.0", "--port", port}, outputs: outputs}
+		case "django":
+			return &devServer{command: []string{"python", "manage.py", "runserver", "0.0.0.0:" + port}, outputs: outputs}
+		}
+	}
+	return nil
+}
+
+// dependencyManifests returns the files whose changes need a new image
+// because they change the installed dependencies
+func dependencyManifests(vars map[string]interface{}) []string {
+	locked := vars["hasLockFile"] == true
+	switch vars["language"] {
+	case "nodejs":
+		if !locked {
+			return []string{"package.json"}
+		}
+		switch vars["packageManager"] {
+		case "pnpm":
+			return []string{"package.json", "pnpm-lock.yaml"}
+		case "yarn":
+			return []string{"package.json", "yarn.lock"}
+		case "bun":
+			return []string{"package.json", "bun.lockb"}
+		}
+		return []string{"package.json", "package-lock.json"}
+	case "python":
+		switch vars["packageManager"] {
+		case "poetry":
+			return []string{"pyproject.toml", "poetry.lock"}
+		case "pipenv":
+			return []string{"Pipfile", "Pipfile.lock"}
+		case "uv":
+			return []string{"pyproject.toml", "uv.lock"}
+		case "pdm":
+			return []string{"pyproject.toml", "pdm.lock"}
+		}
+		if vars["hasRequirements"] == false && vars["hasPyproject"] == true {
+			return []string{"pyproject.toml"}
+		}
+		return []string{"requirements.txt"}
+	case "ruby":
+		if locked {
+			return []string{"Gemfile", "Gemfile.lock"}
+		}
+		return []string{"Gemfile"}
+	case "php":
+		if locked {
+			return []string{"composer.json", "composer.lock"}
+		}
+		return []string{"composer.json"}
+	}
+	return nil
+}
+
+// resolveDevelop sets up docker compose watch for the app service (--dev):
+// devWatch lists the watch rules, devCommand and devTarget run the framework's
+// dev server. Source changes are synced into the container when the app
+// reloads them (a dev server, or an interpreter that restarts); everything
+// else rebuilds the image.
+func resolveDevelop(vars map[string]interface{}) {
+	server := devServerFor(vars)
+	excludes, runsSource := sourceMountExcludes(vars)
+	if server == nil && !runsSource {
+		vars["devWatch"] = []devWatch{{Action: "rebuild", Path: "."}}
+		return
+	}
+
+	manifests := dependencyManifests(vars)
+	var watch []devWatch
+	for _, manifest := range manifests {
+		watch = append(watch, devWatch{Action: "rebuild", Path: manifest})
+	}
+	sync := devWatch{Action: "sync+restart", Path: ".", Target: "/app"}
+	if server != nil {
+		sync.Action = "sync" // The dev server reloads by itself
+		vars["devCommand"] = server.command
+		if server.target != "" {
+			vars["devTarget"] = server.target
+		}
+		excludes = server.outputs
+	}
+	for _, dir := range excludes {
+		sync.Ignore = append(sync.Ignore, dir+"/")
+	}
+	sync.Ignore = append(sync.Ignore, manifests...)
+	vars["devWatch"] = append(watch, sync)
+}
@@ -0,0 +1,313 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// docsName is where the usage instructions are written (--docs)
+const docsName = "DOCKER.md"
+
+// proxyNames are the reverse proxies of --proxy, as DOCKER.md names them
+var proxyNames = map[string]string{"traefik": "Traefik", "nginx": "nginx-proxy", "caddy": "Caddy"}
+
+// fence opens and closes a shell code block in DOCKER.md
+const fence = "```"
+
+// generateDocs renders DOCKER.md: the generated files, how to build and run
+// them, the environment variables and notes for the detected stack. It runs
+// last, so files lists everything else that was generated.
+func (g *generator) generateDocs(files map[string]File, buildSecrets []string, vars map[string]interface{}) string {
+	port := "3000"
+	if p, ok := vars["port"].(string); ok && p != "" {
+		port = p
+	}
+	var b strings.Builder
+
+	b.WriteString("# Running with Docker\n\n")
+	fmt.Fprintf(&b, "Generated by [Dublyo Dockerizer](https://github.com/dublyo/dockerizer) for a %s project", stackName(vars))
+	if version, _ := vars["version"].(string); version != "" {
+		fmt.Fprintf(&b, " (%s)", version)
+	}
+	b.WriteString(". Regenerate it with `dockerizer --docs --force`.\n")
+
+	b.WriteString("\n## Files\n\n| File | Purpose |\n|------|---------|\n")
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "| `%s` | %s |\n", name, g.describeFile(name))
+	}
+
+	// Commands run from the directory DOCKER.md is in
+	compose := "docker compose"
+	if !slices.Contains(ComposeNames, g.composeName()) {
+		compose += " -f " + g.composeName()
+	}
+	if _, ok := files[g.composeName()]; ok {
+		b.WriteString("\n## Run with Docker Compose\n\n" + fence + "sh\n")
+		if _, ok := files[".env.example"]; ok {
+			b.WriteString("cp .env.example .env  # then fill in the values\n")
+		}
+		fmt.Fprintf(&b, "%s up --build -d\n%s logs -f app\n", compose, compose)
+		b.WriteString(fence + "\n\n")
+		if proxy, _ := vars["proxy"].(string); proxy != "" {
+			fmt.Fprintf(&b, "%s serves the app at `https://$DOMAIN` (set `DOMAIN` in `.env`); it is also published on `http://127.0.0.1:%s`.\n", proxyNames[proxy], port)
+		} else {
+			fmt.Fprintf(&b, "The app listens on http://localhost:%s (`PORT` in `.env` changes the published port).\n", port)
+		}
+		if services := composeServices(vars); len(services) > 0 {
+			b.WriteString("\nBesides `app`, the compose file runs:\n\n")
+			for _, s := range services {
+				b.WriteString("- " + s + "\n")
+			}
+		}
+		if len(g.environments) > 0 {
+			b.WriteString("\nEach environment has an override layered on the base file:\n\n" + fence + "sh\n")
+			for _, env := range g.environments {
+				fmt.Fprintf(&b, "docker compose -f %s -f %s up -d\n", g.composeName(), g.environmentName(env.Name))
+			}
+			b.WriteString(fence + "\n")
+		}
+		if vars["devWatch"] != nil {
+			fmt.Fprintf(&b, "\nFor development, `%s watch` syncs source changes into the running container and rebuilds the image when dependencies change.\n", compose)
+		}
+	}
+
+	if f, ok := files[g.dockerfileName()]; ok {
+		parsed := dockerfile.Parse(f.String())
+		hasBuildStage, hasTestStage := parsed.Stage(BuildStage) != nil, parsed.Stage(TestStage) != nil
+		context, dockerfile := g.buildPaths(vars)
+		build := "docker build"
+		if len(buildSecrets) > 0 {
+			build += " " + strings.Join(buildSecrets, " ")
+		}
+		if dockerfile != path.Join(context, defaultDockerfilePath) {
+			build += " -f " + dockerfile
+		}
+		b.WriteString("\n## Build and run the image\n\n" + fence + "sh\n")
+		fmt.Fprintf(&b, "%s -t app %s\n", build, context)
+		fmt.Fprintf(&b, "docker run --rm --env-file .env -p %s:%s app\n", port, port)
+		b.WriteString(fence + "\n")
+		if hasTestStage {
+			fmt.Fprintf(&b, "\nThe `%s` stage runs the test suite with the dev dependencies; `%s --target %s %s` fails when a test does, and the runner image never contains it.\n", TestStage, build, TestStage, context)
+		} else if hasBuildStage {
+			fmt.Fprintf(&b, "\nThe `%s` stage has the toolchain, the dev dependencies and the source; `%s --target %s -t app:%s %s` builds just that, to run the tests in CI.\n", BuildStage, build, BuildStage, BuildStage, context)
+		}
+		if len(buildSecrets) > 0 {
+			b.WriteString("\nPrivate registry credentials are passed as BuildKit secrets, so they are never stored in an image layer.\n")
+		}
+	}
+
+	b.WriteString("\n## Environment variables\n\n")
+	envVars, _ := vars["envVars"].([]scanner.EnvVar)
+	if len(envVars) > 0 {
+		b.WriteString("The source reads these variables; `.env.example` lists them with their defaults.\n\n| Variable | Read in |\n|----------|---------|\n")
+		for _, v := range envVars {
+			fmt.Fprintf(&b, "| `%s` | `%s` |\n", v.Name, v.File)
+		}
+	} else {
+		b.WriteString("No variables were found in the source; add the ones the app needs to `.env`.\n")
+	}
+	if vars["entrypointMigrate"] != nil {
+		fmt.Fprintf(&b, "\n`RUN_MIGRATIONS=true` runs `%s` when the container starts.\n", vars["entrypointMigrate"])
+	}
+
+	if vars["harden"] == true {
+		b.WriteString("\n## Hardening\n\n")
+		if vars["hardenRoot"] == true {
+			b.WriteString("The services run with no capabilities and no privilege escalation. ")
+			b.WriteString("Their root filesystem stays writable, as nginx and PHP-FPM under supervisord write across it.\n\n")
+		} else {
+			b.WriteString("The services run with a read-only root filesystem, no capabilities and no privilege escalation. ")
+			b.WriteString("The app can write only to these paths; mount any other path it writes to the same way in `docker-compose.yml`.\n\n")
+		}
+		b.WriteString("| Path | Mount | Written |\n|------|-------|---------|\n")
+		tmpfs, _ := vars["hardenTmpfs"].([]WritablePath)
+		volumes, _ := vars["hardenVolumes"].([]WritablePath)
+		for _, p := range append(tmpfs, volumes...) {
+			mount, reason := "tmpfs, emptied on restart", p.Reason
+			if p.Volume != "" {
+				mount = "volume `" + p.Volume + "`"
+			}
+			if reason == "" {
+				reason = "temporary files"
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", p.Path, mount, reason)
+		}
+	}
+
+	if notes := stackNotes(vars); len(notes) > 0 {
+		b.WriteString("\n## Notes\n\n")
+		for _, note := range notes {
+			b.WriteString("- " + note + "\n")
+		}
+	}
+	return b.String()
+}
+
+// defaultFiles names the files a run with the default selection generates
+func (g *generator) defaultFiles(vars map[string]interface{}) map[string]File {
+	files := map[string]File{
+		g.dockerfileName(): {},
+		g.composeName():    {},
+		".dockerignore":    {},
+		".env.example":     {},
+	}
+	if vars["workspace"] == true || g.outputSubdir != "" {
+		delete(files, ".dockerignore")
+		files[g.dockerfileName()+".dockerignore"] = File{}
+	}
+	for _, env := range g.environments {
+		files[g.environmentName(env.Name)] = File{}
+	}
+	matrix, _ := vars["matrix"].([]matrixService)
+	for _, m := range matrix {
+		files[m.File] = File{}
+	}
+	if vars["entrypointMigrate"] != nil {
+		files["docker-entrypoint.sh"] = File{}
+	}
+	return files
+}
+
+// buildPaths returns the build context and the Dockerfile, relative to the
+// output directory
+func (g *generator) buildPaths(vars map[string]interface{}) (context, dockerfile string) {
+	buildContext, _ := vars["buildContext"].(string)
+	if buildContext == "" {
+		buildContext = "."
+	}
+	dockerfilePath, _ := vars["dockerfilePath"].(string)
+	if dockerfilePath == "" {
+		dockerfilePath = defaultDockerfilePath
+	}
+	context = path.Join(upTo(path.Clean("./"+g.outputSubdir)), buildContext)
+	return context, path.Join(context, dockerfilePath)
+}
+
+// describeFile says what a generated file is for
+func (g *generator) describeFile(name string) string {
+	switch {
+	case name == g.dockerfileName():
+		return "Builds the production image"
+	case name == g.composeName():
+		return "Runs the app and its services"
+	case strings.HasPrefix(path.Base(name), "docker-compose."):
+		env := strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "docker-compose."), ".yml")
+		return fmt.Sprintf("Compose override for the %s environment", env)
+	case strings.HasSuffix(name, ".dockerignore"):
+		return "Keeps files out of the build context"
+	case strings.HasPrefix(name, g.dockerfileName()+"."):
+		profile := strings.TrimPrefix(name, g.dockerfileName()+".")
+		return fmt.Sprintf("Builds the image of the %s matrix profile (`docker compose up app-%s`)", profile, profile)
+	case name == ".env.example":
+		return "Environment variables; copy it to `.env`"
+	case name == "docker-entrypoint.sh":
+		return "Runs the migrations when `RUN_MIGRATIONS=true`, then starts the app"
+	}
+	return "Generated"
+}
+
+// stackName is the detected language and framework
+func stackName(vars map[string]interface{}) string {
+	language, _ := vars["language"].(string)
+	framework, _ := vars["framework"].(string)
+	if framework == "" || framework == language {
+		return language
+	}
+	return language + "/" + framework
+}
+
+// composeServices lists the services of docker-compose.yml besides the app
+func composeServices(vars map[string]interface{}) []string {
+	var services []string
+	if processes, _ := vars["processes"].([]map[string]string); len(processes) > 0 {
+		for _, p := range processes {
+			services = append(services, fmt.Sprintf("`%s`: the Procfile process `%s`", p["name"], p["command"]))
+		}
+	} else if vars["hasCelery"] == true {
+		services = append(services, "`worker`: the Celery worker")
+		services = append(services, "`beat`: the Celery beat scheduler")
+	} else if queue, _ := vars["jobQueue"].(string); queue != "" {
+		services = append(services, fmt.Sprintf("`worker`: the %s worker (`%s`)", queue, vars["jobCommand"]))
+	}
+	if vars["hasCelery"] == true || vars["jobQueue"] != nil {
+		services = append(services, "`broker`: the message broker of the workers")
+	}
+	if command, _ := vars["releaseCommand"].(string); command != "" {
+		services = append(services, fmt.Sprintf("`release`: runs `%s` before the app starts", command))
+	} else if command, _ := vars["migrateCommand"].(string); command != "" {
+		services = append(services, fmt.Sprintf("`migrate`: runs `%s` before the app starts", command))
+	}
+	if jobs, _ := vars["setupJobs"].([]map[string]string); len(jobs) > 0 {
+		for _, j := range jobs {
+			services = append(services, fmt.Sprintf("`%s`: runs `%s` once, with `docker compose --profile setup up %s`", j["name"], j["command"], j["name"]))
+		}
+	}
+	if deps, _ := vars["deps"].([]detector.BackingService); len(deps) > 0 {
+		for _, s := range deps {
+			services = append(services, fmt.Sprintf("`%s`: %s for %s", s.Name, backingRoles[s.Name], strings.Join(s.SDKs, ", ")))
+			if len(s.Resources) > 0 {
+				services = append(services, fmt.Sprintf("`%s-init`: creates `%s`, then exits", s.Name, strings.Join(s.Resources, "`, `")))
+			}
+		}
+	}
+	if proxy, _ := vars["proxy"].(string); proxy != "" {
+		services = append(services, fmt.Sprintf("`proxy`: %s, terminating HTTPS for `DOMAIN`", proxyNames[proxy]))
+	}
+	return services
+}
+
+// backingRoles describe the --with-deps services
+var backingRoles = map[string]string{
+	"minio":    "S3-compatible object storage",
+	"redpanda": "a Kafka-compatible broker",
+	"nats":     "a NATS server with JetStream",
+}
+
+// stackNotes are the things to know about running the detected framework
+// in a container
+func stackNotes(vars map[string]interface{}) []string {
+	var notes []string
+	switch vars["framework"] {
+	case "nextjs":
+		notes = append(notes, "`NEXT_PUBLIC_*` variables are inlined when the image is built; set them as build arguments, not only in `.env`.")
+		if vars["standalone"] != true {
+			notes = append(notes, "Setting `output: 'standalone'` in `next.config.js` makes the image much smaller.")
+		}
+	case "spa", "vite", "cra", "angular", "vuecli", "gatsby":
+		notes = append(notes, "The app is built into static files served by nginx; environment variables are read at build time.")
+	case "django":
+		notes = append(notes, "Static files are collected into the image at build time.", "Set `SECRET_KEY`, `ALLOWED_HOSTS` and the database settings in `.env`.")
+	case "rails":
+		notes = append(notes, "Set `RAILS_MASTER_KEY` (from `config/master.key`) or `SECRET_KEY_BASE` in `.env`.")
+	case "laravel":
+		notes = append(notes, "Set `APP_KEY` in `.env` (`php artisan key:generate --show`).")
+	case "springboot":
+		notes = append(notes, "The health check probes `/actuator/health` (add `spring-boot-starter-actuator`).")
+	case "play":
+		notes = append(notes, "Set `APPLICATION_SECRET` in `.env` (`sbt playGenerateSecret`); Play refuses to start in production without a secret key.",
+			"Play only accepts requests for `localhost` by default; list the app's domain in `play.filters.hosts.allowed`.")
+	}
+	switch vars["language"] {
+	case "go", "rust":
+		notes = append(notes, "The app compiles to a single binary; the final image holds only the binary.")
+	}
+	if vars["noShell"] == true {
+		notes = append(notes, "The final image has no shell, so `docker exec ... sh` does not work; debug with `docker debug` or a build stage.")
+	}
+	if websocket, _ := vars["websocket"].(string); websocket != "" {
+		notes = append(notes, fmt.Sprintf("%s keeps connections open: the app gets 30s to close them when it stops.", websocket))
+	}
+	return notes
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestDocs(t *testing.T) {
+	result := &detector.DetectionResult{
+		Detected:  true,
+		Language:  "python",
+		Framework: "django",
+		Template:  "python/django.tmpl",
+		Variables: map[string]interface{}{"packageManager": "pip", "projectName": "mysite", "port": "8000", "pythonVersion": "3.12", "entrypointMigrate": "python manage.py migrate --noinput"},
+	}
+	output, err := New(WithDocs(true), WithOutputSubdir("docker"), WithProxy("caddy")).Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	docs := output.Files[docsName].String()
+	for _, want := range []string{
+		"| `docker-entrypoint.sh` |",
+		"| `Dockerfile.dockerignore` |",
+		"docker build -f ../docker/Dockerfile -t app ..\n",
+		"`proxy`: Caddy",
+		"`RUN_MIGRATIONS=true` runs `python manage.py migrate --noinput`",
+		"Static files are collected",
+	} {
+		if !strings.Contains(docs, want) {
+			t.Errorf("DOCKER.md missing %q:\n%s", want, docs)
+		}
+	}
+
+	// Only DOCKER.md: it still describes the files of a full run
+	output, err = New(WithDocs(true), WithDockerfile(false), WithCompose(false), WithIgnore(false), WithEnv(false)).Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate(docs only) error = %v", err)
+	}
+	if len(output.Files) != 1 {
+		t.Errorf("docs only generated %v", keys(output.Files))
+	}
+	if docs := output.Docs; !strings.Contains(docs, "docker compose up --build") || !strings.Contains(docs, "docker build -t app .\n") {
+		t.Errorf("docs only DOCKER.md lacks the commands:\n%s", docs)
+	}
+}
@@ -0,0 +1,192 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Environment configures a docker-compose.<name>.yml override for one
+// deployment environment. Zero fields take the environment's defaults.
+type Environment struct {
+	Name              string
+	Restart           string            // Restart policy, e.g. "no" or "always"
+	MemoryLimit       string            // Memory limit, e.g. "1G"
+	MemoryReservation string            // Memory reservation, e.g. "256M"
+	CPUs              string            // CPU limit, e.g. "1.5"
+	DebugPort         int               // Published debugger port; -1 disables the language default
+	MountSource       *bool             // Bind-mount the source for live edits
+	Env               map[string]string // Extra environment variables
+}
+
+// envDefaults are the built-in environments; others start from staging
+var envDefaults = map[string]Environment{
+	"dev":     {Restart: "no", MemoryLimit: "2G", MemoryReservation: "256M"},
+	"staging": {Restart: "unless-stopped", MemoryLimit: "512M", MemoryReservation: "256M", DebugPort: -1},
+	"prod":    {Restart: "always", MemoryLimit: "1G", MemoryReservation: "512M", DebugPort: -1},
+}
+
+// EnvironmentNames are the built-in environment names
+func EnvironmentNames() []string {
+	names := make([]string, 0, len(envDefaults))
+	for name := range envDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var validEnvName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// resolve fills unset fields from the environment's defaults
+func (e Environment) resolve() Environment {
+	d, ok := envDefaults[e.Name]
+	if !ok {
+		d = envDefaults["staging"]
+	}
+	if e.Restart == "" {
+		e.Restart = d.Restart
+	}
+	if e.MemoryLimit == "" {
+		e.MemoryLimit = d.MemoryLimit
+	}
+	if e.MemoryReservation == "" {
+		e.MemoryReservation = d.MemoryReservation
+	}
+	if e.DebugPort == 0 {
+		e.DebugPort = d.DebugPort
+	}
+	if e.MountSource == nil {
+		mount := e.Name == "dev"
+		e.MountSource = &mount
+	}
+	return e
+}
+
+// debugger is how a runtime is started with a remote debugger listening
+type debugger struct {
+	port int
+	env  string // Enables the debugger on port %d
+	name string
+}
+
+// debuggerFor returns the debugger a stack can enable through the
+// environment alone, or nil when it needs extra tooling in the image
+func debuggerFor(vars map[string]interface{}) *debugger {
+	switch vars["language"] {
+	case "nodejs":
+		if vars["staticServer"] == nil {
+			return &debugger{port: 9229, env: "NODE_OPTIONS=--inspect=0.0.0.0:%d", name: "Node.js inspector"}
+		}
+	case "java":
+		if vars["native"] != true {
+			return &debugger{port: 5005, env: "JAVA_TOOL_OPTIONS=-agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=*:%d", name: "JDWP"}
+		}
+	}
+	return nil
+}
+
+// sourceMountExcludes returns the image directories a source bind mount must
+// not hide, and false when the stack runs build output rather than source
+func sourceMountExcludes(vars map[string]interface{}) ([]string, bool) {
+	if vars["workspace"] == true {
+		return nil, false // The build context is the monorepo root
+	}
+	switch vars["language"] {
+	case "python":
+		return []string{".venv"}, true
+	case "php":
+		return []string{"vendor"}, true
+	case "ruby":
+		return nil, true
+	case "nodejs":
+		// Only plain JavaScript servers run their source as is
+		switch vars["framework"] {
+		case "express", "fastify", "koa", "hono":
+			if vars["buildScript"] == nil && vars["typescript"] != true {
+				return []string{"node_modules"}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// generateEnvironment renders the compose override for one environment
+func (g *generator) generateEnvironment(env Environment, vars map[string]interface{}) (string, error) {
+	if !validEnvName.MatchString(env.Name) {
+		return "", fmt.Errorf("invalid environment name %q", env.Name)
+	}
+	env = env.resolve()
+
+	envVars := []string{"APP_ENV=" + env.Name}
+	var keys []string
+	for k := range env.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		envVars = append(envVars, k+"="+strings.ReplaceAll(env.Env[k], "$", "$$"))
+	}
+
+	data := map[string]interface{}{
+		"name":              env.Name,
+		"restart":           env.Restart,
+		"memoryLimit":       env.MemoryLimit,
+		"memoryReservation": env.MemoryReservation,
+		"cpus":              env.CPUs,
+	}
+
+	if dbg := debuggerFor(vars); dbg != nil && env.DebugPort >= 0 {
+		port := dbg.port
+		if env.DebugPort > 0 {
+			port = env.DebugPort
+		}
+		envVars = append(envVars, fmt.Sprintf(dbg.env, port))
+		data["debugPort"] = port
+		data["debugName"] = dbg.name
+	}
+
+	if *env.MountSource {
+		if excludes, ok := sourceMountExcludes(vars); ok {
+			data["mountSource"] = true
+			data["mountExcludes"] = excludes
+		}
+	}
+	data["env"] = envVars
+	data["composeSource"] = vars["composeSource"]
+
+	return g.executeTemplate(composeEnvTemplate, data)
+}
+
+// composeEnvTemplate overrides the base docker-compose.yml for one environment
+const composeEnvTemplate = `# Docker Compose overrides: {{.name}}
+# Generated by Dublyo Dockerizer
+# https://github.com/dublyo/dockerizer
+#
+# Usage: docker compose -f docker-compose.yml -f docker-compose.{{.name}}.yml up -d
+
+services:
+  app:
+    restart: {{if eq .restart "no"}}"no"{{else}}{{.restart}}{{end}}
+    environment:{{range .env}}
+      - {{.}}{{end}}
+{{- if .debugPort}}
+    ports:
+      # {{.debugName}} (localhost only)
+      - "127.0.0.1:${DEBUG_PORT:-{{.debugPort}}}:{{.debugPort}}"
+{{- end}}
+{{- if .mountSource}}
+    volumes:
+      # Source bind mount for live edits
+      - {{.composeSource}}/:/app{{range .mountExcludes}}
+      - /app/{{.}}  # Keep the image's {{.}}{{end}}
+{{- end}}
+    deploy:
+      resources:
+        limits:
+          memory: {{.memoryLimit}}{{if .cpus}}
+          cpus: "{{.cpus}}"{{end}}
+        reservations:
+          memory: {{.memoryReservation}}
+`
@@ -0,0 +1,306 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"gopkg.in/yaml.v3"
+)
+
+// templateFuncs returns the function library available to all templates,
+// including external templates loaded via WithProviderPath. Names and
+// argument order follow sprig, the string last so that it pipes, so
+// existing Helm-style snippets work unchanged. env reads the variables the
+// project declares in vars, not the environment the generator runs in, so
+// the generated files stay the same wherever they are generated.
+func templateFuncs(vars map[string]interface{}) template.FuncMap {
+	return template.FuncMap{
+		// Defaults and conditionals
+		"default": func(def, val interface{}) interface{} {
+			if val == nil || val == "" {
+				return def
+			}
+			return val
+		},
+		"empty":    isEmpty,
+		"coalesce": coalesce,
+		"ternary": func(trueVal, falseVal interface{}, cond bool) interface{} {
+			if cond {
+				return trueVal
+			}
+			return falseVal
+		},
+
+		// Strings
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"title": func(s string) string {
+			if len(s) == 0 {
+				return s
+			}
+			return strings.ToUpper(s[:1]) + s[1:]
+		},
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, repl, s string) string { return strings.ReplaceAll(s, old, repl) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"quote":      func(s interface{}) string { return strconv.Quote(fmt.Sprint(s)) },
+		"squote":     func(s interface{}) string { return "'" + fmt.Sprint(s) + "'" },
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       join,
+
+		// Lists
+		"list": func(items ...interface{}) []interface{} { return items },
+		"has":  has,
+
+		// Serialization
+		"toJson": toJSON,
+		"toYaml": toYAML,
+
+		// Environment
+		"env": func(name string) string { return projectEnv(vars, name) },
+
+		// Versions
+		"semverCompare": semverCompare,
+	}
+}
+
+// projectEnv returns the default the project gives an environment variable
+// (its inline fallback in the source, or its value in app.json), or "" for a
+// variable without one
+func projectEnv(vars map[string]interface{}, name string) string {
+	envVars, _ := vars["envVars"].([]scanner.EnvVar)
+	for _, v := range envVars {
+		if v.Name == name {
+			return v.Default
+		}
+	}
+	return ""
+}
+
+// isEmpty reports whether a value is nil, zero, or an empty collection
+func isEmpty(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int:
+		return v == 0
+	case float64:
+		return v == 0
+	case []string:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// coalesce returns the first non-empty value
+func coalesce(vals ...interface{}) interface{} {
+	for _, v := range vals {
+		if !isEmpty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// indent prefixes every line of s with the given number of spaces
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// join concatenates a list of strings or values with a separator
+func join(sep string, list interface{}) string {
+	switch v := list.(type) {
+	case []string:
+		return strings.Join(v, sep)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, sep)
+	}
+	return fmt.Sprint(list)
+}
+
+// has reports whether needle is an element of list
+func has(needle interface{}, list interface{}) bool {
+	switch v := list.(type) {
+	case []string:
+		for _, item := range v {
+			if item == fmt.Sprint(needle) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if item == needle {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// toJSON marshals a value to compact JSON
+func toJSON(val interface{}) string {
+	// Output goes into Dockerfiles and YAML, so keep &, < and > readable
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// toYAML marshals a value to YAML without the trailing newline
+func toYAML(val interface{}) string {
+	data, err := yaml.Marshal(val)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// semverCompare checks a version against a constraint such as ">=18", "<3.12" or "~1.21".
+// Multiple constraints may be combined with commas, e.g. ">=3.9, <3.13".
+func semverCompare(constraint, version string) (bool, error) {
+	for _, c := range strings.Split(constraint, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+
+		op := ""
+		for _, candidate := range []string{">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+			if strings.HasPrefix(c, candidate) {
+				op = candidate
+				break
+			}
+		}
+		target := strings.TrimSpace(strings.TrimPrefix(c, op))
+
+		cmp, err := compareVersions(version, target)
+		if err != nil {
+			return false, err
+		}
+
+		var ok bool
+		switch op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "!=":
+			ok = cmp != 0
+		case "~":
+			// Same major.minor, at least the given patch
+			ok = cmp >= 0 && samePrefix(version, target, 2)
+		case "^":
+			// Same major, at least the given version
+			ok = cmp >= 0 && samePrefix(version, target, 1)
+		default:
+			ok = cmp == 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareVersions compares dotted numeric versions, treating missing parts as zero
+func compareVersions(a, b string) (int, error) {
+	pa, err := parseVersionParts(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseVersionParts(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// parseVersionParts parses "v1.21.3" or "20-alpine" into numeric components
+func parseVersionParts(v string) ([]int, error) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+ "); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" {
+		return nil, fmt.Errorf("invalid version: empty")
+	}
+
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		if p == "x" || p == "*" {
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", v, err)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
+
+// samePrefix reports whether two versions share their first n components
+func samePrefix(a, b string, n int) bool {
+	pa, _ := parseVersionParts(a)
+	pb, _ := parseVersionParts(b)
+	for i := 0; i < n; i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+func TestSemverCompare(t *testing.T) {
+	cases := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=18", "20", true},
+		{">=18", "16.20.1", false},
+		{"<3.12", "3.11", true},
+		{">=3.9, <3.13", "3.13.0", false},
+		{"~1.21", "1.21.5", true},
+		{"~1.21", "1.22.0", false},
+		{"^8", "8.3.1", true},
+		{"^8", "9.0", false},
+		{"20", "v20.0.0", true},
+		{">=17", "21-alpine", true},
+	}
+
+	for _, tc := range cases {
+		got, err := semverCompare(tc.constraint, tc.version)
+		if err != nil {
+			t.Fatalf("semverCompare(%q, %q) returned error: %v", tc.constraint, tc.version, err)
+		}
+		if got != tc.want {
+			t.Errorf("semverCompare(%q, %q) = %v, want %v", tc.constraint, tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestExecuteTemplateFuncs(t *testing.T) {
+	g := &generator{}
+	out, err := g.executeTemplate(`{{ternary "yes" "no" (hasPrefix "1." .v)}}|{{quote .v}}|{{indent 2 "a\nb"}}|{{toJson .list}}|{{.v | trimSuffix ".21" | replace "1" "one"}}`, map[string]interface{}{
+		"v":    "1.21",
+		"list": []string{"x", "y"},
+	})
+	if err != nil {
+		t.Fatalf("executeTemplate failed: %v", err)
+	}
+	want := `yes|"1.21"|  a
+  b|["x","y"]|one`
+	if out != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestEnvTemplateFunc(t *testing.T) {
+	t.Setenv("PORT", "9999") // The generator's own environment is not read
+	g := &generator{}
+	out, err := g.executeTemplate(`{{env "PORT"}}|{{env "LOG_LEVEL" | default "info"}}|{{env "DATABASE_URL"}}`, map[string]interface{}{
+		"envVars": []scanner.EnvVar{
+			{Name: "PORT", Default: "3000", File: "index.js"},
+			{Name: "DATABASE_URL", File: "db.js"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("executeTemplate failed: %v", err)
+	}
+	if want := "3000|info|"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,158 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestGoBaseImage(t *testing.T) {
+	result := func() *detector.DetectionResult {
+		return &detector.DetectionResult{
+			Language:  "go",
+			Framework: "gin",
+			Template:  "go/gin.tmpl",
+			Variables: map[string]interface{}{"goVersion": "1.22", "port": "8080", "goBaseImage": "alpine"},
+		}
+	}
+	out, err := New(WithGoBaseImage("distroless")).Generate(result(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Dockerfile, "gcr.io/distroless/") {
+		t.Errorf("no distroless final stage:\n%s", out.Dockerfile)
+	}
+	if _, err := New(WithGoBaseImage("distroles")).Generate(result(), ""); err == nil {
+		t.Error("an unknown base image should be an error")
+	}
+}
+
+func TestCargoChef(t *testing.T) {
+	result := func() *detector.DetectionResult {
+		return &detector.DetectionResult{
+			Language:  "rust",
+			Framework: "axum",
+			Template:  "rust/axum.tmpl",
+			Variables: map[string]interface{}{"rustVersion": "1.75", "binaryName": "app", "port": "3000", "cargoChef": true},
+		}
+	}
+	out, err := New(WithCargoChef("false")).Generate(result(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.Dockerfile, "cargo chef") {
+		t.Errorf("cargo-chef used with WithCargoChef(\"false\"):\n%s", out.Dockerfile)
+	}
+	if _, err := New(WithCargoChef("no")).Generate(result(), ""); err == nil {
+		t.Error("an unknown cargo-chef setting should be an error")
+	}
+}
+
+func TestStaticSite(t *testing.T) {
+	result := func() *detector.DetectionResult {
+		return &detector.DetectionResult{
+			Language:  "nodejs",
+			Framework: "vite",
+			Template:  "nodejs/spa.tmpl",
+			Variables: map[string]interface{}{
+				"nodeVersion": "20", "packageManager": "npm", "hasLockFile": true, "framework": "vite",
+				"buildCommand": "npm run build", "outputDir": "dist", "basePath": "/", "staticServer": "nginx", "port": "8080",
+			},
+		}
+	}
+	out, err := New(WithStaticServer("caddy"), WithBasePath("app")).Generate(result(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"FROM caddy:", "RUN npm run build -- --base=/app/\n", "/srv/app/"} {
+		if !strings.Contains(out.Dockerfile, want) {
+			t.Errorf("Dockerfile has no %q:\n%s", want, out.Dockerfile)
+		}
+	}
+
+	for _, opt := range []Option{WithStaticServer("apache"), WithBasePath("/app/'; rm -rf /")} {
+		if _, err := New(opt).Generate(result(), ""); err == nil {
+			t.Error("an unknown static server or unsafe base path should be an error")
+		}
+	}
+}
+
+// expressResult is a Node app, which settings for other languages leave alone
+func expressResult() *detector.DetectionResult {
+	return &detector.DetectionResult{
+		Language:  "nodejs",
+		Framework: "express",
+		Template:  "nodejs/express.tmpl",
+		Variables: map[string]interface{}{"nodeVersion": "20", "packageManager": "npm", "hasLockFile": true, "port": "3000", "mainFile": "index.js"},
+	}
+}
+
+func TestWindows(t *testing.T) {
+	aspnet := &detector.DetectionResult{
+		Language:  "dotnet",
+		Framework: "aspnet",
+		Template:  "dotnet/aspnet.tmpl",
+		Variables: map[string]interface{}{"dotnetVersion": "8.0", "projectFile": "App.csproj", "projectName": "App", "port": "8080"},
+	}
+	out, err := New(WithDefaultWindows("nanoserver")).Generate(aspnet, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Dockerfile, "nanoserver-ltsc2022") {
+		t.Errorf("no Windows base image:\n%s", out.Dockerfile)
+	}
+
+	if _, err := New(WithDefaultWindows("servercore")).Generate(expressResult(), ""); err != nil {
+		t.Errorf("the configured Windows base should be ignored for a Node app: %v", err)
+	}
+	if _, err := New(WithWindows("servercore", "")).Generate(expressResult(), ""); err == nil {
+		t.Error("--windows for a Node app should be an error")
+	}
+}
+
+func TestGPU(t *testing.T) {
+	fastapi := &detector.DetectionResult{
+		Language:  "python",
+		Framework: "fastapi",
+		Template:  "python/fastapi.tmpl",
+		Variables: map[string]interface{}{"pythonVersion": "3.12", "hasRequirements": true, "mainFile": "main.py", "moduleName": "main", "port": "8000", "mlFramework": "pytorch"},
+	}
+	out, err := New(WithDefaultGPU("cuda")).Generate(fastapi, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Dockerfile, "nvidia/cuda:") {
+		t.Errorf("no CUDA base image:\n%s", out.Dockerfile)
+	}
+
+	if _, err := New(WithDefaultGPU("cuda")).Generate(expressResult(), ""); err != nil {
+		t.Errorf("the configured GPU mode should be ignored for a Node app: %v", err)
+	}
+	if _, err := New(WithGPU("cuda", "")).Generate(expressResult(), ""); err == nil {
+		t.Error("--gpu for a Node app should be an error")
+	}
+}
+
+func TestJavaRuntime(t *testing.T) {
+	spring := &detector.DetectionResult{
+		Language:  "java",
+		Framework: "springboot",
+		Template:  "java/springboot.tmpl",
+		Variables: map[string]interface{}{"javaVersion": "21", "buildTool": "maven", "hasWrapper": true, "port": "8080", "springBootVersion": "3.3.0"},
+	}
+	out, err := New(WithDefaultJavaRuntime("layered")).Generate(spring, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Dockerfile, "org.springframework.boot.loader.launch.JarLauncher") {
+		t.Errorf("no layered runtime:\n%s", out.Dockerfile)
+	}
+
+	if _, err := New(WithDefaultJavaRuntime("jlink")).Generate(expressResult(), ""); err != nil {
+		t.Errorf("the configured Java runtime should be ignored for a Node app: %v", err)
+	}
+	if _, err := New(WithJavaRuntime("jlink")).Generate(expressResult(), ""); err == nil {
+		t.Error("--java-runtime for a Node app should be an error")
+	}
+}
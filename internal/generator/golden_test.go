@@ -0,0 +1,318 @@
+package generator
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenCase renders one template with a variable set like the one its
+// provider produces
+type goldenCase struct {
+	name      string
+	template  string
+	language  string
+	framework string
+	vars      map[string]interface{}
+}
+
+// with returns a copy of base with extra merged on top
+func with(base map[string]interface{}, extra map[string]interface{}) map[string]interface{} {
+	vars := make(map[string]interface{}, len(base)+len(extra))
+	for k, v := range base {
+		vars[k] = v
+	}
+	for k, v := range extra {
+		vars[k] = v
+	}
+	return vars
+}
+
+func goldenCases() []goldenCase {
+	node := map[string]interface{}{"nodeVersion": "20", "packageManager": "npm", "hasLockFile": true, "port": "3000"}
+	python := map[string]interface{}{"pythonVersion": "3.12", "packageManager": "pip", "port": "8000"}
+	goVars := map[string]interface{}{"goVersion": "1.22", "moduleName": "example.com/app", "mainPath": ".", "port": "8080", "goBaseImage": "alpine"}
+	rust := map[string]interface{}{"rustVersion": "1.79", "projectName": "app", "binaryName": "app", "port": "8080"}
+	ruby := map[string]interface{}{"rubyVersion": "3.3", "hasLockFile": true}
+	php := map[string]interface{}{"phpVersion": "8.3", "hasLockFile": true, "port": "8000"}
+	java := map[string]interface{}{"javaVersion": "21", "buildTool": "maven", "hasWrapper": true, "port": "8080"}
+
+	cases := []goldenCase{
+		{"deno", "deno/deno.tmpl", "deno", "deno", map[string]interface{}{"denoVersion": "1.45", "entrypoint": "main.ts", "ts": true, "permissions": []string{"--allow-net", "--allow-env"}, "hasLockFile": true, "port": "8000"}},
+		{"deno-fresh-compile", "deno/deno.tmpl", "deno", "fresh", map[string]interface{}{"denoVersion": "1.45", "entrypoint": "main.ts", "ts": true, "fresh": true, "compile": true, "buildTask": "build", "permissions": []string{"-A"}, "port": "8000", "noShell": true}},
+		{"bun", "bun/bun.tmpl", "bun", "bun", map[string]interface{}{"bunVersion": "1", "entrypoint": "index.ts", "ts": true, "hasLockFile": true, "lockFile": "bun.lockb", "hasStartScript": true, "port": "3000"}},
+		{"bun-build-js", "bun/bun.tmpl", "bun", "bun", map[string]interface{}{"bunVersion": "1", "entrypoint": "index.js", "hasBuildScript": true, "port": "3000"}},
+
+		{"spa-vite", "nodejs/spa.tmpl", "nodejs", "vite", with(node, map[string]interface{}{"framework": "vite", "buildCommand": "npm run build", "outputDir": "dist", "staticServer": "nginx", "port": "80"})},
+		{"workspace-turbo-pnpm", "nodejs/workspace.tmpl", "nodejs", "nextjs", with(node, map[string]interface{}{"packageManager": "pnpm", "workspace": true, "workspaceTool": "pnpm", "turbo": true, "nx": false, "appDir": "apps/web", "appPackage": "web", "appHasBuild": true, "appHasStart": true, "framework": "nextjs", "standalone": true, "lockFile": "pnpm-lock.yaml", "pnpmVersion": "9.1.0", "turboVersion": "2", "buildContext": "../..", "dockerfilePath": "apps/web/Dockerfile"})},
+		{"node-generic", "nodejs/node.tmpl", "nodejs", "node", with(node, map[string]interface{}{"mainFile": "index.js", "startScript": "node index.js"})},
+		{"nuxt", "nodejs/nuxt.tmpl", "nodejs", "nuxt", with(node, map[string]interface{}{"hasServer": true, "nuxtVersion": "3", "typescript": true})},
+		{"nestjs", "nodejs/nestjs.tmpl", "nodejs", "nestjs", with(node, map[string]interface{}{"typescript": true, "mainFile": "dist/main.js", "buildScript": "nest build", "platform": "express"})},
+		{"remix", "nodejs/remix.tmpl", "nodejs", "remix", with(node, map[string]interface{}{"typescript": true, "usesVite": true, "hasPublicDir": true})},
+		{"astro-server", "nodejs/astro.tmpl", "nodejs", "astro", with(node, map[string]interface{}{"outputMode": "server", "hasNodeAdapter": true, "port": "4321"})},
+		{"astro-static", "nodejs/astro.tmpl", "nodejs", "astro", with(node, map[string]interface{}{"outputMode": "static", "port": "8080"})},
+		{"sveltekit", "nodejs/sveltekit.tmpl", "nodejs", "sveltekit", with(node, map[string]interface{}{"adapter": "node", "typescript": true})},
+		{"hono-node", "nodejs/hono.tmpl", "nodejs", "hono", with(node, map[string]interface{}{"runtime": "node", "hasNodeAdapter": true, "typescript": true, "mainEntry": "src/index.ts"})},
+		{"hono-bun", "nodejs/hono.tmpl", "nodejs", "hono", with(node, map[string]interface{}{"runtime": "bun", "packageManager": "bun", "typescript": true, "mainEntry": "src/index.ts"})},
+		{"koa", "nodejs/koa.tmpl", "nodejs", "koa", with(node, map[string]interface{}{"mainEntry": "app.js", "hasStartScript": true})},
+
+		{"django", "python/django.tmpl", "python", "django", with(python, map[string]interface{}{"projectName": "mysite", "wsgiServer": "gunicorn", "hasStatic": true, "entrypointMigrate": "python manage.py migrate --noinput"})},
+		{"django-celery-uv", "python/django.tmpl", "python", "django", with(python, map[string]interface{}{"packageManager": "uv", "projectVenv": true, "projectName": "mysite", "wsgiServer": "gunicorn", "hasCelery": true, "celeryApp": "mysite", "celeryBroker": "redis"})},
+		{"fastapi-uv", "python/fastapi.tmpl", "python", "fastapi", with(python, map[string]interface{}{"packageManager": "uv", "projectVenv": true, "mainFile": "main.py", "moduleName": "main"})},
+		{"django-channels-daphne", "python/django.tmpl", "python", "django", with(python, map[string]interface{}{"projectName": "mysite", "wsgiServer": "daphne", "websocket": "Django Channels"})},
+		{"django-torch-cuda", "python/django.tmpl", "python", "django", with(python, map[string]interface{}{"projectName": "mysite", "wsgiServer": "gunicorn", "gpu": "cuda", "mlFramework": "pytorch"})},
+		{"fastapi-uv-cuda", "python/fastapi.tmpl", "python", "fastapi", with(python, map[string]interface{}{"packageManager": "uv", "projectVenv": true, "mainFile": "main.py", "moduleName": "main", "gpu": "cuda", "mlFramework": "tensorflow"})},
+		{"python-torch-cpu", "python/python.tmpl", "python", "python", with(python, map[string]interface{}{"hasRequirements": true, "mainFile": "main.py", "gpu": "cpu", "mlFramework": "pytorch"})},
+		{"python-generic", "python/python.tmpl", "python", "python", with(python, map[string]interface{}{"hasRequirements": true, "mainFile": "main.py"})},
+
+		{"gin", "go/gin.tmpl", "go", "gin", goVars},
+		{"gin-distroless", "go/gin.tmpl", "go", "gin", with(goVars, map[string]interface{}{"goBaseImage": "distroless"})},
+		{"fiber", "go/fiber.tmpl", "go", "fiber", with(goVars, map[string]interface{}{"port": "3000"})},
+		{"echo", "go/echo.tmpl", "go", "echo", goVars},
+		{"go-standard-scratch", "go/standard.tmpl", "go", "standard", with(goVars, map[string]interface{}{"goBaseImage": "scratch", "needsTzdata": true})},
+		{"go-generic-cgo", "go/generic.tmpl", "go", "go", with(goVars, map[string]interface{}{"cgo": true, "cgoReason": "github.com/mattn/go-sqlite3"})},
+
+		{"actix", "rust/actix.tmpl", "rust", "actix", rust},
+		{"axum-workspace", "rust/axum.tmpl", "rust", "axum", with(rust, map[string]interface{}{"workspace": true, "cargoPackage": "server", "binaryName": "server", "cargoChef": true, "port": "3000"})},
+
+		{"rails", "ruby/rails.tmpl", "ruby", "rails", with(ruby, map[string]interface{}{"database": "postgresql", "hasAssets": true, "railsVersion": "7.1", "port": "3000", "entrypointMigrate": "./bin/rails db:prepare"})},
+		{"rails-api", "ruby/rails.tmpl", "ruby", "rails", with(ruby, map[string]interface{}{"database": "sqlite3", "apiOnly": true, "port": "3000", "entrypointMigrate": "./bin/rails db:prepare"})},
+		{"sinatra", "ruby/sinatra.tmpl", "ruby", "sinatra", with(ruby, map[string]interface{}{"puma": true, "rackup": true, "port": "4567"})},
+		{"hanami", "ruby/hanami.tmpl", "ruby", "hanami", with(ruby, map[string]interface{}{"hasAssets": true, "hasPackageLock": true, "database": "postgresql", "port": "2300", "entrypointMigrate": "bundle exec hanami db migrate"})},
+
+		{"laravel-vite", "php/laravel.tmpl", "php", "laravel", with(php, map[string]interface{}{"hasVite": true, "database": "mysql", "entrypointMigrate": "php artisan migrate --force"})},
+		{"laravel-octane", "php/laravel.tmpl", "php", "laravel", with(php, map[string]interface{}{"hasOctane": true, "octaneServer": "frankenphp", "frankenphp": true, "workers": "auto", "maxRequests": "500"})},
+		{"symfony", "php/symfony.tmpl", "php", "symfony", with(php, map[string]interface{}{"hasSymfonyLock": true, "hasEncore": true, "hasDoctrine": true})},
+
+		{"springboot-maven", "java/springboot.tmpl", "java", "springboot", java},
+		{"springboot-gradle", "java/springboot.tmpl", "java", "springboot", with(java, map[string]interface{}{"buildTool": "gradle", "hasWrapper": false})},
+		{"quarkus", "java/quarkus.tmpl", "java", "quarkus", java},
+		{"quarkus-native", "java/quarkus.tmpl", "java", "quarkus", with(java, map[string]interface{}{"native": true})},
+		{"micronaut-gradle", "java/micronaut.tmpl", "java", "micronaut", with(java, map[string]interface{}{"buildTool": "gradle", "hasShadow": true, "hasManagement": true})},
+		{"java-generic", "java/java.tmpl", "java", "java", with(java, map[string]interface{}{"buildTool": "gradle"})},
+		{"ktor", "java/ktor.tmpl", "java", "ktor", with(java, map[string]interface{}{"buildTool": "gradle"})},
+
+		{"play", "scala/play.tmpl", "scala", "play", map[string]interface{}{"javaVersion": "21", "sbtVersion": "1.10.7", "playVersion": "3.0.5", "port": "9000"}},
+
+		{"aspnet", "dotnet/aspnet.tmpl", "dotnet", "aspnet", map[string]interface{}{"dotnetVersion": "8.0", "projectFile": "App.csproj", "projectName": "App", "port": "8080"}},
+		{"aspnet-solution-ef", "dotnet/aspnet.tmpl", "dotnet", "aspnet", map[string]interface{}{"dotnetVersion": "8.0", "solutionFile": "App.sln", "projectFile": "src/Web/Web.csproj", "projectName": "Web", "hasDirectoryBuildProps": true, "hasDirectoryPackagesProps": true, "hasEF": true, "efBundle": true, "entrypointMigrate": "./efbundle", "port": "8080"}},
+		{"aspnet-windows-ef", "dotnet/aspnet-windows.tmpl", "dotnet", "aspnet", map[string]interface{}{"dotnetVersion": "8.0", "projectFile": "App.csproj", "projectName": "App", "hasEF": true, "efBundle": true, "entrypointMigrate": "./efbundle", "windows": "servercore", "port": "8080"}},
+		{"phoenix", "elixir/phoenix.tmpl", "elixir", "phoenix", map[string]interface{}{"appName": "my_app", "elixirVersion": "1.16", "erlangVersion": "26", "hasAssets": true, "hasEcto": true, "port": "4000"}},
+	}
+
+	// Every Node.js package manager for the templates that branch on it
+	for _, pm := range []string{"npm", "pnpm", "yarn", "bun"} {
+		cases = append(cases,
+			goldenCase{"nextjs-standalone-" + pm, "nodejs/nextjs.tmpl", "nodejs", "nextjs", with(node, map[string]interface{}{"packageManager": pm, "standalone": true, "hasPublicDir": true, "typescript": true})},
+			goldenCase{"express-js-" + pm, "nodejs/express.tmpl", "nodejs", "express", with(node, map[string]interface{}{"packageManager": pm, "mainFile": "index.js"})},
+		)
+	}
+	cases = append(cases,
+		goldenCase{"nextjs-server-nolock", "nodejs/nextjs.tmpl", "nodejs", "nextjs", with(node, map[string]interface{}{"hasLockFile": false})},
+		goldenCase{"express-ts", "nodejs/express.tmpl", "nodejs", "express", with(node, map[string]interface{}{"typescript": true, "mainFile": "dist/index.js", "buildScript": "tsc"})},
+		goldenCase{"fastify-ts-prisma", "nodejs/fastify.tmpl", "nodejs", "fastify", with(node, map[string]interface{}{"typescript": true, "mainFile": "dist/app.js", "buildScript": "tsc", "orm": "prisma", "prismaGenerate": true, "prismaSchema": "prisma/schema.prisma"})},
+		goldenCase{"fastify-js-yarn", "nodejs/fastify.tmpl", "nodejs", "fastify", with(node, map[string]interface{}{"packageManager": "yarn", "mainFile": "app.js"})},
+	)
+
+	// Root install scripts that need the source, run after it is copied
+	cases = append(cases,
+		goldenCase{"express-ts-install-scripts", "nodejs/express.tmpl", "nodejs", "express", with(node, map[string]interface{}{"typescript": true, "mainFile": "dist/index.js", "buildScript": "tsc", "ignoreScripts": true, "installScripts": []string{"postinstall"}, "patchPackage": true})},
+		goldenCase{"express-js-install-scripts", "nodejs/express.tmpl", "nodejs", "express", with(node, map[string]interface{}{"mainFile": "index.js", "ignoreScripts": true, "installScripts": []string{"prepare"}})},
+	)
+	for _, pm := range []string{"pip", "poetry", "pipenv", "uv"} {
+		cases = append(cases, goldenCase{"flask-" + pm, "python/flask.tmpl", "python", "flask", with(python, map[string]interface{}{"packageManager": pm, "projectVenv": pm == "uv", "mainFile": "app.py", "moduleName": "app", "wsgiServer": "gunicorn", "port": "5000"})})
+	}
+
+	// BuildKit cache mounts
+	for _, pm := range []string{"npm", "pnpm", "yarn", "bun"} {
+		cases = append(cases, goldenCase{"express-js-" + pm + "-cache", "nodejs/express.tmpl", "nodejs", "express", with(node, map[string]interface{}{"packageManager": pm, "mainFile": "index.js", "cacheMounts": true})})
+	}
+	cases = append(cases,
+		goldenCase{"flask-pip-cache", "python/flask.tmpl", "python", "flask", with(python, map[string]interface{}{"mainFile": "app.py", "moduleName": "app", "wsgiServer": "gunicorn", "port": "5000", "cacheMounts": true})},
+		goldenCase{"gin-cache", "go/gin.tmpl", "go", "gin", with(goVars, map[string]interface{}{"cacheMounts": true})},
+		goldenCase{"axum-workspace-cache", "rust/axum.tmpl", "rust", "axum", with(rust, map[string]interface{}{"workspace": true, "cargoPackage": "server", "binaryName": "server", "cargoChef": true, "port": "3000", "cacheMounts": true})},
+	)
+
+	// Orchestrator build targets
+	cases = append(cases,
+		goldenCase{"nx-node-api", "nodejs/nx.tmpl", "nodejs", "node", map[string]interface{}{"nxProject": "api", "nxKind": "node", "nxOutputPath": "dist/apps/api", "nxMain": "main.js", "nxPackageJSON": true, "nxConfiguration": "production", "packageManager": "pnpm", "workspaceTool": "pnpm", "lockFile": "pnpm-lock.yaml", "hasLockFile": true, "port": "3000", "buildContext": "../..", "dockerfilePath": "apps/api/Dockerfile", "cacheMounts": true}},
+		goldenCase{"nx-static-web", "nodejs/nx.tmpl", "nodejs", "spa", map[string]interface{}{"nxProject": "web", "nxKind": "static", "nxOutputPath": "dist/apps/web", "packageManager": "npm", "workspaceTool": "npm", "lockFile": "package-lock.json", "hasLockFile": true, "port": "8080", "buildContext": "../..", "dockerfilePath": "apps/web/Dockerfile"}},
+		goldenCase{"bazel-go-binary", "bazel/binary.tmpl", "bazel", "go_binary", map[string]interface{}{"bazelTarget": "//services/api:server", "bazelOutput": "services/api/server", "bazelVersion": "7.4.1", "port": "8080", "noShell": true, "buildContext": "../..", "dockerfilePath": "services/api/Dockerfile", "cacheMounts": true}},
+		goldenCase{"bazel-java-binary", "bazel/binary.tmpl", "bazel", "java_binary", map[string]interface{}{"bazelTarget": "//:app_deploy.jar", "bazelOutput": "app_deploy.jar", "bazelJava": true, "port": "8080", "buildContext": ".", "dockerfilePath": "Dockerfile"}},
+		goldenCase{"springboot-gradle-module", "java/springboot.tmpl", "java", "springboot", with(java, map[string]interface{}{"buildTool": "gradle", "gradleProject": ":services:api", "gradleDir": "services/api", "cacheMounts": true})},
+	)
+
+	// Spring Boot runtimes: extracted jar layers, and a jlink runtime
+	cases = append(cases,
+		goldenCase{"springboot-layered", "java/springboot.tmpl", "java", "springboot", with(java, map[string]interface{}{"javaRuntime": "layered", "springBootVersion": "3.1.5"})},
+		goldenCase{"springboot-gradle-jlink", "java/springboot.tmpl", "java", "springboot", with(java, map[string]interface{}{"buildTool": "gradle", "hasWrapper": false, "javaVersion": "17", "javaRuntime": "jlink", "springBootVersion": "3.3.0"})},
+	)
+
+	// Build secrets for private package registries
+	npmrc := []scanner.BuildSecret{{ID: scanner.SecretNpmrc, File: ".npmrc", Credentials: true}}
+	pipIndex := []scanner.BuildSecret{{ID: scanner.SecretPipConf, File: "pip.conf", Credentials: true}, {ID: scanner.SecretNetrc}}
+	cases = append(cases,
+		goldenCase{"express-js-pnpm-npmrc", "nodejs/express.tmpl", "nodejs", "express", with(node, map[string]interface{}{"packageManager": "pnpm", "mainFile": "index.js", "cacheMounts": true, "buildSecrets": npmrc})},
+		goldenCase{"flask-pip-index", "python/flask.tmpl", "python", "flask", with(python, map[string]interface{}{"mainFile": "app.py", "moduleName": "app", "wsgiServer": "gunicorn", "port": "5000", "buildSecrets": pipIndex})},
+		goldenCase{"fastapi-uv-netrc", "python/fastapi.tmpl", "python", "fastapi", with(python, map[string]interface{}{"packageManager": "uv", "projectVenv": true, "mainFile": "main.py", "moduleName": "main", "buildSecrets": []scanner.BuildSecret{{ID: scanner.SecretNetrc}}})},
+		goldenCase{"gin-netrc", "go/gin.tmpl", "go", "gin", with(goVars, map[string]interface{}{"buildSecrets": []scanner.BuildSecret{{ID: scanner.SecretNetrc, File: ".netrc", Credentials: true}}})},
+	)
+	return cases
+}
+
+func TestGoldenDockerfiles(t *testing.T) {
+	g := New(WithCompose(false), WithIgnore(false), WithEnv(false))
+
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := g.Generate(&detector.DetectionResult{
+				Language:  tc.language,
+				Framework: tc.framework,
+				Template:  tc.template,
+				Variables: with(tc.vars, nil),
+			}, "")
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			for _, problem := range checkDockerfile(output.Dockerfile) {
+				t.Error(problem)
+			}
+
+			golden := filepath.Join("testdata", "golden", tc.name+".Dockerfile")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, []byte(output.Dockerfile), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run go test ./internal/generator -run Golden -update): %v", err)
+			}
+			if output.Dockerfile != string(want) {
+				t.Errorf("Dockerfile differs from %s (run with -update to accept):\n%s", golden, firstDiff(string(want), output.Dockerfile))
+			}
+
+			dockerBuildCheck(t, output)
+		})
+	}
+}
+
+// TestGoldenCoversAllTemplates keeps the golden cases in step with the
+// built-in templates
+func TestGoldenCoversAllTemplates(t *testing.T) {
+	covered := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, tc := range goldenCases() {
+		if names[tc.name] {
+			t.Errorf("duplicate golden case %q", tc.name)
+		}
+		names[tc.name] = true
+		covered[tc.template] = true
+	}
+
+	src, err := os.ReadFile("generator.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var missing []string
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, `"`) || !strings.Contains(line, `.tmpl":`) {
+			continue
+		}
+		path := line[1:strings.Index(line, `.tmpl":`)] + ".tmpl"
+		if HasTemplate(path) && !covered[path] {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("templates without a golden case: %s", strings.Join(missing, ", "))
+	}
+}
+
+// checkDockerfile parses a rendered Dockerfile and reports what would make
+// the build fail
+func checkDockerfile(content string) []string {
+	var problems []string
+	if strings.Contains(content, "<no value>") {
+		problems = append(problems, "rendered output contains <no value>")
+	}
+
+	df := dockerfile.Parse(content)
+	for _, e := range df.Errors {
+		problems = append(problems, e.Error())
+	}
+	for i, stage := range df.Stages {
+		if strings.HasPrefix(stage.Image, ":") || strings.HasSuffix(stage.Image, ":") {
+			problems = append(problems, "line "+strconv.Itoa(stage.From.StartLine)+": FROM without a complete image")
+		}
+		for _, in := range stage.Instructions {
+			from, ok := in.Flag("from")
+			if !ok || strings.ContainsAny(from, ":/") {
+				continue // Not copying from a stage
+			}
+			if s := df.Stage(from); s == nil || s.Index >= i {
+				problems = append(problems, "line "+strconv.Itoa(in.StartLine)+": COPY from unknown stage "+from)
+			}
+		}
+	}
+	return problems
+}
+
+// dockerBuildCheck runs the Dockerfile through `docker build --check` when a
+// docker daemon is available; the build context is a scratch directory
+// holding the generated files only
+func dockerBuildCheck(t *testing.T, output *Output) {
+	if os.Getenv("DOCKERIZER_DOCKER_CHECK") == "" {
+		return
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker not found")
+	}
+	dir := t.TempDir()
+	for name, f := range output.Files {
+		if err := os.WriteFile(filepath.Join(dir, name), f.Content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("docker", "build", "--check", dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("docker build --check failed: %v\n%s", err, out)
+	}
+}
+
+// firstDiff describes the first line where want and got differ
+func firstDiff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return "line " + strconv.Itoa(i+1) + ":\n  want: " + w + "\n  got:  " + g
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"path"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+)
+
+// WithHarden adds a hardening profile to the compose services that run the
+// app image: a read-only root filesystem, with the paths the app writes to
+// mounted, all capabilities dropped and no privilege escalation
+func WithHarden(harden bool) Option {
+	return func(g *generator) {
+		g.harden = harden
+	}
+}
+
+// WritablePath is a path the app writes to, which a read-only root
+// filesystem needs mounted
+type WritablePath struct {
+	Path   string // Absolute, or relative to the working directory of the final stage
+	Volume string // Named volume, seeded with the image's content; empty mounts a tmpfs
+	Reason string
+}
+
+// writablePaths are the paths frameworks write to at runtime, besides /tmp
+var writablePaths = map[string][]WritablePath{
+	"rails":   {{Path: "tmp", Reason: "pids and caches"}, {Path: "log", Reason: "log files, when not logging to stdout"}},
+	"hanami":  {{Path: "tmp", Reason: "pids and caches"}},
+	"sinatra": {{Path: "tmp", Reason: "pids"}},
+	"nextjs":  {{Path: ".next/cache", Reason: "image optimization and fetch caches"}},
+	"laravel": {
+		{Path: "storage", Volume: "app-storage", Reason: "compiled views, sessions, cache and logs, in the directories the image has"},
+		{Path: "bootstrap/cache", Reason: "package and service caches"},
+	},
+	"symfony": {{Path: "var", Reason: "cache and logs"}},
+	"phoenix": {{Path: "tmp", Reason: "the release's temporary files"}},
+}
+
+// resolveHarden sets the mounts of the hardening profile from the framework,
+// what was detected and the final stage of the Dockerfile
+func resolveHarden(vars map[string]interface{}, framework, content string) {
+	workdir := ""
+	if final := dockerfile.Parse(content).FinalStage(); final != nil {
+		for _, in := range final.Instructions {
+			if in.Cmd == "WORKDIR" && len(in.Args) > 0 {
+				workdir = path.Join(workdir, in.Args[0])
+			}
+		}
+	}
+
+	paths := append([]WritablePath{{Path: "/tmp"}}, writablePaths[framework]...)
+	database, _ := vars["database"].(string)
+	switch {
+	case framework == "rails" && strings.HasPrefix(database, "sqlite"):
+		paths = append(paths, WritablePath{Path: "storage", Volume: "app-storage", Reason: "the SQLite database"})
+	case framework == "laravel" && vars["octaneServer"] == "frankenphp",
+		vars["staticServer"] == "caddy":
+		dir := ""
+		if framework == "laravel" {
+			dir = "/caddy"
+		}
+		paths = append(paths,
+			WritablePath{Path: "/data" + dir, Reason: "Caddy's data"},
+			WritablePath{Path: "/config" + dir, Reason: "Caddy's autosaved config"})
+	}
+
+	var tmpfs, volumes []WritablePath
+	for _, p := range paths {
+		if !strings.HasPrefix(p.Path, "/") {
+			if workdir == "" {
+				continue
+			}
+			p.Path = path.Join(workdir, p.Path)
+		}
+		if p.Volume != "" {
+			volumes = append(volumes, p)
+		} else {
+			tmpfs = append(tmpfs, p)
+		}
+	}
+	vars["harden"] = true
+	vars["hardenTmpfs"] = tmpfs
+	if len(volumes) > 0 {
+		vars["hardenVolumes"] = volumes
+	}
+	if strings.Contains(content, "supervisord") {
+		// nginx and PHP-FPM under supervisord write pid files and logs
+		// across the filesystem
+		vars["hardenRoot"] = true
+	}
+}
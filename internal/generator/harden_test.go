@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestHarden(t *testing.T) {
+	rails := &detector.DetectionResult{
+		Language:  "ruby",
+		Framework: "rails",
+		Template:  "ruby/rails.tmpl",
+		Variables: map[string]interface{}{"rubyVersion": "3.3", "hasLockFile": true, "database": "sqlite3", "port": "3000"},
+	}
+	out, err := New(WithHarden(true)).Generate(rails, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"    read_only: true\n    tmpfs:\n      - /tmp\n      - /app/tmp  # pids and caches\n",
+		"    volumes:\n      - app-storage:/app/storage  # the SQLite database\n",
+		"    cap_drop:\n      - ALL\n    security_opt:\n      - no-new-privileges:true\n",
+		"\nvolumes:\n  app-storage:\n",
+	} {
+		if !strings.Contains(out.DockerCompose, want) {
+			t.Errorf("docker-compose.yml lacks %q:\n%s", want, out.DockerCompose)
+		}
+	}
+
+	// PHP-FPM and nginx under supervisord write across the filesystem
+	laravel := &detector.DetectionResult{
+		Language:  "php",
+		Framework: "laravel",
+		Template:  "php/laravel.tmpl",
+		Variables: map[string]interface{}{"phpVersion": "8.3", "hasLockFile": true, "port": "8000"},
+	}
+	if out, err = New(WithHarden(true)).Generate(laravel, ""); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.DockerCompose, "read_only") || strings.Contains(out.DockerCompose, "cap_add") {
+		t.Errorf("supervisord should keep a writable root filesystem and no capabilities:\n%s", out.DockerCompose)
+	}
+
+	if out, err = New(WithHarden(true), WithDev(true)).Generate(rails, ""); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.DockerCompose, "read_only") || len(out.Warnings) == 0 {
+		t.Errorf("--harden should be left out with --dev, with a warning:\n%s", out.DockerCompose)
+	}
+}
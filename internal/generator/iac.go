@@ -0,0 +1,226 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fargateSizes are the Fargate CPU units with the memory range (MiB) each
+// takes, in size order. Above 512 MiB memory comes in whole GiB.
+var fargateSizes = []struct{ cpu, minMemory, maxMemory int }{
+	{256, 512, 2048},
+	{512, 1024, 4096},
+	{1024, 2048, 8192},
+	{2048, 4096, 16384},
+	{4096, 8192, 30720},
+}
+
+// mebibytes converts a compose memory size (512M, 1G, 1.5g) to MiB; 0 when
+// it can't be read
+func mebibytes(size string) int {
+	size = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(size)), "B")
+	scale := 1.0 / (1024 * 1024)
+	switch {
+	case strings.HasSuffix(size, "K"):
+		scale = 1.0 / 1024
+	case strings.HasSuffix(size, "M"):
+		scale = 1
+	case strings.HasSuffix(size, "G"):
+		scale = 1024
+	}
+	n, err := strconv.ParseFloat(strings.TrimRight(size, "KMG"), 64)
+	if err != nil {
+		return 0
+	}
+	return int(math.Ceil(n * scale))
+}
+
+// fargateSize picks the smallest Fargate task size with the CPUs and the
+// memory limit of an environment
+func fargateSize(env Environment) (cpu, memory int) {
+	memory = mebibytes(env.MemoryLimit)
+	cpus, _ := strconv.ParseFloat(env.CPUs, 64)
+	for _, s := range fargateSizes {
+		if float64(s.cpu) < cpus*1024 || memory > s.maxMemory {
+			continue
+		}
+		switch {
+		case memory <= s.minMemory:
+			return s.cpu, s.minMemory
+		case memory <= 1024:
+			return s.cpu, 1024
+		}
+		return s.cpu, (memory + 1023) / 1024 * 1024
+	}
+	last := fargateSizes[len(fargateSizes)-1]
+	return last.cpu, last.maxMemory
+}
+
+// seconds is a health check duration in whole seconds, or def when unset
+func seconds(d time.Duration, def int) int {
+	if d <= 0 {
+		return def
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ecsTaskDefinition renders an AWS ECS task definition for Fargate, as
+// aws ecs register-task-definition --cli-input-json takes it. The image,
+// account and region are placeholders; variables without a default are
+// read from SSM Parameter Store.
+func ecsTaskDefinition(t DeployTarget) (string, error) {
+	env := t.Resources.resolve()
+	cpu, memory := fargateSize(env)
+	port, _ := strconv.Atoi(t.Port)
+
+	type keyValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	type secret struct {
+		Name      string `json:"name"`
+		ValueFrom string `json:"valueFrom"`
+	}
+	environment := []keyValue{{Name: "PORT", Value: t.Port}}
+	var secrets []secret
+	for _, v := range t.Env {
+		switch {
+		case v.Name == "PORT":
+		case v.Default != "":
+			environment = append(environment, keyValue{Name: v.Name, Value: v.Default})
+		default:
+			secrets = append(secrets, secret{Name: v.Name, ValueFrom: "arn:aws:ssm:us-east-1:ACCOUNT_ID:parameter/" + t.Name + "/" + v.Name})
+		}
+	}
+
+	container := map[string]interface{}{
+		"name":         t.Name,
+		"image":        "ACCOUNT_ID.dkr.ecr.us-east-1.amazonaws.com/" + t.Name + ":latest",
+		"essential":    true,
+		"portMappings": []map[string]interface{}{{"containerPort": port, "protocol": "tcp", "appProtocol": "http"}},
+		"environment":  environment,
+		"logConfiguration": map[string]interface{}{
+			"logDriver": "awslogs",
+			"options": map[string]string{
+				"awslogs-group":         "/ecs/" + t.Name,
+				"awslogs-region":        "us-east-1",
+				"awslogs-stream-prefix": t.Name,
+				"awslogs-create-group":  "true",
+			},
+		},
+	}
+	if env.MemoryReservation != "" && mebibytes(env.MemoryReservation) < memory {
+		container["memoryReservation"] = mebibytes(env.MemoryReservation)
+	}
+	if len(secrets) > 0 {
+		container["secrets"] = secrets
+	}
+	if t.Health != nil {
+		container["healthCheck"] = map[string]interface{}{
+			"command":     []string{"CMD-SHELL", t.Health.Command},
+			"interval":    seconds(t.Health.Interval, 30),
+			"timeout":     seconds(t.Health.Timeout, 5),
+			"retries":     max(t.Health.Retries, 3),
+			"startPeriod": seconds(t.Health.StartPeriod, 0),
+		}
+	}
+
+	definition := map[string]interface{}{
+		"family":                  t.Name,
+		"networkMode":             "awsvpc",
+		"requiresCompatibilities": []string{"FARGATE"},
+		"cpu":                     strconv.Itoa(cpu),
+		"memory":                  strconv.Itoa(memory),
+		"executionRoleArn":        "arn:aws:iam::ACCOUNT_ID:role/ecsTaskExecutionRole",
+		"containerDefinitions":    []interface{}{container},
+	}
+	data, err := json.MarshalIndent(definition, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// hclString quotes s as an HCL string, escaping template sequences
+func hclString(s string) string {
+	s = strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+	return strconv.Quote(s)
+}
+
+// hclIdent turns a name into a Terraform identifier
+func hclIdent(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// terraformDocker renders a Terraform configuration for the Docker provider
+// (kreuzwerker/docker): the image built from the Dockerfile and a container
+// per process, with the environment's limits. Variables without a default
+// become sensitive Terraform variables.
+func terraformDocker(t DeployTarget) string {
+	env := t.Resources.resolve()
+	var b strings.Builder
+	b.WriteString("# Terraform configuration for the Docker provider\n")
+	b.WriteString("# Generated by Dublyo Dockerizer; apply with: terraform init && terraform apply\n\n")
+	b.WriteString("terraform {\n  required_providers {\n    docker = {\n      source  = \"kreuzwerker/docker\"\n      version = \"~> 3.0\"\n    }\n  }\n}\n")
+
+	envList := []string{hclString("PORT=" + t.Port)}
+	var variables []string
+	for _, v := range t.Env {
+		switch {
+		case v.Name == "PORT":
+		case v.Default != "":
+			envList = append(envList, hclString(v.Name+"="+v.Default))
+		default:
+			ident := strings.ToLower(hclIdent(v.Name))
+			envList = append(envList, `"`+v.Name+`=${var.`+ident+`}"`)
+			variables = append(variables, ident)
+		}
+	}
+	for _, name := range variables {
+		fmt.Fprintf(&b, "\nvariable %q {\n  type      = string\n  sensitive = true\n}\n", name)
+	}
+
+	fmt.Fprintf(&b, "\nresource \"docker_image\" \"app\" {\n  name = %s\n\n  build {\n    context    = \".\"\n    dockerfile = %s\n  }\n}\n",
+		hclString(t.Name+":latest"), hclString(t.Dockerfile))
+
+	container := func(resource, name, command string, web bool) {
+		fmt.Fprintf(&b, "\nresource \"docker_container\" %q {\n", resource)
+		fmt.Fprintf(&b, "  name    = %s\n  image   = docker_image.app.image_id\n  restart = %s\n", hclString(name), hclString(env.Restart))
+		if command != "" {
+			fmt.Fprintf(&b, "  command = [\"sh\", \"-c\", %s]\n", hclString(command))
+		}
+		if memory := mebibytes(env.MemoryLimit); memory > 0 {
+			fmt.Fprintf(&b, "  memory  = %d\n", memory)
+		}
+		if cpus, err := strconv.ParseFloat(env.CPUs, 64); err == nil && cpus > 0 {
+			fmt.Fprintf(&b, "  cpu_shares = %d\n", int(cpus*1024))
+		}
+		fmt.Fprintf(&b, "\n  env = [\n    %s,\n  ]\n", strings.Join(envList, ",\n    "))
+		if !web {
+			b.WriteString("}\n")
+			return
+		}
+		fmt.Fprintf(&b, "\n  ports {\n    internal = %s\n    external = %s\n  }\n", t.Port, t.Port)
+		if h := t.Health; h != nil {
+			fmt.Fprintf(&b, "\n  healthcheck {\n    test         = [\"CMD-SHELL\", %s]\n", hclString(h.Command))
+			fmt.Fprintf(&b, "    interval     = \"%ds\"\n    timeout      = \"%ds\"\n    start_period = \"%ds\"\n    retries      = %d\n  }\n",
+				seconds(h.Interval, 30), seconds(h.Timeout, 30), seconds(h.StartPeriod, 0), max(h.Retries, 3))
+		}
+		b.WriteString("}\n")
+	}
+	container("app", t.Name, "", true)
+	for _, p := range t.Processes {
+		container(hclIdent(p.Name), t.Name+"-"+p.Name, p.Command, false)
+	}
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+)
+
+// MatrixProfile is a named set of options rendered beside the main output:
+// a Dockerfile.<name> and an app-<name> compose service building it, in the
+// compose profile of the same name
+type MatrixProfile struct {
+	Name     string
+	Platform string   // Target platform of the service, e.g. linux/arm64
+	Options  []Option // Applied over the generator's own options
+}
+
+// WithMatrix renders a Dockerfile and compose service per profile, e.g.
+// Dockerfile.dev, Dockerfile.prod and Dockerfile.arm64 in one run
+func WithMatrix(profiles []MatrixProfile) Option {
+	return func(g *generator) {
+		g.matrix = profiles
+	}
+}
+
+// matrixService is the compose service of a matrix profile
+type matrixService struct {
+	Name       string
+	File       string // The Dockerfile's path in the output directory
+	Dockerfile string // Relative to the build context
+	Target     string // Final stage, or the dev server's stage
+	Platform   string
+	Command    []string // Dev server
+	Reset      bool     // The app service runs a dev server the profile doesn't
+}
+
+// generateMatrix renders the Dockerfile of each matrix profile into output,
+// and returns the compose services building them
+func (g *generator) generateMatrix(result *detector.DetectionResult, output *Output, vars map[string]interface{}) ([]matrixService, error) {
+	seen := make(map[string]bool)
+	var services []matrixService
+	for _, p := range g.matrix {
+		if !validServiceName.MatchString(p.Name) || seen[p.Name] {
+			return nil, fmt.Errorf("invalid matrix profile name %q (use lowercase letters, digits, - and _, once each)", p.Name)
+		}
+		seen[p.Name] = true
+		if p.Platform != "" && !strings.Contains(p.Platform, "/") {
+			return nil, fmt.Errorf("invalid platform %q of matrix profile %s (use os/arch, e.g. linux/arm64)", p.Platform, p.Name)
+		}
+
+		// The profile's options over a copy of the generator, for the
+		// Dockerfile only; it is stamped with the other files
+		sub := *g
+		for _, opt := range p.Options {
+			opt(&sub)
+		}
+		sub.matrix, sub.environments, sub.banner, sub.provenance = nil, nil, "", nil
+		sub.includeCompose, sub.includeIgnore, sub.includeEnv, sub.includeDocs, sub.harden = false, false, false, false, false
+		sub.dockerfilePath = g.dockerfileName() + "." + p.Name
+		out, err := sub.Generate(result, "")
+		if err != nil {
+			return nil, fmt.Errorf("matrix profile %s: %w", p.Name, err)
+		}
+		for _, w := range out.Warnings {
+			if !slices.Contains(output.Warnings, w) {
+				output.Warnings = append(output.Warnings, fmt.Sprintf("matrix profile %s: %s", p.Name, w))
+			}
+		}
+
+		service := matrixService{Name: p.Name, File: sub.dockerfileName(), Platform: p.Platform}
+		service.Dockerfile, _ = out.vars["composeDockerfile"].(string)
+		if command, ok := out.vars["devCommand"].([]string); ok {
+			service.Command = command
+			service.Target, _ = out.vars["devTarget"].(string)
+		} else {
+			service.Reset = vars["devCommand"] != nil
+		}
+		if final := dockerfile.Parse(out.Dockerfile).FinalStage(); service.Target == "" && final != nil {
+			service.Target = final.Name
+		}
+		if f, ok := out.Files[sub.dockerfileName()]; ok {
+			output.Files[sub.dockerfileName()] = f
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
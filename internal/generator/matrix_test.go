@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestMatrix(t *testing.T) {
+	nextjs := &detector.DetectionResult{
+		Language:  "nodejs",
+		Framework: "nextjs",
+		Template:  "nodejs/nextjs.tmpl",
+		Variables: map[string]interface{}{"nodeVersion": "20", "packageManager": "npm", "hasLockFile": true, "port": "3000"},
+	}
+	out, err := New(WithDev(true), WithMatrix([]MatrixProfile{
+		{Name: "dev", Options: []Option{WithDev(true)}},
+		{Name: "arm64", Platform: "linux/arm64", Options: []Option{WithDev(false), WithBase("debian")}},
+	})).Generate(nextjs, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Files["Dockerfile.arm64"].String(), "-bookworm-slim") {
+		t.Errorf("Dockerfile.arm64 is not on Debian:\n%s", out.Files["Dockerfile.arm64"])
+	}
+	if _, ok := out.Files["Dockerfile.dev"]; !ok {
+		t.Error("Dockerfile.dev was not generated")
+	}
+	for _, want := range []string{
+		"  app-dev:\n    extends:\n      service: app\n    build:\n      dockerfile: Dockerfile.dev\n      target: build\n",
+		`    command: ["npx", "next", "dev", "--hostname", "0.0.0.0", "--port", "3000"]`,
+		"      dockerfile: Dockerfile.arm64\n      target: runner\n    image: ${APP_NAME:-app}:arm64\n",
+		"    profiles: [\"arm64\"]\n    platform: linux/arm64\n    command: !reset null",
+	} {
+		if !strings.Contains(out.DockerCompose, want) {
+			t.Errorf("docker-compose.yml lacks %q:\n%s", want, out.DockerCompose)
+		}
+	}
+
+	if _, err := New(WithMatrix([]MatrixProfile{{Name: "App"}})).Generate(nextjs, ""); err == nil {
+		t.Error("Generate() accepted an invalid matrix profile name")
+	}
+}
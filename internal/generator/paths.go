@@ -0,0 +1,175 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Default names of the generated files, relative to the output directory
+const (
+	defaultDockerfilePath = "Dockerfile"
+	defaultComposePath    = "docker-compose.yml"
+)
+
+// DockerfileNames are the names of a Dockerfile: Docker's, and the
+// Containerfile of Podman and Buildah
+var DockerfileNames = []string{"Dockerfile", "Containerfile"}
+
+// ComposeNames are the compose file names docker compose and podman-compose
+// find on their own, in the order they look for them
+var ComposeNames = []string{"compose.yaml", "compose.yml", "docker-compose.yaml", "docker-compose.yml"}
+
+// ExistingFile returns the first of names that is a file in dir, or ""
+func ExistingFile(dir string, names ...string) string {
+	for _, name := range names {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return name
+		}
+	}
+	return ""
+}
+
+// WithDockerfile enables/disables Dockerfile (and docker-entrypoint.sh)
+// output; the Dockerfile is still rendered for the other files
+func WithDockerfile(include bool) Option {
+	return func(g *generator) {
+		g.includeDockerfile = include
+	}
+}
+
+// WithDockerfilePath writes the Dockerfile to p, relative to the output
+// directory, e.g. docker/Dockerfile.api. The build context stays the output
+// directory.
+func WithDockerfilePath(p string) Option {
+	return func(g *generator) {
+		g.dockerfilePath = p
+	}
+}
+
+// WithExistingNames keeps the names of a Dockerfile and compose file dir
+// has already, such as Containerfile or compose.yaml, so they are updated
+// rather than joined by a Dockerfile or docker-compose.yml the tools would
+// not pick. Paths set by WithDockerfilePath and WithComposePath win, so it
+// goes after them.
+func WithExistingNames(dir string) Option {
+	return func(g *generator) {
+		if name := ExistingFile(dir, DockerfileNames...); g.dockerfilePath == "" && name != defaultDockerfilePath {
+			g.dockerfilePath = name
+		}
+		if name := ExistingFile(dir, ComposeNames...); g.composePath == "" && name != defaultComposePath {
+			g.composePath = name
+		}
+	}
+}
+
+// WithOutputSubdir tells the generator that the output directory is sub,
+// relative to the project directory (e.g. docker), so the build context
+// stays the project directory
+func WithOutputSubdir(sub string) Option {
+	return func(g *generator) {
+		g.outputSubdir = sub
+	}
+}
+
+// WithComposePath writes docker-compose.yml to p, relative to the output
+// directory, e.g. deploy/compose.yml. Environment overrides go next to it.
+func WithComposePath(p string) Option {
+	return func(g *generator) {
+		g.composePath = p
+	}
+}
+
+// dockerfileName is where the Dockerfile is written
+func (g *generator) dockerfileName() string {
+	if g.dockerfilePath != "" {
+		return path.Clean(g.dockerfilePath)
+	}
+	return defaultDockerfilePath
+}
+
+// composeName is where docker-compose.yml is written
+func (g *generator) composeName() string {
+	if g.composePath != "" {
+		return path.Clean(g.composePath)
+	}
+	return defaultComposePath
+}
+
+// environmentName is where the compose override of an environment is written
+func (g *generator) environmentName(env string) string {
+	return path.Join(path.Dir(g.composeName()), "docker-compose."+env+".yml")
+}
+
+// CheckOutputPath rejects a file path that leaves the output directory;
+// name says which path it is in the error
+func CheckOutputPath(name, p string) error {
+	if p == "" {
+		return nil
+	}
+	clean := path.Clean(p)
+	if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("%s %q must be a file inside the output directory", name, p)
+	}
+	return nil
+}
+
+// upTo returns the relative path from dir back to where dir starts,
+// e.g. "../.." for "deploy/compose"
+func upTo(dir string) string {
+	if dir == "." || dir == "" {
+		return "."
+	}
+	return strings.TrimSuffix(strings.Repeat("../", strings.Count(dir, "/")+1), "/")
+}
+
+// layoutVars points the Dockerfile and compose file at each other, the
+// build context and .env wherever they are written. buildContext comes in
+// relative to the project directory and dockerfilePath relative to the
+// build context (both set for workspace packages, whose build context is
+// the workspace root). composeDir, composeContext, composeSource and
+// envFile are relative to the compose file; composeDockerfile and
+// entrypointSource to the build context.
+func (g *generator) layoutVars(vars map[string]interface{}) error {
+	if err := CheckOutputPath("Dockerfile path", g.dockerfilePath); err != nil {
+		return err
+	}
+	if err := CheckOutputPath("compose path", g.composePath); err != nil {
+		return err
+	}
+	if g.outputSubdir != "" {
+		if err := CheckOutputPath("output directory", g.outputSubdir); err != nil {
+			return err
+		}
+	}
+	sub := path.Clean("./" + g.outputSubdir)
+
+	buildContext, _ := vars["buildContext"].(string)
+	if buildContext == "" {
+		buildContext = "."
+	}
+	dockerfilePath, _ := vars["dockerfilePath"].(string)
+	if dockerfilePath == "" {
+		dockerfilePath = defaultDockerfilePath
+	}
+
+	// The output directory, seen from the build context
+	outputDir := path.Join(path.Dir(dockerfilePath), sub)
+	if g.dockerfilePath != "" || sub != "." {
+		dockerfilePath = path.Join(outputDir, g.dockerfileName())
+		vars["dockerfilePath"] = dockerfilePath
+	}
+	vars["entrypointSource"] = path.Join(outputDir, "docker-entrypoint.sh")
+
+	// The project directory, seen from the compose file
+	composeDir := upTo(path.Dir(g.composeName()))
+	project := path.Join(composeDir, upTo(sub))
+	vars["composeDir"] = composeDir
+	vars["composeContext"] = path.Join(project, buildContext)
+	vars["composeDockerfile"] = dockerfilePath
+	vars["composeSource"] = project
+	vars["envFile"] = path.Join(composeDir, ".env")
+	return nil
+}
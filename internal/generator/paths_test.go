@@ -0,0 +1,187 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestOutputPaths(t *testing.T) {
+	result := &detector.DetectionResult{
+		Detected:  true,
+		Language:  "nodejs",
+		Framework: "express",
+		Template:  "nodejs/express.tmpl",
+		Variables: map[string]interface{}{"packageManager": "npm", "mainFile": "index.js", "port": "3000", "nodeVersion": "20"},
+	}
+	gen := New(
+		WithDockerfilePath("docker/Dockerfile.api"),
+		WithComposePath("deploy/compose.yml"),
+		WithEnvironments([]Environment{{Name: "dev"}}),
+	)
+	output, err := gen.Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, name := range []string{"docker/Dockerfile.api", "deploy/compose.yml", "deploy/docker-compose.dev.yml", ".dockerignore", ".env.example"} {
+		if _, ok := output.Files[name]; !ok {
+			t.Errorf("missing %s in %v", name, keys(output.Files))
+		}
+	}
+	compose := output.Files["deploy/compose.yml"].String()
+	for _, want := range []string{"context: ..\n", "dockerfile: docker/Dockerfile.api\n", "- ../.env\n"} {
+		if !strings.Contains(compose, want) {
+			t.Errorf("compose missing %q", want)
+		}
+	}
+	if dev := output.Files["deploy/docker-compose.dev.yml"].String(); strings.Contains(dev, "/app") && !strings.Contains(dev, "- ../:/app") {
+		t.Errorf("dev override mounts the wrong source directory:\n%s", dev)
+	}
+
+	// Output in docker/: the project directory stays the build context
+	output, err = New(WithOutputSubdir("docker")).Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate(subdir) error = %v", err)
+	}
+	compose = output.Files["docker-compose.yml"].String()
+	for _, want := range []string{"context: ..\n", "dockerfile: docker/Dockerfile\n", "- .env\n"} {
+		if !strings.Contains(compose, want) {
+			t.Errorf("subdir compose missing %q", want)
+		}
+	}
+	if _, ok := output.Files["Dockerfile.dockerignore"]; !ok {
+		t.Errorf("subdir output needs a per-Dockerfile ignore file, got %v", keys(output.Files))
+	}
+
+	output, err = New(WithDockerfile(false), WithCompose(false)).Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate(no Dockerfile) error = %v", err)
+	}
+	if _, ok := output.Files["Dockerfile"]; ok || output.Dockerfile == "" {
+		t.Errorf("WithDockerfile(false) wrote %v", keys(output.Files))
+	}
+
+	if _, err := New(WithComposePath("../compose.yml")).Generate(result, ""); err == nil {
+		t.Error("expected an error for a compose path outside the output directory")
+	}
+}
+
+func TestExistingNames(t *testing.T) {
+	result := &detector.DetectionResult{
+		Detected:  true,
+		Language:  "nodejs",
+		Framework: "express",
+		Template:  "nodejs/express.tmpl",
+		Variables: map[string]interface{}{"packageManager": "npm", "mainFile": "index.js", "port": "3000", "nodeVersion": "20"},
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"Containerfile", "compose.yaml", "docker-compose.yml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output, err := New(WithExistingNames(dir)).Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, name := range []string{"Containerfile", "compose.yaml"} {
+		if _, ok := output.Files[name]; !ok {
+			t.Errorf("missing %s in %v", name, keys(output.Files))
+		}
+	}
+	if compose := output.Files["compose.yaml"].String(); !strings.Contains(compose, "dockerfile: Containerfile\n") {
+		t.Errorf("compose does not build the Containerfile:\n%s", compose)
+	}
+
+	// Paths set explicitly win over the files found
+	output, err = New(WithDockerfilePath("Dockerfile"), WithExistingNames(dir)).Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if _, ok := output.Files["Dockerfile"]; !ok {
+		t.Errorf("WithDockerfilePath lost to the existing Containerfile: %v", keys(output.Files))
+	}
+}
+
+func keys(m map[string]File) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestWriteFilesAllOrNothing(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// docker is a file, so docker/compose.yml can't be written
+	if err := os.WriteFile(filepath.Join(dir, "docker"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := New(WithOverwrite(true)).(*generator)
+	output := &Output{Files: map[string]File{"Dockerfile": textFile("FROM new\n"), "docker/compose.yml": textFile("services: {}\n")}}
+	if err := g.writeFiles(output, dir); err == nil {
+		t.Fatal("writeFiles succeeded writing below a file")
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "Dockerfile")); string(got) != "FROM old\n" {
+		t.Errorf("Dockerfile = %q after a failed write, want it unchanged", got)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".dockerizer-tmp") {
+			t.Errorf("left temporary file %s", e.Name())
+		}
+	}
+
+	output.Files = map[string]File{"Dockerfile": textFile("FROM new\n")}
+	if err := g.writeFiles(output, dir); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "Dockerfile")); string(got) != "FROM new\n" {
+		t.Errorf("Dockerfile = %q, want FROM new", got)
+	}
+}
+
+func TestWriteFilesBackup(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out") // Created by writeFiles
+	g := New(WithOverwrite(true), WithBackup(true)).(*generator)
+	output := &Output{Files: map[string]File{
+		"Dockerfile":           textFile("FROM old\n"),
+		".env.example":         textFile("PORT=8080\n"),
+		"docker-entrypoint.sh": {Content: []byte("#!/bin/sh\n"), Mode: 0755},
+	}}
+	if err := g.writeFiles(output, dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Backups) != 0 {
+		t.Errorf("backups of new files: %v", output.Backups)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "docker-entrypoint.sh")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("docker-entrypoint.sh is not executable (%v)", err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "Dockerfile"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	output = &Output{Files: map[string]File{"Dockerfile": textFile("FROM new\n"), ".env.example": textFile("PORT=8080\n")}}
+	if err := g.writeFiles(output, dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Backups) != 1 || output.Backups[0] != "Dockerfile.bak" {
+		t.Errorf("backups = %v, want only Dockerfile.bak (.env.example is unchanged)", output.Backups)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "Dockerfile.bak")); string(got) != "FROM old\n" {
+		t.Errorf("Dockerfile.bak = %q, want the previous content", got)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "Dockerfile")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Dockerfile mode = %v (%v), want 0600 kept", info.Mode().Perm(), err)
+	}
+}
@@ -0,0 +1,73 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+)
+
+// FinalStage names the last stage of a Dockerfile in Preserved.BaseImages,
+// whatever its AS name
+const FinalStage = "final"
+
+// Preserved are choices made in a project's own Dockerfile that generation
+// keeps, as dockerizer import records them in .dockerizer.yml
+type Preserved struct {
+	BaseImages map[string]string // Stage name (or FinalStage) -> base image
+	Packages   []string          // System packages installed in the final stage
+}
+
+// WithPreserved keeps base images and system packages of an existing
+// Dockerfile in the generated one
+func WithPreserved(p Preserved) Option {
+	return func(g *generator) {
+		g.preserved = p
+	}
+}
+
+// preserve applies the preserved choices to a generated Dockerfile. Stages
+// the template doesn't have are reported as warnings rather than errors, as
+// templates change between versions.
+func (g *generator) preserve(content string) (string, []string, error) {
+	p := g.preserved
+	if len(p.BaseImages) == 0 && len(p.Packages) == 0 {
+		return content, nil, nil
+	}
+	df := dockerfile.Parse(content)
+
+	var warnings []string
+	names := make([]string, 0, len(p.BaseImages))
+	for name := range p.BaseImages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stage := df.Stage(name)
+		if renamed, ok := renamedStages[name]; ok && stage == nil {
+			stage = df.Stage(renamed)
+		}
+		if name == FinalStage && stage == nil {
+			stage = df.FinalStage()
+		}
+		if stage == nil {
+			warnings = append(warnings, fmt.Sprintf("the base image of stage %q (%s) is not kept: the generated Dockerfile has no such stage", name, p.BaseImages[name]))
+			continue
+		}
+		if df.BaseStage(stage) != nil {
+			warnings = append(warnings, fmt.Sprintf("the base image of stage %q (%s) is not kept: the stage is built on an earlier one", name, p.BaseImages[name]))
+			continue
+		}
+		if err := df.SetBaseImage(stage.Index, p.BaseImages[name]); err != nil {
+			return "", nil, fmt.Errorf("failed to keep the base image of stage %q: %w", name, err)
+		}
+	}
+
+	if len(p.Packages) > 0 {
+		if _, err := df.InstallPackages(p.Packages); err != nil {
+			warnings = append(warnings, fmt.Sprintf("the system packages %s are not kept: %v", strings.Join(p.Packages, ", "), err))
+		}
+	}
+	return df.String(), warnings, nil
+}
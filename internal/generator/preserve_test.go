@@ -0,0 +1,43 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestPreserved(t *testing.T) {
+	result := &detector.DetectionResult{
+		Detected:  true,
+		Language:  "nodejs",
+		Framework: "express",
+		Provider:  "express",
+		Template:  "nodejs/express.tmpl",
+		Variables: map[string]interface{}{"packageManager": "npm", "typescript": true, "hasLockFile": true, "port": "3000", "nodeVersion": "20"},
+	}
+	g := New(WithCompose(false), WithIgnore(false), WithEnv(false), WithPreserved(Preserved{
+		BaseImages: map[string]string{"builder": "node:22-bookworm", FinalStage: "registry.example.com/node:22-alpine", "deps": "node:22"},
+		Packages:   []string{"tini", "curl"},
+	}))
+	output, err := g.Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// The build stage, by its former name, keeps the parameterized version,
+	// the runner the whole image
+	for _, want := range []string{
+		"ARG NODE_VERSION=22\n",
+		"FROM node:${NODE_VERSION}-bookworm AS build\n",
+		"FROM registry.example.com/node:22-alpine AS runner\n",
+		"# Additional system packages\nRUN apk add --no-cache tini curl\n\nCOPY --from=build /app/package-lock.json ./\n",
+	} {
+		if !strings.Contains(output.Dockerfile, want) {
+			t.Errorf("Dockerfile lacks %q:\n%s", want, output.Dockerfile)
+		}
+	}
+	if len(output.Warnings) != 1 || !strings.Contains(output.Warnings[0], `stage "deps"`) {
+		t.Errorf("Warnings = %v", output.Warnings)
+	}
+}
@@ -0,0 +1,173 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// provenancePrefix starts the provenance comment of a generated file
+const provenancePrefix = "# dockerizer: "
+
+// BannerNone suppresses the "Generated by Dublyo Dockerizer" comments
+const BannerNone = "none"
+
+// Provenance records what produced a generated file, so that a later run
+// can tell whether today's templates would write it differently
+type Provenance struct {
+	Version   string    // dockerizer version
+	Provider  string    // Detection provider
+	Template  string    // Provider template, e.g. nodejs/express.tmpl
+	Hash      string    // TemplateHash of the templates
+	Generated time.Time // Zero unless the generation time is recorded
+}
+
+// String is the provenance comment line
+func (p Provenance) String() string {
+	fields := []string{"version=" + p.Version}
+	if p.Provider != "" {
+		fields = append(fields, "provider="+p.Provider)
+	}
+	if p.Template != "" {
+		fields = append(fields, "template="+p.Template)
+	}
+	if p.Hash != "" {
+		fields = append(fields, "hash="+p.Hash)
+	}
+	if !p.Generated.IsZero() {
+		fields = append(fields, "generated="+p.Generated.UTC().Format(time.RFC3339))
+	}
+	return provenancePrefix + strings.Join(fields, " ")
+}
+
+// ParseProvenance reads the provenance comment of a generated file
+func ParseProvenance(content string) (*Provenance, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), provenancePrefix)
+		if !ok {
+			continue
+		}
+		p := &Provenance{}
+		for _, field := range strings.Fields(rest) {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "version":
+				p.Version = value
+			case "provider":
+				p.Provider = value
+			case "template":
+				p.Template = value
+			case "hash":
+				p.Hash = value
+			case "generated":
+				p.Generated, _ = time.Parse(time.RFC3339, value)
+			}
+		}
+		return p, true
+	}
+	return nil, false
+}
+
+// TemplateHash identifies the built-in templates that render a project with
+// the given provider template: the template itself and the shared compose,
+// .dockerignore and entrypoint templates. It is empty for templates that are
+// not built in.
+func TemplateHash(template string) string {
+	content, err := getProviderTemplate(template)
+	if err != nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, part := range []string{string(content), composeTemplate, composeEnvTemplate, baseDockerignore, entrypointTemplate} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// WithBanner replaces the "Generated by Dublyo Dockerizer" comments of the
+// generated files with banner (one comment line per line), or removes them
+// when banner is BannerNone; empty keeps them
+func WithBanner(banner string) Option {
+	return func(g *generator) {
+		g.banner = banner
+	}
+}
+
+// WithProvenance adds a provenance comment to the generated files, naming
+// the dockerizer version, the provider and the template hash, plus the
+// generation time when timestamp is set (the output then changes on every
+// run)
+func WithProvenance(version string, timestamp bool) Option {
+	return func(g *generator) {
+		g.provenance = &Provenance{Version: version}
+		if timestamp {
+			g.provenance.Generated = time.Now().UTC().Truncate(time.Second)
+		}
+	}
+}
+
+var (
+	bannerLine = regexp.MustCompile(`(?m)^# (?:\S+ )?[Gg]enerated by Dublyo Dockerizer\n`)
+	bannerURL  = regexp.MustCompile(`(?m)^# https://github\.com/dublyo/dockerizer\n`)
+	// emptyFence is the ==== frame of a Dockerfile header left with nothing in it
+	emptyFence = regexp.MustCompile(`(?m)^# =+\n# =+\n\n?`)
+)
+
+// onlyComments reports whether every line of s is a comment
+func onlyComments(s string) bool {
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// stamp applies the banner setting and the provenance comment to a
+// generated file
+func (g *generator) stamp(content string, provenance *Provenance) string {
+	if content == "" {
+		return content
+	}
+	switch g.banner {
+	case "":
+	case BannerNone:
+		content = bannerLine.ReplaceAllString(content, "")
+		content = bannerURL.ReplaceAllString(content, "")
+		if loc := emptyFence.FindStringIndex(content); loc != nil && onlyComments(content[:loc[0]]) {
+			content = content[:loc[0]] + content[loc[1]:]
+		}
+	default:
+		var lines []string
+		for _, line := range strings.Split(strings.TrimRight(g.banner, "\n"), "\n") {
+			lines = append(lines, strings.TrimRight("# "+line, " ")+"\n")
+		}
+		banner := strings.Join(lines, "")
+		replaced := false
+		content = bannerLine.ReplaceAllStringFunc(content, func(string) string {
+			if replaced {
+				return ""
+			}
+			replaced = true
+			return banner
+		})
+		content = bannerURL.ReplaceAllString(content, "")
+	}
+	if provenance == nil {
+		return content
+	}
+
+	// After the lines that have to come first: a shebang or a parser directive
+	at := 0
+	for strings.HasPrefix(content[at:], "#!") || strings.HasPrefix(content[at:], "# syntax=") {
+		end := strings.IndexByte(content[at:], '\n')
+		if end < 0 {
+			return content + "\n" + provenance.String() + "\n"
+		}
+		at += end + 1
+	}
+	return content[:at] + provenance.String() + "\n" + content[at:]
+}
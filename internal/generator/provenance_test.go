@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestProvenance(t *testing.T) {
+	result := &detector.DetectionResult{
+		Detected:  true,
+		Language:  "nodejs",
+		Framework: "express",
+		Provider:  "express",
+		Template:  "nodejs/express.tmpl",
+		Variables: map[string]interface{}{"packageManager": "npm", "mainFile": "index.js", "port": "3000", "nodeVersion": "20"},
+	}
+	output, err := New(WithProvenance("1.2.3", false), WithBanner(BannerNone), WithCacheMounts(true)).Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	want := Provenance{Version: "1.2.3", Provider: "express", Template: "nodejs/express.tmpl", Hash: TemplateHash("nodejs/express.tmpl")}
+	for name, f := range output.Files {
+		content := f.String()
+		if strings.Contains(content, "Dublyo Dockerizer") {
+			t.Errorf("%s keeps the banner:\n%s", name, content)
+		}
+		p, ok := ParseProvenance(content)
+		if !ok || *p != want {
+			t.Errorf("%s provenance = %+v, want %+v", name, p, want)
+		}
+	}
+	// The parser directive stays the first line
+	if !strings.HasPrefix(output.Dockerfile, "# syntax=docker/dockerfile:1\n"+want.String()+"\n# ====") {
+		t.Errorf("Dockerfile header:\n%s", output.Dockerfile[:200])
+	}
+
+	output, err = New(WithBanner("Copyright Example Corp.\nInternal use only")).Generate(result, "")
+	if err != nil {
+		t.Fatalf("Generate(banner) error = %v", err)
+	}
+	if !strings.HasPrefix(output.DockerCompose, "# Docker Compose Configuration\n# Copyright Example Corp.\n# Internal use only\n\n") {
+		t.Errorf("compose header:\n%s", output.DockerCompose[:200])
+	}
+	if _, ok := ParseProvenance(output.DockerCompose); ok {
+		t.Error("provenance written without WithProvenance")
+	}
+}
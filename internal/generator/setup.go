@@ -0,0 +1,63 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// SetupJob is a one-shot service of the compose "setup" profile, such as
+// seeding the database or warming a cache, run with
+// docker compose --profile setup up
+type SetupJob struct {
+	Name    string
+	Command string
+	Target  string // Build stage to run in; "" runs in the app image
+}
+
+// WithSetupJobs adds setup jobs to the detected ones; a job replaces the
+// detected one of the same name
+func WithSetupJobs(jobs []SetupJob) Option {
+	return func(g *generator) {
+		g.setupJobs = jobs
+	}
+}
+
+var validServiceName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// reservedServices are the compose services the generator names itself
+var reservedServices = map[string]bool{
+	"app": true, "release": true, "migrate": true, "worker": true, "beat": true, "broker": true,
+	"proxy": true, "acme": true, "minio": true, "minio-init": true, "redpanda": true, "redpanda-init": true, "nats": true,
+}
+
+// resolveSetupJobs merges the configured setup jobs into the detected ones
+// (setupJobs)
+func resolveSetupJobs(vars map[string]interface{}, jobs []SetupJob) error {
+	detected, _ := vars["setupJobs"].([]map[string]string)
+	merged := append([]map[string]string(nil), detected...)
+	for _, job := range jobs {
+		if !validServiceName.MatchString(job.Name) || reservedServices[job.Name] {
+			return fmt.Errorf("invalid setup job name %q (use lowercase letters, digits, - and _, and not a service dockerizer generates)", job.Name)
+		}
+		if job.Command == "" {
+			return fmt.Errorf("setup job %q has no command", job.Name)
+		}
+		entry := map[string]string{"name": job.Name, "command": job.Command}
+		if job.Target != "" {
+			entry["target"] = job.Target
+		}
+		replaced := false
+		for i, d := range merged {
+			if d["name"] == job.Name {
+				merged[i], replaced = entry, true
+			}
+		}
+		if !replaced {
+			merged = append(merged, entry)
+		}
+	}
+	if len(merged) > 0 {
+		vars["setupJobs"] = merged
+	}
+	return nil
+}
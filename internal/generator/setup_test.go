@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+func TestSetupJobs(t *testing.T) {
+	result := &detector.DetectionResult{
+		Language:  "nodejs",
+		Framework: "express",
+		Template:  "nodejs/express.tmpl",
+		Variables: map[string]interface{}{
+			"port":           "3000",
+			"packageManager": "npm",
+			"setupJobs":      []map[string]string{{"name": "seed", "command": "npm run db:seed"}},
+		},
+	}
+	out, err := New(WithSetupJobs([]SetupJob{
+		{Name: "seed", Command: "npm run seed:dev"},
+		{Name: "assets", Command: "npm run assets", Target: "builder"},
+	})).Generate(result, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"    image: ${APP_NAME:-app}:latest  # Shared with the setup jobs\n",
+		"  seed:\n    image: ${APP_NAME:-app}:latest\n    pull_policy: never\n    profiles: [\"setup\"]\n",
+		`command: ["sh", "-c", "npm run seed:dev"]`,
+		"  assets:\n    build:\n      context: .\n      dockerfile: Dockerfile\n      target: builder\n      args:\n        NODE_VERSION: ${NODE_VERSION:-20}\n    profiles: [\"setup\"]\n",
+	} {
+		if !strings.Contains(out.DockerCompose, want) {
+			t.Errorf("docker-compose.yml lacks %q:\n%s", want, out.DockerCompose)
+		}
+	}
+	if strings.Contains(out.DockerCompose, "db:seed") {
+		t.Errorf("the configured seed job should replace the detected one:\n%s", out.DockerCompose)
+	}
+
+	for _, job := range []SetupJob{{Name: "migrate", Command: "true"}, {Name: "Seed", Command: "true"}, {Name: "seed"}} {
+		if _, err := New(WithSetupJobs([]SetupJob{job})).Generate(result, t.TempDir()); err == nil {
+			t.Errorf("Generate() with setup job %+v succeeded, want an error", job)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+)
+
+// The stage names the templates share, so that compose targets and
+// docker build --target work the same on every stack: deps installs the
+// dependencies (where it is a stage of its own), build has the toolchain,
+// the dev dependencies and the source, runner is the final image, and test
+// (with --with-tests) runs the test suite
+const (
+	DepsStage   = "deps"
+	BuildStage  = "build"
+	RunnerStage = "runner"
+	TestStage   = "test"
+)
+
+// renamedStages are the names stages had in earlier versions, which
+// configurations written then refer to
+var renamedStages = map[string]string{"builder": BuildStage}
+
+// nameFinalStage names the final stage runner when a template leaves it
+// unnamed, as single-stage templates and those ending on scratch do
+func nameFinalStage(content string) string {
+	df := dockerfile.Parse(content)
+	final := df.FinalStage()
+	if final == nil || final.Name != "" || df.Stage(RunnerStage) != nil {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	line := final.From.StartLine - 1
+	lines[line] = strings.TrimRight(lines[line], " ") + " AS " + RunnerStage
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,13 @@
+package generator
+
+import "testing"
+
+func TestNameFinalStage(t *testing.T) {
+	got := nameFinalStage("FROM golang:1.22 AS build\nRUN go build\n\nFROM scratch\nCOPY --from=build /app /app\n")
+	if want := "FROM golang:1.22 AS build\nRUN go build\n\nFROM scratch AS runner\nCOPY --from=build /app /app\n"; got != want {
+		t.Errorf("nameFinalStage() = %q", got)
+	}
+	if content := "FROM node:20 AS base\n"; nameFinalStage(content) != content {
+		t.Error("nameFinalStage() renamed a named stage")
+	}
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+)
+
+// SourceStage is the part of a single-stage Dockerfile that its test stage
+// and runner share with --with-tests: the base image, the production
+// dependencies and the source
+const SourceStage = "source"
+
+// WithTests adds a test stage running the project's test suite, which CI
+// builds with docker build --target test; the runner image is unchanged
+func WithTests(tests bool) Option {
+	return func(g *generator) {
+		g.tests = tests
+	}
+}
+
+// stageAlias matches the AS name of a FROM instruction
+var stageAlias = regexp.MustCompile(`(?i)(\s+AS\s+)\S+\s*$`)
+
+// addTestStage adds a test stage running suite (see the detector's
+// testSuite) to a Dockerfile. It builds on the build stage, which has the
+// dev dependencies and the source; a Dockerfile without one is split after
+// the final stage copies the source, into a source stage that the test
+// stage and runner both build on. It reports false when neither is found.
+func addTestStage(content string, suite map[string]string) (string, bool) {
+	df := dockerfile.Parse(content)
+	final := df.FinalStage()
+	if final == nil || final.Name == "" || df.Stage(TestStage) != nil {
+		return content, false
+	}
+	lines := strings.Split(content, "\n")
+	// heading is the start of the comments heading the final stage
+	heading := final.From.StartLine - 1
+	for heading > 0 && strings.HasPrefix(lines[heading-1], "#") {
+		heading--
+	}
+	stage := func(base string, installs ...string) []string {
+		text := []string{
+			"# Test stage: runs the test suite, with the dev dependencies the runner",
+			"# leaves out; docker build --target " + TestStage + " . fails when a test does",
+			"FROM " + base + " AS " + TestStage,
+		}
+		if suite["env"] != "" {
+			text = append(text, "ENV "+suite["env"])
+		}
+		for _, install := range installs {
+			if install != "" {
+				text = append(text, "RUN "+install)
+			}
+		}
+		return append(text, "RUN "+suite["command"], "")
+	}
+
+	if df.Stage(BuildStage) != nil {
+		out := append(append([]string{}, lines[:heading]...), stage(BuildStage, suite["install"])...)
+		return strings.Join(append(out, lines[heading:]...), "\n"), true
+	}
+
+	var source *dockerfile.Instruction
+	for _, in := range final.Instructions {
+		if in.Cmd == "COPY" && copiesContext(in) {
+			source = in
+		}
+	}
+	if source == nil || df.Stage(SourceStage) != nil {
+		return content, false
+	}
+	// ARGs are scoped to their stage, so the runner declares them again
+	var args []string
+	for _, in := range final.Instructions {
+		if in.Cmd == "ARG" && in.StartLine < source.StartLine {
+			args = append(args, strings.Join(lines[in.StartLine-1:in.EndLine], "\n"))
+		}
+	}
+	// The final stage's heading moves to the runner
+	from := final.From.StartLine - 1
+	runnerHeading := append([]string{}, lines[heading:from]...)
+	if len(runnerHeading) == 0 {
+		runnerHeading = []string{"# Production stage"}
+	}
+	out := append(append([]string{}, lines[:heading]...),
+		"# Source stage: the production dependencies and the source, shared by test and runner",
+		stageAlias.ReplaceAllString(lines[from], "${1}"+SourceStage))
+	out = append(append(out, lines[from+1:source.EndLine]...), "")
+	out = append(out, stage(SourceStage, suite["devInstall"], suite["install"])...)
+	out = append(append(out, runnerHeading...), "FROM "+SourceStage+" AS "+final.Name)
+	out = append(out, args...)
+	return strings.Join(append(out, lines[source.EndLine:]...), "\n"), true
+}
+
+// copiesContext reports whether a COPY copies the whole build context
+func copiesContext(in *dockerfile.Instruction) bool {
+	if _, ok := in.Flag("from"); ok || len(in.Args) < 2 {
+		return false
+	}
+	for _, src := range in.Args[:len(in.Args)-1] {
+		if src == "." || src == "./" {
+			return true
+		}
+	}
+	return false
+}
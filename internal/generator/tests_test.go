@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+)
+
+func TestWithTests(t *testing.T) {
+	gin := &detector.DetectionResult{
+		Language:  "go",
+		Framework: "gin",
+		Template:  "go/gin.tmpl",
+		Variables: map[string]interface{}{"goVersion": "1.22", "port": "8080", "testSuite": map[string]string{"command": "go test ./..."}},
+	}
+	out, err := New(WithTests(true)).Generate(gin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	df := dockerfile.Parse(out.Dockerfile)
+	if test := df.Stage(TestStage); test == nil || test.Image != BuildStage || !strings.Contains(out.Dockerfile, "\nRUN go test ./...\n") {
+		t.Errorf("no test stage on the build stage:\n%s", out.Dockerfile)
+	}
+	if final := df.FinalStage(); final.Name != RunnerStage {
+		t.Errorf("final stage = %q, want %q", final.Name, RunnerStage)
+	}
+
+	// A single-stage Dockerfile is split after it copies the source
+	express := &detector.DetectionResult{
+		Language:  "nodejs",
+		Framework: "express",
+		Template:  "nodejs/express.tmpl",
+		Variables: map[string]interface{}{
+			"nodeVersion": "20", "packageManager": "npm", "hasLockFile": true, "port": "3000", "mainFile": "index.js",
+			"testSuite": map[string]string{"command": "npm test", "devInstall": "npm ci", "env": "NODE_ENV=test"},
+		},
+	}
+	if out, err = New(WithTests(true)).Generate(express, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Dockerfile, "FROM source AS test\nENV NODE_ENV=test\nRUN npm ci\nRUN npm test\n") {
+		t.Errorf("no test stage on the source stage:\n%s", out.Dockerfile)
+	}
+	df = dockerfile.Parse(out.Dockerfile)
+	if final := df.FinalStage(); final.Name != RunnerStage || final.Image != SourceStage {
+		t.Errorf("final stage = %q from %q, want %q from %q", final.Name, final.Image, RunnerStage, SourceStage)
+	}
+
+	delete(express.Variables, "testSuite")
+	if out, err = New(WithTests(true)).Generate(express, ""); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.Dockerfile, "AS test") || len(out.Warnings) == 0 {
+		t.Errorf("a project without a test suite should get no test stage, with a warning:\n%s", out.Dockerfile)
+	}
+}
@@ -0,0 +1,323 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultUser is the UID:GID the images run as: a fixed non-root UID in the
+// root group, which is the group OpenShift runs its arbitrary UIDs in
+const DefaultUser = "1001:0"
+
+// WithUser sets the UID[:GID] the images run as (default DefaultUser)
+func WithUser(user string) Option {
+	return func(g *generator) {
+		g.user = user
+	}
+}
+
+// parseUser parses a UID[:GID]; the GID defaults to 0, the root group
+func parseUser(user string) (uid, gid int, err error) {
+	if user == "" {
+		user = DefaultUser
+	}
+	u, gr, hasGroup := strings.Cut(user, ":")
+	uid, err = strconv.Atoi(u)
+	if err == nil && hasGroup {
+		gid, err = strconv.Atoi(gr)
+	}
+	if err != nil || uid < 0 || gid < 0 {
+		return 0, 0, fmt.Errorf("invalid user %q (use UID[:GID], e.g. %s)", user, DefaultUser)
+	}
+	if uid == 0 {
+		return 0, 0, fmt.Errorf("invalid user %q: UID 0 is root", user)
+	}
+	return uid, gid, nil
+}
+
+// imageUser is a non-root user a base image ships
+type imageUser struct {
+	uid    int
+	create bool     // Runs tools that need a home, so a user is created for another UID
+	dirs   []string // Directories the image gives the user
+}
+
+// imageUsers are the base image users the templates run as, by name or UID
+var imageUsers = map[string]imageUser{
+	"node":    {uid: 1000, create: true},
+	"bun":     {uid: 1000, create: true},
+	"deno":    {uid: 1993, create: true, dirs: []string{"/deno-dir"}},
+	"nginx":   {uid: 101},   // nginx-unprivileged, whose directories the root group can write
+	"nonroot": {uid: 65532}, // distroless
+	"65534":   {uid: 65534}, // nobody, on scratch
+	"1001":    {uid: 1001},  // the Quarkus micro image's convention
+}
+
+// userCommand matches the RUN instructions applyUser rewrites
+var userCommand = regexp.MustCompile(`\b(addgroup|adduser|groupadd|useradd|chown)\s`)
+
+// applyUser makes a Dockerfile run as uid:gid: the users the templates
+// create get that UID and GID, the image users they run as are replaced by
+// one that has them, and USER and file ownership are numeric, as restricted
+// platforms require. Recursively chowned directories, and the final stage's
+// working directory, are made writable by the group too, for platforms that
+// run the image as an arbitrary UID in that group (OpenShift).
+func applyUser(content string, uid, gid int) string {
+	owner := fmt.Sprintf("%d:%d", uid, gid)
+	lines := strings.Split(content, "\n")
+	out := make([]string, 0, len(lines))
+	users := make(map[string]bool) // Created by the Dockerfile
+	known := func(spec string) bool {
+		name, _, _ := strings.Cut(spec, ":")
+		_, ok := imageUsers[name]
+		return users[name] || ok
+	}
+
+	// The stage being rewritten
+	var image, workdir, group string
+	var replaced map[string]bool // Image users replaced in the stage
+	shell, chowned, lastUser, lastWorkdir := true, false, -1, ""
+
+	// replace creates the user that replaces an image user, before the
+	// stage first refers to it
+	replace := func(spec string) {
+		name, _, _ := strings.Cut(spec, ":")
+		u, ok := imageUsers[name]
+		if !ok || !u.create || u.uid == uid || replaced[name] {
+			return
+		}
+		replaced[name] = true
+		commands := createUser(image, "app", uid, gid)
+		for _, dir := range u.dirs {
+			commands = append(commands, "chown -R "+owner+" "+dir, "chmod -R g=u "+dir)
+		}
+		out = append(out, fmt.Sprintf("# UID %d in place of the image's %s user", uid, name), "RUN "+strings.Join(commands, " && "), "")
+	}
+
+	for i := 0; i < len(lines); i++ {
+		start := i
+		for strings.HasSuffix(strings.TrimSpace(lines[i]), "\\") && i+1 < len(lines) {
+			i++
+		}
+		instruction := lines[start : i+1]
+		fields := strings.Fields(instruction[0])
+		keyword := ""
+		if len(fields) > 0 {
+			keyword = strings.ToUpper(fields[0])
+		}
+		switch keyword {
+		case "FROM":
+			image = ""
+			for _, f := range fields[1:] {
+				if !strings.HasPrefix(f, "--") {
+					image = f
+					break
+				}
+			}
+			workdir, group, replaced = "", "", make(map[string]bool)
+			shell = image != "scratch" && !strings.Contains(image, "distroless")
+			chowned, lastUser = false, -1
+			out = append(out, instruction...)
+		case "WORKDIR":
+			if len(fields) > 1 {
+				workdir = fields[1]
+			}
+			out = append(out, instruction...)
+		case "COPY", "ADD":
+			for _, f := range fields[1:] {
+				if spec, ok := strings.CutPrefix(f, "--chown="); ok && known(spec) {
+					replace(spec)
+					instruction = append([]string{strings.Replace(instruction[0], f, "--chown="+owner, 1)}, instruction[1:]...)
+				}
+			}
+			out = append(out, instruction...)
+		case "RUN":
+			if !userCommand.MatchString(strings.Join(instruction, " ")) {
+				out = append(out, instruction...)
+				break
+			}
+			rewritten := rewriteRun(instruction, func(command string) []string {
+				fields := strings.Fields(command)
+				if len(fields) == 0 {
+					return nil
+				}
+				switch fields[0] {
+				case "chown":
+					opts, args := commandArgs(fields[1:], nil)
+					if len(args) < 2 || !known(args[0]) {
+						return []string{command}
+					}
+					replace(args[0])
+					recursive := false
+					for _, o := range opts {
+						recursive = recursive || o[0] == "-R" || o[0] == "--recursive"
+					}
+					for _, path := range args[1:] {
+						chowned = chowned || path == workdir || path == strings.TrimSuffix(workdir, "/")
+					}
+					commands := []string{joinCommand("chown", opts, nil, append([]string{owner}, args[1:]...))}
+					if recursive {
+						commands = append(commands, "chmod -R g=u "+strings.Join(args[1:], " "))
+					}
+					return commands
+				case "addgroup", "groupadd":
+					opts, args := commandArgs(fields[1:], map[string]bool{"-g": true, "--gid": true, "-K": true})
+					if len(args) == 0 {
+						return []string{command}
+					}
+					group = args[0]
+					if gid == 0 {
+						return nil // The users get the root group instead
+					}
+					return []string{joinCommand(fields[0], without(opts, "-g", "--gid"), idOpt(hasLongOpts(opts), "-g", "--gid", gid), args)}
+				case "adduser", "useradd":
+					useradd := fields[0] == "useradd"
+					valued := map[string]bool{"-u": true, "--uid": true, "-G": true, "--ingroup": true, "-g": true, "--gecos": true, "-s": true, "--shell": true, "-h": true, "--home": true, "-k": true}
+					if useradd {
+						valued = map[string]bool{"-u": true, "--uid": true, "-g": true, "--gid": true, "-G": true, "--groups": true, "-s": true, "--shell": true, "-d": true, "--home-dir": true, "-c": true, "--comment": true, "-k": true, "--skel": true, "-K": true}
+					}
+					opts, args := commandArgs(fields[1:], valued)
+					if len(args) == 0 {
+						return []string{command}
+					}
+					users[args[0]] = true
+					long := hasLongOpts(opts)
+					var commands []string
+					if gid != 0 && group == "" {
+						// The user's own group, with the GID
+						group = args[0]
+						if useradd {
+							commands = append(commands, fmt.Sprintf("groupadd --gid %d %s", gid, group))
+						} else {
+							commands = append(commands, joinCommand("addgroup", nil, idOpt(long, "-g", "--gid", gid), []string{group}))
+						}
+					}
+					var ids []string
+					if useradd {
+						opts = without(opts, "-u", "--uid", "-g", "--gid")
+						ids = append(idOpt(long, "-u", "--uid", uid), idOpt(long, "-g", "--gid", gid)...)
+					} else {
+						// BusyBox and Debian adduser take the primary group by name
+						primary := "root"
+						if gid != 0 {
+							primary = group
+						}
+						opts = without(opts, "-u", "--uid", "-G", "--ingroup")
+						ids = append(idOpt(long, "-u", "--uid", uid), "-G", primary)
+						if long {
+							ids[2] = "--ingroup"
+						}
+					}
+					return append(commands, joinCommand(fields[0], opts, ids, args))
+				}
+				return []string{command}
+			})
+			out = append(out, rewritten...)
+		case "USER":
+			if len(fields) > 1 && known(fields[1]) {
+				replace(fields[1])
+				lastUser, lastWorkdir = len(out), workdir
+				out = append(out, "USER "+owner)
+				out = append(out, instruction[1:]...)
+				break
+			}
+			out = append(out, instruction...)
+		default:
+			out = append(out, instruction...)
+		}
+	}
+
+	// The final stage's working directory, for an app that writes to it
+	if lastUser >= 0 && shell && lastWorkdir != "" && !chowned {
+		for lastUser > 0 && strings.HasPrefix(out[lastUser-1], "#") {
+			lastUser-- // Above the USER's comment
+		}
+		writable := []string{
+			"# Writable by the group too, for platforms that run the image as an arbitrary UID",
+			fmt.Sprintf("RUN chown %s %s && chmod g+rwX %s", owner, lastWorkdir, lastWorkdir),
+			"",
+		}
+		out = append(out[:lastUser], append(writable, out[lastUser:]...)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// createUser are the commands creating a user with uid and gid, with the
+// tools of the image: BusyBox on Alpine, shadow elsewhere
+func createUser(image, name string, uid, gid int) []string {
+	if strings.Contains(image, "alpine") {
+		if gid == 0 {
+			return []string{fmt.Sprintf("adduser -S -u %d -G root %s", uid, name)}
+		}
+		return []string{fmt.Sprintf("addgroup -S -g %d %s", gid, name), fmt.Sprintf("adduser -S -u %d -G %s %s", uid, name, name)}
+	}
+	var commands []string
+	if gid != 0 {
+		commands = append(commands, fmt.Sprintf("groupadd --gid %d %s", gid, name))
+	}
+	return append(commands, fmt.Sprintf("useradd --uid %d --gid %d --create-home %s", uid, gid, name))
+}
+
+// commandArgs splits the fields of a command into its options, with the
+// value of those in valued, and its arguments
+func commandArgs(fields []string, valued map[string]bool) (opts [][]string, args []string) {
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case !strings.HasPrefix(f, "-"):
+			args = append(args, f)
+		case valued[f] && i+1 < len(fields):
+			opts = append(opts, []string{f, fields[i+1]})
+			i++
+		default:
+			opts = append(opts, []string{f})
+		}
+	}
+	return opts, args
+}
+
+// without drops the named options
+func without(opts [][]string, names ...string) [][]string {
+	var kept [][]string
+	for _, o := range opts {
+		drop := false
+		for _, name := range names {
+			drop = drop || o[0] == name
+		}
+		if !drop {
+			kept = append(kept, o)
+		}
+	}
+	return kept
+}
+
+// hasLongOpts reports whether a command is written with --long options
+func hasLongOpts(opts [][]string) bool {
+	for _, o := range opts {
+		if strings.HasPrefix(o[0], "--") {
+			return true
+		}
+	}
+	return false
+}
+
+// idOpt is an ID option, long or short as the command's other options
+func idOpt(long bool, shortName, longName string, id int) []string {
+	if long {
+		return []string{longName, strconv.Itoa(id)}
+	}
+	return []string{shortName, strconv.Itoa(id)}
+}
+
+// joinCommand joins a command back together, with extra options before its
+// arguments
+func joinCommand(name string, opts [][]string, extra, args []string) string {
+	parts := []string{name}
+	for _, o := range opts {
+		parts = append(parts, o...)
+	}
+	parts = append(parts, extra...)
+	return strings.Join(append(parts, args...), " ")
+}
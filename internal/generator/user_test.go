@@ -0,0 +1,106 @@
+package generator
+
+import "testing"
+
+func TestApplyUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		uid, gid int
+		want     string
+	}{
+		{
+			name: "created user, root group",
+			content: `FROM node:20-alpine AS runner
+WORKDIR /app
+RUN addgroup --system --gid 1001 nodejs
+RUN adduser --system --uid 1001 nextjs
+COPY --from=builder --chown=nextjs:nodejs /app/.next ./.next
+# Run as the app user
+USER nextjs`,
+			uid: 1001,
+			want: `FROM node:20-alpine AS runner
+WORKDIR /app
+RUN adduser --system --uid 1001 --ingroup root nextjs
+COPY --from=builder --chown=1001:0 /app/.next ./.next
+# Writable by the group too, for platforms that run the image as an arbitrary UID
+RUN chown 1001:0 /app && chmod g+rwX /app
+
+# Run as the app user
+USER 1001:0`,
+		},
+		{
+			name: "created user and group",
+			content: `FROM python:3.12-slim
+WORKDIR /app
+RUN useradd --create-home --shell /bin/bash django
+RUN chown -R django:django /app
+USER django`,
+			uid: 2000, gid: 3000,
+			want: `FROM python:3.12-slim
+WORKDIR /app
+RUN groupadd --gid 3000 django && useradd --create-home --shell /bin/bash --uid 2000 --gid 3000 django
+RUN chown -R 2000:3000 /app && chmod -R g=u /app
+USER 2000:3000`,
+		},
+		{
+			name: "image user",
+			content: `FROM node:20-alpine
+WORKDIR /app
+COPY --chown=node:node . .
+USER node`,
+			uid: 1001,
+			want: `FROM node:20-alpine
+WORKDIR /app
+# UID 1001 in place of the image's node user
+RUN adduser -S -u 1001 -G root app
+
+COPY --chown=1001:0 . .
+# Writable by the group too, for platforms that run the image as an arbitrary UID
+RUN chown 1001:0 /app && chmod g+rwX /app
+
+USER 1001:0`,
+		},
+		{
+			name: "image user with the UID already",
+			content: `FROM node:20-alpine
+COPY --chown=node:node . .
+USER node`,
+			uid: 1000, gid: 1000,
+			want: `FROM node:20-alpine
+COPY --chown=1000:1000 . .
+USER 1000:1000`,
+		},
+		{
+			name: "no shell",
+			content: `FROM gcr.io/distroless/static-debian12:nonroot
+WORKDIR /app
+USER nonroot:nonroot`,
+			uid: 1001,
+			want: `FROM gcr.io/distroless/static-debian12:nonroot
+WORKDIR /app
+USER 1001:0`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := applyUser(tt.content, tt.uid, tt.gid); got != tt.want {
+				t.Errorf("applyUser() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUser(t *testing.T) {
+	for user, want := range map[string][2]int{"": {1001, 0}, "2000": {2000, 0}, "2000:3000": {2000, 3000}} {
+		uid, gid, err := parseUser(user)
+		if err != nil || uid != want[0] || gid != want[1] {
+			t.Errorf("parseUser(%q) = %d, %d, %v", user, uid, gid, err)
+		}
+	}
+	for _, user := range []string{"0", "0:0", "app", "1001:root", "-1"} {
+		if _, _, err := parseUser(user); err == nil {
+			t.Errorf("parseUser(%q) succeeded, want an error", user)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package history keeps a local log of dockerizer runs for the stats
+// command. Nothing in it is ever sent anywhere.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Run outcomes
+const (
+	OutcomeSuccess   = "success"
+	OutcomeFailure   = "failure"
+	OutcomeCancelled = "cancelled"
+)
+
+// Run is one dockerize, init or agent run
+type Run struct {
+	Time       time.Time `json:"time"`
+	Command    string    `json:"command"`
+	Path       string    `json:"path"`
+	Detected   bool      `json:"detected"`
+	Language   string    `json:"language,omitempty"`
+	Framework  string    `json:"framework,omitempty"`
+	Confidence int       `json:"confidence,omitempty"`
+	AI         bool      `json:"ai"` // The configuration came from an AI provider
+	DurationMS int64     `json:"duration_ms"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Stack returns language/framework, or "undetected"
+func (r Run) Stack() string {
+	switch {
+	case !r.Detected || r.Language == "":
+		return "undetected"
+	case r.Framework == "":
+		return r.Language
+	}
+	return r.Language + "/" + r.Framework
+}
+
+// LogPath is where runs are kept, one JSON object per line
+func LogPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the history log: %w", err)
+	}
+	return filepath.Join(home, ".dockerizer", "history.jsonl"), nil
+}
+
+// Record appends a run to the history log
+func Record(run Run) error {
+	path, err := LogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer f.Close()
+
+	if err := json.NewEncoder(f).Encode(run); err != nil {
+		return fmt.Errorf("failed to write history log: %w", err)
+	}
+	return nil
+}
+
+// Load reads the runs made since the given time (zero for all). A missing
+// log means no runs yet.
+func Load(since time.Time) ([]Run, error) {
+	path, err := LogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer f.Close()
+
+	var runs []Run
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var r Run
+		if json.Unmarshal(sc.Bytes(), &r) != nil {
+			continue // Skip corrupt lines rather than losing the whole report
+		}
+		if !r.Time.Before(since) {
+			runs = append(runs, r)
+		}
+	}
+	return runs, sc.Err()
+}
+
+// StackCount is how often a stack was seen
+type StackCount struct {
+	Stack string `json:"stack"`
+	Runs  int    `json:"runs"`
+}
+
+// Stats summarizes runs
+type Stats struct {
+	Runs          int            `json:"runs"`
+	Repositories  int            `json:"repositories"`
+	Outcomes      map[string]int `json:"outcomes"`
+	SuccessRate   float64        `json:"success_rate"`
+	AIRate        float64        `json:"ai_rate"` // Share of successful runs generated by AI
+	AvgDurationMS int64          `json:"avg_duration_ms"`
+	AvgConfidence int            `json:"avg_confidence"` // Over runs that detected a stack
+	Stacks        []StackCount   `json:"stacks"`
+	Commands      map[string]int `json:"commands"`
+}
+
+// Summarize computes stats over runs. Durations and the AI rate only count
+// successful runs, so failures that stop early do not skew them.
+func Summarize(runs []Run) Stats {
+	s := Stats{
+		Runs:     len(runs),
+		Outcomes: make(map[string]int),
+		Commands: make(map[string]int),
+	}
+	repos := make(map[string]bool)
+	stacks := make(map[string]int)
+	var succeeded, withAI, detected, confidence int
+	var duration int64
+	for _, r := range runs {
+		s.Outcomes[r.Outcome]++
+		s.Commands[r.Command]++
+		repos[r.Path] = true
+		stacks[r.Stack()]++
+		if r.Detected {
+			detected++
+			confidence += r.Confidence
+		}
+		if r.Outcome != OutcomeSuccess {
+			continue
+		}
+		succeeded++
+		duration += r.DurationMS
+		if r.AI {
+			withAI++
+		}
+	}
+	s.Repositories = len(repos)
+
+	if len(runs) > 0 {
+		s.SuccessRate = float64(succeeded) / float64(len(runs))
+	}
+	if succeeded > 0 {
+		s.AIRate = float64(withAI) / float64(succeeded)
+		s.AvgDurationMS = duration / int64(succeeded)
+	}
+	if detected > 0 {
+		s.AvgConfidence = confidence / detected
+	}
+
+	for stack, n := range stacks {
+		s.Stacks = append(s.Stacks, StackCount{Stack: stack, Runs: n})
+	}
+	sort.Slice(s.Stacks, func(i, j int) bool {
+		if s.Stacks[i].Runs != s.Stacks[j].Runs {
+			return s.Stacks[i].Runs > s.Stacks[j].Runs
+		}
+		return s.Stacks[i].Stack < s.Stacks[j].Stack
+	})
+	return s
+}
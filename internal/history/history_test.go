@@ -0,0 +1,55 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordAndSummarize(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	runs := []Run{
+		{Command: "dockerize", Path: "/a", Detected: true, Language: "nodejs", Framework: "nextjs", Confidence: 90, DurationMS: 100, Outcome: OutcomeSuccess},
+		{Command: "dockerize", Path: "/b", Detected: true, Language: "nodejs", Framework: "nextjs", Confidence: 70, AI: true, DurationMS: 300, Outcome: OutcomeSuccess},
+		{Command: "init", Path: "/a", Detected: true, Language: "go", Confidence: 80, DurationMS: 50, Outcome: OutcomeSuccess},
+		{Command: "dockerize", Path: "/c", DurationMS: 5, Outcome: OutcomeFailure, Error: "no stack detected"},
+	}
+	for i, r := range runs {
+		r.Time = time.Now().Add(time.Duration(i-len(runs)) * time.Hour)
+		if err := Record(r); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	loaded, err := Load(time.Time{})
+	if err != nil || len(loaded) != len(runs) {
+		t.Fatalf("Load() = %d runs, %v", len(loaded), err)
+	}
+	if recent, _ := Load(time.Now().Add(-90 * time.Minute)); len(recent) != 1 {
+		t.Errorf("Load(since) = %d runs, want 1", len(recent))
+	}
+
+	s := Summarize(loaded)
+	if s.Runs != 4 || s.Repositories != 3 || s.Outcomes[OutcomeFailure] != 1 || s.Commands["dockerize"] != 3 {
+		t.Errorf("Summarize() = %+v", s)
+	}
+	if s.SuccessRate != 0.75 || s.AIRate != 1.0/3 || s.AvgDurationMS != 150 || s.AvgConfidence != 80 {
+		t.Errorf("rates = success %v, ai %v, duration %d, confidence %d", s.SuccessRate, s.AIRate, s.AvgDurationMS, s.AvgConfidence)
+	}
+	want := []StackCount{{"nodejs/nextjs", 2}, {"go", 1}, {"undetected", 1}}
+	if len(s.Stacks) != len(want) {
+		t.Fatalf("Stacks = %v", s.Stacks)
+	}
+	for i := range want {
+		if s.Stacks[i] != want[i] {
+			t.Errorf("Stacks[%d] = %v, want %v", i, s.Stacks[i], want[i])
+		}
+	}
+}
+
+func TestRecordWithoutHome(t *testing.T) {
+	t.Setenv("HOME", "")
+	if err := Record(Run{Command: "dockerize", Time: time.Now()}); err == nil {
+		t.Error("Record() without a home directory should be an error, not a log in the working directory")
+	}
+}
@@ -0,0 +1,88 @@
+package imagesize
+
+import (
+	"strings"
+)
+
+// baseSizes are the approximate compressed sizes of common base images, in
+// MB, for when the registry can't be asked. The first entry whose repository
+// matches and whose variant the tag contains wins, so variants come first.
+var baseSizes = []struct {
+	repository string
+	variant    string
+	mb         int64
+}{
+	{"alpine", "", 4},
+	{"busybox", "", 2},
+	{"debian", "slim", 29},
+	{"debian", "", 49},
+	{"ubuntu", "", 29},
+	{"node", "alpine", 50},
+	{"node", "slim", 75},
+	{"node", "", 390},
+	{"python", "alpine", 20},
+	{"python", "slim", 45},
+	{"python", "", 370},
+	{"golang", "alpine", 80},
+	{"golang", "", 280},
+	{"rust", "alpine", 300},
+	{"rust", "slim", 270},
+	{"rust", "", 550},
+	{"ruby", "alpine", 30},
+	{"ruby", "slim", 70},
+	{"ruby", "", 360},
+	{"php", "alpine", 35},
+	{"php", "", 165},
+	{"composer", "", 70},
+	{"eclipse-temurin", "jre-alpine", 70},
+	{"eclipse-temurin", "jre", 90},
+	{"eclipse-temurin", "", 200},
+	{"maven", "", 300},
+	{"gradle", "", 330},
+	{"elixir", "alpine", 60},
+	{"elixir", "", 400},
+	{"nginx", "alpine", 20},
+	{"nginx", "", 70},
+	{"httpd", "alpine", 20},
+	{"httpd", "", 60},
+	{"caddy", "", 20},
+	{"oven/bun", "alpine", 40},
+	{"oven/bun", "", 60},
+	{"denoland/deno", "", 50},
+	{"dotnet/aspnet", "alpine", 45},
+	{"dotnet/aspnet", "", 90},
+	{"dotnet/runtime", "", 80},
+	{"dotnet/sdk", "", 300},
+	{"distroless/static", "", 1},
+	{"distroless/base", "", 9},
+	{"distroless/cc", "", 10},
+	{"distroless/python3", "", 20},
+	{"distroless/nodejs", "", 45},
+	{"distroless/java", "", 80},
+}
+
+// KnownBaseSize returns the approximate compressed size of a common base
+// image, without asking its registry
+func KnownBaseSize(image string) (int64, bool) {
+	name, tag := image, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "docker.io/"), "library/")
+
+	for _, b := range baseSizes {
+		repository := b.repository
+		matched := name == repository || strings.HasSuffix(name, "/"+repository)
+		if strings.HasPrefix(repository, "distroless/") {
+			// gcr.io/distroless/nodejs22-debian12 and the like
+			matched = strings.Contains(name, repository)
+		}
+		if matched && strings.Contains(tag, b.variant) {
+			return b.mb * MB, true
+		}
+	}
+	return 0, false
+}
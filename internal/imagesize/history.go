@@ -0,0 +1,70 @@
+package imagesize
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HistoryLayer is a layer of a built image, from docker history
+type HistoryLayer struct {
+	CreatedBy string `json:"created_by"`
+	Size      int64  `json:"size"` // Uncompressed, as docker history reports it
+}
+
+// ParseHistory reads the output of docker history --no-trunc --format
+// '{{json .}}', newest layer first. Layers without content are left out.
+func ParseHistory(output string) ([]HistoryLayer, error) {
+	var layers []HistoryLayer
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var entry struct {
+			CreatedBy string `json:"CreatedBy"`
+			Size      string `json:"Size"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("unexpected docker history output: %w", err)
+		}
+		size, err := ParseSize(entry.Size)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			continue
+		}
+		createdBy := strings.TrimPrefix(entry.CreatedBy, "/bin/sh -c #(nop) ")
+		createdBy = strings.TrimSpace(strings.TrimPrefix(createdBy, "|"))
+		layers = append(layers, HistoryLayer{CreatedBy: createdBy, Size: size})
+	}
+	return layers, nil
+}
+
+// sizeUnits are the suffixes ParseSize accepts, in decimal units as Docker
+// uses them
+var sizeUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"gb", 1e9}, {"mb", 1e6}, {"kb", 1e3}, {"b", 1},
+}
+
+// ParseSize parses a size such as "250MB", "1.2 GB" or "0B"; a bare number
+// is in MB
+func ParseSize(s string) (int64, error) {
+	value := strings.ToLower(strings.TrimSpace(s))
+	unit := float64(MB)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value, unit = strings.TrimSpace(strings.TrimSuffix(value, u.suffix)), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * unit), nil
+}
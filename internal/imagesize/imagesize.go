@@ -0,0 +1,436 @@
+// Package imagesize estimates the size of the image a Dockerfile builds,
+// stage by stage and layer by layer, from the size of its base images and
+// the weight of the project's dependencies. Sizes are compressed, as a
+// registry stores them and a pull downloads them.
+package imagesize
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// MB is a megabyte, in the decimal units Docker reports sizes in
+const MB = 1_000_000
+
+// Dependency weights: the typical compressed size an installed package adds
+const (
+	nodePackage     = 1_500_000 // A direct npm dependency, its own dependencies included
+	pythonPackage   = 5 * MB
+	rubyGem         = 1 * MB // A locked gem
+	composerPackage = 1 * MB
+	javaDependency  = 1_500_000
+	goModule        = 2 * MB
+	aptPackage      = 8 * MB // Debian packages pull in their own dependencies
+	apkPackage      = 2 * MB
+	aptLists        = 20 * MB
+	nativeBinary    = 12 * MB // A statically linked Go or Rust binary
+	buildOutput     = 5 * MB  // Bundled frontend assets, compiled classes and the like
+	systemFiles     = MB / 4  // CA certificates, time zone data and the like
+	sourceRatio     = 0.4     // Compressed size of source files
+)
+
+// Report is the estimated size of an image
+type Report struct {
+	Total       int64    `json:"total"` // The final image
+	Stages      []*Stage `json:"stages"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// Stage is the estimate of one build stage
+type Stage struct {
+	Name       string  `json:"name"`
+	Base       string  `json:"base"`
+	BaseSize   int64   `json:"base_size"`
+	BaseSource string  `json:"base_source"` // registry, estimate, stage or unknown
+	Shipped    bool    `json:"shipped"`     // Part of the final image
+	Layers     []Layer `json:"layers"`
+	Size       int64   `json:"size"` // Base plus layers
+
+	node, nodeDev int64 // node_modules installed, and how much of it is dev dependencies
+	python        int64
+	output        int64 // Binaries and assets a later stage would copy
+	aptLists      bool  // apt lists left in a layer
+	pipCache      bool  // pip download cache left in a layer
+	builds        bool  // Compiles or bundles the app
+}
+
+// Layer is the estimate of one instruction that adds a layer
+type Layer struct {
+	Line        int    `json:"line"`
+	Instruction string `json:"instruction"`
+	Size        int64  `json:"size"`
+	Basis       string `json:"basis"` // What the estimate rests on
+}
+
+// Options tune an estimate
+type Options struct {
+	// BaseSize looks up the size of a base image, e.g. on its registry. When
+	// nil or failing, a table of common images is used.
+	BaseSize func(image string) (int64, error)
+	// Scan is the project the Dockerfile builds; its manifests weigh the
+	// dependency installs and its files the copies from the build context
+	Scan *scanner.ScanResult
+}
+
+// Estimate estimates the size of the image d builds
+func Estimate(d *dockerfile.Dockerfile, opts Options) *Report {
+	e := &estimator{d: d, opts: opts, sizes: map[string]int64{}}
+
+	report := &Report{}
+	final := d.FinalStage()
+	shipped := map[*dockerfile.Stage]bool{}
+	for s := final; s != nil; s = d.BaseStage(s) {
+		shipped[s] = true
+	}
+	stages := map[*dockerfile.Stage]*Stage{}
+	for _, s := range d.Stages {
+		stage := e.stage(s, stages)
+		stage.Shipped = shipped[s]
+		stages[s] = stage
+		report.Stages = append(report.Stages, stage)
+	}
+	if final != nil {
+		report.Total = stages[final].Size
+		report.Suggestions = suggestions(stages[final], report.Stages)
+	}
+	return report
+}
+
+type estimator struct {
+	d     *dockerfile.Dockerfile
+	opts  Options
+	sizes map[string]int64 // Files of the build context by path, read once
+}
+
+// stage estimates a build stage; earlier holds the stages before it
+func (e *estimator) stage(s *dockerfile.Stage, earlier map[*dockerfile.Stage]*Stage) *Stage {
+	stage := &Stage{Name: s.Name, Base: e.d.ExpandArgs(s.Image)}
+	if stage.Name == "" {
+		stage.Name = fmt.Sprintf("#%d", s.Index+1)
+	}
+	if base := e.d.BaseStage(s); base != nil {
+		parent := earlier[base]
+		stage.BaseSize, stage.BaseSource = parent.Size, "stage"
+		stage.node, stage.nodeDev, stage.python, stage.output = parent.node, parent.nodeDev, parent.python, parent.output
+	} else {
+		stage.BaseSize, stage.BaseSource = e.baseSize(stage.Base)
+	}
+
+	production := false // NODE_ENV=production makes npm skip dev dependencies
+	for _, in := range s.Instructions {
+		switch in.Cmd {
+		case "ENV":
+			production = production || strings.Contains(in.Value, "NODE_ENV=production") || strings.Contains(in.Value, "NODE_ENV production")
+		case "RUN":
+			stage.Layers = append(stage.Layers, e.run(stage, in, production))
+		case "COPY", "ADD":
+			stage.Layers = append(stage.Layers, e.copy(stage, in, earlier))
+		}
+	}
+
+	stage.Size = stage.BaseSize
+	for _, l := range stage.Layers {
+		stage.Size += l.Size
+	}
+	return stage
+}
+
+// baseSize looks up the size of a base image, on the registry first
+func (e *estimator) baseSize(image string) (int64, string) {
+	if strings.EqualFold(image, "scratch") {
+		return 0, "estimate"
+	}
+	if e.opts.BaseSize != nil && !strings.Contains(image, "$") {
+		if size, err := e.opts.BaseSize(image); err == nil {
+			return size, "registry"
+		}
+	}
+	if size, ok := KnownBaseSize(image); ok {
+		return size, "estimate"
+	}
+	return 0, "unknown"
+}
+
+var (
+	npmInstall    = regexp.MustCompile(`\b(npm (ci|install|i)|yarn( install)?|pnpm (install|i)|bun install)\b`)
+	npmProduction = regexp.MustCompile(`--omit[= ]dev|--production|--prod\b|--only[= ]prod`)
+	pipInstall    = regexp.MustCompile(`\b(pip3? install|poetry install|uv sync|uv pip install|pipenv install|pdm install)\b`)
+	buildCommand  = regexp.MustCompile(`\b(npm run build|yarn (run )?build|pnpm (run )?build|bun run build|next build|vite build|tsc\b|mvn\b[^&;]*(package|install)|gradle\w*\b[^&;]*(build|bootJar|jar|assemble)|dotnet publish|mix release|rake assets:precompile)`)
+	nativeBuild   = regexp.MustCompile(`\b(go build|cargo build)\b`)
+)
+
+// run estimates what a RUN instruction adds
+func (e *estimator) run(stage *Stage, in *dockerfile.Instruction, production bool) Layer {
+	script := in.Value
+	for _, h := range in.Heredocs {
+		script += "\n" + h.Content
+	}
+	layer := Layer{Line: in.StartLine, Instruction: shorten("RUN " + in.Value)}
+	cacheMount := false
+	for _, f := range in.Flags {
+		cacheMount = cacheMount || f.Name == "mount" && strings.Contains(f.Value, "type=cache")
+	}
+	var basis []string
+	add := func(size int64, format string, args ...interface{}) {
+		layer.Size += size
+		basis = append(basis, fmt.Sprintf(format, args...))
+	}
+	meta := e.metadata()
+
+	if n := countPackages(script, "apt-get install", "apt install"); n > 0 {
+		add(int64(n)*aptPackage, "%d apt packages", n)
+		if !strings.Contains(script, "/var/lib/apt/lists") && !cacheMount {
+			add(aptLists, "apt lists left behind")
+			stage.aptLists = true
+		}
+	}
+	if n := countPackages(script, "apk add"); n > 0 {
+		add(int64(n)*apkPackage, "%d apk packages", n)
+	}
+	if npmInstall.MatchString(script) && meta.PackageJSON != nil {
+		prod := int64(len(meta.PackageJSON.Dependencies)) * nodePackage
+		dev := int64(len(meta.PackageJSON.DevDependencies)) * nodePackage
+		if production || npmProduction.MatchString(script) || dev == 0 {
+			add(prod, "%d npm dependencies", len(meta.PackageJSON.Dependencies))
+			stage.node, stage.nodeDev = prod, 0
+		} else {
+			add(prod+dev, "%d npm dependencies and %d dev dependencies", len(meta.PackageJSON.Dependencies), len(meta.PackageJSON.DevDependencies))
+			stage.node, stage.nodeDev = prod+dev, dev
+		}
+	}
+	if pipInstall.MatchString(script) {
+		n := len(meta.Requirements)
+		if meta.PyProject != nil && (n == 0 || !strings.Contains(script, "-r ")) {
+			n = len(meta.PyProject.Dependencies)
+		}
+		size := int64(n) * pythonPackage
+		if !strings.Contains(script, "--no-cache-dir") && strings.Contains(script, "pip") && !cacheMount {
+			size += size / 5
+			stage.pipCache = true
+			add(size, "%d Python packages and the pip cache", n)
+		} else {
+			add(size, "%d Python packages", n)
+		}
+		stage.python += size
+	}
+	if strings.Contains(script, "bundle install") && meta.Gemfile != nil {
+		n := len(meta.Gemfile.Locked)
+		if n == 0 {
+			n = 3 * len(meta.Gemfile.Gems)
+		}
+		add(int64(n)*rubyGem, "%d gems", n)
+	}
+	if strings.Contains(script, "composer install") && meta.ComposerJSON != nil {
+		n := len(meta.ComposerJSON.Require)
+		add(int64(n)*composerPackage, "%d Composer packages", n)
+	}
+	if strings.Contains(script, "go mod download") && meta.GoMod != nil && !cacheMount {
+		n := len(meta.GoMod.Require)
+		add(int64(n)*goModule, "%d Go modules", n)
+	}
+	if nativeBuild.MatchString(script) {
+		add(nativeBinary, "a compiled binary")
+		stage.output += nativeBinary
+		stage.builds = true
+	}
+	if buildCommand.MatchString(script) {
+		size := int64(buildOutput)
+		if meta.PomXML != nil {
+			size += int64(len(meta.PomXML.Dependencies)) * javaDependency
+		}
+		add(size, "build output")
+		stage.output += size
+		stage.builds = true
+	}
+
+	if len(basis) == 0 {
+		layer.Basis = "no packages installed; small"
+	} else {
+		layer.Basis = strings.Join(basis, ", ")
+	}
+	return layer
+}
+
+// copy estimates what a COPY or ADD adds
+func (e *estimator) copy(stage *Stage, in *dockerfile.Instruction, earlier map[*dockerfile.Stage]*Stage) Layer {
+	layer := Layer{Line: in.StartLine, Instruction: shorten(in.Cmd + " " + in.Value)}
+	if len(in.Args) < 2 {
+		layer.Basis = "inline file"
+		return layer
+	}
+	sources := in.Args[:len(in.Args)-1]
+
+	from, ok := in.Flag("from")
+	if !ok {
+		size := int64(0)
+		for _, src := range sources {
+			if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+				size += buildOutput
+				continue
+			}
+			size += e.contextSize(src)
+		}
+		layer.Size = int64(float64(size) * sourceRatio)
+		layer.Basis = "files of the build context"
+		if e.opts.Scan == nil {
+			layer.Basis = "build context not scanned"
+		}
+		return layer
+	}
+
+	var source *Stage
+	if s := e.d.Stage(from); s != nil {
+		source = earlier[s]
+	}
+	if source == nil {
+		layer.Size, layer.Basis = buildOutput, "files from "+from+", guessed"
+		return layer
+	}
+	var basis []string
+	for _, src := range sources {
+		switch {
+		case strings.Contains(src, "node_modules"):
+			layer.Size += source.node
+			stage.node, stage.nodeDev = stage.node+source.node, stage.nodeDev+source.nodeDev
+			basis = append(basis, "node_modules of stage "+source.Name)
+		case strings.Contains(src, "venv") || strings.Contains(src, "site-packages") || strings.Contains(src, ".local") || strings.Contains(src, "/install"):
+			layer.Size += source.python
+			stage.python += source.python
+			basis = append(basis, "Python packages of stage "+source.Name)
+		case strings.HasPrefix(src, "/etc/") || strings.HasPrefix(src, "/usr/share/"):
+			layer.Size += systemFiles
+			basis = append(basis, "system files of stage "+source.Name)
+		case source.output > 0:
+			layer.Size += source.output
+			stage.output += source.output
+			basis = append(basis, "build output of stage "+source.Name)
+		default:
+			layer.Size += buildOutput
+			basis = append(basis, "files of stage "+source.Name+", guessed")
+		}
+	}
+	layer.Basis = strings.Join(basis, ", ")
+	return layer
+}
+
+// contextSize sums the files of the build context a COPY source matches
+func (e *estimator) contextSize(src string) int64 {
+	if e.opts.Scan == nil || e.opts.Scan.FileTree == nil {
+		return 0
+	}
+	src = strings.TrimSuffix(strings.TrimPrefix(path.Clean(strings.TrimPrefix(src, "./")), "/"), "/")
+	var size int64
+	for _, f := range e.opts.Scan.FileTree.Files {
+		matched := src == "." || f == src || strings.HasPrefix(f, src+"/")
+		if !matched && strings.ContainsAny(src, "*?[") {
+			matched, _ = path.Match(src, f)
+		}
+		if matched {
+			size += e.fileSize(f)
+		}
+	}
+	return size
+}
+
+func (e *estimator) fileSize(f string) int64 {
+	if size, ok := e.sizes[f]; ok {
+		return size
+	}
+	var size int64
+	if info, err := os.Stat(filepath.Join(e.opts.Scan.Path, filepath.FromSlash(f))); err == nil {
+		size = info.Size()
+	}
+	e.sizes[f] = size
+	return size
+}
+
+func (e *estimator) metadata() *scanner.Metadata {
+	if e.opts.Scan == nil || e.opts.Scan.Metadata == nil {
+		return &scanner.Metadata{}
+	}
+	return e.opts.Scan.Metadata
+}
+
+// countPackages counts the packages named after any of the install commands
+// in a script, up to the end of each command
+func countPackages(script string, commands ...string) int {
+	n := 0
+	for _, command := range commands {
+		for _, part := range strings.Split(script, command)[1:] {
+			if end := strings.IndexAny(part, "&;|\n"); end >= 0 {
+				part = part[:end]
+			}
+			for _, word := range strings.Fields(strings.ReplaceAll(part, `\`, " ")) {
+				if !strings.HasPrefix(word, "-") && !strings.HasPrefix(word, "$") {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// suggestions lists what would shrink the final image
+func suggestions(final *Stage, stages []*Stage) []string {
+	var out []string
+	if len(stages) == 1 && final.builds {
+		out = append(out, "Use a multi-stage build: compile in a build stage and copy only its output into a small runtime image, leaving compilers, dev dependencies and sources behind")
+	}
+	if final.nodeDev > 0 {
+		out = append(out, fmt.Sprintf("Prune dev dependencies from the final image (npm ci --omit=dev, or install them only in the build stage): about %s", Format(final.nodeDev)))
+	}
+	if final.BaseSource != "stage" && final.BaseSize > 150*MB && !smallVariant(final.Base) {
+		out = append(out, fmt.Sprintf("%s is a full image (%s); its -slim or -alpine variant is a fraction of that", final.Base, Format(final.BaseSize)))
+	}
+	for _, s := range stages {
+		if !s.Shipped {
+			continue
+		}
+		if s.aptLists {
+			out = append(out, fmt.Sprintf("Remove /var/lib/apt/lists/* in the RUN that installs packages (stage %s): about %s", s.Name, Format(aptLists)))
+		}
+		if s.pipCache {
+			out = append(out, fmt.Sprintf("Install Python packages with pip --no-cache-dir (stage %s)", s.Name))
+		}
+	}
+	return out
+}
+
+// smallVariant reports whether an image is a minimal variant already
+func smallVariant(image string) bool {
+	for _, marker := range []string{"alpine", "slim", "distroless", "chainguard", "busybox", "scratch"} {
+		if strings.Contains(strings.ToLower(image), marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// shorten cuts an instruction down to one line for a report
+func shorten(s string) string {
+	s = strings.Join(strings.Fields(strings.ReplaceAll(s, `\`, " ")), " ")
+	if len(s) > 70 {
+		s = s[:67] + "..."
+	}
+	return s
+}
+
+// Format formats a size in decimal units, e.g. "45.2 MB"
+func Format(size int64) string {
+	switch {
+	case size >= 1000*MB:
+		return fmt.Sprintf("%.2f GB", float64(size)/(1000*MB))
+	case size >= MB:
+		return fmt.Sprintf("%.1f MB", float64(size)/MB)
+	case size >= 1000:
+		return fmt.Sprintf("%.1f kB", float64(size)/1000)
+	}
+	return fmt.Sprintf("%d B", size)
+}
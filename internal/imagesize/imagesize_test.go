@@ -0,0 +1,84 @@
+package imagesize
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/dockerfile"
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+func TestEstimate(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"dependencies":{"express":"4","pg":"8"},"devDependencies":{"typescript":"5","vite":"5"}}`,
+		"src/index.ts": strings.Repeat("x", 10_000),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scan, err := scanner.New().Scan(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := dockerfile.Parse(`ARG NODE=20
+FROM node:${NODE}-alpine AS build
+WORKDIR /app
+COPY . .
+RUN npm ci && npm run build
+
+FROM node:${NODE}
+RUN apt-get update && apt-get install -y curl tini
+COPY --from=build /app/node_modules ./node_modules
+COPY --from=build /app/dist ./dist
+CMD ["node", "dist/index.js"]
+`)
+	report := Estimate(d, Options{Scan: scan})
+
+	build, final := report.Stages[0], report.Stages[1]
+	if build.Base != "node:20-alpine" || build.BaseSize != 50*MB || build.Shipped {
+		t.Errorf("build stage = %+v", build)
+	}
+	if got := build.Layers[0].Size; got < 4000 || got > 5000 {
+		t.Errorf("COPY . . = %d, want the compressed context", got)
+	}
+	// Base, two apt packages and their lists, four npm packages, build output
+	want := int64(390*MB + 2*aptPackage + aptLists + 4*nodePackage + buildOutput)
+	if report.Total != want || final.Size != want || !final.Shipped {
+		t.Errorf("total = %s, want %s", Format(report.Total), Format(want))
+	}
+
+	text := strings.Join(report.Suggestions, "\n")
+	for _, s := range []string{"Prune dev dependencies", "full image", "/var/lib/apt/lists"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("suggestions missing %q:\n%s", s, text)
+		}
+	}
+}
+
+func TestParseHistory(t *testing.T) {
+	output := `{"CreatedBy":"CMD [\"node\" \"index.js\"]","Size":"0B"}
+{"CreatedBy":"RUN /bin/sh -c npm ci # buildkit","Size":"48.3MB"}
+{"CreatedBy":"/bin/sh -c #(nop) ADD file:abc in / ","Size":"7.8MB"}
+`
+	layers, err := ParseHistory(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(layers) != 2 || layers[0].Size != 48_300_000 || layers[1].CreatedBy != "ADD file:abc in /" {
+		t.Errorf("layers = %+v", layers)
+	}
+	if size, err := ParseSize("1.5 GB"); err != nil || size != 1_500_000_000 {
+		t.Errorf("ParseSize = %d, %v", size, err)
+	}
+}
@@ -161,6 +161,10 @@ func (s *Server) handleToolsList(msg *Message) *Message {
 						"type":        "boolean",
 						"description": "Whether to overwrite existing files",
 					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Return the generated files' contents without writing them",
+					},
 				},
 				"required": []string{"path"},
 			},
@@ -339,6 +343,11 @@ func (s *Server) toolGenerate(ctx context.Context, args map[string]interface{})
 	}
 
 	overwrite, _ := args["overwrite"].(bool)
+	dryRun, _ := args["dry_run"].(bool)
+	existing := outputPath // Where a Containerfile or compose.yaml keeps its name
+	if dryRun {
+		outputPath = "" // Render only
+	}
 
 	// Scan and detect
 	scan, err := s.scanner.Scan(ctx, path)
@@ -357,7 +366,7 @@ func (s *Server) toolGenerate(ctx context.Context, args map[string]interface{})
 	}
 
 	// Generate
-	gen := generator.New(generator.WithOverwrite(overwrite))
+	gen := generator.New(generator.WithOverwrite(overwrite), generator.WithExistingNames(existing))
 	output, err := gen.Generate(result, outputPath)
 	if err != nil {
 		return nil, err
@@ -368,12 +377,17 @@ func (s *Server) toolGenerate(ctx context.Context, args map[string]interface{})
 		files = append(files, f)
 	}
 
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"success":   true,
 		"files":     files,
 		"language":  result.Language,
 		"framework": result.Framework,
-	}, nil
+	}
+	if dryRun {
+		response["dry_run"] = true
+		response["contents"] = output.Contents()
+	}
+	return response, nil
 }
 
 func (s *Server) toolDockerBuild(ctx context.Context, args map[string]interface{}) (interface{}, error) {
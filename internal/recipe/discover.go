@@ -0,0 +1,212 @@
+package recipe
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Sources a recipe can come from, in order of precedence
+const (
+	SourceProject = "project" // <project>/.dockerizer/recipes
+	SourceUser    = "user"    // ~/.dockerizer/recipes
+	SourceBuiltin = "builtin"
+)
+
+// Entry describes an available recipe
+type Entry struct {
+	Name        string
+	Description string
+	Source      string // SourceProject, SourceUser or SourceBuiltin
+	Path        string // File the recipe was loaded from; empty for built-ins
+	Err         error  // Set when the file does not load or validate
+}
+
+// ProjectRecipeDir is where a project keeps its recipes
+func ProjectRecipeDir(projectDir string) string {
+	return filepath.Join(projectDir, ".dockerizer", "recipes")
+}
+
+// UserRecipeDir is where the user keeps recipes shared across projects
+func UserRecipeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user recipes: %w", err)
+	}
+	return filepath.Join(home, ".dockerizer", "recipes"), nil
+}
+
+// Discover lists the built-in recipes and the *.yaml and *.yml files in the
+// project and user recipe directories, named after the file. A project recipe
+// shadows a user recipe of the same name, and both shadow a built-in.
+func Discover(projectDir string) []Entry {
+	byName := make(map[string]Entry)
+	for _, name := range ListBuiltinRecipes() {
+		r, err := GetBuiltinRecipe(name)
+		entry := Entry{Name: name, Source: SourceBuiltin, Err: err}
+		if r != nil {
+			entry.Description = r.Description
+		}
+		byName[name] = entry
+	}
+
+	// Lowest precedence first, so later directories overwrite; without a
+	// home directory there are no user recipes
+	type recipeDir struct{ path, source string }
+	var dirs []recipeDir
+	if userDir, err := UserRecipeDir(); err == nil {
+		dirs = append(dirs, recipeDir{userDir, SourceUser})
+	}
+	dirs = append(dirs, recipeDir{ProjectRecipeDir(projectDir), SourceProject})
+	for _, dir := range dirs {
+		for _, file := range recipeFiles(dir.path) {
+			name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".yaml"), ".yml")
+			entry := Entry{Name: name, Source: dir.source, Path: file}
+			if r, err := Load(file); err != nil {
+				entry.Err = err
+			} else if err := r.Validate(); err != nil {
+				entry.Err = err
+			} else {
+				entry.Description = r.Description
+			}
+			byName[name] = entry
+		}
+	}
+
+	entries := make([]Entry, 0, len(byName))
+	for _, entry := range byName {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Find loads the recipe called name, looking in the project and user recipe
+// directories before the built-ins
+func Find(projectDir, name string) (*Recipe, Entry, error) {
+	for _, entry := range Discover(projectDir) {
+		if entry.Name != name {
+			continue
+		}
+		if entry.Err != nil {
+			return nil, entry, entry.Err
+		}
+		if entry.Source == SourceBuiltin {
+			r, err := GetBuiltinRecipe(name)
+			return r, entry, err
+		}
+		r, err := Load(entry.Path)
+		return r, entry, err
+	}
+	return nil, Entry{}, fmt.Errorf("unknown recipe: %s (see dockerizer recipe list)", name)
+}
+
+// recipeFiles returns the recipe files in dir, or nil when it does not exist
+func recipeFiles(dir string) []string {
+	var files []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// validName matches recipe names usable as file and command names
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Scaffold writes a commented template recipe called name into dir, along
+// with the JSON schema the template points editors at, and returns its path.
+// It refuses to overwrite an existing recipe unless force is set.
+func Scaffold(dir, name string, force bool) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid recipe name %q: use lowercase letters, digits, - and _", name)
+	}
+	path := filepath.Join(dir, name+".yaml")
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("%s already exists (use --force to overwrite)", path)
+	}
+
+	content := strings.ReplaceAll(scaffoldTemplate, "{{name}}", name)
+	r, err := LoadFromString(content)
+	if err == nil {
+		err = r.Validate()
+	}
+	if err != nil {
+		return "", fmt.Errorf("scaffold does not validate: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, SchemaFile), []byte(Schema), 0644); err != nil {
+		return "", fmt.Errorf("failed to write schema: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write recipe: %w", err)
+	}
+	return path, nil
+}
+
+// scaffoldTemplate is the starting point written by `dockerizer recipe init`
+const scaffoldTemplate = `# yaml-language-server: $schema=./` + SchemaFile + `
+#
+# Recipe: {{name}}
+# Run it with: dockerizer recipe {{name}} --path <project>
+#
+# Variables are referenced as ${name}. Built-in variables: ${path} (--path)
+# and ${image_tag} (--image-tag); set others with --var key=value.
+# A step with an id exposes its output as ${steps.<id>}; JSON output can be
+# addressed by path, e.g. ${steps.analyze.language}.
+
+name: {{name}}
+description: Describe what this recipe does
+version: "1.0"
+
+# Defaults for variables, overridden by --var
+variables:
+  image_tag: "{{name}}:latest"
+
+# Steps run in order. Once any step sets depends_on they run as a dependency
+# graph instead, with up to this many independent steps at once.
+# parallelism: 4
+
+steps:
+  - id: analyze
+    name: Analyze Repository
+    tool: dockerizer_analyze          # See: dockerizer recipe list --tools
+    args:
+      path: "${path}"
+
+  - id: generate
+    name: Generate Docker Files
+    tool: dockerizer_generate
+    # Skip the step unless the condition holds: ==, !=, contains,
+    # and/or/not and parentheses
+    condition: steps.analyze.detected == true
+    args:
+      path: "${path}"
+      overwrite: "true"
+
+  - id: build
+    name: Build Image
+    tool: docker_build
+    args:
+      path: "${path}"
+      tag: "${image_tag}"
+    retries: 2                        # Attempts before the step fails
+    timeout: 15m                      # Per attempt
+    on_error: fail                    # fail (default) or continue
+
+  # Run a step once per list item, e.g. every app of a monorepo:
+  # - name: Generate App
+  #   tool: dockerizer_generate
+  #   foreach: steps.analyze.apps
+  #   as: app
+  #   args:
+  #     path: "${path}"
+  #     app: "${app.name}"
+`
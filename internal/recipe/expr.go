@@ -0,0 +1,411 @@
+package recipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// scope holds the values visible to interpolation, conditions and foreach:
+// recipe variables, last_output, the steps map of named step outputs and
+// the current foreach item
+type scope map[string]interface{}
+
+// lookup resolves a reference such as "path", "steps.analyze.language",
+// "last_output.files[0]" or "item.name". Segments after the first walk into
+// JSON: string values are parsed on demand, so tool output that is a JSON
+// document can be addressed field by field.
+func (s scope) lookup(ref string) (interface{}, bool) {
+	segments, err := splitPath(ref)
+	if err != nil || len(segments) == 0 {
+		return nil, false
+	}
+
+	value, ok := s[segments[0]]
+	if !ok {
+		return nil, false
+	}
+	for _, seg := range segments[1:] {
+		if str, isString := value.(string); isString {
+			var parsed interface{}
+			if json.Unmarshal([]byte(str), &parsed) != nil {
+				return nil, false
+			}
+			value = parsed
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if value, ok = v[seg]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// splitPath splits a jq-style path ("a.b[0].c", ".a.b", "a.b.0") into segments
+func splitPath(ref string) ([]string, error) {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), ".")
+	var segments []string
+	for _, part := range strings.Split(ref, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name == "" && rest == "" {
+			return nil, fmt.Errorf("empty path segment in %q", ref)
+		}
+		if name != "" {
+			segments = append(segments, name)
+		}
+		for rest != "" {
+			index, after, ok := strings.Cut(rest, "]")
+			if !ok || index == "" {
+				return nil, fmt.Errorf("unterminated index in %q", ref)
+			}
+			segments = append(segments, index)
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return segments, nil
+}
+
+// stringify renders a resolved value for interpolation: scalars as text,
+// objects and lists as compact JSON
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// truthy reports whether a resolved value counts as true in a condition:
+// anything but "", "false", "0", null and empty lists or objects
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != "" && v != "false" && v != "0"
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// listItems turns a foreach value into items: a JSON list as is, a string
+// holding a JSON list parsed, and any other string split on commas and newlines
+func listItems(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case string:
+		var parsed []interface{}
+		if json.Unmarshal([]byte(v), &parsed) == nil {
+			return parsed
+		}
+		var items []interface{}
+		for _, item := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == '\n' }) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	case nil:
+		return nil
+	}
+	return []interface{}{value}
+}
+
+// interpolation matches ${reference} placeholders
+var interpolation = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// interpolate replaces ${reference} placeholders; unresolved ones are kept
+func (s scope) interpolate(text string) string {
+	return interpolation.ReplaceAllStringFunc(text, func(match string) string {
+		if value, ok := s.lookup(match[2 : len(match)-1]); ok {
+			return stringify(value)
+		}
+		return match
+	})
+}
+
+// resolve evaluates a foreach expression: a ${reference}, a bare reference,
+// or a literal comma-separated list
+func (s scope) resolve(expr string) interface{} {
+	expr = strings.TrimSpace(expr)
+	if m := interpolation.FindStringSubmatch(expr); m != nil && m[0] == expr {
+		value, _ := s.lookup(m[1])
+		return value
+	}
+	if value, ok := s.lookup(expr); ok {
+		return value
+	}
+	return s.interpolate(expr)
+}
+
+// Conditions
+//
+//	condition  := or
+//	or         := and { ("||" | "or") and }
+//	and        := unary { ("&&" | "and") unary }
+//	unary      := ("!" | "not") unary | "(" or ")" | comparison
+//	comparison := operand [ ("==" | "!=" | "contains") operand ]
+//
+// A bare word on the left of a comparison, or standing alone, is a
+// reference; on the right it is a literal, as are quoted strings. ${...}
+// is interpolated on either side. A lone operand tests truthiness.
+
+// evaluateCondition parses and evaluates a step condition
+func evaluateCondition(condition string, s scope) (bool, error) {
+	tokens, err := tokenize(condition)
+	if err != nil {
+		return false, err
+	}
+	p := &condParser{tokens: tokens, scope: s}
+	result, err := p.or()
+	if err != nil {
+		return false, err
+	}
+	if p.pos < len(p.tokens) {
+		return false, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	return result, nil
+}
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokString
+	tokInterp
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits a condition into words, quoted strings, ${...} references and operators
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(input[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in %q", input)
+			}
+			tokens = append(tokens, token{tokString, input[i+1 : i+1+end]})
+			i += end + 2
+		case strings.HasPrefix(input[i:], "${"):
+			end := strings.IndexByte(input[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated ${ in %q", input)
+			}
+			tokens = append(tokens, token{tokInterp, input[i : i+end+1]})
+			i += end + 1
+		case strings.HasPrefix(input[i:], "=="), strings.HasPrefix(input[i:], "!="),
+			strings.HasPrefix(input[i:], "&&"), strings.HasPrefix(input[i:], "||"):
+			tokens = append(tokens, token{tokOp, input[i : i+2]})
+			i += 2
+		case c == '!' || c == '(' || c == ')':
+			tokens = append(tokens, token{tokOp, string(c)})
+			i++
+		default:
+			start := i
+			for i < len(input) && !strings.ContainsRune(" \t\"'()!=&|", rune(input[i])) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected %q in %q", input[i], input)
+			}
+			word := input[start:i]
+			switch word {
+			case "and":
+				tokens = append(tokens, token{tokOp, "&&"})
+			case "or":
+				tokens = append(tokens, token{tokOp, "||"})
+			case "not":
+				tokens = append(tokens, token{tokOp, "!"})
+			case "contains":
+				tokens = append(tokens, token{tokOp, word})
+			default:
+				tokens = append(tokens, token{tokWord, word})
+			}
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	return tokens, nil
+}
+
+type condParser struct {
+	tokens []token
+	pos    int
+	scope  scope
+}
+
+func (p *condParser) peekOp(ops ...string) string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokOp {
+		return ""
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *condParser) or() (bool, error) {
+	result, err := p.and()
+	if err != nil {
+		return false, err
+	}
+	for p.peekOp("||") != "" {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return false, err
+		}
+		result = result || right
+	}
+	return result, nil
+}
+
+func (p *condParser) and() (bool, error) {
+	result, err := p.unary()
+	if err != nil {
+		return false, err
+	}
+	for p.peekOp("&&") != "" {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return false, err
+		}
+		result = result && right
+	}
+	return result, nil
+}
+
+func (p *condParser) unary() (bool, error) {
+	switch p.peekOp("!", "(") {
+	case "!":
+		p.pos++
+		result, err := p.unary()
+		return !result, err
+	case "(":
+		p.pos++
+		result, err := p.or()
+		if err != nil {
+			return false, err
+		}
+		if p.peekOp(")") == "" {
+			return false, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return result, nil
+	}
+	return p.comparison()
+}
+
+func (p *condParser) comparison() (bool, error) {
+	left, ok, err := p.operand(true)
+	if err != nil {
+		return false, err
+	}
+
+	op := p.peekOp("==", "!=", "contains")
+	if op == "" {
+		return ok && truthy(left), nil
+	}
+	p.pos++
+	right, _, err := p.operand(false)
+	if err != nil {
+		return false, err
+	}
+	expected := stringify(right)
+
+	switch op {
+	case "==":
+		return ok && stringify(left) == expected, nil
+	case "!=":
+		return !ok || stringify(left) != expected, nil
+	}
+	if !ok {
+		return false, nil
+	}
+	switch v := left.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if stringify(item) == expected {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		_, found := v[expected]
+		return found, nil
+	}
+	return strings.Contains(stringify(left), expected), nil
+}
+
+// operand reads one operand; reference says how a bare word is read
+func (p *condParser) operand(reference bool) (interface{}, bool, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, false, fmt.Errorf("missing operand")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch t.kind {
+	case tokString:
+		return t.text, true, nil
+	case tokInterp:
+		if value, ok := p.scope.lookup(t.text[2 : len(t.text)-1]); ok {
+			return value, true, nil
+		}
+		return nil, false, nil
+	case tokWord:
+		if !reference {
+			return t.text, true, nil
+		}
+		value, ok := p.scope.lookup(t.text)
+		return value, ok, nil
+	}
+	return nil, false, fmt.Errorf("unexpected %q", t.text)
+}
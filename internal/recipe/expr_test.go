@@ -0,0 +1,165 @@
+package recipe
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testScope is a scope with a recipe variable, step outputs holding JSON
+// and a foreach item
+func testScope() scope {
+	return scope{
+		"path":        "/app",
+		"debug":       "false",
+		"last_output": `{"files": ["Dockerfile", "compose.yml"], "count": 2}`,
+		"steps": map[string]interface{}{
+			"analyze": `{"language": "go", "framework": "gin", "ports": [8080], "meta": {"cgo": true}}`,
+		},
+		"item": map[string]interface{}{"name": "api"},
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want interface{}
+		ok   bool
+	}{
+		{"path", "/app", true},
+		{".path", "/app", true},
+		{"steps.analyze.language", "go", true},
+		{"steps.analyze.meta.cgo", true, true},
+		{"steps.analyze.ports[0]", float64(8080), true},
+		{"steps.analyze.ports.0", float64(8080), true},
+		{"last_output.files[1]", "compose.yml", true},
+		{"item.name", "api", true},
+
+		// Missing keys
+		{"missing", nil, false},
+		{"steps.build.output", nil, false},
+		{"steps.analyze.version", nil, false},
+		{"last_output.files[2]", nil, false},
+		{"last_output.files[-1]", nil, false},
+		{"last_output.files.first", nil, false},
+		// path is not JSON, so it has no fields
+		{"path.dir", nil, false},
+		// Malformed paths
+		{"steps..analyze", nil, false},
+		{"last_output.files[0", nil, false},
+		{"", nil, false},
+	}
+	s := testScope()
+	for _, tt := range tests {
+		got, ok := s.lookup(tt.ref)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lookup(%q) = %v, %v; want %v, %v", tt.ref, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"cd ${path}", "cd /app"},
+		{"${steps.analyze.language}/${steps.analyze.framework}", "go/gin"},
+		{"port ${steps.analyze.ports[0]}", "port 8080"},
+		{"${last_output.files}", `["Dockerfile","compose.yml"]`},
+		{"${item}", `{"name":"api"}`},
+		// Unresolved placeholders are kept
+		{"${missing} and ${steps.analyze.version}", "${missing} and ${steps.analyze.version}"},
+		{"no placeholders", "no placeholders"},
+	}
+	s := testScope()
+	for _, tt := range tests {
+		if got := s.interpolate(tt.text); got != tt.want {
+			t.Errorf("interpolate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      bool
+		wantErr   bool
+	}{
+		// Truthiness
+		{"path", true, false},
+		{"debug", false, false},
+		{"missing", false, false},
+		{"steps.analyze.meta.cgo", true, false},
+		{"steps.analyze.ports", true, false},
+		{"${steps.analyze.language}", true, false},
+
+		// Comparisons
+		{"steps.analyze.language == go", true, false},
+		{"steps.analyze.language == 'python'", false, false},
+		{`steps.analyze.language != "python"`, true, false},
+		{"steps.analyze.ports[0] == 8080", true, false},
+		{"${steps.analyze.framework} == gin", true, false},
+		{"missing == ''", false, false},
+		{"missing != go", true, false},
+		{"last_output.files contains Dockerfile", true, false},
+		{"last_output.files contains Docker", false, false},
+		{"steps.analyze.meta contains cgo", true, false},
+		{"path contains app", true, false},
+		{"missing contains app", false, false},
+
+		// Boolean operators and precedence
+		{"path && steps.analyze.language == go", true, false},
+		{"path and debug", false, false},
+		{"debug || path", true, false},
+		{"debug or missing", false, false},
+		{"!debug", true, false},
+		{"not path", false, false},
+		{"debug && path || path", true, false},
+		{"debug && (path || path)", false, false},
+		{"!(steps.analyze.language == go && debug)", true, false},
+
+		// Errors
+		{"", false, true},
+		{"path ==", false, true},
+		{"(path", false, true},
+		{"path path", false, true},
+		{"'unterminated", false, true},
+		{"${unterminated", false, true},
+	}
+	s := testScope()
+	for _, tt := range tests {
+		got, err := evaluateCondition(tt.condition, s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("evaluateCondition(%q) error = %v, wantErr %v", tt.condition, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("evaluateCondition(%q) = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestResolveForeach(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []interface{}
+	}{
+		{"${last_output.files}", []interface{}{"Dockerfile", "compose.yml"}},
+		{"last_output.files", []interface{}{"Dockerfile", "compose.yml"}},
+		{"steps.analyze.ports", []interface{}{float64(8080)}},
+		{"alpine, debian,\nubuntu", []interface{}{"alpine", "debian", "ubuntu"}},
+		{`["a", "b"]`, []interface{}{"a", "b"}},
+		// Values that are not lists
+		{"${path}", []interface{}{"/app"}},
+		{"steps.analyze.meta.cgo", []interface{}{true}},
+		{"last_output.count", []interface{}{float64(2)}},
+		{"item", []interface{}{map[string]interface{}{"name": "api"}}},
+		{"${missing}", nil},
+		{"", nil},
+	}
+	s := testScope()
+	for _, tt := range tests {
+		if got := listItems(s.resolve(tt.expr)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("listItems(resolve(%q)) = %#v, want %#v", tt.expr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package recipe
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultParallelism limits concurrent steps when neither the recipe nor the
+// executor sets a limit
+const defaultParallelism = 4
+
+// hasDependencies reports whether the recipe runs as a dependency graph
+func (r *Recipe) hasDependencies() bool {
+	for _, step := range r.Steps {
+		if len(step.DependsOn) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCycles topologically sorts the steps, failing on a dependency cycle
+func (r *Recipe) checkCycles(ids map[string]int) error {
+	pending := make([]int, len(r.Steps))
+	dependents := make([][]int, len(r.Steps))
+	for i, step := range r.Steps {
+		for _, dep := range step.DependsOn {
+			pending[i]++
+			dependents[ids[dep]] = append(dependents[ids[dep]], i)
+		}
+	}
+
+	var ready []int
+	sorted := 0
+	for i := range r.Steps {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		sorted++
+		for _, d := range dependents[i] {
+			if pending[d]--; pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	if sorted < len(r.Steps) {
+		var cycle []string
+		for i, step := range r.Steps {
+			if pending[i] > 0 {
+				cycle = append(cycle, step.Name)
+			}
+		}
+		return fmt.Errorf("dependency cycle between steps %q", cycle)
+	}
+	return nil
+}
+
+// stepDone reports a finished step of a graph run
+type stepDone struct {
+	index   int
+	results []StepResult
+	outputs []interface{}
+	err     error
+}
+
+// executeGraph runs the steps as a dependency graph, starting each once its
+// dependencies finish and running up to the parallelism limit at once. Each
+// step sees the outputs of the steps finished before it started, with
+// last_output set to the output of its last dependency. The first failure
+// cancels the running steps and starts no more.
+func (e *Executor) executeGraph(ctx context.Context, recipe *Recipe, vars scope, result *ExecutionResult) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := e.parallelism
+	if limit <= 0 {
+		limit = recipe.Parallelism
+	}
+	if limit <= 0 {
+		limit = defaultParallelism
+	}
+
+	ids := make(map[string]int)
+	for i, step := range recipe.Steps {
+		if step.ID != "" {
+			ids[step.ID] = i
+		}
+	}
+	pending := make([]int, len(recipe.Steps))
+	dependents := make([][]int, len(recipe.Steps))
+	for i, step := range recipe.Steps {
+		pending[i] = len(step.DependsOn)
+		for _, dep := range step.DependsOn {
+			dependents[ids[dep]] = append(dependents[ids[dep]], i)
+		}
+	}
+
+	steps := vars["steps"].(map[string]interface{})
+	lastOutputs := make(map[string]interface{})
+	results := make([][]StepResult, len(recipe.Steps))
+	done := make(chan stepDone)
+	sem := make(chan struct{}, limit)
+
+	start := func(i int) {
+		step := recipe.Steps[i]
+
+		// Steps run on a snapshot of the scope, so concurrent steps never share maps
+		local := make(scope, len(vars)+1)
+		for k, v := range vars {
+			local[k] = v
+		}
+		localSteps := make(map[string]interface{}, len(steps))
+		for k, v := range steps {
+			localSteps[k] = v
+		}
+		local["steps"] = localSteps
+		delete(local, "last_output")
+		if n := len(step.DependsOn); n > 0 {
+			if out, ok := lastOutputs[step.DependsOn[n-1]]; ok {
+				local["last_output"] = out
+			}
+		}
+
+		go func() {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				done <- stepDone{index: i, err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+
+			stepResults, outputs, err := e.executeStep(ctx, step, local)
+			done <- stepDone{index: i, results: stepResults, outputs: outputs, err: err}
+		}()
+	}
+
+	running := 0
+	for i := range recipe.Steps {
+		if pending[i] == 0 {
+			start(i)
+			running++
+		}
+	}
+
+	var firstErr error
+	for running > 0 {
+		d := <-done
+		running--
+		results[d.index] = d.results
+
+		if d.err != nil {
+			if firstErr == nil {
+				firstErr = d.err
+				cancel()
+			}
+			continue
+		}
+
+		step := recipe.Steps[d.index]
+		storeOutputs(steps, step, d.outputs)
+		if step.ID != "" && len(d.outputs) > 0 {
+			lastOutputs[step.ID] = d.outputs[len(d.outputs)-1]
+		}
+
+		if firstErr != nil {
+			continue
+		}
+		for _, next := range dependents[d.index] {
+			if pending[next]--; pending[next] == 0 {
+				start(next)
+				running++
+			}
+		}
+	}
+
+	// Report results in recipe order
+	for _, r := range results {
+		result.Steps = append(result.Steps, r...)
+	}
+	return firstErr
+}
@@ -0,0 +1,190 @@
+package recipe
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTools runs the test tools: echo returns its msg argument after an
+// optional delay in milliseconds, fail always fails. It records the calls.
+type fakeTools struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (f *fakeTools) Execute(ctx context.Context, tool string, args map[string]interface{}) (string, error) {
+	msg, _ := args["msg"].(string)
+	f.mu.Lock()
+	f.calls = append(f.calls, tool+":"+msg)
+	f.mu.Unlock()
+
+	if delay, _ := args["delay"].(string); delay != "" {
+		d, _ := time.ParseDuration(delay + "ms")
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if tool == "fail" {
+		return "", errors.New("tool failed")
+	}
+	return msg, nil
+}
+
+func (f *fakeTools) called(msg string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, call := range f.calls {
+		if strings.HasSuffix(call, ":"+msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func stepNames(results []StepResult) string {
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestValidateDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []Step
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			steps: []Step{
+				{ID: "a", Name: "A", Tool: "echo", DependsOn: []string{"c"}},
+				{ID: "b", Name: "B", Tool: "echo", DependsOn: []string{"a"}},
+				{ID: "c", Name: "C", Tool: "echo", DependsOn: []string{"b"}},
+				{ID: "d", Name: "D", Tool: "echo"},
+			},
+			wantErr: `dependency cycle between steps ["A" "B" "C"]`,
+		},
+		{
+			name: "self",
+			steps: []Step{
+				{ID: "a", Name: "A", Tool: "echo", DependsOn: []string{"a"}},
+			},
+			wantErr: `step "A" depends on itself`,
+		},
+		{
+			name: "unknown dependency",
+			steps: []Step{
+				{ID: "a", Name: "A", Tool: "echo"},
+				{ID: "b", Name: "B", Tool: "echo", DependsOn: []string{"build"}},
+			},
+			wantErr: `step "B" depends on unknown step "build"`,
+		},
+		{
+			name: "dependency on a step without an id",
+			steps: []Step{
+				{Name: "A", Tool: "echo"},
+				{ID: "b", Name: "B", Tool: "echo", DependsOn: []string{"A"}},
+			},
+			wantErr: `step "B" depends on unknown step "A"`,
+		},
+		{
+			name: "diamond",
+			steps: []Step{
+				{ID: "a", Name: "A", Tool: "echo"},
+				{ID: "b", Name: "B", Tool: "echo", DependsOn: []string{"a"}},
+				{ID: "c", Name: "C", Tool: "echo", DependsOn: []string{"a"}},
+				{ID: "d", Name: "D", Tool: "echo", DependsOn: []string{"b", "c"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tools := &fakeTools{}
+			_, err := NewExecutor(tools).Execute(context.Background(), &Recipe{Name: tt.name, Steps: tt.steps})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Execute() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("Execute() error = %v, want %q", err, tt.wantErr)
+			case tt.wantErr != "" && len(tools.calls) > 0:
+				t.Errorf("an invalid recipe ran tools: %v", tools.calls)
+			}
+		})
+	}
+}
+
+func TestExecuteGraphOrder(t *testing.T) {
+	// The steps finish in reverse order, but are reported in recipe order
+	r := &Recipe{
+		Name: "order",
+		Steps: []Step{
+			{ID: "root", Name: "root", Tool: "echo", Args: map[string]string{"msg": "root"}},
+			{ID: "slow", Name: "slow", Tool: "echo", Args: map[string]string{"msg": "slow", "delay": "60"}, DependsOn: []string{"root"}},
+			{ID: "medium", Name: "medium", Tool: "echo", Args: map[string]string{"msg": "medium", "delay": "30"}, DependsOn: []string{"root"}},
+			{ID: "fast", Name: "fast", Tool: "echo", Args: map[string]string{"msg": "fast"}, DependsOn: []string{"root"}},
+			{ID: "join", Name: "join", Tool: "echo", Args: map[string]string{"msg": "${steps.slow}+${last_output}"}, DependsOn: []string{"fast", "slow"}},
+		},
+	}
+	for run := 0; run < 5; run++ {
+		result, err := NewExecutor(&fakeTools{}).Execute(context.Background(), r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := stepNames(result.Steps); got != "root,slow,medium,fast,join" {
+			t.Fatalf("steps reported as %s, want recipe order", got)
+		}
+		// last_output is the output of the last dependency listed
+		if got := result.Steps[4].Output; got != "slow+slow" {
+			t.Errorf("join output = %q, want %q", got, "slow+slow")
+		}
+		if !result.Success {
+			t.Error("result not successful")
+		}
+	}
+}
+
+func TestExecuteGraphFailure(t *testing.T) {
+	tools := &fakeTools{}
+	r := &Recipe{
+		Name:        "failure",
+		Parallelism: 1,
+		Steps: []Step{
+			{ID: "build", Name: "build", Tool: "fail", Args: map[string]string{"msg": "build"}},
+			{ID: "test", Name: "test", Tool: "echo", Args: map[string]string{"msg": "test"}, DependsOn: []string{"build"}},
+			{ID: "deploy", Name: "deploy", Tool: "echo", Args: map[string]string{"msg": "deploy"}, DependsOn: []string{"test"}},
+			{ID: "lint", Name: "lint", Tool: "echo", Args: map[string]string{"msg": "lint"}},
+		},
+	}
+	result, err := NewExecutor(tools).Execute(context.Background(), r)
+	if err == nil || result.Success {
+		t.Fatalf("Execute() = %v, %v; want the failure", result.Success, err)
+	}
+	for _, msg := range []string{"test", "deploy"} {
+		if tools.called(msg) {
+			t.Errorf("%s ran after the step it depends on failed", msg)
+		}
+	}
+	if len(result.Steps) == 0 || result.Steps[0].Name != "build" || result.Steps[0].Success {
+		t.Errorf("steps = %+v, want the failed build first", result.Steps)
+	}
+
+	// A failure the step continues past lets its dependents run
+	tools = &fakeTools{}
+	r.Steps[0].OnError = "continue"
+	if result, err = NewExecutor(tools).Execute(context.Background(), r); err != nil {
+		t.Fatalf("Execute() with on_error continue: %v", err)
+	}
+	if got := stepNames(result.Steps); got != "build,test,deploy,lint" {
+		t.Errorf("steps reported as %s", got)
+	}
+	if !tools.called("deploy") {
+		t.Error("deploy did not run after build continued past its failure")
+	}
+}
@@ -4,9 +4,11 @@ package recipe
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -17,25 +19,33 @@ type Recipe struct {
 	Description string            `yaml:"description"`
 	Version     string            `yaml:"version"`
 	Variables   map[string]string `yaml:"variables"`
+	Parallelism int               `yaml:"parallelism,omitempty"` // Concurrent steps when using depends_on
 	Steps       []Step            `yaml:"steps"`
 }
 
 // Step defines a single step in a recipe
 type Step struct {
+	ID        string            `yaml:"id,omitempty"` // Names the output as ${steps.<id>}
 	Name      string            `yaml:"name"`
 	Tool      string            `yaml:"tool"`
 	Args      map[string]string `yaml:"args"`
+	DependsOn []string          `yaml:"depends_on,omitempty"` // IDs of steps that must finish first
 	Condition string            `yaml:"condition,omitempty"`
+	Foreach   string            `yaml:"foreach,omitempty"`  // List to run the step for, e.g. steps.analyze.apps
+	As        string            `yaml:"as,omitempty"`       // Variable holding the current item (default "item")
 	OnError   string            `yaml:"on_error,omitempty"` // "continue", "fail", "retry"
 	Retries   int               `yaml:"retries,omitempty"`
+	Timeout   string            `yaml:"timeout,omitempty"` // Per attempt, e.g. "10m"
 }
 
 // StepResult contains the result of executing a step
 type StepResult struct {
-	Name    string
-	Success bool
-	Output  string
-	Error   error
+	Name     string
+	Success  bool
+	Output   string
+	Error    error
+	Attempts int           // Tries, with retries
+	Duration time.Duration // Of all tries
 }
 
 // ExecutionResult contains the overall recipe execution result
@@ -52,27 +62,89 @@ func Load(path string) (*Recipe, error) {
 		return nil, fmt.Errorf("failed to read recipe: %w", err)
 	}
 
-	var recipe Recipe
-	if err := yaml.Unmarshal(data, &recipe); err != nil {
-		return nil, fmt.Errorf("failed to parse recipe: %w", err)
-	}
-
-	return &recipe, nil
+	return LoadFromString(string(data))
 }
 
-// LoadFromString loads a recipe from a YAML string
+// LoadFromString loads a recipe from a YAML string. Unknown keys are errors,
+// so a misspelled option does not go silently unused.
 func LoadFromString(content string) (*Recipe, error) {
 	var recipe Recipe
-	if err := yaml.Unmarshal([]byte(content), &recipe); err != nil {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&recipe); err == io.EOF {
+		return nil, fmt.Errorf("failed to parse recipe: empty document")
+	} else if err != nil {
 		return nil, fmt.Errorf("failed to parse recipe: %w", err)
 	}
 	return &recipe, nil
 }
 
+// validID matches step IDs usable in ${steps.<id>} references
+var validID = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Validate checks the recipe against the schema: steps have a name and a
+// tool, step IDs are unique, depends_on names existing steps without cycles,
+// and options have valid values
+func (r *Recipe) Validate() error {
+	if len(r.Steps) == 0 {
+		return fmt.Errorf("recipe has no steps")
+	}
+	if r.Parallelism < 0 {
+		return fmt.Errorf("parallelism must not be negative")
+	}
+
+	ids := make(map[string]int)
+	for i, step := range r.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("step %d has no name", i+1)
+		}
+		if step.Tool == "" {
+			return fmt.Errorf("step %q has no tool", step.Name)
+		}
+		switch step.OnError {
+		case "", "fail", "continue", "retry":
+		default:
+			return fmt.Errorf("step %q: on_error must be fail, continue or retry, not %q", step.Name, step.OnError)
+		}
+		if step.As != "" && step.Foreach == "" {
+			return fmt.Errorf("step %q: as needs foreach", step.Name)
+		}
+		if step.ID == "" {
+			continue
+		}
+		if !validID.MatchString(step.ID) {
+			return fmt.Errorf("step %q: id %q must be letters, digits, - and _", step.Name, step.ID)
+		}
+		if _, dup := ids[step.ID]; dup {
+			return fmt.Errorf("duplicate step id %q", step.ID)
+		}
+		ids[step.ID] = i
+	}
+
+	for _, step := range r.Steps {
+		for _, dep := range step.DependsOn {
+			if _, ok := ids[dep]; !ok {
+				return fmt.Errorf("step %q depends on unknown step %q", step.Name, dep)
+			}
+			if dep == step.ID {
+				return fmt.Errorf("step %q depends on itself", step.Name)
+			}
+		}
+		if step.Timeout != "" {
+			if d, err := time.ParseDuration(step.Timeout); err != nil || d <= 0 {
+				return fmt.Errorf("step %q: invalid timeout %q", step.Name, step.Timeout)
+			}
+		}
+	}
+
+	return r.checkCycles(ids)
+}
+
 // Executor executes recipes
 type Executor struct {
 	toolExecutor ToolExecutor
 	variables    map[string]string
+	parallelism  int
 }
 
 // ToolExecutor is the interface for executing tools
@@ -93,60 +165,98 @@ func (e *Executor) SetVariable(name, value string) {
 	e.variables[name] = value
 }
 
-// Execute runs a recipe
+// SetParallelism overrides the recipe's limit on concurrently running steps
+func (e *Executor) SetParallelism(n int) {
+	e.parallelism = n
+}
+
+// Execute runs a recipe. Steps run in order unless some step declares
+// depends_on, in which case they run as a dependency graph: each step starts
+// once the steps it depends on have finished, independent steps concurrently.
 func (e *Executor) Execute(ctx context.Context, recipe *Recipe) (*ExecutionResult, error) {
 	result := &ExecutionResult{
 		Recipe: recipe.Name,
 		Steps:  make([]StepResult, 0, len(recipe.Steps)),
 	}
 
+	if err := recipe.Validate(); err != nil {
+		return result, err
+	}
+
 	// Merge recipe variables with executor variables
-	vars := make(map[string]string)
+	vars := make(scope)
 	for k, v := range recipe.Variables {
 		vars[k] = v
 	}
 	for k, v := range e.variables {
 		vars[k] = v
 	}
+	steps := make(map[string]interface{})
+	vars["steps"] = steps
+
+	if recipe.hasDependencies() {
+		if err := e.executeGraph(ctx, recipe, vars, result); err != nil {
+			return result, err
+		}
+		result.Success = true
+		return result, nil
+	}
 
 	// Execute each step
 	for _, step := range recipe.Steps {
-		// Check condition
-		if step.Condition != "" {
-			if !e.evaluateCondition(step.Condition, vars) {
-				continue
-			}
+		results, outputs, err := e.executeStep(ctx, step, vars)
+		result.Steps = append(result.Steps, results...)
+		if err != nil {
+			return result, err
 		}
+		storeOutputs(steps, step, outputs)
+	}
 
-		// Interpolate args
-		args := e.interpolateArgs(step.Args, vars)
-
-		// Execute with retries
-		var stepResult StepResult
-		stepResult.Name = step.Name
+	result.Success = true
+	return result, nil
+}
 
-		retries := step.Retries
-		if retries == 0 {
-			retries = 1
+// executeStep runs a step, once per item for a foreach step, and returns the
+// results and the outputs of the successful runs. The error is set when a
+// failure should stop the recipe.
+func (e *Executor) executeStep(ctx context.Context, step Step, vars scope) ([]StepResult, []interface{}, error) {
+	// A foreach step runs once per item, with the item in scope
+	items := []interface{}{nil}
+	as := step.As
+	if step.Foreach != "" {
+		items = listItems(vars.resolve(step.Foreach))
+		if as == "" {
+			as = "item"
 		}
+		defer func() {
+			delete(vars, as)
+			delete(vars, "index")
+		}()
+	}
 
-		for attempt := 0; attempt < retries; attempt++ {
-			output, err := e.toolExecutor.Execute(ctx, step.Tool, args)
-			stepResult.Output = output
+	var results []StepResult
+	var outputs []interface{}
+	for i, item := range items {
+		name := step.Name
+		if step.Foreach != "" {
+			vars[as] = item
+			vars["index"] = i
+			name = fmt.Sprintf("%s [%s]", step.Name, itemLabel(item, i))
+		}
 
-			if err == nil {
-				stepResult.Success = true
-				break
+		// Check condition
+		if step.Condition != "" {
+			ok, err := evaluateCondition(step.Condition, vars)
+			if err != nil {
+				return results, outputs, fmt.Errorf("step %q: invalid condition %q: %w", step.Name, step.Condition, err)
 			}
-
-			stepResult.Error = err
-
-			if attempt < retries-1 {
-				continue // Retry
+			if !ok {
+				continue
 			}
 		}
 
-		result.Steps = append(result.Steps, stepResult)
+		stepResult := e.runStep(ctx, step, name, vars)
+		results = append(results, stepResult)
 
 		// Handle errors
 		if !stepResult.Success {
@@ -154,66 +264,98 @@ func (e *Executor) Execute(ctx context.Context, recipe *Recipe) (*ExecutionResul
 			case "continue":
 				continue
 			case "fail", "":
-				result.Success = false
-				return result, stepResult.Error
+				return results, outputs, stepResult.Error
 			}
 		}
 
 		// Store output as variable for next steps
 		vars["last_output"] = stepResult.Output
+		outputs = append(outputs, stepResult.Output)
 	}
 
-	result.Success = true
-	return result, nil
+	return results, outputs, nil
 }
 
-// interpolateArgs replaces ${var} patterns with variable values
-func (e *Executor) interpolateArgs(args map[string]string, vars map[string]string) map[string]interface{} {
-	result := make(map[string]interface{})
-	re := regexp.MustCompile(`\$\{(\w+)\}`)
+// storeOutputs makes a named step's outputs available as ${steps.<id>}: the
+// output of a plain step, or the list of outputs of a foreach step
+func storeOutputs(steps map[string]interface{}, step Step, outputs []interface{}) {
+	if step.ID == "" {
+		return
+	}
+	if step.Foreach != "" {
+		steps[step.ID] = outputs
+	} else if len(outputs) > 0 {
+		steps[step.ID] = outputs[0]
+	}
+}
 
-	for k, v := range args {
-		interpolated := re.ReplaceAllStringFunc(v, func(match string) string {
-			varName := match[2 : len(match)-1]
-			if val, ok := vars[varName]; ok {
-				return val
-			}
-			return match
-		})
-		result[k] = interpolated
+// runStep executes one step (or one foreach iteration) with retries
+func (e *Executor) runStep(ctx context.Context, step Step, name string, vars scope) StepResult {
+	// Interpolate args
+	args := e.interpolateArgs(step.Args, vars)
+
+	stepResult := StepResult{Name: name}
+	start := time.Now()
+
+	retries := step.Retries
+	if retries == 0 {
+		retries = 1
 	}
 
-	return result
-}
+	// Validate has checked the timeout parses
+	timeout, _ := time.ParseDuration(step.Timeout)
 
-// evaluateCondition evaluates a simple condition
-func (e *Executor) evaluateCondition(condition string, vars map[string]string) bool {
-	// Simple conditions: "var == value" or "var != value"
-	condition = strings.TrimSpace(condition)
-
-	if strings.Contains(condition, "==") {
-		parts := strings.SplitN(condition, "==", 2)
-		if len(parts) == 2 {
-			varName := strings.TrimSpace(parts[0])
-			expected := strings.TrimSpace(strings.Trim(parts[1], "\"'"))
-			actual, ok := vars[varName]
-			return ok && actual == expected
+	for attempt := 0; attempt < retries && ctx.Err() == nil; attempt++ {
+		stepResult.Attempts++
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
 		}
-	}
+		output, err := e.toolExecutor.Execute(attemptCtx, step.Tool, args)
+		if err != nil && ctx.Err() == nil && attemptCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s: %w", timeout, err)
+		}
+		cancel()
+		stepResult.Output = output
 
-	if strings.Contains(condition, "!=") {
-		parts := strings.SplitN(condition, "!=", 2)
-		if len(parts) == 2 {
-			varName := strings.TrimSpace(parts[0])
-			expected := strings.TrimSpace(strings.Trim(parts[1], "\"'"))
-			actual, ok := vars[varName]
-			return !ok || actual != expected
+		if err == nil {
+			stepResult.Success = true
+			stepResult.Error = nil
+			break
 		}
+
+		stepResult.Error = err
 	}
+	if stepResult.Error == nil && !stepResult.Success {
+		stepResult.Error = ctx.Err()
+	}
+	stepResult.Duration = time.Since(start)
 
-	// Check if variable exists
-	_, exists := vars[condition]
-	return exists
+	return stepResult
+}
+
+// interpolateArgs replaces ${var} patterns with variable values
+func (e *Executor) interpolateArgs(args map[string]string, vars scope) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range args {
+		result[k] = vars.interpolate(v)
+	}
+	return result
+}
+
+// itemLabel names a foreach iteration: a scalar item itself, an object by its
+// name field, anything else by its index
+func itemLabel(item interface{}, index int) string {
+	switch v := item.(type) {
+	case map[string]interface{}:
+		if name, ok := v["name"].(string); ok && name != "" {
+			return name
+		}
+	case []interface{}:
+	default:
+		return stringify(v)
+	}
+	return fmt.Sprint(index)
 }
 
 // BuiltinRecipes contains built-in recipe definitions
@@ -272,6 +414,27 @@ steps:
       tail: "50"
 `,
 
+	"monorepo": `
+name: monorepo
+description: Generate Docker configuration for every app in a monorepo
+version: "1.0"
+steps:
+  - id: analyze
+    name: Analyze Workspace
+    tool: dockerizer_analyze
+    args:
+      path: "${path}"
+  - name: Generate
+    tool: dockerizer_generate
+    foreach: steps.analyze.apps
+    as: app
+    args:
+      path: "${path}"
+      app: "${app.name}"
+      overwrite: "${overwrite}"
+    on_error: continue
+`,
+
 	"full-deploy": `
 name: full-deploy
 description: Complete deployment workflow with validation
@@ -279,26 +442,34 @@ version: "1.0"
 variables:
   image_tag: "app:latest"
 steps:
-  - name: Analyze Repository
+  - id: analyze
+    name: Analyze Repository
     tool: dockerizer_analyze
     args:
       path: "${path}"
-  - name: Generate Docker Files
+  - id: generate
+    name: Generate Docker Files
     tool: dockerizer_generate
     args:
       path: "${path}"
       overwrite: "true"
-  - name: Build Docker Image
+  - id: build
+    name: Build Docker Image
     tool: docker_build
+    depends_on: [generate]
     args:
       path: "${path}"
       tag: "${image_tag}"
     retries: 2
-  - name: Test Container
+    timeout: 20m
+  - id: test
+    name: Test Container
     tool: docker_run
+    depends_on: [build]
     args:
       image: "${image_tag}"
     on_error: fail
+    timeout: 5m
 `,
 }
 
@@ -0,0 +1,81 @@
+package recipe
+
+// SchemaFile is the name the recipe JSON schema is written under, next to
+// scaffolded recipes
+const SchemaFile = "recipe.schema.json"
+
+// Schema is the JSON schema of a recipe file, for editor validation and
+// completion via the yaml-language-server comment in scaffolded recipes
+const Schema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Dockerizer recipe",
+  "type": "object",
+  "required": ["name", "steps"],
+  "additionalProperties": false,
+  "properties": {
+    "name": { "type": "string", "description": "Recipe name" },
+    "description": { "type": "string", "description": "Shown by dockerizer recipe list" },
+    "version": { "type": "string" },
+    "variables": {
+      "type": "object",
+      "description": "Default variable values, overridden by --var",
+      "additionalProperties": { "type": "string" }
+    },
+    "parallelism": {
+      "type": "integer",
+      "minimum": 0,
+      "description": "Maximum steps running at once when steps use depends_on"
+    },
+    "steps": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "required": ["name", "tool"],
+        "additionalProperties": false,
+        "properties": {
+          "id": {
+            "type": "string",
+            "pattern": "^[A-Za-z_][A-Za-z0-9_-]*$",
+            "description": "Names the step's output as ${steps.<id>}"
+          },
+          "name": { "type": "string" },
+          "tool": {
+            "type": "string",
+            "description": "Tool to run, e.g. dockerizer_analyze, dockerizer_generate, docker_build, docker_run"
+          },
+          "args": {
+            "type": "object",
+            "description": "Tool arguments; ${...} references are interpolated",
+            "additionalProperties": { "type": "string" }
+          },
+          "depends_on": {
+            "type": "array",
+            "items": { "type": "string" },
+            "description": "IDs of steps that must finish first"
+          },
+          "condition": {
+            "type": "string",
+            "description": "Run only when true: ==, !=, contains, and/or/not, parentheses"
+          },
+          "foreach": {
+            "type": "string",
+            "description": "List to run the step for, e.g. steps.analyze.apps"
+          },
+          "as": {
+            "type": "string",
+            "description": "Variable holding the current foreach item (default item)"
+          },
+          "on_error": { "enum": ["fail", "continue", "retry"] },
+          "retries": { "type": "integer", "minimum": 0 },
+          "timeout": {
+            "type": "string",
+            "pattern": "^([0-9]+(\\.[0-9]+)?(ns|us|ms|s|m|h))+$",
+            "description": "Per attempt, e.g. 30s or 10m"
+          }
+        }
+      }
+    }
+  }
+}
+`
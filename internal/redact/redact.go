@@ -0,0 +1,102 @@
+// Package redact removes secrets from file contents before they leave the machine.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Placeholder replaces each redacted value
+const Placeholder = "[REDACTED]"
+
+// Finding is a secret found (and redacted) in some content
+type Finding struct {
+	Rule string // What kind of secret it looked like
+	Line int    // 1-based line number
+}
+
+// rule matches a secret. With a value group, only that group is redacted and
+// the rest of the match (such as the key name) is kept.
+type rule struct {
+	name  string
+	re    *regexp.Regexp
+	value int // Capture group holding the secret; 0 redacts the whole match
+}
+
+var rules = []rule{
+	{name: "private key", re: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----`)},
+	{name: "AWS access key", re: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA)[0-9A-Z]{16}\b`)},
+	{name: "GitHub token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{name: "GitLab token", re: regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{name: "Slack token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{name: "Stripe key", re: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}\b`)},
+	{name: "Anthropic API key", re: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{name: "OpenAI API key", re: regexp.MustCompile(`\bsk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{32,}`)},
+	{name: "Google API key", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{name: "SendGrid key", re: regexp.MustCompile(`\bSG\.[A-Za-z0-9_-]{22}\.[A-Za-z0-9_-]{43}\b`)},
+	{name: "JSON web token", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`)},
+	{name: "URL credentials", re: regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@"']+:([^\s@/"']+)@`), value: 1},
+	// KEY=value, key: value and "key": "value" where the key names a secret:
+	// a quoted value up to its closing quote, any other up to the end of the
+	// line or a trailing comment
+	{name: "secret assignment", re: regexp.MustCompile(secretKey + `"([^"\n]*)"`), value: 1},
+	{name: "secret assignment", re: regexp.MustCompile(secretKey + `'([^'\n]*)'`), value: 1},
+	{name: "secret assignment", re: regexp.MustCompile(`(?m)` + secretKey + `([^\s"'=#][^\n]*?)[ \t]*[,;]?(?:[ \t]+#.*)?\r?$`), value: 1},
+}
+
+// secretKey matches a key with secret, key, token, pass or credential
+// anywhere in its name (SECRET_KEY_BASE, DB_PASS, apiKey), and the
+// assignment after it
+const secretKey = `(?i)\b[\w.-]*(?:secret|key|token|pass|pwd|credential)[\w.-]*["']?[ \t]*(?::=|[:=])[ \t]*`
+
+// notSecret are assignment values that are placeholders, references or
+// dependency versions (package.json "jsonwebtoken": "^9.0.0"), not secrets
+var notSecret = regexp.MustCompile(`(?i)^(?:[~^<>=]*v?\d+(?:\.[\dx*]+)*(?:[-+][\w.-]*)?|latest|workspace:.*|\$\{?[A-Za-z_][A-Za-z0-9_]*\}?|%\(?[A-Za-z_]+\)?s?|<[^>]*>|\*+|x+|changeme|change-me|your[_-].*|null|none|nil|true|false|\[REDACTED\]|ENV\[.*|process\.env\..*|os\.environ.*|os\.Getenv.*|env\(.*|[\[{].*)$`)
+
+// Secrets replaces secret values in content with Placeholder and reports what it found
+func Secrets(content string) (string, []Finding) {
+	type span struct {
+		start, end int
+		rule       string
+	}
+	var spans []span
+	for _, r := range rules {
+		for _, m := range r.re.FindAllStringSubmatchIndex(content, -1) {
+			start, end := m[0], m[1]
+			if r.value > 0 {
+				start, end = m[2*r.value], m[2*r.value+1]
+				if start >= end || notSecret.MatchString(content[start:end]) {
+					continue
+				}
+			}
+			spans = append(spans, span{start, end, r.name})
+		}
+	}
+	if len(spans) == 0 {
+		return content, nil
+	}
+
+	// Earlier, then longer, matches win where rules overlap
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var b strings.Builder
+	var findings []Finding
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			continue
+		}
+		b.WriteString(content[last:s.start])
+		b.WriteString(Placeholder)
+		findings = append(findings, Finding{Rule: s.rule, Line: strings.Count(content[:s.start], "\n") + 1})
+		last = s.end
+	}
+	b.WriteString(content[last:])
+	return b.String(), findings
+}
@@ -0,0 +1,115 @@
+package redact
+
+import "testing"
+
+func TestSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		rules   []string
+	}{
+		{
+			name:    "Django secret key",
+			content: "SECRET_KEY=django-insecure-abc123\nDEBUG=true",
+			want:    "SECRET_KEY=[REDACTED]\nDEBUG=true",
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "Rails secret key base",
+			content: "SECRET_KEY_BASE=9f8e7d6c5b4a",
+			want:    "SECRET_KEY_BASE=[REDACTED]",
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "password abbreviation",
+			content: "DB_PASS=hunter2",
+			want:    "DB_PASS=[REDACTED]",
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "value with a space",
+			content: "DATABASE_PASSWORD=p@ss word\n",
+			want:    "DATABASE_PASSWORD=[REDACTED]\n",
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "quoted value with a space",
+			content: `DATABASE_PASSWORD="p@ss word" # the staging one`,
+			want:    `DATABASE_PASSWORD="[REDACTED]" # the staging one`,
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "YAML with a comment",
+			content: "  api_key: abc 123 # rotate monthly\n  port: 8080",
+			want:    "  api_key: [REDACTED] # rotate monthly\n  port: 8080",
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "JSON",
+			content: `{"clientSecret": "s3cr3t", "name": "app"}`,
+			want:    `{"clientSecret": "[REDACTED]", "name": "app"}`,
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "single-quoted",
+			content: "AUTH_TOKEN='tok en'",
+			want:    "AUTH_TOKEN='[REDACTED]'",
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "Go short assignment",
+			content: `apiKey := "k-123"`,
+			want:    `apiKey := "[REDACTED]"`,
+			rules:   []string{"secret assignment"},
+		},
+		{
+			name:    "URL credentials",
+			content: "DATABASE_URL=postgres://app:pa55@db:5432/app",
+			want:    "DATABASE_URL=postgres://app:[REDACTED]@db:5432/app",
+			rules:   []string{"URL credentials"},
+		},
+		{
+			name:    "token pattern",
+			content: "export GH=ghp_" + "abcdefghijklmnopqrstuvwxyz0123456789",
+			want:    "export GH=[REDACTED]",
+			rules:   []string{"GitHub token"},
+		},
+		{
+			name:    "placeholders and references",
+			content: "SECRET_KEY=${SECRET_KEY}\nDB_PASSWORD=changeme\napi_key = os.environ[\"API_KEY\"]\ntoken: process.env.TOKEN\nPASSWORD=\n",
+			want:    "SECRET_KEY=${SECRET_KEY}\nDB_PASSWORD=changeme\napi_key = os.environ[\"API_KEY\"]\ntoken: process.env.TOKEN\nPASSWORD=\n",
+		},
+		{
+			name:    "dependency versions",
+			content: `"jsonwebtoken": "^9.0.0",` + "\n" + `"passport": "0.7.0"`,
+			want:    `"jsonwebtoken": "^9.0.0",` + "\n" + `"passport": "0.7.0"`,
+		},
+		{
+			name:    "comparison",
+			content: "if password == expected {",
+			want:    "if password == expected {",
+		},
+		{
+			name:    "YAML mapping",
+			content: "secrets:\n  db_password:\n    file: ./db_password.txt",
+			want:    "secrets:\n  db_password:\n    file: ./db_password.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, findings := Secrets(tt.content)
+			if got != tt.want {
+				t.Errorf("Secrets() = %q, want %q", got, tt.want)
+			}
+			if len(findings) != len(tt.rules) {
+				t.Fatalf("findings = %v, want rules %v", findings, tt.rules)
+			}
+			for i, f := range findings {
+				if f.Rule != tt.rules[i] {
+					t.Errorf("finding %d rule = %q, want %q", i, f.Rule, tt.rules[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+// Package registry resolves image tags to content digests through the
+// registry HTTP API (Docker Hub, GHCR, Quay, ECR, ...), without Docker.
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+)
+
+// manifestTypes are accepted for a tag; an index (multi-platform list) is
+// preferred so the digest pins every platform of the image
+var manifestTypes = strings.Join([]string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+// Reference is a parsed image reference
+type Reference struct {
+	Host       string // Registry host, registry-1.docker.io for Docker Hub
+	Repository string // e.g. library/node
+	Tag        string
+	Digest     string
+}
+
+// dockerHubHosts are the names Docker Hub goes by in image references and
+// in ~/.docker/config.json
+var dockerHubHosts = []string{"docker.io", "index.docker.io", "registry-1.docker.io", "https://index.docker.io/v1/"}
+
+// ParseReference parses an image reference such as node:20-alpine,
+// ghcr.io/org/app:1.2 or localhost:5000/app@sha256:...
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if name == "" || name != strings.ToLower(name) || strings.Contains(image, "$") {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	first, rest, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Host, ref.Repository = first, rest
+	} else {
+		ref.Host, ref.Repository = "docker.io", name
+	}
+	if ref.Host == "docker.io" || ref.Host == "index.docker.io" {
+		ref.Host = "registry-1.docker.io"
+		if !strings.Contains(ref.Repository, "/") {
+			ref.Repository = "library/" + ref.Repository
+		}
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// Client resolves image digests. It is safe for concurrent use.
+type Client struct {
+	client *http.Client
+	auths  map[string]string // Registry host -> base64 user:password
+
+	mu     sync.Mutex
+	tokens map[string]string // Repository scope -> bearer token
+}
+
+// New creates a client that authenticates with the credentials stored by
+// docker login in ~/.docker/config.json (or $DOCKER_CONFIG), and anonymously
+// elsewhere
+func New() *Client {
+	return &Client{
+		client: &http.Client{Timeout: 30 * time.Second},
+		auths:  loadDockerAuths(),
+		tokens: make(map[string]string),
+	}
+}
+
+// Digest returns the digest of the manifest image points to, such as
+// sha256:4f0f..., resolving the tag on the registry
+func (c *Client) Digest(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	digest, err := c.manifestDigest(ctx, ref, http.MethodHead)
+	if err == nil && digest == "" {
+		// Some registries omit Docker-Content-Digest on HEAD
+		digest, err = c.manifestDigest(ctx, ref, http.MethodGet)
+	}
+	if err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// manifestDigest requests the tag's manifest, authenticating when the
+// registry asks to
+func (c *Client) manifestDigest(ctx context.Context, ref Reference, method string) (string, error) {
+	resp, err := c.request(ctx, method, ref, baseURL(ref)+"/manifests/"+ref.Tag)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		return digest, nil
+	}
+	if method == http.MethodHead {
+		return "", nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+// request sends a registry API request, authenticating when the registry
+// asks to, and returns the response when it succeeded
+func (c *Client) request(ctx context.Context, method string, ref Reference, url string) (*http.Response, error) {
+	resp, err := c.do(ctx, method, url, c.authorization(ref))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		auth, err := c.authenticate(ctx, ref, challenge)
+		if err != nil {
+			return nil, err
+		}
+		if resp, err = c.do(ctx, method, url, auth); err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound:
+		err = errors.ErrImageNotFound
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = errors.ErrRegistryAuth
+	default:
+		err = fmt.Errorf("registry returned %s", resp.Status)
+	}
+	resp.Body.Close()
+	return nil, err
+}
+
+// baseURL is the API root of the reference's repository
+func baseURL(ref Reference) string {
+	return scheme(ref.Host) + "://" + ref.Host + "/v2/" + ref.Repository
+}
+
+func (c *Client) do(ctx context.Context, method, url, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", manifestTypes)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return c.client.Do(req)
+}
+
+// authorization returns a cached bearer token for the repository, if any
+func (c *Client) authorization(ref Reference) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if token := c.tokens[ref.Host+"/"+ref.Repository]; token != "" {
+		return "Bearer " + token
+	}
+	return ""
+}
+
+// challengeParam matches a key="value" parameter of a WWW-Authenticate header
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate answers a WWW-Authenticate challenge: Basic with stored
+// credentials, or Bearer with a pull token from the registry's token service
+func (c *Client) authenticate(ctx context.Context, ref Reference, challenge string) (string, error) {
+	authScheme, params, _ := strings.Cut(challenge, " ")
+	creds := c.auths[ref.Host]
+
+	switch strings.ToLower(authScheme) {
+	case "basic":
+		if creds == "" {
+			return "", errors.ErrRegistryAuth
+		}
+		return "Basic " + creds, nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("%w: unsupported challenge %q", errors.ErrRegistryAuth, challenge)
+	}
+
+	values := make(map[string]string)
+	for _, m := range challengeParam.FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(m[1])] = m[2]
+	}
+	if values["realm"] == "" {
+		return "", fmt.Errorf("%w: challenge without realm", errors.ErrRegistryAuth)
+	}
+
+	query := url.Values{}
+	if values["service"] != "" {
+		query.Set("service", values["service"])
+	}
+	query.Set("scope", "repository:"+ref.Repository+":pull")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, values["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if creds != "" {
+		req.Header.Set("Authorization", "Basic "+creds)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: token service returned %s", errors.ErrRegistryAuth, resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: %v", errors.ErrRegistryAuth, err)
+	}
+	token := body.Token
+	if token == "" {
+		token = body.AccessToken
+	}
+	if token == "" {
+		return "", fmt.Errorf("%w: token service returned no token", errors.ErrRegistryAuth)
+	}
+
+	c.mu.Lock()
+	c.tokens[ref.Host+"/"+ref.Repository] = token
+	c.mu.Unlock()
+	return "Bearer " + token, nil
+}
+
+// scheme is http for registries on the local machine, as Docker allows, and
+// https everywhere else
+func scheme(host string) string {
+	hostname := host
+	if h, _, found := strings.Cut(host, ":"); found {
+		hostname = h
+	}
+	if hostname == "localhost" || strings.HasPrefix(hostname, "127.") {
+		return "http"
+	}
+	return "https"
+}
+
+// loadDockerAuths reads the base64 credentials docker login stores inline;
+// credential helpers are not consulted
+func loadDockerAuths() map[string]string {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return nil
+	}
+
+	var cfg struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if json.Unmarshal(data, &cfg) != nil {
+		return nil
+	}
+
+	auths := make(map[string]string)
+	for key, entry := range cfg.Auths {
+		auth := entry.Auth
+		if auth == "" && entry.Username != "" {
+			auth = base64.StdEncoding.EncodeToString([]byte(entry.Username + ":" + entry.Password))
+		}
+		if auth == "" {
+			continue
+		}
+		host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://"), "/")
+		for _, hub := range dockerHubHosts {
+			if key == hub || host == hub {
+				host = "registry-1.docker.io"
+			}
+		}
+		if h, _, found := strings.Cut(host, "/"); found {
+			host = h
+		}
+		auths[host] = auth
+	}
+	return auths
+}
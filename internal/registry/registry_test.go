@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"node", Reference{Host: "registry-1.docker.io", Repository: "library/node", Tag: "latest"}},
+		{"node:20-alpine", Reference{Host: "registry-1.docker.io", Repository: "library/node", Tag: "20-alpine"}},
+		{"oven/bun:1", Reference{Host: "registry-1.docker.io", Repository: "oven/bun", Tag: "1"}},
+		{"docker.io/library/python:3.12", Reference{Host: "registry-1.docker.io", Repository: "library/python", Tag: "3.12"}},
+		{"ghcr.io/org/app:1.2", Reference{Host: "ghcr.io", Repository: "org/app", Tag: "1.2"}},
+		{"localhost:5000/app", Reference{Host: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{"alpine:3.19@sha256:abc", Reference{Host: "registry-1.docker.io", Repository: "library/alpine", Tag: "3.19", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.image)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, %v; want %+v", tt.image, got, err, tt.want)
+		}
+	}
+
+	for _, image := range []string{"", "Node:20", "node:${VERSION}"} {
+		if _, err := ParseReference(image); err == nil {
+			t.Errorf("ParseReference(%q): expected error", image)
+		}
+	}
+}
+
+func TestDigest(t *testing.T) {
+	manifest := `{"schemaVersion":2}`
+	manifestDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(manifest)))
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:team/app:pull" {
+				t.Errorf("token scope = %q", r.URL.Query().Get("scope"))
+			}
+			fmt.Fprint(w, `{"token":"secret"}`)
+		case r.Header.Get("Authorization") != "Bearer secret":
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/v2/team/app/manifests/1.0":
+			if !strings.Contains(r.Header.Get("Accept"), "manifest.list.v2+json") {
+				t.Errorf("Accept = %q", r.Header.Get("Accept"))
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:tagged")
+		case r.URL.Path == "/v2/team/app/manifests/nohead":
+			// No digest header: the client must hash the manifest itself
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, manifest)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	c := New()
+	c.auths = nil
+	ctx := context.Background()
+
+	if got, err := c.Digest(ctx, host+"/team/app:1.0"); err != nil || got != "sha256:tagged" {
+		t.Errorf("Digest(1.0) = %q, %v", got, err)
+	}
+	if got, err := c.Digest(ctx, host+"/team/app:nohead"); err != nil || got != manifestDigest {
+		t.Errorf("Digest(nohead) = %q, %v; want %s", got, err, manifestDigest)
+	}
+	if _, err := c.Digest(ctx, host+"/team/app:missing"); err == nil || !strings.Contains(err.Error(), errors.ErrImageNotFound.Error()) {
+		t.Errorf("Digest(missing) error = %v", err)
+	}
+	if got, _ := c.Digest(ctx, "node:20@sha256:pinned"); got != "sha256:pinned" {
+		t.Errorf("Digest(pinned) = %q", got)
+	}
+}
+
+func TestLatestTag(t *testing.T) {
+	tags := []string{"18-alpine", "20-alpine", "21-alpine", "22-alpine", "23-alpine", "22-slim", "22.1-alpine", "latest", "3.12-slim", "3.13-slim", "3.14.0a1-slim", "3.14-rc-slim", "1.22.4", "1.22.5", "1.23.0"}
+	tests := []struct {
+		repository, current string
+		want, kind          string
+	}{
+		{"library/node", "20-alpine", "22-alpine", UpdateMajor},
+		{"library/other", "20-alpine", "23-alpine", UpdateMajor},
+		{"library/node", "22-alpine", "", ""},
+		{"library/python", "3.12-slim", "3.13-slim", UpdateMinor},
+		{"library/golang", "1.22.4", "1.23.0", UpdateMinor},
+		{"library/golang", "1.23.0", "", ""},
+		{"library/alpine", "latest", "", ""},
+	}
+	for _, tt := range tests {
+		got, kind := LatestTag(tt.repository, tt.current, tags)
+		if got != tt.want || kind != tt.kind {
+			t.Errorf("LatestTag(%s, %s) = %q, %q; want %q, %q", tt.repository, tt.current, got, kind, tt.want, tt.kind)
+		}
+	}
+	if got, kind := LatestTag("x", "1.2.3", []string{"1.2.4"}); got != "1.2.4" || kind != UpdatePatch {
+		t.Errorf("patch update = %q, %q", got, kind)
+	}
+}
+
+func TestTags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/app/tags/list?n=2&last=b>; rel="next"`)
+			fmt.Fprint(w, `{"name":"app","tags":["a","b"]}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"app","tags":["c"]}`)
+	}))
+	defer srv.Close()
+
+	tags, err := New().Tags(context.Background(), strings.TrimPrefix(srv.URL, "http://")+"/app")
+	if err != nil || strings.Join(tags, ",") != "a,b,c" {
+		t.Errorf("Tags() = %v, %v", tags, err)
+	}
+}
+
+func TestSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/team/app/manifests/1.0":
+			fmt.Fprint(w, `{"manifests":[
+				{"digest":"sha256:arm","platform":{"os":"linux","architecture":"arm64","variant":"v8"}},
+				{"digest":"sha256:amd","platform":{"os":"linux","architecture":"amd64"}}]}`)
+		case "/v2/team/app/manifests/sha256:amd":
+			fmt.Fprint(w, `{"layers":[{"size":1000},{"size":234}]}`)
+		case "/v2/team/app/manifests/sha256:arm":
+			fmt.Fprint(w, `{"layers":[{"size":900}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	c := New()
+	c.auths = nil
+	ctx := context.Background()
+	if got, err := c.Size(ctx, host+"/team/app:1.0", ""); err != nil || got != 1234 {
+		t.Errorf("Size(amd64) = %d, %v", got, err)
+	}
+	if got, err := c.Size(ctx, host+"/team/app:1.0", "linux/arm64/v8"); err != nil || got != 900 {
+		t.Errorf("Size(arm64) = %d, %v", got, err)
+	}
+	if _, err := c.Size(ctx, host+"/team/app:1.0", "windows/amd64"); err == nil {
+		t.Error("Size(windows) should fail")
+	}
+}
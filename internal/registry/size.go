@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// manifest is an image manifest or a multi-platform index
+type manifest struct {
+	Layers []struct {
+		Size int64 `json:"size"`
+	} `json:"layers"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// Size returns the compressed size of image's layers, what a pull
+// downloads, for platform (e.g. linux/amd64; "" for linux/amd64)
+func (c *Client) Size(ctx context.Context, image, platform string) (int64, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return 0, err
+	}
+	if platform == "" {
+		platform = "linux/amd64"
+	}
+
+	reference := ref.Tag
+	if ref.Digest != "" {
+		reference = ref.Digest
+	}
+	m, err := c.manifest(ctx, ref, reference)
+	if err != nil {
+		return 0, err
+	}
+	if len(m.Manifests) > 0 {
+		digest := ""
+		for _, entry := range m.Manifests {
+			p := entry.Platform.OS + "/" + entry.Platform.Architecture
+			if p == platform || p+"/"+entry.Platform.Variant == platform {
+				digest = entry.Digest
+				break
+			}
+		}
+		if digest == "" {
+			return 0, fmt.Errorf("%s has no %s image", image, platform)
+		}
+		if m, err = c.manifest(ctx, ref, digest); err != nil {
+			return 0, err
+		}
+	}
+
+	var size int64
+	for _, layer := range m.Layers {
+		size += layer.Size
+	}
+	return size, nil
+}
+
+// manifest fetches the manifest of a tag or digest of ref's repository
+func (c *Client) manifest(ctx context.Context, ref Reference, reference string) (*manifest, error) {
+	resp, err := c.request(ctx, http.MethodGet, ref, baseURL(ref)+"/manifests/"+reference)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var m manifest
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest for %s: %w", strings.TrimPrefix(ref.Repository, "library/"), err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,152 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxTagPages bounds the pages of a tag list that are fetched
+const maxTagPages = 50
+
+// linkNext matches the next page in a Link header
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="?next"?`)
+
+// Tags lists the tags of image's repository, following pagination
+func (c *Client) Tags(ctx context.Context, image string) ([]string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	next := baseURL(ref) + "/tags/list?n=1000"
+	for page := 0; next != "" && page < maxTagPages; page++ {
+		resp, err := c.request(ctx, http.MethodGet, ref, next)
+		if err != nil {
+			return nil, err
+		}
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		link := resp.Header.Get("Link")
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, body.Tags...)
+
+		next = ""
+		if m := linkNext.FindStringSubmatch(link); m != nil {
+			u, err := url.Parse(m[1])
+			if err != nil {
+				return nil, err
+			}
+			next = scheme(ref.Host) + "://" + ref.Host + u.RequestURI()
+			if u.IsAbs() {
+				next = u.String()
+			}
+		}
+	}
+	return tags, nil
+}
+
+// Update kinds, by the first version component that changed
+const (
+	UpdateMajor = "major"
+	UpdateMinor = "minor"
+	UpdatePatch = "patch"
+)
+
+// tagVersion splits a tag such as 20-alpine or v3.12.1-slim into its
+// version numbers and the variant suffix after them
+var tagVersion = regexp.MustCompile(`^v?(\d+(?:\.\d+)*)(.*)$`)
+
+// evenMajorsOnly lists repositories whose odd major versions are short-lived
+// releases that are never recommended (Node.js: only even majors become LTS)
+var evenMajorsOnly = map[string]bool{"library/node": true}
+
+// Version is a parsed image tag
+type Version struct {
+	Numbers []int
+	Suffix  string // Variant, e.g. -alpine or -jre-alpine
+}
+
+// ParseTag parses a versioned tag; ok is false for tags such as latest,
+// alpine or bookworm-slim that carry no version
+func ParseTag(tag string) (Version, bool) {
+	m := tagVersion.FindStringSubmatch(tag)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Suffix: m[2]}
+	for _, part := range strings.Split(m[1], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Version{}, false
+		}
+		v.Numbers = append(v.Numbers, n)
+	}
+	// A letter right after the version is a prerelease (3.14.0a1, 1.0b2)
+	if v.Suffix != "" && v.Suffix[0] != '-' && v.Suffix[0] != '_' {
+		return Version{}, false
+	}
+	return v, true
+}
+
+// compare orders versions of the same precision
+func (v Version) compare(o Version) int {
+	for i := range v.Numbers {
+		if v.Numbers[i] != o.Numbers[i] {
+			if v.Numbers[i] < o.Numbers[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// LatestTag returns the newest of tags in the same family as current: the
+// same variant suffix and the same precision, so 20-alpine is compared with
+// 22-alpine but not 22.1-alpine or 22-slim. It returns "" when current is
+// the newest or carries no version. kind is UpdateMajor, UpdateMinor or
+// UpdatePatch.
+func LatestTag(repository, current string, tags []string) (latest, kind string) {
+	cur, ok := ParseTag(current)
+	if !ok {
+		return "", ""
+	}
+
+	best := cur
+	for _, tag := range tags {
+		v, ok := ParseTag(tag)
+		if !ok || v.Suffix != cur.Suffix || len(v.Numbers) != len(cur.Numbers) {
+			continue
+		}
+		if evenMajorsOnly[repository] && v.Numbers[0]%2 == 1 {
+			continue
+		}
+		if v.compare(best) > 0 {
+			best, latest = v, tag
+		}
+	}
+	if latest == "" {
+		return "", ""
+	}
+
+	switch {
+	case best.Numbers[0] != cur.Numbers[0]:
+		kind = UpdateMajor
+	case len(cur.Numbers) > 1 && best.Numbers[1] != cur.Numbers[1]:
+		kind = UpdateMinor
+	default:
+		kind = UpdatePatch
+	}
+	return latest, kind
+}
@@ -0,0 +1,207 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+)
+
+// maxEnvScanFiles caps how many source files are read for environment variables
+const maxEnvScanFiles = 2000
+
+// envName matches conventional (upper-case) environment variable names
+const envName = `([A-Z_][A-Z0-9_]*)`
+
+// envPattern builds a pattern for an environment variable read. The name is
+// the first group; when sep matches after the read, a quoted string (second
+// group) or number (third group) following it is the default value.
+func envPattern(read, sep string) *regexp.Regexp {
+	pattern := read
+	if sep != "" {
+		pattern += `(?:` + sep + `(?:["'` + "`" + `]([^"'` + "`" + `\n]*)["'` + "`" + `]|(-?\d+(?:\.\d+)?)))?`
+	}
+	return regexp.MustCompile(pattern)
+}
+
+var (
+	jsDefault     = `\s*(?:\|\||\?\?)\s*`
+	pythonDefault = `\s*,\s*(?:default\s*=\s*)?`
+
+	jsEnvPatterns = []*regexp.Regexp{
+		envPattern(`process\.env\.`+envName+`\b`, jsDefault),
+		envPattern(`process\.env\[\s*["']`+envName+`["']\s*\]`, jsDefault),
+		envPattern(`import\.meta\.env\.`+envName+`\b`, jsDefault),
+		envPattern(`Bun\.env\.`+envName+`\b`, jsDefault),
+		envPattern(`Deno\.env\.get\(\s*["']`+envName+`["']\s*\)`, jsDefault),
+	}
+	pythonEnvPatterns = []*regexp.Regexp{
+		envPattern(`os\.environ\[\s*["']`+envName+`["']\s*\]`, ""),
+		envPattern(`os\.(?:environ\.get|getenv)\(\s*["']`+envName+`["']`, pythonDefault),
+		// django-environ and python-decouple
+		envPattern(`\b(?:env|config)(?:\.\w+)?\(\s*["']`+envName+`["']`, pythonDefault),
+	}
+	rubyEnvPatterns = []*regexp.Regexp{
+		envPattern(`ENV\[\s*["']`+envName+`["']\s*\]`, `\s*\|\|\s*`),
+		envPattern(`ENV\.fetch\(\s*["']`+envName+`["']`, `(?:\s*,\s*|\s*\)\s*\{\s*)`),
+	}
+	goEnvPatterns = []*regexp.Regexp{
+		envPattern(`os\.(?:Getenv|LookupEnv)\(\s*"`+envName+`"\s*\)`, ""),
+	}
+	phpEnvPatterns = []*regexp.Regexp{
+		envPattern(`\benv\(\s*["']`+envName+`["']`, `\s*,\s*`),
+		envPattern(`\bgetenv\(\s*["']`+envName+`["']\s*\)`, ""),
+		envPattern(`\$_ENV\[\s*["']`+envName+`["']\s*\]`, `\s*\?\?\s*`),
+	}
+	rustEnvPatterns = []*regexp.Regexp{
+		envPattern(`\b(?:env|dotenvy|dotenv)::var(?:_os)?\(\s*"`+envName+`"\s*\)`, `\s*\.unwrap_or(?:_else)?\(\s*(?:\|_\|\s*)?`),
+	}
+	javaEnvPatterns = []*regexp.Regexp{
+		envPattern(`System\.getenv\(\s*"`+envName+`"\s*\)`, `\s*\?:\s*`),
+	}
+	scalaEnvPatterns = []*regexp.Regexp{
+		envPattern(`\bsys\.env(?:\.get)?\(\s*"`+envName+`"\s*\)`, `\s*\.getOrElse\(\s*`),
+	}
+	// Spring, Quarkus and Micronaut placeholders, ${NAME:default}, and
+	// HOCON substitutions (Play, Ktor), ${?NAME}
+	javaConfigEnvPattern = regexp.MustCompile(`\$\{\??` + envName + `(?::([^}\n]*))?\}`)
+	dotnetEnvPatterns    = []*regexp.Regexp{
+		envPattern(`Environment\.GetEnvironmentVariable\(\s*"`+envName+`"\s*\)`, `\s*\?\?\s*`),
+	}
+	elixirEnvPatterns = []*regexp.Regexp{
+		envPattern(`System\.(?:get_env|fetch_env!?)\(\s*"`+envName+`"`, `(?:\s*,\s*|\s*\)\s*\|\|\s*)`),
+	}
+)
+
+// envPatternsByExt maps source file extensions to the patterns that find env reads
+var envPatternsByExt = map[string][]*regexp.Regexp{
+	".js":     jsEnvPatterns,
+	".jsx":    jsEnvPatterns,
+	".mjs":    jsEnvPatterns,
+	".cjs":    jsEnvPatterns,
+	".ts":     jsEnvPatterns,
+	".tsx":    jsEnvPatterns,
+	".mts":    jsEnvPatterns,
+	".cts":    jsEnvPatterns,
+	".vue":    jsEnvPatterns,
+	".svelte": jsEnvPatterns,
+	".astro":  jsEnvPatterns,
+	".py":     pythonEnvPatterns,
+	".rb":     rubyEnvPatterns,
+	".rake":   rubyEnvPatterns,
+	".erb":    rubyEnvPatterns,
+	".go":     goEnvPatterns,
+	".php":    phpEnvPatterns,
+	".rs":     rustEnvPatterns,
+	".java":   javaEnvPatterns,
+	".kt":     javaEnvPatterns,
+	".scala":  scalaEnvPatterns,
+	".cs":     dotnetEnvPatterns,
+	".ex":     elixirEnvPatterns,
+	".exs":    elixirEnvPatterns,
+}
+
+// ignoredEnvVars are set by the OS or platform rather than configured for the app
+var ignoredEnvVars = map[string]bool{
+	"HOME": true, "PATH": true, "PWD": true, "HOSTNAME": true, "USER": true,
+	"SHELL": true, "TERM": true, "TMPDIR": true, "LANG": true, "CI": true,
+	"NEXT_RUNTIME": true, "NEXT_PHASE": true, "DEV": true, "PROD": true,
+	"SSR": true, "MODE": true, "BASE_URL": true,
+}
+
+// scanEnvVars finds the environment variables the application source reads,
+// with a default value where one is assigned inline
+func (s *scanner) scanEnvVars(root string, tree *FileTree, stats *ScanStats) []EnvVar {
+	found := make(map[string]*EnvVar)
+	scanned := 0
+	for _, file := range tree.Files {
+		patterns := envPatternsByExt[filepath.Ext(file)]
+		javaConfig := isJavaConfigFile(file)
+		if (patterns == nil && !javaConfig) || isTestFile(file) {
+			continue
+		}
+		if scanned >= maxEnvScanFiles {
+			break
+		}
+		scanned++
+
+		data, err := s.readFile(stats, root, file)
+		if err == errors.ErrReadBudget {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		content := string(data)
+
+		if javaConfig {
+			patterns = []*regexp.Regexp{javaConfigEnvPattern}
+		}
+		for _, re := range patterns {
+			for _, m := range re.FindAllStringSubmatch(content, -1) {
+				name := m[1]
+				if ignoredEnvVars[name] || strings.HasPrefix(name, "npm_") {
+					continue
+				}
+				def := ""
+				for _, group := range m[2:] {
+					if group != "" {
+						def = strings.TrimSpace(group)
+						break
+					}
+				}
+				if v, ok := found[name]; ok {
+					if v.Default == "" {
+						v.Default = def
+					}
+					continue
+				}
+				found[name] = &EnvVar{Name: name, Default: def, File: file}
+			}
+		}
+	}
+
+	vars := make([]EnvVar, 0, len(found))
+	for _, v := range found {
+		vars = append(vars, *v)
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		if vars[i].File != vars[j].File {
+			return vars[i].File < vars[j].File
+		}
+		return vars[i].Name < vars[j].Name
+	})
+	return vars
+}
+
+// isJavaConfigFile reports whether a file is a Spring/Quarkus/Micronaut/Ktor
+// application config, or Play's conf/application.conf
+func isJavaConfigFile(file string) bool {
+	base := filepath.Base(file)
+	if !strings.HasPrefix(base, "application") {
+		return false
+	}
+	switch filepath.Ext(base) {
+	case ".properties", ".yml", ".yaml", ".conf":
+		return strings.Contains(filepath.ToSlash(file), "src/main/resources/") || filepath.ToSlash(file) == "conf/application.conf"
+	}
+	return false
+}
+
+// isTestFile reports whether a file is a test, whose env reads don't configure the app
+func isTestFile(file string) bool {
+	file = filepath.ToSlash(file)
+	base := filepath.Base(file)
+	if strings.HasSuffix(base, "_test.go") || strings.HasPrefix(base, "test_") ||
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") || strings.HasSuffix(base, "_spec.rb") {
+		return true
+	}
+	for _, dir := range []string{"test/", "tests/", "spec/", "__tests__/", "src/test/"} {
+		if strings.HasPrefix(file, dir) || strings.Contains(file, "/"+dir) {
+			return true
+		}
+	}
+	return false
+}
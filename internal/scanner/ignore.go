@@ -0,0 +1,191 @@
+package scanner
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultIgnoreFiles are read in every directory of a scan, in this order,
+// so a .dockerizerignore can re-include (!pattern) what .gitignore excludes
+var defaultIgnoreFiles = []string{".gitignore", ".dockerizerignore"}
+
+// ignoreRule is one pattern of an ignore file
+type ignoreRule struct {
+	base    string // Directory of the ignore file, relative to the root ("" for the root)
+	re      *regexp.Regexp
+	negate  bool // !pattern re-includes a path
+	dirOnly bool // pattern/ matches directories only
+}
+
+// ignoreRules are the rules read so far, from shallower to deeper ignore
+// files; later rules take precedence, as with git
+type ignoreRules []ignoreRule
+
+// ignored reports whether the slash-separated path rel is excluded
+func (rules ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		p := rel
+		if r.base != "" {
+			if !strings.HasPrefix(rel, r.base+"/") {
+				continue
+			}
+			p = rel[len(r.base)+1:]
+		}
+		if r.re.MatchString(p) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// parseIgnoreFile parses the content of a .gitignore-style file whose
+// patterns are relative to base
+func parseIgnoreFile(content, base string) ignoreRules {
+	var rules ignoreRules
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		if rule, ok := parseIgnorePattern(sc.Text()); ok {
+			rule.base = base
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseIgnorePattern compiles one line of an ignore file following
+// gitignore(5): blank lines and # comments are skipped, a leading ! negates,
+// a trailing / matches directories only, and a pattern with a / anywhere but
+// the end is anchored to the ignore file's directory, while one without
+// matches at any depth. * and ? don't cross /, ** does.
+func parseIgnorePattern(line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch c {
+		case '*':
+			if strings.HasPrefix(line[i:], "**") && (i == 0 || line[i-1] == '/') && (i+2 == len(line) || line[i+2] == '/') {
+				switch {
+				case i+2 == len(line):
+					sb.WriteString(".*") // Trailing /** matches everything inside
+				default:
+					sb.WriteString("(?:.*/)?") // **/ matches zero or more directories
+					i++
+				}
+				i++
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(line) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(line[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// loadIgnoreFiles reads the scanner's ignore files in dir (relative to root)
+// and returns their rules. Ignore files given as absolute paths apply to the
+// whole tree and are only read for the root.
+func (s *scanner) loadIgnoreFiles(root, dir string) ignoreRules {
+	var rules ignoreRules
+	base := filepath.ToSlash(dir)
+	if base == "." {
+		base = ""
+	}
+	for _, name := range s.ignoreFiles {
+		var data []byte
+		var err error
+		switch {
+		case !filepath.IsAbs(name):
+			data, err = safeReadFileInRoot(root, filepath.Join(root, dir, name))
+		case base == "":
+			data, err = os.ReadFile(name)
+		default:
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		rules = append(rules, parseIgnoreFile(string(data), base)...)
+	}
+	return rules
+}
+
+// ignoreMatch reports whether rel (relative to the root, OS separators) is
+// excluded by rules
+func ignoreMatch(rules ignoreRules, rel string, isDir bool) bool {
+	return len(rules) > 0 && rules.ignored(path.Clean(filepath.ToSlash(rel)), isDir)
+}
+
+// IgnoreMatcher returns whether the slash-separated path rel is excluded
+// by the .gitignore-style patterns in content, as a scan applies them
+func IgnoreMatcher(content string) func(rel string, isDir bool) bool {
+	rules := parseIgnoreFile(content, "")
+	return func(rel string, isDir bool) bool {
+		return ignoreMatch(rules, rel, isDir)
+	}
+}
@@ -0,0 +1,556 @@
+package scanner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// pyproject is the part of pyproject.toml the scanner reads
+type pyproject struct {
+	BuildSystem struct {
+		Requires     []string `toml:"requires"`
+		BuildBackend string   `toml:"build-backend"`
+	} `toml:"build-system"`
+	Project struct {
+		Name                 string              `toml:"name"`
+		Version              string              `toml:"version"`
+		RequiresPython       string              `toml:"requires-python"`
+		Dependencies         []string            `toml:"dependencies"`
+		OptionalDependencies map[string][]string `toml:"optional-dependencies"`
+		Scripts              map[string]string   `toml:"scripts"`
+	} `toml:"project"`
+	DependencyGroups map[string][]interface{} `toml:"dependency-groups"`
+	Tool             map[string]interface{}   `toml:"tool"`
+}
+
+// poetry is the [tool.poetry] table
+type poetry struct {
+	Name            string                    `toml:"name"`
+	Version         string                    `toml:"version"`
+	Dependencies    map[string]interface{}    `toml:"dependencies"`
+	DevDependencies map[string]interface{}    `toml:"dev-dependencies"`
+	Scripts         map[string]interface{}    `toml:"scripts"`
+	Group           map[string]poetryDepGroup `toml:"group"`
+}
+
+type poetryDepGroup struct {
+	Dependencies map[string]interface{} `toml:"dependencies"`
+}
+
+// requirementName matches the distribution name at the start of a PEP 508 requirement
+var requirementName = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// packageNameSeparators are the runs PEP 503 folds into a single -
+var packageNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePackageName lower-cases a Python distribution name and folds
+// runs of -, _ and . into -, as PEP 503 does
+func normalizePackageName(name string) string {
+	return packageNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// requirementNames returns the normalized names of PEP 508 requirements
+func requirementNames(reqs []string) []string {
+	var names []string
+	for _, req := range reqs {
+		if m := requirementName.FindStringSubmatch(req); m != nil {
+			names = append(names, normalizePackageName(m[1]))
+		}
+	}
+	return names
+}
+
+// parsePyProject parses a pyproject.toml file: PEP 621 [project] metadata,
+// PEP 735 dependency groups, and Poetry's [tool.poetry] tables
+func parsePyProject(content string) *PyProject {
+	pyproj := &PyProject{}
+	var doc pyproject
+	if _, err := toml.Decode(content, &doc); err != nil {
+		return pyproj
+	}
+
+	pyproj.Name = doc.Project.Name
+	pyproj.Version = doc.Project.Version
+	pyproj.PythonVersion = doc.Project.RequiresPython
+	pyproj.Dependencies = requirementNames(doc.Project.Dependencies)
+	pyproj.BuildBackend = doc.BuildSystem.BuildBackend
+	pyproj.Scripts = doc.Project.Scripts
+	if len(doc.Project.OptionalDependencies) > 0 {
+		pyproj.OptionalDependencies = make(map[string][]string, len(doc.Project.OptionalDependencies))
+		for extra, reqs := range doc.Project.OptionalDependencies {
+			pyproj.OptionalDependencies[extra] = requirementNames(reqs)
+		}
+	}
+	for _, group := range doc.DependencyGroups {
+		for _, req := range group {
+			// Entries are requirements or {include-group = "..."} tables
+			if s, ok := req.(string); ok {
+				pyproj.DevDependencies = append(pyproj.DevDependencies, requirementNames([]string{s})...)
+			}
+		}
+	}
+
+	for tool := range doc.Tool {
+		pyproj.Tools = append(pyproj.Tools, tool)
+	}
+	sort.Strings(pyproj.Tools)
+
+	if _, ok := doc.Tool["poetry"]; ok {
+		var doc struct {
+			Tool struct {
+				Poetry poetry `toml:"poetry"`
+			} `toml:"tool"`
+		}
+		if _, err := toml.Decode(content, &doc); err == nil {
+			p := doc.Tool.Poetry
+			if pyproj.Name == "" {
+				pyproj.Name = p.Name
+			}
+			if pyproj.Version == "" {
+				pyproj.Version = p.Version
+			}
+			if python, ok := p.Dependencies["python"].(string); ok && pyproj.PythonVersion == "" {
+				pyproj.PythonVersion = python
+			}
+			delete(p.Dependencies, "python")
+			pyproj.Dependencies = append(pyproj.Dependencies, poetryNames(p.Dependencies)...)
+			pyproj.DevDependencies = append(pyproj.DevDependencies, poetryNames(p.DevDependencies)...)
+			for _, group := range p.Group {
+				pyproj.DevDependencies = append(pyproj.DevDependencies, poetryNames(group.Dependencies)...)
+			}
+			for name, script := range p.Scripts {
+				// Scripts are "module:function" or {reference = ..., type = ...}
+				if s, ok := script.(string); ok {
+					if pyproj.Scripts == nil {
+						pyproj.Scripts = make(map[string]string)
+					}
+					pyproj.Scripts[name] = s
+				}
+			}
+		}
+	}
+	pyproj.Dependencies = uniqueSorted(pyproj.Dependencies)
+	pyproj.DevDependencies = uniqueSorted(pyproj.DevDependencies)
+
+	// Detect build system
+	backend := pyproj.BuildBackend
+	switch {
+	case doc.Tool["poetry"] != nil || strings.HasPrefix(backend, "poetry"):
+		pyproj.BuildSystem = "poetry"
+	case strings.HasPrefix(backend, "flit"):
+		pyproj.BuildSystem = "flit"
+	case strings.HasPrefix(backend, "hatchling"):
+		pyproj.BuildSystem = "hatch"
+	case strings.HasPrefix(backend, "pdm"):
+		pyproj.BuildSystem = "pdm"
+	case strings.HasPrefix(backend, "maturin"):
+		pyproj.BuildSystem = "maturin"
+	case backend != "" || len(doc.BuildSystem.Requires) > 0:
+		pyproj.BuildSystem = "setuptools"
+	}
+
+	return pyproj
+}
+
+// poetryNames returns the normalized names of a Poetry dependency table
+func poetryNames(deps map[string]interface{}) []string {
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, normalizePackageName(name))
+	}
+	return names
+}
+
+// cargoManifest is the part of Cargo.toml the scanner reads
+type cargoManifest struct {
+	Package struct {
+		Name       string      `toml:"name"`
+		Version    interface{} `toml:"version"` // A string or {workspace = true}
+		Edition    interface{} `toml:"edition"`
+		DefaultRun string      `toml:"default-run"`
+	} `toml:"package"`
+	Dependencies    map[string]interface{} `toml:"dependencies"`
+	DevDependencies map[string]interface{} `toml:"dev-dependencies"`
+	Target          map[string]struct {
+		Dependencies map[string]interface{} `toml:"dependencies"`
+	} `toml:"target"`
+	Bin []struct {
+		Name string `toml:"name"`
+		Path string `toml:"path"`
+	} `toml:"bin"`
+	Workspace struct {
+		Members      []string               `toml:"members"`
+		Exclude      []string               `toml:"exclude"`
+		Dependencies map[string]interface{} `toml:"dependencies"`
+		Package      struct {
+			Version string `toml:"version"`
+			Edition string `toml:"edition"`
+		} `toml:"package"`
+	} `toml:"workspace"`
+}
+
+// parseCargoToml parses a Cargo.toml file. Fields a member crate inherits
+// with {workspace = true} are filled in from the workspace root afterwards.
+func parseCargoToml(content string) *CargoToml {
+	cargo := &CargoToml{
+		Dependencies: make([]string, 0),
+	}
+	var doc cargoManifest
+	if _, err := toml.Decode(content, &doc); err != nil {
+		return cargo
+	}
+
+	cargo.Name = doc.Package.Name
+	cargo.Version = tomlString(doc.Package.Version)
+	cargo.Edition = tomlString(doc.Package.Edition)
+	cargo.DefaultRun = doc.Package.DefaultRun
+	cargo.Members = doc.Workspace.Members
+	cargo.Exclude = doc.Workspace.Exclude
+	cargo.WorkspaceVersion = doc.Workspace.Package.Version
+	cargo.WorkspaceEdition = doc.Workspace.Package.Edition
+	for _, bin := range doc.Bin {
+		cargo.Bins = append(cargo.Bins, bin.Name)
+	}
+
+	deps := cargoNames(doc.Dependencies)
+	deps = append(deps, cargoNames(doc.Workspace.Dependencies)...)
+	for _, target := range doc.Target {
+		deps = append(deps, cargoNames(target.Dependencies)...)
+	}
+	cargo.Dependencies = append(cargo.Dependencies, uniqueSorted(deps)...)
+	cargo.DevDependencies = uniqueSorted(cargoNames(doc.DevDependencies))
+
+	return cargo
+}
+
+// inheritWorkspace fills in the version and edition a member crate takes
+// from the workspace root
+func (c *CargoToml) inheritWorkspace(root *CargoToml) {
+	if c.Version == "" {
+		c.Version = root.WorkspaceVersion
+	}
+	if c.Edition == "" {
+		c.Edition = root.WorkspaceEdition
+	}
+}
+
+// cargoNames returns the crate names of a dependency table, following
+// renames (web = { package = "actix-web" })
+func cargoNames(deps map[string]interface{}) []string {
+	names := make([]string, 0, len(deps))
+	for key, spec := range deps {
+		if table, ok := spec.(map[string]interface{}); ok {
+			if pkg, ok := table["package"].(string); ok && pkg != "" {
+				key = pkg
+			}
+		}
+		names = append(names, key)
+	}
+	return names
+}
+
+// tomlString returns a TOML value that is a string, or "" for anything
+// else (e.g. {workspace = true})
+func tomlString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+// uniqueSorted sorts names and drops duplicates
+func uniqueSorted(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	out := names[:1]
+	for _, n := range names[1:] {
+		if n != out[len(out)-1] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// pomFile is the part of pom.xml the scanner reads
+type pomFile struct {
+	GroupID    string        `xml:"groupId"`
+	ArtifactID string        `xml:"artifactId"`
+	Version    string        `xml:"version"`
+	Packaging  string        `xml:"packaging"`
+	Parent     MavenArtifact `xml:"parent"`
+	Properties struct {
+		Entries []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	} `xml:"properties"`
+	Dependencies        []MavenArtifact `xml:"dependencies>dependency"`
+	ManagedDependencies []MavenArtifact `xml:"dependencyManagement>dependencies>dependency"`
+	FinalName           string          `xml:"build>finalName"`
+	Plugins             []MavenArtifact `xml:"build>plugins>plugin"`
+	Modules             []string        `xml:"modules>module"`
+}
+
+// pomProperty matches a ${name} property reference
+var pomProperty = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// parsePomXML parses a Maven pom.xml, resolving ${property} references to
+// the POM's own properties
+func parsePomXML(data []byte) (*PomXML, error) {
+	var doc pomFile
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse pom.xml: %w", err)
+	}
+
+	pom := &PomXML{
+		GroupID:    doc.GroupID,
+		ArtifactID: doc.ArtifactID,
+		Version:    doc.Version,
+		Packaging:  doc.Packaging,
+		Parent:     doc.Parent,
+		Modules:    doc.Modules,
+		Properties: make(map[string]string, len(doc.Properties.Entries)),
+	}
+	if pom.GroupID == "" {
+		pom.GroupID = doc.Parent.GroupID
+	}
+	if pom.Version == "" {
+		pom.Version = doc.Parent.Version
+	}
+	for _, p := range doc.Properties.Entries {
+		pom.Properties[p.XMLName.Local] = strings.TrimSpace(p.Value)
+	}
+
+	resolve := func(s string) string {
+		return pomProperty.ReplaceAllStringFunc(strings.TrimSpace(s), func(ref string) string {
+			name := ref[2 : len(ref)-1]
+			switch name {
+			case "project.version":
+				return pom.Version
+			case "project.groupId":
+				return pom.GroupID
+			case "project.artifactId":
+				return pom.ArtifactID
+			case "project.parent.version":
+				return pom.Parent.Version
+			}
+			if v, ok := pom.Properties[name]; ok {
+				return v
+			}
+			return ref
+		})
+	}
+	resolveAll := func(artifacts []MavenArtifact) []MavenArtifact {
+		for i := range artifacts {
+			artifacts[i].GroupID = resolve(artifacts[i].GroupID)
+			artifacts[i].ArtifactID = resolve(artifacts[i].ArtifactID)
+			artifacts[i].Version = resolve(artifacts[i].Version)
+		}
+		return artifacts
+	}
+
+	pom.Dependencies = resolveAll(doc.Dependencies)
+	pom.ManagedDependencies = resolveAll(doc.ManagedDependencies)
+	pom.Plugins = resolveAll(doc.Plugins)
+	pom.FinalName = resolve(doc.FinalName)
+	for _, key := range []string{"java.version", "maven.compiler.release", "maven.compiler.source", "maven.compiler.target"} {
+		if v := resolve(pom.Properties[key]); v != "" {
+			pom.JavaVersion = v
+			break
+		}
+	}
+
+	return pom, nil
+}
+
+var (
+	// gradleComment matches // and /* */ comments of a Gradle script; a //
+	// after a colon is part of a URL
+	gradleComment = regexp.MustCompile(`(?m)/\*(?s:.*?)\*/|(^|[^:"'])//.*$`)
+	// gradlePluginID matches id "x" version "y", id("x") version "y" and apply plugin: "x"
+	gradlePluginID = regexp.MustCompile(`(?:\bid\s*\(?|apply\s+plugin:)\s*["']([\w.-]+)["']\s*\)?(?:\s*version\s*\(?\s*["']([^"']+)["'])?`)
+	// gradlePluginAlias matches a version catalog plugin: alias(libs.plugins.spring.boot)
+	gradlePluginAlias = regexp.MustCompile(`\balias\s*\(\s*(libs\.plugins\.[\w.]+)\s*\)`)
+	// gradleKotlinApplication matches the application plugin of a Kotlin DSL plugins block
+	gradleKotlinApplication = regexp.MustCompile(`(?m)^\s*application\s*$`)
+	// gradleToolchain matches languageVersion = JavaLanguageVersion.of(21) and jvmToolchain(21)
+	gradleToolchain = regexp.MustCompile(`(?:JavaLanguageVersion\.of|jvmToolchain)\s*\(\s*["']?(\d+)`)
+	// gradleCompatibility matches sourceCompatibility = '17', = JavaVersion.VERSION_17 or = 1.8
+	gradleCompatibility = regexp.MustCompile(`\b(?:source|target)Compatibility\s*(?:=|\.set\()\s*(?:JavaVersion\.(?:VERSION_|toVersion\(\s*)|["'])?["']?(1[._]\d+|\d+)`)
+)
+
+// stripGradleComments blanks out the comments of a Gradle script
+func stripGradleComments(script string) string {
+	return gradleComment.ReplaceAllStringFunc(script, func(c string) string {
+		if strings.HasPrefix(c, "/*") {
+			return ""
+		}
+		return c[:strings.Index(c, "//")]
+	})
+}
+
+// parseGradleBuild reads the plugins and Java version of a Gradle build
+// script, Groovy or Kotlin DSL
+func parseGradleBuild(script string, kotlin bool) *GradleBuild {
+	script = stripGradleComments(script)
+	build := &GradleBuild{Kotlin: kotlin, Plugins: map[string]string{}}
+	for _, m := range gradlePluginID.FindAllStringSubmatch(script, -1) {
+		build.Plugins[m[1]] = m[2]
+	}
+	for _, m := range gradlePluginAlias.FindAllStringSubmatch(script, -1) {
+		build.Plugins[m[1]] = ""
+	}
+	if kotlin && gradleKotlinApplication.MatchString(script) {
+		build.Plugins["application"] = ""
+	}
+
+	if m := gradleToolchain.FindStringSubmatch(script); m != nil {
+		build.JavaVersion = m[1]
+	} else if m := gradleCompatibility.FindStringSubmatch(script); m != nil {
+		build.JavaVersion = m[1]
+		if len(m[1]) > 2 && (m[1][1] == '.' || m[1][1] == '_') {
+			build.JavaVersion = m[1][2:] // 1.8 and VERSION_1_8 are Java 8
+		}
+	}
+	return build
+}
+
+var (
+	// gemDecl matches a gem declaration: gem "name"
+	gemDecl = regexp.MustCompile(`^gem\s*\(?\s*["']([^"']+)["']`)
+	// gemInlineGroup matches an inline group option: group: :test or groups: [:development, :test]
+	gemInlineGroup = regexp.MustCompile(`\bgroups?:\s*(\[[^\]]*\]|:\w+)`)
+	// rubyDecl matches the ruby directive: ruby "3.2.2"
+	rubyDecl = regexp.MustCompile(`^ruby\s*\(?\s*["']([^"']+)["']`)
+	// sourceDecl matches a source directive: source "https://rubygems.org"
+	sourceDecl = regexp.MustCompile(`^source\s*\(?\s*["']([^"']+)["']`)
+	// blockStart matches a line that opens a do ... end block
+	blockStart = regexp.MustCompile(`\bdo(\s*\|[^|]*\|)?\s*(#.*)?$`)
+	// lockedSpec matches a resolved gem in Gemfile.lock: "    rails (7.1.3)"
+	lockedSpec = regexp.MustCompile(`^    ([^\s(]+) \(([^)]+)\)$`)
+)
+
+// devGroups are the Bundler groups left out of production installs
+var devGroups = map[string]bool{"development": true, "test": true}
+
+// parseGemfile parses a Gemfile: declared gems, the groups they are in,
+// the ruby directive and the first source. The Gemfile is Ruby, so this
+// reads the common declarative forms and skips anything computed.
+func parseGemfile(content string) *Gemfile {
+	gemfile := &Gemfile{}
+
+	// Groups of the enclosing blocks; "" for blocks that are not groups
+	var blocks [][]string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case line == "end":
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		case strings.HasPrefix(line, "group ") && blockStart.MatchString(line):
+			blocks = append(blocks, symbols(strings.TrimPrefix(line, "group ")))
+			continue
+		case blockStart.MatchString(line):
+			blocks = append(blocks, nil)
+		}
+
+		if m := gemDecl.FindStringSubmatch(line); m != nil {
+			var groups []string
+			for _, b := range blocks {
+				groups = append(groups, b...)
+			}
+			if m := gemInlineGroup.FindStringSubmatch(line); m != nil {
+				groups = append(groups, symbols(m[1])...)
+			}
+			gemfile.Gems = append(gemfile.Gems, m[1])
+			if isDevOnly(groups) {
+				gemfile.DevGems = append(gemfile.DevGems, m[1])
+			}
+			continue
+		}
+		if m := rubyDecl.FindStringSubmatch(line); m != nil {
+			gemfile.RubyVersion = strings.TrimLeft(m[1], "~>=< ")
+			continue
+		}
+		if m := sourceDecl.FindStringSubmatch(line); m != nil && gemfile.Source == "" {
+			gemfile.Source = m[1]
+		}
+	}
+	return gemfile
+}
+
+// symbols returns the Ruby symbols (:name) in s
+func symbols(s string) []string {
+	var names []string
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '[' || r == ']' }) {
+		if name, ok := strings.CutPrefix(field, ":"); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isDevOnly reports whether groups are all development or test groups
+func isDevOnly(groups []string) bool {
+	if len(groups) == 0 {
+		return false
+	}
+	for _, g := range groups {
+		if !devGroups[g] {
+			return false
+		}
+	}
+	return true
+}
+
+// parseGemfileLock adds the resolved gem versions, Ruby version, platforms
+// and Bundler version from a Gemfile.lock to gemfile
+func parseGemfileLock(content string, gemfile *Gemfile) {
+	gemfile.Locked = make(map[string]string)
+	section := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r ")
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			section = line
+			continue
+		}
+
+		switch section {
+		case "GEM", "GIT", "PATH":
+			if m := lockedSpec.FindStringSubmatch(line); m != nil {
+				// Platform gems carry a suffix: nokogiri (1.16.2-x86_64-linux)
+				version, _, _ := strings.Cut(m[2], "-")
+				gemfile.Locked[m[1]] = version
+			}
+		case "PLATFORMS":
+			gemfile.Platforms = append(gemfile.Platforms, strings.TrimSpace(line))
+		case "RUBY VERSION":
+			// ruby 3.2.2p53
+			if v, ok := strings.CutPrefix(strings.TrimSpace(line), "ruby "); ok && gemfile.LockedRubyVersion == "" {
+				v, _, _ = strings.Cut(v, "p")
+				gemfile.LockedRubyVersion = v
+			}
+		case "BUNDLED WITH":
+			gemfile.BundlerVersion = strings.TrimSpace(line)
+		}
+	}
+
+	gemfile.RailsVersion = gemfile.Locked["rails"]
+	if gemfile.RailsVersion == "" {
+		gemfile.RailsVersion = gemfile.Locked["railties"]
+	}
+}
@@ -0,0 +1,209 @@
+package scanner
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// parseOrchestrator detects a Bazel, Nx or Gradle build of several
+// projects. Nx repositories with package.json workspaces are JavaScript
+// workspaces instead: their packages are selected with --app.
+func parseOrchestrator(root string, tree *FileTree, ws *Workspace) *Orchestrator {
+	var o *Orchestrator
+	switch {
+	case tree.HasFile("MODULE.bazel") || tree.HasFile("WORKSPACE") || tree.HasFile("WORKSPACE.bazel"):
+		o = parseBazel(root, tree)
+	case tree.HasFile("nx.json") && ws == nil:
+		o = parseNx(root, tree)
+	case tree.HasFile("settings.gradle") || tree.HasFile("settings.gradle.kts"):
+		o = parseGradle(root, tree)
+	}
+	if o == nil || len(o.Projects) == 0 {
+		return nil
+	}
+	return o
+}
+
+// nxProject is the part of an Nx project.json the Dockerfile needs
+type nxProject struct {
+	Name        string `json:"name"`
+	ProjectType string `json:"projectType"`
+	Targets     map[string]struct {
+		Executor       string                     `json:"executor"`
+		Options        map[string]interface{}     `json:"options"`
+		Configurations map[string]json.RawMessage `json:"configurations"`
+	} `json:"targets"`
+}
+
+// parseNx reads the project.json of every Nx project
+func parseNx(root string, tree *FileTree) *Orchestrator {
+	o := &Orchestrator{Tool: "nx"}
+	for _, f := range tree.FilesMatching("project.json") {
+		if strings.Contains(f, "node_modules/") {
+			continue
+		}
+		data, err := safeReadFileInRoot(root, filepath.Join(root, f))
+		if err != nil {
+			continue
+		}
+		var proj nxProject
+		if json.Unmarshal(data, &proj) != nil {
+			continue
+		}
+		dir := path.Dir(f)
+		p := BuildProject{Name: proj.Name, Dir: dir, Options: map[string]string{}}
+		if p.Name == "" {
+			p.Name = path.Base(dir)
+		}
+		if build, ok := proj.Targets["build"]; ok {
+			p.Kind = build.Executor
+			p.Application = proj.ProjectType == "application"
+			_, p.Production = build.Configurations["production"]
+			for _, key := range []string{"outputPath", "main", "target", "generatePackageJson"} {
+				switch v := build.Options[key].(type) {
+				case string:
+					p.Options[key] = v
+				case bool:
+					if v {
+						p.Options[key] = "true"
+					}
+				}
+			}
+		}
+		o.Projects = append(o.Projects, p)
+	}
+	sort.Slice(o.Projects, func(i, j int) bool { return o.Projects[i].Dir < o.Projects[j].Dir })
+	return o
+}
+
+// gradleInclude matches include statements of settings.gradle(.kts); a
+// Groovy include without parentheses continues on lines after a comma
+var gradleInclude = regexp.MustCompile(`(?m)^\s*include\b\s*(\([^)]*\)|[^\n]*(?:,[ \t]*\r?\n[^\n]*)*)`)
+
+// gradleProjectDir matches a project moved out of its default directory:
+// project(":api").projectDir = file("services/api")
+var gradleProjectDir = regexp.MustCompile(`project\(\s*["']([^"']+)["']\s*\)\.projectDir\s*=\s*(?:new\s+)?[Ff]ile\(\s*(?:(?:rootDir|settingsDir|rootProject\.projectDir)\s*,\s*)?["']([^"']+)["']\s*\)`)
+
+// gradleQuoted matches the project paths of an include statement
+var gradleQuoted = regexp.MustCompile(`["']([^"']+)["']`)
+
+// gradleApplicationPlugins identify the Gradle projects that build a
+// runnable app, by plugin ID or by the end of a version catalog alias
+var gradleApplicationPlugins = []struct{ marker, alias, kind string }{
+	{"org.springframework.boot", "boot", "spring-boot"},
+	{"io.quarkus", "quarkus", "quarkus"},
+	{"io.micronaut.application", "micronaut.application", "micronaut"},
+	{"io.ktor.plugin", "ktor", "ktor"},
+	{"application", "", "application"},
+}
+
+// parseGradle reads the subprojects settings.gradle includes
+func parseGradle(root string, tree *FileTree) *Orchestrator {
+	settings := "settings.gradle"
+	if !tree.HasFile(settings) {
+		settings = "settings.gradle.kts"
+	}
+	data, err := safeReadFileInRoot(root, filepath.Join(root, settings))
+	if err != nil {
+		return nil
+	}
+	settingsScript := stripGradleComments(string(data))
+	dirs := map[string]string{}
+	for _, m := range gradleProjectDir.FindAllStringSubmatch(settingsScript, -1) {
+		dirs[":"+strings.TrimPrefix(m[1], ":")] = path.Clean(m[2])
+	}
+
+	o := &Orchestrator{Tool: "gradle"}
+	for _, include := range gradleInclude.FindAllStringSubmatch(settingsScript, -1) {
+		for _, m := range gradleQuoted.FindAllStringSubmatch(include[1], -1) {
+			name := ":" + strings.TrimPrefix(m[1], ":")
+			p := BuildProject{Name: name, Dir: strings.ReplaceAll(strings.TrimPrefix(name, ":"), ":", "/")}
+			if dir, ok := dirs[name]; ok {
+				p.Dir = dir
+			}
+			for _, build := range []string{"build.gradle", "build.gradle.kts"} {
+				script, err := safeReadFileInRoot(root, filepath.Join(root, p.Dir, build))
+				if err != nil {
+					continue
+				}
+				b := parseGradleBuild(string(script), build == "build.gradle.kts")
+				p.Kind = gradlePlugin(b)
+				p.Application = p.Kind != ""
+				p.JavaVersion = b.JavaVersion
+			}
+			o.Projects = append(o.Projects, p)
+		}
+	}
+	return o
+}
+
+// gradlePlugin returns the application plugin a build script applies, if any
+func gradlePlugin(build *GradleBuild) string {
+	for _, plugin := range gradleApplicationPlugins {
+		if _, ok := build.Plugins[plugin.marker]; ok {
+			return plugin.kind
+		}
+		for id := range build.Plugins {
+			alias, ok := strings.CutPrefix(id, "libs.plugins.")
+			if ok && plugin.alias != "" && strings.HasSuffix(strings.ToLower(alias), plugin.alias) {
+				return plugin.kind
+			}
+		}
+	}
+	return ""
+}
+
+// bazelRule matches the start of a Bazel rule call
+var bazelRule = regexp.MustCompile(`\b([a-z_]+_binary)\s*\(`)
+
+// bazelName matches the name attribute of a rule
+var bazelName = regexp.MustCompile(`\bname\s*=\s*"([^"]+)"`)
+
+// bazelDeployable are the binary rules whose output runs in a container on
+// its own: native executables and self-contained Java deploy jars
+var bazelDeployable = map[string]bool{"go_binary": true, "cc_binary": true, "rust_binary": true, "java_binary": true}
+
+// parseBazel reads the binary targets of every BUILD file
+func parseBazel(root string, tree *FileTree) *Orchestrator {
+	o := &Orchestrator{Tool: "bazel"}
+	if data, err := safeReadFileInRoot(root, filepath.Join(root, ".bazelversion")); err == nil {
+		o.Version = strings.TrimSpace(string(data))
+	}
+	var builds []string
+	for _, f := range append(tree.FilesMatching("BUILD"), tree.FilesMatching("BUILD.bazel")...) {
+		if !strings.HasPrefix(f, "bazel-") {
+			builds = append(builds, f)
+		}
+	}
+	sort.Strings(builds)
+	for _, f := range builds {
+		data, err := safeReadFileInRoot(root, filepath.Join(root, f))
+		if err != nil {
+			continue
+		}
+		content := string(data)
+		dir := path.Dir(f)
+		pkg := dir
+		if pkg == "." {
+			pkg = ""
+		}
+		for _, loc := range bazelRule.FindAllStringSubmatchIndex(content, -1) {
+			rule := content[loc[2]:loc[3]]
+			m := bazelName.FindStringSubmatch(content[loc[1]:])
+			if m == nil {
+				continue
+			}
+			o.Projects = append(o.Projects, BuildProject{
+				Name:        "//" + pkg + ":" + m[1],
+				Dir:         dir,
+				Application: bazelDeployable[rule],
+				Kind:        rule,
+			})
+		}
+	}
+	return o
+}
@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	"github.com/dublyo/dockerizer/internal/errors"
+	"github.com/dublyo/dockerizer/internal/redact"
+	"gopkg.in/yaml.v3"
 )
 
 // Scanner scans repositories
@@ -25,6 +27,12 @@ type scanner struct {
 	maxFiles           int
 	ignoreHidden       bool
 	ignorePaths        []string
+	ignoreFiles        []string            // .gitignore-style files read in each directory
+	maxDepth           int                 // Directory levels walked below the root; 0 for no limit
+	followSymlinks     bool                // Walk symlinked directories inside the root
+	readBudget         int64               // Total bytes read from files; 0 for no limit
+	redact             bool                // Redact secrets from key file contents
+	progress           func(files int)     // Called as the walk finds files
 	allowedHiddenFiles map[string]struct{} // Important hidden files to always include
 }
 
@@ -33,7 +41,10 @@ func New(opts ...Option) Scanner {
 	s := &scanner{
 		maxFileSize:  1024 * 1024, // 1MB
 		maxFiles:     10000,
+		readBudget:   32 * 1024 * 1024, // 32MB
 		ignoreHidden: true,
+		redact:       true,
+		ignoreFiles:  append([]string(nil), defaultIgnoreFiles...),
 		ignorePaths: []string{
 			"node_modules",
 			".git",
@@ -49,27 +60,34 @@ func New(opts ...Option) Scanner {
 		},
 		// Important hidden files for version detection and configuration
 		allowedHiddenFiles: map[string]struct{}{
-			".nvmrc":           {},
-			".node-version":    {},
-			".python-version":  {},
-			".ruby-version":    {},
-			".go-version":      {},
-			".java-version":    {},
-			".sdkmanrc":        {},
-			".tool-versions":   {},
-			".mise.toml":       {},
-			".rtx.toml":        {},
-			".env":             {},
-			".env.example":     {},
-			".env.local":       {},
-			".dockerizer.yml":  {},
-			".dockerizer.yaml": {},
-			".editorconfig":    {},
-			".gitignore":       {},
-			".dockerignore":    {},
-			".babelrc":         {},
-			".eslintrc":        {},
-			".prettierrc":      {},
+			".nvmrc":            {},
+			".node-version":     {},
+			".python-version":   {},
+			".ruby-version":     {},
+			".go-version":       {},
+			".dvmrc":            {},
+			".bun-version":      {},
+			".rr.yaml":          {},
+			".java-version":     {},
+			".sdkmanrc":         {},
+			".tool-versions":    {},
+			".mise.toml":        {},
+			".rtx.toml":         {},
+			".env":              {},
+			".env.example":      {},
+			".env.local":        {},
+			".dockerizer.yml":   {},
+			".dockerizer.yaml":  {},
+			".editorconfig":     {},
+			".gitignore":        {},
+			".dockerignore":     {},
+			".dockerizerignore": {},
+			".babelrc":          {},
+			".eslintrc":         {},
+			".prettierrc":       {},
+			".npmrc":            {}, // Read for registry settings, never sent to AI providers
+			".netrc":            {},
+			".pip.conf":         {},
 		},
 	}
 	for _, opt := range opts {
@@ -92,6 +110,32 @@ func WithMaxFiles(count int) Option {
 	}
 }
 
+// WithMaxDepth sets how many directory levels below the root are walked;
+// 0 means no limit
+func WithMaxDepth(depth int) Option {
+	return func(s *scanner) {
+		s.maxDepth = depth
+	}
+}
+
+// WithFollowSymlinks sets whether symlinked directories are walked. Links
+// are only followed inside the root, and never back into a directory that
+// contains them. Symlinked files inside the root are always included.
+func WithFollowSymlinks(follow bool) Option {
+	return func(s *scanner) {
+		s.followSymlinks = follow
+	}
+}
+
+// WithReadBudget caps the total bytes read from files during a scan;
+// 0 means no limit. Manifests are read first, then key files, then source
+// files for environment variables.
+func WithReadBudget(bytes int64) Option {
+	return func(s *scanner) {
+		s.readBudget = bytes
+	}
+}
+
 // WithIgnoreHidden sets whether to ignore hidden files
 func WithIgnoreHidden(ignore bool) Option {
 	return func(s *scanner) {
@@ -99,6 +143,33 @@ func WithIgnoreHidden(ignore bool) Option {
 	}
 }
 
+// WithIgnoreFile adds a .gitignore-style file to the ones read, after
+// .gitignore and .dockerizerignore. A relative name is looked up in every
+// directory of the tree; an absolute path applies from the root.
+func WithIgnoreFile(name string) Option {
+	return func(s *scanner) {
+		s.ignoreFiles = append(s.ignoreFiles, name)
+	}
+}
+
+// WithRedaction sets whether secrets are redacted from key file contents
+func WithRedaction(enabled bool) Option {
+	return func(s *scanner) {
+		s.redact = enabled
+	}
+}
+
+// progressEvery is how many files the walk finds between progress calls
+const progressEvery = 100
+
+// WithProgress sets a function called with the number of files found so far
+// while the tree is walked, every 100 files and once when the walk ends
+func WithProgress(fn func(files int)) Option {
+	return func(s *scanner) {
+		s.progress = fn
+	}
+}
+
 // safeReadFileInRoot reads a file only if it resolves to a path within the given root.
 // This prevents symlink-based disclosure attacks where a malicious repo
 // could include a symlink (or intermediate directory symlink) pointing outside the repo.
@@ -163,35 +234,51 @@ func (s *scanner) Scan(ctx context.Context, path string) (*ScanResult, error) {
 
 	result := &ScanResult{
 		Path:     absPath,
+		Redacted: s.redact,
 		rootPath: absPath,
 	}
 
 	// Scan file tree with periodic cancellation checks
-	tree, err := s.scanFileTree(ctx, absPath)
+	stats := &result.Stats
+	tree, err := s.scanFileTree(ctx, absPath, stats)
 	if err != nil {
 		return nil, err
 	}
 	result.FileTree = tree
 
 	// Extract metadata
-	metadata, err := s.extractMetadata(ctx, absPath, tree)
+	metadata, err := s.extractMetadata(ctx, absPath, tree, stats)
 	if err != nil {
 		return nil, err
 	}
 	result.Metadata = metadata
 
 	// Collect key files for AI context
-	keyFiles, err := s.collectKeyFiles(ctx, absPath, tree)
+	keyFiles, err := s.collectKeyFiles(ctx, absPath, tree, stats)
 	if err != nil {
 		return nil, err
 	}
 	result.KeyFiles = keyFiles
 
+	// Find environment variables the source reads, with what is left of the budget
+	metadata.EnvVars = s.scanEnvVars(absPath, tree, stats)
+
+	// Key files are sent to AI providers, so keep secrets out of them
+	if s.redact {
+		for i := range result.KeyFiles {
+			content, findings := redact.Secrets(result.KeyFiles[i].Content)
+			result.KeyFiles[i].Content = content
+			for _, f := range findings {
+				result.Redactions = append(result.Redactions, Redaction{Path: result.KeyFiles[i].Path, Line: f.Line, Rule: f.Rule})
+			}
+		}
+	}
+
 	return result, nil
 }
 
 // scanFileTree builds the file tree structure
-func (s *scanner) scanFileTree(ctx context.Context, root string) (*FileTree, error) {
+func (s *scanner) scanFileTree(ctx context.Context, root string, stats *ScanStats) (*FileTree, error) {
 	tree := &FileTree{
 		Root:    root,
 		Files:   make([]string, 0, 1000),
@@ -200,84 +287,35 @@ func (s *scanner) scanFileTree(ctx context.Context, root string) (*FileTree, err
 		dirSet:  make(map[string]struct{}, 100),
 	}
 
-	fileCount := 0
-	maxDepth := 0
-
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return nil // Skip files with errors
-		}
-
-		// Check for cancellation periodically
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// Get relative path
-		relPath, err := filepath.Rel(root, path)
-		if err != nil {
-			return nil
-		}
-
-		// Skip root
-		if relPath == "." {
-			return nil
-		}
-
-		// Calculate depth
-		depth := strings.Count(relPath, string(filepath.Separator))
-		if depth > maxDepth {
-			maxDepth = depth
-		}
-
-		// Check if should ignore
-		baseName := filepath.Base(relPath)
-		if s.ignoreHidden && strings.HasPrefix(baseName, ".") && baseName != "." {
-			// Check if this is an allowed hidden file
-			if _, allowed := s.allowedHiddenFiles[baseName]; !allowed {
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-		}
-
-		for _, ignorePath := range s.ignorePaths {
-			if baseName == ignorePath || strings.HasPrefix(relPath, ignorePath+string(filepath.Separator)) {
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-		}
-
-		if d.IsDir() {
-			tree.Dirs = append(tree.Dirs, relPath)
-			tree.dirSet[relPath] = struct{}{}
-		} else {
-			if fileCount >= s.maxFiles {
-				return nil // Silently skip if we've hit the limit
-			}
-			tree.Files = append(tree.Files, relPath)
-			tree.fileSet[relPath] = struct{}{}
-			fileCount++
-		}
-
-		return nil
-	})
-
+	realRoot, err := filepath.EvalSymlinks(root)
 	if err != nil {
 		return nil, err
 	}
+	realRoot, _ = filepath.Abs(realRoot)
 
-	tree.MaxDepth = maxDepth
+	w := &walker{
+		s:         s,
+		root:      root,
+		realRoot:  realRoot,
+		tree:      tree,
+		stats:     stats,
+		rules:     s.loadIgnoreFiles(root, "."),
+		following: map[string]bool{realRoot: true},
+	}
+	if err := w.walk(ctx, root, ""); err != nil {
+		return nil, err
+	}
+
+	stats.Files = len(tree.Files)
+	stats.Dirs = len(tree.Dirs)
+	if s.progress != nil {
+		s.progress(stats.Files)
+	}
 	return tree, nil
 }
 
 // extractMetadata parses configuration files
-func (s *scanner) extractMetadata(ctx context.Context, root string, tree *FileTree) (*Metadata, error) {
+func (s *scanner) extractMetadata(ctx context.Context, root string, tree *FileTree, stats *ScanStats) (*Metadata, error) {
 	metadata := &Metadata{}
 
 	// Check for cancellation
@@ -289,7 +327,7 @@ func (s *scanner) extractMetadata(ctx context.Context, root string, tree *FileTr
 
 	// Parse package.json
 	if tree.HasFile("package.json") {
-		data, err := safeReadFileInRoot(root, filepath.Join(root, "package.json"))
+		data, err := s.readFile(stats, root, "package.json")
 		if err == nil {
 			var pkg PackageJSON
 			if json.Unmarshal(data, &pkg) == nil {
@@ -298,9 +336,13 @@ func (s *scanner) extractMetadata(ctx context.Context, root string, tree *FileTr
 		}
 	}
 
+	// Detect JavaScript workspaces
+	metadata.Workspace = parseWorkspace(root, tree, metadata.PackageJSON)
+	metadata.Orchestrator = parseOrchestrator(root, tree, metadata.Workspace)
+
 	// Parse go.mod
 	if tree.HasFile("go.mod") {
-		data, err := safeReadFileInRoot(root, filepath.Join(root, "go.mod"))
+		data, err := s.readFile(stats, root, "go.mod")
 		if err == nil {
 			metadata.GoMod = parseGoMod(string(data))
 		}
@@ -308,7 +350,7 @@ func (s *scanner) extractMetadata(ctx context.Context, root string, tree *FileTr
 
 	// Parse requirements.txt
 	if tree.HasFile("requirements.txt") {
-		data, err := safeReadFileInRoot(root, filepath.Join(root, "requirements.txt"))
+		data, err := s.readFile(stats, root, "requirements.txt")
 		if err == nil {
 			metadata.Requirements = parseRequirements(string(data))
 		}
@@ -316,7 +358,7 @@ func (s *scanner) extractMetadata(ctx context.Context, root string, tree *FileTr
 
 	// Parse pyproject.toml
 	if tree.HasFile("pyproject.toml") {
-		data, err := safeReadFileInRoot(root, filepath.Join(root, "pyproject.toml"))
+		data, err := s.readFile(stats, root, "pyproject.toml")
 		if err == nil {
 			metadata.PyProject = parsePyProject(string(data))
 		}
@@ -324,15 +366,58 @@ func (s *scanner) extractMetadata(ctx context.Context, root string, tree *FileTr
 
 	// Parse Cargo.toml
 	if tree.HasFile("Cargo.toml") {
-		data, err := safeReadFileInRoot(root, filepath.Join(root, "Cargo.toml"))
+		data, err := s.readFile(stats, root, "Cargo.toml")
 		if err == nil {
 			metadata.CargoToml = parseCargoToml(string(data))
+			for _, dir := range cargoMemberDirs(tree, metadata.CargoToml.Members, metadata.CargoToml.Exclude) {
+				data, err := s.readFile(stats, root, filepath.Join(dir, "Cargo.toml"))
+				if err != nil {
+					continue
+				}
+				crate := parseCargoToml(string(data))
+				crate.Dir = dir
+				crate.inheritWorkspace(metadata.CargoToml)
+				metadata.CargoToml.Crates = append(metadata.CargoToml.Crates, crate)
+			}
+		}
+	}
+
+	// Parse Gemfile and Gemfile.lock
+	if tree.HasFile("Gemfile") {
+		data, err := s.readFile(stats, root, "Gemfile")
+		if err == nil {
+			metadata.Gemfile = parseGemfile(string(data))
+			if tree.HasFile("Gemfile.lock") {
+				if lock, err := s.readFile(stats, root, "Gemfile.lock"); err == nil {
+					parseGemfileLock(string(lock), metadata.Gemfile)
+				}
+			}
+		}
+	}
+
+	// Parse pom.xml
+	if tree.HasFile("pom.xml") {
+		data, err := s.readFile(stats, root, "pom.xml")
+		if err == nil {
+			if pom, err := parsePomXML(data); err == nil {
+				metadata.PomXML = pom
+			}
+		}
+	}
+
+	// Parse build.gradle or build.gradle.kts
+	for _, name := range []string{"build.gradle.kts", "build.gradle"} {
+		if tree.HasFile(name) {
+			if data, err := s.readFile(stats, root, name); err == nil {
+				metadata.Gradle = parseGradleBuild(string(data), name == "build.gradle.kts")
+			}
+			break
 		}
 	}
 
 	// Parse composer.json
 	if tree.HasFile("composer.json") {
-		data, err := safeReadFileInRoot(root, filepath.Join(root, "composer.json"))
+		data, err := s.readFile(stats, root, "composer.json")
 		if err == nil {
 			var composer ComposerJSON
 			if json.Unmarshal(data, &composer) == nil {
@@ -341,11 +426,33 @@ func (s *scanner) extractMetadata(ctx context.Context, root string, tree *FileTr
 		}
 	}
 
+	// Parse Procfile
+	if tree.HasFile("Procfile") {
+		data, err := s.readFile(stats, root, "Procfile")
+		if err == nil {
+			metadata.Procfile = parseProcfile(string(data))
+		}
+	}
+
+	// Parse app.json (Heroku)
+	if tree.HasFile("app.json") {
+		data, err := s.readFile(stats, root, "app.json")
+		if err == nil {
+			var app AppJSON
+			if json.Unmarshal(data, &app) == nil {
+				metadata.AppJSON = &app
+			}
+		}
+	}
+
+	// Private package registries the dependency install needs credentials for
+	metadata.BuildSecrets = s.scanBuildSecrets(root, tree, stats)
+
 	return metadata, nil
 }
 
 // collectKeyFiles gathers important files for AI context
-func (s *scanner) collectKeyFiles(ctx context.Context, root string, tree *FileTree) ([]KeyFile, error) {
+func (s *scanner) collectKeyFiles(ctx context.Context, root string, tree *FileTree, stats *ScanStats) ([]KeyFile, error) {
 	keyFilePatterns := []string{
 		"package.json",
 		"go.mod",
@@ -372,15 +479,9 @@ func (s *scanner) collectKeyFiles(ctx context.Context, root string, tree *FileTr
 		".tool-versions",
 		".mise.toml",
 		"Procfile",
+		"app.json",
 	}
 
-	// Resolve the root directory once for containment checks
-	realRoot, err := filepath.EvalSymlinks(root)
-	if err != nil {
-		return nil, err
-	}
-	realRoot, _ = filepath.Abs(realRoot)
-
 	var keyFiles []KeyFile
 	for _, pattern := range keyFilePatterns {
 		select {
@@ -389,41 +490,19 @@ func (s *scanner) collectKeyFiles(ctx context.Context, root string, tree *FileTr
 		default:
 		}
 
-		if tree.HasFile(pattern) {
-			fullPath := filepath.Join(root, pattern)
-
-			// Security: resolve all symlinks and verify containment
-			realPath, err := filepath.EvalSymlinks(fullPath)
-			if err != nil {
-				continue
-			}
-			realPath, _ = filepath.Abs(realPath)
-
-			// Skip if path escapes the root via symlinks
-			if !isPathWithin(realPath, realRoot) {
-				continue
-			}
-
-			info, err := os.Stat(fullPath)
-			if err != nil {
-				continue
-			}
-
-			if info.Size() > s.maxFileSize {
-				continue
-			}
-
-			data, err := os.ReadFile(fullPath)
-			if err != nil {
-				continue
-			}
-
-			keyFiles = append(keyFiles, KeyFile{
-				Path:    pattern,
-				Content: string(data),
-				Size:    info.Size(),
-			})
+		if !tree.HasFile(pattern) {
+			continue
+		}
+		// Security: readFile resolves all symlinks and verifies containment
+		data, err := s.readFile(stats, root, pattern)
+		if err != nil {
+			continue
 		}
+		keyFiles = append(keyFiles, KeyFile{
+			Path:    pattern,
+			Content: string(data),
+			Size:    int64(len(data)),
+		})
 	}
 
 	return keyFiles, nil
@@ -490,72 +569,146 @@ func parseRequirements(content string) []string {
 	return reqs
 }
 
-// parsePyProject parses a pyproject.toml file (simplified)
-func parsePyProject(content string) *PyProject {
-	pyproj := &PyProject{}
-	lines := strings.Split(content, "\n")
-
-	for _, line := range lines {
+// parseProcfile parses a Heroku-style Procfile ("<type>: <command>" per line)
+func parseProcfile(content string) []Process {
+	var processes []Process
+	for _, line := range strings.Split(content, "\n") {
 		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "name = ") {
-			pyproj.Name = strings.Trim(strings.TrimPrefix(line, "name = "), "\"")
-		} else if strings.HasPrefix(line, "requires-python = ") {
-			pyproj.PythonVersion = strings.Trim(strings.TrimPrefix(line, "requires-python = "), "\"")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
 		}
+		name, command, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if name == "" || command == "" || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		processes = append(processes, Process{Name: name, Command: command})
 	}
+	return processes
+}
 
-	// Detect build system
-	if strings.Contains(content, "[tool.poetry]") {
-		pyproj.BuildSystem = "poetry"
-	} else if strings.Contains(content, "[build-system]") {
-		if strings.Contains(content, "flit") {
-			pyproj.BuildSystem = "flit"
-		} else if strings.Contains(content, "hatchling") {
-			pyproj.BuildSystem = "hatch"
-		} else {
-			pyproj.BuildSystem = "setuptools"
-		}
+// cargoMemberDirs resolves workspace member patterns to directories that have a Cargo.toml
+func cargoMemberDirs(tree *FileTree, members, exclude []string) []string {
+	excluded := make(map[string]bool, len(exclude))
+	for _, dir := range exclude {
+		excluded[strings.TrimSuffix(strings.TrimPrefix(dir, "./"), "/")] = true
 	}
 
-	return pyproj
+	var dirs []string
+	for _, member := range members {
+		member = strings.TrimSuffix(strings.TrimPrefix(member, "./"), "/")
+		if !strings.ContainsAny(member, "*?[") {
+			if tree.HasFile(filepath.Join(member, "Cargo.toml")) {
+				dirs = append(dirs, member)
+			}
+			continue
+		}
+		for _, dir := range tree.Dirs {
+			if excluded[filepath.ToSlash(dir)] {
+				continue
+			}
+			if ok, _ := filepath.Match(member, dir); ok && tree.HasFile(filepath.Join(dir, "Cargo.toml")) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
 }
 
-// parseCargoToml parses a Cargo.toml file (simplified)
-func parseCargoToml(content string) *CargoToml {
-	cargo := &CargoToml{
-		Dependencies: make([]string, 0),
+// parseWorkspace detects a pnpm, yarn, npm or bun workspace at the root and
+// reads the package.json of every package it matches
+func parseWorkspace(root string, tree *FileTree, pkg *PackageJSON) *Workspace {
+	ws := &Workspace{
+		Turbo: tree.HasFile("turbo.json"),
+		Nx:    tree.HasFile("nx.json"),
 	}
-	lines := strings.Split(content, "\n")
-	inDependencies := false
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	var globs []string
+	if tree.HasFile("pnpm-workspace.yaml") {
+		data, err := safeReadFileInRoot(root, filepath.Join(root, "pnpm-workspace.yaml"))
+		if err != nil {
+			return nil
+		}
+		var cfg struct {
+			Packages []string `yaml:"packages"`
+		}
+		if yaml.Unmarshal(data, &cfg) != nil {
+			return nil
+		}
+		ws.Tool = "pnpm"
+		globs = cfg.Packages
+	} else if pkg != nil && len(pkg.Workspaces) > 0 {
+		globs = pkg.Workspaces
+		switch {
+		case tree.HasFile("yarn.lock"):
+			ws.Tool = "yarn"
+		case tree.HasFile("bun.lock") || tree.HasFile("bun.lockb"):
+			ws.Tool = "bun"
+		default:
+			ws.Tool = "npm"
+		}
+	} else {
+		return nil
+	}
 
-		if strings.HasPrefix(line, "[package]") {
-			inDependencies = false
-		} else if strings.HasPrefix(line, "[dependencies]") {
-			inDependencies = true
-		} else if strings.HasPrefix(line, "[") {
-			inDependencies = false
-		}
-
-		if strings.HasPrefix(line, "name = ") && !inDependencies {
-			cargo.Name = strings.Trim(strings.TrimPrefix(line, "name = "), "\"")
-		} else if strings.HasPrefix(line, "version = ") && !inDependencies {
-			cargo.Version = strings.Trim(strings.TrimPrefix(line, "version = "), "\"")
-		} else if strings.HasPrefix(line, "edition = ") {
-			cargo.Edition = strings.Trim(strings.TrimPrefix(line, "edition = "), "\"")
-		} else if inDependencies && strings.Contains(line, "=") && !strings.HasPrefix(line, "#") {
-			// Parse dependency line like: actix-web = "4" or serde = { version = "1", ... }
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) >= 1 {
-				depName := strings.TrimSpace(parts[0])
-				if depName != "" {
-					cargo.Dependencies = append(cargo.Dependencies, depName)
+	for _, f := range []string{"pnpm-lock.yaml", "yarn.lock", "package-lock.json", "bun.lock", "bun.lockb"} {
+		if tree.HasFile(f) {
+			ws.LockFile = f
+			break
+		}
+	}
+
+	for _, dir := range workspaceDirs(tree, globs) {
+		data, err := safeReadFileInRoot(root, filepath.Join(root, dir, "package.json"))
+		if err != nil {
+			continue
+		}
+		var member PackageJSON
+		if json.Unmarshal(data, &member) != nil {
+			continue
+		}
+		ws.Packages = append(ws.Packages, WorkspacePackage{Name: member.Name, Dir: dir, PackageJSON: &member})
+	}
+	if len(ws.Packages) == 0 {
+		return nil
+	}
+	return ws
+}
+
+// workspaceDirs resolves workspace globs ("apps/*", "packages/**", "!excluded")
+// to directories that have a package.json
+func workspaceDirs(tree *FileTree, globs []string) []string {
+	var include, exclude []string
+	for _, g := range globs {
+		g = strings.TrimSuffix(strings.TrimPrefix(g, "./"), "/")
+		if rest, ok := strings.CutPrefix(g, "!"); ok {
+			exclude = append(exclude, strings.TrimPrefix(rest, "./"))
+		} else if g != "" {
+			include = append(include, g)
+		}
+	}
+
+	matches := func(patterns []string, dir string) bool {
+		for _, p := range patterns {
+			if prefix, ok := strings.CutSuffix(p, "/**"); ok {
+				if strings.HasPrefix(dir, prefix+"/") {
+					return true
 				}
+			} else if ok, _ := filepath.Match(p, dir); ok {
+				return true
 			}
 		}
+		return false
 	}
 
-	return cargo
+	var dirs []string
+	for _, dir := range tree.Dirs {
+		if matches(include, dir) && !matches(exclude, dir) && tree.HasFile(filepath.Join(dir, "package.json")) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
 }
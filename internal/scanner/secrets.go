@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Build secret IDs, as passed to docker build --secret id=<id>
+const (
+	SecretNpmrc   = "npmrc"   // npm, pnpm, Yarn and Bun registry auth
+	SecretPipConf = "pipconf" // pip index configuration
+	SecretNetrc   = "netrc"   // HTTP credentials for package indexes and Go modules
+)
+
+// npmrcAuth matches .npmrc lines that hold registry credentials or point a
+// scope at another registry
+var npmrcAuth = regexp.MustCompile(`(?m)^\s*(?:\S*:)?(?:_authToken|_auth|_password|username)\s*=|^\s*@[\w.-]+:registry\s*=`)
+
+// customIndex matches requirements.txt options that install from another
+// package index
+var customIndex = regexp.MustCompile(`(?m)^\s*(?:--index-url|--extra-index-url|-i)[\s=]`)
+
+// scanBuildSecrets finds what points at a private package registry: the
+// dependency install then needs credentials, mounted as build secrets
+func (s *scanner) scanBuildSecrets(root string, tree *FileTree, stats *ScanStats) []BuildSecret {
+	var secrets []BuildSecret
+	seen := make(map[string]bool)
+	add := func(secret BuildSecret) {
+		if !seen[secret.ID] {
+			seen[secret.ID] = true
+			secrets = append(secrets, secret)
+		}
+	}
+
+	if tree.HasFile(".npmrc") {
+		if data, err := s.readFile(stats, root, ".npmrc"); err == nil && npmrcAuth.Match(data) {
+			add(BuildSecret{
+				ID:          SecretNpmrc,
+				File:        ".npmrc",
+				Reason:      ".npmrc configures a private registry",
+				Credentials: hasLiteralCredentials(string(data)),
+			})
+		}
+	}
+	for _, f := range []string{"pip.conf", ".pip.conf", "pip.ini"} {
+		if tree.HasFile(f) {
+			add(BuildSecret{ID: SecretPipConf, File: f, Reason: f + " configures the package index", Credentials: true})
+			break
+		}
+	}
+	if tree.HasFile(".netrc") {
+		add(BuildSecret{ID: SecretNetrc, File: ".netrc", Reason: ".netrc holds registry credentials", Credentials: true})
+	}
+	if tree.HasFile("requirements.txt") {
+		if data, err := s.readFile(stats, root, "requirements.txt"); err == nil && customIndex.Match(data) {
+			add(BuildSecret{ID: SecretNetrc, Reason: "requirements.txt installs from a custom index"})
+		}
+	}
+	if tree.HasFile("pyproject.toml") {
+		if data, err := s.readFile(stats, root, "pyproject.toml"); err == nil {
+			for _, table := range []string{"[[tool.uv.index]]", "[[tool.poetry.source]]", "[[tool.pdm.source]]"} {
+				if strings.Contains(string(data), table) {
+					add(BuildSecret{ID: SecretNetrc, Reason: "pyproject.toml declares a package index (" + strings.Trim(table, "[]") + ")"})
+					break
+				}
+			}
+		}
+	}
+	return secrets
+}
+
+// hasLiteralCredentials reports whether an .npmrc holds a credential itself
+// rather than a ${VAR} reference to one
+func hasLiteralCredentials(npmrc string) bool {
+	for _, line := range strings.Split(npmrc, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || strings.HasPrefix(key, "#") || strings.HasPrefix(key, ";") {
+			continue
+		}
+		if strings.HasSuffix(key, "_authToken") || strings.HasSuffix(key, "_auth") || strings.HasSuffix(key, "_password") {
+			if value = strings.TrimSpace(value); value != "" && !strings.HasPrefix(value, "${") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@
 package scanner
 
 import (
+	"encoding/json"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -14,9 +15,39 @@ type ScanResult struct {
 	FileTree *FileTree
 	Metadata *Metadata
 	KeyFiles []KeyFile
+	// Redactions lists secrets removed from KeyFiles contents
+	Redactions []Redaction
+	// Redacted is set when secrets are redacted from contents sent to AI providers
+	Redacted bool
+	// Stats explains what the scan left out and why
+	Stats    ScanStats
 	rootPath string // For ReadFile operations
 }
 
+// ScanStats counts what a scan walked, read and skipped, to explain why a
+// file is missing from the tree or from the key files
+type ScanStats struct {
+	Files           int      `json:"files"`
+	Dirs            int      `json:"dirs"`
+	BytesRead       int64    `json:"bytes_read"`
+	Hidden          int      `json:"hidden"`                  // Hidden files and directories skipped
+	Ignored         int      `json:"ignored"`                 // Entries excluded by built-in or ignore-file rules
+	IgnoredPaths    []string `json:"ignored_paths,omitempty"` // The first entries excluded by ignore files
+	TooDeep         int      `json:"too_deep"`                // Entries below the depth limit
+	TooLarge        []string `json:"too_large,omitempty"`     // Files not read for exceeding the size limit
+	Symlinks        int      `json:"symlinks_skipped"`        // Broken, outside the root, or unfollowed directory links
+	SymlinkCycles   int      `json:"symlink_cycles"`
+	Truncated       bool     `json:"truncated"`        // The file limit cut the tree short
+	BudgetExhausted bool     `json:"budget_exhausted"` // Files went unread once the read budget ran out
+}
+
+// Redaction is a secret redacted from a key file
+type Redaction struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Rule string `json:"rule"` // Kind of secret, e.g. "AWS access key"
+}
+
 // ReadFile reads a file relative to the repository root.
 // It resolves all symlinks and rejects paths that escape the repository root.
 func (s *ScanResult) ReadFile(path string) ([]byte, error) {
@@ -135,7 +166,128 @@ type Metadata struct {
 	CargoToml    *CargoToml    // Cargo.toml
 	ComposerJSON *ComposerJSON // composer.json
 	PomXML       *PomXML       // pom.xml
+	Gradle       *GradleBuild  // build.gradle or build.gradle.kts
 	Csproj       *Csproj       // *.csproj
+	Procfile     []Process     // Procfile process types, in file order
+	AppJSON      *AppJSON      // app.json (Heroku)
+	Workspace    *Workspace    // JavaScript monorepo (pnpm, yarn or npm workspaces)
+	Orchestrator *Orchestrator // Nx, Gradle or Bazel multi-project build
+	EnvVars      []EnvVar      // Environment variables read by the source, by file then name
+	BuildSecrets []BuildSecret // Credentials for private package registries
+}
+
+// Dependencies returns the names of the dependencies declared in every
+// manifest scanned: npm packages, Python distributions (normalized), Go
+// modules, gems, crates, Composer packages and Maven artifact IDs
+func (m *Metadata) Dependencies() []string {
+	var names []string
+	if p := m.PackageJSON; p != nil {
+		for name := range p.Dependencies {
+			names = append(names, name)
+		}
+		for name := range p.DevDependencies {
+			names = append(names, name)
+		}
+	}
+	names = append(names, requirementNames(m.Requirements)...)
+	if m.PyProject != nil {
+		names = append(names, m.PyProject.Dependencies...)
+	}
+	if m.GoMod != nil {
+		names = append(names, m.GoMod.Require...)
+	}
+	if m.Gemfile != nil {
+		names = append(names, m.Gemfile.Gems...)
+	}
+	if m.CargoToml != nil {
+		names = append(names, m.CargoToml.Dependencies...)
+	}
+	if m.ComposerJSON != nil {
+		for name := range m.ComposerJSON.Require {
+			names = append(names, name)
+		}
+	}
+	if m.PomXML != nil {
+		for _, a := range m.PomXML.Dependencies {
+			names = append(names, a.ArtifactID)
+		}
+	}
+	return uniqueSorted(names)
+}
+
+// BuildSecret is a credential the dependency install needs for a private
+// package registry. It is mounted with docker build --secret, never copied
+// into the image.
+type BuildSecret struct {
+	ID          string `json:"id"`             // SecretNpmrc, SecretPipConf or SecretNetrc
+	File        string `json:"file,omitempty"` // Project file that configures the registry, if any
+	Reason      string `json:"reason"`
+	Credentials bool   `json:"credentials,omitempty"` // File holds the credentials themselves: keep it out of the build context
+}
+
+// EnvVar is an environment variable the application reads
+type EnvVar struct {
+	Name        string `json:"name"`
+	Default     string `json:"default,omitempty"`     // Inline fallback value, if any
+	File        string `json:"file"`                  // First file that reads it
+	Description string `json:"description,omitempty"` // What to set it to (app.json)
+}
+
+// Process is a Procfile entry (e.g. "worker: bundle exec sidekiq")
+type Process struct {
+	Name    string
+	Command string
+}
+
+// AppJSON is a Heroku app.json manifest
+type AppJSON struct {
+	Name       string                `json:"name"`
+	Env        map[string]AppJSONEnv `json:"env"`
+	Addons     []AppJSONAddon        `json:"addons"`
+	Scripts    map[string]string     `json:"scripts"` // postdeploy, pr-predestroy
+	Buildpacks []struct {
+		URL string `json:"url"`
+	} `json:"buildpacks"`
+}
+
+// AppJSONEnv is a config var of app.json: its value, or a description of
+// the value to set
+type AppJSONEnv struct {
+	Description string `json:"description"`
+	Value       string `json:"value"`
+	Required    *bool  `json:"required"`  // Unset means required
+	Generator   string `json:"generator"` // "secret": Heroku sets a random value
+}
+
+// UnmarshalJSON accepts both forms of a config var: "value" or an object
+func (e *AppJSONEnv) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*e = AppJSONEnv{Value: value}
+		return nil
+	}
+	type env AppJSONEnv
+	return json.Unmarshal(data, (*env)(e))
+}
+
+// AppJSONAddon is an add-on of app.json, such as heroku-postgresql
+type AppJSONAddon struct {
+	Plan string `json:"plan"` // Service and plan: heroku-postgresql:essential-0
+}
+
+// UnmarshalJSON accepts both forms of an add-on: "plan" or an object
+func (a *AppJSONAddon) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Plan); err == nil {
+		return nil
+	}
+	type addon AppJSONAddon
+	return json.Unmarshal(data, (*addon)(a))
+}
+
+// Service is the add-on service without its plan: heroku-postgresql
+func (a AppJSONAddon) Service() string {
+	service, _, _ := strings.Cut(a.Plan, ":")
+	return service
 }
 
 // PackageJSON represents a Node.js package.json file
@@ -150,8 +302,97 @@ type PackageJSON struct {
 		Node string `json:"node"`
 		NPM  string `json:"npm"`
 	} `json:"engines"`
-	PackageManager string `json:"packageManager"`
-	Type           string `json:"type"` // "module" or "commonjs"
+	PackageManager string     `json:"packageManager"`
+	Type           string     `json:"type"` // "module" or "commonjs"
+	Workspaces     Workspaces `json:"workspaces"`
+}
+
+// Workspaces is the package.json "workspaces" field: an array of globs, or
+// an object with a "packages" array (yarn)
+type Workspaces []string
+
+// UnmarshalJSON accepts both forms of the workspaces field
+func (w *Workspaces) UnmarshalJSON(data []byte) error {
+	var globs []string
+	if err := json.Unmarshal(data, &globs); err == nil {
+		*w = globs
+		return nil
+	}
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*w = obj.Packages
+	return nil
+}
+
+// Workspace describes a JavaScript monorepo
+type Workspace struct {
+	Tool     string             // Package manager that owns the workspace: pnpm, yarn, npm or bun
+	LockFile string             // Root lock file, if any
+	Turbo    bool               // turbo.json is present
+	Nx       bool               // nx.json is present
+	Packages []WorkspacePackage // Packages matched by the workspace globs
+}
+
+// WorkspacePackage is a package inside a workspace
+type WorkspacePackage struct {
+	Name        string
+	Dir         string // Relative to the workspace root
+	PackageJSON *PackageJSON
+}
+
+// Package finds a workspace package by name or directory
+func (w *Workspace) Package(nameOrDir string) *WorkspacePackage {
+	dir := strings.TrimSuffix(strings.TrimPrefix(nameOrDir, "./"), "/")
+	for i := range w.Packages {
+		if w.Packages[i].Name == nameOrDir || w.Packages[i].Dir == dir {
+			return &w.Packages[i]
+		}
+	}
+	return nil
+}
+
+// Orchestrator describes a build orchestrator that builds several projects
+// from the repository root
+type Orchestrator struct {
+	Tool     string         // nx, gradle or bazel
+	Version  string         // Bazel: .bazelversion
+	Projects []BuildProject // In the order they were found
+}
+
+// BuildProject is one buildable project of an orchestrator
+type BuildProject struct {
+	Name        string            // Nx project, Gradle project path (:api) or Bazel label (//services/api:server)
+	Dir         string            // Relative to the repository root
+	Application bool              // Deployable, as opposed to a library
+	Kind        string            // Nx build executor, Gradle application plugin or Bazel rule
+	Options     map[string]string // Nx build options (outputPath, main, target, generatePackageJson)
+	Production  bool              // Nx: the build target has a production configuration
+	JavaVersion string            // Gradle: the toolchain its build script declares
+}
+
+// Project finds a build project by name, short name or directory: "api"
+// matches the Gradle project ":api" and the Bazel label "//api:api"
+func (o *Orchestrator) Project(target string) *BuildProject {
+	dir := strings.TrimSuffix(strings.TrimPrefix(target, "./"), "/")
+	for i := range o.Projects {
+		p := &o.Projects[i]
+		if p.Name == target || strings.TrimPrefix(p.Name, ":") == target {
+			return p
+		}
+		if _, short, ok := strings.Cut(p.Name, ":"); ok && o.Tool == "bazel" && short == target {
+			return p
+		}
+	}
+	for i := range o.Projects {
+		if o.Projects[i].Dir == dir && o.Projects[i].Application {
+			return &o.Projects[i]
+		}
+	}
+	return nil
 }
 
 // HasDependency checks if a dependency exists (dev or regular)
@@ -186,26 +427,66 @@ type GoMod struct {
 
 // PyProject represents a Python pyproject.toml file
 type PyProject struct {
-	Name          string
-	Version       string
-	PythonVersion string
-	Dependencies  []string
-	BuildSystem   string // poetry, setuptools, flit, etc.
+	Name                 string
+	Version              string
+	PythonVersion        string              // requires-python, or Poetry's python dependency
+	Dependencies         []string            // Runtime dependency names, normalized (lower-case, - separated)
+	DevDependencies      []string            // Dependency groups and Poetry dev/group dependencies
+	OptionalDependencies map[string][]string // Extras and their dependency names
+	Scripts              map[string]string   // Console scripts: name to "module:function"
+	BuildSystem          string              // poetry, setuptools, flit, hatch, pdm, maturin
+	BuildBackend         string              // [build-system] build-backend as written
+	Tools                []string            // [tool.*] tables present, e.g. poetry, uv, pdm
 }
 
-// Gemfile represents a Ruby Gemfile
+// Gemfile represents a Ruby Gemfile, with its Gemfile.lock when there is one
 type Gemfile struct {
-	RubyVersion string
-	Gems        []string
-	Source      string
+	RubyVersion       string            // ruby directive in the Gemfile
+	Gems              []string          // Gems declared in the Gemfile, in order
+	DevGems           []string          // Declared gems only in the development or test groups
+	Source            string            // First gem source
+	Locked            map[string]string // Gemfile.lock: every resolved gem to its version
+	LockedRubyVersion string            // Gemfile.lock RUBY VERSION
+	RailsVersion      string            // Locked rails (or railties) version
+	BundlerVersion    string            // Gemfile.lock BUNDLED WITH
+	Platforms         []string          // Gemfile.lock PLATFORMS
+}
+
+// HasGem reports whether the Gemfile declares gem or the lock resolved it
+func (g *Gemfile) HasGem(gem string) bool {
+	for _, name := range g.Gems {
+		if name == gem {
+			return true
+		}
+	}
+	_, locked := g.Locked[gem]
+	return locked
+}
+
+// Ruby returns the Ruby version the Gemfile asks for, or the one the lock
+// was resolved with
+func (g *Gemfile) Ruby() string {
+	if g.RubyVersion != "" {
+		return g.RubyVersion
+	}
+	return g.LockedRubyVersion
 }
 
 // CargoToml represents a Rust Cargo.toml file
 type CargoToml struct {
-	Name         string
-	Version      string
-	Edition      string // 2018, 2021
-	Dependencies []string
+	Name             string
+	Version          string
+	Edition          string       // 2018, 2021
+	DefaultRun       string       // [package] default-run binary
+	Dependencies     []string     // Crate names from [dependencies], [target.*.dependencies] and [workspace.dependencies], sorted
+	DevDependencies  []string     // Crate names from [dev-dependencies], sorted
+	Bins             []string     // [[bin]] target names
+	Members          []string     // [workspace] members, as written (may be globs)
+	Exclude          []string     // [workspace] exclude
+	WorkspaceVersion string       // [workspace.package] version members may inherit
+	WorkspaceEdition string       // [workspace.package] edition members may inherit
+	Dir              string       // Directory relative to the root ("" for the root manifest)
+	Crates           []*CargoToml // Parsed workspace member manifests (root manifest only)
 }
 
 // ComposerJSON represents a PHP composer.json file
@@ -217,13 +498,65 @@ type ComposerJSON struct {
 	} `json:"autoload"`
 }
 
-// PomXML represents a Java pom.xml file
+// PomXML represents a Java pom.xml file, with ${property} references resolved
 type PomXML struct {
-	GroupID      string
-	ArtifactID   string
-	Version      string
-	JavaVersion  string
-	Dependencies []string
+	GroupID             string // Inherited from the parent when not set
+	ArtifactID          string
+	Version             string // Inherited from the parent when not set
+	Packaging           string // jar, war, pom, native-image, ...
+	Parent              MavenArtifact
+	JavaVersion         string // java.version, maven.compiler.release or maven.compiler.source
+	FinalName           string // build finalName
+	Properties          map[string]string
+	Dependencies        []MavenArtifact
+	ManagedDependencies []MavenArtifact // dependencyManagement, e.g. imported BOMs
+	Plugins             []MavenArtifact // build plugins
+	Modules             []string        // Multi-module build modules
+}
+
+// GradleBuild is what a Gradle build script declares about the build
+type GradleBuild struct {
+	Kotlin      bool              // build.gradle.kts
+	JavaVersion string            // Toolchain languageVersion or jvmToolchain, else sourceCompatibility
+	Plugins     map[string]string // Plugin IDs and version catalog aliases (libs.plugins.spring.boot), to their version if given
+}
+
+// MavenArtifact is a Maven parent, dependency or plugin
+type MavenArtifact struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Scope      string `xml:"scope"`
+}
+
+// artifacts returns the parent, dependencies, managed dependencies and plugins
+func (p *PomXML) artifacts() []MavenArtifact {
+	all := []MavenArtifact{p.Parent}
+	all = append(all, p.Dependencies...)
+	all = append(all, p.ManagedDependencies...)
+	return append(all, p.Plugins...)
+}
+
+// UsesGroup reports whether the parent, a dependency, a managed dependency
+// or a plugin belongs to group or one of its subgroups
+func (p *PomXML) UsesGroup(group string) bool {
+	for _, a := range p.artifacts() {
+		if a.GroupID == group || strings.HasPrefix(a.GroupID, group+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesArtifact reports whether the parent, a dependency, a managed
+// dependency or a plugin has the artifact ID
+func (p *PomXML) UsesArtifact(artifactID string) bool {
+	for _, a := range p.artifacts() {
+		if a.ArtifactID == artifactID {
+			return true
+		}
+	}
+	return false
 }
 
 // Csproj represents a .NET .csproj file
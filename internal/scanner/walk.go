@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/errors"
+)
+
+// maxIgnoredPaths caps how many ignore-rule exclusions ScanStats lists
+const maxIgnoredPaths = 100
+
+// walker is the state of one file tree walk
+type walker struct {
+	s        *scanner
+	root     string
+	realRoot string
+	tree     *FileTree
+	stats    *ScanStats
+	rules    ignoreRules
+	// following holds the targets of the symlinked directories being walked,
+	// to stop at a link back into one of them
+	following map[string]bool
+}
+
+// walk adds the entries under dir, whose path relative to the root is
+// prefix, to the tree. dir differs from root+prefix inside a followed
+// symlink.
+func (w *walker) walk(ctx context.Context, dir, prefix string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip files with errors
+		}
+
+		// Check for cancellation periodically
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		relPath := filepath.Join(prefix, rel)
+
+		depth := strings.Count(relPath, string(filepath.Separator))
+		if w.s.maxDepth > 0 && depth >= w.s.maxDepth {
+			w.stats.TooDeep++
+			return skip(d)
+		}
+		if depth > w.tree.MaxDepth {
+			w.tree.MaxDepth = depth
+		}
+
+		isDir := d.IsDir()
+		var target string
+		if d.Type()&fs.ModeSymlink != 0 {
+			if target, isDir = w.resolveSymlink(path); target == "" {
+				return nil
+			}
+		}
+
+		baseName := filepath.Base(relPath)
+		if w.s.ignoreHidden && strings.HasPrefix(baseName, ".") {
+			// Check if this is an allowed hidden file
+			if _, allowed := w.s.allowedHiddenFiles[baseName]; !allowed {
+				w.stats.Hidden++
+				return skip(d)
+			}
+		}
+
+		for _, ignorePath := range w.s.ignorePaths {
+			if baseName == ignorePath || strings.HasPrefix(relPath, ignorePath+string(filepath.Separator)) {
+				w.stats.Ignored++
+				return skip(d)
+			}
+		}
+
+		if ignoreMatch(w.rules, relPath, isDir) {
+			w.stats.Ignored++
+			if len(w.stats.IgnoredPaths) < maxIgnoredPaths {
+				name := filepath.ToSlash(relPath)
+				if isDir {
+					name += "/"
+				}
+				w.stats.IgnoredPaths = append(w.stats.IgnoredPaths, name)
+			}
+			return skip(d)
+		}
+
+		if !isDir {
+			if len(w.tree.Files) >= w.s.maxFiles {
+				w.stats.Truncated = true
+				return nil
+			}
+			w.tree.Files = append(w.tree.Files, relPath)
+			w.tree.fileSet[relPath] = struct{}{}
+			if w.s.progress != nil && len(w.tree.Files)%progressEvery == 0 {
+				w.s.progress(len(w.tree.Files))
+			}
+			return nil
+		}
+
+		w.rules = append(w.rules, w.s.loadIgnoreFiles(w.root, relPath)...)
+		w.tree.Dirs = append(w.tree.Dirs, relPath)
+		w.tree.dirSet[relPath] = struct{}{}
+
+		if target != "" {
+			w.following[target] = true
+			err := w.walk(ctx, target, relPath)
+			delete(w.following, target)
+			return err
+		}
+		return nil
+	})
+}
+
+// resolveSymlink decides what to do with the symlink at path. It returns
+// the link's target and whether it is a directory to walk, or "" when the
+// link is skipped: it is broken, points outside the root, is a directory
+// while symlinks aren't followed, or leads back into a directory it is in.
+func (w *walker) resolveSymlink(path string) (string, bool) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		w.stats.Symlinks++
+		return "", false
+	}
+	target, _ = filepath.Abs(target)
+	info, err := os.Stat(target)
+	if err != nil || !isPathWithin(target, w.realRoot) {
+		w.stats.Symlinks++
+		return "", false
+	}
+	if !info.IsDir() {
+		return target, false
+	}
+	if !w.s.followSymlinks {
+		w.stats.Symlinks++
+		return "", false
+	}
+
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		w.stats.Symlinks++
+		return "", false
+	}
+	parent, _ = filepath.Abs(parent)
+	if isPathWithin(parent, target) || w.following[target] {
+		w.stats.SymlinkCycles++
+		return "", false
+	}
+	return target, true
+}
+
+// skip leaves out an entry, and everything under it for a directory
+func skip(d fs.DirEntry) error {
+	if d.IsDir() {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// readFile reads a file relative to the root within the scan's limits: it
+// must resolve inside the root, fit the size limit, and fit what is left of
+// the read budget. Files refused for size are listed in stats.
+func (s *scanner) readFile(stats *ScanStats, root, rel string) ([]byte, error) {
+	path := filepath.Join(root, rel)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > s.maxFileSize {
+		stats.TooLarge = append(stats.TooLarge, filepath.ToSlash(rel))
+		return nil, errors.ErrFileTooLarge
+	}
+	if s.readBudget > 0 && stats.BytesRead+info.Size() > s.readBudget {
+		stats.BudgetExhausted = true
+		return nil, errors.ErrReadBudget
+	}
+
+	data, err := safeReadFileInRoot(root, path)
+	if err != nil {
+		return nil, err
+	}
+	stats.BytesRead += int64(len(data))
+	return data, nil
+}
@@ -0,0 +1,67 @@
+// Package secrets keeps credentials such as AI API keys in the operating
+// system's secret store: the macOS Keychain, the Windows Credential Manager,
+// or the Secret Service (libsecret) on Linux and BSD.
+package secrets
+
+import (
+	"errors"
+	"strings"
+)
+
+// Service names dockerizer's entries in the secret store
+const Service = "dockerizer"
+
+var (
+	// ErrNotFound is returned when the store has no secret for the account
+	ErrNotFound = errors.New("secret not found")
+
+	// ErrUnavailable is returned when the platform has no usable secret store
+	ErrUnavailable = errors.New("no secret store available")
+)
+
+// Store is an operating system secret store
+type Store interface {
+	// Name describes the store, e.g. "macOS Keychain"
+	Name() string
+	// Get returns the secret saved for account
+	Get(account string) (string, error)
+	// Set saves the secret for account, replacing any previous one
+	Set(account, secret string) error
+	// Delete removes the secret for account
+	Delete(account string) error
+}
+
+// Default returns the platform's secret store. Its methods return
+// ErrUnavailable when the store cannot be reached, e.g. when secret-tool is
+// not installed.
+func Default() Store {
+	return platformStore()
+}
+
+// Get returns the secret saved for account in the default store
+func Get(account string) (string, error) {
+	return Default().Get(account)
+}
+
+// Set saves the secret for account in the default store
+func Set(account, secret string) error {
+	return Default().Set(account, secret)
+}
+
+// Delete removes the secret for account from the default store
+func Delete(account string) error {
+	return Default().Delete(account)
+}
+
+// unavailable is the store of platforms without a supported secret store
+type unavailable struct{}
+
+func (unavailable) Name() string               { return "none" }
+func (unavailable) Get(string) (string, error) { return "", ErrUnavailable }
+func (unavailable) Set(string, string) error   { return ErrUnavailable }
+func (unavailable) Delete(string) error        { return ErrUnavailable }
+
+// trimOutput strips the newline command-line tools print after a secret
+func trimOutput(out []byte) string {
+	return strings.TrimRight(string(out), "\r\n")
+}
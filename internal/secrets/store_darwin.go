@@ -0,0 +1,56 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychain stores secrets as generic passwords in the login keychain,
+// through the security command
+type keychain struct{}
+
+func platformStore() Store { return keychain{} }
+
+func (keychain) Name() string { return "macOS Keychain" }
+
+func (keychain) Get(account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", Service, "-a", account, "-w").Output()
+	if err != nil {
+		return "", keychainError(err)
+	}
+	return trimOutput(out), nil
+}
+
+func (keychain) Set(account, secret string) error {
+	// -U updates an existing item. The secret is passed as an argument, as
+	// security offers no way to read it from stdin non-interactively.
+	// Output, unlike Run, keeps stderr for keychainError
+	_, err := exec.Command("security", "add-generic-password", "-U", "-s", Service, "-a", account, "-l", Service+" "+account, "-w", secret).Output()
+	return keychainError(err)
+}
+
+func (keychain) Delete(account string) error {
+	_, err := exec.Command("security", "delete-generic-password", "-s", Service, "-a", account).Output()
+	return keychainError(err)
+}
+
+// keychainError maps the security command's failures to the package errors
+func keychainError(err error) error {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, exec.ErrNotFound):
+		return ErrUnavailable
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 44: // errSecItemNotFound
+		return ErrNotFound
+	case errors.As(err, &exitErr):
+		if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+			return errors.New("keychain: " + msg)
+		}
+		return fmt.Errorf("keychain: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,5 @@
+//go:build !darwin && !windows && !linux && !freebsd && !openbsd && !netbsd
+
+package secrets
+
+func platformStore() Store { return unavailable{} }
@@ -0,0 +1,65 @@
+//go:build linux || freebsd || openbsd || netbsd
+
+package secrets
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// secretService stores secrets through the freedesktop Secret Service
+// (GNOME Keyring, KWallet) with libsecret's secret-tool
+type secretService struct{}
+
+func platformStore() Store { return secretService{} }
+
+func (secretService) Name() string { return "Secret Service (libsecret)" }
+
+func (secretService) Get(account string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", Service, "account", account).Output()
+	if err != nil {
+		return "", secretToolError(err)
+	}
+	// lookup succeeds with no output when nothing matches
+	if len(out) == 0 {
+		return "", ErrNotFound
+	}
+	return trimOutput(out), nil
+}
+
+func (secretService) Set(account, secret string) error {
+	// secret-tool reads the secret from stdin, keeping it off the command line
+	cmd := exec.Command("secret-tool", "store", "--label", Service+" "+account, "service", Service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	// Output, unlike Run, keeps stderr for secretToolError
+	_, err := cmd.Output()
+	return secretToolError(err)
+}
+
+func (secretService) Delete(account string) error {
+	if _, err := (secretService{}).Get(account); err != nil {
+		return err
+	}
+	_, err := exec.Command("secret-tool", "clear", "service", Service, "account", account).Output()
+	return secretToolError(err)
+}
+
+// secretToolError maps secret-tool's failures to the package errors. Without
+// a running Secret Service (e.g. over SSH or in CI) the store is unavailable.
+func secretToolError(err error) error {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, exec.ErrNotFound):
+		return ErrUnavailable
+	case errors.As(err, &exitErr):
+		msg := strings.TrimSpace(string(exitErr.Stderr))
+		if msg == "" {
+			return ErrNotFound
+		}
+		return errors.Join(ErrUnavailable, errors.New("secret-tool: "+msg))
+	}
+	return err
+}
@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// diffMaxCells bounds the line-matching table; larger files are shown as
+// replaced wholesale
+const diffMaxCells = 4_000_000
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// Diff returns a colored unified diff of a file's old and new content, or ""
+// when they are the same. An empty old content is shown as a new file.
+func Diff(name, old, new string) string {
+	if old == new {
+		return ""
+	}
+
+	var sb strings.Builder
+	if old == "" {
+		fmt.Fprintf(&sb, "%s+++ %s (new file)%s\n", bold, name, reset)
+	} else {
+		fmt.Fprintf(&sb, "%s--- %s\n+++ %s%s\n", bold, name, name, reset)
+	}
+
+	ops := diffLines(splitLines(old), splitLines(new))
+	for _, h := range hunks(ops) {
+		oldStart, newStart, oldLen, newLen := h.position(ops)
+		fmt.Fprintf(&sb, "%s@@ -%d,%d +%d,%d @@%s\n", cyan, oldStart, oldLen, newStart, newLen, reset)
+		for _, op := range ops[h.from:h.to] {
+			switch op.kind {
+			case '-':
+				fmt.Fprintf(&sb, "%s-%s%s\n", red, op.text, reset)
+			case '+':
+				fmt.Fprintf(&sb, "%s+%s%s\n", green, op.text, reset)
+			default:
+				fmt.Fprintf(&sb, " %s\n", op.text)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// DiffStat counts the lines a change adds and removes
+func DiffStat(old, new string) (added, removed int) {
+	for _, op := range diffLines(splitLines(old), splitLines(new)) {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines matches the lines of a and b by longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	// Trim the common prefix and suffix; most edits are local
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var ops []diffOp
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	if (len(ma)+1)*(len(mb)+1) > diffMaxCells {
+		for _, l := range ma {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range mb {
+			ops = append(ops, diffOp{'+', l})
+		}
+	} else {
+		// lcs[i][j] is the LCS length of ma[i:] and mb[j:]
+		lcs := make([][]int, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				ops = append(ops, diffOp{' ', ma[i]})
+				i++
+				j++
+			case i < len(ma) && (j == len(mb) || lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, diffOp{'-', ma[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', mb[j]})
+				j++
+			}
+		}
+	}
+
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+// hunk is a range of ops holding changes and their context
+type hunk struct{ from, to int }
+
+// hunks groups changes whose context overlaps
+func hunks(ops []diffOp) []hunk {
+	var hs []hunk
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		from := max(i-diffContext, 0)
+		to := min(i+diffContext+1, len(ops))
+		if n := len(hs); n > 0 && from <= hs[n-1].to {
+			hs[n-1].to = to
+		} else {
+			hs = append(hs, hunk{from, to})
+		}
+	}
+	return hs
+}
+
+// position returns the hunk's 1-based start lines and lengths in the old and
+// new content
+func (h hunk) position(ops []diffOp) (oldStart, newStart, oldLen, newLen int) {
+	for _, op := range ops[:h.from] {
+		if op.kind != '+' {
+			oldStart++
+		}
+		if op.kind != '-' {
+			newStart++
+		}
+	}
+	for _, op := range ops[h.from:h.to] {
+		if op.kind != '+' {
+			oldLen++
+		}
+		if op.kind != '-' {
+			newLen++
+		}
+	}
+	if oldLen > 0 {
+		oldStart++
+	}
+	if newLen > 0 {
+		newStart++
+	}
+	return oldStart, newStart, oldLen, newLen
+}
@@ -0,0 +1,199 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Step states in a Pane
+const (
+	StepRunning = iota
+	StepDone
+	StepFailed
+)
+
+// paneRedraw throttles redraws caused by log output
+const paneRedraw = 50 * time.Millisecond
+
+type paneStep struct {
+	label  string
+	detail string
+	state  int
+	start  time.Time
+	took   time.Duration
+}
+
+// Pane is a live progress display: a list of steps, the running one marked,
+// above a scrolling window with the tail of the current step's log. Finished
+// steps are printed once and scroll away like normal output; only the
+// running step and the log window are redrawn in place. It is safe for
+// concurrent use; log output is written to it as an io.Writer.
+type Pane struct {
+	mu        sync.Mutex
+	out       io.Writer
+	steps     []paneStep
+	committed int // Steps printed for good
+	logs      []string
+	partial   string
+	logLines  int
+	drawn     int // Lines of the live region
+	last      time.Time
+	pending   bool // A throttled redraw is scheduled
+	closed    bool
+}
+
+// paneDetailLines limits the detail shown under a finished step
+const paneDetailLines = 3
+
+// NewPane starts a pane under title showing up to logLines lines of log
+func NewPane(out io.Writer, title string, logLines int) *Pane {
+	if title != "" {
+		fmt.Fprintf(out, "  %s%s%s\n", bold, truncate(title, Width()-2), reset)
+	}
+	return &Pane{out: out, logLines: logLines}
+}
+
+// Step finishes the running step successfully, if any, and starts a new
+// one with an empty log
+func (p *Pane) Step(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finish(StepDone, "")
+	p.steps = append(p.steps, paneStep{label: label, state: StepRunning, start: time.Now()})
+	p.logs, p.partial = nil, ""
+	p.redraw()
+}
+
+// Finish ends the running step as done or failed, with an optional detail
+// shown after its label. The log stays visible until the next step.
+func (p *Pane) Finish(state int, detail string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finish(state, detail)
+	p.redraw()
+}
+
+// Note adds a finished line that is not a step, e.g. a section heading
+func (p *Pane) Note(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finish(StepDone, "")
+	p.steps = append(p.steps, paneStep{label: label, state: -1})
+	p.redraw()
+}
+
+func (p *Pane) finish(state int, detail string) {
+	if n := len(p.steps); n > 0 && p.steps[n-1].state == StepRunning {
+		s := &p.steps[n-1]
+		s.state = state
+		s.detail = detail
+		s.took = time.Since(s.start)
+	}
+}
+
+// Write appends output to the running step's log
+func (p *Pane) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	text := strings.ReplaceAll(p.partial+string(b), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	p.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		// Progress bars rewrite their line with \r; keep the final state
+		if i := strings.LastIndex(line, "\r"); i >= 0 {
+			line = line[i+1:]
+		}
+		p.logs = append(p.logs, strings.ReplaceAll(line, "\t", "    "))
+	}
+	if extra := len(p.logs) - p.logLines; extra > 0 {
+		p.logs = append([]string(nil), p.logs[extra:]...)
+	}
+	if time.Since(p.last) >= paneRedraw {
+		p.redraw()
+	} else if !p.pending {
+		// Show the latest lines even if no more output follows
+		p.pending = true
+		time.AfterFunc(paneRedraw, func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.pending = false
+			p.redraw()
+		})
+	}
+	return len(b), nil
+}
+
+// Close draws the final state without the log window
+func (p *Pane) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logs, p.partial = nil, ""
+	p.redraw()
+	p.closed = true
+}
+
+// redraw prints newly finished steps and repaints the live region over its
+// previous drawing; the caller holds mu
+func (p *Pane) redraw() {
+	if p.closed {
+		return
+	}
+	p.last = time.Now()
+	w := Width()
+
+	var sb strings.Builder
+	sb.WriteString(cursorUp(p.drawn))
+	sb.WriteString(clearDown)
+	lines := 0
+	line := func(format string, args ...interface{}) {
+		sb.WriteString(fmt.Sprintf(format, args...) + "\n")
+		lines++
+	}
+
+	for i := p.committed; i < len(p.steps); i++ {
+		s := p.steps[i]
+		if i == p.committed && s.state != StepRunning {
+			p.committed++
+		}
+		label := truncate(s.label, w-24)
+		switch s.state {
+		case StepRunning:
+			line("  %s›%s %s %s…%s", cyan, reset, label, dim, reset)
+		case StepDone:
+			line("  %s✓%s %s %s%s%s", green, reset, label, dim, s.took.Round(time.Millisecond), reset)
+		case StepFailed:
+			line("  %s✗%s %s %s%s%s", red, reset, label, dim, s.took.Round(time.Millisecond), reset)
+		default:
+			line("  %s%s%s", yellow, label, reset)
+		}
+		if s.detail != "" {
+			details := strings.Split(strings.TrimSpace(s.detail), "\n")
+			if len(details) > paneDetailLines {
+				details = append(details[:paneDetailLines], "…")
+			}
+			for _, d := range details {
+				line("    %s%s%s", dim, truncate(d, w-5), reset)
+			}
+		}
+		if i < p.committed {
+			lines = 0 // Finished steps are not part of the live region
+		}
+	}
+
+	logs := p.logs
+	if p.partial != "" {
+		logs = append(logs[:len(logs):len(logs)], p.partial)
+	}
+	if len(logs) > p.logLines {
+		logs = logs[len(logs)-p.logLines:]
+	}
+	for _, l := range logs {
+		line("    %s│ %s%s", dim, truncate(l, w-7), reset)
+	}
+
+	fmt.Fprint(p.out, sb.String())
+	p.drawn = lines
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin && !freebsd
+
+package tui
+
+import "errors"
+
+// Raw terminal input is not implemented here, so callers fall back to plain
+// prompts
+
+func isTerminal(fd int) bool { return false }
+
+func makeRaw(fd int) (func(), error) {
+	return nil, errors.New("terminal UI not supported on this platform")
+}
+
+func noEcho(fd int) (func(), error) {
+	return nil, errors.New("terminal UI not supported on this platform")
+}
+
+func width(fd int) int { return 0 }
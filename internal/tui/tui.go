@@ -0,0 +1,209 @@
+// Package tui provides the terminal UI used by interactive commands:
+// selectable lists, a live progress pane and a diff view. It needs no
+// dependencies beyond the standard library; on terminals it cannot drive,
+// Available reports false and callers fall back to plain prompts.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrInterrupted is returned when the user cancels with Ctrl+C or Esc
+var ErrInterrupted = errors.New("interrupted")
+
+// Available reports whether stdin and stdout are terminals the UI can drive
+func Available() bool {
+	return isTerminal(int(os.Stdin.Fd())) && isTerminal(int(os.Stdout.Fd())) && os.Getenv("TERM") != "dumb"
+}
+
+// Width returns the width of the terminal on stdout, defaulting to 80
+func Width() int {
+	if w := width(int(os.Stdout.Fd())); w > 0 {
+		return w
+	}
+	return 80
+}
+
+// ANSI styles; empty when NO_COLOR is set
+var (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	dim    = "\x1b[2m"
+	red    = "\x1b[31m"
+	green  = "\x1b[32m"
+	yellow = "\x1b[33m"
+	cyan   = "\x1b[36m"
+)
+
+func init() {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		reset, bold, dim, red, green, yellow, cyan = "", "", "", "", "", "", ""
+	}
+}
+
+// clearDown erases from the cursor to the end of the screen
+const clearDown = "\x1b[J"
+
+// cursorUp moves the cursor to the start of the line n lines up
+func cursorUp(n int) string {
+	if n <= 0 {
+		return "\r"
+	}
+	return fmt.Sprintf("\x1b[%dA\r", n)
+}
+
+// truncate shortens s to n columns, counting runes
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// ReadSecret prints prompt and reads a line from the terminal without
+// echoing it, for passwords and API keys
+func ReadSecret(prompt string) (string, error) {
+	fmt.Print(prompt)
+	restore, err := noEcho(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	restore()
+	fmt.Println()
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Option is one entry of a Select list
+type Option struct {
+	Label  string
+	Detail string // Shown dimmed after the label
+}
+
+// key is a decoded keypress
+type key int
+
+const (
+	keyOther key = iota
+	keyUp
+	keyDown
+	keyEnter
+	keyCancel
+)
+
+// readKey reads one keypress, returning digits and letters as rune
+func readKey(in *os.File) (key, rune, error) {
+	buf := make([]byte, 8)
+	n, err := in.Read(buf)
+	if err != nil {
+		return keyOther, 0, err
+	}
+	b := buf[:n]
+	switch {
+	case len(b) >= 3 && b[0] == 0x1b && (b[1] == '[' || b[1] == 'O'):
+		switch b[2] {
+		case 'A':
+			return keyUp, 0, nil
+		case 'B':
+			return keyDown, 0, nil
+		}
+		return keyOther, 0, nil
+	case b[0] == 0x1b, b[0] == 0x03:
+		return keyCancel, 0, nil
+	case b[0] == '\r', b[0] == '\n':
+		return keyEnter, 0, nil
+	case b[0] == 'k', b[0] == 0x10: // k, Ctrl+P
+		return keyUp, 0, nil
+	case b[0] == 'j', b[0] == 0x0e: // j, Ctrl+N
+		return keyDown, 0, nil
+	}
+	return keyOther, rune(b[0]), nil
+}
+
+// Select shows options as a list navigated with the arrow keys (or j/k)
+// and returns the index chosen with Enter; digits pick an option directly.
+// selected is the option highlighted first.
+func Select(title string, options []Option, selected int) (int, error) {
+	if len(options) == 0 {
+		return 0, errors.New("nothing to select")
+	}
+	if selected < 0 || selected >= len(options) {
+		selected = 0
+	}
+
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return 0, err
+	}
+	defer restore()
+
+	out := os.Stdout
+	w := Width()
+	drawn := 0
+	render := func() {
+		var sb strings.Builder
+		sb.WriteString(cursorUp(drawn))
+		sb.WriteString(clearDown)
+		fmt.Fprintf(&sb, "  %s%s%s\r\n", bold, title, reset)
+		for i, opt := range options {
+			label := truncate(fmt.Sprintf("%d. %s", i+1, opt.Label), w-6)
+			detail := ""
+			if opt.Detail != "" {
+				detail = "  " + dim + truncate(opt.Detail, w-9-len([]rune(label))) + reset
+			}
+			if i == selected {
+				fmt.Fprintf(&sb, "  %s› %s%s%s\r\n", cyan, label, reset, detail)
+			} else {
+				fmt.Fprintf(&sb, "    %s%s\r\n", label, detail)
+			}
+		}
+		fmt.Fprintf(&sb, "  %s↑/↓ move · enter select · esc cancel%s", dim, reset)
+		fmt.Fprint(out, sb.String())
+		drawn = len(options) + 1
+	}
+
+	// choose collapses the list to the chosen option
+	choose := func(i int) (int, error) {
+		fmt.Fprintf(out, "%s%s  %s: %s%s%s\r\n", cursorUp(drawn), clearDown, title, cyan, options[i].Label, reset)
+		return i, nil
+	}
+
+	render()
+	for {
+		k, r, err := readKey(os.Stdin)
+		if err != nil {
+			return 0, err
+		}
+		switch k {
+		case keyUp:
+			selected = (selected - 1 + len(options)) % len(options)
+		case keyDown:
+			selected = (selected + 1) % len(options)
+		case keyCancel:
+			fmt.Fprint(out, cursorUp(drawn)+clearDown)
+			return 0, ErrInterrupted
+		case keyEnter:
+			return choose(selected)
+		default:
+			if r >= '1' && r <= '9' && int(r-'1') < len(options) {
+				return choose(int(r - '1'))
+			}
+			continue
+		}
+		render()
+	}
+}
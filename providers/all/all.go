@@ -0,0 +1,44 @@
+// Package all registers the providers of every supported language, so the
+// CLI, the agent tools and the MCP server all detect the same stacks.
+package all
+
+import (
+	"github.com/dublyo/dockerizer/internal/detector"
+	"github.com/dublyo/dockerizer/providers/bun"
+	"github.com/dublyo/dockerizer/providers/deno"
+	"github.com/dublyo/dockerizer/providers/dotnet"
+	"github.com/dublyo/dockerizer/providers/elixir"
+	"github.com/dublyo/dockerizer/providers/golang"
+	"github.com/dublyo/dockerizer/providers/java"
+	"github.com/dublyo/dockerizer/providers/nodejs"
+	"github.com/dublyo/dockerizer/providers/php"
+	"github.com/dublyo/dockerizer/providers/python"
+	"github.com/dublyo/dockerizer/providers/ruby"
+	"github.com/dublyo/dockerizer/providers/rust"
+	"github.com/dublyo/dockerizer/providers/scala"
+)
+
+// RegisterAll registers the providers of every language with the registry.
+// Languages are registered in this order; within a language, each package
+// registers its most specific providers first.
+func RegisterAll(registry *detector.Registry) {
+	nodejs.RegisterAll(registry)
+	python.RegisterAll(registry)
+	golang.RegisterAll(registry)
+	rust.RegisterAll(registry)
+	ruby.RegisterAll(registry)
+	php.RegisterAll(registry)
+	java.RegisterAll(registry)
+	scala.RegisterAll(registry)
+	dotnet.RegisterAll(registry)
+	elixir.RegisterAll(registry)
+	deno.RegisterAll(registry)
+	bun.RegisterAll(registry)
+}
+
+// NewRegistry returns a registry with every provider registered
+func NewRegistry() *detector.Registry {
+	registry := detector.NewRegistry()
+	RegisterAll(registry)
+	return registry
+}
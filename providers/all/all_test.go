@@ -0,0 +1,32 @@
+package all_test
+
+import (
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/generator"
+	"github.com/dublyo/dockerizer/providers/all"
+)
+
+// TestRegisterAllLanguages guards against a language's providers being left
+// out of the shared registry, as .NET and Elixir once were from the CLI
+func TestRegisterAllLanguages(t *testing.T) {
+	registry := all.NewRegistry()
+
+	languages := make(map[string]bool)
+	for _, lang := range registry.Languages() {
+		languages[lang] = true
+	}
+	for _, want := range []string{"nodejs", "python", "go", "rust", "ruby", "php", "java", "scala", "dotnet", "elixir", "deno", "bun"} {
+		if !languages[want] {
+			t.Errorf("no %s providers registered", want)
+		}
+	}
+}
+
+func TestRegisteredProvidersHaveTemplates(t *testing.T) {
+	for _, p := range all.NewRegistry().Providers() {
+		if !generator.HasTemplate(p.Template()) {
+			t.Errorf("provider %s: template %s not found", p.Name(), p.Template())
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package bun provides detection for Bun projects.
+package bun
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// BunProvider detects and generates Dockerfiles for Bun projects
+type BunProvider struct {
+	providers.BaseProvider
+}
+
+// NewBunProvider creates a new Bun provider
+func NewBunProvider() *BunProvider {
+	return &BunProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "bun",
+			ProviderLanguage:    "bun",
+			ProviderFramework:   "bun",
+			ProviderTemplate:    "bun/bun.tmpl",
+			ProviderDescription: "Bun JavaScript/TypeScript runtime",
+			ProviderURL:         "https://bun.sh",
+		},
+	}
+}
+
+// nodeFrameworks have dedicated Node.js providers that already handle Bun lock files
+var nodeFrameworks = []string{
+	"next", "nuxt", "@nestjs/core", "@remix-run/node", "@remix-run/react",
+	"astro", "@sveltejs/kit", "hono", "koa", "fastify", "express",
+}
+
+// Detect checks if the repository is a Bun project
+func (p *BunProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	score := 0
+	vars := make(map[string]interface{})
+
+	// Must have package.json
+	if scan.Metadata.PackageJSON == nil {
+		return 0, nil, nil
+	}
+
+	pkg := scan.Metadata.PackageJSON
+
+	// Leave framework projects to their own providers
+	for _, dep := range nodeFrameworks {
+		if pkg.HasDependency(dep) {
+			return 0, nil, nil
+		}
+	}
+
+	// Check for Bun-specific files
+	if scan.FileTree.HasFile("bunfig.toml") {
+		score += providers.Award(ctx, 40, "bunfig.toml")
+	}
+
+	lockFile := ""
+	for _, f := range []string{"bun.lock", "bun.lockb"} {
+		if scan.FileTree.HasFile(f) {
+			lockFile = f
+			break
+		}
+	}
+	if lockFile != "" && !scan.FileTree.HasFile("package-lock.json") {
+		score += providers.Award(ctx, 40, lockFile+" without package-lock.json")
+		vars["lockFile"] = lockFile
+		vars["hasLockFile"] = true
+	}
+
+	// Check for Bun types or packageManager field
+	if pkg.HasDependency("@types/bun") || pkg.HasDependency("bun-types") {
+		score += providers.Award(ctx, 20, "dependency @types/bun or bun-types")
+	}
+	if strings.HasPrefix(pkg.PackageManager, "bun@") {
+		score += providers.Award(ctx, 20, "packageManager bun")
+	}
+
+	// Check for scripts invoking bun
+	for _, script := range pkg.Scripts {
+		if strings.HasPrefix(script, "bun ") || strings.Contains(script, " bun ") {
+			score += providers.Award(ctx, 10, "script runs bun")
+			break
+		}
+	}
+
+	if score == 0 {
+		return 0, nil, nil
+	}
+
+	// Entry point from package.json main, otherwise common file names
+	entrypoint := ""
+	if pkg.Main != "" {
+		entrypoint = pkg.Main
+	} else {
+		candidates := []string{"index.ts", "src/index.ts", "server.ts", "src/server.ts", "index.js", "src/index.js"}
+		for _, c := range candidates {
+			if scan.FileTree.HasFile(c) {
+				entrypoint = c
+				break
+			}
+		}
+	}
+	if entrypoint != "" {
+		score += providers.Award(ctx, 10, "entrypoint "+entrypoint)
+		vars["entrypoint"] = entrypoint
+	}
+
+	if pkg.HasScript("build") {
+		vars["hasBuildScript"] = true
+	}
+	if pkg.HasScript("start") {
+		vars["hasStartScript"] = true
+	}
+
+	vars["bunVersion"] = p.DetectVersion(scan)
+	vars["port"] = detectPort(scan, "3000")
+
+	// Cap at 100
+	if score > 100 {
+		score = 100
+	}
+
+	return score, vars, nil
+}
+
+// DetectVersion detects the Bun version to use
+func (p *BunProvider) DetectVersion(scan *scanner.ScanResult) string {
+	// Check packageManager field (e.g. "bun@1.1.38")
+	if pkg := scan.Metadata.PackageJSON; pkg != nil && strings.HasPrefix(pkg.PackageManager, "bun@") {
+		return strings.TrimPrefix(pkg.PackageManager, "bun@")
+	}
+
+	// Check .bun-version
+	if scan.FileTree.HasFile(".bun-version") {
+		data, err := scan.ReadFile(".bun-version")
+		if err == nil {
+			if v := strings.TrimPrefix(strings.TrimSpace(string(data)), "v"); v != "" {
+				return v
+			}
+		}
+	}
+
+	// Check .tool-versions (asdf/mise)
+	if scan.FileTree.HasFile(".tool-versions") {
+		data, err := scan.ReadFile(".tool-versions")
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				parts := strings.Fields(line)
+				if len(parts) >= 2 && parts[0] == "bun" {
+					return strings.TrimPrefix(parts[1], "v")
+				}
+			}
+		}
+	}
+
+	// Default to Bun 1
+	return "1"
+}
+
+// detectPort detects the port from .env file
+func detectPort(scan *scanner.ScanResult, defaultPort string) string {
+	if scan.FileTree.HasFile(".env") {
+		data, err := scan.ReadFile(".env")
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if strings.HasPrefix(line, "PORT=") {
+					return strings.TrimSpace(strings.TrimPrefix(line, "PORT="))
+				}
+			}
+		}
+	}
+	return defaultPort
+}
@@ -0,0 +1,10 @@
+package bun
+
+import (
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+// RegisterAll registers all Bun providers with the registry
+func RegisterAll(registry *detector.Registry) {
+	registry.Register(NewBunProvider())
+}
@@ -0,0 +1,209 @@
+// Package deno provides detection for Deno projects.
+package deno
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// DenoProvider detects and generates Dockerfiles for Deno projects
+type DenoProvider struct {
+	providers.BaseProvider
+}
+
+// NewDenoProvider creates a new Deno provider
+func NewDenoProvider() *DenoProvider {
+	return &DenoProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "deno",
+			ProviderLanguage:    "deno",
+			ProviderFramework:   "deno",
+			ProviderTemplate:    "deno/deno.tmpl",
+			ProviderDescription: "Deno JavaScript/TypeScript runtime",
+			ProviderURL:         "https://deno.com",
+		},
+	}
+}
+
+// denoConfig is the subset of deno.json(c) used for detection
+type denoConfig struct {
+	Tasks   map[string]string `json:"tasks"`
+	Imports map[string]string `json:"imports"`
+}
+
+// Detect checks if the repository is a Deno project
+func (p *DenoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	score := 0
+	vars := make(map[string]interface{})
+
+	// Check for Deno config file
+	configFile := ""
+	for _, f := range []string{"deno.json", "deno.jsonc"} {
+		if scan.FileTree.HasFile(f) {
+			configFile = f
+			break
+		}
+	}
+
+	hasLock := scan.FileTree.HasFile("deno.lock")
+	if configFile == "" && !hasLock {
+		return 0, nil, nil
+	}
+
+	if configFile != "" {
+		score += providers.Award(ctx, 50, configFile)
+		vars["configFile"] = configFile
+	}
+	if hasLock {
+		score += providers.Award(ctx, 20, "deno.lock")
+		vars["hasLockFile"] = true
+	}
+
+	// Parse tasks and imports from the config
+	var cfg denoConfig
+	if configFile != "" {
+		data, err := scan.ReadFile(configFile)
+		if err == nil {
+			_ = json.Unmarshal(stripJSONComments(data), &cfg)
+		}
+	}
+
+	// Fresh framework
+	for spec, target := range cfg.Imports {
+		if strings.HasPrefix(spec, "$fresh/") || strings.Contains(target, "@fresh/core") || strings.Contains(target, "deno.land/x/fresh") {
+			vars["fresh"] = true
+			score += providers.Award(ctx, 10, "Fresh import")
+			break
+		}
+	}
+
+	// Entry point and permissions from the start task, if any
+	entrypoint, permissions := parseRunTask(cfg.Tasks["start"])
+	if entrypoint == "" {
+		candidates := []string{"main.ts", "mod.ts", "server.ts", "app.ts", "src/main.ts", "src/server.ts", "main.js", "server.js"}
+		for _, c := range candidates {
+			if scan.FileTree.HasFile(c) {
+				entrypoint = c
+				break
+			}
+		}
+	}
+	if entrypoint != "" {
+		score += providers.Award(ctx, 20, "entrypoint "+entrypoint)
+		vars["entrypoint"] = entrypoint
+	}
+	if len(permissions) == 0 {
+		permissions = []string{"--allow-net", "--allow-env", "--allow-read"}
+	}
+	vars["permissions"] = permissions
+
+	if _, ok := cfg.Tasks["build"]; ok {
+		vars["buildTask"] = true
+	}
+
+	// Prefer a single compiled binary when the project already compiles itself
+	for name, task := range cfg.Tasks {
+		if name == "compile" || strings.Contains(task, "deno compile") {
+			vars["compile"] = true
+			// The distroless runtime has nothing to run a healthcheck with
+			vars["noShell"] = true
+			break
+		}
+	}
+
+	// A bare package.json project is more likely Node with a stray lock file
+	if scan.Metadata.PackageJSON == nil {
+		score += providers.Award(ctx, 10, "no package.json")
+	}
+
+	vars["denoVersion"] = p.DetectVersion(scan)
+	vars["port"] = detectPort(scan, "8000")
+
+	// Cap at 100
+	if score > 100 {
+		score = 100
+	}
+
+	return score, vars, nil
+}
+
+// DetectVersion detects the Deno version to use
+func (p *DenoProvider) DetectVersion(scan *scanner.ScanResult) string {
+	// Check .dvmrc (Deno Version Manager)
+	if scan.FileTree.HasFile(".dvmrc") {
+		data, err := scan.ReadFile(".dvmrc")
+		if err == nil {
+			if v := strings.TrimPrefix(strings.TrimSpace(string(data)), "v"); v != "" {
+				return v
+			}
+		}
+	}
+
+	// Check .tool-versions (asdf/mise)
+	if scan.FileTree.HasFile(".tool-versions") {
+		data, err := scan.ReadFile(".tool-versions")
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				parts := strings.Fields(line)
+				if len(parts) >= 2 && parts[0] == "deno" {
+					return strings.TrimPrefix(parts[1], "v")
+				}
+			}
+		}
+	}
+
+	// Default to Deno 2
+	return "2.1.4"
+}
+
+// parseRunTask extracts the entry point and permission flags from a "deno run" task
+func parseRunTask(task string) (string, []string) {
+	if !strings.Contains(task, "deno run") && !strings.Contains(task, "deno serve") {
+		return "", nil
+	}
+
+	var entrypoint string
+	var permissions []string
+	for _, field := range strings.Fields(task) {
+		switch {
+		case field == "-A" || strings.HasPrefix(field, "--allow-") || strings.HasPrefix(field, "--unstable"):
+			permissions = append(permissions, field)
+		case strings.HasSuffix(field, ".ts") || strings.HasSuffix(field, ".tsx") || strings.HasSuffix(field, ".js"):
+			if entrypoint == "" {
+				entrypoint = field
+			}
+		}
+	}
+	return entrypoint, permissions
+}
+
+// stripJSONComments removes // line comments so deno.jsonc can be parsed
+func stripJSONComments(data []byte) []byte {
+	var out []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// detectPort detects the port from .env file
+func detectPort(scan *scanner.ScanResult, defaultPort string) string {
+	if scan.FileTree.HasFile(".env") {
+		data, err := scan.ReadFile(".env")
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if strings.HasPrefix(line, "PORT=") {
+					return strings.TrimSpace(strings.TrimPrefix(line, "PORT="))
+				}
+			}
+		}
+	}
+	return defaultPort
+}
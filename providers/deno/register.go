@@ -0,0 +1,10 @@
+package deno
+
+import (
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+// RegisterAll registers all Deno providers with the registry
+func RegisterAll(registry *detector.Registry) {
+	registry.Register(NewDenoProvider())
+}
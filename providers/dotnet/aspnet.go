@@ -35,9 +35,11 @@ type CsprojFile struct {
 	XMLName       xml.Name `xml:"Project"`
 	Sdk           string   `xml:"Sdk,attr"`
 	PropertyGroup struct {
-		TargetFramework string `xml:"TargetFramework"`
-		Nullable        string `xml:"Nullable"`
-		ImplicitUsings  string `xml:"ImplicitUsings"`
+		TargetFramework    string `xml:"TargetFramework"`
+		RuntimeIdentifier  string `xml:"RuntimeIdentifier"`
+		RuntimeIdentifiers string `xml:"RuntimeIdentifiers"`
+		Nullable           string `xml:"Nullable"`
+		ImplicitUsings     string `xml:"ImplicitUsings"`
 	} `xml:"PropertyGroup"`
 	ItemGroup struct {
 		PackageReferences []struct {
@@ -69,7 +71,7 @@ func (p *AspNetProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 
 		// Check for Web SDK
 		if strings.Contains(content, "Microsoft.NET.Sdk.Web") {
-			score += 50
+			score += providers.Award(ctx, 50, csprojFile+" uses Microsoft.NET.Sdk.Web")
 			vars["isWebProject"] = true
 		}
 
@@ -81,16 +83,24 @@ func (p *AspNetProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 				vars["targetFramework"] = csproj.PropertyGroup.TargetFramework
 				vars["dotnetVersion"] = extractDotnetVersion(csproj.PropertyGroup.TargetFramework)
 			}
+			if base := windowsBase(csproj.PropertyGroup.TargetFramework, csproj.PropertyGroup.RuntimeIdentifier, csproj.PropertyGroup.RuntimeIdentifiers); base != "" {
+				vars["windows"] = base
+			}
 		}
 
 		// Check for ASP.NET Core packages
 		if strings.Contains(content, "Microsoft.AspNetCore") {
-			score += 20
+			score += providers.Award(ctx, 20, csprojFile+" references Microsoft.AspNetCore")
 		}
 
 		// Check for Entity Framework
 		if strings.Contains(content, "Microsoft.EntityFrameworkCore") {
 			vars["hasEF"] = true
+			// Migration bundles need the design-time package in the startup project
+			if strings.Contains(content, "Microsoft.EntityFrameworkCore.Design") {
+				vars["efBundle"] = true
+				vars["entrypointMigrate"] = "./efbundle"
+			}
 		}
 
 		// Store project file name and extract project name (without .csproj extension)
@@ -106,23 +116,23 @@ func (p *AspNetProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 
 	// Check for Program.cs (entry point)
 	if scan.FileTree.HasFile("Program.cs") {
-		score += 10
+		score += providers.Award(ctx, 10, "Program.cs")
 	}
 
 	// Check for appsettings.json
 	if scan.FileTree.HasFile("appsettings.json") {
-		score += 10
+		score += providers.Award(ctx, 10, "appsettings.json")
 	}
 
 	// Check for Controllers directory (MVC pattern)
 	if scan.FileTree.HasDir("Controllers") {
-		score += 5
+		score += providers.Award(ctx, 5, "Controllers/ directory")
 		vars["hasMVC"] = true
 	}
 
 	// Check for Pages directory (Razor Pages)
 	if scan.FileTree.HasDir("Pages") {
-		score += 5
+		score += providers.Award(ctx, 5, "Pages/ directory")
 		vars["hasRazorPages"] = true
 	}
 
@@ -208,9 +218,39 @@ func (p *AspNetProvider) DetectVersion(scan *scanner.ScanResult) string {
 }
 
 // extractDotnetVersion extracts version number from target framework
+// windowsBase picks a Windows container base for projects that target
+// Windows: servercore for Windows-only frameworks (net8.0-windows), which
+// need the full Windows API, and nanoserver for a win-* runtime identifier
+func windowsBase(targetFramework, runtimeIdentifier, runtimeIdentifiers string) string {
+	if strings.Contains(targetFramework, "-windows") {
+		return "servercore"
+	}
+	rids := strings.Split(runtimeIdentifiers, ";")
+	if runtimeIdentifier != "" {
+		// A single runtime identifier decides; a list only when all are Windows
+		rids = []string{runtimeIdentifier}
+	}
+	found := false
+	for _, rid := range rids {
+		rid = strings.TrimSpace(rid)
+		if rid == "" {
+			continue
+		}
+		if !strings.HasPrefix(rid, "win") {
+			return ""
+		}
+		found = true
+	}
+	if found {
+		return "nanoserver"
+	}
+	return ""
+}
+
 func extractDotnetVersion(targetFramework string) string {
-	// Handle formats like "net8.0", "net7.0", "netcoreapp3.1"
+	// Handle formats like "net8.0", "net7.0", "netcoreapp3.1", "net8.0-windows"
 	targetFramework = strings.ToLower(targetFramework)
+	targetFramework, _, _ = strings.Cut(targetFramework, "-")
 
 	if strings.HasPrefix(targetFramework, "net") {
 		version := strings.TrimPrefix(targetFramework, "net")
@@ -48,7 +48,7 @@ func (p *PhoenixProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	// Check for phoenix dependency
 	if strings.Contains(content, ":phoenix") {
-		score += 50
+		score += providers.Award(ctx, 50, "mix.exs dependency :phoenix")
 	} else {
 		return 0, nil, nil // Not Phoenix
 	}
@@ -61,13 +61,13 @@ func (p *PhoenixProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	// Check for phoenix_html
 	if strings.Contains(content, ":phoenix_html") {
-		score += 10
+		score += providers.Award(ctx, 10, "mix.exs dependency :phoenix_html")
 		vars["hasPhoenixHTML"] = true
 	}
 
 	// Check for phoenix_live_view
 	if strings.Contains(content, ":phoenix_live_view") {
-		score += 10
+		score += providers.Award(ctx, 10, "mix.exs dependency :phoenix_live_view")
 		vars["hasLiveView"] = true
 	}
 
@@ -84,23 +84,23 @@ func (p *PhoenixProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	// Check for config directory
 	if scan.FileTree.HasDir("config") {
-		score += 5
+		score += providers.Award(ctx, 5, "config/ directory")
 	}
 
 	// Check for lib directory
 	if scan.FileTree.HasDir("lib") {
-		score += 5
+		score += providers.Award(ctx, 5, "lib/ directory")
 	}
 
 	// Check for assets directory (Phoenix assets pipeline)
 	if scan.FileTree.HasDir("assets") {
-		score += 5
+		score += providers.Award(ctx, 5, "assets/ directory")
 		vars["hasAssets"] = true
 	}
 
 	// Check for priv/static
 	if scan.FileTree.HasDir("priv/static") {
-		score += 5
+		score += providers.Award(ctx, 5, "priv/static/ directory")
 	}
 
 	// Check for .tool-versions (asdf)
@@ -0,0 +1,70 @@
+package golang
+
+import (
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// cgoModules are modules that only build with cgo enabled
+var cgoModules = []string{
+	"github.com/mattn/go-sqlite3",
+	"github.com/confluentinc/confluent-kafka-go",
+	"gopkg.in/confluentinc/confluent-kafka-go",
+	"github.com/mattn/go-oci8",
+	"github.com/godror/godror",
+	"github.com/linxGnu/grocksdb",
+	"github.com/tecbot/gorocksdb",
+	"github.com/h2non/bimg",
+	"github.com/davidbyttow/govips",
+	"github.com/DataDog/zstd",
+}
+
+// detectGoBuild detects cgo and time zone usage. The final stage is alpine;
+// --go-base-image selects distroless or scratch when generating.
+func detectGoBuild(scan *scanner.ScanResult, vars map[string]interface{}) {
+	for _, req := range scan.Metadata.GoMod.Require {
+		for _, mod := range cgoModules {
+			if strings.HasPrefix(req, mod) {
+				vars["cgo"] = true
+				vars["cgoReason"] = mod
+				// confluent-kafka-go bundles librdkafka per libc
+				if strings.Contains(mod, "confluent-kafka-go") {
+					vars["kafkaMusl"] = true
+				}
+				break
+			}
+		}
+		if vars["cgo"] == true {
+			break
+		}
+	}
+
+	embedsTzdata := false
+	usesTimeZones := false
+	for _, gf := range scan.FileTree.FilesWithExtension(".go") {
+		if strings.HasPrefix(gf, "vendor/") || strings.HasSuffix(gf, "_test.go") {
+			continue
+		}
+		data, err := scan.ReadFile(gf)
+		if err != nil {
+			continue
+		}
+		content := string(data)
+		if vars["cgo"] != true && strings.Contains(content, `import "C"`) {
+			vars["cgo"] = true
+			vars["cgoReason"] = `import "C" in ` + gf
+		}
+		if strings.Contains(content, `"time/tzdata"`) {
+			embedsTzdata = true
+		}
+		if strings.Contains(content, "time.LoadLocation(") {
+			usesTimeZones = true
+		}
+	}
+	if usesTimeZones && !embedsTzdata {
+		vars["needsTzdata"] = true
+	}
+
+	vars["goBaseImage"] = "alpine"
+}
@@ -40,7 +40,7 @@ func (p *EchoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 	// Check for labstack/echo in go.mod
 	for _, req := range scan.Metadata.GoMod.Require {
 		if strings.Contains(req, "github.com/labstack/echo") {
-			score += 60
+			score += providers.Award(ctx, 60, "go.mod requires github.com/labstack/echo")
 			break
 		}
 	}
@@ -52,7 +52,7 @@ func (p *EchoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 		if err == nil {
 			content := string(data)
 			if strings.Contains(content, `"github.com/labstack/echo`) {
-				score += 20
+				score += providers.Award(ctx, 20, gf+" imports echo")
 				break
 			}
 		}
@@ -60,7 +60,7 @@ func (p *EchoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 
 	// Check for main.go
 	if scan.FileTree.HasFile("main.go") {
-		score += 10
+		score += providers.Award(ctx, 10, "main.go")
 	}
 
 	if score == 0 {
@@ -71,6 +71,7 @@ func (p *EchoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 	vars["moduleName"] = scan.Metadata.GoMod.Module
 	vars["port"] = detectGoPort(scan)
 	vars["mainPath"] = detectMainPath(scan)
+	detectGoBuild(scan, vars)
 
 	if score > 100 {
 		score = 100
@@ -40,7 +40,7 @@ func (p *FiberProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	// Check for gofiber/fiber in go.mod
 	for _, req := range scan.Metadata.GoMod.Require {
 		if strings.Contains(req, "github.com/gofiber/fiber") {
-			score += 60
+			score += providers.Award(ctx, 60, "go.mod requires github.com/gofiber/fiber")
 			break
 		}
 	}
@@ -52,7 +52,7 @@ func (p *FiberProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 		if err == nil {
 			content := string(data)
 			if strings.Contains(content, `"github.com/gofiber/fiber`) {
-				score += 20
+				score += providers.Award(ctx, 20, gf+" imports fiber")
 				break
 			}
 		}
@@ -60,7 +60,7 @@ func (p *FiberProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 
 	// Check for main.go
 	if scan.FileTree.HasFile("main.go") {
-		score += 10
+		score += providers.Award(ctx, 10, "main.go")
 	}
 
 	if score == 0 {
@@ -71,6 +71,7 @@ func (p *FiberProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	vars["moduleName"] = scan.Metadata.GoMod.Module
 	vars["port"] = detectGoPort(scan)
 	vars["mainPath"] = detectMainPath(scan)
+	detectGoBuild(scan, vars)
 
 	if score > 100 {
 		score = 100
@@ -0,0 +1,78 @@
+package golang
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// GenericProvider is the fallback for Go modules no other provider matched,
+// such as workers and CLIs: it builds the main package and runs the binary
+type GenericProvider struct {
+	providers.BaseProvider
+}
+
+// NewGenericProvider creates the generic Go provider
+func NewGenericProvider() *GenericProvider {
+	return &GenericProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "go",
+			ProviderLanguage:    "go",
+			ProviderFramework:   "generic",
+			ProviderTemplate:    "go/generic.tmpl",
+			ProviderDescription: "Go program (no framework detected)",
+			ProviderURL:         "https://go.dev",
+		},
+	}
+}
+
+// Detect matches a Go module with a main package
+func (p *GenericProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	if scan.Metadata.GoMod == nil {
+		return 0, nil, nil
+	}
+	score := providers.Award(ctx, 25, "go.mod")
+
+	mainPath := findMainPackage(scan)
+	if mainPath == "" {
+		// A library: nothing to run
+		return 0, nil, nil
+	}
+	score += providers.Award(ctx, 15, "main package "+mainPath)
+
+	vars := map[string]interface{}{
+		"goVersion":  p.DetectVersion(scan),
+		"moduleName": scan.Metadata.GoMod.Module,
+		"mainPath":   mainPath,
+	}
+	detectGoBuild(scan, vars)
+
+	return min(score, providers.GenericConfidence), vars, nil
+}
+
+// findMainPackage returns the directory of the package to build: the module
+// root, the usual cmd/ layouts, or the only cmd/<name> with a main.go
+func findMainPackage(scan *scanner.ScanResult) string {
+	if path := detectMainPath(scan); path != "." || scan.FileTree.HasFile("main.go") {
+		return path
+	}
+	var mains []string
+	for _, f := range scan.FileTree.FilesMatching("main.go") {
+		dir := filepath.ToSlash(filepath.Dir(f))
+		if strings.HasPrefix(dir, "cmd/") && strings.Count(dir, "/") == 1 {
+			mains = append(mains, "./"+dir)
+		}
+	}
+	if len(mains) == 1 {
+		return mains[0]
+	}
+	return ""
+}
+
+// DetectVersion detects the Go version
+func (p *GenericProvider) DetectVersion(scan *scanner.ScanResult) string {
+	return detectGoVersion(scan)
+}
@@ -42,7 +42,7 @@ func (p *GinProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int
 	// Check for gin-gonic/gin in go.mod
 	for _, req := range scan.Metadata.GoMod.Require {
 		if strings.Contains(req, "github.com/gin-gonic/gin") {
-			score += 60
+			score += providers.Award(ctx, 60, "go.mod requires github.com/gin-gonic/gin")
 			break
 		}
 	}
@@ -54,7 +54,7 @@ func (p *GinProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int
 		if err == nil {
 			content := string(data)
 			if strings.Contains(content, `"github.com/gin-gonic/gin"`) {
-				score += 20
+				score += providers.Award(ctx, 20, gf+" imports gin")
 				break
 			}
 		}
@@ -62,7 +62,7 @@ func (p *GinProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int
 
 	// Check for main.go
 	if scan.FileTree.HasFile("main.go") || scan.FileTree.HasFile("cmd/main.go") || scan.FileTree.HasFile("cmd/server/main.go") {
-		score += 10
+		score += providers.Award(ctx, 10, "main.go or cmd/main.go or cmd/server/main.go")
 	}
 
 	if score == 0 {
@@ -74,6 +74,7 @@ func (p *GinProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int
 	vars["moduleName"] = scan.Metadata.GoMod.Module
 	vars["port"] = detectGoPort(scan)
 	vars["mainPath"] = detectMainPath(scan)
+	detectGoBuild(scan, vars)
 
 	if score > 100 {
 		score = 100
@@ -11,4 +11,5 @@ func RegisterAll(registry *detector.Registry) {
 	registry.Register(NewEchoProvider())
 	registry.Register(NewFiberProvider())
 	registry.Register(NewStandardProvider()) // Fallback for any Go HTTP app
+	registry.Register(NewGenericProvider())  // Fallback for any Go program
 }
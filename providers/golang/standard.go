@@ -37,7 +37,7 @@ func (p *StandardProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		return 0, nil, nil
 	}
 
-	score += 30 // Has go.mod
+	score += providers.Award(ctx, 30, "go.mod")
 
 	// Check for net/http import in .go files (standard library)
 	goFiles := scan.FileTree.FilesWithExtension(".go")
@@ -46,7 +46,7 @@ func (p *StandardProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		if err == nil {
 			content := string(data)
 			if strings.Contains(content, `"net/http"`) {
-				score += 30
+				score += providers.Award(ctx, 30, gf+" imports net/http")
 				break
 			}
 		}
@@ -54,12 +54,12 @@ func (p *StandardProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	// Check for main.go
 	if scan.FileTree.HasFile("main.go") {
-		score += 20
+		score += providers.Award(ctx, 20, "main.go")
 	}
 
 	// Check for cmd directory structure
 	if scan.FileTree.HasDir("cmd") {
-		score += 10
+		score += providers.Award(ctx, 10, "cmd/ directory")
 	}
 
 	if score < 50 { // Need at least go.mod and main.go or net/http
@@ -70,6 +70,7 @@ func (p *StandardProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	vars["moduleName"] = scan.Metadata.GoMod.Module
 	vars["port"] = detectGoPort(scan)
 	vars["mainPath"] = detectMainPath(scan)
+	detectGoBuild(scan, vars)
 
 	if score > 100 {
 		score = 100
@@ -0,0 +1,69 @@
+package java
+
+import (
+	"context"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// GenericProvider is the fallback for Maven and Gradle projects no framework
+// provider matched: it packages the project and runs the jar with java -jar
+type GenericProvider struct {
+	providers.BaseProvider
+}
+
+// NewGenericProvider creates the generic Java provider
+func NewGenericProvider() *GenericProvider {
+	return &GenericProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "java",
+			ProviderLanguage:    "java",
+			ProviderFramework:   "generic",
+			ProviderTemplate:    "java/java.tmpl",
+			ProviderDescription: "Java application (no framework detected)",
+			ProviderURL:         "https://adoptium.net",
+		},
+	}
+}
+
+// Detect matches any Maven or Gradle project
+func (p *GenericProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	score := 0
+	vars := make(map[string]interface{})
+
+	switch {
+	case scan.FileTree.HasFile("pom.xml"):
+		vars["buildTool"] = "maven"
+		score += providers.Award(ctx, 25, "pom.xml")
+		if pom := scan.Metadata.PomXML; pom != nil && pom.JavaVersion != "" {
+			vars["javaVersion"] = pom.JavaVersion
+		}
+		vars["hasWrapper"] = scan.FileTree.HasFile("mvnw")
+	case scan.FileTree.HasFile("build.gradle"), scan.FileTree.HasFile("build.gradle.kts"):
+		vars["buildTool"] = "gradle"
+		score += providers.Award(ctx, 25, "Gradle build file")
+		vars["hasWrapper"] = scan.FileTree.HasFile("gradlew")
+		if v := gradleJavaVersion(scan); v != "" {
+			vars["javaVersion"] = v
+		}
+	default:
+		return 0, nil, nil
+	}
+
+	if scan.FileTree.HasDir("src/main/java") || scan.FileTree.HasDir("src/main/kotlin") {
+		score += providers.Award(ctx, 15, "src/main sources")
+	}
+
+	if _, ok := vars["javaVersion"]; !ok {
+		vars["javaVersion"] = p.DetectVersion(scan)
+	}
+	vars["port"] = "8080"
+
+	return min(score, providers.GenericConfidence), vars, nil
+}
+
+// DetectVersion detects the Java version
+func (p *GenericProvider) DetectVersion(scan *scanner.ScanResult) string {
+	return detectJavaVersionFromFiles(scan)
+}
@@ -0,0 +1,116 @@
+package java
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// KtorProvider detects and generates Dockerfiles for Ktor (Kotlin) projects
+type KtorProvider struct {
+	providers.BaseProvider
+}
+
+// NewKtorProvider creates a new Ktor provider
+func NewKtorProvider() *KtorProvider {
+	return &KtorProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "ktor",
+			ProviderLanguage:    "java",
+			ProviderFramework:   "ktor",
+			ProviderTemplate:    "java/ktor.tmpl",
+			ProviderDescription: "Ktor Kotlin server framework",
+			ProviderURL:         "https://ktor.io",
+		},
+	}
+}
+
+// Detect checks if the repository is a Ktor server built with Gradle
+func (p *KtorProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	buildFile := "build.gradle.kts"
+	if !scan.FileTree.HasFile(buildFile) {
+		buildFile = "build.gradle"
+	}
+	data, err := scan.ReadFile(buildFile)
+	if err != nil {
+		return 0, nil, nil
+	}
+	content := string(data)
+	// Versions and libraries may live in the version catalog
+	if catalog, err := scan.ReadFile("gradle/libs.versions.toml"); err == nil {
+		content += "\n" + string(catalog)
+	}
+
+	if !strings.Contains(content, "io.ktor") && !strings.Contains(content, "libs.plugins.ktor") {
+		return 0, nil, nil
+	}
+
+	score := 0
+	vars := map[string]interface{}{"buildTool": "gradle"}
+	if strings.Contains(content, "ktor-server") || strings.Contains(content, "libs.ktor.server") {
+		score += providers.Award(ctx, 60, "Gradle build depends on ktor-server")
+	} else {
+		score += providers.Award(ctx, 40, "Gradle build references io.ktor")
+	}
+
+	// The Ktor Gradle plugin applies the application plugin
+	if strings.Contains(content, `"io.ktor.plugin"`) || strings.Contains(content, "libs.plugins.ktor") {
+		score += providers.Award(ctx, 15, "Ktor Gradle plugin")
+	}
+	if strings.Contains(content, "mainClass") {
+		score += providers.Award(ctx, 10, "Gradle application main class")
+	}
+	if scan.FileTree.HasDir("src/main/kotlin") {
+		score += providers.Award(ctx, 10, "src/main/kotlin")
+	}
+
+	vars["hasWrapper"] = scan.FileTree.HasFile("gradlew")
+	if v := gradleJavaVersion(scan); v != "" {
+		vars["javaVersion"] = v
+	} else {
+		vars["javaVersion"] = detectJavaVersionFromFiles(scan)
+	}
+	vars["port"] = detectKtorPort(scan)
+
+	return min(score, 100), vars, nil
+}
+
+var (
+	// ktorConfigPort matches ktor.deployment.port in application.conf
+	// (port = 8080) or application.yaml (port: 8080)
+	ktorConfigPort = regexp.MustCompile(`(?m)^\s*port\s*[=:]\s*"?(\d+)"?\s*$`)
+	// ktorServerPort matches embeddedServer(Netty, port = 8080)
+	ktorServerPort = regexp.MustCompile(`embeddedServer\([^)]*\bport\s*=\s*(\d+)`)
+)
+
+// detectKtorPort reads the port from the application config, or the
+// embeddedServer call of the main file; Ktor's default is 8080
+func detectKtorPort(scan *scanner.ScanResult) string {
+	for _, cfg := range []string{
+		"src/main/resources/application.conf",
+		"src/main/resources/application.yaml",
+		"src/main/resources/application.yml",
+	} {
+		if data, err := scan.ReadFile(cfg); err == nil {
+			if m := ktorConfigPort.FindStringSubmatch(string(data)); m != nil {
+				return m[1]
+			}
+		}
+	}
+	for _, file := range scan.FileTree.FilesMatching("Application.kt") {
+		if data, err := scan.ReadFile(file); err == nil {
+			if m := ktorServerPort.FindStringSubmatch(string(data)); m != nil {
+				return m[1]
+			}
+		}
+	}
+	return "8080"
+}
+
+// DetectVersion detects the Java version
+func (p *KtorProvider) DetectVersion(scan *scanner.ScanResult) string {
+	return detectJavaVersionFromFiles(scan)
+}
@@ -0,0 +1,225 @@
+package java
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// MicronautProvider detects and generates Dockerfiles for Micronaut projects
+type MicronautProvider struct {
+	providers.BaseProvider
+}
+
+// NewMicronautProvider creates a new Micronaut provider
+func NewMicronautProvider() *MicronautProvider {
+	return &MicronautProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "micronaut",
+			ProviderLanguage:    "java",
+			ProviderFramework:   "micronaut",
+			ProviderTemplate:    "java/micronaut.tmpl",
+			ProviderDescription: "Micronaut JVM framework",
+			ProviderURL:         "https://micronaut.io",
+		},
+	}
+}
+
+// Detect checks if the repository is a Micronaut project
+func (p *MicronautProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	score := 0
+	vars := make(map[string]interface{})
+
+	// Check for Maven (pom.xml) or Gradle (build.gradle)
+	hasMaven := scan.FileTree.HasFile("pom.xml")
+	hasGradle := scan.FileTree.HasFile("build.gradle") || scan.FileTree.HasFile("build.gradle.kts")
+
+	if !hasMaven && !hasGradle {
+		return 0, nil, nil
+	}
+
+	if hasMaven {
+		vars["buildTool"] = "maven"
+		score += p.detectMaven(ctx, scan, vars)
+	} else if hasGradle {
+		vars["buildTool"] = "gradle"
+		score += p.detectGradle(ctx, scan, vars)
+	}
+
+	if score == 0 {
+		return 0, nil, nil
+	}
+
+	// micronaut-cli.yml is created by Micronaut Launch and the mn CLI
+	if scan.FileTree.HasFile("micronaut-cli.yml") {
+		score += providers.Award(ctx, 15, "micronaut-cli.yml")
+	}
+
+	// Check for Micronaut configuration
+	for _, cfg := range []string{
+		"src/main/resources/application.yml",
+		"src/main/resources/application.yaml",
+		"src/main/resources/application.properties",
+	} {
+		if !scan.FileTree.HasFile(cfg) {
+			continue
+		}
+		data, err := scan.ReadFile(cfg)
+		if err == nil && strings.Contains(string(data), "micronaut") {
+			score += providers.Award(ctx, 10, cfg+" configures micronaut")
+			if port := detectMicronautPort(string(data)); port != "" {
+				vars["port"] = port
+			}
+		}
+		break
+	}
+
+	// Check for mvnw or gradlew wrapper
+	if scan.FileTree.HasFile("mvnw") {
+		vars["hasWrapper"] = true
+	} else if scan.FileTree.HasFile("gradlew") {
+		vars["hasWrapper"] = true
+	}
+
+	// Detect Java version (if not already detected from build files)
+	if _, ok := vars["javaVersion"]; !ok {
+		vars["javaVersion"] = detectJavaVersionFromFiles(scan)
+	}
+
+	// Default port for Micronaut
+	if _, ok := vars["port"]; !ok {
+		vars["port"] = "8080"
+	}
+
+	// Cap at 100
+	if score > 100 {
+		score = 100
+	}
+
+	return score, vars, nil
+}
+
+// detectMaven checks pom.xml for Micronaut
+func (p *MicronautProvider) detectMaven(ctx context.Context, scan *scanner.ScanResult, vars map[string]interface{}) int {
+	pom := scan.Metadata.PomXML
+	if pom == nil {
+		return 0
+	}
+
+	score := 0
+
+	// Check for Micronaut parent, BOM, dependencies or plugin
+	if pom.UsesGroup("io.micronaut") {
+		score += providers.Award(ctx, 50, "pom.xml references io.micronaut")
+	}
+
+	// Check for micronaut-maven-plugin
+	if pom.UsesArtifact("micronaut-maven-plugin") {
+		score += providers.Award(ctx, 15, "pom.xml uses micronaut-maven-plugin")
+	}
+
+	// GraalVM native image packaging
+	if pom.Packaging == "native-image" {
+		vars["native"] = true
+	}
+
+	if pom.Parent.ArtifactID == "micronaut-parent" {
+		score += providers.Award(ctx, 10, "pom.xml parent micronaut-parent")
+	}
+
+	if pom.JavaVersion != "" {
+		vars["javaVersion"] = pom.JavaVersion
+	}
+
+	for _, dep := range pom.Dependencies {
+		if strings.HasPrefix(dep.GroupID, "io.micronaut") {
+			score += providers.Award(ctx, 5, "dependency "+dep.GroupID+":"+dep.ArtifactID)
+			if dep.ArtifactID == "micronaut-management" {
+				vars["hasManagement"] = true
+			}
+		}
+	}
+
+	// Extract Micronaut version
+	if v := pom.Properties["micronaut.version"]; v != "" {
+		vars["micronautVersion"] = v
+	}
+
+	return score
+}
+
+// detectGradle parses build.gradle for Micronaut
+func (p *MicronautProvider) detectGradle(ctx context.Context, scan *scanner.ScanResult, vars map[string]interface{}) int {
+	var content string
+
+	if scan.FileTree.HasFile("build.gradle.kts") {
+		data, err := scan.ReadFile("build.gradle.kts")
+		if err != nil {
+			return 0
+		}
+		content = string(data)
+	} else {
+		data, err := scan.ReadFile("build.gradle")
+		if err != nil {
+			return 0
+		}
+		content = string(data)
+	}
+
+	score := 0
+
+	// Check for Micronaut application plugin
+	if strings.Contains(content, "io.micronaut.application") || strings.Contains(content, "io.micronaut.minimal.application") {
+		score += providers.Award(ctx, 60, "Micronaut application Gradle plugin")
+	} else if strings.Contains(content, "io.micronaut") {
+		score += providers.Award(ctx, 40, "Gradle build references io.micronaut")
+	}
+
+	// Shadow plugin produces the runnable *-all.jar
+	if strings.Contains(content, "com.github.johnrengelman.shadow") || strings.Contains(content, "com.gradleup.shadow") {
+		vars["hasShadow"] = true
+		score += providers.Award(ctx, 5, "Gradle shadow plugin")
+	}
+
+	if strings.Contains(content, "micronaut-management") {
+		vars["hasManagement"] = true
+	}
+
+	// Extract Micronaut version
+	versionRe := regexp.MustCompile(`micronautVersion\s*=\s*["']?([0-9][^"'\s]*)`)
+	if matches := versionRe.FindStringSubmatch(content); len(matches) > 1 {
+		vars["micronautVersion"] = matches[1]
+	}
+
+	// Java version from the toolchain or sourceCompatibility
+	if v := gradleJavaVersion(scan); v != "" {
+		vars["javaVersion"] = v
+	}
+
+	return score
+}
+
+// detectMicronautPort extracts micronaut.server.port from application config
+func detectMicronautPort(content string) string {
+	// application.properties
+	propsRe := regexp.MustCompile(`micronaut\.server\.port\s*[=:]\s*(\d+)`)
+	if matches := propsRe.FindStringSubmatch(content); len(matches) > 1 {
+		return matches[1]
+	}
+
+	// application.yml (micronaut: server: port: 8081)
+	yamlRe := regexp.MustCompile(`(?s)server:\s*\n\s+port:\s*(\d+)`)
+	if matches := yamlRe.FindStringSubmatch(content); len(matches) > 1 {
+		return matches[1]
+	}
+
+	return ""
+}
+
+// DetectVersion detects the Java version
+func (p *MicronautProvider) DetectVersion(scan *scanner.ScanResult) string {
+	return detectJavaVersionFromFiles(scan)
+}
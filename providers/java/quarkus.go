@@ -2,8 +2,6 @@ package java
 
 import (
 	"context"
-	"encoding/xml"
-	"regexp"
 	"strings"
 
 	"github.com/dublyo/dockerizer/internal/scanner"
@@ -44,10 +42,10 @@ func (p *QuarkusProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	if hasMaven {
 		vars["buildTool"] = "maven"
-		score += p.detectMaven(scan, vars)
+		score += p.detectMaven(ctx, scan, vars)
 	} else if hasGradle {
 		vars["buildTool"] = "gradle"
-		score += p.detectGradle(scan, vars)
+		score += p.detectGradle(ctx, scan, vars)
 	}
 
 	if score == 0 {
@@ -60,14 +58,17 @@ func (p *QuarkusProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		if err == nil {
 			content := string(data)
 			if strings.Contains(content, "quarkus.") {
-				score += 10
+				score += providers.Award(ctx, 10, "application.properties configures quarkus")
+			}
+			if isQuarkusNative(content) {
+				vars["native"] = true
 			}
 		}
 	}
 
 	if scan.FileTree.HasFile("src/main/resources/application.yaml") ||
 		scan.FileTree.HasFile("src/main/resources/application.yml") {
-		score += 5
+		score += providers.Award(ctx, 5, "src/main/resources/application.yaml or application.yml")
 	}
 
 	// Check for mvnw or gradlew wrapper
@@ -104,68 +105,69 @@ func (p *QuarkusProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	return score, vars, nil
 }
 
-// detectMaven parses pom.xml for Quarkus
-func (p *QuarkusProvider) detectMaven(scan *scanner.ScanResult, vars map[string]interface{}) int {
-	data, err := scan.ReadFile("pom.xml")
-	if err != nil {
+// detectMaven checks pom.xml for Quarkus
+func (p *QuarkusProvider) detectMaven(ctx context.Context, scan *scanner.ScanResult, vars map[string]interface{}) int {
+	pom := scan.Metadata.PomXML
+	if pom == nil {
 		return 0
 	}
 
-	content := string(data)
 	score := 0
 
-	// Check for Quarkus BOM or parent
-	if strings.Contains(content, "io.quarkus") {
-		score += 50
+	// Check for Quarkus BOM, parent, dependencies or plugin
+	if pom.UsesGroup("io.quarkus") {
+		score += providers.Award(ctx, 50, "pom.xml references io.quarkus")
 	}
 
 	// Check for quarkus-bom
-	if strings.Contains(content, "quarkus-bom") || strings.Contains(content, "quarkus-universe-bom") {
-		score += 10
+	if pom.UsesArtifact("quarkus-bom") || pom.UsesArtifact("quarkus-universe-bom") {
+		score += providers.Award(ctx, 10, "pom.xml imports the Quarkus BOM")
 	}
 
 	// Check for quarkus-maven-plugin
-	if strings.Contains(content, "quarkus-maven-plugin") {
-		score += 15
+	if pom.UsesArtifact("quarkus-maven-plugin") {
+		score += providers.Award(ctx, 15, "pom.xml uses quarkus-maven-plugin")
 	}
 
-	// Try to parse XML for more details
-	var pom PomXML
-	if err := xml.Unmarshal(data, &pom); err == nil {
-		// Get Java version from properties
-		if pom.Properties.JavaVersion != "" {
-			vars["javaVersion"] = pom.Properties.JavaVersion
-		}
+	// Native build enabled by default in the POM (not just via a profile)
+	if pom.Properties["quarkus.native.enabled"] == "true" || pom.Properties["quarkus.package.type"] == "native" {
+		vars["native"] = true
+	}
 
-		// Check dependencies
-		for _, dep := range pom.Dependencies.Dependency {
-			if dep.GroupID == "io.quarkus" {
-				score += 5
-				// Check for specific extensions
-				if strings.Contains(dep.ArtifactID, "resteasy") {
-					vars["hasResteasy"] = true
-				}
-				if strings.Contains(dep.ArtifactID, "hibernate") {
-					vars["hasHibernate"] = true
-				}
-				if strings.Contains(dep.ArtifactID, "smallrye") {
-					vars["hasSmallrye"] = true
-				}
+	if pom.JavaVersion != "" {
+		vars["javaVersion"] = pom.JavaVersion
+	}
+
+	// Check dependencies
+	for _, dep := range pom.Dependencies {
+		if dep.GroupID == "io.quarkus" {
+			score += providers.Award(ctx, 5, "dependency io.quarkus:"+dep.ArtifactID)
+			// Check for specific extensions
+			if strings.Contains(dep.ArtifactID, "resteasy") {
+				vars["hasResteasy"] = true
+			}
+			if strings.Contains(dep.ArtifactID, "hibernate") {
+				vars["hasHibernate"] = true
+			}
+			if strings.Contains(dep.ArtifactID, "smallrye") {
+				vars["hasSmallrye"] = true
 			}
 		}
 	}
 
 	// Extract Quarkus version
-	quarkusVersionRe := regexp.MustCompile(`<quarkus[.-]?(?:platform)?[.-]?version>([^<]+)</quarkus`)
-	if matches := quarkusVersionRe.FindStringSubmatch(content); len(matches) > 1 {
-		vars["quarkusVersion"] = matches[1]
+	for _, key := range []string{"quarkus.platform.version", "quarkus-platform.version", "quarkus.version"} {
+		if v := pom.Properties[key]; v != "" {
+			vars["quarkusVersion"] = v
+			break
+		}
 	}
 
 	return score
 }
 
 // detectGradle parses build.gradle for Quarkus
-func (p *QuarkusProvider) detectGradle(scan *scanner.ScanResult, vars map[string]interface{}) int {
+func (p *QuarkusProvider) detectGradle(ctx context.Context, scan *scanner.ScanResult, vars map[string]interface{}) int {
 	var content string
 
 	if scan.FileTree.HasFile("build.gradle.kts") {
@@ -186,29 +188,23 @@ func (p *QuarkusProvider) detectGradle(scan *scanner.ScanResult, vars map[string
 
 	// Check for Quarkus plugin
 	if strings.Contains(content, "io.quarkus") {
-		score += 50
+		score += providers.Award(ctx, 50, "Gradle build references io.quarkus")
 	}
 
 	// Check for quarkus platform
 	if strings.Contains(content, "quarkus-universe-bom") || strings.Contains(content, "quarkus-bom") {
-		score += 10
+		score += providers.Award(ctx, 10, "Gradle build imports the Quarkus BOM")
 	}
 
 	// Check for Quarkus dependencies
 	if strings.Contains(content, "quarkus-resteasy") || strings.Contains(content, "quarkus-rest") {
-		score += 10
+		score += providers.Award(ctx, 10, "Gradle build uses RESTEasy")
 		vars["hasResteasy"] = true
 	}
 
-	// Try to extract Java version
-	if strings.Contains(content, "sourceCompatibility") || strings.Contains(content, "java.toolchain") {
-		if strings.Contains(content, "21") {
-			vars["javaVersion"] = "21"
-		} else if strings.Contains(content, "17") {
-			vars["javaVersion"] = "17"
-		} else if strings.Contains(content, "11") {
-			vars["javaVersion"] = "11"
-		}
+	// Java version from the toolchain or sourceCompatibility
+	if v := gradleJavaVersion(scan); v != "" {
+		vars["javaVersion"] = v
 	}
 
 	return score
@@ -218,3 +214,14 @@ func (p *QuarkusProvider) detectGradle(scan *scanner.ScanResult, vars map[string
 func (p *QuarkusProvider) DetectVersion(scan *scanner.ScanResult) string {
 	return detectJavaVersionFromFiles(scan)
 }
+
+// isQuarkusNative checks application.properties for a GraalVM native build
+func isQuarkusNative(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+		if line == "quarkus.native.enabled=true" || line == "quarkus.package.type=native" {
+			return true
+		}
+	}
+	return false
+}
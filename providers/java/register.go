@@ -8,8 +8,10 @@ import (
 func RegisterAll(registry *detector.Registry) {
 	// Register in order of specificity
 	registry.Register(NewQuarkusProvider())
+	registry.Register(NewMicronautProvider())
+	registry.Register(NewKtorProvider())
 	registry.Register(NewSpringBootProvider())
+	registry.Register(NewGenericProvider()) // Fallback when no framework matched
 	// Future providers:
-	// registry.Register(NewMicronautProvider())
 	// registry.Register(NewJakartaEEProvider())
 }
@@ -2,7 +2,6 @@ package java
 
 import (
 	"context"
-	"encoding/xml"
 	"strings"
 
 	"github.com/dublyo/dockerizer/internal/scanner"
@@ -28,34 +27,6 @@ func NewSpringBootProvider() *SpringBootProvider {
 	}
 }
 
-// PomXML represents a Maven pom.xml structure
-type PomXML struct {
-	XMLName      xml.Name `xml:"project"`
-	Parent       Parent   `xml:"parent"`
-	Dependencies struct {
-		Dependency []Dependency `xml:"dependency"`
-	} `xml:"dependencies"`
-	Properties struct {
-		JavaVersion string `xml:"java.version"`
-	} `xml:"properties"`
-	Build struct {
-		FinalName string `xml:"finalName"`
-	} `xml:"build"`
-}
-
-// Parent represents the parent POM
-type Parent struct {
-	GroupID    string `xml:"groupId"`
-	ArtifactID string `xml:"artifactId"`
-	Version    string `xml:"version"`
-}
-
-// Dependency represents a Maven dependency
-type Dependency struct {
-	GroupID    string `xml:"groupId"`
-	ArtifactID string `xml:"artifactId"`
-}
-
 // Detect checks if the repository is a Spring Boot project
 func (p *SpringBootProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
 	score := 0
@@ -71,10 +42,10 @@ func (p *SpringBootProvider) Detect(ctx context.Context, scan *scanner.ScanResul
 
 	if hasMaven {
 		vars["buildTool"] = "maven"
-		score += p.detectMaven(scan, vars)
+		score += p.detectMaven(ctx, scan, vars)
 	} else if hasGradle {
 		vars["buildTool"] = "gradle"
-		score += p.detectGradle(scan, vars)
+		score += p.detectGradle(ctx, scan, vars)
 	}
 
 	if score == 0 {
@@ -83,14 +54,14 @@ func (p *SpringBootProvider) Detect(ctx context.Context, scan *scanner.ScanResul
 
 	// Check for Spring Boot application class
 	if p.hasSpringBootApplication(scan) {
-		score += 15
+		score += providers.Award(ctx, 15, "@SpringBootApplication class")
 	}
 
 	// Check for application.properties or application.yml
 	if scan.FileTree.HasFile("src/main/resources/application.properties") ||
 		scan.FileTree.HasFile("src/main/resources/application.yml") ||
 		scan.FileTree.HasFile("src/main/resources/application.yaml") {
-		score += 10
+		score += providers.Award(ctx, 10, "src/main/resources/application.properties or application.yml")
 	}
 
 	// Check for mvnw or gradlew wrapper
@@ -116,19 +87,14 @@ func (p *SpringBootProvider) Detect(ctx context.Context, scan *scanner.ScanResul
 	return score, vars, nil
 }
 
-// detectMaven parses pom.xml for Spring Boot
-func (p *SpringBootProvider) detectMaven(scan *scanner.ScanResult, vars map[string]interface{}) int {
-	data, err := scan.ReadFile("pom.xml")
-	if err != nil {
-		return 0
-	}
-
-	var pom PomXML
-	if err := xml.Unmarshal(data, &pom); err != nil {
-		// Fallback to string search
-		content := string(data)
-		if strings.Contains(content, "spring-boot") {
-			return 50
+// detectMaven checks pom.xml for Spring Boot
+func (p *SpringBootProvider) detectMaven(ctx context.Context, scan *scanner.ScanResult, vars map[string]interface{}) int {
+	pom := scan.Metadata.PomXML
+	if pom == nil {
+		// Unparseable POM: fall back to a string search
+		data, err := scan.ReadFile("pom.xml")
+		if err == nil && strings.Contains(string(data), "spring-boot") {
+			return providers.Award(ctx, 50, "pom.xml mentions spring-boot")
 		}
 		return 0
 	}
@@ -137,33 +103,30 @@ func (p *SpringBootProvider) detectMaven(scan *scanner.ScanResult, vars map[stri
 
 	// Check parent for spring-boot-starter-parent
 	if pom.Parent.ArtifactID == "spring-boot-starter-parent" {
-		score += 50
+		score += providers.Award(ctx, 50, "pom.xml parent spring-boot-starter-parent")
 		vars["springBootVersion"] = pom.Parent.Version
 	}
 
 	// Check dependencies for spring-boot-starter
-	for _, dep := range pom.Dependencies.Dependency {
+	for _, dep := range pom.Dependencies {
 		if strings.HasPrefix(dep.ArtifactID, "spring-boot-starter") {
-			score += 20
+			score += providers.Award(ctx, 20, "dependency "+dep.ArtifactID)
 			break
 		}
 	}
 
-	// Get Java version from properties
-	if pom.Properties.JavaVersion != "" {
-		vars["javaVersion"] = pom.Properties.JavaVersion
+	if pom.JavaVersion != "" {
+		vars["javaVersion"] = pom.JavaVersion
 	}
-
-	// Get final name
-	if pom.Build.FinalName != "" {
-		vars["jarName"] = pom.Build.FinalName
+	if pom.FinalName != "" {
+		vars["jarName"] = pom.FinalName
 	}
 
 	return score
 }
 
 // detectGradle parses build.gradle for Spring Boot
-func (p *SpringBootProvider) detectGradle(scan *scanner.ScanResult, vars map[string]interface{}) int {
+func (p *SpringBootProvider) detectGradle(ctx context.Context, scan *scanner.ScanResult, vars map[string]interface{}) int {
 	var content string
 
 	if scan.FileTree.HasFile("build.gradle.kts") {
@@ -182,31 +145,46 @@ func (p *SpringBootProvider) detectGradle(scan *scanner.ScanResult, vars map[str
 
 	score := 0
 
-	// Check for Spring Boot plugin
-	if strings.Contains(content, "org.springframework.boot") {
-		score += 50
+	// Check for Spring Boot plugin, by ID or version catalog alias
+	if version, ok := springBootPlugin(scan.Metadata.Gradle); ok {
+		score += providers.Award(ctx, 50, "Gradle build applies the Spring Boot plugin")
+		if version != "" {
+			vars["springBootVersion"] = version
+		}
+	} else if strings.Contains(content, "org.springframework.boot") {
+		score += providers.Award(ctx, 50, "Gradle build references org.springframework.boot")
 	}
 
 	// Check for Spring Boot dependencies
 	if strings.Contains(content, "spring-boot-starter") {
-		score += 20
+		score += providers.Award(ctx, 20, "Gradle build uses spring-boot-starter")
 	}
 
-	// Try to extract Java version
-	if strings.Contains(content, "sourceCompatibility") || strings.Contains(content, "java.toolchain") {
-		// Common patterns: sourceCompatibility = '17' or JavaVersion.VERSION_17
-		if strings.Contains(content, "21") {
-			vars["javaVersion"] = "21"
-		} else if strings.Contains(content, "17") {
-			vars["javaVersion"] = "17"
-		} else if strings.Contains(content, "11") {
-			vars["javaVersion"] = "11"
-		}
+	// Java version from the toolchain or sourceCompatibility
+	if v := gradleJavaVersion(scan); v != "" {
+		vars["javaVersion"] = v
 	}
 
 	return score
 }
 
+// springBootPlugin reports whether a Gradle build applies the Spring Boot
+// plugin, and its version when the build script gives it
+func springBootPlugin(build *scanner.GradleBuild) (version string, ok bool) {
+	if build == nil {
+		return "", false
+	}
+	if version, ok := build.Plugins["org.springframework.boot"]; ok {
+		return version, true
+	}
+	for id := range build.Plugins {
+		if strings.HasPrefix(id, "libs.plugins.") && strings.HasSuffix(strings.ToLower(id), "boot") {
+			return "", true
+		}
+	}
+	return "", false
+}
+
 // hasSpringBootApplication checks for @SpringBootApplication annotation
 func (p *SpringBootProvider) hasSpringBootApplication(scan *scanner.ScanResult) bool {
 	// Look for Java files in src/main/java
@@ -227,6 +205,15 @@ func (p *SpringBootProvider) DetectVersion(scan *scanner.ScanResult) string {
 	return detectJavaVersionFromFiles(scan)
 }
 
+// gradleJavaVersion is the Java version the Gradle build script declares,
+// by toolchain or sourceCompatibility, if any
+func gradleJavaVersion(scan *scanner.ScanResult) string {
+	if build := scan.Metadata.Gradle; build != nil {
+		return build.JavaVersion
+	}
+	return ""
+}
+
 // detectJavaVersionFromFiles detects Java version from configuration files
 func detectJavaVersionFromFiles(scan *scanner.ScanResult) string {
 	// Check .java-version file
@@ -41,29 +41,29 @@ func (p *AstroProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 
 	// Check for astro dependency (required)
 	if pkg.HasDependency("astro") {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency astro")
 	} else {
 		return 0, nil, nil // Not Astro
 	}
 
 	// Check for astro.config.mjs or astro.config.ts
 	if scan.FileTree.HasFile("astro.config.mjs") || scan.FileTree.HasFile("astro.config.ts") || scan.FileTree.HasFile("astro.config.js") {
-		score += 20
+		score += providers.Award(ctx, 20, "astro.config.mjs or astro.config.ts or astro.config.js")
 	}
 
 	// Check for src/pages directory (Astro convention)
 	if scan.FileTree.HasDir("src/pages") {
-		score += 10
+		score += providers.Award(ctx, 10, "src/pages/ directory")
 	}
 
 	// Check for src/layouts directory
 	if scan.FileTree.HasDir("src/layouts") {
-		score += 5
+		score += providers.Award(ctx, 5, "src/layouts/ directory")
 	}
 
 	// Check for src/components directory
 	if scan.FileTree.HasDir("src/components") {
-		score += 5
+		score += providers.Award(ctx, 5, "src/components/ directory")
 	}
 
 	// Detect output mode from astro.config
@@ -76,10 +76,10 @@ func (p *AstroProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 				content := string(data)
 				if strings.Contains(content, "output: 'server'") || strings.Contains(content, "output: \"server\"") {
 					vars["outputMode"] = "server"
-					score += 5
+					score += providers.Award(ctx, 5, configFile+" sets output: server")
 				} else if strings.Contains(content, "output: 'hybrid'") || strings.Contains(content, "output: \"hybrid\"") {
 					vars["outputMode"] = "hybrid"
-					score += 5
+					score += providers.Award(ctx, 5, configFile+" sets output: hybrid")
 				}
 				// Check for Node adapter
 				if strings.Contains(content, "@astrojs/node") {
@@ -103,8 +103,19 @@ func (p *AstroProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 		vars["typescript"] = true
 	}
 
-	// Detect port
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
+	// Detect port; static sites are served by nginx-unprivileged on 8080
 	vars["port"] = detectPort(scan, "4321")
+	if vars["outputMode"] == "static" {
+		vars["port"] = "8080"
+	}
 
 	// Cap at 100
 	if score > 100 {
@@ -40,7 +40,7 @@ func (p *ExpressProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	// Check for express dependency (required)
 	if pkg.HasDependency("express") {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency express")
 	} else {
 		return 0, nil, nil // Not Express
 	}
@@ -59,7 +59,7 @@ func (p *ExpressProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		}
 	}
 	if mainFile != "" {
-		score += 20
+		score += providers.Award(ctx, 20, "entry point "+mainFile)
 		vars["mainFile"] = mainFile
 	}
 
@@ -77,14 +77,14 @@ func (p *ExpressProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	}
 	for _, middleware := range middlewarePackages {
 		if pkg.HasDependency(middleware) {
-			score += 5
+			score += providers.Award(ctx, 5, "dependency "+middleware)
 		}
 	}
 
 	// Check for TypeScript
 	if pkg.HasDependency("typescript") || scan.FileTree.HasFile("tsconfig.json") {
 		vars["typescript"] = true
-		score += 5
+		score += providers.Award(ctx, 5, "dependency typescript or tsconfig.json")
 		// Check for ts-node or tsx
 		if pkg.HasDependency("ts-node") || pkg.HasDependency("tsx") {
 			vars["tsRunner"] = true
@@ -102,13 +102,26 @@ func (p *ExpressProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	// Check for common scripts
 	if pkg.HasScript("start") {
 		vars["startScript"] = "start"
-		score += 10
+		score += providers.Award(ctx, 10, "\"start\" script")
 	}
 	if pkg.HasScript("build") {
 		vars["buildScript"] = "build"
-		score += 5
+		score += providers.Award(ctx, 5, "\"build\" script")
 	}
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+	detectInstallScripts(scan, vars)
+	if vars["typescript"] != true {
+		// JavaScript builds are single-stage, so migrations run from the runner
+		vars["migrateStage"] = "runner"
+	}
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port
 	vars["port"] = detectPort(scan, "3000")
 
@@ -119,7 +132,7 @@ func (p *ExpressProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	// Check for engines specification (production ready)
 	if pkg.Engines.Node != "" {
-		score += 5
+		score += providers.Award(ctx, 5, "engines.node set")
 	}
 
 	// Cap at 100
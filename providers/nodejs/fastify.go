@@ -40,7 +40,7 @@ func (p *FastifyProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	// Check for fastify dependency (required)
 	if pkg.HasDependency("fastify") {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency fastify")
 	} else {
 		return 0, nil, nil // Not Fastify
 	}
@@ -57,7 +57,7 @@ func (p *FastifyProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		}
 	}
 	if mainFile != "" {
-		score += 20
+		score += providers.Award(ctx, 20, "entry point "+mainFile)
 		vars["mainFile"] = mainFile
 	}
 
@@ -76,14 +76,14 @@ func (p *FastifyProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	}
 	for _, plugin := range plugins {
 		if pkg.HasDependency(plugin) {
-			score += 5
+			score += providers.Award(ctx, 5, "dependency "+plugin)
 		}
 	}
 
 	// Check for TypeScript
 	if pkg.HasDependency("typescript") || scan.FileTree.HasFile("tsconfig.json") {
 		vars["typescript"] = true
-		score += 5
+		score += providers.Award(ctx, 5, "dependency typescript or tsconfig.json")
 	}
 
 	// Detect package manager
@@ -97,13 +97,25 @@ func (p *FastifyProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	// Check for common scripts
 	if pkg.HasScript("start") {
 		vars["startScript"] = "start"
-		score += 10
+		score += providers.Award(ctx, 10, "\"start\" script")
 	}
 	if pkg.HasScript("build") {
 		vars["buildScript"] = "build"
-		score += 5
+		score += providers.Award(ctx, 5, "\"build\" script")
 	}
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+	if vars["typescript"] != true {
+		// JavaScript builds are single-stage, so migrations run from the runner
+		vars["migrateStage"] = "runner"
+	}
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port
 	vars["port"] = detectPort(scan, "3000")
 
@@ -115,12 +127,12 @@ func (p *FastifyProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	// Check for Fastify CLI
 	if pkg.HasDependency("fastify-cli") {
 		vars["hasCLI"] = true
-		score += 5
+		score += providers.Award(ctx, 5, "dependency fastify-cli")
 	}
 
 	// Check for engines specification
 	if pkg.Engines.Node != "" {
-		score += 5
+		score += providers.Award(ctx, 5, "engines.node set")
 	}
 
 	// Cap at 100
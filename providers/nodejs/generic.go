@@ -0,0 +1,104 @@
+package nodejs
+
+import (
+	"context"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// NodeProvider is the fallback for Node.js projects no framework provider
+// matched: it installs the dependencies, runs the build script if there is
+// one, and starts the app with the start script or the main file
+type NodeProvider struct {
+	providers.BaseProvider
+}
+
+// NewNodeProvider creates the generic Node.js provider
+func NewNodeProvider() *NodeProvider {
+	return &NodeProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "node",
+			ProviderLanguage:    "nodejs",
+			ProviderFramework:   "generic",
+			ProviderTemplate:    "nodejs/node.tmpl",
+			ProviderDescription: "Node.js application (no framework detected)",
+			ProviderURL:         "https://nodejs.org",
+		},
+	}
+}
+
+// Detect matches any package.json with something to run
+func (p *NodeProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	pkg := scan.Metadata.PackageJSON
+	if pkg == nil || len(pkg.Workspaces) > 0 {
+		// Workspace roots are handled per package
+		return 0, nil, nil
+	}
+
+	score := providers.Award(ctx, 25, "package.json")
+	vars := make(map[string]interface{})
+
+	if pkg.HasScript("start") {
+		vars["startScript"] = "start"
+		score += providers.Award(ctx, 15, `"start" script`)
+	} else {
+		mainFile := pkg.Main
+		if mainFile == "" {
+			for _, ep := range []string{"index.js", "server.js", "app.js", "main.js", "src/index.js"} {
+				if scan.FileTree.HasFile(ep) {
+					mainFile = ep
+					break
+				}
+			}
+		}
+		if mainFile == "" {
+			// Nothing to start: a library or a front-end tooling manifest
+			return 0, nil, nil
+		}
+		vars["mainFile"] = mainFile
+		score += providers.Award(ctx, 10, "entry point "+mainFile)
+	}
+	if pkg.HasScript("build") {
+		vars["buildScript"] = "build"
+		score += providers.Award(ctx, 5, `"build" script`)
+	}
+
+	pm := detectPackageManager(scan)
+	vars["packageManager"] = pm
+	vars["hasLockFile"] = hasLockFile(scan, pm)
+	vars["nodeVersion"] = p.DetectVersion(scan)
+	vars["port"] = detectPort(scan, "3000")
+	detectNativeDeps(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+	if vars["websocket"] != nil {
+		// Without an HTTP framework, a plain GET may be answered with an
+		// upgrade error: probe the port instead
+		vars["healthTCP"] = true
+	}
+
+	return min(score, providers.GenericConfidence), vars, nil
+}
+
+// DetectVersion detects the Node.js version to use
+func (p *NodeProvider) DetectVersion(scan *scanner.ScanResult) string {
+	if scan.Metadata.PackageJSON == nil {
+		return "20"
+	}
+
+	pkg := scan.Metadata.PackageJSON
+
+	if pkg.Engines.Node != "" {
+		return parseNodeVersion(pkg.Engines.Node)
+	}
+
+	if scan.FileTree.HasFile(".nvmrc") {
+		data, err := scan.ReadFile(".nvmrc")
+		if err == nil {
+			return parseNodeVersion(string(data))
+		}
+	}
+
+	return "20"
+}
@@ -40,28 +40,28 @@ func (p *HonoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 
 	// Check for hono dependency (required)
 	if pkg.HasDependency("hono") {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency hono")
 	} else {
 		return 0, nil, nil // Not Hono
 	}
 
 	// Check for @hono/node-server (Node.js adapter)
 	if pkg.HasDependency("@hono/node-server") {
-		score += 20
+		score += providers.Award(ctx, 20, "dependency @hono/node-server")
 		vars["hasNodeAdapter"] = true
 	}
 
 	// Check for common entry points
 	if scan.FileTree.HasFile("src/index.ts") || scan.FileTree.HasFile("src/index.js") {
-		score += 10
+		score += providers.Award(ctx, 10, "src/index.ts or src/index.js")
 	}
 	if scan.FileTree.HasFile("index.ts") || scan.FileTree.HasFile("index.js") {
-		score += 10
+		score += providers.Award(ctx, 10, "index.ts or index.js")
 	}
 
 	// Check for TypeScript
 	if scan.FileTree.HasFile("tsconfig.json") {
-		score += 5
+		score += providers.Award(ctx, 5, "tsconfig.json")
 		vars["typescript"] = true
 	}
 
@@ -98,6 +98,14 @@ func (p *HonoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 		vars["mainEntry"] = "index.js"
 	}
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port
 	vars["port"] = detectPort(scan, "3000")
 
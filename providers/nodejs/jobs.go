@@ -0,0 +1,68 @@
+package nodejs
+
+import (
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// workerScripts are package.json scripts that conventionally start a queue worker
+var workerScripts = []string{"worker", "start:worker", "worker:start", "jobs", "queue"}
+
+// workerFiles are conventional worker entrypoints of JavaScript projects
+var workerFiles = []string{"worker.js", "worker.mjs", "src/worker.js", "src/worker.mjs", "workers/index.js", "src/workers/index.js"}
+
+// detectJobQueue detects BullMQ, Bull and Bee-Queue, and sets the variables
+// for the compose worker service: jobQueue, jobCommand and jobBroker. Without
+// a worker script or entrypoint there is nothing to start, so no worker.
+func detectJobQueue(scan *scanner.ScanResult, vars map[string]interface{}) {
+	pkg := scan.Metadata.PackageJSON
+	if pkg == nil {
+		return
+	}
+	var queue string
+	for _, lib := range [][2]string{{"bullmq", "BullMQ"}, {"bull", "Bull"}, {"bee-queue", "Bee-Queue"}} {
+		if pkg.HasDependency(lib[0]) {
+			queue = lib[1]
+			break
+		}
+	}
+	if queue == "" {
+		return
+	}
+
+	run := "npm run"
+	if vars["packageManager"] == "bun" {
+		run = "bun run" // The bun runner image has no npm
+	}
+	var command string
+	for _, script := range workerScripts {
+		if pkg.HasScript(script) {
+			command = run + " " + script
+			break
+		}
+	}
+	if command == "" && vars["typescript"] == true && vars["buildScript"] != nil {
+		for _, src := range []string{"src/worker.ts", "src/workers/index.ts"} {
+			if scan.FileTree.HasFile(src) {
+				command = "node dist/" + strings.TrimSuffix(strings.TrimPrefix(src, "src/"), ".ts") + ".js"
+				break
+			}
+		}
+	}
+	if command == "" && vars["typescript"] != true {
+		for _, f := range workerFiles {
+			if scan.FileTree.HasFile(f) {
+				command = "node " + f
+				break
+			}
+		}
+	}
+	if command == "" {
+		return
+	}
+
+	vars["jobQueue"] = queue
+	vars["jobCommand"] = command
+	vars["jobBroker"] = "redis"
+}
@@ -40,33 +40,33 @@ func (p *KoaProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int
 
 	// Check for koa dependency (required)
 	if pkg.HasDependency("koa") {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency koa")
 	} else {
 		return 0, nil, nil // Not Koa
 	}
 
 	// Check for koa-router
 	if pkg.HasDependency("koa-router") || pkg.HasDependency("@koa/router") {
-		score += 15
+		score += providers.Award(ctx, 15, "dependency koa-router or @koa/router")
 	}
 
 	// Check for koa-bodyparser
 	if pkg.HasDependency("koa-bodyparser") || pkg.HasDependency("@koa/bodyparser") {
-		score += 10
+		score += providers.Award(ctx, 10, "dependency koa-bodyparser or @koa/bodyparser")
 	}
 
 	// Check for common entry points
 	if scan.FileTree.HasFile("app.js") || scan.FileTree.HasFile("app.ts") {
-		score += 10
+		score += providers.Award(ctx, 10, "app.js or app.ts")
 	}
 	if scan.FileTree.HasFile("server.js") || scan.FileTree.HasFile("server.ts") {
-		score += 10
+		score += providers.Award(ctx, 10, "server.js or server.ts")
 	}
 	if scan.FileTree.HasFile("src/app.js") || scan.FileTree.HasFile("src/app.ts") {
-		score += 10
+		score += providers.Award(ctx, 10, "src/app.js or src/app.ts")
 	}
 	if scan.FileTree.HasFile("src/index.js") || scan.FileTree.HasFile("src/index.ts") {
-		score += 5
+		score += providers.Award(ctx, 5, "src/index.js or src/index.ts")
 	}
 
 	// Detect package manager
@@ -100,6 +100,14 @@ func (p *KoaProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int
 		vars["mainEntry"] = "index.js"
 	}
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port
 	vars["port"] = detectPort(scan, "3000")
 
@@ -0,0 +1,86 @@
+package nodejs
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// nativeAddon describes a dependency that compiles a native addon on install
+type nativeAddon struct {
+	dep     string
+	build   []string // Alpine packages needed to compile it
+	runtime []string // Alpine packages the compiled addon links against
+}
+
+// nativeAddons need python3, make and g++ (node-gyp) plus any listed
+// libraries, which no two addons share
+var nativeAddons = []nativeAddon{
+	{dep: "sharp", build: []string{"vips-dev"}, runtime: []string{"vips"}},
+	{
+		dep:     "canvas",
+		build:   []string{"cairo-dev", "pango-dev", "jpeg-dev", "giflib-dev", "librsvg-dev", "pixman-dev"},
+		runtime: []string{"cairo", "pango", "jpeg", "giflib", "librsvg", "pixman"},
+	},
+	{dep: "bcrypt"},
+	{dep: "better-sqlite3"},
+	{dep: "sqlite3"},
+	{dep: "argon2"},
+	{dep: "node-gyp"},
+}
+
+// nodeGypToolchain is what node-gyp needs to compile any addon on Alpine
+var nodeGypToolchain = []string{"python3", "make", "g++"}
+
+// detectNativeDeps detects dependencies with native addons and sets the Alpine
+// packages the templates install to build and run them
+func detectNativeDeps(scan *scanner.ScanResult, vars map[string]interface{}) {
+	pkg := scan.Metadata.PackageJSON
+	if pkg == nil {
+		return
+	}
+
+	var deps []string
+	build := append([]string{}, nodeGypToolchain...)
+	var runtime []string
+	for _, addon := range nativeAddons {
+		if !pkg.HasDependency(addon.dep) {
+			continue
+		}
+		deps = append(deps, addon.dep)
+
+		// sharp 0.33+ ships prebuilt binaries with libvips bundled, and a
+		// system libvips would make it try to build from source instead
+		if addon.dep == "sharp" && sharpBundlesLibvips(dependencyVersion(pkg, "sharp")) {
+			continue
+		}
+		build = append(build, addon.build...)
+		runtime = append(runtime, addon.runtime...)
+	}
+
+	if len(deps) == 0 {
+		return
+	}
+
+	vars["nativeDeps"] = strings.Join(deps, ", ")
+	vars["nativeBuildPackages"] = strings.Join(build, " ")
+	if len(runtime) > 0 {
+		vars["nativeRuntimePackages"] = strings.Join(runtime, " ")
+	}
+}
+
+// sharpBundlesLibvips reports whether a sharp version ships libvips in its
+// prebuilt binaries (0.33 and later). Unknown versions are assumed current.
+func sharpBundlesLibvips(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return true
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	return major > 0 || minor >= 33
+}
@@ -0,0 +1,71 @@
+package nodejs
+
+import (
+	"testing"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+func TestSharpBundlesLibvips(t *testing.T) {
+	cases := map[string]bool{
+		"0.33.2": true,
+		"0.34":   true,
+		"1.0.0":  true,
+		"0.32.6": false,
+		"0.29":   false,
+		"":       true, // Unknown versions are assumed current
+		"latest": true,
+	}
+	for version, want := range cases {
+		if got := sharpBundlesLibvips(version); got != want {
+			t.Errorf("sharpBundlesLibvips(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestDetectNativeDeps(t *testing.T) {
+	cases := []struct {
+		name            string
+		deps            map[string]string
+		nativeDeps      string
+		buildPackages   string
+		runtimePackages string
+	}{
+		{name: "none", deps: map[string]string{"express": "^4.18.0"}},
+		{
+			name:          "bcrypt",
+			deps:          map[string]string{"bcrypt": "^5.1.0"},
+			nativeDeps:    "bcrypt",
+			buildPackages: "python3 make g++",
+		},
+		{
+			name:          "sharp with bundled libvips",
+			deps:          map[string]string{"sharp": "^0.33.1"},
+			nativeDeps:    "sharp",
+			buildPackages: "python3 make g++",
+		},
+		{
+			name:            "old sharp and canvas",
+			deps:            map[string]string{"sharp": "0.32.6", "canvas": "^2.11.0"},
+			nativeDeps:      "sharp, canvas",
+			buildPackages:   "python3 make g++ vips-dev cairo-dev pango-dev jpeg-dev giflib-dev librsvg-dev pixman-dev",
+			runtimePackages: "vips cairo pango jpeg giflib librsvg pixman",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			scan := &scanner.ScanResult{Metadata: &scanner.Metadata{PackageJSON: &scanner.PackageJSON{Dependencies: tc.deps}}}
+			vars := map[string]interface{}{}
+			detectNativeDeps(scan, vars)
+			for key, want := range map[string]string{
+				"nativeDeps":            tc.nativeDeps,
+				"nativeBuildPackages":   tc.buildPackages,
+				"nativeRuntimePackages": tc.runtimePackages,
+			} {
+				if got, _ := vars[key].(string); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -40,33 +40,33 @@ func (p *NestJSProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 
 	// Check for @nestjs/core dependency (required)
 	if pkg.HasDependency("@nestjs/core") {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency @nestjs/core")
 	} else {
 		return 0, nil, nil // Not NestJS
 	}
 
 	// Check for @nestjs/common
 	if pkg.HasDependency("@nestjs/common") {
-		score += 15
+		score += providers.Award(ctx, 15, "dependency @nestjs/common")
 	}
 
 	// Check for @nestjs/platform-express or @nestjs/platform-fastify
 	if pkg.HasDependency("@nestjs/platform-express") {
-		score += 10
+		score += providers.Award(ctx, 10, "dependency @nestjs/platform-express")
 		vars["platform"] = "express"
 	} else if pkg.HasDependency("@nestjs/platform-fastify") {
-		score += 10
+		score += providers.Award(ctx, 10, "dependency @nestjs/platform-fastify")
 		vars["platform"] = "fastify"
 	}
 
 	// Check for nest-cli.json
 	if scan.FileTree.HasFile("nest-cli.json") {
-		score += 15
+		score += providers.Award(ctx, 15, "nest-cli.json")
 	}
 
 	// Check for src/main.ts (NestJS convention)
 	if scan.FileTree.HasFile("src/main.ts") {
-		score += 10
+		score += providers.Award(ctx, 10, "src/main.ts")
 		vars["mainFile"] = "src/main.ts"
 	}
 
@@ -93,6 +93,15 @@ func (p *NestJSProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 		vars["startScript"] = "start"
 	}
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+	detectInstallScripts(scan, vars)
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port
 	vars["port"] = detectPort(scan, "3000")
 
@@ -43,7 +43,7 @@ func (p *NextJSProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 
 	// Check for next dependency (required)
 	if pkg.HasDependency("next") {
-		score += 40
+		score += providers.Award(ctx, 40, "dependency next")
 	} else {
 		return 0, nil, nil // Not Next.js
 	}
@@ -52,7 +52,7 @@ func (p *NextJSProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 	configFiles := []string{"next.config.js", "next.config.ts", "next.config.mjs"}
 	for _, cf := range configFiles {
 		if scan.FileTree.HasFile(cf) {
-			score += 30
+			score += providers.Award(ctx, 30, cf)
 			break
 		}
 	}
@@ -61,16 +61,16 @@ func (p *NextJSProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 	hasAppDir := scan.FileTree.HasDir("app") || scan.FileTree.HasDir("src/app")
 	hasPagesDir := scan.FileTree.HasDir("pages") || scan.FileTree.HasDir("src/pages")
 	if hasAppDir {
-		score += 20
+		score += providers.Award(ctx, 20, "app/ directory (App Router)")
 		vars["routingMode"] = "app"
 	} else if hasPagesDir {
-		score += 15
+		score += providers.Award(ctx, 15, "pages/ directory (Pages Router)")
 		vars["routingMode"] = "pages"
 	}
 
 	// Check for React dependency (expected with Next.js)
 	if pkg.HasDependency("react") {
-		score += 10
+		score += providers.Award(ctx, 10, "dependency react")
 	}
 
 	// Detect package manager
@@ -108,6 +108,14 @@ func (p *NextJSProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 	// Check for public directory
 	vars["hasPublicDir"] = scan.FileTree.HasDir("public")
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port from environment or common patterns
 	vars["port"] = detectPort(scan, "3000")
 
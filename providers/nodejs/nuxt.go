@@ -41,38 +41,38 @@ func (p *NuxtProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 
 	// Check for nuxt dependency (required)
 	if pkg.HasDependency("nuxt") {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency nuxt")
 	} else {
 		return 0, nil, nil // Not Nuxt
 	}
 
 	// Check for nuxt.config.ts or nuxt.config.js
 	if scan.FileTree.HasFile("nuxt.config.ts") {
-		score += 25
+		score += providers.Award(ctx, 25, "nuxt.config.ts")
 		vars["typescript"] = true
 	} else if scan.FileTree.HasFile("nuxt.config.js") {
-		score += 25
+		score += providers.Award(ctx, 25, "nuxt.config.js")
 	}
 
 	// Check for .nuxt directory (development build output)
 	if scan.FileTree.HasDir(".nuxt") {
-		score += 5
+		score += providers.Award(ctx, 5, ".nuxt/ directory")
 	}
 
 	// Check for Nuxt 3 specific directories
 	if scan.FileTree.HasDir("server") {
-		score += 5
+		score += providers.Award(ctx, 5, "server/ directory")
 		vars["hasServer"] = true
 	}
 
 	// Check for pages directory (file-based routing)
 	if scan.FileTree.HasDir("pages") {
-		score += 5
+		score += providers.Award(ctx, 5, "pages/ directory")
 	}
 
 	// Check for app.vue (Nuxt 3 entry point)
 	if scan.FileTree.HasFile("app.vue") {
-		score += 5
+		score += providers.Award(ctx, 5, "app.vue")
 		vars["nuxtVersion"] = "3"
 	}
 
@@ -101,6 +101,14 @@ func (p *NuxtProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 		vars["startScript"] = "start"
 	}
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port
 	vars["port"] = detectPort(scan, "3000")
 
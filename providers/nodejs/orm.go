@@ -0,0 +1,110 @@
+package nodejs
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// migrateScripts are package.json scripts that conventionally apply migrations
+var migrateScripts = []string{"migrate:deploy", "db:migrate", "migration:run", "migrate", "migrations:run"}
+
+// detectORM detects Prisma, Drizzle, TypeORM and knex, and sets the variables
+// the templates use for client generation and the compose migration service
+func detectORM(scan *scanner.ScanResult, vars map[string]interface{}) {
+	pkg := scan.Metadata.PackageJSON
+	if pkg == nil {
+		return
+	}
+
+	var migrate string
+	switch {
+	case pkg.HasDependency("prisma") || pkg.HasDependency("@prisma/client") || scan.FileTree.HasFile("prisma/schema.prisma"):
+		vars["orm"] = "prisma"
+		vars["prismaGenerate"] = true
+
+		schema := "prisma/schema.prisma"
+		if !scan.FileTree.HasFile(schema) {
+			if files := scan.FileTree.FilesMatching("schema.prisma"); len(files) > 0 {
+				schema = files[0]
+				vars["prismaSchema"] = schema
+			}
+		}
+		vars["prismaSchemaDir"] = filepath.Dir(schema)
+
+		// Pin the CLI so the runtime stage generates a client matching @prisma/client
+		vars["prismaCLI"] = "prisma"
+		if v := dependencyVersion(pkg, "prisma"); v != "" {
+			vars["prismaCLI"] = "prisma@" + v
+		}
+
+		if scan.FileTree.HasDir(filepath.Join(filepath.Dir(schema), "migrations")) {
+			migrate = "npx prisma migrate deploy"
+			if s, ok := vars["prismaSchema"].(string); ok {
+				migrate += " --schema " + s
+			}
+		}
+	case pkg.HasDependency("drizzle-orm"):
+		vars["orm"] = "drizzle"
+		for _, cfg := range []string{"drizzle.config.ts", "drizzle.config.js", "drizzle.config.mjs"} {
+			if scan.FileTree.HasFile(cfg) && pkg.HasDependency("drizzle-kit") {
+				migrate = "npx drizzle-kit migrate"
+				break
+			}
+		}
+	case pkg.HasDependency("typeorm"):
+		vars["orm"] = "typeorm"
+		for _, ds := range []string{"src/data-source.ts", "src/data-source.js", "data-source.ts", "data-source.js", "ormconfig.ts", "ormconfig.js"} {
+			if scan.FileTree.HasFile(ds) {
+				if strings.HasSuffix(ds, ".ts") {
+					migrate = "npx typeorm-ts-node-commonjs migration:run -d " + ds
+				} else {
+					migrate = "npx typeorm migration:run -d " + ds
+				}
+				break
+			}
+		}
+	case pkg.HasDependency("knex"):
+		vars["orm"] = "knex"
+		for _, kf := range []string{"knexfile.js", "knexfile.ts", "knexfile.cjs"} {
+			if scan.FileTree.HasFile(kf) {
+				migrate = "npx knex migrate:latest --knexfile " + kf
+				break
+			}
+		}
+	default:
+		return
+	}
+
+	// A project-defined migration script knows its own setup best
+	for _, script := range migrateScripts {
+		if pkg.HasScript(script) {
+			migrate = "npm run " + script
+			break
+		}
+	}
+
+	// Bun images have bunx but no npx
+	if vars["runtime"] == "bun" {
+		migrate = strings.Replace(migrate, "npx ", "bunx ", 1)
+		migrate = strings.Replace(migrate, "npm run ", "bun run ", 1)
+	}
+
+	if migrate != "" {
+		vars["migrateCommand"] = migrate
+	}
+}
+
+// dependencyVersion returns the pinned version of a dependency, without range operators
+func dependencyVersion(pkg *scanner.PackageJSON, name string) string {
+	v, ok := pkg.Dependencies[name]
+	if !ok {
+		v = pkg.DevDependencies[name]
+	}
+	v = strings.TrimLeft(strings.TrimSpace(v), "^~=v")
+	if v == "" || strings.ContainsAny(v, " <>|*x:/") {
+		return ""
+	}
+	return v
+}
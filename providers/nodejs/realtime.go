@@ -0,0 +1,33 @@
+package nodejs
+
+import "github.com/dublyo/dockerizer/internal/scanner"
+
+// realtimeLibs are WebSocket servers, with the name shown in generated files
+var realtimeLibs = [][2]string{
+	{"socket.io", "Socket.IO"},
+	{"@nestjs/platform-socket.io", "Socket.IO"},
+	{"ws", "ws"},
+	{"@nestjs/platform-ws", "ws"},
+	{"express-ws", "ws"},
+	{"@fastify/websocket", "ws"},
+	{"uWebSockets.js", "uWebSockets.js"},
+}
+
+// detectRealtime detects WebSocket servers, which hold connections open:
+// websocket names the library, stickySessions is set for Socket.IO, whose
+// polling transport needs every request of a client on the same instance
+func detectRealtime(scan *scanner.ScanResult, vars map[string]interface{}) {
+	pkg := scan.Metadata.PackageJSON
+	if pkg == nil {
+		return
+	}
+	for _, lib := range realtimeLibs {
+		if pkg.HasDependency(lib[0]) {
+			vars["websocket"] = lib[1]
+			if lib[1] == "Socket.IO" {
+				vars["stickySessions"] = true
+			}
+			return
+		}
+	}
+}
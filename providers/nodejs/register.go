@@ -17,4 +17,12 @@ func RegisterAll(registry *detector.Registry) {
 	registry.Register(NewKoaProvider())
 	registry.Register(NewFastifyProvider())
 	registry.Register(NewExpressProvider()) // Express last as it's most generic
+	// Static SPAs served by nginx; these never match server-side projects
+	registry.Register(NewAngularProvider())
+	registry.Register(NewCRAProvider())
+	registry.Register(NewVueCLIProvider())
+	registry.Register(NewGatsbyProvider())
+	registry.Register(NewViteProvider())
+	// Fallback when no framework matched
+	registry.Register(NewNodeProvider())
 }
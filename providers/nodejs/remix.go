@@ -45,14 +45,14 @@ func (p *RemixProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 		pkg.HasDependency("@remix-run/serve")
 
 	if hasRemix {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency @remix-run/node, react or serve")
 	} else {
 		return 0, nil, nil // Not Remix
 	}
 
 	// Check for remix.config.js or remix.config.ts
 	if scan.FileTree.HasFile("remix.config.js") || scan.FileTree.HasFile("remix.config.ts") {
-		score += 20
+		score += providers.Award(ctx, 20, "remix.config.js or remix.config.ts")
 	}
 
 	// Check for vite.config.ts with Remix (Remix v2+)
@@ -62,24 +62,24 @@ func (p *RemixProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 			data, _ = scan.ReadFile("vite.config.js")
 		}
 		if strings.Contains(string(data), "@remix-run/dev") {
-			score += 15
+			score += providers.Award(ctx, 15, "Vite config uses @remix-run/dev")
 			vars["usesVite"] = true
 		}
 	}
 
 	// Check for app directory (Remix convention)
 	if scan.FileTree.HasDir("app") {
-		score += 10
+		score += providers.Award(ctx, 10, "app/ directory")
 	}
 
 	// Check for root.tsx (Remix entry point)
 	if scan.FileTree.HasFile("app/root.tsx") || scan.FileTree.HasFile("app/root.jsx") {
-		score += 10
+		score += providers.Award(ctx, 10, "app/root.tsx or app/root.jsx")
 	}
 
 	// Check for routes directory
 	if scan.FileTree.HasDir("app/routes") {
-		score += 5
+		score += providers.Award(ctx, 5, "app/routes/ directory")
 	}
 
 	// Detect package manager
@@ -95,6 +95,14 @@ func (p *RemixProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 		vars["typescript"] = true
 	}
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port
 	vars["port"] = detectPort(scan, "3000")
 
@@ -0,0 +1,50 @@
+package nodejs
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// installLifecycle are the root package.json scripts npm runs on install, in order
+var installLifecycle = []string{"preinstall", "install", "postinstall", "prepare"}
+
+// huskyScript matches a script that only installs husky's git hooks
+var huskyScript = regexp.MustCompile(`^(is-ci \|\| |node \.husky/install\.mjs|npx )?husky( install)?( \S+)?$`)
+
+// detectInstallScripts detects root install scripts, which fail when npm
+// installs from package.json and the lock file alone: they need the source,
+// or dev dependencies and a git checkout (husky). The templates then install
+// with --ignore-scripts (ignoreScripts), rebuild the dependencies so their own
+// scripts still run, and run the project's scripts once the source is copied
+// (installScripts). Husky and Prisma client generation are left out, as the
+// image needs no git hooks and the templates generate the client already.
+func detectInstallScripts(scan *scanner.ScanResult, vars map[string]interface{}) {
+	pkg := scan.Metadata.PackageJSON
+	if pkg == nil || vars["packageManager"] != "npm" {
+		return
+	}
+
+	var scripts []string
+	for _, name := range installLifecycle {
+		script := strings.TrimSpace(pkg.Scripts[name])
+		if script == "" {
+			continue
+		}
+		vars["ignoreScripts"] = true
+		switch {
+		case huskyScript.MatchString(script):
+		case vars["prismaGenerate"] == true && strings.HasPrefix(script, "prisma generate"):
+		default:
+			scripts = append(scripts, name)
+			if strings.Contains(script, "patch-package") {
+				// The production install in the runner needs the patches too
+				vars["patchPackage"] = true
+			}
+		}
+	}
+	if len(scripts) > 0 {
+		vars["installScripts"] = scripts
+	}
+}
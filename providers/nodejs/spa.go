@@ -0,0 +1,390 @@
+package nodejs
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// spaSpec describes a frontend toolchain that builds to static assets
+type spaSpec struct {
+	framework   string
+	description string
+	url         string
+	dependency  string   // Required dependency
+	configFiles []string // Toolchain config files that raise confidence
+	outputDir   string   // Default build output directory
+}
+
+// SPAProvider detects single-page apps that build to static files and are
+// served by nginx (or caddy) instead of a Node.js server
+type SPAProvider struct {
+	providers.BaseProvider
+	spec spaSpec
+}
+
+func newSPAProvider(spec spaSpec) *SPAProvider {
+	return &SPAProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        spec.framework,
+			ProviderLanguage:    "nodejs",
+			ProviderFramework:   spec.framework,
+			ProviderTemplate:    "nodejs/spa.tmpl",
+			ProviderDescription: spec.description,
+			ProviderURL:         spec.url,
+		},
+		spec: spec,
+	}
+}
+
+// NewViteProvider creates a provider for Vite single-page apps
+func NewViteProvider() *SPAProvider {
+	return newSPAProvider(spaSpec{
+		framework:   "vite",
+		description: "Vite single-page app (static)",
+		url:         "https://vitejs.dev",
+		dependency:  "vite",
+		configFiles: []string{"vite.config.ts", "vite.config.js", "vite.config.mjs", "vite.config.mts"},
+		outputDir:   "dist",
+	})
+}
+
+// NewCRAProvider creates a provider for Create React App projects
+func NewCRAProvider() *SPAProvider {
+	return newSPAProvider(spaSpec{
+		framework:   "cra",
+		description: "Create React App (static)",
+		url:         "https://create-react-app.dev",
+		dependency:  "react-scripts",
+		configFiles: []string{"public/index.html"},
+		outputDir:   "build",
+	})
+}
+
+// NewAngularProvider creates a provider for Angular single-page apps
+func NewAngularProvider() *SPAProvider {
+	return newSPAProvider(spaSpec{
+		framework:   "angular",
+		description: "Angular single-page app (static)",
+		url:         "https://angular.dev",
+		dependency:  "@angular/core",
+		configFiles: []string{"angular.json"},
+		outputDir:   "dist",
+	})
+}
+
+// NewVueCLIProvider creates a provider for Vue CLI projects
+func NewVueCLIProvider() *SPAProvider {
+	return newSPAProvider(spaSpec{
+		framework:   "vuecli",
+		description: "Vue CLI single-page app (static)",
+		url:         "https://cli.vuejs.org",
+		dependency:  "@vue/cli-service",
+		configFiles: []string{"vue.config.js", "vue.config.ts"},
+		outputDir:   "dist",
+	})
+}
+
+// NewGatsbyProvider creates a provider for Gatsby static sites
+func NewGatsbyProvider() *SPAProvider {
+	return newSPAProvider(spaSpec{
+		framework:   "gatsby",
+		description: "Gatsby static site",
+		url:         "https://www.gatsbyjs.com",
+		dependency:  "gatsby",
+		configFiles: []string{"gatsby-config.ts", "gatsby-config.js", "gatsby-config.mjs"},
+		outputDir:   "public",
+	})
+}
+
+// serverSideDeps mark projects that need a running server, not a static host
+var serverSideDeps = []string{
+	"next", "nuxt", "@nestjs/core", "@remix-run/node", "@remix-run/react",
+	"astro", "@sveltejs/kit", "hono", "koa", "fastify", "express",
+	"@angular/ssr", "@nguniversal/express-engine", "vite-plugin-ssr", "vike",
+	"@react-router/node", "@solidjs/start",
+}
+
+// Detect checks if the repository is a static SPA built by this toolchain
+func (p *SPAProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	score := 0
+	vars := make(map[string]interface{})
+
+	// Must have package.json
+	if scan.Metadata.PackageJSON == nil {
+		return 0, nil, nil
+	}
+
+	pkg := scan.Metadata.PackageJSON
+
+	// Check for the toolchain dependency (required)
+	if pkg.HasDependency(p.spec.dependency) {
+		score += providers.Award(ctx, 50, "dependency "+p.spec.dependency)
+	} else {
+		return 0, nil, nil
+	}
+
+	// Unlike Vite, the CLI toolchains are only ever used for their own apps
+	if p.spec.framework != "vite" {
+		score += providers.Award(ctx, 15, p.spec.framework+" toolchain is app-specific")
+	}
+
+	// Server-rendered apps belong to their framework providers
+	for _, dep := range serverSideDeps {
+		if pkg.HasDependency(dep) {
+			return 0, nil, nil
+		}
+	}
+
+	// Vite is also used by the other toolchains' plugins; defer to them
+	if p.spec.framework == "vite" && (pkg.HasDependency("react-scripts") || pkg.HasDependency("@vue/cli-service") || pkg.HasDependency("gatsby")) {
+		return 0, nil, nil
+	}
+
+	configFile := ""
+	for _, f := range p.spec.configFiles {
+		if scan.FileTree.HasFile(f) {
+			configFile = f
+			score += providers.Award(ctx, 25, f)
+			break
+		}
+	}
+
+	if pkg.HasScript("build") {
+		score += providers.Award(ctx, 15, "\"build\" script")
+	} else if p.spec.framework != "angular" {
+		// Without a build script there is nothing to serve
+		return 0, nil, nil
+	}
+
+	if p.spec.framework == "vite" && scan.FileTree.HasFile("index.html") {
+		score += providers.Award(ctx, 10, "index.html")
+	}
+
+	outputDir := p.spec.outputDir
+	basePath := ""
+
+	switch p.spec.framework {
+	case "vite":
+		outputDir, basePath = matchConfig(scan, configFile, outputDir,
+			`outDir:\s*['"]([^'"]+)['"]`, `base:\s*['"]([^'"]+)['"]`)
+	case "cra":
+		basePath = craHomepagePath(scan)
+	case "angular":
+		if dir, base := angularConfig(scan); dir != "" {
+			outputDir, basePath = dir, base
+		}
+		if pkg.HasDependency("@angular/cli") {
+			score += providers.Award(ctx, 10, "dependency @angular/cli")
+		}
+	case "vuecli":
+		outputDir, basePath = matchConfig(scan, configFile, outputDir,
+			`outputDir:\s*['"]([^'"]+)['"]`, `publicPath:\s*['"]([^'"]+)['"]`)
+	case "gatsby":
+		_, basePath = matchConfig(scan, configFile, outputDir, "", `pathPrefix:\s*['"]([^'"]+)['"]`)
+		if basePath != "" {
+			vars["prefixPaths"] = true
+		}
+	}
+
+	vars["basePath"] = normalizeBasePath(basePath)
+	vars["outputDir"] = strings.TrimSuffix(strings.TrimPrefix(outputDir, "./"), "/")
+
+	// Static file server: nginx, or caddy with --static-server when generating
+	vars["staticServer"] = "nginx"
+
+	pm := detectPackageManager(scan)
+	vars["packageManager"] = pm
+	vars["hasLockFile"] = hasLockFile(scan, pm)
+	vars["buildCommand"] = p.buildCommand(pm, pkg.HasScript("build"), vars)
+	vars["nodeVersion"] = p.DetectVersion(scan)
+	vars["framework"] = p.spec.framework
+
+	// Build-time toolchain for native addons
+	detectNativeDeps(scan, vars)
+
+	// nginx-unprivileged and our caddy config both listen on 8080
+	vars["port"] = "8080"
+
+	// Cap at 100
+	if score > 100 {
+		score = 100
+	}
+
+	return score, vars, nil
+}
+
+// DetectVersion detects the Node.js version used to build the assets
+func (p *SPAProvider) DetectVersion(scan *scanner.ScanResult) string {
+	if scan.Metadata.PackageJSON == nil {
+		return "20"
+	}
+
+	pkg := scan.Metadata.PackageJSON
+
+	if pkg.Engines.Node != "" {
+		return parseNodeVersion(pkg.Engines.Node)
+	}
+
+	if scan.FileTree.HasFile(".nvmrc") {
+		data, err := scan.ReadFile(".nvmrc")
+		if err == nil {
+			return parseNodeVersion(string(data))
+		}
+	}
+
+	return "20"
+}
+
+// buildCommand returns the shell command that produces the static assets
+func (p *SPAProvider) buildCommand(pm string, hasBuildScript bool, vars map[string]interface{}) string {
+	var args []string
+	if p.spec.framework == "gatsby" && vars["prefixPaths"] == true {
+		args = append(args, "--prefix-paths")
+	}
+
+	if !hasBuildScript {
+		return strings.Join(append([]string{"npx", "ng", "build"}, args...), " ")
+	}
+
+	cmd := pm + " run build"
+	if pm == "yarn" {
+		cmd = "yarn build"
+	}
+	if len(args) > 0 {
+		if pm != "yarn" {
+			cmd += " --"
+		}
+		cmd += " " + strings.Join(args, " ")
+	}
+	return cmd
+}
+
+// matchConfig extracts the output directory and base path from a JS config file
+func matchConfig(scan *scanner.ScanResult, configFile, outputDir, outPattern, basePattern string) (string, string) {
+	if configFile == "" {
+		return outputDir, ""
+	}
+	data, err := scan.ReadFile(configFile)
+	if err != nil {
+		return outputDir, ""
+	}
+	content := string(data)
+
+	if outPattern != "" {
+		if matches := regexp.MustCompile(outPattern).FindStringSubmatch(content); len(matches) > 1 {
+			outputDir = matches[1]
+		}
+	}
+
+	basePath := ""
+	if matches := regexp.MustCompile(basePattern).FindStringSubmatch(content); len(matches) > 1 {
+		basePath = matches[1]
+	}
+	return outputDir, basePath
+}
+
+// craHomepagePath derives the base path from the package.json "homepage" field
+func craHomepagePath(scan *scanner.ScanResult) string {
+	data, err := scan.ReadFile("package.json")
+	if err != nil {
+		return ""
+	}
+	var pkg struct {
+		Homepage string `json:"homepage"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil || pkg.Homepage == "" {
+		return ""
+	}
+
+	homepage := pkg.Homepage
+	if i := strings.Index(homepage, "://"); i >= 0 {
+		homepage = homepage[i+3:]
+		if j := strings.Index(homepage, "/"); j >= 0 {
+			return homepage[j:]
+		}
+		return ""
+	}
+	return homepage
+}
+
+// angularConfig reads the output path and baseHref of the default project in angular.json
+func angularConfig(scan *scanner.ScanResult) (string, string) {
+	data, err := scan.ReadFile("angular.json")
+	if err != nil {
+		return "", ""
+	}
+
+	type buildTarget struct {
+		Builder string `json:"builder"`
+		Options struct {
+			OutputPath json.RawMessage `json:"outputPath"`
+			BaseHref   string          `json:"baseHref"`
+		} `json:"options"`
+	}
+	var workspace struct {
+		DefaultProject string `json:"defaultProject"`
+		Projects       map[string]struct {
+			ProjectType string `json:"projectType"`
+			Architect   struct {
+				Build buildTarget `json:"build"`
+			} `json:"architect"`
+		} `json:"projects"`
+	}
+	if err := json.Unmarshal(data, &workspace); err != nil {
+		return "", ""
+	}
+
+	name := workspace.DefaultProject
+	if _, ok := workspace.Projects[name]; !ok {
+		name = ""
+		for n, proj := range workspace.Projects {
+			if proj.ProjectType == "application" {
+				name = n
+				break
+			}
+		}
+	}
+	if name == "" {
+		return "", ""
+	}
+
+	build := workspace.Projects[name].Architect.Build
+	outputPath := "dist/" + name
+
+	// outputPath is either a string or {"base": "...", "browser": "..."}
+	var path string
+	var pathObj struct {
+		Base    string  `json:"base"`
+		Browser *string `json:"browser"`
+	}
+	browser := "browser"
+	if err := json.Unmarshal(build.Options.OutputPath, &path); err == nil && path != "" {
+		outputPath = path
+	} else if err := json.Unmarshal(build.Options.OutputPath, &pathObj); err == nil && pathObj.Base != "" {
+		outputPath = pathObj.Base
+		if pathObj.Browser != nil {
+			browser = *pathObj.Browser
+		}
+	}
+
+	// The application builder (Angular 17+) writes browser assets to a subdirectory
+	if strings.HasSuffix(build.Builder, ":application") && browser != "" {
+		outputPath += "/" + browser
+	}
+
+	return outputPath, build.Options.BaseHref
+}
+
+// normalizeBasePath returns the base path as "/" or "/path/"
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(strings.TrimSpace(basePath), "/")
+	if basePath == "" || basePath == "." {
+		return "/"
+	}
+	return "/" + basePath + "/"
+}
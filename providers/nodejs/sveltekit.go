@@ -42,29 +42,29 @@ func (p *SvelteKitProvider) Detect(ctx context.Context, scan *scanner.ScanResult
 	// Check for @sveltejs/kit dependency (required)
 	// HasDependency checks both dependencies and devDependencies
 	if pkg.HasDependency("@sveltejs/kit") {
-		score += 50
+		score += providers.Award(ctx, 50, "dependency @sveltejs/kit")
 	} else {
 		return 0, nil, nil // Not SvelteKit
 	}
 
 	// Check for svelte.config.js
 	if scan.FileTree.HasFile("svelte.config.js") || scan.FileTree.HasFile("svelte.config.ts") {
-		score += 20
+		score += providers.Award(ctx, 20, "svelte.config.js or svelte.config.ts")
 	}
 
 	// Check for src/routes directory (SvelteKit convention)
 	if scan.FileTree.HasDir("src/routes") {
-		score += 15
+		score += providers.Award(ctx, 15, "src/routes/ directory")
 	}
 
 	// Check for src/app.html
 	if scan.FileTree.HasFile("src/app.html") {
-		score += 5
+		score += providers.Award(ctx, 5, "src/app.html")
 	}
 
 	// Check for +page.svelte files
 	if scan.FileTree.HasFile("src/routes/+page.svelte") {
-		score += 5
+		score += providers.Award(ctx, 5, "src/routes/+page.svelte")
 	}
 
 	// Detect adapter from svelte.config.js
@@ -100,6 +100,14 @@ func (p *SvelteKitProvider) Detect(ctx context.Context, scan *scanner.ScanResult
 		vars["typescript"] = true
 	}
 
+	// Detect ORM client generation and migrations
+	detectORM(scan, vars)
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
+	// Detect native addons that need a compiler toolchain
+	detectNativeDeps(scan, vars)
+
 	// Detect port
 	vars["port"] = detectPort(scan, "3000")
 
@@ -3,6 +3,7 @@ package php
 import (
 	"context"
 	"encoding/json"
+	"regexp"
 	"strings"
 
 	"github.com/dublyo/dockerizer/internal/scanner"
@@ -56,33 +57,33 @@ func (p *LaravelProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	}
 
 	if _, hasLaravel := require["laravel/framework"]; hasLaravel {
-		score += 50
+		score += providers.Award(ctx, 50, "composer requires laravel/framework")
 	} else {
 		return 0, nil, nil // Not Laravel
 	}
 
 	// Check for artisan file
 	if scan.FileTree.HasFile("artisan") {
-		score += 20
+		score += providers.Award(ctx, 20, "artisan")
 	}
 
 	// Check for Laravel-specific directories
 	if scan.FileTree.HasDir("app/Http") {
-		score += 10
+		score += providers.Award(ctx, 10, "app/Http/ directory")
 	}
 	if scan.FileTree.HasDir("resources/views") {
-		score += 5
+		score += providers.Award(ctx, 5, "resources/views/ directory")
 	}
 	if scan.FileTree.HasDir("routes") {
-		score += 5
+		score += providers.Award(ctx, 5, "routes/ directory")
 	}
 	if scan.FileTree.HasDir("database/migrations") {
-		score += 5
+		score += providers.Award(ctx, 5, "database/migrations/ directory")
 	}
 
 	// Check for .env.example
 	if scan.FileTree.HasFile(".env.example") {
-		score += 5
+		score += providers.Award(ctx, 5, ".env.example")
 	}
 
 	// Check for composer.lock
@@ -104,9 +105,23 @@ func (p *LaravelProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		vars["hasRedis"] = true
 	}
 
-	// Check for Laravel Octane
+	// Check for Laravel Octane and the application server it runs on
 	if _, hasOctane := require["laravel/octane"]; hasOctane {
 		vars["hasOctane"] = true
+		vars["octaneServer"] = detectOctaneServer(scan, require)
+		vars["workers"] = envExampleValue(scan, "OCTANE_WORKERS", "auto")
+		vars["maxRequests"] = envExampleValue(scan, "OCTANE_MAX_REQUESTS", "500")
+	} else if isFrankenPHP(scan) {
+		// Classic (non-worker) FrankenPHP replaces nginx + php-fpm
+		vars["frankenphp"] = true
+	}
+
+	// Laravel 11+ registers a /up health route in bootstrap/app.php
+	vars["healthPath"] = "/"
+	if scan.FileTree.HasFile("bootstrap/app.php") {
+		if data, err := scan.ReadFile("bootstrap/app.php"); err == nil && strings.Contains(string(data), "health:") {
+			vars["healthPath"] = "/up"
+		}
 	}
 
 	// Check for Vite or Mix
@@ -116,6 +131,11 @@ func (p *LaravelProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		vars["hasMix"] = true
 	}
 
+	// Migrations the entrypoint can run on start
+	if scan.FileTree.HasDir("database/migrations") {
+		vars["entrypointMigrate"] = "php artisan migrate --force"
+	}
+
 	// Default port
 	vars["port"] = "8000"
 
@@ -148,6 +168,69 @@ func (p *LaravelProvider) DetectVersion(scan *scanner.ScanResult) string {
 	return detectPhpVersion(scan, require)
 }
 
+// detectOctaneServer determines which Octane server (swoole, roadrunner, frankenphp) is used
+func detectOctaneServer(scan *scanner.ScanResult, require map[string]interface{}) string {
+	// An explicit OCTANE_SERVER wins
+	if server := envExampleValue(scan, "OCTANE_SERVER", ""); server != "" {
+		return server
+	}
+
+	// config/octane.php: 'server' => env('OCTANE_SERVER', 'roadrunner')
+	if scan.FileTree.HasFile("config/octane.php") {
+		data, err := scan.ReadFile("config/octane.php")
+		if err == nil {
+			re := regexp.MustCompile(`env\(\s*['"]OCTANE_SERVER['"]\s*,\s*['"](\w+)['"]`)
+			if matches := re.FindStringSubmatch(string(data)); len(matches) > 1 {
+				return matches[1]
+			}
+		}
+	}
+
+	// RoadRunner needs its PHP client packages and a .rr.yaml
+	if _, ok := require["spiral/roadrunner-http"]; ok || scan.FileTree.HasFile(".rr.yaml") {
+		return "roadrunner"
+	}
+	if isFrankenPHP(scan) {
+		return "frankenphp"
+	}
+
+	return "swoole"
+}
+
+// isFrankenPHP checks for a FrankenPHP Caddyfile in the project
+func isFrankenPHP(scan *scanner.ScanResult) bool {
+	if !scan.FileTree.HasFile("Caddyfile") {
+		return false
+	}
+	data, err := scan.ReadFile("Caddyfile")
+	if err != nil {
+		return false
+	}
+	content := string(data)
+	return strings.Contains(content, "frankenphp") || strings.Contains(content, "php_server")
+}
+
+// envExampleValue reads a variable from .env.example or .env
+func envExampleValue(scan *scanner.ScanResult, key, defaultValue string) string {
+	for _, f := range []string{".env.example", ".env"} {
+		if !scan.FileTree.HasFile(f) {
+			continue
+		}
+		data, err := scan.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, key+"=") {
+				if v := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, key+"=")), `"'`); v != "" {
+					return v
+				}
+			}
+		}
+	}
+	return defaultValue
+}
+
 // detectPhpVersion extracts PHP version from composer.json or files
 func detectPhpVersion(scan *scanner.ScanResult, require map[string]interface{}) string {
 	// Check composer.json require.php
@@ -56,9 +56,9 @@ func (p *SymfonyProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 
 	// Check for Symfony framework bundle (required)
 	if _, hasSymfony := require["symfony/framework-bundle"]; hasSymfony {
-		score += 50
+		score += providers.Award(ctx, 50, "composer requires symfony/framework-bundle")
 	} else if _, hasSymfony := require["symfony/symfony"]; hasSymfony {
-		score += 50
+		score += providers.Award(ctx, 50, "composer requires symfony/symfony")
 	} else {
 		return 0, nil, nil // Not Symfony
 	}
@@ -67,40 +67,40 @@ func (p *SymfonyProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	hasSymfonyLock := scan.FileTree.HasFile("symfony.lock")
 	hasComposerLock := scan.FileTree.HasFile("composer.lock")
 	if hasSymfonyLock {
-		score += 15
+		score += providers.Award(ctx, 15, "symfony.lock")
 	}
 	vars["hasLockFile"] = hasComposerLock
 	vars["hasSymfonyLock"] = hasSymfonyLock
 
 	// Check for Symfony CLI config
 	if scan.FileTree.HasFile(".symfony.local.yaml") || scan.FileTree.HasFile(".symfony/services.yaml") {
-		score += 5
+		score += providers.Award(ctx, 5, ".symfony.local.yaml or .symfony/services.yaml")
 	}
 
 	// Check for bin/console (Symfony CLI)
 	if scan.FileTree.HasFile("bin/console") {
-		score += 10
+		score += providers.Award(ctx, 10, "bin/console")
 	}
 
 	// Check for config/bundles.php
 	if scan.FileTree.HasFile("config/bundles.php") {
-		score += 5
+		score += providers.Award(ctx, 5, "config/bundles.php")
 	}
 
 	// Check for src/Kernel.php
 	if scan.FileTree.HasFile("src/Kernel.php") {
-		score += 5
+		score += providers.Award(ctx, 5, "src/Kernel.php")
 	}
 
 	// Check for Symfony-specific directories
 	if scan.FileTree.HasDir("config") {
-		score += 5
+		score += providers.Award(ctx, 5, "config/ directory")
 	}
 	if scan.FileTree.HasDir("templates") {
 		vars["hasTwig"] = true
 	}
 	if scan.FileTree.HasDir("public") {
-		score += 5
+		score += providers.Award(ctx, 5, "public/ directory")
 	}
 
 	// Check for Doctrine (database)
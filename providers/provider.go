@@ -45,6 +45,11 @@ func (p *BaseProvider) Template() string    { return p.ProviderTemplate }
 func (p *BaseProvider) Description() string { return p.ProviderDescription }
 func (p *BaseProvider) URL() string         { return p.ProviderURL }
 
+// GenericConfidence caps the score of the language-only fallback providers,
+// so any framework match outranks them and the result stays below the AI
+// threshold
+const GenericConfidence = 45
+
 // Rule defines a detection rule
 type Rule struct {
 	// Files that MUST exist
@@ -37,19 +37,19 @@ func (p *DjangoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 
 	// Check for manage.py (Django signature file)
 	if scan.FileTree.HasFile("manage.py") {
-		score += 40
+		score += providers.Award(ctx, 40, "manage.py")
 	}
 
 	// Check requirements.txt for django
 	if hasDjangoInRequirements(scan) {
-		score += 30
+		score += providers.Award(ctx, 30, "requirements.txt lists django")
 	}
 
 	// Check pyproject.toml for django
 	if scan.Metadata.PyProject != nil {
 		for _, dep := range scan.Metadata.PyProject.Dependencies {
 			if strings.Contains(strings.ToLower(dep), "django") {
-				score += 30
+				score += providers.Award(ctx, 30, "pyproject.toml lists django")
 				break
 			}
 		}
@@ -58,7 +58,7 @@ func (p *DjangoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 	// Check for settings.py or <project>/settings.py and extract project name
 	settingsFiles := scan.FileTree.FilesMatching("settings.py")
 	if len(settingsFiles) > 0 {
-		score += 20
+		score += providers.Award(ctx, 20, settingsFiles[0])
 		// Extract project name from settings.py path (e.g., "myproject/settings.py" -> "myproject")
 		// Convert filepath to Python module path (e.g., "src/myproj" -> "src.myproj")
 		for _, sf := range settingsFiles {
@@ -74,7 +74,7 @@ func (p *DjangoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 	wsgiFiles := scan.FileTree.FilesMatching("wsgi.py")
 	asgiFiles := scan.FileTree.FilesMatching("asgi.py")
 	if len(wsgiFiles) > 0 || len(asgiFiles) > 0 {
-		score += 10
+		score += providers.Award(ctx, 10, "wsgi.py or asgi.py")
 		// If projectName not set, try to get it from wsgi/asgi path
 		if _, ok := vars["projectName"]; !ok {
 			allFiles := append(wsgiFiles, asgiFiles...)
@@ -102,6 +102,8 @@ func (p *DjangoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 
 	// Detect package manager
 	vars["packageManager"] = detectPythonPackageManager(scan)
+	vars["projectVenv"] = usesProjectVenv(vars["packageManager"].(string))
+	detectGPU(scan, vars)
 
 	// Check for gunicorn/uvicorn
 	vars["wsgiServer"] = detectWSGIServer(scan)
@@ -111,6 +113,23 @@ func (p *DjangoProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (
 		vars["hasStatic"] = true
 	}
 
+	// Check for Celery background workers
+	if hasPythonDependency(scan, "celery") {
+		vars["hasCelery"] = true
+		vars["celeryApp"] = detectCeleryApp(scan, vars["projectName"].(string))
+		vars["celeryBroker"] = detectCeleryBroker(scan)
+		if hasPythonDependency(scan, "django-celery-beat") {
+			vars["celeryBeatScheduler"] = "django_celery_beat.schedulers:DatabaseScheduler"
+		}
+	}
+
+	// Migrations the entrypoint can run on start
+	vars["entrypointMigrate"] = "python manage.py migrate --noinput"
+
+	// Background job workers
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
 	// Default port
 	vars["port"] = "8000"
 
@@ -136,6 +155,65 @@ func hasDjangoInRequirements(scan *scanner.ScanResult) bool {
 	return false
 }
 
+// hasPythonDependency checks requirements.txt and pyproject.toml for a package
+func hasPythonDependency(scan *scanner.ScanResult, name string) bool {
+	matches := func(req string) bool {
+		req = strings.ToLower(strings.TrimSpace(req))
+		if !strings.HasPrefix(req, name) {
+			return false
+		}
+		rest := req[len(name):]
+		return rest == "" || strings.ContainsAny(rest[:1], "=<>~![; ")
+	}
+
+	for _, req := range scan.Metadata.Requirements {
+		if matches(req) {
+			return true
+		}
+	}
+	if scan.Metadata.PyProject != nil {
+		for _, dep := range scan.Metadata.PyProject.Dependencies {
+			if matches(dep) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// detectCeleryApp finds the module that defines the Celery app (usually <project>/celery.py)
+func detectCeleryApp(scan *scanner.ScanResult, projectName string) string {
+	for _, f := range scan.FileTree.FilesMatching("celery.py") {
+		dir := filepath.Dir(f)
+		if dir != "." && dir != "" {
+			return strings.ReplaceAll(dir, "/", ".")
+		}
+	}
+	return projectName
+}
+
+// detectCeleryBroker returns "rabbitmq" when the project points Celery at AMQP, otherwise "redis"
+func detectCeleryBroker(scan *scanner.ScanResult) string {
+	for _, f := range []string{".env.example", ".env"} {
+		if !scan.FileTree.HasFile(f) {
+			continue
+		}
+		data, err := scan.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "CELERY_BROKER_URL=") && strings.Contains(line, "amqp") {
+				return "rabbitmq"
+			}
+		}
+	}
+	if hasPythonDependency(scan, "librabbitmq") {
+		return "rabbitmq"
+	}
+	return "redis"
+}
+
 func detectPythonPackageManager(scan *scanner.ScanResult) string {
 	if scan.FileTree.HasFile("poetry.lock") {
 		return "poetry"
@@ -146,12 +224,21 @@ func detectPythonPackageManager(scan *scanner.ScanResult) string {
 	if scan.FileTree.HasFile("uv.lock") {
 		return "uv"
 	}
+	if scan.FileTree.HasFile("pdm.lock") {
+		return "pdm"
+	}
 	if scan.Metadata.PyProject != nil && scan.Metadata.PyProject.BuildSystem == "poetry" {
 		return "poetry"
 	}
 	return "pip"
 }
 
+// usesProjectVenv reports whether a package manager installs into a project
+// virtualenv (/app/.venv) rather than the system site-packages
+func usesProjectVenv(packageManager string) bool {
+	return packageManager == "uv" || packageManager == "pdm"
+}
+
 func detectWSGIServer(scan *scanner.ScanResult) string {
 	for _, req := range scan.Metadata.Requirements {
 		reqLower := strings.ToLower(req)
@@ -36,7 +36,7 @@ func (p *FastAPIProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	for _, req := range scan.Metadata.Requirements {
 		reqLower := strings.ToLower(req)
 		if strings.HasPrefix(reqLower, "fastapi") {
-			score += 50
+			score += providers.Award(ctx, 50, "requirements.txt lists fastapi")
 			break
 		}
 	}
@@ -47,7 +47,7 @@ func (p *FastAPIProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		if scan.FileTree.HasFile(mf) {
 			data, err := scan.ReadFile(mf)
 			if err == nil && strings.Contains(string(data), "FastAPI") {
-				score += 30
+				score += providers.Award(ctx, 30, mf+" creates a FastAPI app")
 				vars["mainFile"] = mf
 				break
 			}
@@ -57,7 +57,7 @@ func (p *FastAPIProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	// Check for uvicorn in requirements (common with FastAPI)
 	for _, req := range scan.Metadata.Requirements {
 		if strings.HasPrefix(strings.ToLower(req), "uvicorn") {
-			score += 10
+			score += providers.Award(ctx, 10, "requirements.txt lists uvicorn")
 			vars["wsgiServer"] = "uvicorn"
 			break
 		}
@@ -67,7 +67,7 @@ func (p *FastAPIProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	if scan.Metadata.PyProject != nil {
 		for _, dep := range scan.Metadata.PyProject.Dependencies {
 			if strings.Contains(strings.ToLower(dep), "fastapi") {
-				score += 30
+				score += providers.Award(ctx, 30, "pyproject.toml lists fastapi")
 				break
 			}
 		}
@@ -80,6 +80,8 @@ func (p *FastAPIProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 	// Set defaults
 	vars["pythonVersion"] = p.DetectVersion(scan)
 	vars["packageManager"] = detectPythonPackageManager(scan)
+	vars["projectVenv"] = usesProjectVenv(vars["packageManager"].(string))
+	detectGPU(scan, vars)
 	if vars["wsgiServer"] == nil {
 		vars["wsgiServer"] = "uvicorn"
 	}
@@ -92,6 +94,9 @@ func (p *FastAPIProvider) Detect(ctx context.Context, scan *scanner.ScanResult)
 		moduleName = strings.ReplaceAll(moduleName, "/", ".")
 		vars["moduleName"] = moduleName
 	}
+	// Background job workers
+	detectJobQueue(scan, vars)
+
 	vars["port"] = "8000"
 
 	if score > 100 {
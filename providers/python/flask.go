@@ -35,7 +35,7 @@ func (p *FlaskProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	// Check requirements.txt for flask
 	for _, req := range scan.Metadata.Requirements {
 		if strings.HasPrefix(strings.ToLower(req), "flask") {
-			score += 50
+			score += providers.Award(ctx, 50, "requirements.txt lists flask")
 			break
 		}
 	}
@@ -48,7 +48,7 @@ func (p *FlaskProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 			if err == nil {
 				content := string(data)
 				if strings.Contains(content, "from flask import") || strings.Contains(content, "import flask") {
-					score += 30
+					score += providers.Award(ctx, 30, mf+" imports flask")
 					vars["mainFile"] = mf
 					break
 				}
@@ -60,7 +60,7 @@ func (p *FlaskProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	if scan.Metadata.PyProject != nil {
 		for _, dep := range scan.Metadata.PyProject.Dependencies {
 			if strings.Contains(strings.ToLower(dep), "flask") {
-				score += 30
+				score += providers.Award(ctx, 30, "pyproject.toml lists flask")
 				break
 			}
 		}
@@ -71,7 +71,7 @@ func (p *FlaskProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	for _, ext := range flaskExtensions {
 		for _, req := range scan.Metadata.Requirements {
 			if strings.HasPrefix(strings.ToLower(req), ext) {
-				score += 5
+				score += providers.Award(ctx, 5, "requirements.txt lists "+ext)
 				break
 			}
 		}
@@ -81,20 +81,20 @@ func (p *FlaskProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	for _, req := range scan.Metadata.Requirements {
 		reqLower := strings.ToLower(req)
 		if strings.HasPrefix(reqLower, "gunicorn") || strings.HasPrefix(reqLower, "waitress") {
-			score += 10
+			score += providers.Award(ctx, 10, "requirements.txt lists a WSGI server")
 			break
 		}
 	}
 
 	// Check for templates directory (Flask convention)
 	if scan.FileTree.HasDir("templates") {
-		score += 10
+		score += providers.Award(ctx, 10, "templates/ directory")
 		vars["hasTemplates"] = true
 	}
 
 	// Check for static directory
 	if scan.FileTree.HasDir("static") {
-		score += 5
+		score += providers.Award(ctx, 5, "static/ directory")
 		vars["hasStatic"] = true
 	}
 
@@ -105,6 +105,8 @@ func (p *FlaskProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	// Set defaults
 	vars["pythonVersion"] = p.DetectVersion(scan)
 	vars["packageManager"] = detectPythonPackageManager(scan)
+	vars["projectVenv"] = usesProjectVenv(vars["packageManager"].(string))
+	detectGPU(scan, vars)
 	vars["wsgiServer"] = detectWSGIServer(scan)
 
 	// Set mainFile for FLASK_APP and module name for gunicorn
@@ -118,6 +120,9 @@ func (p *FlaskProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	moduleName = strings.ReplaceAll(moduleName, "/", ".")
 	vars["moduleName"] = moduleName
 
+	// Background job workers
+	detectJobQueue(scan, vars)
+
 	vars["port"] = "5000"
 
 	if score > 100 {
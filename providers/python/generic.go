@@ -0,0 +1,87 @@
+package python
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// PythonProvider is the fallback for Python projects no framework provider
+// matched: it installs the dependencies and runs the entry script
+type PythonProvider struct {
+	providers.BaseProvider
+}
+
+// NewPythonProvider creates the generic Python provider
+func NewPythonProvider() *PythonProvider {
+	return &PythonProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "python",
+			ProviderLanguage:    "python",
+			ProviderFramework:   "generic",
+			ProviderTemplate:    "python/python.tmpl",
+			ProviderDescription: "Python application (no framework detected)",
+			ProviderURL:         "https://www.python.org",
+		},
+	}
+}
+
+// pythonEntryFiles are the scripts tried, in order, as the program to run
+var pythonEntryFiles = []string{"main.py", "app.py", "run.py", "server.py", "bot.py", "src/main.py"}
+
+// Detect matches a Python project with an entry script or a runnable package
+func (p *PythonProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	score := 0
+	vars := make(map[string]interface{})
+
+	for _, manifest := range []string{"requirements.txt", "pyproject.toml", "Pipfile", "setup.py"} {
+		if scan.FileTree.HasFile(manifest) {
+			score += providers.Award(ctx, 25, manifest)
+			break
+		}
+	}
+	vars["hasRequirements"] = scan.FileTree.HasFile("requirements.txt")
+	vars["hasPyproject"] = scan.FileTree.HasFile("pyproject.toml")
+
+	for _, f := range pythonEntryFiles {
+		if scan.FileTree.HasFile(f) {
+			vars["mainFile"] = f
+			score += providers.Award(ctx, 15, "entry point "+f)
+			break
+		}
+	}
+	if vars["mainFile"] == nil {
+		// A top-level package with __main__.py runs with python -m
+		for _, f := range scan.FileTree.FilesMatching("__main__.py") {
+			dir := filepath.Dir(f)
+			if dir != "." && !strings.Contains(dir, "/") {
+				vars["mainModule"] = dir
+				score += providers.Award(ctx, 15, "package "+dir+" has __main__.py")
+				break
+			}
+		}
+	}
+	if vars["mainFile"] == nil && vars["mainModule"] == nil {
+		// Nothing to run
+		return 0, nil, nil
+	}
+
+	vars["pythonVersion"] = p.DetectVersion(scan)
+	vars["packageManager"] = detectPythonPackageManager(scan)
+	vars["projectVenv"] = usesProjectVenv(vars["packageManager"].(string))
+	detectGPU(scan, vars)
+	// Background job workers
+	detectJobQueue(scan, vars)
+
+	vars["port"] = "8000"
+
+	return min(score, providers.GenericConfidence), vars, nil
+}
+
+// DetectVersion detects the Python version
+func (p *PythonProvider) DetectVersion(scan *scanner.ScanResult) string {
+	return detectPythonVersion(scan)
+}
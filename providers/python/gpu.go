@@ -0,0 +1,33 @@
+package python
+
+import (
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// gpuPackages are the dependencies that mark a GPU machine learning workload,
+// with the framework they belong to
+var gpuPackages = []struct{ name, framework string }{
+	{"torch", "pytorch"},
+	{"torchvision", "pytorch"},
+	{"torchaudio", "pytorch"},
+	{"vllm", "pytorch"},
+	{"xformers", "pytorch"},
+	{"tensorflow", "tensorflow"},
+	{"tensorflow-gpu", "tensorflow"},
+	{"jax", "jax"},
+	{"cupy-cuda12x", "cupy"},
+	{"cupy-cuda11x", "cupy"},
+	{"onnxruntime-gpu", "onnxruntime"},
+}
+
+// detectGPU marks projects that depend on a GPU machine learning library:
+// gpu selects the CUDA image and mlFramework the wheel index to use
+func detectGPU(scan *scanner.ScanResult, vars map[string]interface{}) {
+	for _, pkg := range gpuPackages {
+		if hasPythonDependency(scan, pkg.name) {
+			vars["gpu"] = "cuda"
+			vars["mlFramework"] = pkg.framework
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package python
+
+import (
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// dramatiqModules are where Dramatiq actors conventionally live
+var dramatiqModules = []string{"tasks.py", "actors.py", "worker.py", "app/tasks.py", "app/actors.py", "src/tasks.py"}
+
+// detectJobQueue detects RQ and Dramatiq, and sets the variables for the
+// compose worker service: jobQueue, jobCommand and jobBroker. Celery has its
+// own worker and beat services.
+func detectJobQueue(scan *scanner.ScanResult, vars map[string]interface{}) {
+	if vars["hasCelery"] == true {
+		return
+	}
+	switch {
+	case hasPythonDependency(scan, "django-rq"):
+		setJobQueue(vars, "RQ", "python manage.py rqworker", "redis")
+	case hasPythonDependency(scan, "rq"):
+		setJobQueue(vars, "RQ", `rq worker --url "$REDIS_URL"`, "redis")
+	case hasPythonDependency(scan, "django-dramatiq"):
+		setJobQueue(vars, "Dramatiq", "python manage.py rundramatiq", dramatiqBroker(scan, scan.FileTree.FilesMatching("settings.py")))
+	case hasPythonDependency(scan, "dramatiq"):
+		for _, f := range dramatiqModules {
+			if scan.FileTree.HasFile(f) {
+				module := strings.ReplaceAll(strings.TrimSuffix(f, ".py"), "/", ".")
+				setJobQueue(vars, "Dramatiq", "dramatiq "+module, dramatiqBroker(scan, []string{f}))
+				return
+			}
+		}
+	}
+}
+
+func setJobQueue(vars map[string]interface{}, queue, command, broker string) {
+	vars["jobQueue"] = queue
+	vars["jobCommand"] = command
+	vars["jobBroker"] = broker
+}
+
+// dramatiqBroker returns "redis" when the actors module (or a Django
+// settings file) sets up a Redis broker, otherwise Dramatiq's default RabbitMQ
+func dramatiqBroker(scan *scanner.ScanResult, files []string) string {
+	for _, f := range files {
+		if data, err := scan.ReadFile(f); err == nil && strings.Contains(string(data), "Redis") {
+			return "redis"
+		}
+	}
+	return "rabbitmq"
+}
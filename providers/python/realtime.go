@@ -0,0 +1,18 @@
+package python
+
+import "github.com/dublyo/dockerizer/internal/scanner"
+
+// detectRealtime detects Django Channels and sets websocket. Channels serves
+// WebSockets through the ASGI application, so the app runs on Daphne or
+// Uvicorn instead of a WSGI server.
+func detectRealtime(scan *scanner.ScanResult, vars map[string]interface{}) {
+	if !hasPythonDependency(scan, "channels") {
+		return
+	}
+	vars["websocket"] = "Django Channels"
+	// Daphne comes with channels[daphne] (and with Channels before 4.0)
+	vars["wsgiServer"] = "daphne"
+	if hasPythonDependency(scan, "uvicorn") && !hasPythonDependency(scan, "daphne") {
+		vars["wsgiServer"] = "uvicorn"
+	}
+}
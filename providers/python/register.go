@@ -10,4 +10,5 @@ func RegisterAll(registry *detector.Registry) {
 	registry.Register(NewFastAPIProvider())
 	registry.Register(NewDjangoProvider())
 	registry.Register(NewFlaskProvider())
+	registry.Register(NewPythonProvider()) // Fallback when no framework matched
 }
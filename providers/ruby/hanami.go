@@ -0,0 +1,86 @@
+package ruby
+
+import (
+	"context"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// HanamiProvider detects and generates Dockerfiles for Hanami projects
+type HanamiProvider struct {
+	providers.BaseProvider
+}
+
+// NewHanamiProvider creates a new Hanami provider
+func NewHanamiProvider() *HanamiProvider {
+	return &HanamiProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "hanami",
+			ProviderLanguage:    "ruby",
+			ProviderFramework:   "hanami",
+			ProviderTemplate:    "ruby/hanami.tmpl",
+			ProviderDescription: "Hanami Ruby web framework",
+			ProviderURL:         "https://hanamirb.org",
+		},
+	}
+}
+
+// Detect checks if the repository is a Hanami project
+func (p *HanamiProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	score := 0
+	vars := make(map[string]interface{})
+
+	// Must have a Gemfile with the hanami gem
+	gemfile := scan.Metadata.Gemfile
+	if gemfile == nil || !gemfile.HasGem("hanami") {
+		return 0, nil, nil
+	}
+	score += providers.Award(ctx, 50, "Gemfile lists hanami")
+	if v := gemfile.Locked["hanami"]; v != "" {
+		vars["hanamiVersion"] = v
+	}
+
+	// Hanami 2 apps are configured in config/app.rb; Hanami 1 in config/environment.rb
+	if scan.FileTree.HasFile("config/app.rb") {
+		score += providers.Award(ctx, 15, "config/app.rb")
+	} else if scan.FileTree.HasFile("config/environment.rb") && scan.FileTree.HasDir("apps") {
+		score += providers.Award(ctx, 15, "config/environment.rb with apps/")
+	}
+	if scan.FileTree.HasFile("config.ru") {
+		score += providers.Award(ctx, 10, "config.ru")
+	}
+	if scan.FileTree.HasDir("slices") || scan.FileTree.HasDir("app/actions") {
+		score += providers.Award(ctx, 10, "slices/ or app/actions/")
+	}
+
+	rubyServer(scan, vars)
+
+	// Front-end assets compiled with hanami-assets (needs Node.js)
+	if gemfile.HasGem("hanami-assets") && scan.FileTree.HasFile("package.json") {
+		vars["hasAssets"] = true
+		vars["hasPackageLock"] = scan.FileTree.HasFile("package-lock.json")
+	}
+
+	// Migrations the entrypoint can run on start (Hanami 2.2 keeps them in config/db/migrate)
+	if scan.FileTree.HasDir("config/db/migrate") || scan.FileTree.HasDir("db/migrate") {
+		vars["entrypointMigrate"] = "bundle exec hanami db migrate"
+	}
+
+	vars["rubyVersion"] = p.DetectVersion(scan)
+	// Background job workers
+	detectJobQueue(scan, vars)
+
+	vars["port"] = "2300"
+
+	if score > 100 {
+		score = 100
+	}
+
+	return score, vars, nil
+}
+
+// DetectVersion detects the Ruby version
+func (p *HanamiProvider) DetectVersion(scan *scanner.ScanResult) string {
+	return detectRubyVersion(scan)
+}
@@ -0,0 +1,19 @@
+package ruby
+
+import "github.com/dublyo/dockerizer/internal/scanner"
+
+// detectJobQueue detects Sidekiq and sets the variables for the compose
+// worker service: jobQueue, jobCommand and jobBroker
+func detectJobQueue(scan *scanner.ScanResult, vars map[string]interface{}) {
+	gemfile := scan.Metadata.Gemfile
+	if gemfile == nil || !gemfile.HasGem("sidekiq") {
+		return
+	}
+	command := "bundle exec sidekiq"
+	if scan.FileTree.HasFile("config/sidekiq.yml") {
+		command += " -C config/sidekiq.yml"
+	}
+	vars["jobQueue"] = "Sidekiq"
+	vars["jobCommand"] = command
+	vars["jobBroker"] = "redis"
+}
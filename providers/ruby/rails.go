@@ -32,70 +32,66 @@ func (p *RailsProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 	score := 0
 	vars := make(map[string]interface{})
 
-	// Must have Gemfile
-	if !scan.FileTree.HasFile("Gemfile") {
+	// Must have a Gemfile with the rails gem
+	gemfile := scan.Metadata.Gemfile
+	if gemfile == nil {
 		return 0, nil, nil
 	}
-
-	// Check Gemfile for rails gem
-	data, err := scan.ReadFile("Gemfile")
-	if err != nil {
+	switch {
+	case gemfile.HasGem("rails"):
+		score += providers.Award(ctx, 50, "Gemfile lists rails")
+	case gemfile.HasGem("railties"):
+		score += providers.Award(ctx, 50, "Gemfile lists railties")
+	default:
 		return 0, nil, nil
 	}
-
-	gemfileContent := string(data)
-	if !strings.Contains(gemfileContent, "rails") {
-		return 0, nil, nil
+	if gemfile.RailsVersion != "" {
+		vars["railsVersion"] = gemfile.RailsVersion
 	}
-	score += 50
 
 	// Check for Rails-specific directories
 	if scan.FileTree.HasDir("app") {
-		score += 10
+		score += providers.Award(ctx, 10, "app/ directory")
 	}
 	if scan.FileTree.HasDir("config") {
-		score += 10
+		score += providers.Award(ctx, 10, "config/ directory")
 	}
 	if scan.FileTree.HasDir("db") {
-		score += 10
+		score += providers.Award(ctx, 10, "db/ directory")
 	}
 
 	// Check for config/routes.rb
 	if scan.FileTree.HasFile("config/routes.rb") {
-		score += 10
+		score += providers.Award(ctx, 10, "config/routes.rb")
 	}
 
 	// Check for config/application.rb
 	if scan.FileTree.HasFile("config/application.rb") {
-		score += 5
+		score += providers.Award(ctx, 5, "config/application.rb")
 	}
 
 	// Check for bin/rails
 	if scan.FileTree.HasFile("bin/rails") {
-		score += 5
+		score += providers.Award(ctx, 5, "bin/rails")
 	}
 
 	// Detect Ruby version
 	vars["rubyVersion"] = p.DetectVersion(scan)
 
 	// Check for database type
-	if strings.Contains(gemfileContent, "pg") || strings.Contains(gemfileContent, "postgresql") {
-		vars["database"] = "postgresql"
-	} else if strings.Contains(gemfileContent, "mysql2") {
-		vars["database"] = "mysql"
-	} else if strings.Contains(gemfileContent, "sqlite") {
-		vars["database"] = "sqlite"
+	if db := detectDatabase(gemfile); db != "" {
+		vars["database"] = db
 	}
 
 	// Check for asset pipeline
-	if strings.Contains(gemfileContent, "sprockets") || scan.FileTree.HasDir("app/assets") {
+	if gemfile.HasGem("sprockets") || gemfile.HasGem("sprockets-rails") || scan.FileTree.HasDir("app/assets") {
 		vars["hasAssets"] = true
 	}
 
 	// Check for webpacker/jsbundling
-	if strings.Contains(gemfileContent, "webpacker") {
+	if gemfile.HasGem("webpacker") {
 		vars["webpacker"] = true
-	} else if strings.Contains(gemfileContent, "jsbundling-rails") {
+	} else if gemfile.HasGem("jsbundling-rails") {
 		vars["jsbundling"] = true
 	}
 
@@ -107,6 +103,15 @@ func (p *RailsProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 		}
 	}
 
+	// Migrations the entrypoint can run on start (db:prepare also creates and seeds a new database)
+	if scan.FileTree.HasDir("db/migrate") || scan.FileTree.HasFile("db/schema.rb") {
+		vars["entrypointMigrate"] = "bundle exec rails db:prepare"
+	}
+
+	// Background job workers
+	detectJobQueue(scan, vars)
+	detectRealtime(scan, vars)
+
 	// Default port
 	vars["port"] = "3000"
 
@@ -120,39 +125,5 @@ func (p *RailsProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 
 // DetectVersion detects the Ruby version
 func (p *RailsProvider) DetectVersion(scan *scanner.ScanResult) string {
-	// Check .ruby-version
-	if scan.FileTree.HasFile(".ruby-version") {
-		data, err := scan.ReadFile(".ruby-version")
-		if err == nil {
-			version := strings.TrimSpace(string(data))
-			// Remove ruby- prefix if present
-			version = strings.TrimPrefix(version, "ruby-")
-			if version != "" {
-				return version
-			}
-		}
-	}
-
-	// Check Gemfile for ruby version
-	if scan.FileTree.HasFile("Gemfile") {
-		data, err := scan.ReadFile("Gemfile")
-		if err == nil {
-			lines := strings.Split(string(data), "\n")
-			for _, line := range lines {
-				if strings.HasPrefix(strings.TrimSpace(line), "ruby") {
-					// Parse ruby "3.2.0" or ruby '3.2.0'
-					parts := strings.Split(line, "\"")
-					if len(parts) >= 2 {
-						return parts[1]
-					}
-					parts = strings.Split(line, "'")
-					if len(parts) >= 2 {
-						return parts[1]
-					}
-				}
-			}
-		}
-	}
-
-	return "3.3"
+	return detectRubyVersion(scan)
 }
@@ -0,0 +1,19 @@
+package ruby
+
+import (
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// detectRealtime detects Action Cable channels and sets websocket. Every Rails
+// app ships the application_cable base classes, so only channels of the app
+// itself count.
+func detectRealtime(scan *scanner.ScanResult, vars map[string]interface{}) {
+	for _, f := range scan.FileTree.Files {
+		if strings.HasPrefix(f, "app/channels/") && !strings.HasPrefix(f, "app/channels/application_cable/") && strings.HasSuffix(f, ".rb") {
+			vars["websocket"] = "Action Cable"
+			return
+		}
+	}
+}
@@ -8,7 +8,6 @@ import (
 func RegisterAll(registry *detector.Registry) {
 	// Register in order of specificity
 	registry.Register(NewRailsProvider())
-	// Future providers:
-	// registry.Register(NewSinatraProvider())
-	// registry.Register(NewHanamiProvider())
+	registry.Register(NewHanamiProvider())
+	registry.Register(NewSinatraProvider())
 }
@@ -0,0 +1,99 @@
+package ruby
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// SinatraProvider detects and generates Dockerfiles for Sinatra projects
+type SinatraProvider struct {
+	providers.BaseProvider
+}
+
+// NewSinatraProvider creates a new Sinatra provider
+func NewSinatraProvider() *SinatraProvider {
+	return &SinatraProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "sinatra",
+			ProviderLanguage:    "ruby",
+			ProviderFramework:   "sinatra",
+			ProviderTemplate:    "ruby/sinatra.tmpl",
+			ProviderDescription: "Sinatra Ruby web framework",
+			ProviderURL:         "https://sinatrarb.com",
+		},
+	}
+}
+
+// sinatraMainFiles are where classic and modular Sinatra apps usually live
+var sinatraMainFiles = []string{"app.rb", "server.rb", "main.rb", "application.rb", "web.rb", "api.rb"}
+
+// Detect checks if the repository is a Sinatra project
+func (p *SinatraProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	score := 0
+	vars := make(map[string]interface{})
+
+	gemfile := scan.Metadata.Gemfile
+	if gemfile == nil {
+		return 0, nil, nil
+	}
+	// Rails apps often mount Sinatra (e.g. the Sidekiq web UI)
+	if gemfile.HasGem("rails") || gemfile.HasGem("hanami") {
+		return 0, nil, nil
+	}
+
+	if gemfile.HasGem("sinatra") {
+		score += providers.Award(ctx, 50, "Gemfile lists sinatra")
+		if v := gemfile.Locked["sinatra"]; v != "" {
+			vars["sinatraVersion"] = v
+		}
+	}
+
+	for _, mf := range sinatraMainFiles {
+		if !scan.FileTree.HasFile(mf) {
+			continue
+		}
+		data, err := scan.ReadFile(mf)
+		if err != nil {
+			continue
+		}
+		content := string(data)
+		if strings.Contains(content, `require 'sinatra`) || strings.Contains(content, `require "sinatra`) {
+			score += providers.Award(ctx, 20, mf+" requires sinatra")
+			vars["mainFile"] = mf
+			break
+		}
+	}
+	if score == 0 {
+		return 0, nil, nil
+	}
+
+	if scan.FileTree.HasFile("config.ru") {
+		score += providers.Award(ctx, 15, "config.ru")
+		vars["rackup"] = true
+	}
+
+	rubyServer(scan, vars)
+	if vars["puma"] == true {
+		score += providers.Award(ctx, 5, "Gemfile lists puma")
+	}
+
+	vars["rubyVersion"] = p.DetectVersion(scan)
+	// Background job workers
+	detectJobQueue(scan, vars)
+
+	vars["port"] = "4567"
+
+	if score > 100 {
+		score = 100
+	}
+
+	return score, vars, nil
+}
+
+// DetectVersion detects the Ruby version
+func (p *SinatraProvider) DetectVersion(scan *scanner.ScanResult) string {
+	return detectRubyVersion(scan)
+}
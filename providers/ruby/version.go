@@ -0,0 +1,60 @@
+package ruby
+
+import (
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// detectRubyVersion reads .ruby-version, then the Gemfile's ruby directive
+// or the version Gemfile.lock was resolved with
+func detectRubyVersion(scan *scanner.ScanResult) string {
+	if scan.FileTree.HasFile(".ruby-version") {
+		data, err := scan.ReadFile(".ruby-version")
+		if err == nil {
+			version := strings.TrimSpace(string(data))
+			// Remove ruby- prefix if present
+			version = strings.TrimPrefix(version, "ruby-")
+			if version != "" {
+				return version
+			}
+		}
+	}
+
+	if gemfile := scan.Metadata.Gemfile; gemfile != nil && gemfile.Ruby() != "" {
+		return gemfile.Ruby()
+	}
+
+	return "3.3"
+}
+
+// rubyServer sets how a Rack app is served: puma when the Gemfile has it,
+// with config/puma.rb when the app ships one, else rackup. It also sets the
+// lock file and database the build needs.
+func rubyServer(scan *scanner.ScanResult, vars map[string]interface{}) {
+	gemfile := scan.Metadata.Gemfile
+	vars["hasLockFile"] = scan.FileTree.HasFile("Gemfile.lock")
+	if db := detectDatabase(gemfile); db != "" {
+		vars["database"] = db
+	}
+	if gemfile.HasGem("puma") {
+		vars["puma"] = true
+		if scan.FileTree.HasFile("config/puma.rb") {
+			vars["pumaConfig"] = "config/puma.rb"
+		}
+	}
+}
+
+// detectDatabase names the database driver gem in the Gemfile, for the
+// client libraries the image needs
+func detectDatabase(gemfile *scanner.Gemfile) string {
+	switch {
+	case gemfile.HasGem("pg"):
+		return "postgresql"
+	case gemfile.HasGem("mysql2"), gemfile.HasGem("trilogy"):
+		return "mysql"
+	case gemfile.HasGem("sqlite3"):
+		return "sqlite"
+	}
+	return ""
+}
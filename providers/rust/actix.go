@@ -38,25 +38,22 @@ func (p *ActixProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 		return 0, nil, nil
 	}
 
-	// Check for actix-web in dependencies
-	for _, dep := range scan.Metadata.CargoToml.Dependencies {
-		if strings.Contains(dep, "actix-web") {
-			score += 60
-			break
-		}
+	// Check for actix-web in dependencies (including workspace members)
+	if hasCargoDependency(scan.Metadata.CargoToml, "actix-web") {
+		score += providers.Award(ctx, 60, "Cargo dependency actix-web")
 	}
 
 	// Check Cargo.toml content for actix-web
 	if scan.FileTree.HasFile("Cargo.toml") {
 		data, err := scan.ReadFile("Cargo.toml")
 		if err == nil && strings.Contains(string(data), "actix-web") {
-			score += 20
+			score += providers.Award(ctx, 20, "Cargo.toml mentions actix-web")
 		}
 	}
 
 	// Check for src/main.rs
 	if scan.FileTree.HasFile("src/main.rs") {
-		score += 10
+		score += providers.Award(ctx, 10, "src/main.rs")
 	}
 
 	if score == 0 {
@@ -65,6 +62,7 @@ func (p *ActixProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 
 	vars["rustVersion"] = p.DetectVersion(scan)
 	vars["projectName"] = scan.Metadata.CargoToml.Name
+	detectCargoTarget(scan.Metadata.CargoToml, "actix-web", vars)
 	vars["port"] = "8080"
 
 	if score > 100 {
@@ -76,25 +74,5 @@ func (p *ActixProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (i
 
 // DetectVersion detects the Rust version to use
 func (p *ActixProvider) DetectVersion(scan *scanner.ScanResult) string {
-	// Check rust-toolchain.toml or rust-toolchain
-	if scan.FileTree.HasFile("rust-toolchain.toml") {
-		data, err := scan.ReadFile("rust-toolchain.toml")
-		if err == nil {
-			content := string(data)
-			if strings.Contains(content, "stable") {
-				return "1.75"
-			}
-		}
-	}
-	if scan.FileTree.HasFile("rust-toolchain") {
-		data, err := scan.ReadFile("rust-toolchain")
-		if err == nil {
-			version := strings.TrimSpace(string(data))
-			if version != "" && !strings.Contains(version, "stable") {
-				return version
-			}
-		}
-	}
-	// Default to latest stable
-	return "1.75"
+	return detectRustVersion(scan)
 }
@@ -2,7 +2,6 @@ package rust
 
 import (
 	"context"
-	"strings"
 
 	"github.com/dublyo/dockerizer/internal/scanner"
 	"github.com/dublyo/dockerizer/providers"
@@ -36,16 +35,13 @@ func (p *AxumProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 		return 0, nil, nil
 	}
 
-	// Check Cargo.toml for axum
-	if scan.FileTree.HasFile("Cargo.toml") {
-		data, err := scan.ReadFile("Cargo.toml")
-		if err == nil && strings.Contains(string(data), "axum") {
-			score += 70
-		}
+	// Check Cargo.toml for axum (including workspace members)
+	if hasCargoDependency(scan.Metadata.CargoToml, "axum") {
+		score += providers.Award(ctx, 70, "Cargo dependency axum")
 	}
 
 	if scan.FileTree.HasFile("src/main.rs") {
-		score += 10
+		score += providers.Award(ctx, 10, "src/main.rs")
 	}
 
 	if score == 0 {
@@ -54,6 +50,7 @@ func (p *AxumProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 
 	vars["rustVersion"] = p.DetectVersion(scan)
 	vars["projectName"] = scan.Metadata.CargoToml.Name
+	detectCargoTarget(scan.Metadata.CargoToml, "axum", vars)
 	vars["port"] = "3000"
 
 	if score > 100 {
@@ -63,10 +60,7 @@ func (p *AxumProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (in
 	return score, vars, nil
 }
 
-// DetectVersion detects the Rust edition
+// DetectVersion detects the Rust version to use
 func (p *AxumProvider) DetectVersion(scan *scanner.ScanResult) string {
-	if scan.Metadata.CargoToml != nil && scan.Metadata.CargoToml.Edition != "" {
-		return scan.Metadata.CargoToml.Edition
-	}
-	return "2021"
+	return detectRustVersion(scan)
 }
@@ -0,0 +1,91 @@
+package rust
+
+import (
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+)
+
+// hasCargoDependency checks the root manifest and any workspace members for a dependency
+func hasCargoDependency(cargo *scanner.CargoToml, dep string) bool {
+	if cargoDependsOn(cargo, dep) {
+		return true
+	}
+	for _, crate := range cargo.Crates {
+		if cargoDependsOn(crate, dep) {
+			return true
+		}
+	}
+	return false
+}
+
+func cargoDependsOn(cargo *scanner.CargoToml, dep string) bool {
+	for _, d := range cargo.Dependencies {
+		if d == dep {
+			return true
+		}
+	}
+	return false
+}
+
+// detectCargoTarget sets the binary the templates build and copy. In a
+// workspace it comes from the member crate that depends on the framework.
+func detectCargoTarget(cargo *scanner.CargoToml, frameworkDep string, vars map[string]interface{}) {
+	crate := cargo
+	if len(cargo.Crates) > 0 {
+		crate = cargo.Crates[0]
+		for _, c := range cargo.Crates {
+			if cargoDependsOn(c, frameworkDep) {
+				crate = c
+				break
+			}
+		}
+		vars["workspace"] = true
+		vars["cargoPackage"] = crate.Name
+	}
+
+	// default-run wins, then an explicit [[bin]] named after the package,
+	// then the first [[bin]]
+	binary := crate.Name
+	if crate.DefaultRun != "" {
+		binary = crate.DefaultRun
+	} else if len(crate.Bins) > 0 && crate.Bins[0] != "" {
+		binary = crate.Bins[0]
+		for _, bin := range crate.Bins {
+			if bin == crate.Name {
+				binary = bin
+				break
+			}
+		}
+	}
+	vars["binaryName"] = binary
+
+	// cargo-chef caches dependency builds; providers.rust.cargo_chef: false
+	// falls back to a plain build with cache mounts when generating
+	vars["cargoChef"] = true
+}
+
+// detectRustVersion detects the Rust toolchain version to use
+func detectRustVersion(scan *scanner.ScanResult) string {
+	// Check rust-toolchain.toml or rust-toolchain
+	if scan.FileTree.HasFile("rust-toolchain.toml") {
+		data, err := scan.ReadFile("rust-toolchain.toml")
+		if err == nil {
+			content := string(data)
+			if strings.Contains(content, "stable") {
+				return "1.75"
+			}
+		}
+	}
+	if scan.FileTree.HasFile("rust-toolchain") {
+		data, err := scan.ReadFile("rust-toolchain")
+		if err == nil {
+			version := strings.TrimSpace(string(data))
+			if version != "" && !strings.Contains(version, "stable") {
+				return version
+			}
+		}
+	}
+	// Default to latest stable
+	return "1.75"
+}
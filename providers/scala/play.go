@@ -0,0 +1,113 @@
+package scala
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dublyo/dockerizer/internal/scanner"
+	"github.com/dublyo/dockerizer/providers"
+)
+
+// PlayProvider detects and generates Dockerfiles for Play Framework projects
+type PlayProvider struct {
+	providers.BaseProvider
+}
+
+// NewPlayProvider creates a new Play Framework provider
+func NewPlayProvider() *PlayProvider {
+	return &PlayProvider{
+		BaseProvider: providers.BaseProvider{
+			ProviderName:        "play",
+			ProviderLanguage:    "scala",
+			ProviderFramework:   "play",
+			ProviderTemplate:    "scala/play.tmpl",
+			ProviderDescription: "Play Framework for Scala and Java",
+			ProviderURL:         "https://www.playframework.com",
+		},
+	}
+}
+
+var (
+	// playPlugin matches the Play sbt plugin and its version in plugins.sbt:
+	// addSbtPlugin("org.playframework" % "sbt-plugin" % "3.0.5")
+	playPlugin = regexp.MustCompile(`"(?:org\.playframework|com\.typesafe\.play)"\s*%\s*"sbt-plugin"\s*%\s*"([^"]+)"`)
+	// sbtVersion matches sbt.version in project/build.properties
+	sbtVersion = regexp.MustCompile(`(?m)^\s*sbt\.version\s*=\s*(\S+)`)
+)
+
+// defaultSbtVersion is installed when project/build.properties doesn't pin one
+const defaultSbtVersion = "1.10.7"
+
+// Detect checks if the repository is a Play application built with sbt
+func (p *PlayProvider) Detect(ctx context.Context, scan *scanner.ScanResult) (int, map[string]interface{}, error) {
+	if !scan.FileTree.HasFile("build.sbt") {
+		return 0, nil, nil
+	}
+
+	score := 0
+	vars := make(map[string]interface{})
+
+	if data, err := scan.ReadFile("project/plugins.sbt"); err == nil {
+		if m := playPlugin.FindStringSubmatch(string(data)); m != nil {
+			score += providers.Award(ctx, 60, "project/plugins.sbt adds the Play sbt plugin")
+			vars["playVersion"] = m[1]
+		}
+	}
+	if data, err := scan.ReadFile("build.sbt"); err == nil {
+		content := string(data)
+		if strings.Contains(content, "PlayScala") || strings.Contains(content, "PlayJava") {
+			score += providers.Award(ctx, 25, "build.sbt enables the Play plugin")
+		}
+		if strings.Contains(content, "PlayJava") {
+			vars["playJava"] = true
+		}
+	}
+	if score == 0 {
+		return 0, nil, nil
+	}
+
+	if scan.FileTree.HasFile("conf/routes") {
+		score += providers.Award(ctx, 10, "conf/routes")
+	}
+	if scan.FileTree.HasFile("conf/application.conf") {
+		score += providers.Award(ctx, 5, "conf/application.conf")
+	}
+
+	vars["sbtVersion"] = defaultSbtVersion
+	if data, err := scan.ReadFile("project/build.properties"); err == nil {
+		if m := sbtVersion.FindStringSubmatch(string(data)); m != nil {
+			vars["sbtVersion"] = m[1]
+		}
+	}
+	vars["javaVersion"] = p.DetectVersion(scan)
+	if version, _ := vars["playVersion"].(string); olderThanPlay29(version) && !scan.FileTree.HasFile(".java-version") {
+		vars["javaVersion"] = "11" // Play 2.8 runs on Java 8 and 11
+	}
+	vars["port"] = "9000"
+
+	return min(score, 100), vars, nil
+}
+
+// olderThanPlay29 reports whether a Play version predates 2.9, the first
+// to run on Java 17 and 21
+func olderThanPlay29(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 || parts[0] != "2" {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	return err == nil && minor < 9
+}
+
+// DetectVersion detects the Java version from .java-version, or defaults
+// to Java 21 (LTS)
+func (p *PlayProvider) DetectVersion(scan *scanner.ScanResult) string {
+	if data, err := scan.ReadFile(".java-version"); err == nil {
+		if version := strings.TrimSpace(string(data)); version != "" {
+			return version
+		}
+	}
+	return "21"
+}
@@ -0,0 +1,10 @@
+package scala
+
+import (
+	"github.com/dublyo/dockerizer/internal/detector"
+)
+
+// RegisterAll registers all Scala providers with the registry
+func RegisterAll(registry *detector.Registry) {
+	registry.Register(NewPlayProvider())
+}
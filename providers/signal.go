@@ -0,0 +1,29 @@
+package providers
+
+import "context"
+
+// Signal is a piece of evidence a provider found during detection and the
+// points it added to the provider's confidence
+type Signal struct {
+	Points int    `json:"points"`
+	Reason string `json:"reason"`
+}
+
+type signalsKey struct{}
+
+// WithSignals returns a context in which Award records every signal a
+// provider finds in signals, to explain its confidence
+func WithSignals(ctx context.Context, signals *[]Signal) context.Context {
+	return context.WithValue(ctx, signalsKey{}, signals)
+}
+
+// Award returns points, recording them with reason when the context collects
+// signals. Providers add its result to their score:
+//
+//	score += providers.Award(ctx, 50, "dependency express")
+func Award(ctx context.Context, points int, reason string) int {
+	if signals, ok := ctx.Value(signalsKey{}).(*[]Signal); ok {
+		*signals = append(*signals, Signal{Points: points, Reason: reason})
+	}
+	return points
+}